@@ -0,0 +1,39 @@
+// Command openapi generates the OpenAPI document served at /api/v1/openapi.json from the handler annotations.
+//
+// It is run by "go generate ./internal/handlers".
+// With -check, it exits with a non-zero status if the committed document does not match the handler annotations.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	codegen "seanime/codegen/internal"
+)
+
+func main() {
+
+	opts := &codegen.OpenAPIOptions{
+		Version: "v1",
+	}
+	flag.StringVar(&opts.HandlersDir, "handlers", ".", "Directory containing the route handlers")
+	flag.StringVar(&opts.SourceDir, "src", "..", "Directory containing the types used by the route handlers")
+	flag.StringVar(&opts.EventsFile, "events", "../events/events.go", "File declaring the websocket events")
+	flag.StringVar(&opts.OutFile, "out", "openapi.json", "Output file")
+
+	var check bool
+	flag.BoolVar(&check, "check", false, "Check that the output file is up to date instead of writing it")
+
+	flag.Parse()
+
+	var err error
+	if check {
+		err = codegen.CheckOpenAPISpec(opts)
+	} else {
+		err = codegen.GenerateOpenAPISpec(opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -22,15 +22,17 @@ type (
 	}
 
 	RouteHandlerApi struct {
-		Summary              string               `json:"summary"`
-		Descriptions         []string             `json:"descriptions"`
-		Endpoint             string               `json:"endpoint"`
-		Methods              []string             `json:"methods"`
-		Params               []*RouteHandlerParam `json:"params"`
-		BodyFields           []*RouteHandlerParam `json:"bodyFields"`
-		Returns              string               `json:"returns"`
-		ReturnGoType         string               `json:"returnGoType"`
-		ReturnTypescriptType string               `json:"returnTypescriptType"`
+		Summary      string               `json:"summary"`
+		Descriptions []string             `json:"descriptions"`
+		Endpoint     string               `json:"endpoint"`
+		Methods      []string             `json:"methods"`
+		Params       []*RouteHandlerParam `json:"params"`
+		BodyFields   []*RouteHandlerParam `json:"bodyFields"`
+		// Body is the package-level struct referenced by the @body annotation, empty if the body is declared in the handler
+		Body                 string `json:"body,omitempty"`
+		Returns              string `json:"returns"`
+		ReturnGoType         string `json:"returnGoType"`
+		ReturnTypescriptType string `json:"returnTypescriptType"`
 	}
 
 	RouteHandlerParam struct {
@@ -47,8 +49,41 @@ type (
 
 func GenerateHandlers(dir string, outDir string) {
 
+	handlers, err := ParseHandlers(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	// Write structs to file
+	_ = os.MkdirAll(outDir, os.ModePerm)
+	file, err := os.Create(outDir + "/handlers.json")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(handlers); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	return
+}
+
+// ParseHandlers parses the route handlers and their annotations in the given directory.
+//
+// The request body is read from a struct called "body" declared in the handler,
+// or from the package-level struct referenced by the "@body" annotation, e.g. "@body TorrentClientDownloadBody".
+func ParseHandlers(dir string) ([]*RouteHandler, error) {
+
 	handlers := make([]*RouteHandler, 0)
 
+	files := make([]*ast.File, 0)
+	paths := make([]string, 0)
+
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -66,6 +101,37 @@ func GenerateHandlers(dir string, outDir string) {
 			return err
 		}
 
+		files = append(files, file)
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the package-level structs that can be referenced by the @body annotation
+	bodyStructs := make(map[string]*ast.StructType)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					bodyStructs[typeSpec.Name.Name] = structType
+				}
+			}
+		}
+	}
+
+	for i, file := range files {
+		path := paths[i]
+
 		for _, decl := range file.Decls {
 			// Check if the declaration is a function
 			fn, ok := decl.(*ast.FuncDecl)
@@ -99,6 +165,7 @@ func GenerateHandlers(dir string, outDir string) {
 			summary := ""
 			descriptions := make([]string, 0)
 			returns := "bool"
+			bodyType := ""
 
 			for _, comment := range comments {
 				cmt := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
@@ -136,6 +203,10 @@ func GenerateHandlers(dir string, outDir string) {
 				if strings.HasPrefix(cmt, "@returns") {
 					returns = strings.TrimSpace(strings.TrimPrefix(cmt, "@returns"))
 				}
+
+				if strings.HasPrefix(cmt, "@body") {
+					bodyType = strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(cmt, "@body")), "handlers.")
+				}
 			}
 
 			bodyFields := make([]*RouteHandlerParam, 0)
@@ -176,86 +247,16 @@ func GenerateHandlers(dir string, outDir string) {
 						continue
 					}
 
-					// Get the fields
-					for _, field := range structType.Fields.List {
-						// Get the field name
-						fieldName := field.Names[0].Name
-
-						// Get the field type
-						fieldType := field.Type
-
-						jsonName := fieldName
-						// Get the field tag
-						required := !jsonFieldOmitEmpty(field)
-						jsonField := jsonFieldName(field)
-						if jsonField != "" {
-							jsonName = jsonField
-						}
-
-						// Get field comments
-						fieldComments := make([]string, 0)
-						cmtsTxt := field.Doc.Text()
-						if cmtsTxt != "" {
-							fieldComments = strings.Split(cmtsTxt, "\n")
-						}
-						for _, cmt := range fieldComments {
-							cmt = strings.TrimSpace(strings.TrimPrefix(cmt, "//"))
-							if cmt != "" {
-								fieldComments = append(fieldComments, cmt)
-							}
-						}
-
-						switch fieldType.(type) {
-						case *ast.StarExpr:
-							required = false
-						}
-
-						goType := fieldTypeString(fieldType)
-						goTypeUnformatted := fieldTypeUnformattedString(fieldType)
-						packageName := "handlers"
-						if strings.Contains(goTypeUnformatted, ".") {
-							parts := strings.Split(goTypeUnformatted, ".")
-							packageName = parts[0]
-						}
-
-						tsType := fieldTypeToTypescriptType(fieldType, packageName)
-
-						usedStructType := goTypeUnformatted
-						switch goTypeUnformatted {
-						case "string", "int", "int64", "float64", "float32", "bool", "nil", "uint", "uint64", "uint32", "uint16", "uint8", "byte", "rune", "[]byte", "interface{}", "error":
-							usedStructType = ""
-						}
-
-						// Add the request body field
-						bodyFields = append(bodyFields, &RouteHandlerParam{
-							Name:           fieldName,
-							JsonName:       jsonName,
-							GoType:         goType,
-							UsedStructType: usedStructType,
-							TypescriptType: tsType,
-							Required:       required,
-							Descriptions:   fieldComments,
-						})
+					bodyFields = getBodyFields(structType)
+				}
+			}
 
-						// Check if it's an inline struct and capture its definition
-						if structType, ok := fieldType.(*ast.StructType); ok {
-							bodyFields[len(bodyFields)-1].InlineStructType = formatInlineStruct(structType)
-						} else {
-							// Check if it's a slice of inline structs
-							if arrayType, ok := fieldType.(*ast.ArrayType); ok {
-								if structType, ok := arrayType.Elt.(*ast.StructType); ok {
-									bodyFields[len(bodyFields)-1].InlineStructType = "[]" + formatInlineStruct(structType)
-								}
-							}
-							// Check if it's a map with inline struct values
-							if mapType, ok := fieldType.(*ast.MapType); ok {
-								if structType, ok := mapType.Value.(*ast.StructType); ok {
-									bodyFields[len(bodyFields)-1].InlineStructType = "map[" + fieldTypeString(mapType.Key) + "]" + formatInlineStruct(structType)
-								}
-							}
-						}
-					}
+			if bodyType != "" {
+				structType, ok := bodyStructs[bodyType]
+				if !ok {
+					return nil, fmt.Errorf("%s: @body type %s not found", name, bodyType)
 				}
+				bodyFields = getBodyFields(structType)
 			}
 
 			// Add the route handler
@@ -272,6 +273,7 @@ func GenerateHandlers(dir string, outDir string) {
 					Methods:              methods,
 					Params:               params,
 					BodyFields:           bodyFields,
+					Body:                 bodyType,
 					Returns:              returns,
 					ReturnGoType:         getUnformattedGoType(returns),
 					ReturnTypescriptType: stringGoTypeToTypescriptType(returns),
@@ -282,27 +284,98 @@ func GenerateHandlers(dir string, outDir string) {
 
 		}
 
-		return nil
-	})
-	if err != nil {
-		panic(err)
 	}
 
-	// Write structs to file
-	_ = os.MkdirAll(outDir, os.ModePerm)
-	file, err := os.Create(outDir + "/handlers.json")
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-	defer file.Close()
+	return handlers, nil
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(handlers); err != nil {
-		fmt.Println("Error:", err)
-		return
+// getBodyFields returns the request body fields of a handler from the body struct.
+func getBodyFields(structType *ast.StructType) []*RouteHandlerParam {
+	bodyFields := make([]*RouteHandlerParam, 0)
+
+	for _, field := range structType.Fields.List {
+		// Skip embedded fields
+		if len(field.Names) == 0 {
+			continue
+		}
+
+		// Get the field name
+		fieldName := field.Names[0].Name
+
+		// Get the field type
+		fieldType := field.Type
+
+		jsonName := fieldName
+		// Get the field tag
+		required := !jsonFieldOmitEmpty(field)
+		jsonField := jsonFieldName(field)
+		if jsonField != "" {
+			jsonName = jsonField
+		}
+
+		// Get field comments
+		fieldComments := make([]string, 0)
+		cmtsTxt := field.Doc.Text()
+		if cmtsTxt != "" {
+			fieldComments = strings.Split(cmtsTxt, "\n")
+		}
+		for _, cmt := range fieldComments {
+			cmt = strings.TrimSpace(strings.TrimPrefix(cmt, "//"))
+			if cmt != "" {
+				fieldComments = append(fieldComments, cmt)
+			}
+		}
+
+		switch fieldType.(type) {
+		case *ast.StarExpr:
+			required = false
+		}
+
+		goType := fieldTypeString(fieldType)
+		goTypeUnformatted := fieldTypeUnformattedString(fieldType)
+		packageName := "handlers"
+		if strings.Contains(goTypeUnformatted, ".") {
+			parts := strings.Split(goTypeUnformatted, ".")
+			packageName = parts[0]
+		}
+
+		tsType := fieldTypeToTypescriptType(fieldType, packageName)
+
+		usedStructType := goTypeUnformatted
+		switch goTypeUnformatted {
+		case "string", "int", "int64", "float64", "float32", "bool", "nil", "uint", "uint64", "uint32", "uint16", "uint8", "byte", "rune", "[]byte", "interface{}", "error":
+			usedStructType = ""
+		}
+
+		// Add the request body field
+		bodyFields = append(bodyFields, &RouteHandlerParam{
+			Name:           fieldName,
+			JsonName:       jsonName,
+			GoType:         goType,
+			UsedStructType: usedStructType,
+			TypescriptType: tsType,
+			Required:       required,
+			Descriptions:   fieldComments,
+		})
+
+		// Check if it's an inline struct and capture its definition
+		if structType, ok := fieldType.(*ast.StructType); ok {
+			bodyFields[len(bodyFields)-1].InlineStructType = formatInlineStruct(structType)
+		} else {
+			// Check if it's a slice of inline structs
+			if arrayType, ok := fieldType.(*ast.ArrayType); ok {
+				if structType, ok := arrayType.Elt.(*ast.StructType); ok {
+					bodyFields[len(bodyFields)-1].InlineStructType = "[]" + formatInlineStruct(structType)
+				}
+			}
+			// Check if it's a map with inline struct values
+			if mapType, ok := fieldType.(*ast.MapType); ok {
+				if structType, ok := mapType.Value.(*ast.StructType); ok {
+					bodyFields[len(bodyFields)-1].InlineStructType = "map[" + fieldTypeString(mapType.Key) + "]" + formatInlineStruct(structType)
+				}
+			}
+		}
 	}
 
-	return
+	return bodyFields
 }
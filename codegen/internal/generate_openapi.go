@@ -0,0 +1,633 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+
+	openAPISessionCookie  = "Seanime-Session-Id"
+	openAPIPasswordHeader = "X-Seanime-Token"
+	openAPIWebsocketPath  = "/events"
+
+	openAPIErrorSchema = "handlers.ErrorResponse"
+)
+
+type (
+	OpenAPIOptions struct {
+		// HandlersDir is the directory containing the route handlers, e.g. ../internal/handlers
+		HandlersDir string
+		// SourceDir is the directory containing the types used by the handlers, e.g. ../internal
+		SourceDir string
+		// EventsFile is the file declaring the websocket events, e.g. ../internal/events/events.go
+		EventsFile string
+		// OutFile is the path of the generated spec, e.g. ../internal/handlers/openapi.json
+		OutFile string
+		// Version is the version of the API
+		Version string
+	}
+
+	OpenAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       *OpenAPIInfo                            `json:"info"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components *OpenAPIComponents                      `json:"components"`
+		Security   []map[string][]string                   `json:"security"`
+		// Websocket is the catalog of events sent over the websocket connection
+		Websocket *OpenAPIWebsocket `json:"x-websocket"`
+	}
+
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	OpenAPIOperation struct {
+		OperationID string                      `json:"operationId"`
+		Summary     string                      `json:"summary,omitempty"`
+		Description string                      `json:"description,omitempty"`
+		Tags        []string                    `json:"tags,omitempty"`
+		Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+		// GoHandler is the name of the Go function handling the route
+		GoHandler string `json:"x-go-handler"`
+	}
+
+	OpenAPIParameter struct {
+		Name        string         `json:"name"`
+		In          string         `json:"in"`
+		Description string         `json:"description,omitempty"`
+		Required    bool           `json:"required"`
+		Schema      *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPIRequestBody struct {
+		Required bool                         `json:"required"`
+		Content  map[string]*OpenAPIMediaType `json:"content"`
+	}
+
+	OpenAPIResponse struct {
+		Description string                       `json:"description"`
+		Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPIComponents struct {
+		Schemas         map[string]*OpenAPISchema         `json:"schemas"`
+		SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes"`
+	}
+
+	OpenAPISecurityScheme struct {
+		Type        string `json:"type"`
+		In          string `json:"in"`
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Description          string                    `json:"description,omitempty"`
+		Enum                 []any                     `json:"enum,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+		AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+		// GoType is set when the Go type could not be described, e.g. types from external packages
+		GoType string `json:"x-go-type,omitempty"`
+	}
+
+	OpenAPIWebsocket struct {
+		Path string `json:"path"`
+		// Events are sent by the server
+		Events []*OpenAPIWebsocketEvent `json:"events"`
+		// ClientEvents are sent by the client
+		ClientEvents []*OpenAPIWebsocketEvent `json:"clientEvents"`
+	}
+
+	OpenAPIWebsocketEvent struct {
+		Name        string `json:"name"`
+		GoName      string `json:"x-go-name"`
+		Description string `json:"description,omitempty"`
+	}
+)
+
+var routeParamRegex = regexp.MustCompile(`\{([^}]+)}`)
+
+// GenerateOpenAPISpec generates the OpenAPI document from the handler annotations and writes it to opts.OutFile.
+func GenerateOpenAPISpec(opts *OpenAPIOptions) error {
+	content, warnings, err := buildOpenAPISpecFile(opts)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Println("openapi: Warning:", w)
+	}
+
+	if err := os.WriteFile(opts.OutFile, content, 0644); err != nil {
+		return err
+	}
+
+	fmt.Println("OpenAPI spec generated and saved to", filepath.Base(opts.OutFile))
+	return nil
+}
+
+// CheckOpenAPISpec returns an error if the spec at opts.OutFile does not match the handler annotations.
+func CheckOpenAPISpec(opts *OpenAPIOptions) error {
+	content, _, err := buildOpenAPISpecFile(opts)
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(opts.OutFile)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(bytes.ReplaceAll(current, []byte("\r\n"), []byte("\n")), content) {
+		return fmt.Errorf("openapi: %s is out of date, run 'go generate ./internal/handlers'", filepath.Base(opts.OutFile))
+	}
+
+	return nil
+}
+
+func buildOpenAPISpecFile(opts *OpenAPIOptions) ([]byte, []string, error) {
+	handlers, err := ParseHandlers(opts.HandlersDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	structs, err := ParseStructs(opts.SourceDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	websocket, err := parseWebsocketEvents(opts.EventsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, warnings := BuildOpenAPISpec(handlers, structs, opts.Version)
+	doc.Websocket = websocket
+
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), warnings, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type openAPIBuilder struct {
+	// structs by "package.Name"
+	structs map[string]*GoStruct
+	// structs by formatted name, used for the inline structs extracted from struct fields
+	formattedStructs map[string]*GoStruct
+	schemas          map[string]*OpenAPISchema
+	queue            []*GoStruct
+	warnings         []string
+}
+
+// BuildOpenAPISpec builds the OpenAPI document from the route handlers.
+// The schemas of the types used by the handlers are resolved from structs.
+// It also returns warnings for types that could not be described, such as inline structs.
+func BuildOpenAPISpec(handlers []*RouteHandler, structs []*GoStruct, version string) (*OpenAPIDocument, []string) {
+	b := &openAPIBuilder{
+		structs:          make(map[string]*GoStruct),
+		formattedStructs: make(map[string]*GoStruct),
+		schemas:          make(map[string]*OpenAPISchema),
+		warnings:         make([]string, 0),
+	}
+	for _, s := range structs {
+		key := s.Package + "." + s.Name
+		if _, found := b.structs[key]; !found {
+			b.structs[key] = s
+		}
+		if _, found := b.formattedStructs[s.FormattedName]; !found {
+			b.formattedStructs[s.FormattedName] = s
+		}
+	}
+
+	b.schemas[openAPIErrorSchema] = &OpenAPISchema{
+		Type:        "object",
+		Description: "Returned with a 500 status code when the request fails.",
+		Properties: map[string]*OpenAPISchema{
+			"error": {Type: "string"},
+		},
+		Required: []string{"error"},
+	}
+
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: &OpenAPIInfo{
+			Title:       "Seanime API",
+			Description: "Successful responses are wrapped in a \"data\" field, errors are returned as {\"error\": \"message\"}.",
+			Version:     version,
+		},
+		Paths: make(map[string]map[string]*OpenAPIOperation),
+		Components: &OpenAPIComponents{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				"session": {
+					Type:        "apiKey",
+					In:          "cookie",
+					Name:        openAPISessionCookie,
+					Description: "Identifies the browser session and the AniList account it is logged in with. It is set by the server on the first request.",
+				},
+				"apiKey": {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        openAPIPasswordHeader,
+					Description: "Hash of the server password. Only required when a server password is set.",
+				},
+			},
+		},
+		Security: []map[string][]string{
+			{"session": {}, "apiKey": {}},
+			{"session": {}},
+		},
+	}
+
+	for _, handler := range handlers {
+		if handler.Api == nil || handler.Api.Endpoint == "" || len(handler.Api.Methods) == 0 {
+			continue
+		}
+
+		path, ok := doc.Paths[handler.Api.Endpoint]
+		if !ok {
+			path = make(map[string]*OpenAPIOperation)
+			doc.Paths[handler.Api.Endpoint] = path
+		}
+
+		for _, method := range handler.Api.Methods {
+			method = strings.ToLower(strings.TrimSpace(method))
+
+			operationID := handler.TrimmedName
+			if len(handler.Api.Methods) > 1 {
+				operationID += "_" + method
+			}
+
+			path[method] = b.buildOperation(handler, method, operationID)
+		}
+	}
+
+	// Resolve the schemas of the referenced types
+	for len(b.queue) > 0 {
+		s := b.queue[0]
+		b.queue = b.queue[1:]
+		b.schemas[schemaName(s)] = b.structSchema(s)
+	}
+
+	sort.Strings(b.warnings)
+
+	return doc, b.warnings
+}
+
+func (b *openAPIBuilder) buildOperation(handler *RouteHandler, method string, operationID string) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: operationID,
+		Summary:     handler.Api.Summary,
+		Description: strings.Join(handler.Api.Descriptions, "\n"),
+		Tags:        []string{strings.TrimSuffix(handler.Filename, ".go")},
+		GoHandler:   handler.Name,
+		Responses: map[string]*OpenAPIResponse{
+			"500": {
+				Description: "Error",
+				Content: map[string]*OpenAPIMediaType{
+					"application/json": {Schema: &OpenAPISchema{Ref: schemaRef(openAPIErrorSchema)}},
+				},
+			},
+		},
+	}
+
+	// Parameters
+	pathParams := make(map[string]bool)
+	for _, match := range routeParamRegex.FindAllStringSubmatch(handler.Api.Endpoint, -1) {
+		pathParams[match[1]] = true
+	}
+	for _, param := range handler.Api.Params {
+		in := "query"
+		if pathParams[param.Name] {
+			in = "path"
+		}
+		op.Parameters = append(op.Parameters, &OpenAPIParameter{
+			Name:        param.Name,
+			In:          in,
+			Description: strings.Join(param.Descriptions, "\n"),
+			Required:    param.Required || in == "path",
+			Schema:      b.typeSchema(param.GoType, "handlers", handler.Name),
+		})
+	}
+
+	// Request body
+	if method != "get" && method != "head" {
+		var bodySchema *OpenAPISchema
+		if handler.Api.Body != "" {
+			bodySchema = b.typeSchema(handler.Api.Body, "handlers", handler.Name)
+		} else if len(handler.Api.BodyFields) > 0 {
+			bodySchema = &OpenAPISchema{
+				Type:       "object",
+				Properties: make(map[string]*OpenAPISchema),
+			}
+			for _, field := range handler.Api.BodyFields {
+				var fieldSchema *OpenAPISchema
+				if field.InlineStructType != "" {
+					b.warn("%s: Field %s of the request body is an inline struct, use a named type and the @body annotation", handler.Name, field.Name)
+					fieldSchema = &OpenAPISchema{Type: "object", GoType: field.InlineStructType}
+				} else {
+					fieldSchema = b.typeSchema(field.GoType, "handlers", handler.Name)
+				}
+				if len(field.Descriptions) > 0 && fieldSchema.Ref == "" {
+					fieldSchema.Description = strings.Join(field.Descriptions, "\n")
+				}
+				bodySchema.Properties[field.JsonName] = fieldSchema
+				if field.Required {
+					bodySchema.Required = append(bodySchema.Required, field.JsonName)
+				}
+			}
+		}
+		if bodySchema != nil {
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]*OpenAPIMediaType{
+					"application/json": {Schema: bodySchema},
+				},
+			}
+		}
+	}
+
+	// Response
+	dataSchema := &OpenAPISchema{Type: "object"}
+	switch handler.Api.Returns {
+	case "nil", "":
+	case "true":
+		dataSchema.Properties = map[string]*OpenAPISchema{"data": {Type: "boolean"}}
+	default:
+		dataSchema.Properties = map[string]*OpenAPISchema{"data": b.typeSchema(handler.Api.Returns, "handlers", handler.Name)}
+	}
+	op.Responses["200"] = &OpenAPIResponse{
+		Description: "Success",
+		Content: map[string]*OpenAPIMediaType{
+			"application/json": {Schema: dataSchema},
+		},
+	}
+
+	return op
+}
+
+// typeSchema returns the schema of a Go type string, e.g. "[]*anime.LocalFile" or "map[string]models.Theme".
+// Named types that are not qualified are resolved in pkg.
+func (b *openAPIBuilder) typeSchema(goType string, pkg string, owner string) *OpenAPISchema {
+	goType = strings.TrimSpace(goType)
+	goType = strings.TrimPrefix(goType, "*")
+
+	switch goType {
+	case "", "any", "interface{}", "json.RawMessage":
+		return &OpenAPISchema{}
+	case "string", "error":
+		return &OpenAPISchema{Type: "string"}
+	case "bool":
+		return &OpenAPISchema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+		return &OpenAPISchema{Type: "integer"}
+	case "int64", "uint64", "time.Duration":
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case "float64", "float":
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case "time.Time":
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case "[]byte":
+		return &OpenAPISchema{Type: "string", Format: "byte"}
+	case "__STRUCT__", "[]__STRUCT__":
+		b.warn("%s: Inline struct cannot be described, use a named type", owner)
+		return &OpenAPISchema{Type: "object"}
+	}
+
+	if strings.HasPrefix(goType, "[]") {
+		return &OpenAPISchema{Type: "array", Items: b.typeSchema(goType[2:], pkg, owner)}
+	}
+
+	if strings.HasPrefix(goType, "map[") {
+		depth := 0
+		for i, c := range goType {
+			switch c {
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return &OpenAPISchema{Type: "object", AdditionalProperties: b.typeSchema(goType[i+1:], pkg, owner)}
+				}
+			}
+		}
+	}
+
+	// Named type
+	key := goType
+	if !strings.Contains(key, ".") {
+		key = pkg + "." + key
+	}
+	s, ok := b.structs[key]
+	if !ok && !strings.Contains(goType, ".") {
+		s, ok = b.formattedStructs[goType]
+	}
+	if !ok {
+		b.warn("%s: Type %s not found", owner, goType)
+		return &OpenAPISchema{GoType: goType}
+	}
+
+	name := schemaName(s)
+	if _, found := b.schemas[name]; !found {
+		// Reserve the name so that the struct is only queued once
+		b.schemas[name] = nil
+		b.queue = append(b.queue, s)
+	}
+
+	return &OpenAPISchema{Ref: schemaRef(name)}
+}
+
+// structSchema returns the schema of a struct or type definition.
+func (b *openAPIBuilder) structSchema(s *GoStruct) *OpenAPISchema {
+	owner := s.Package + "." + s.Name
+	description := strings.TrimSpace(strings.Join(trimComments(s.Comments), "\n"))
+
+	if s.AliasOf != nil {
+		ret := b.typeSchema(s.AliasOf.GoType, s.Package, owner)
+		if ret.Ref != "" {
+			return ret
+		}
+		ret.Description = description
+		for _, value := range s.AliasOf.DeclaredValues {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				ret.Enum = append(ret.Enum, unquoted)
+			} else if n, err := strconv.ParseFloat(value, 64); err == nil {
+				ret.Enum = append(ret.Enum, n)
+			}
+		}
+		return ret
+	}
+
+	ret := &OpenAPISchema{
+		Type:        "object",
+		Description: description,
+		Properties:  make(map[string]*OpenAPISchema),
+	}
+
+	for _, field := range s.Fields {
+		if !field.Public || field.JsonName == "" {
+			continue
+		}
+		var fieldSchema *OpenAPISchema
+		if strings.Contains(field.GoType, "__STRUCT__") {
+			b.warn("%s: Field %s is an inline struct, use a named type", owner, field.Name)
+			fieldSchema = &OpenAPISchema{Type: "object", GoType: field.InlineStructType}
+		} else {
+			fieldSchema = b.typeSchema(field.GoType, s.Package, owner)
+		}
+		if comments := strings.TrimSpace(strings.Join(trimComments(field.Comments), "\n")); comments != "" && fieldSchema.Ref == "" {
+			fieldSchema.Description = comments
+		}
+		ret.Properties[field.JsonName] = fieldSchema
+		if field.Required {
+			ret.Required = append(ret.Required, field.JsonName)
+		}
+	}
+
+	if len(s.EmbeddedStructTypes) == 0 {
+		return ret
+	}
+
+	// Embedded struct fields are flattened by encoding/json
+	allOf := make([]*OpenAPISchema, 0, len(s.EmbeddedStructTypes)+1)
+	for _, embedded := range s.EmbeddedStructTypes {
+		if embedded == "" {
+			continue
+		}
+		allOf = append(allOf, b.typeSchema(embedded, s.Package, owner))
+	}
+	allOf = append(allOf, &OpenAPISchema{
+		Type:       "object",
+		Properties: ret.Properties,
+		Required:   ret.Required,
+	})
+
+	return &OpenAPISchema{
+		Description: description,
+		AllOf:       allOf,
+	}
+}
+
+func (b *openAPIBuilder) warn(format string, args ...any) {
+	w := fmt.Sprintf(format, args...)
+	for _, existing := range b.warnings {
+		if existing == w {
+			return
+		}
+	}
+	b.warnings = append(b.warnings, w)
+}
+
+func schemaName(s *GoStruct) string {
+	return s.Package + "." + s.Name
+}
+
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+func trimComments(comments []string) []string {
+	ret := make([]string, 0, len(comments))
+	for _, c := range comments {
+		if c = strings.TrimSpace(c); c != "" {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseWebsocketEvents returns the events declared as string constants in the events file.
+// Constants of type WebsocketClientEventType are sent by the client, the others are sent by the server.
+func parseWebsocketEvents(path string) (*OpenAPIWebsocket, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &OpenAPIWebsocket{
+		Path:         openAPIWebsocketPath,
+		Events:       make([]*OpenAPIWebsocketEvent, 0),
+		ClientEvents: make([]*OpenAPIWebsocketEvent, 0),
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok || len(valueSpec.Names) != 1 || len(valueSpec.Values) != 1 {
+				continue
+			}
+			lit, ok := valueSpec.Values[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			event := &OpenAPIWebsocketEvent{
+				Name:   value,
+				GoName: valueSpec.Names[0].Name,
+			}
+			if valueSpec.Comment != nil {
+				event.Description = strings.TrimSpace(valueSpec.Comment.Text())
+			} else if valueSpec.Doc != nil {
+				event.Description = strings.TrimSpace(valueSpec.Doc.Text())
+			}
+
+			if ident, ok := valueSpec.Type.(*ast.Ident); ok && ident.Name == "WebsocketClientEventType" {
+				ret.ClientEvents = append(ret.ClientEvents, event)
+			} else {
+				ret.Events = append(ret.Events, event)
+			}
+		}
+	}
+
+	if len(ret.Events) == 0 {
+		return nil, errors.New("openapi: No websocket events found")
+	}
+
+	return ret, nil
+}
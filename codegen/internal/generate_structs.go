@@ -134,21 +134,7 @@ func getTypePrefix(packageName string) string {
 
 func ExtractStructs(dir string, outDir string) {
 
-	structs := make([]*GoStruct, 0)
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
-			res, err := getGoStructsFromFile(path, info)
-			if err != nil {
-				return err
-			}
-			structs = append(structs, res...)
-		}
-		return nil
-	})
+	structs, err := ParseStructs(dir)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return
@@ -173,6 +159,30 @@ func ExtractStructs(dir string, outDir string) {
 	fmt.Println("Public structs extracted and saved to public_structs.json")
 }
 
+// ParseStructs parses the exported types declared in the Go files of the given directory and its subdirectories.
+func ParseStructs(dir string) ([]*GoStruct, error) {
+	structs := make([]*GoStruct, 0)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+			res, err := getGoStructsFromFile(path, info)
+			if err != nil {
+				return err
+			}
+			structs = append(structs, res...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return structs, nil
+}
+
 func getGoStructsFromFile(path string, info os.FileInfo) (structs []*GoStruct, err error) {
 
 	// Parse the Go file
@@ -1,8 +1,9 @@
-//go:generate go run main.go --skipHandlers=false --skipStructs=false --skipTypes=false --skipPluginEvents=false --skipHookEvents=false --skipHandlerHookEvents=false
+//go:generate go run main.go --skipHandlers=false --skipStructs=false --skipTypes=false --skipPluginEvents=false --skipHookEvents=false --skipHandlerHookEvents=false --skipOpenAPI=false
 package main
 
 import (
 	"flag"
+	"fmt"
 	codegen "seanime/codegen/internal"
 )
 
@@ -23,6 +24,9 @@ func main() {
 	var skipHookEvents bool
 	flag.BoolVar(&skipHookEvents, "skipHookEvents", false, "Skip generating hook events")
 
+	var skipOpenAPI bool
+	flag.BoolVar(&skipOpenAPI, "skipOpenAPI", false, "Skip generating the OpenAPI spec")
+
 	var skipHandlerHookEvents bool
 	flag.BoolVar(&skipHandlerHookEvents, "skipHandlerHookEvents", false, "Skip generating handler hook events")
 
@@ -36,6 +40,19 @@ func main() {
 		codegen.ExtractStructs("../internal", "./generated")
 	}
 
+	if !skipOpenAPI {
+		err := codegen.GenerateOpenAPISpec(&codegen.OpenAPIOptions{
+			HandlersDir: "../internal/handlers",
+			SourceDir:   "../internal",
+			EventsFile:  "../internal/events/events.go",
+			OutFile:     "../internal/handlers/openapi.json",
+			Version:     "v1",
+		})
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+
 	if !skipTypes {
 		goStructStrs := codegen.GenerateTypescriptEndpointsFile("./generated/handlers.json", "./generated/public_structs.json", "../seanime-web/src/api/generated", "../internal/events")
 		codegen.GenerateTypescriptFile("./generated/handlers.json", "./generated/public_structs.json", "../seanime-web/src/api/generated", goStructStrs)
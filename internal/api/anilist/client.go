@@ -29,6 +29,7 @@ type AnilistClient interface {
 	IsAuthenticated() bool
 	AnimeCollection(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeCollection, error)
 	AnimeCollectionWithRelations(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeCollectionWithRelations, error)
+	AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeListLatestUpdate, error)
 	BaseAnimeByMalID(ctx context.Context, id *int, interceptors ...clientv2.RequestInterceptor) (*BaseAnimeByMalID, error)
 	BaseAnimeByID(ctx context.Context, id *int, interceptors ...clientv2.RequestInterceptor) (*BaseAnimeByID, error)
 	SearchBaseAnimeByIds(ctx context.Context, ids []*int, page *int, perPage *int, status []*MediaStatus, inCollection *bool, sort []*MediaSort, season *MediaSeason, year *int, genre *string, format *MediaFormat, interceptors ...clientv2.RequestInterceptor) (*SearchBaseAnimeByIds, error)
@@ -38,6 +39,7 @@ type AnilistClient interface {
 	ListRecentAnime(ctx context.Context, page *int, perPage *int, airingAtGreater *int, airingAtLesser *int, notYetAired *bool, interceptors ...clientv2.RequestInterceptor) (*ListRecentAnime, error)
 	UpdateMediaListEntry(ctx context.Context, mediaID *int, status *MediaListStatus, scoreRaw *int, progress *int, startedAt *FuzzyDateInput, completedAt *FuzzyDateInput, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntry, error)
 	UpdateMediaListEntryProgress(ctx context.Context, mediaID *int, progress *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryProgress, error)
+	UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMangaListEntryProgress, error)
 	UpdateMediaListEntryRepeat(ctx context.Context, mediaID *int, repeat *int, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryRepeat, error)
 	DeleteEntry(ctx context.Context, mediaListEntryID *int, interceptors ...clientv2.RequestInterceptor) (*DeleteEntry, error)
 	MangaCollection(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*MangaCollection, error)
@@ -50,6 +52,8 @@ type AnilistClient interface {
 	GetViewer(ctx context.Context, interceptors ...clientv2.RequestInterceptor) (*GetViewer, error)
 	AnimeAiringSchedule(ctx context.Context, ids []*int, season *MediaSeason, seasonYear *int, previousSeason *MediaSeason, previousSeasonYear *int, nextSeason *MediaSeason, nextSeasonYear *int, interceptors ...clientv2.RequestInterceptor) (*AnimeAiringSchedule, error)
 	AnimeAiringScheduleRaw(ctx context.Context, ids []*int, interceptors ...clientv2.RequestInterceptor) (*AnimeAiringScheduleRaw, error)
+	GetStreamingEpisodes(ctx context.Context, id int) ([]*StreamingEpisode, error)
+	GetEntryProgress(ctx context.Context, mediaID int) (*EntryProgress, error)
 	GetCacheDir() string
 	CustomQuery(body []byte, logger *zerolog.Logger, token ...string) (interface{}, error)
 }
@@ -128,6 +132,14 @@ func (ac *AnilistClientImpl) UpdateMediaListEntryProgress(ctx context.Context, m
 	return ac.Client.UpdateMediaListEntryProgress(ctx, mediaID, progress, status, interceptors...)
 }
 
+func (ac *AnilistClientImpl) UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMangaListEntryProgress, error) {
+	if !ac.IsAuthenticated() {
+		return nil, ErrNotAuthenticated
+	}
+	ac.logger.Debug().Int("mediaId", *mediaID).Msg("anilist: Updating manga list entry progress")
+	return ac.Client.UpdateMangaListEntryProgress(ctx, mediaID, progress, progressVolumes, status, interceptors...)
+}
+
 func (ac *AnilistClientImpl) UpdateMediaListEntryRepeat(ctx context.Context, mediaID *int, repeat *int, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryRepeat, error) {
 	if !ac.IsAuthenticated() {
 		return nil, ErrNotAuthenticated
@@ -160,6 +172,14 @@ func (ac *AnilistClientImpl) AnimeCollectionWithRelations(ctx context.Context, u
 	return ac.Client.AnimeCollectionWithRelations(ctx, userName, interceptors...)
 }
 
+func (ac *AnilistClientImpl) AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeListLatestUpdate, error) {
+	if !ac.IsAuthenticated() {
+		return nil, ErrNotAuthenticated
+	}
+	ac.logger.Trace().Msg("anilist: Fetching latest anime list update")
+	return ac.Client.AnimeListLatestUpdate(ctx, userName, interceptors...)
+}
+
 func (ac *AnilistClientImpl) GetViewer(ctx context.Context, interceptors ...clientv2.RequestInterceptor) (*GetViewer, error) {
 	if !ac.IsAuthenticated() {
 		return nil, ErrNotAuthenticated
@@ -380,6 +400,28 @@ func parseResponse(body []byte, httpCode int, result interface{}) error {
 	return nil
 }
 
+// IsNotFoundError returns true if AniList responded that the requested resource does not exist,
+// e.g. a media that was deleted.
+func IsNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var errResponse *clientv2.ErrorResponse
+	if errors.As(err, &errResponse) {
+		if errResponse.NetworkError != nil && errResponse.NetworkError.Code == http.StatusNotFound {
+			return true
+		}
+		if errResponse.GqlErrors != nil {
+			for _, e := range *errResponse.GqlErrors {
+				if e != nil && e.Message == "Not Found." {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // response is a GraphQL layer response from a handler.
 type response struct {
 	Data   json.RawMessage `json:"data"`
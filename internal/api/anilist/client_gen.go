@@ -12,6 +12,7 @@ import (
 type GithubGraphQLClient interface {
 	AnimeCollection(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeCollection, error)
 	AnimeCollectionWithRelations(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeCollectionWithRelations, error)
+	AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeListLatestUpdate, error)
 	BaseAnimeByMalID(ctx context.Context, id *int, interceptors ...clientv2.RequestInterceptor) (*BaseAnimeByMalID, error)
 	BaseAnimeByID(ctx context.Context, id *int, interceptors ...clientv2.RequestInterceptor) (*BaseAnimeByID, error)
 	SearchBaseAnimeByIds(ctx context.Context, ids []*int, page *int, perPage *int, status []*MediaStatus, inCollection *bool, sort []*MediaSort, season *MediaSeason, year *int, genre *string, format *MediaFormat, interceptors ...clientv2.RequestInterceptor) (*SearchBaseAnimeByIds, error)
@@ -23,6 +24,7 @@ type GithubGraphQLClient interface {
 	AnimeAiringScheduleRaw(ctx context.Context, ids []*int, interceptors ...clientv2.RequestInterceptor) (*AnimeAiringScheduleRaw, error)
 	UpdateMediaListEntry(ctx context.Context, mediaID *int, status *MediaListStatus, scoreRaw *int, progress *int, startedAt *FuzzyDateInput, completedAt *FuzzyDateInput, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntry, error)
 	UpdateMediaListEntryProgress(ctx context.Context, mediaID *int, progress *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryProgress, error)
+	UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMangaListEntryProgress, error)
 	DeleteEntry(ctx context.Context, mediaListEntryID *int, interceptors ...clientv2.RequestInterceptor) (*DeleteEntry, error)
 	UpdateMediaListEntryRepeat(ctx context.Context, mediaID *int, repeat *int, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryRepeat, error)
 	MangaCollection(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*MangaCollection, error)
@@ -1957,6 +1959,7 @@ type AnimeCollection_MediaListCollection_Lists_Entries struct {
 	Score       *float64                                                       "json:\"score,omitempty\" graphql:\"score\""
 	Progress    *int                                                           "json:\"progress,omitempty\" graphql:\"progress\""
 	Status      *MediaListStatus                                               "json:\"status,omitempty\" graphql:\"status\""
+	UpdatedAt   *int                                                           "json:\"updatedAt,omitempty\" graphql:\"updatedAt\""
 	Notes       *string                                                        "json:\"notes,omitempty\" graphql:\"notes\""
 	Repeat      *int                                                           "json:\"repeat,omitempty\" graphql:\"repeat\""
 	Private     *bool                                                          "json:\"private,omitempty\" graphql:\"private\""
@@ -1989,6 +1992,12 @@ func (t *AnimeCollection_MediaListCollection_Lists_Entries) GetStatus() *MediaLi
 	}
 	return t.Status
 }
+func (t *AnimeCollection_MediaListCollection_Lists_Entries) GetUpdatedAt() *int {
+	if t == nil {
+		t = &AnimeCollection_MediaListCollection_Lists_Entries{}
+	}
+	return t.UpdatedAt
+}
 func (t *AnimeCollection_MediaListCollection_Lists_Entries) GetNotes() *string {
 	if t == nil {
 		t = &AnimeCollection_MediaListCollection_Lists_Entries{}
@@ -2593,6 +2602,42 @@ func (t *AnimeCollectionWithRelations_MediaListCollection) GetLists() []*AnimeCo
 	return t.Lists
 }
 
+type AnimeListLatestUpdate_Page_MediaList struct {
+	ID        int  "json:\"id\" graphql:\"id\""
+	MediaID   int  "json:\"mediaId\" graphql:\"mediaId\""
+	UpdatedAt *int "json:\"updatedAt,omitempty\" graphql:\"updatedAt\""
+}
+
+func (t *AnimeListLatestUpdate_Page_MediaList) GetID() int {
+	if t == nil {
+		t = &AnimeListLatestUpdate_Page_MediaList{}
+	}
+	return t.ID
+}
+func (t *AnimeListLatestUpdate_Page_MediaList) GetMediaID() int {
+	if t == nil {
+		t = &AnimeListLatestUpdate_Page_MediaList{}
+	}
+	return t.MediaID
+}
+func (t *AnimeListLatestUpdate_Page_MediaList) GetUpdatedAt() *int {
+	if t == nil {
+		t = &AnimeListLatestUpdate_Page_MediaList{}
+	}
+	return t.UpdatedAt
+}
+
+type AnimeListLatestUpdate_Page struct {
+	MediaList []*AnimeListLatestUpdate_Page_MediaList "json:\"mediaList,omitempty\" graphql:\"mediaList\""
+}
+
+func (t *AnimeListLatestUpdate_Page) GetMediaList() []*AnimeListLatestUpdate_Page_MediaList {
+	if t == nil {
+		t = &AnimeListLatestUpdate_Page{}
+	}
+	return t.MediaList
+}
+
 type BaseAnimeByMalId_Media_BaseAnime_Trailer struct {
 	ID        *string "json:\"id,omitempty\" graphql:\"id\""
 	Site      *string "json:\"site,omitempty\" graphql:\"site\""
@@ -5370,6 +5415,17 @@ func (t *UpdateMediaListEntryProgress_SaveMediaListEntry) GetID() int {
 	return t.ID
 }
 
+type UpdateMangaListEntryProgress_SaveMediaListEntry struct {
+	ID int "json:\"id\" graphql:\"id\""
+}
+
+func (t *UpdateMangaListEntryProgress_SaveMediaListEntry) GetID() int {
+	if t == nil {
+		t = &UpdateMangaListEntryProgress_SaveMediaListEntry{}
+	}
+	return t.ID
+}
+
 type DeleteEntry_DeleteMediaListEntry struct {
 	Deleted *bool "json:\"deleted,omitempty\" graphql:\"deleted\""
 }
@@ -5557,16 +5613,17 @@ func (t *MangaCollection_MediaListCollection_Lists_Entries_Media_BaseManga_EndDa
 }
 
 type MangaCollection_MediaListCollection_Lists_Entries struct {
-	ID          int                                                            "json:\"id\" graphql:\"id\""
-	Score       *float64                                                       "json:\"score,omitempty\" graphql:\"score\""
-	Progress    *int                                                           "json:\"progress,omitempty\" graphql:\"progress\""
-	Status      *MediaListStatus                                               "json:\"status,omitempty\" graphql:\"status\""
-	Notes       *string                                                        "json:\"notes,omitempty\" graphql:\"notes\""
-	Repeat      *int                                                           "json:\"repeat,omitempty\" graphql:\"repeat\""
-	Private     *bool                                                          "json:\"private,omitempty\" graphql:\"private\""
-	StartedAt   *MangaCollection_MediaListCollection_Lists_Entries_StartedAt   "json:\"startedAt,omitempty\" graphql:\"startedAt\""
-	CompletedAt *MangaCollection_MediaListCollection_Lists_Entries_CompletedAt "json:\"completedAt,omitempty\" graphql:\"completedAt\""
-	Media       *BaseManga                                                     "json:\"media,omitempty\" graphql:\"media\""
+	ID              int                                                            "json:\"id\" graphql:\"id\""
+	Score           *float64                                                       "json:\"score,omitempty\" graphql:\"score\""
+	Progress        *int                                                           "json:\"progress,omitempty\" graphql:\"progress\""
+	ProgressVolumes *int                                                           "json:\"progressVolumes,omitempty\" graphql:\"progressVolumes\""
+	Status          *MediaListStatus                                               "json:\"status,omitempty\" graphql:\"status\""
+	Notes           *string                                                        "json:\"notes,omitempty\" graphql:\"notes\""
+	Repeat          *int                                                           "json:\"repeat,omitempty\" graphql:\"repeat\""
+	Private         *bool                                                          "json:\"private,omitempty\" graphql:\"private\""
+	StartedAt       *MangaCollection_MediaListCollection_Lists_Entries_StartedAt   "json:\"startedAt,omitempty\" graphql:\"startedAt\""
+	CompletedAt     *MangaCollection_MediaListCollection_Lists_Entries_CompletedAt "json:\"completedAt,omitempty\" graphql:\"completedAt\""
+	Media           *BaseManga                                                     "json:\"media,omitempty\" graphql:\"media\""
 }
 
 func (t *MangaCollection_MediaListCollection_Lists_Entries) GetID() int {
@@ -5587,6 +5644,12 @@ func (t *MangaCollection_MediaListCollection_Lists_Entries) GetProgress() *int {
 	}
 	return t.Progress
 }
+func (t *MangaCollection_MediaListCollection_Lists_Entries) GetProgressVolumes() *int {
+	if t == nil {
+		t = &MangaCollection_MediaListCollection_Lists_Entries{}
+	}
+	return t.ProgressVolumes
+}
 func (t *MangaCollection_MediaListCollection_Lists_Entries) GetStatus() *MediaListStatus {
 	if t == nil {
 		t = &MangaCollection_MediaListCollection_Lists_Entries{}
@@ -7290,6 +7353,17 @@ func (t *AnimeCollectionWithRelations) GetMediaListCollection() *AnimeCollection
 	return t.MediaListCollection
 }
 
+type AnimeListLatestUpdate struct {
+	Page *AnimeListLatestUpdate_Page "json:\"Page,omitempty\" graphql:\"Page\""
+}
+
+func (t *AnimeListLatestUpdate) GetPage() *AnimeListLatestUpdate_Page {
+	if t == nil {
+		t = &AnimeListLatestUpdate{}
+	}
+	return t.Page
+}
+
 type BaseAnimeByMalID struct {
 	Media *BaseAnime "json:\"Media,omitempty\" graphql:\"Media\""
 }
@@ -7439,6 +7513,17 @@ func (t *UpdateMediaListEntryProgress) GetSaveMediaListEntry() *UpdateMediaListE
 	return t.SaveMediaListEntry
 }
 
+type UpdateMangaListEntryProgress struct {
+	SaveMediaListEntry *UpdateMangaListEntryProgress_SaveMediaListEntry "json:\"SaveMediaListEntry,omitempty\" graphql:\"SaveMediaListEntry\""
+}
+
+func (t *UpdateMangaListEntryProgress) GetSaveMediaListEntry() *UpdateMangaListEntryProgress_SaveMediaListEntry {
+	if t == nil {
+		t = &UpdateMangaListEntryProgress{}
+	}
+	return t.SaveMediaListEntry
+}
+
 type DeleteEntry struct {
 	DeleteMediaListEntry *DeleteEntry_DeleteMediaListEntry "json:\"DeleteMediaListEntry,omitempty\" graphql:\"DeleteMediaListEntry\""
 }
@@ -7560,6 +7645,7 @@ const AnimeCollectionDocument = `query AnimeCollection ($userName: String) {
 				score(format: POINT_100)
 				progress
 				status
+				updatedAt
 				notes
 				repeat
 				private
@@ -7810,6 +7896,34 @@ func (c *Client) AnimeCollectionWithRelations(ctx context.Context, userName *str
 	return &res, nil
 }
 
+const AnimeListLatestUpdateDocument = `query AnimeListLatestUpdate ($userName: String) {
+	Page(page: 1, perPage: 1) {
+		mediaList(userName: $userName, type: ANIME, sort: UPDATED_TIME_DESC) {
+			id
+			mediaId
+			updatedAt
+		}
+	}
+}
+`
+
+func (c *Client) AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeListLatestUpdate, error) {
+	vars := map[string]any{
+		"userName": userName,
+	}
+
+	var res AnimeListLatestUpdate
+	if err := c.Client.Post(ctx, "AnimeListLatestUpdate", AnimeListLatestUpdateDocument, &res, vars, interceptors...); err != nil {
+		if c.Client.ParseDataWhenErrors {
+			return &res, err
+		}
+
+		return nil, err
+	}
+
+	return &res, nil
+}
+
 const BaseAnimeByMalIDDocument = `query BaseAnimeByMalId ($id: Int) {
 	Media(idMal: $id, type: ANIME) {
 		... baseAnime
@@ -8744,6 +8858,33 @@ func (c *Client) UpdateMediaListEntryProgress(ctx context.Context, mediaID *int,
 	return &res, nil
 }
 
+const UpdateMangaListEntryProgressDocument = `mutation UpdateMangaListEntryProgress ($mediaId: Int, $progress: Int, $progressVolumes: Int, $status: MediaListStatus) {
+	SaveMediaListEntry(mediaId: $mediaId, progress: $progress, progressVolumes: $progressVolumes, status: $status) {
+		id
+	}
+}
+`
+
+func (c *Client) UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMangaListEntryProgress, error) {
+	vars := map[string]any{
+		"mediaId":         mediaID,
+		"progress":        progress,
+		"progressVolumes": progressVolumes,
+		"status":          status,
+	}
+
+	var res UpdateMangaListEntryProgress
+	if err := c.Client.Post(ctx, "UpdateMangaListEntryProgress", UpdateMangaListEntryProgressDocument, &res, vars, interceptors...); err != nil {
+		if c.Client.ParseDataWhenErrors {
+			return &res, err
+		}
+
+		return nil, err
+	}
+
+	return &res, nil
+}
+
 const DeleteEntryDocument = `mutation DeleteEntry ($mediaListEntryId: Int) {
 	DeleteMediaListEntry(id: $mediaListEntryId) {
 		deleted
@@ -8803,6 +8944,7 @@ const MangaCollectionDocument = `query MangaCollection ($userName: String) {
 				id
 				score(format: POINT_100)
 				progress
+				progressVolumes
 				status
 				notes
 				repeat
@@ -9518,6 +9660,7 @@ func (c *Client) GetViewer(ctx context.Context, interceptors ...clientv2.Request
 var DocumentOperationNames = map[string]string{
 	AnimeCollectionDocument:              "AnimeCollection",
 	AnimeCollectionWithRelationsDocument: "AnimeCollectionWithRelations",
+	AnimeListLatestUpdateDocument:        "AnimeListLatestUpdate",
 	BaseAnimeByMalIDDocument:             "BaseAnimeByMalId",
 	BaseAnimeByIDDocument:                "BaseAnimeById",
 	SearchBaseAnimeByIdsDocument:         "SearchBaseAnimeByIds",
@@ -9529,6 +9672,7 @@ var DocumentOperationNames = map[string]string{
 	AnimeAiringScheduleRawDocument:       "AnimeAiringScheduleRaw",
 	UpdateMediaListEntryDocument:         "UpdateMediaListEntry",
 	UpdateMediaListEntryProgressDocument: "UpdateMediaListEntryProgress",
+	UpdateMangaListEntryProgressDocument: "UpdateMangaListEntryProgress",
 	DeleteEntryDocument:                  "DeleteEntry",
 	UpdateMediaListEntryRepeatDocument:   "UpdateMediaListEntryRepeat",
 	MangaCollectionDocument:              "MangaCollection",
@@ -491,6 +491,16 @@ func (ac *MockAnilistClientImpl) UpdateMediaListEntryProgress(ctx context.Contex
 	return &UpdateMediaListEntryProgress{}, nil
 }
 
+func (ac *MockAnilistClientImpl) UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*UpdateMangaListEntryProgress, error) {
+	ac.logger.Debug().Int("mediaId", *mediaID).Msg("anilist: Updating manga list entry progress")
+	return &UpdateMangaListEntryProgress{}, nil
+}
+
+func (ac *MockAnilistClientImpl) AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*AnimeListLatestUpdate, error) {
+	ac.logger.Debug().Msg("anilist: Fetching latest anime list update")
+	return &AnimeListLatestUpdate{}, nil
+}
+
 func (ac *MockAnilistClientImpl) UpdateMediaListEntryRepeat(ctx context.Context, mediaID *int, repeat *int, interceptors ...clientv2.RequestInterceptor) (*UpdateMediaListEntryRepeat, error) {
 	ac.logger.Debug().Int("mediaId", *mediaID).Msg("anilist: Updating media list entry repeat")
 	return &UpdateMediaListEntryRepeat{}, nil
@@ -575,3 +585,13 @@ func (ac *MockAnilistClientImpl) AnimeAiringScheduleRaw(ctx context.Context, ids
 	ac.logger.Debug().Msg("anilist: Fetching schedule")
 	return ac.realAnilistClient.AnimeAiringScheduleRaw(ctx, ids, interceptors...)
 }
+
+func (ac *MockAnilistClientImpl) GetStreamingEpisodes(ctx context.Context, id int) ([]*StreamingEpisode, error) {
+	ac.logger.Debug().Int("mediaId", id).Msg("anilist: Fetching streaming episodes")
+	return ac.realAnilistClient.GetStreamingEpisodes(ctx, id)
+}
+
+func (ac *MockAnilistClientImpl) GetEntryProgress(ctx context.Context, mediaID int) (*EntryProgress, error) {
+	ac.logger.Debug().Int("mediaId", mediaID).Msg("anilist: Fetching entry progress")
+	return ac.realAnilistClient.GetEntryProgress(ctx, mediaID)
+}
@@ -0,0 +1,59 @@
+package anilist
+
+import (
+	"context"
+)
+
+// EntryProgress is the progress of the viewer's list entry of a media.
+type EntryProgress struct {
+	ID       int              `json:"id"`
+	Progress int              `json:"progress"`
+	Status   *MediaListStatus `json:"status,omitempty"`
+}
+
+const EntryProgressByIDDocument = `query EntryProgressById ($id: Int) {
+	Media(id: $id) {
+		id
+		mediaListEntry {
+			id
+			progress
+			status
+		}
+	}
+}
+`
+
+type EntryProgressByID struct {
+	Media *struct {
+		ID             int `json:"id"`
+		MediaListEntry *struct {
+			ID       int              `json:"id"`
+			Progress *int             `json:"progress,omitempty"`
+			Status   *MediaListStatus `json:"status,omitempty"`
+		} `json:"mediaListEntry,omitempty"`
+	} `json:"Media,omitempty"`
+}
+
+// GetEntryProgress returns the progress of the viewer's list entry of an anime or manga, nil if the media is not in their lists.
+// It is used to check the progress before sending a progress update, e.g. when it was changed from another device.
+func (ac *AnilistClientImpl) GetEntryProgress(ctx context.Context, mediaID int) (*EntryProgress, error) {
+	ac.logger.Debug().Int("mediaId", mediaID).Msg("anilist: Fetching entry progress")
+
+	var res EntryProgressByID
+	if err := ac.Client.Client.Post(ctx, "EntryProgressById", EntryProgressByIDDocument, &res, map[string]any{"id": mediaID}); err != nil {
+		return nil, err
+	}
+
+	if res.Media == nil || res.Media.MediaListEntry == nil {
+		return nil, nil
+	}
+
+	ret := &EntryProgress{
+		ID:     res.Media.MediaListEntry.ID,
+		Status: res.Media.MediaListEntry.Status,
+	}
+	if res.Media.MediaListEntry.Progress != nil {
+		ret.Progress = *res.Media.MediaListEntry.Progress
+	}
+	return ret, nil
+}
@@ -121,3 +121,10 @@ func (m *MangaListEntry) GetRepeatSafe() int {
 	}
 	return *m.Repeat
 }
+
+func (m *MangaListEntry) GetProgressVolumesSafe() int {
+	if m.ProgressVolumes == nil {
+		return 0
+	}
+	return *m.ProgressVolumes
+}
@@ -0,0 +1,36 @@
+package anilist
+
+import (
+	"errors"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+)
+
+const saveMediaListEntryNotesDocument = `mutation SaveMediaListEntryNotes($mediaId: Int, $notes: String) {
+  SaveMediaListEntry(mediaId: $mediaId, notes: $notes) {
+    id
+  }
+}`
+
+// UpdateMediaListEntryNotes replaces the notes of the user's list entry of a media.
+// The entry is created if the media is not in the user's list.
+func UpdateMediaListEntryNotes(client AnilistClient, mediaId int, notes string, logger *zerolog.Logger, token string) error {
+	if token == "" {
+		return errors.New("anilist: not authenticated")
+	}
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"query": saveMediaListEntryNotesDocument,
+		"variables": map[string]interface{}{
+			"mediaId": mediaId,
+			"notes":   notes,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = client.CustomQuery(requestBody, logger, token)
+	return err
+}
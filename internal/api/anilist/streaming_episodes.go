@@ -0,0 +1,64 @@
+package anilist
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+)
+
+// StreamingEpisode is a link to an episode on a legal streaming site.
+type StreamingEpisode struct {
+	Title     string `json:"title"`
+	Thumbnail string `json:"thumbnail"`
+	URL       string `json:"url"`
+	Site      string `json:"site"`
+}
+
+const StreamingEpisodesByIDDocument = `query StreamingEpisodesById ($id: Int) {
+	Media(id: $id, type: ANIME) {
+		id
+		streamingEpisodes {
+			title
+			thumbnail
+			url
+			site
+		}
+	}
+}
+`
+
+type StreamingEpisodesByID struct {
+	Media *struct {
+		ID                int                      `json:"id"`
+		StreamingEpisodes []*MediaStreamingEpisode `json:"streamingEpisodes,omitempty"`
+	} `json:"Media,omitempty"`
+}
+
+// GetStreamingEpisodes returns the official streaming links of an anime.
+func (ac *AnilistClientImpl) GetStreamingEpisodes(ctx context.Context, id int) ([]*StreamingEpisode, error) {
+	ac.logger.Debug().Int("mediaId", id).Msg("anilist: Fetching streaming episodes")
+
+	var res StreamingEpisodesByID
+	if err := ac.Client.Client.Post(ctx, "StreamingEpisodesById", StreamingEpisodesByIDDocument, &res, map[string]any{"id": id}); err != nil {
+		return nil, err
+	}
+
+	ret := make([]*StreamingEpisode, 0)
+	if res.Media == nil {
+		return ret, nil
+	}
+
+	for _, ep := range res.Media.StreamingEpisodes {
+		if ep == nil || ep.URL == nil || *ep.URL == "" {
+			continue
+		}
+		ret = append(ret, &StreamingEpisode{
+			Title:     lo.FromPtr(ep.Title),
+			Thumbnail: lo.FromPtr(ep.Thumbnail),
+			URL:       *ep.URL,
+			Site:      lo.FromPtr(ep.Site),
+		})
+	}
+
+	return ret, nil
+}
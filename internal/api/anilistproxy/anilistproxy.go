@@ -0,0 +1,210 @@
+package anilistproxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"seanime/internal/util/limiter"
+	"seanime/internal/util/result"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const (
+	// cacheTTL is how long the result of an allowed query is served from the cache
+	cacheTTL = 5 * time.Minute
+	// cacheCapacity is the number of results kept in the cache
+	cacheCapacity = 500
+)
+
+const (
+	CacheStatusHit    CacheStatus = "HIT"
+	CacheStatusMiss   CacheStatus = "MISS"
+	CacheStatusBypass CacheStatus = "BYPASS"
+)
+
+var (
+	ErrInvalidQuery        = errors.New("anilist proxy: invalid query")
+	ErrOperationNotAllowed = errors.New("anilist proxy: only media, airing schedule, character and staff queries are allowed")
+)
+
+var (
+	// rootFields are the fields of the queries that can be executed through the proxy
+	rootFields = map[string]bool{
+		"Media":          true,
+		"AiringSchedule": true,
+		"Character":      true,
+		"Staff":          true,
+		"Page":           true,
+	}
+	// pageFields are the fields of Page that can be requested
+	pageFields = map[string]bool{
+		"media":           true,
+		"airingSchedules": true,
+		"characters":      true,
+		"staff":           true,
+		"pageInfo":        true,
+	}
+)
+
+type (
+	// Proxy executes the AniList requests of the extensions and of the clients of the server.
+	// Every request waits for the same rate limiter so that they do not add up to rate limit errors.
+	// The results of the allowed queries are cached for a few minutes, per token.
+	Proxy struct {
+		logger    *zerolog.Logger
+		limiter   *limiter.Limiter
+		cache     *result.BoundedCache[string, interface{}]
+		queryFunc QueryFunc
+	}
+
+	// QueryFunc sends the GraphQL request body to AniList with the token and returns the data of the response.
+	QueryFunc func(body []byte, token string) (interface{}, error)
+
+	NewProxyOptions struct {
+		Logger    *zerolog.Logger
+		QueryFunc QueryFunc
+	}
+
+	// Request is a GraphQL request.
+	Request struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+		OperationName string                 `json:"operationName,omitempty"`
+	}
+
+	// CacheStatus tells whether the result of a request was served from the cache.
+	CacheStatus string
+)
+
+func New(opts *NewProxyOptions) *Proxy {
+	return &Proxy{
+		logger:    opts.Logger,
+		limiter:   limiter.NewAnilistLimiter(),
+		cache:     result.NewBoundedCache[string, interface{}](cacheCapacity),
+		queryFunc: opts.QueryFunc,
+	}
+}
+
+// Query executes a query with the token of the caller.
+// Mutations and queries of other fields than the allowed ones are rejected.
+func (p *Proxy) Query(req *Request, token string) (interface{}, CacheStatus, error) {
+	if err := CheckQuery(req.Query); err != nil {
+		return nil, CacheStatusBypass, err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, CacheStatusBypass, err
+	}
+
+	// The results of the queries can depend on the user, e.g. their list entries
+	key := getCacheKey(body, token)
+	if data, found := p.cache.Get(key); found {
+		return data, CacheStatusHit, nil
+	}
+
+	data, err := p.send(body, token)
+	if err != nil {
+		return nil, CacheStatusMiss, err
+	}
+	p.cache.SetT(key, data, cacheTTL)
+
+	return data, CacheStatusMiss, nil
+}
+
+// Forward executes any request, including mutations, with the rate limiter of the proxy.
+// The allowed queries are served from the cache, the other requests are always sent.
+// It is used by the extensions, which can send their own mutations.
+func (p *Proxy) Forward(body map[string]interface{}, token string) (interface{}, CacheStatus, error) {
+	if query, ok := body["query"].(string); ok && CheckQuery(query) == nil {
+		req := &Request{Query: query}
+		req.Variables, _ = body["variables"].(map[string]interface{})
+		req.OperationName, _ = body["operationName"].(string)
+		return p.Query(req, token)
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, CacheStatusBypass, err
+	}
+	data, err := p.send(bodyBytes, token)
+	return data, CacheStatusBypass, err
+}
+
+func (p *Proxy) send(body []byte, token string) (interface{}, error) {
+	p.limiter.Wait()
+	return p.queryFunc(body, token)
+}
+
+// CheckQuery returns an error if the document is not made of queries of the allowed fields.
+// Fragments are followed, introspection is not allowed.
+func CheckQuery(query string) error {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, err)
+	}
+	if len(doc.Operations) == 0 {
+		return ErrInvalidQuery
+	}
+
+	for _, op := range doc.Operations {
+		if op.Operation != ast.Query {
+			return ErrOperationNotAllowed
+		}
+		if err := checkSelections(op.SelectionSet, rootFields, doc, make(map[string]bool)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSelections checks that the selected fields are allowed, the fields of Page are checked against pageFields.
+func checkSelections(set ast.SelectionSet, allowed map[string]bool, doc *ast.QueryDocument, visited map[string]bool) error {
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Name == "__typename" {
+				continue
+			}
+			if !allowed[s.Name] {
+				return fmt.Errorf("%w, %q was requested", ErrOperationNotAllowed, s.Name)
+			}
+			if s.Name == "Page" {
+				if err := checkSelections(s.SelectionSet, pageFields, doc, visited); err != nil {
+					return err
+				}
+			}
+		case *ast.InlineFragment:
+			if err := checkSelections(s.SelectionSet, allowed, doc, visited); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			// The same fragment can be spread at the root and in Page
+			key := fmt.Sprintf("%s|%p", s.Name, allowed)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			fragment := doc.Fragments.ForName(s.Name)
+			if fragment == nil {
+				return fmt.Errorf("%w: unknown fragment %q", ErrInvalidQuery, s.Name)
+			}
+			if err := checkSelections(fragment.SelectionSet, allowed, doc, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getCacheKey(body []byte, token string) string {
+	tokenHash := sha256.Sum256([]byte(token))
+	bodyHash := sha256.Sum256(body)
+	return hex.EncodeToString(tokenHash[:]) + hex.EncodeToString(bodyHash[:])
+}
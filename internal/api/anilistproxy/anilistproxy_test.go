@@ -0,0 +1,179 @@
+package anilistproxy
+
+import (
+	"errors"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   error
+	}{
+		{
+			name:  "media lookup",
+			query: `query ($id: Int) { Media(id: $id) { id title { romaji } } }`,
+		},
+		{
+			name:  "airing schedule page",
+			query: `query { Page(perPage: 50) { pageInfo { hasNextPage } airingSchedules(notYetAired: true) { episode airingAt media { id } } } }`,
+		},
+		{
+			name:  "characters and staff with aliases",
+			query: `{ c: Character(id: 1) { name { full } } s: Staff(id: 2) { name { full } } __typename }`,
+		},
+		{
+			name: "fragments",
+			query: `
+				query { Media(id: 1) { ...media } Page { media { ...media } } }
+				fragment media on Media { id ... on Media { format } }`,
+		},
+		{
+			name:  "mutation",
+			query: `mutation { SaveMediaListEntry(mediaId: 1, progress: 2) { id } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "viewer",
+			query: `query { Viewer { id name } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "media list collection",
+			query: `query { MediaListCollection(userName: "user", type: ANIME) { lists { name } } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "users in page",
+			query: `query { Page { users { id } } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "field hidden in a fragment",
+			query: `query { ...root } fragment root on Query { Viewer { id } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "mutation after a query",
+			query: `query { Media(id: 1) { id } } mutation { DeleteMediaListEntry(id: 1) { deleted } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "introspection",
+			query: `query { __schema { types { name } } }`,
+			err:   ErrOperationNotAllowed,
+		},
+		{
+			name:  "syntax error",
+			query: `query { Media(id: 1) { id }`,
+			err:   ErrInvalidQuery,
+		},
+		{
+			name:  "unknown fragment",
+			query: `query { ...root }`,
+			err:   ErrInvalidQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckQuery(tt.query)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestProxy_Cache(t *testing.T) {
+	calls := 0
+	p := New(&NewProxyOptions{
+		Logger: util.NewLogger(),
+		QueryFunc: func(body []byte, token string) (interface{}, error) {
+			calls++
+			if token == "invalid" {
+				return nil, errors.New("Invalid token")
+			}
+			return map[string]interface{}{"token": token}, nil
+		},
+	})
+
+	req := &Request{
+		Query:     `query ($id: Int) { Media(id: $id) { id } }`,
+		Variables: map[string]interface{}{"id": 1},
+	}
+
+	data, status, err := p.Query(req, "token1")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, map[string]interface{}{"token": "token1"}, data)
+
+	data, status, err = p.Query(req, "token1")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Equal(t, map[string]interface{}{"token": "token1"}, data)
+	assert.Equal(t, 1, calls)
+
+	// The results are not shared between accounts
+	data, status, err = p.Query(req, "token2")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, map[string]interface{}{"token": "token2"}, data)
+
+	// Other variables are another query
+	_, status, err = p.Query(&Request{Query: req.Query, Variables: map[string]interface{}{"id": 2}}, "token1")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, 3, calls)
+
+	// Errors are not cached
+	_, _, err = p.Query(req, "invalid")
+	require.Error(t, err)
+	_, _, err = p.Query(req, "invalid")
+	require.Error(t, err)
+	assert.Equal(t, 5, calls)
+
+	// Rejected queries are not sent
+	_, status, err = p.Query(&Request{Query: `mutation { DeleteMediaListEntry(id: 1) { deleted } }`}, "token1")
+	assert.ErrorIs(t, err, ErrOperationNotAllowed)
+	assert.Equal(t, CacheStatusBypass, status)
+	assert.Equal(t, 5, calls)
+}
+
+func TestProxy_Forward(t *testing.T) {
+	calls := 0
+	p := New(&NewProxyOptions{
+		Logger: util.NewLogger(),
+		QueryFunc: func(body []byte, token string) (interface{}, error) {
+			calls++
+			return string(body), nil
+		},
+	})
+
+	mutation := map[string]interface{}{"query": `mutation { DeleteMediaListEntry(id: 1) { deleted } }`}
+	for i := 0; i < 2; i++ {
+		_, status, err := p.Forward(mutation, "token")
+		require.NoError(t, err)
+		assert.Equal(t, CacheStatusBypass, status)
+	}
+	assert.Equal(t, 2, calls)
+
+	query := map[string]interface{}{
+		"query":     `query ($id: Int) { Media(id: $id) { id } }`,
+		"variables": map[string]interface{}{"id": 1},
+	}
+	_, status, err := p.Forward(query, "token")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusMiss, status)
+	_, status, err = p.Forward(query, "token")
+	require.NoError(t, err)
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,301 @@
+package animethemes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"seanime/internal/constants"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+)
+
+const (
+	ThemeTypeOpening = "OP"
+	ThemeTypeEnding  = "ED"
+
+	SiteAnilist     = "AniList"
+	SiteMyAnimeList = "MyAnimeList"
+)
+
+var ErrNotFound = errors.New("animethemes: Anime not found")
+
+type (
+	// Theme is an opening or ending of an anime.
+	Theme struct {
+		// Type is either "OP" or "ED"
+		Type     string `json:"type"`
+		Sequence int    `json:"sequence"`
+		// Slug is the type and the sequence, e.g. "OP1"
+		Slug    string        `json:"slug"`
+		Song    *Song         `json:"song,omitempty"`
+		Entries []*ThemeEntry `json:"entries"`
+	}
+
+	Song struct {
+		Title   string    `json:"title"`
+		Artists []*Artist `json:"artists"`
+	}
+
+	Artist struct {
+		Name string `json:"name"`
+		// As is the character the artist performs as, if any
+		As string `json:"as,omitempty"`
+	}
+
+	// ThemeEntry is a version of a theme, e.g. the theme can change after some episodes.
+	ThemeEntry struct {
+		Version int `json:"version"`
+		// Episodes is the raw episode range, e.g. "1-12, 14"
+		Episodes      string          `json:"episodes"`
+		EpisodeRanges []*EpisodeRange `json:"episodeRanges"`
+		Nsfw          bool            `json:"nsfw"`
+		Spoiler       bool            `json:"spoiler"`
+		Videos        []*Video        `json:"videos"`
+	}
+
+	EpisodeRange struct {
+		Start int `json:"start"`
+		End   int `json:"end"`
+	}
+
+	Video struct {
+		Basename   string `json:"basename"`
+		Resolution int    `json:"resolution"`
+		// VideoUrl is the URL of the WebM video
+		VideoUrl string `json:"videoUrl"`
+		// AudioUrl is the URL of the audio-only OGG file, empty if not available
+		AudioUrl string `json:"audioUrl,omitempty"`
+		// ProxyVideoUrl and ProxyAudioUrl are set when the themes are returned to the client
+		ProxyVideoUrl string `json:"proxyVideoUrl,omitempty"`
+		ProxyAudioUrl string `json:"proxyAudioUrl,omitempty"`
+	}
+)
+
+// Client fetches the themes from the AnimeThemes API.
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	logger     *zerolog.Logger
+}
+
+func NewClient(logger *zerolog.Logger) *Client {
+	return &Client{
+		baseUrl: "https://api.animethemes.moe",
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// IsMediaUrl returns true if the URL points to a video or audio file hosted by AnimeThemes.
+func IsMediaUrl(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	return parsed.Host == "v.animethemes.moe" || parsed.Host == "a.animethemes.moe"
+}
+
+// GetThemes returns the themes of the anime with the given AniList ID.
+// If AniList is not mapped, the MyAnimeList ID is used when it is not 0.
+// It returns ErrNotFound if the anime does not exist on AnimeThemes.
+func (c *Client) GetThemes(anilistId int, malId int) ([]*Theme, error) {
+	ret, err := c.getThemes(SiteAnilist, anilistId)
+	if errors.Is(err, ErrNotFound) && malId != 0 {
+		ret, err = c.getThemes(SiteMyAnimeList, malId)
+	}
+	return ret, err
+}
+
+func (c *Client) getThemes(site string, externalId int) ([]*Theme, error) {
+	query := url.Values{}
+	query.Set("filter[has]", "resources")
+	query.Set("filter[site]", site)
+	query.Set("filter[external_id]", strconv.Itoa(externalId))
+	query.Set("include", "animethemes.animethemeentries.videos.audio,animethemes.song.artists")
+
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/anime?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Seanime/"+constants.Version)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, errors.New("animethemes: Rate limited")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("animethemes: Unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var res animeResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Anime) == 0 {
+		return nil, ErrNotFound
+	}
+
+	c.logger.Trace().Str("site", site).Int("id", externalId).Int("count", len(res.Anime[0].AnimeThemes)).Msg("animethemes: Fetched themes")
+
+	return res.Anime[0].toThemes(), nil
+}
+
+// ParseEpisodeRanges parses an episode range such as "1-12, 14".
+// Parts that cannot be parsed are ignored.
+func ParseEpisodeRanges(episodes string) []*EpisodeRange {
+	ret := make([]*EpisodeRange, 0)
+	for _, part := range strings.Split(episodes, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(endStr))
+			if err != nil || end < start {
+				continue
+			}
+		}
+		ret = append(ret, &EpisodeRange{Start: start, End: end})
+	}
+	return ret
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+type (
+	animeResponse struct {
+		Anime []*animeItem `json:"anime"`
+	}
+
+	animeItem struct {
+		Name        string       `json:"name"`
+		AnimeThemes []*themeItem `json:"animethemes"`
+	}
+
+	themeItem struct {
+		Type         string       `json:"type"`
+		Sequence     *int         `json:"sequence"`
+		Slug         string       `json:"slug"`
+		Song         *songItem    `json:"song"`
+		ThemeEntries []*entryItem `json:"animethemeentries"`
+	}
+
+	songItem struct {
+		Title   string        `json:"title"`
+		Artists []*artistItem `json:"artists"`
+	}
+
+	artistItem struct {
+		Name       string `json:"name"`
+		ArtistSong *struct {
+			As *string `json:"as"`
+		} `json:"artistsong"`
+	}
+
+	entryItem struct {
+		Version  *int         `json:"version"`
+		Episodes *string      `json:"episodes"`
+		Nsfw     bool         `json:"nsfw"`
+		Spoiler  bool         `json:"spoiler"`
+		Videos   []*videoItem `json:"videos"`
+	}
+
+	videoItem struct {
+		Basename   string `json:"basename"`
+		Link       string `json:"link"`
+		Resolution *int   `json:"resolution"`
+		Audio      *struct {
+			Link string `json:"link"`
+		} `json:"audio"`
+	}
+)
+
+func (a *animeItem) toThemes() []*Theme {
+	ret := make([]*Theme, 0, len(a.AnimeThemes))
+	for _, t := range a.AnimeThemes {
+		theme := &Theme{
+			Type:     t.Type,
+			Sequence: 1,
+			Slug:     t.Slug,
+			Entries:  make([]*ThemeEntry, 0, len(t.ThemeEntries)),
+		}
+		if t.Sequence != nil {
+			theme.Sequence = *t.Sequence
+		}
+
+		if t.Song != nil {
+			theme.Song = &Song{
+				Title:   t.Song.Title,
+				Artists: make([]*Artist, 0, len(t.Song.Artists)),
+			}
+			for _, artist := range t.Song.Artists {
+				a := &Artist{Name: artist.Name}
+				if artist.ArtistSong != nil && artist.ArtistSong.As != nil {
+					a.As = *artist.ArtistSong.As
+				}
+				theme.Song.Artists = append(theme.Song.Artists, a)
+			}
+		}
+
+		for _, e := range t.ThemeEntries {
+			entry := &ThemeEntry{
+				Version: 1,
+				Nsfw:    e.Nsfw,
+				Spoiler: e.Spoiler,
+				Videos:  make([]*Video, 0, len(e.Videos)),
+			}
+			if e.Version != nil {
+				entry.Version = *e.Version
+			}
+			if e.Episodes != nil {
+				entry.Episodes = *e.Episodes
+			}
+			entry.EpisodeRanges = ParseEpisodeRanges(entry.Episodes)
+
+			for _, v := range e.Videos {
+				video := &Video{
+					Basename: v.Basename,
+					VideoUrl: v.Link,
+				}
+				if v.Resolution != nil {
+					video.Resolution = *v.Resolution
+				}
+				if v.Audio != nil {
+					video.AudioUrl = v.Audio.Link
+				}
+				entry.Videos = append(entry.Videos, video)
+			}
+
+			theme.Entries = append(theme.Entries, entry)
+		}
+
+		ret = append(ret, theme)
+	}
+	return ret
+}
@@ -0,0 +1,78 @@
+package animethemes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEpisodeRanges(t *testing.T) {
+	tests := []struct {
+		episodes string
+		expected []*EpisodeRange
+	}{
+		{episodes: "1-12", expected: []*EpisodeRange{{Start: 1, End: 12}}},
+		{episodes: "1-12, 14", expected: []*EpisodeRange{{Start: 1, End: 12}, {Start: 14, End: 14}}},
+		{episodes: "", expected: []*EpisodeRange{}},
+		{episodes: "12-1, abc, 3", expected: []*EpisodeRange{{Start: 3, End: 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.episodes, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseEpisodeRanges(tt.episodes))
+		})
+	}
+}
+
+func TestClient_GetThemes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter[site]") == SiteAnilist {
+			_, _ = w.Write([]byte(`{"anime":[]}`))
+			return
+		}
+		assert.Equal(t, "5114", r.URL.Query().Get("filter[external_id]"))
+		_, _ = w.Write([]byte(`{"anime":[{"name":"Fullmetal Alchemist: Brotherhood","animethemes":[{
+			"type":"OP","sequence":1,"slug":"OP1",
+			"song":{"title":"Again","artists":[{"name":"YUI","artistsong":{"as":null}}]},
+			"animethemeentries":[{"version":null,"episodes":"1-14","nsfw":false,"spoiler":false,"videos":[
+				{"basename":"FMAB-OP1.webm","link":"https://v.animethemes.moe/FMAB-OP1.webm","resolution":1080,"audio":{"link":"https://a.animethemes.moe/FMAB-OP1.ogg"}}
+			]}]
+		}]}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(util.NewLogger())
+	client.baseUrl = srv.URL
+
+	themes, err := client.GetThemes(5114, 5114)
+	require.NoError(t, err)
+	require.Len(t, themes, 1)
+
+	theme := themes[0]
+	assert.Equal(t, ThemeTypeOpening, theme.Type)
+	assert.Equal(t, "OP1", theme.Slug)
+	require.NotNil(t, theme.Song)
+	assert.Equal(t, "Again", theme.Song.Title)
+	assert.Equal(t, "YUI", theme.Song.Artists[0].Name)
+	require.Len(t, theme.Entries, 1)
+	assert.Equal(t, 1, theme.Entries[0].Version)
+	assert.Equal(t, []*EpisodeRange{{Start: 1, End: 14}}, theme.Entries[0].EpisodeRanges)
+	require.Len(t, theme.Entries[0].Videos, 1)
+	assert.Equal(t, 1080, theme.Entries[0].Videos[0].Resolution)
+	assert.Equal(t, "https://a.animethemes.moe/FMAB-OP1.ogg", theme.Entries[0].Videos[0].AudioUrl)
+
+	_, err = client.GetThemes(5114, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestIsMediaUrl(t *testing.T) {
+	assert.True(t, IsMediaUrl("https://v.animethemes.moe/FMAB-OP1.webm"))
+	assert.True(t, IsMediaUrl("https://a.animethemes.moe/FMAB-OP1.ogg"))
+	assert.False(t, IsMediaUrl("http://v.animethemes.moe/FMAB-OP1.webm"))
+	assert.False(t, IsMediaUrl("https://example.com/FMAB-OP1.webm"))
+	assert.False(t, IsMediaUrl("https://v.animethemes.moe.example.com/a.webm"))
+}
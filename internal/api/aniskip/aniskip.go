@@ -0,0 +1,141 @@
+package aniskip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"seanime/internal/constants"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+)
+
+const (
+	SkipTypeOpening = "op"
+	SkipTypeEnding  = "ed"
+)
+
+var ErrNotFound = errors.New("aniskip: Skip times not found")
+
+type (
+	// SkipTime is the interval of an opening or ending, in seconds.
+	SkipTime struct {
+		// SkipType is either "op" or "ed"
+		SkipType  string  `json:"skipType"`
+		StartTime float64 `json:"startTime"`
+		EndTime   float64 `json:"endTime"`
+		// EpisodeLength is the length of the episode the interval was submitted for
+		EpisodeLength float64 `json:"episodeLength"`
+	}
+)
+
+// Client fetches the opening and ending intervals from the AniSkip API.
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	logger     *zerolog.Logger
+}
+
+func NewClient(logger *zerolog.Logger) *Client {
+	return &Client{
+		baseUrl: "https://api.aniskip.com",
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// GetSkipTimes returns the opening and ending intervals of an episode.
+// episodeLength is in seconds, it is used by AniSkip to pick the intervals submitted for the same release, 0 if unknown.
+// It returns ErrNotFound if there are no intervals for the episode.
+func (c *Client) GetSkipTimes(malId int, episode int, episodeLength float64) ([]*SkipTime, error) {
+	query := url.Values{}
+	query.Add("types", SkipTypeOpening)
+	query.Add("types", SkipTypeEnding)
+	query.Set("episodeLength", strconv.FormatFloat(episodeLength, 'f', 0, 64))
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/skip-times/%d/%d?%s", c.baseUrl, malId, episode, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Seanime/"+constants.Version)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// AniSkip responds with 404 when there are no intervals
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, errors.New("aniskip: Rate limited")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aniskip: Unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var res skipTimesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	if !res.Found || len(res.Results) == 0 {
+		return nil, ErrNotFound
+	}
+
+	ret := make([]*SkipTime, 0, len(res.Results))
+	for _, r := range res.Results {
+		ret = append(ret, &SkipTime{
+			SkipType:      r.SkipType,
+			StartTime:     r.Interval.StartTime,
+			EndTime:       r.Interval.EndTime,
+			EpisodeLength: r.EpisodeLength,
+		})
+	}
+
+	c.logger.Trace().Int("malId", malId).Int("episode", episode).Int("count", len(ret)).Msg("aniskip: Fetched skip times")
+
+	return ret, nil
+}
+
+// GetOpening returns the opening interval from the skip times, if any.
+func GetOpening(skipTimes []*SkipTime) (*SkipTime, bool) {
+	for _, st := range skipTimes {
+		if st.SkipType == SkipTypeOpening {
+			return st, true
+		}
+	}
+	return nil, false
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+type (
+	skipTimesResponse struct {
+		Found   bool          `json:"found"`
+		Results []*skipResult `json:"results"`
+	}
+
+	skipResult struct {
+		Interval struct {
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+		} `json:"interval"`
+		SkipType      string  `json:"skipType"`
+		EpisodeLength float64 `json:"episodeLength"`
+	}
+)
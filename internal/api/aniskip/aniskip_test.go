@@ -0,0 +1,44 @@
+package aniskip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetSkipTimes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/skip-times/5114/2" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"found":false,"results":[],"message":"No results found","statusCode":404}`))
+			return
+		}
+		assert.Equal(t, "/v2/skip-times/5114/1", r.URL.Path)
+		assert.Equal(t, []string{"op", "ed"}, r.URL.Query()["types"])
+		assert.Equal(t, "1420", r.URL.Query().Get("episodeLength"))
+		_, _ = w.Write([]byte(`{"found":true,"results":[
+			{"interval":{"startTime":1300.5,"endTime":1390},"skipType":"ed","skipId":"a","episodeLength":1420.1},
+			{"interval":{"startTime":60,"endTime":150.2},"skipType":"op","skipId":"b","episodeLength":1420.1}
+		],"message":"Successfully found skip times","statusCode":200}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(util.NewLogger())
+	c.baseUrl = srv.URL
+
+	ret, err := c.GetSkipTimes(5114, 1, 1420)
+	require.NoError(t, err)
+	require.Len(t, ret, 2)
+
+	op, found := GetOpening(ret)
+	require.True(t, found)
+	assert.Equal(t, 60.0, op.StartTime)
+	assert.Equal(t, 150.2, op.EndTime)
+
+	_, err = c.GetSkipTimes(5114, 2, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
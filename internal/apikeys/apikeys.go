@@ -0,0 +1,148 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// HeaderApiKey is the header of the requests authenticated with an API key
+	HeaderApiKey = "X-Seanime-Api-Key"
+
+	// keyPrefix is prepended to the generated keys so that they can be recognized
+	keyPrefix = "sea_"
+	// displayedPrefixLength is the number of characters of the key stored to recognize it
+	displayedPrefixLength = 12
+	// lastUsedInterval is the minimum time between two updates of the last-used timestamp of a key
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrInvalidKey    = errors.New("api keys: invalid API key")
+	ErrInvalidName   = errors.New("api keys: name is required")
+	ErrInvalidScopes = errors.New("api keys: at least one valid scope is required")
+)
+
+type (
+	// Manager creates the API keys and authorizes their requests.
+	Manager struct {
+		logger   *zerolog.Logger
+		database *db.Database
+		// now is replaced in tests
+		now func() time.Time
+	}
+
+	NewManagerOptions struct {
+		Logger   *zerolog.Logger
+		Database *db.Database
+	}
+
+	// MissingScopeError is returned when an API key does not have the scope required by a route.
+	MissingScopeError struct {
+		Scope Scope
+	}
+)
+
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("api keys: missing scope %q", e.Scope)
+}
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		logger:   opts.Logger,
+		database: opts.Database,
+		now:      time.Now,
+	}
+}
+
+// Create generates a key with the scopes. The key is only returned here, only its hash is stored.
+func (m *Manager) Create(name string, scopes []Scope) (string, *models.ApiKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, ErrInvalidName
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScopes
+	}
+	scopeStrs := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !s.IsValid() {
+			return "", nil, fmt.Errorf("api keys: unknown scope %q", s)
+		}
+		scopeStrs = append(scopeStrs, string(s))
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := &models.ApiKey{
+		Name:    name,
+		KeyHash: hashKey(key),
+		Prefix:  key[:displayedPrefixLength],
+		Scopes:  scopeStrs,
+	}
+	if err := m.database.InsertApiKey(apiKey); err != nil {
+		return "", nil, err
+	}
+
+	m.logger.Info().Uint("id", apiKey.ID).Strs("scopes", scopeStrs).Msg("api keys: Created API key")
+	return key, apiKey, nil
+}
+
+func (m *Manager) GetAll() ([]*models.ApiKey, error) {
+	return m.database.GetApiKeys()
+}
+
+func (m *Manager) Delete(id uint) error {
+	return m.database.DeleteApiKey(id)
+}
+
+// Authorize returns ErrInvalidKey if the key does not exist
+// and a MissingScopeError if it cannot send the request.
+// The last-used timestamp of the key is updated when it is valid.
+func (m *Manager) Authorize(key string, method string, path string) (*models.ApiKey, error) {
+	apiKey, err := m.database.GetApiKeyByHash(hashKey(key))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	now := m.now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedInterval {
+		if err := m.database.UpdateApiKeyLastUsedAt(apiKey.ID, now); err != nil {
+			m.logger.Warn().Err(err).Uint("id", apiKey.ID).Msg("api keys: Failed to update last used timestamp")
+		} else {
+			apiKey.LastUsedAt = &now
+		}
+	}
+
+	scope := RequiredScope(method, path)
+	if !HasScope(apiKey.Scopes, scope) {
+		return apiKey, &MissingScopeError{Scope: scope}
+	}
+
+	return apiKey, nil
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,117 @@
+package apikeys
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected Scope
+	}{
+		{"GET", "/api/v1/library/collection", ScopeLibraryRead},
+		{"POST", "/api/v1/library/scan", ScopeLibraryWrite},
+		{"GET", "/api/v1/torrent-client/list", ScopeTorrentsRead},
+		{"POST", "/api/v1/torrent-client/download", ScopeTorrentsWrite},
+		{"POST", "/api/v1/torrent/search", ScopeTorrentsWrite},
+		{"POST", "/api/v1/download-torrent-file", ScopeTorrentsWrite},
+		{"POST", "/api/v1/playback-manager/play", ScopePlaybackControl},
+		{"GET", "/api/v1/torrentstream/batch-history", ScopePlaybackControl},
+		// The most specific group is used
+		{"PATCH", "/api/v1/torrentstream/settings", ScopeSettingsAdmin},
+		{"PATCH", "/api/v1/settings", ScopeSettingsAdmin},
+		{"GET", "/api/v1/settings", ScopeSettingsAdmin},
+		// The prefix must end at a path segment
+		{"GET", "/api/v1/settings-export", ScopeAdmin},
+		{"GET", "/api/v1/api-keys", ScopeAdmin},
+		// Routes that are not in a group
+		{"GET", "/api/v1/nakama/status", ScopeAdmin},
+		{"POST", "/api/v1/some-new-route", ScopeAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RequiredScope(tt.method, tt.path))
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, HasScope([]string{"torrents:write"}, ScopeTorrentsWrite))
+	assert.True(t, HasScope([]string{"torrents:write"}, ScopeTorrentsRead))
+	assert.False(t, HasScope([]string{"torrents:read"}, ScopeTorrentsWrite))
+	assert.False(t, HasScope([]string{"torrents:write"}, ScopeSettingsAdmin))
+	assert.True(t, HasScope([]string{"library:read", "admin"}, ScopeSettingsAdmin))
+	assert.False(t, HasScope(nil, ScopeLibraryRead))
+}
+
+func TestManager(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "apikeys_test", util.NewLogger())
+	require.NoError(t, err)
+
+	m := NewManager(&NewManagerOptions{
+		Logger:   util.NewLogger(),
+		Database: database,
+	})
+	now := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	_, _, err = m.Create("automation", nil)
+	assert.ErrorIs(t, err, ErrInvalidScopes)
+	_, _, err = m.Create(" ", []Scope{ScopeTorrentsWrite})
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, _, err = m.Create("automation", []Scope{"torrents:delete"})
+	assert.Error(t, err)
+
+	key, apiKey, err := m.Create("automation", []Scope{ScopeTorrentsWrite})
+	require.NoError(t, err)
+	assert.True(t, len(key) > displayedPrefixLength)
+	assert.Equal(t, key[:displayedPrefixLength], apiKey.Prefix)
+	assert.NotContains(t, apiKey.KeyHash, key)
+
+	// Allowed
+	ret, err := m.Authorize(key, "POST", "/api/v1/torrent-client/download")
+	require.NoError(t, err)
+	require.NotNil(t, ret.LastUsedAt)
+	assert.True(t, now.Equal(*ret.LastUsedAt))
+
+	// Missing scope
+	_, err = m.Authorize(key, "PATCH", "/api/v1/settings")
+	var missingScopeErr *MissingScopeError
+	require.ErrorAs(t, err, &missingScopeErr)
+	assert.Equal(t, ScopeSettingsAdmin, missingScopeErr.Scope)
+
+	// Unknown key
+	_, err = m.Authorize("sea_invalid", "GET", "/api/v1/torrent-client/list")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	// The last-used timestamp is not updated on every request
+	used := now
+	now = now.Add(30 * time.Second)
+	_, err = m.Authorize(key, "GET", "/api/v1/torrent-client/list")
+	require.NoError(t, err)
+	keys, err := m.GetAll()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, used.Equal(*keys[0].LastUsedAt))
+	assert.Equal(t, []string{"torrents:write"}, []string(keys[0].Scopes))
+
+	now = now.Add(time.Minute)
+	_, err = m.Authorize(key, "GET", "/api/v1/torrent-client/list")
+	require.NoError(t, err)
+	keys, err = m.GetAll()
+	require.NoError(t, err)
+	assert.True(t, now.Equal(*keys[0].LastUsedAt))
+
+	// Deleted keys are rejected
+	require.NoError(t, m.Delete(apiKey.ID))
+	_, err = m.Authorize(key, "GET", "/api/v1/torrent-client/list")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,129 @@
+package apikeys
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scope grants an API key access to a group of routes.
+type Scope string
+
+const (
+	// ScopeAdmin grants access to every route, including the routes that are not in a group
+	ScopeAdmin           Scope = "admin"
+	ScopeSettingsAdmin   Scope = "settings:admin"
+	ScopeLibraryRead     Scope = "library:read"
+	ScopeLibraryWrite    Scope = "library:write"
+	ScopeTorrentsRead    Scope = "torrents:read"
+	ScopeTorrentsWrite   Scope = "torrents:write"
+	ScopePlaybackControl Scope = "playback:control"
+)
+
+// AllScopes are the scopes that can be given to an API key.
+var AllScopes = []Scope{
+	ScopeAdmin,
+	ScopeSettingsAdmin,
+	ScopeLibraryRead,
+	ScopeLibraryWrite,
+	ScopeTorrentsRead,
+	ScopeTorrentsWrite,
+	ScopePlaybackControl,
+}
+
+// impliedScopes are the scopes granted by another scope.
+var impliedScopes = map[Scope][]Scope{
+	ScopeLibraryWrite:  {ScopeLibraryRead},
+	ScopeTorrentsWrite: {ScopeTorrentsRead},
+}
+
+func (s Scope) IsValid() bool {
+	return slices.Contains(AllScopes, s)
+}
+
+// routeGroup maps the routes under a path to the scopes required to read (GET, HEAD) and modify them.
+type routeGroup struct {
+	PathPrefix string
+	Read       Scope
+	Write      Scope
+}
+
+// routeGroups are the route groups accessible to API keys.
+// The most specific group of a path is used. Routes that are not in a group require ScopeAdmin,
+// new routes must be added here to be accessible to keys without it.
+var routeGroups = []routeGroup{
+	// library
+	{"/api/v1/status", ScopeLibraryRead, ScopeSettingsAdmin},
+	{"/api/v1/library", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/anilist", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/manga", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/discover", ScopeLibraryRead, ScopeLibraryRead},
+	{"/api/v1/metadata-provider", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/media-annotation", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/media-annotations", ScopeLibraryRead, ScopeLibraryWrite},
+	{"/api/v1/media-remaps", ScopeLibraryRead, ScopeLibraryWrite},
+	// torrents
+	{"/api/v1/torrent", ScopeTorrentsRead, ScopeTorrentsWrite},
+	{"/api/v1/torrent-client", ScopeTorrentsRead, ScopeTorrentsWrite},
+	{"/api/v1/auto-downloader", ScopeTorrentsRead, ScopeTorrentsWrite},
+	{"/api/v1/download-torrent-file", ScopeTorrentsWrite, ScopeTorrentsWrite},
+	{"/api/v1/debrid", ScopeTorrentsRead, ScopeTorrentsWrite},
+	{"/api/v1/torrentstream", ScopePlaybackControl, ScopePlaybackControl},
+	// playback
+	{"/api/v1/playback-manager", ScopePlaybackControl, ScopePlaybackControl},
+	{"/api/v1/media-player", ScopePlaybackControl, ScopePlaybackControl},
+	{"/api/v1/mediastream", ScopePlaybackControl, ScopePlaybackControl},
+	{"/api/v1/directstream", ScopePlaybackControl, ScopePlaybackControl},
+	{"/api/v1/onlinestream", ScopePlaybackControl, ScopePlaybackControl},
+	{"/api/v1/playlist", ScopePlaybackControl, ScopePlaybackControl},
+	// settings
+	{"/api/v1/settings", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/start", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/theme", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/torrentstream/settings", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/debrid/settings", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/mediastream/settings", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/maintenance", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/webhooks", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/logs", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/log", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/memory", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/filecache", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	{"/api/v1/extensions", ScopeSettingsAdmin, ScopeSettingsAdmin},
+	// API keys can only be managed by admin keys so that a key cannot create a key with more scopes
+	{"/api/v1/api-keys", ScopeAdmin, ScopeAdmin},
+}
+
+// RequiredScope returns the scope an API key needs to send the request.
+func RequiredScope(method string, path string) Scope {
+	var group *routeGroup
+	for i, g := range routeGroups {
+		if path != g.PathPrefix && !strings.HasPrefix(path, g.PathPrefix+"/") {
+			continue
+		}
+		if group == nil || len(g.PathPrefix) > len(group.PathPrefix) {
+			group = &routeGroups[i]
+		}
+	}
+	if group == nil {
+		return ScopeAdmin
+	}
+
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead:
+		return group.Read
+	default:
+		return group.Write
+	}
+}
+
+// HasScope returns true if the scopes grant the scope, directly or through ScopeAdmin or an implying scope.
+func HasScope(scopes []string, scope Scope) bool {
+	for _, s := range scopes {
+		granted := Scope(s)
+		if granted == scope || granted == ScopeAdmin || slices.Contains(impliedScopes[granted], scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -16,6 +16,8 @@ const (
 	MaxWatchHistoryItems   = 100
 	IgnoreRatioThreshold   = 0.9
 	WatchHistoryBucketName = "watch_history"
+	// IncognitoWatchHistoryBucketName is the bucket of the positions of incognito playbacks.
+	IncognitoWatchHistoryBucketName = "watch_history_incognito"
 )
 
 type (
@@ -159,6 +161,35 @@ func (m *Manager) DeleteWatchHistoryItem(mediaId int) (err error) {
 	return nil
 }
 
+// RemapWatchHistoryItem moves the watch history item of a media to another media ID, e.g. after AniList merged the two entries.
+// The item of the new media is kept if it exists.
+func (m *Manager) RemapWatchHistoryItem(from int, to int) (err error) {
+	defer util.HandlePanicInModuleWithError("continuity/RemapWatchHistoryItem", &err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, found := m.getWatchHistory(from)
+	if !found {
+		return nil
+	}
+
+	if _, exists := m.getWatchHistory(to); !exists {
+		i.MediaId = to
+		err = m.fileCacher.Set(*m.watchHistoryFileCacheBucket, strconv.Itoa(to), i)
+		if err != nil {
+			return fmt.Errorf("continuity: Failed to save watch history item: %w", err)
+		}
+	}
+
+	err = m.fileCacher.Delete(*m.watchHistoryFileCacheBucket, strconv.Itoa(from))
+	if err != nil {
+		return fmt.Errorf("continuity: Failed to delete watch history item: %w", err)
+	}
+
+	return nil
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetExternalPlayerEpisodeWatchHistoryItem is called before launching the external player to get the last known position.
@@ -213,7 +244,7 @@ func (m *Manager) GetExternalPlayerEpisodeWatchHistoryItem(path string, isStream
 			return
 		}
 
-		i, found := m.getWatchHistory(mediaId)
+		i, found := m.getExternalPlayerWatchHistory(mediaId)
 		if !found || i.EpisodeNumber != episode {
 			m.logger.Trace().
 				Interface("item", i).
@@ -275,7 +306,7 @@ func (m *Manager) GetExternalPlayerEpisodeWatchHistoryItem(path string, isStream
 			return
 		}
 
-		i, found := m.getWatchHistory(lf.MediaId)
+		i, found := m.getExternalPlayerWatchHistory(lf.MediaId)
 		if !found || i.EpisodeNumber != lf.GetEpisodeNumber() {
 			m.logger.Trace().
 				Interface("item", i).
@@ -315,8 +346,13 @@ func (m *Manager) UpdateExternalPlayerEpisodeWatchHistoryItem(currentTime, durat
 		return
 	}
 
+	bucket := m.watchHistoryFileCacheBucket
+	if m.incognito {
+		bucket = m.incognitoWatchHistoryFileCacheBucket
+	}
+
 	// Get the current history
-	i, found := m.getWatchHistory(opts.MediaId)
+	i, found := m.getExternalPlayerWatchHistory(opts.MediaId)
 	if !found {
 		added = true
 		i = &WatchHistoryItem{
@@ -338,10 +374,11 @@ func (m *Manager) UpdateExternalPlayerEpisodeWatchHistoryItem(currentTime, durat
 	}
 
 	// Save the i
-	_ = m.fileCacher.Set(*m.watchHistoryFileCacheBucket, strconv.Itoa(opts.MediaId), i)
+	_ = m.fileCacher.Set(*bucket, strconv.Itoa(opts.MediaId), i)
 
 	// If the item was added, check if we need to remove the oldest item
-	if added {
+	// Incognito items expire on their own
+	if added && !m.incognito {
 		_ = m.trimWatchHistoryItems()
 	}
 
@@ -388,6 +425,17 @@ func (m *Manager) getWatchHistory(mediaId int) (ret *WatchHistoryItem, exists bo
 	return
 }
 
+// getExternalPlayerWatchHistory returns the position of an incognito playback first when the current playback is incognito.
+func (m *Manager) getExternalPlayerWatchHistory(mediaId int) (ret *WatchHistoryItem, exists bool) {
+	if m.incognito {
+		exists, _ = m.fileCacher.Get(*m.incognitoWatchHistoryFileCacheBucket, strconv.Itoa(mediaId), &ret)
+		if exists && ret != nil {
+			return ret, true
+		}
+	}
+	return m.getWatchHistory(mediaId)
+}
+
 // removes the oldest WatchHistoryItem from the file cache.
 func (m *Manager) trimWatchHistoryItems() error {
 	defer util.HandlePanicInModuleThen("continuity/TrimWatchHistoryItems", func() {})
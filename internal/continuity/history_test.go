@@ -77,3 +77,42 @@ func TestHistoryItems(t *testing.T) {
 	require.Equal(t, 100., item.Duration)
 
 }
+
+func TestIncognitoHistoryItems(t *testing.T) {
+	logger := util.NewLogger()
+	tempDir := t.TempDir()
+
+	database, err := db.NewDatabase(tempDir, "continuity_test", logger)
+	require.NoError(t, err)
+
+	cacher, err := filecache.NewCacher(filepath.Join(tempDir, "cache"))
+	require.NoError(t, err)
+
+	manager := NewManager(&NewManagerOptions{
+		FileCacher: cacher,
+		Logger:     logger,
+		Database:   database,
+	})
+	manager.SetSettings(&Settings{WatchContinuityEnabled: true})
+
+	manager.SetExternalPlayerEpisodeDetails(&ExternalPlayerEpisodeDetails{EpisodeNumber: 3, MediaId: 1})
+	manager.UpdateExternalPlayerEpisodeWatchHistoryItem(20, 100)
+
+	// Incognito positions are stored in their own bucket
+	manager.SetIncognito(true)
+	manager.SetExternalPlayerEpisodeDetails(&ExternalPlayerEpisodeDetails{EpisodeNumber: 4, MediaId: 1})
+	manager.UpdateExternalPlayerEpisodeWatchHistoryItem(50, 100)
+
+	res := manager.GetExternalPlayerEpisodeWatchHistoryItem("", true, 4, 1)
+	require.True(t, res.Found)
+	require.Equal(t, 50.0, res.Item.CurrentTime)
+
+	// The watch history is not affected
+	manager.SetIncognito(false)
+	item := manager.GetWatchHistoryItem(1)
+	require.True(t, item.Found)
+	require.Equal(t, 3, item.Item.EpisodeNumber)
+	require.Equal(t, 20.0, item.Item.CurrentTime)
+
+	require.False(t, manager.GetExternalPlayerEpisodeWatchHistoryItem("", true, 4, 1).Found)
+}
@@ -21,6 +21,9 @@ type (
 		fileCacher                  *filecache.Cacher
 		db                          *db.Database
 		watchHistoryFileCacheBucket *filecache.Bucket
+		// incognitoWatchHistoryFileCacheBucket stores the positions of incognito playbacks, they expire after a day
+		incognitoWatchHistoryFileCacheBucket *filecache.Bucket
+		incognito                            bool
 
 		externalPlayerEpisodeDetails mo.Option[*ExternalPlayerEpisodeDetails]
 
@@ -55,12 +58,14 @@ type (
 // NewManager creates a new Manager, it should be initialized once.
 func NewManager(opts *NewManagerOptions) *Manager {
 	watchHistoryFileCacheBucket := filecache.NewBucket(WatchHistoryBucketName, time.Hour*24*99999)
+	incognitoWatchHistoryFileCacheBucket := filecache.NewBucket(IncognitoWatchHistoryBucketName, time.Hour*24)
 
 	ret := &Manager{
-		fileCacher:                  opts.FileCacher,
-		logger:                      opts.Logger,
-		db:                          opts.Database,
-		watchHistoryFileCacheBucket: &watchHistoryFileCacheBucket,
+		fileCacher:                           opts.FileCacher,
+		logger:                               opts.Logger,
+		db:                                   opts.Database,
+		watchHistoryFileCacheBucket:          &watchHistoryFileCacheBucket,
+		incognitoWatchHistoryFileCacheBucket: &incognitoWatchHistoryFileCacheBucket,
 		settings: &Settings{
 			WatchContinuityEnabled: false,
 		},
@@ -104,3 +109,14 @@ func (m *Manager) SetExternalPlayerEpisodeDetails(details *ExternalPlayerEpisode
 	defer m.mu.Unlock()
 	m.externalPlayerEpisodeDetails = mo.Some(details)
 }
+
+// SetIncognito is called by the PlaybackManager when the current playback is incognito.
+// The positions of incognito playbacks are stored in a separate bucket and do not affect the watch history.
+func (m *Manager) SetIncognito(incognito bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.incognito = incognito
+}
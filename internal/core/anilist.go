@@ -2,10 +2,15 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"seanime/internal/api/anilist"
 	"seanime/internal/events"
+	"seanime/internal/maintenance"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/syncstatus"
 	"seanime/internal/user"
+	"seanime/internal/util"
+	"time"
 )
 
 // GetUser returns the currently logged-in user or a simulated one.
@@ -27,11 +32,13 @@ func (a *App) GetUserFromSession(sessionID string) *user.User {
 	if sess == nil || sess.IsSimulated {
 		return user.NewSimulatedUser()
 	}
-	return sess.ToUser()
+	return sess.ToUser(a.SessionStore.GetContext(sessionID))
 }
 
+// GetUserAnilistToken returns the Anilist token of the logged-in user.
+// It returns an empty string if there is no user, or if the user is simulated or logged out.
 func (a *App) GetUserAnilistToken() string {
-	if a.user == nil || a.user.Token == user.SimulatedUserToken {
+	if a.user == nil || a.user.IsSimulated || a.user.Token == "" || a.user.Token == user.SimulatedUserToken {
 		return ""
 	}
 
@@ -60,10 +67,131 @@ func (a *App) GetAnilistClientForSession(sessionID string) anilist.AnilistClient
 	return a.SessionStore.GetAnilistClient(sessionID)
 }
 
+// GetSyncStatusUsername returns the username under which the mutations of a session are tracked.
+// It is empty for the local account.
+func (a *App) GetSyncStatusUsername(sessionID string) string {
+	if a.SessionStore != nil && sessionID != "" {
+		sess := a.SessionStore.GetSession(sessionID)
+		if sess != nil && !sess.IsSimulated && sess.Username != "" {
+			return sess.Username
+		}
+	}
+	if u := a.GetUser(); !u.IsSimulated && u.Viewer != nil {
+		return u.Viewer.Name
+	}
+	return ""
+}
+
 // UpdateEntryProgressForSession updates the progress for a media entry using the session-specific Anilist client.
 // This is used by PlaybackManager and DirectStreamManager to update progress for the correct user session.
 // If sessionID is empty, it falls back to the global platform.
+// The outcome is recorded in the SyncStatusTracker.
 func (a *App) UpdateEntryProgressForSession(ctx context.Context, sessionID string, mediaID int, progress int, totalEpisodes *int) error {
+	return a.updateProgress(ctx, sessionID, syncstatus.MediaKindAnime, mediaID, progress, func(ctx context.Context) error {
+		return a.updateEntryProgressForSession(ctx, sessionID, mediaID, progress, totalEpisodes)
+	})
+}
+
+// UpdatePlatformEntryProgress updates the progress for a media entry using the active platform and records the outcome in the SyncStatusTracker.
+func (a *App) UpdatePlatformEntryProgress(ctx context.Context, sessionID string, kind syncstatus.MediaKind, mediaID int, progress int, totalCount *int) error {
+	return a.updateProgress(ctx, sessionID, kind, mediaID, progress, func(ctx context.Context) error {
+		return a.AnilistPlatformRef.Get().UpdateEntryProgress(ctx, mediaID, progress, totalCount)
+	})
+}
+
+// UpdatePlatformMangaEntryProgress updates the chapter and volume progress for a manga entry using the active platform and records the outcome in the SyncStatusTracker.
+func (a *App) UpdatePlatformMangaEntryProgress(ctx context.Context, sessionID string, mediaID int, progress int, progressVolumes *int, totalChapters *int) error {
+	return a.updateProgress(ctx, sessionID, syncstatus.MediaKindManga, mediaID, progress, func(ctx context.Context) error {
+		return a.AnilistPlatformRef.Get().UpdateMangaEntryProgress(ctx, mediaID, progress, progressVolumes, totalChapters)
+	})
+}
+
+const (
+	// remoteProgressTTL is how long the progress of an entry fetched before an update is reused
+	remoteProgressTTL = 30 * time.Second
+	// remoteProgressTimeout bounds the request, the update is sent without the check if it takes longer
+	remoteProgressTimeout = 5 * time.Second
+)
+
+// updateProgress sends a progress update unless the progress on AniList is already ahead,
+// e.g. when the entry was updated from another device at the same time. Skipped updates are recorded as stale in the SyncStatusTracker.
+// The check is also done when a failed update is retried.
+func (a *App) updateProgress(ctx context.Context, sessionID string, kind syncstatus.MediaKind, mediaID int, progress int, send func(ctx context.Context) error) error {
+	username := a.GetSyncStatusUsername(sessionID)
+
+	update := func(ctx context.Context) (skipped bool, err error) {
+		if remote := a.getRemoteProgress(ctx, sessionID, username, mediaID); remote != nil && remote.Progress > progress {
+			a.Logger.Info().Int("mediaId", mediaID).Int("progress", progress).Int("remoteProgress", remote.Progress).
+				Msg("anilist: Skipped progress update, the progress on AniList is ahead")
+			a.SyncStatusTracker.RecordStale(username, kind, mediaID, progress, remote.Progress)
+			return true, nil
+		}
+
+		if err := send(ctx); err != nil {
+			return false, err
+		}
+
+		a.setRemoteProgress(username, mediaID, progress)
+		a.BumpCollectionVersion()
+		return false, nil
+	}
+	retry := func(ctx context.Context) error {
+		_, err := update(ctx)
+		return err
+	}
+
+	skipped, err := update(ctx)
+	if !skipped {
+		a.SyncStatusTracker.Record(username, kind, mediaID, progress, err, retry)
+	}
+	return err
+}
+
+// getRemoteProgress returns the progress of the entry on AniList, cached briefly.
+// It returns nil if the media is not in the user's lists or if the progress could not be fetched.
+func (a *App) getRemoteProgress(ctx context.Context, sessionID string, username string, mediaID int) *anilist.EntryProgress {
+	if a.remoteProgressCache == nil || a.IsOffline() {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%d", username, mediaID)
+	if entry, ok := a.remoteProgressCache.Get(key); ok {
+		return entry
+	}
+
+	client := a.GetAnilistClientForSession(sessionID)
+	if client == nil || !client.IsAuthenticated() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteProgressTimeout)
+	defer cancel()
+	entry, err := client.GetEntryProgress(ctx, mediaID)
+	if err != nil {
+		a.Logger.Debug().Err(err).Int("mediaId", mediaID).Msg("anilist: Could not fetch the entry progress before the update")
+		return nil
+	}
+
+	a.remoteProgressCache.SetT(key, entry, remoteProgressTTL)
+	return entry
+}
+
+// setRemoteProgress updates the cached progress of an entry after a successful update.
+func (a *App) setRemoteProgress(username string, mediaID int, progress int) {
+	if a.remoteProgressCache == nil {
+		return
+	}
+	key := fmt.Sprintf("%s/%d", username, mediaID)
+	entry, _ := a.remoteProgressCache.Get(key)
+	updated := &anilist.EntryProgress{Progress: progress}
+	if entry != nil {
+		updated.ID = entry.ID
+		updated.Status = entry.Status
+	}
+	a.remoteProgressCache.SetT(key, updated, remoteProgressTTL)
+}
+
+func (a *App) updateEntryProgressForSession(ctx context.Context, sessionID string, mediaID int, progress int, totalEpisodes *int) error {
 	// If no session ID or no session store, use the global platform
 	if sessionID == "" || a.SessionStore == nil {
 		return a.AnilistPlatformRef.Get().UpdateEntryProgress(ctx, mediaID, progress, totalEpisodes)
@@ -82,6 +210,10 @@ func (a *App) UpdateEntryProgressForSession(ctx context.Context, sessionID strin
 		return a.AnilistPlatformRef.Get().UpdateEntryProgress(ctx, mediaID, progress, totalEpisodes)
 	}
 
+	// Cancel the request if the session is deleted in the meantime
+	ctx, cancel := a.SessionStore.WithContext(ctx, sessionID)
+	defer cancel()
+
 	// Determine the status based on progress
 	status := anilist.MediaListStatusCurrent
 	realTotalCount := 0
@@ -96,6 +228,10 @@ func (a *App) UpdateEntryProgressForSession(ctx context.Context, sessionID strin
 	}
 
 	_, err := client.UpdateMediaListEntryProgress(ctx, &mediaID, &progress, &status)
+	if err == nil && a.sessionPlatforms != nil {
+		// Update the cached collection of the session with the saved progress
+		a.sessionPlatforms.reconcileProgress(sessionID, mediaID, progress, &status)
+	}
 	return err
 }
 
@@ -107,6 +243,7 @@ func (a *App) UpdatePlatform(platform platform.Platform) {
 		a.AnilistPlatformRef.Get().Close()
 	}
 	a.AnilistPlatformRef.Set(platform)
+	a.BumpCollectionVersion()
 	a.AddOnRefreshAnilistCollectionFunc("anilist-platform", func() {
 		a.AnilistPlatformRef.Get().ClearCache()
 	})
@@ -119,10 +256,10 @@ func (a *App) UpdateAnilistClientToken(token string) {
 	a.AnilistClientRef.Set(ac)
 }
 
-// GetAnimeCollection returns the user's Anilist collection if it in the cache, otherwise it queries Anilist for the user's collection.
-// When bypassCache is true, it will always query Anilist for the user's collection
-func (a *App) GetAnimeCollection(bypassCache bool) (*anilist.AnimeCollection, error) {
-	return a.AnilistPlatformRef.Get().GetAnimeCollection(context.Background(), bypassCache)
+// GetAnimeCollection returns the user's Anilist collection if it is in the cache and fresh enough, otherwise it queries Anilist for the user's collection.
+// maxStaleness is the maximum age of the cached collection, platform.FreshCollection always queries Anilist.
+func (a *App) GetAnimeCollection(maxStaleness time.Duration) (*anilist.AnimeCollection, error) {
+	return a.AnilistPlatformRef.Get().GetAnimeCollection(context.Background(), maxStaleness)
 }
 
 // GetRawAnimeCollection is the same as GetAnimeCollection but returns the raw collection that includes custom lists
@@ -140,8 +277,63 @@ func (a *App) SyncAnilistToSimulatedCollection() {
 	}
 }
 
-// RefreshAnimeCollection queries Anilist for the user's collection
+// RefreshAnimeCollection queries Anilist for the user's collection.
+// Calls made at the same time are coalesced into a single query and refreshes never run concurrently.
 func (a *App) RefreshAnimeCollection() (*anilist.AnimeCollection, error) {
+	return a.AnimeCollectionRefresher.Do(true)
+}
+
+// RefreshAnimeCollectionInBackground is the same as RefreshAnimeCollection but should be used for refreshes that are not initiated by the user.
+// It returns the last fetched collection if a refresh happened recently.
+func (a *App) RefreshAnimeCollectionInBackground() (*anilist.AnimeCollection, error) {
+	return a.AnimeCollectionRefresher.Do(false)
+}
+
+// anilistCacheValidationInterval is how often the cached anime collection is compared with AniList
+const anilistCacheValidationInterval = 10 * time.Minute
+
+// startAnilistCacheValidation periodically checks that the cached anime collection is up to date with AniList.
+// The collection is refreshed when it is missing changes made outside of Seanime, e.g. on the website.
+func (a *App) startAnilistCacheValidation() {
+	go func() {
+		defer util.HandlePanicInModuleThen("core/startAnilistCacheValidation", func() {})
+
+		ticker := time.NewTicker(anilistCacheValidationInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.validateAnilistCache()
+		}
+	}()
+}
+
+func (a *App) validateAnilistCache() {
+	if a.IsOffline() || a.Maintenance.ShouldSkip(maintenance.TaskMetadataRefresh) {
+		return
+	}
+	validator, ok := a.AnilistPlatformRef.Get().(platform.CacheValidator)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	incoherent, err := validator.ValidateAnimeCollectionCache(ctx)
+	if err != nil {
+		a.Logger.Debug().Err(err).Msg("app: Could not validate the cached anime collection")
+		return
+	}
+	if !incoherent {
+		return
+	}
+
+	a.Logger.Info().Msg("app: Refreshing the out of date anime collection")
+	if _, err := a.RefreshAnimeCollection(); err != nil {
+		a.Logger.Error().Err(err).Msg("app: Could not refresh the anime collection")
+	}
+}
+
+func (a *App) refreshAnimeCollection() (*anilist.AnimeCollection, error) {
 	go func() {
 		a.OnRefreshAnilistCollectionFuncs.Range(func(key string, f func()) bool {
 			go f()
@@ -170,8 +362,23 @@ func (a *App) RefreshAnimeCollection() (*anilist.AnimeCollection, error) {
 	// Save the collection to LibraryExplorer
 	a.LibraryExplorer.SetAnimeCollection(ret)
 
+	// Fetch the missing theme songs in the background
+	a.ThemeSongsManager.PopulateCollection(ret)
+
+	// Detect the dropped or removed media and apply the cleanup policy
+	if !a.Maintenance.ShouldSkip(maintenance.TaskLibraryCleanup) {
+		go a.LibraryCleanupManager.Evaluate(ret)
+	}
+
+	// Remap the local records of the media AniList merged into another entry, and flag the deleted ones
+	if !a.Maintenance.ShouldSkip(maintenance.TaskMetadataRefresh) {
+		go a.MediaRemapManager.Check(ret)
+	}
+
 	//a.SyncAnilistToSimulatedCollection()
 
+	a.BumpCollectionVersion()
+
 	a.WSEventManager.SendEvent(events.RefreshedAnilistAnimeCollection, nil)
 
 	return ret, nil
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/api/anilist"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/syncstatus"
+	"seanime/internal/user"
+	"seanime/internal/util"
+	"seanime/internal/util/result"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_GetUserAnilistToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *user.User
+		expected string
+	}{
+		{name: "no user", user: nil, expected: ""},
+		{name: "simulated user", user: user.NewSimulatedUser(), expected: ""},
+		{name: "simulated token", user: &user.User{Token: user.SimulatedUserToken}, expected: ""},
+		{name: "logged out", user: &user.User{Token: ""}, expected: ""},
+		{name: "simulated flag with a token", user: &user.User{Token: "token", IsSimulated: true}, expected: ""},
+		{name: "logged in", user: &user.User{Token: "token"}, expected: "token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{user: tt.user}
+			assert.Equal(t, tt.expected, a.GetUserAnilistToken())
+		})
+	}
+}
+
+// entryProgressClient only implements the methods used by the progress check
+type entryProgressClient struct {
+	anilist.AnilistClient
+	progress int
+	fetches  int
+}
+
+func (c *entryProgressClient) IsAuthenticated() bool {
+	return true
+}
+
+func (c *entryProgressClient) GetEntryProgress(ctx context.Context, mediaID int) (*anilist.EntryProgress, error) {
+	c.fetches++
+	return &anilist.EntryProgress{ID: 1, Progress: c.progress}, nil
+}
+
+// progressPlatform records the progress updates
+type progressPlatform struct {
+	platform.Platform
+	updates []int
+	err     error
+}
+
+func (p *progressPlatform) UpdateEntryProgress(ctx context.Context, mediaID int, progress int, totalCount *int) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.updates = append(p.updates, progress)
+	return nil
+}
+
+func TestApp_UpdatePlatformEntryProgress_RemoteAhead(t *testing.T) {
+	client := &entryProgressClient{progress: 5}
+	p := &progressPlatform{}
+	a := &App{
+		Logger:              util.NewLogger(),
+		user:                &user.User{Token: "token"},
+		isOfflineRef:        util.NewRef(false),
+		AnilistClientRef:    util.NewRef[anilist.AnilistClient](client),
+		AnilistPlatformRef:  util.NewRef[platform.Platform](p),
+		SyncStatusTracker:   syncstatus.NewTracker(),
+		remoteProgressCache: result.NewCache[string, *anilist.EntryProgress](),
+	}
+	ctx := context.Background()
+
+	// The progress was updated from another device
+	require.NoError(t, a.UpdatePlatformEntryProgress(ctx, "", syncstatus.MediaKindAnime, 1, 4, nil))
+	assert.Empty(t, p.updates)
+	status := a.SyncStatusTracker.GetStatus("", true)
+	require.Len(t, status.RecentStale, 1)
+	assert.Equal(t, 4, status.RecentStale[0].Progress)
+	assert.Equal(t, 5, status.RecentStale[0].RemoteProgress)
+	assert.Nil(t, status.LastSuccessAt)
+
+	// The progress of the entry is cached between the updates
+	require.NoError(t, a.UpdatePlatformEntryProgress(ctx, "", syncstatus.MediaKindAnime, 1, 6, nil))
+	require.NoError(t, a.UpdatePlatformEntryProgress(ctx, "", syncstatus.MediaKindAnime, 1, 7, nil))
+	assert.Equal(t, []int{6, 7}, p.updates)
+	assert.Equal(t, 1, client.fetches)
+
+	// A failed update is not retried once the progress on AniList is ahead
+	p.err = errors.New("rate limited")
+	require.Error(t, a.UpdatePlatformEntryProgress(ctx, "", syncstatus.MediaKindAnime, 2, 8, nil))
+	pending := a.SyncStatusTracker.GetStatus("", true).Pending
+	require.Len(t, pending, 1)
+
+	p.err = nil
+	client.progress = 9
+	a.remoteProgressCache.Delete("/2")
+	require.NoError(t, a.SyncStatusTracker.Retry(ctx, "", pending[0].ID))
+	assert.Equal(t, []int{6, 7}, p.updates)
+	status = a.SyncStatusTracker.GetStatus("", true)
+	assert.Empty(t, status.Pending)
+	assert.Len(t, status.RecentStale, 2)
+}
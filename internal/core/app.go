@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"seanime/internal/api/anilist"
+	"seanime/internal/api/anilistproxy"
 	"seanime/internal/api/metadata_provider"
+	"seanime/internal/apikeys"
 	"seanime/internal/constants"
 	"seanime/internal/continuity"
 	"seanime/internal/database/db"
@@ -21,11 +23,20 @@ import (
 	"seanime/internal/hook"
 	"seanime/internal/library/autodownloader"
 	"seanime/internal/library/autoscanner"
+	"seanime/internal/library/cleanup"
 	"seanime/internal/library/fillermanager"
+	"seanime/internal/library/mediaremap"
+	"seanime/internal/library/pathresolver"
 	"seanime/internal/library/playbackmanager"
+	"seanime/internal/library/releasepredictor"
+	"seanime/internal/library/scancoordinator"
 	"seanime/internal/library/scanner"
+	"seanime/internal/library/sidecar"
+	"seanime/internal/library/themesongs"
+	"seanime/internal/library/thumbnails"
 	"seanime/internal/library_explorer"
 	"seanime/internal/local"
+	"seanime/internal/maintenance"
 	"seanime/internal/manga"
 	"seanime/internal/mediaplayers/iina"
 	"seanime/internal/mediaplayers/mediaplayer"
@@ -35,6 +46,7 @@ import (
 	"seanime/internal/mediastream"
 	"seanime/internal/nakama"
 	"seanime/internal/nativeplayer"
+	"seanime/internal/notifications"
 	"seanime/internal/onlinestream"
 	"seanime/internal/platforms/anilist_platform"
 	"seanime/internal/platforms/offline_platform"
@@ -42,17 +54,29 @@ import (
 	"seanime/internal/platforms/simulated_platform"
 	"seanime/internal/playlist"
 	"seanime/internal/plugin"
+	"seanime/internal/publicstatus"
 	"seanime/internal/report"
 	"seanime/internal/session"
+	"seanime/internal/syncstatus"
+	"seanime/internal/torrent_clients/batchwatch"
+	"seanime/internal/torrent_clients/client_migration"
+	"seanime/internal/torrent_clients/network_binding"
+	"seanime/internal/torrent_clients/playback_priority"
+	"seanime/internal/torrent_clients/speedhistory"
 	"seanime/internal/torrent_clients/torrent_client"
+	torrent_history "seanime/internal/torrents/history"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/torrentstream"
+	"seanime/internal/uistate"
 	"seanime/internal/updater"
 	"seanime/internal/user"
 	"seanime/internal/util"
+	"seanime/internal/util/coalesce"
 	"seanime/internal/util/filecache"
 	"seanime/internal/util/result"
+	"seanime/internal/webhooks"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -79,10 +103,19 @@ type (
 		MetadataProviderRef *util.Ref[metadata_provider.Provider]
 
 		// Library
-		FillerManager   *fillermanager.FillerManager
-		AutoDownloader  *autodownloader.AutoDownloader
-		AutoScanner     *autoscanner.AutoScanner
-		PlaybackManager *playbackmanager.PlaybackManager
+		FillerManager         *fillermanager.FillerManager
+		ThemeSongsManager     *themesongs.Manager
+		LibraryCleanupManager *cleanup.Manager
+		MediaRemapManager     *mediaremap.Manager
+		EpisodeThumbnails     *thumbnails.Manager
+		SidecarStore          *sidecar.Store
+		PathResolverRegistry  *pathresolver.Registry
+		AutoDownloader        *autodownloader.AutoDownloader
+		BatchWatchManager     *batchwatch.Manager
+		SpeedHistory          *speedhistory.Recorder
+		AutoScanner           *autoscanner.AutoScanner
+		ScanCoordinator       *scancoordinator.Coordinator
+		PlaybackManager       *playbackmanager.PlaybackManager
 
 		// Real-time communication
 		WSEventManager *events.WSEventManager
@@ -168,6 +201,58 @@ type (
 
 		// Multi-user session support
 		SessionStore *session.Store
+		// AniList platforms of the sessions logged into another account than the main user
+		sessionPlatforms *sessionPlatforms
+
+		// Coordinates anime collection refreshes triggered by different modules
+		AnimeCollectionRefresher *coalesce.Coordinator[*anilist.AnimeCollection]
+
+		// Records the outcome of AniList progress updates
+		SyncStatusTracker *syncstatus.Tracker
+		// Progress of the AniList entries checked before the progress updates, per user and media
+		remoteProgressCache *result.Cache[string, *anilist.EntryProgress]
+
+		// Used for the ETag of the collection endpoints
+		collectionVersion *collectionVersion
+
+		// UI state synced across the devices of a user
+		UIStateStore *uistate.Store
+
+		// Limits the torrent client download speed during playback
+		PlaybackPriority *playback_priority.Manager
+		// Pauses seeding torrents during remote streams
+		SeedingPause *playback_priority.SeedingManager
+		// Binds the torrent traffic to an interface and pauses it when the interface goes down
+		NetworkBinding *network_binding.Manager
+		// Moves the torrents managed by Seanime to another torrent client
+		TorrentClientMigrator *client_migration.Migrator
+
+		// Initialization state of the modules initialized in the background
+		Readiness *ModuleReadiness
+
+		// Pauses the background activity during backups and disk work
+		Maintenance *maintenance.Manager
+
+		// Unauthenticated status page data shared with the people using the server
+		PublicStatus *publicstatus.Manager
+
+		// Sends signed events to the URLs subscribed by the user
+		Webhooks *webhooks.Manager
+
+		// Authenticates the scripts and applications using scoped API keys
+		ApiKeys *apikeys.Manager
+
+		// Notification center of the app
+		Notifications *notifications.Manager
+
+		// Per-user memory of the torrent search results that were seen, dismissed or downloaded
+		TorrentHistory *torrent_history.Store
+
+		// Predicts when the episodes are released from the releases seen by the AutoDownloader
+		ReleasePredictor *releasepredictor.Predictor
+
+		// Rate-limited and cached AniList queries of the extensions and of the clients
+		AnilistProxy *anilistproxy.Proxy
 	}
 )
 
@@ -242,6 +327,12 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		AnimeLibraryPaths: &animeLibraryPaths,
 	})
 
+	// Get the session cleanup interval from the settings, the default is used if there are no settings yet
+	var sessionCleanupInterval time.Duration
+	if settings, err := database.GetSettings(); err == nil {
+		sessionCleanupInterval = settings.GetServer().SessionCleanupInterval
+	}
+
 	// Get Anilist token from database if available
 	anilistToken := database.GetAnilistToken()
 
@@ -357,12 +448,21 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 	isOfflineRef := util.NewRef(cfg.Server.Offline)
 	offlinePlatformRef := util.NewRef[platform.Platform](offlinePlatform)
 
+	// All the AniList queries of the extensions and of the clients go through the same rate limiter
+	anilistProxy := anilistproxy.New(&anilistproxy.NewProxyOptions{
+		Logger: logger,
+		QueryFunc: func(body []byte, token string) (interface{}, error) {
+			return anilistCWRef.Get().CustomQuery(body, logger, token)
+		},
+	})
+
 	// Update plugin context with new modules
 	plugin.GlobalAppContext.SetModulesPartial(plugin.AppContextModules{
 		IsOfflineRef:        isOfflineRef,
 		AnilistPlatformRef:  activePlatformRef,
 		WSEventManager:      wsEventManager,
 		MetadataProviderRef: metadataProviderRef,
+		AnilistProxy:        anilistProxy,
 	})
 
 	// Initialize online streaming repository
@@ -378,8 +478,16 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 	// Initialize extension playground for testing extensions
 	extensionPlaygroundRepository := extension_playground.NewPlaygroundRepository(logger, activePlatformRef, metadataProviderRef)
 
+	readiness := NewModuleReadiness()
+
 	// Load extensions in background
-	go LoadExtensions(extensionRepository, logger, cfg)
+	readiness.SetPending(ModuleExtensions)
+	go func() {
+		_ = readiness.Run(ModuleExtensions, func() error {
+			LoadExtensions(extensionRepository, logger, cfg)
+			return nil
+		})
+	}()
 
 	// Create the main app instance with initialized components
 	app := &App{
@@ -406,9 +514,17 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		ReportRepository:              report.NewRepository(logger),
 		TorrentRepository:             nil, // Initialized in App.initModulesOnce
 		FillerManager:                 nil, // Initialized in App.initModulesOnce
+		ThemeSongsManager:             nil, // Initialized in App.initModulesOnce
+		LibraryCleanupManager:         nil, // Initialized in App.initModulesOnce
+		MediaRemapManager:             nil, // Initialized in App.initModulesOnce
+		EpisodeThumbnails:             nil, // Initialized in App.initModulesOnce
+		SidecarStore:                  nil, // Initialized in App.initModulesOnce
+		PathResolverRegistry:          pathresolver.NewRegistry(),
 		MangaDownloader:               nil, // Initialized in App.initModulesOnce
 		PlaybackManager:               nil, // Initialized in App.initModulesOnce
 		AutoDownloader:                nil, // Initialized in App.initModulesOnce
+		BatchWatchManager:             nil, // Initialized in App.initModulesOnce
+		SpeedHistory:                  nil, // Initialized in App.initModulesOnce
 		AutoScanner:                   nil, // Initialized in App.initModulesOnce
 		MediastreamRepository:         nil, // Initialized in App.initModulesOnce
 		TorrentstreamRepository:       nil, // Initialized in App.initModulesOnce
@@ -435,9 +551,67 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		HookManager:                     hookManager,
 		isOfflineRef:                    isOfflineRef,
 		ServerPasswordHash:              serverPasswordHash,
-		SessionStore:                    session.NewStore(anilistCacheDir),
+		SessionStore:                    session.NewStore(anilistCacheDir, sessionCleanupInterval),
+		SyncStatusTracker:               syncstatus.NewTracker(),
+		collectionVersion:               newCollectionVersion(),
+		UIStateStore: uistate.NewStore(&uistate.NewStoreOptions{
+			Persistence: database,
+			Logger:      logger,
+		}),
+		PlaybackPriority: playback_priority.NewManager(logger),
+		SeedingPause:     playback_priority.NewSeedingManager(logger),
+		NetworkBinding: network_binding.NewManager(&network_binding.NewManagerOptions{
+			Logger:         logger,
+			WSEventManager: wsEventManager,
+		}),
+		TorrentClientMigrator: client_migration.NewMigrator(&client_migration.NewMigratorOptions{
+			Logger:         logger,
+			Database:       database,
+			WSEventManager: wsEventManager,
+		}),
+		Readiness:   readiness,
+		Maintenance: newMaintenanceManager(logger, wsEventManager),
+		PublicStatus: publicstatus.NewManager(&publicstatus.NewManagerOptions{
+			Logger:         logger,
+			WSEventManager: wsEventManager,
+		}),
+		Webhooks: webhooks.NewManager(&webhooks.NewManagerOptions{
+			Logger:   logger,
+			Database: database,
+		}),
+		ApiKeys: apikeys.NewManager(&apikeys.NewManagerOptions{
+			Logger:   logger,
+			Database: database,
+		}),
+		Notifications: notifications.NewManager(&notifications.NewManagerOptions{
+			Logger:         logger,
+			Database:       database,
+			WSEventManager: wsEventManager,
+		}),
+		TorrentHistory: torrent_history.NewStore(&torrent_history.NewStoreOptions{
+			Logger:   logger,
+			Database: database,
+		}),
+		ReleasePredictor: releasepredictor.New(&releasepredictor.NewPredictorOptions{
+			Logger:   logger,
+			Database: database,
+		}),
+		AnilistProxy: anilistProxy,
 	}
 
+	app.AnimeCollectionRefresher = coalesce.NewCoordinator(app.refreshAnimeCollection, coalesce.Options{
+		Window:      300 * time.Millisecond,
+		MinInterval: 30 * time.Second,
+	})
+
+	app.sessionPlatforms = newSessionPlatforms(app.newAnilistSessionPlatform)
+	app.remoteProgressCache = result.NewCache[string, *anilist.EntryProgress]()
+	app.SessionStore.SetOnExpired(app.onSessionExpired)
+
+	app.startUIStatePruning()
+
+	app.startAnilistCacheValidation()
+
 	// Run database migrations if version has changed
 	app.runMigrations()
 
@@ -458,31 +632,13 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		go app.Updater.FetchAnnouncements()
 	}
 
-	// Initialize all modules that depend on settings
-	app.InitOrRefreshModules()
-
-	// Initialize Anilist data if not in offline mode
-	if !app.IsOffline() {
-		app.InitOrRefreshAnilistData()
-	} else {
-		app.ServerReady = true
-	}
-
-	// Initialize mediastream settings (for streaming media)
-	app.InitOrRefreshMediastreamSettings()
-
-	// Initialize torrentstream settings (for torrent streaming)
-	app.InitOrRefreshTorrentstreamSettings()
-
-	// Initialize debrid settings (for debrid services)
-	app.InitOrRefreshDebridSettings()
+	// Initialize all modules that depend on settings in the background
+	// so that the server can serve requests right away
+	app.initModulesInBackground()
 
 	// Register Nakama manager cleanup
 	app.AddCleanupFunction(app.NakamaManager.Cleanup)
 
-	// Run one-time initialization actions
-	app.performActionsOnce()
-
 	return app
 }
 
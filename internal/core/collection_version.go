@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"seanime/internal/database/db_bridge"
+	"sync"
+	"time"
+)
+
+// collectionVersion is a counter that changes every time the anime collection or the local library changes.
+// It is used to generate the ETag of the collection endpoints.
+type collectionVersion struct {
+	mu sync.Mutex
+	// epoch makes versions from a previous run of the server invalid
+	epoch int64
+	value uint64
+	// lastLocalFilesVersion is the version of the local files the last time the counter was read
+	lastLocalFilesVersion uint64
+}
+
+func newCollectionVersion() *collectionVersion {
+	return &collectionVersion{
+		epoch:                 time.Now().UnixNano(),
+		lastLocalFilesVersion: db_bridge.GetLocalFilesVersion(),
+	}
+}
+
+// BumpCollectionVersion invalidates the ETag of the collection endpoints.
+// It is called when the anime collection is refreshed, and when settings affecting the library are changed.
+func (a *App) BumpCollectionVersion() {
+	if a.collectionVersion == nil {
+		return
+	}
+	a.collectionVersion.mu.Lock()
+	defer a.collectionVersion.mu.Unlock()
+	a.collectionVersion.value++
+}
+
+// GetCollectionVersion returns the current version of the collection.
+// Changes to the local files are picked up when the version is read.
+func (a *App) GetCollectionVersion() string {
+	if a.collectionVersion == nil {
+		return ""
+	}
+	a.collectionVersion.mu.Lock()
+	defer a.collectionVersion.mu.Unlock()
+
+	if lfVersion := db_bridge.GetLocalFilesVersion(); lfVersion != a.collectionVersion.lastLocalFilesVersion {
+		a.collectionVersion.lastLocalFilesVersion = lfVersion
+		a.collectionVersion.value++
+	}
+
+	return fmt.Sprintf("%x-%d", a.collectionVersion.epoch, a.collectionVersion.value)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"path/filepath"
+	"seanime/internal/api/metadata"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/thumbnails"
+	"seanime/internal/util"
+)
+
+// newEpisodeThumbnailsManager creates the manager that extracts the thumbnails of the episodes that have no image.
+// It uses the FFmpeg binaries of the media streaming settings and waits while something is being played.
+func (a *App) newEpisodeThumbnailsManager() *thumbnails.Manager {
+	return thumbnails.NewManager(&thumbnails.NewManagerOptions{
+		Logger: a.Logger,
+		Dir:    filepath.Join(a.Config.Cache.Dir, "episode-thumbnails"),
+		FfmpegPathFunc: func() string {
+			if settings, found := a.Database.GetMediastreamSettings(); found {
+				return settings.FfmpegPath
+			}
+			return ""
+		},
+		FfprobePathFunc: func() string {
+			if settings, found := a.Database.GetMediastreamSettings(); found {
+				return settings.FfprobePath
+			}
+			return ""
+		},
+		IsPausedFunc: func() bool {
+			return a.Maintenance.IsActive() || len(a.PlaybackPriority.GetStatus().Sessions) > 0
+		},
+	})
+}
+
+// QueueEpisodeThumbnails queues the extraction of the thumbnails of the scanned files whose episode has no image.
+// It is called after scans.
+func (a *App) QueueEpisodeThumbnails(lfs []*anime.LocalFile) {
+	if a.EpisodeThumbnails == nil || !a.MetadataProviderRef.IsPresent() {
+		return
+	}
+
+	go func() {
+		defer util.HandlePanicInModuleThen("core/QueueEpisodeThumbnails", func() {})
+
+		a.EpisodeThumbnails.QueueLocalFiles(lfs, func(mediaId int) (*metadata.AnimeMetadata, error) {
+			return a.MetadataProviderRef.Get().GetAnimeMetadata(metadata.AnilistPlatform, mediaId)
+		})
+	}()
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/scancoordinator"
+	"seanime/internal/library/scanner"
+	"seanime/internal/library/summary"
+	"seanime/internal/notifications"
+	"seanime/internal/util"
+	"time"
+)
+
+const (
+	// defaultUnverifiedFileGraceDays is used when the grace period of the unverified files is not set
+	defaultUnverifiedFileGraceDays = 7
+	// defaultTorrentPreMatchRetentionDays is used when the retention of the torrent pre-matches is not set
+	defaultTorrentPreMatchRetentionDays = 14
+)
+
+// GetTorrentPreMatchMap builds the pre-match map from the database for accurate torrent file matching.
+func (a *App) GetTorrentPreMatchMap() map[string]int {
+	preMatchMap := make(map[string]int)
+	if preMatches, err := a.Database.GetAllTorrentPreMatches(); err == nil {
+		for _, pm := range preMatches {
+			preMatchMap[pm.Destination] = pm.MediaId
+		}
+	}
+	return preMatchMap
+}
+
+// scanLibraryJob scans the library paths of a job queued by the ScanCoordinator.
+// The local files of the library paths that are not part of the job are kept unchanged.
+func (a *App) scanLibraryJob(ctx context.Context, job *scancoordinator.Job) ([]*anime.LocalFile, error) {
+	settings, err := a.Database.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.Library == nil || settings.Library.LibraryPath == "" {
+		return nil, errors.New("library path is not set")
+	}
+
+	// Get the latest local files
+	existingLfs, _, err := db_bridge.GetLocalFiles(a.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	scanSummaryLogger := summary.NewScanSummaryLogger()
+
+	scanLogger, err := scanner.NewScanLogger(a.Config.Logs.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer scanLogger.Done()
+
+	sc := scanner.Scanner{
+		DirPath:             settings.Library.LibraryPath,
+		OtherDirPaths:       settings.Library.LibraryPaths,
+		Enhanced:            job.Options.Enhanced,
+		PlatformRef:         a.AnilistPlatformRef,
+		Logger:              a.Logger,
+		WSEventManager:      a.WSEventManager,
+		ExistingLocalFiles:  existingLfs,
+		SkipLockedFiles:     job.Options.SkipLockedFiles,
+		SkipIgnoredFiles:    job.Options.SkipIgnoredFiles,
+		ScanSummaryLogger:   scanSummaryLogger,
+		ScanLogger:          scanLogger,
+		MetadataProviderRef: a.MetadataProviderRef,
+		MatchingAlgorithm:   settings.Library.ScannerMatchingAlgorithm,
+		MatchingThreshold:   settings.Library.ScannerMatchingThreshold,
+		PreMatchMap:         a.GetTorrentPreMatchMap(),
+		ExcludedPaths:       a.GetScannerExcludedPaths(),
+		Roots:               job.Roots,
+		IOConcurrency:       a.ScanCoordinator.GetIOConcurrency,
+		SentinelFile:        a.ScanCoordinator.GetSentinelFile,
+		ForceReconcile:      job.Options.ForceReconcile,
+	}
+
+	allLfs, err := sc.Scan(ctx)
+	if err != nil {
+		if errors.Is(err, scanner.ErrNoLocalFiles) {
+			return []*anime.LocalFile{}, nil
+		}
+		return nil, err
+	}
+
+	for _, root := range sc.UnavailableRoots {
+		a.Notifications.Notify(notifications.TypeLibraryPathUnavailable, fmt.Sprintf("%s looks unavailable, its files were kept and marked as unverified", root), 0)
+	}
+	a.notifyStaleUnverifiedFiles(allLfs, settings.Library)
+
+	// Insert the local files
+	lfs, err := db_bridge.InsertLocalFiles(a.Database, allLfs)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cleanupTorrentPreMatches(sc.MatchedPreMatches, settings.Library)
+
+	// Save the scan summary
+	if err := db_bridge.InsertScanSummary(a.Database, scanSummaryLogger.GenerateSummary()); err != nil {
+		a.Logger.Error().Err(err).Msg("app: Failed to insert scan summary")
+	}
+
+	go a.AutoDownloader.CleanUpDownloadedItems()
+
+	a.QueueEpisodeThumbnails(lfs)
+	a.DispatchScanFinishedWebhook(lfs, job.Trigger != scancoordinator.TriggerManual)
+
+	return lfs, nil
+}
+
+// GetTorrentPreMatchRetentionDays returns the number of days after which the torrent pre-matches are removed.
+func (a *App) GetTorrentPreMatchRetentionDays() int {
+	settings, err := a.Database.GetSettings()
+	if err != nil {
+		return defaultTorrentPreMatchRetentionDays
+	}
+	return getTorrentPreMatchRetentionDays(settings.Library)
+}
+
+func getTorrentPreMatchRetentionDays(settings *models.LibrarySettings) int {
+	if settings == nil || settings.TorrentPreMatchRetentionDays <= 0 {
+		return defaultTorrentPreMatchRetentionDays
+	}
+	return settings.TorrentPreMatchRetentionDays
+}
+
+// cleanupTorrentPreMatches removes the pre-matches whose files were matched by a scan and the ones older than the retention,
+// so that files later added to the same destination are not matched to the wrong media.
+func (a *App) cleanupTorrentPreMatches(matchedDestinations []string, settings *models.LibrarySettings) {
+	if err := a.Database.DeleteTorrentPreMatchesByDestination(matchedDestinations); err != nil {
+		a.Logger.Error().Err(err).Msg("app: Failed to delete matched torrent pre-matches")
+	} else if len(matchedDestinations) > 0 {
+		a.Logger.Debug().Strs("destinations", matchedDestinations).Msg("app: Deleted matched torrent pre-matches")
+	}
+
+	if err := a.Database.CleanupOldTorrentPreMatches(getTorrentPreMatchRetentionDays(settings)); err != nil {
+		a.Logger.Error().Err(err).Msg("app: Failed to clean up old torrent pre-matches")
+	}
+}
+
+// notifyStaleUnverifiedFiles asks the user to clean up the files that have been unverified for longer than the grace period.
+// They are never removed without a reconciliation of their library path.
+func (a *App) notifyStaleUnverifiedFiles(lfs []*anime.LocalFile, settings *models.LibrarySettings) {
+	graceDays := settings.UnverifiedFileGraceDays
+	if graceDays <= 0 {
+		graceDays = defaultUnverifiedFileGraceDays
+	}
+	cutoff := time.Now().AddDate(0, 0, -graceDays)
+
+	libraryPaths := append([]string{settings.LibraryPath}, settings.LibraryPaths...)
+	counts := make(map[string]int)
+	for _, lf := range lfs {
+		if lf.UnverifiedSince == nil || lf.UnverifiedSince.After(cutoff) {
+			continue
+		}
+		for _, libraryPath := range libraryPaths {
+			if libraryPath != "" && util.IsSubpath(libraryPath, lf.Path) {
+				counts[libraryPath]++
+				break
+			}
+		}
+	}
+
+	for libraryPath, count := range counts {
+		a.Notifications.Notify(notifications.TypeUnverifiedFiles, fmt.Sprintf("%d files in %s have not been found for more than %d days, reconcile the library path to remove them", count, libraryPath, graceDays), 0)
+	}
+}
+
+// autoScanLibrary queues a full scan for the auto scanner and waits for it.
+func (a *App) autoScanLibrary() ([]*anime.LocalFile, error) {
+	lfs, _, err := a.ScanCoordinator.ScanFull(context.Background(), scancoordinator.TriggerAuto, scancoordinator.ScanOptions{
+		SkipLockedFiles:  true,
+		SkipIgnoredFiles: true,
+	})
+	return lfs, err
+}
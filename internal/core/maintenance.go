@@ -0,0 +1,34 @@
+package core
+
+import (
+	"seanime/internal/events"
+	"seanime/internal/maintenance"
+
+	"github.com/rs/zerolog"
+)
+
+// newMaintenanceManager creates the maintenance manager and registers the background tasks it pauses.
+func newMaintenanceManager(logger *zerolog.Logger, wsEventManager events.WSEventManagerInterface) *maintenance.Manager {
+	m := maintenance.NewManager(&maintenance.NewManagerOptions{
+		Logger:         logger,
+		WSEventManager: wsEventManager,
+	})
+
+	m.RegisterTask(maintenance.TaskAutoDownloader, "Checks for new episodes and downloads them")
+	m.RegisterTask(maintenance.TaskAutoScanner, "Scans the library when files change")
+	m.RegisterTask(maintenance.TaskMetadataRefresh, "Refreshes the AniList collections")
+	m.RegisterTask(maintenance.TaskLocalSync, "Synchronizes the offline data")
+	m.RegisterTask(maintenance.TaskLibraryCleanup, "Moves the files of dropped media to the trash")
+	m.RegisterTask(maintenance.TaskTorrentProgress, "Polls the torrent client for active torrents")
+	m.RegisterTask(maintenance.TaskUpdateCheck, "Checks for updates and announcements")
+	m.RegisterTask(maintenance.TaskBatchWatch, "Selects the files of new episodes in watched batch torrents")
+
+	return m
+}
+
+// IsTaskPaused returns a function that reports whether the background task should be skipped because of maintenance mode.
+func (a *App) IsTaskPaused(task string) func() bool {
+	return func() bool {
+		return a.Maintenance.ShouldSkip(task)
+	}
+}
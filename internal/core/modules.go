@@ -1,6 +1,7 @@
 package core
 
 import (
+	"path/filepath"
 	"seanime/internal/api/anilist"
 	"seanime/internal/continuity"
 	"seanime/internal/database/db"
@@ -13,8 +14,15 @@ import (
 	"seanime/internal/library/anime"
 	"seanime/internal/library/autodownloader"
 	"seanime/internal/library/autoscanner"
+	"seanime/internal/library/cleanup"
 	"seanime/internal/library/fillermanager"
+	"seanime/internal/library/mediaremap"
 	"seanime/internal/library/playbackmanager"
+	"seanime/internal/library/scancoordinator"
+	"seanime/internal/library/sidecar"
+	"seanime/internal/maintenance"
+	"seanime/internal/library/themesongs"
+	"seanime/internal/library/thumbnails"
 	"seanime/internal/library_explorer"
 	"seanime/internal/manga"
 	"seanime/internal/mediaplayers/iina"
@@ -29,7 +37,11 @@ import (
 	"seanime/internal/platforms/shared_platform"
 	"seanime/internal/playlist"
 	"seanime/internal/plugin"
+	"seanime/internal/publicstatus"
+	"seanime/internal/torrent_clients/batchwatch"
+	"seanime/internal/torrent_clients/clientpath"
 	"seanime/internal/torrent_clients/qbittorrent"
+	"seanime/internal/torrent_clients/speedhistory"
 	"seanime/internal/torrent_clients/torrent_client"
 	"seanime/internal/torrent_clients/transmission"
 	"seanime/internal/torrents/torrent"
@@ -46,7 +58,7 @@ import (
 func (a *App) initModulesOnce() {
 
 	a.LocalManager.SetRefreshAnilistCollectionsFunc(func() {
-		_, _ = a.RefreshAnimeCollection()
+		_, _ = a.RefreshAnimeCollectionInBackground()
 		_, _ = a.RefreshMangaCollection()
 	})
 
@@ -85,6 +97,46 @@ func (a *App) initModulesOnce() {
 		FillerManager: a.FillerManager,
 	})
 
+	// +---------------------+
+	// |     Theme Songs     |
+	// +---------------------+
+
+	a.ThemeSongsManager = themesongs.New(&themesongs.NewManagerOptions{
+		DB:     a.Database,
+		Logger: a.Logger,
+	})
+
+	// +---------------------+
+	// |   Library Cleanup   |
+	// +---------------------+
+
+	a.LibraryCleanupManager = cleanup.New(&cleanup.NewManagerOptions{
+		DB:             a.Database,
+		Logger:         a.Logger,
+		WSEventManager: a.WSEventManager,
+		TrashDir:       filepath.Join(a.Config.Data.AppDataDir, "trash"),
+	})
+
+	// Move the due files to the trash at night, in the timezone of the user
+	if err := a.Maintenance.ScheduleDaily(maintenance.TaskLibraryCleanup, 3, 0, a.LibraryCleanupManager.TrashDue); err != nil {
+		a.Logger.Error().Err(err).Msg("app: Failed to schedule the library cleanup")
+	}
+
+	// +---------------------+
+	// | Episode Thumbnails  |
+	// +---------------------+
+
+	a.EpisodeThumbnails = a.newEpisodeThumbnailsManager()
+
+	// +---------------------+
+	// |  Local File Sidecar |
+	// +---------------------+
+
+	a.SidecarStore = sidecar.NewStore(&sidecar.NewStoreOptions{
+		DB:     a.Database,
+		Logger: a.Logger,
+	})
+
 	// +---------------------+
 	// |     Continuity      |
 	// +---------------------+
@@ -95,6 +147,20 @@ func (a *App) initModulesOnce() {
 		Database:   a.Database,
 	})
 
+	// +---------------------+
+	// |     Media Remap     |
+	// +---------------------+
+
+	a.MediaRemapManager = mediaremap.New(&mediaremap.NewManagerOptions{
+		DB:                a.Database,
+		Logger:            a.Logger,
+		WSEventManager:    a.WSEventManager,
+		AnilistClientRef:  a.AnilistClientRef,
+		ContinuityManager: a.ContinuityManager,
+		Notifications:     a.Notifications,
+		OnRemapped:        a.BumpCollectionVersion,
+	})
+
 	// +---------------------+
 	// |   Playback Manager  |
 	// +---------------------+
@@ -115,6 +181,11 @@ func (a *App) initModulesOnce() {
 		UpdateProgressForSessionFunc: a.UpdateEntryProgressForSession,
 	})
 
+	a.listenToPlaybackForPlaybackPriority()
+	a.listenToPlaybackForWebhooks()
+	a.startWebhookWatchers()
+	a.startQueuedDownloadWatcher()
+
 	// +---------------------+
 	// |  Torrent Repository |
 	// +---------------------+
@@ -148,6 +219,7 @@ func (a *App) initModulesOnce() {
 		Logger:         a.Logger,
 		WSEventManager: a.WSEventManager,
 		FileCacher:     a.FileCacher,
+		SidecarStore:   a.SidecarStore,
 	})
 
 	a.AddCleanupFunction(func() {
@@ -180,6 +252,8 @@ func (a *App) initModulesOnce() {
 		IsOfflineRef:                 a.IsOfflineRef(),
 		NativePlayer:                 a.NativePlayer,
 		UpdateProgressForSessionFunc: a.UpdateEntryProgressForSession,
+		PlaybackPriority:             a.PlaybackPriority,
+		SidecarStore:                 a.SidecarStore,
 	})
 
 	// +---------------------+
@@ -198,7 +272,10 @@ func (a *App) initModulesOnce() {
 		Database:            a.Database,
 		DirectStreamManager: a.DirectStreamManager,
 		NativePlayer:        a.NativePlayer,
+		NetworkBinding:      a.NetworkBinding,
 	})
+	a.SeedingPause.SetClient(seedingClientTorrentstream, &torrentstreamSeedingClient{a.TorrentstreamRepository})
+	a.NetworkBinding.SetClient(seedingClientTorrentstream, a.TorrentstreamRepository)
 
 	// +---------------------+
 	// | Debrid Client Repo  |
@@ -233,15 +310,50 @@ func (a *App) initModulesOnce() {
 		MetadataProviderRef:     a.MetadataProviderRef,
 		DebridClientRepository:  a.DebridClientRepository,
 		IsOfflineRef:            a.IsOfflineRef(),
+		PlatformRef:             a.AnilistPlatformRef,
+		IsPausedFunc:            a.IsTaskPaused(maintenance.TaskAutoDownloader),
+		Notifications:           a.Notifications,
+		ReleasePredictor:        a.ReleasePredictor,
 	})
 
 	// This is run in a goroutine
 	a.AutoDownloader.Start()
 
+	// +---------------------+
+	// |     Batch Watch     |
+	// +---------------------+
+
+	a.BatchWatchManager = batchwatch.New(&batchwatch.NewManagerOptions{
+		DB:                      a.Database,
+		Logger:                  a.Logger,
+		PlatformRef:             a.AnilistPlatformRef,
+		MetadataProviderRef:     a.MetadataProviderRef,
+		TorrentRepository:       a.TorrentRepository,
+		TorrentClientRepository: a.TorrentClientRepository,
+		Notifications:           a.Notifications,
+		IsPausedFunc:            a.IsTaskPaused(maintenance.TaskBatchWatch),
+	})
+
+	// This is run in a goroutine
+	a.BatchWatchManager.Start()
+
+	a.SpeedHistory = speedhistory.New(&speedhistory.NewRecorderOptions{
+		Logger:   a.Logger,
+		Database: a.Database,
+	})
+	a.SpeedHistory.Start()
+
 	// +---------------------+
 	// |    Auto Scanner     |
 	// +---------------------+
 
+	// Queues the scans of the library, including the scheduled scans of the library paths
+	a.ScanCoordinator = scancoordinator.New(&scancoordinator.NewCoordinatorOptions{
+		Logger:    a.Logger,
+		ScanFunc:  a.scanLibraryJob,
+		Scheduler: a.Maintenance,
+	})
+
 	a.AutoScanner = autoscanner.New(&autoscanner.NewAutoScannerOptions{
 		Database:            a.Database,
 		PlatformRef:         a.AnilistPlatformRef,
@@ -251,11 +363,18 @@ func (a *App) initModulesOnce() {
 		AutoDownloader:      a.AutoDownloader,
 		MetadataProviderRef: a.MetadataProviderRef,
 		LogsDir:             a.Config.Logs.Dir,
+		ExcludedPathsFunc:   a.GetScannerExcludedPaths,
+		IsPausedFunc:        a.IsTaskPaused(maintenance.TaskAutoScanner),
+		// The scan is queued with the other scans and only covers the library paths that are not excluded from full scans
+		ScanFunc: a.autoScanLibrary,
 	})
 
 	// This is run in a goroutine
 	a.AutoScanner.Start()
 
+	// Catch up on the file changes that happened during maintenance
+	a.Maintenance.OnExit(a.AutoScanner.Notify)
+
 	// +---------------------+
 	// |       Nakama        |
 	// +---------------------+
@@ -318,16 +437,25 @@ func HandleNewDatabaseEntries(database *db.Database, logger *zerolog.Logger) {
 
 // InitOrRefreshModules will initialize or refresh modules that depend on settings.
 // This function is called:
-//   - After the App instance is created
+//   - In the background after the App instance is created
 //   - After settings are updated.
 //
+// Concurrent calls are serialized and the state of the module is tracked by App.Readiness.
+//
 // DEVNOTE: Make sure there's no blocking code in this function.
 func (a *App) InitOrRefreshModules() {
+	_ = a.Readiness.Run(ModuleSettings, a.initOrRefreshModules)
+}
+
+func (a *App) initOrRefreshModules() error {
 	a.moduleMu.Lock()
 	defer a.moduleMu.Unlock()
 
 	a.Logger.Debug().Msgf("app: Refreshing modules")
 
+	// Settings can change what is included in the library
+	a.BumpCollectionVersion()
+
 	// Stop watching if already watching
 	if a.Watcher != nil {
 		a.Watcher.StopWatching()
@@ -342,7 +470,7 @@ func (a *App) InitOrRefreshModules() {
 	settings, err := a.Database.GetSettings()
 	if err != nil || settings == nil {
 		a.Logger.Warn().Msg("app: Did not initialize modules, no settings found")
-		return
+		return nil
 	}
 
 	a.Settings = settings // Store settings instance in app
@@ -358,6 +486,23 @@ func (a *App) InitOrRefreshModules() {
 		shared_platform.ShouldCache.Store(!settings.Anilist.DisableCacheLayer)
 	}
 
+	if a.SessionStore != nil {
+		a.SessionStore.SetCleanupInterval(settings.GetServer().SessionCleanupInterval)
+		a.SessionStore.SetMaxIdle(settings.GetServer().SessionMaxIdle)
+	}
+
+	if a.PublicStatus != nil {
+		serverSettings := settings.GetServer()
+		a.PublicStatus.SetSettings(&publicstatus.Settings{
+			Enabled:         serverSettings.PublicStatusEnabled,
+			ShowVersion:     serverSettings.PublicStatusShowVersion,
+			ShowStreaming:   serverSettings.PublicStatusShowStreaming,
+			ShowMaintenance: serverSettings.PublicStatusShowMaintenance,
+			MaxStreams:      serverSettings.PublicStatusMaxStreams,
+			Announcement:    serverSettings.PublicStatusAnnouncement,
+		})
+	}
+
 	// +---------------------+
 	// |   Module settings   |
 	// +---------------------+
@@ -380,6 +525,25 @@ func (a *App) InitOrRefreshModules() {
 			go a.AutoScanner.SetSettings(*settings.Library)
 		}
 
+		if a.LibraryCleanupManager != nil {
+			a.LibraryCleanupManager.SetSettings(settings.Library)
+		}
+
+		// Reschedule the scans of the library paths
+		if a.ScanCoordinator != nil {
+			a.ScanCoordinator.SetSettings(settings.Library)
+		}
+
+		// Reschedule the daily tasks in the timezone of the user
+		a.Maintenance.SetLocation(settings.Library.GetLocation())
+
+		if a.EpisodeThumbnails != nil {
+			a.EpisodeThumbnails.SetSettings(&thumbnails.Settings{
+				Enabled:      !settings.Library.DisableEpisodeThumbnails,
+				SkippedPaths: settings.Library.EpisodeThumbnailSkippedPaths,
+			})
+		}
+
 		// Update the torrent manager settings (thread safe)
 		go a.TorrentRepository.SetSettings(&torrent.RepositorySettings{
 			DefaultAnimeProvider: settings.Library.TorrentProvider,
@@ -493,13 +657,14 @@ func (a *App) InitOrRefreshModules() {
 		}
 		// Init qBittorrent
 		qbit := qbittorrent.NewClient(&qbittorrent.NewClientOptions{
-			Logger:   a.Logger,
-			Username: settings.Torrent.QBittorrentUsername,
-			Password: settings.Torrent.QBittorrentPassword,
-			Port:     settings.Torrent.QBittorrentPort,
-			Host:     settings.Torrent.QBittorrentHost,
-			Path:     settings.Torrent.QBittorrentPath,
-			Tags:     settings.Torrent.QBittorrentTags,
+			Logger:        a.Logger,
+			Username:      settings.Torrent.QBittorrentUsername,
+			Password:      settings.Torrent.QBittorrentPassword,
+			Port:          settings.Torrent.QBittorrentPort,
+			Host:          settings.Torrent.QBittorrentHost,
+			Path:          settings.Torrent.QBittorrentPath,
+			Tags:          settings.Torrent.QBittorrentTags,
+			CustomHeaders: settings.Torrent.CustomHeaders,
 		})
 		// Login to qBittorrent
 		go func() {
@@ -514,12 +679,13 @@ func (a *App) InitOrRefreshModules() {
 		}()
 		// Init Transmission
 		trans, err := transmission.New(&transmission.NewTransmissionOptions{
-			Logger:   a.Logger,
-			Username: settings.Torrent.TransmissionUsername,
-			Password: settings.Torrent.TransmissionPassword,
-			Port:     settings.Torrent.TransmissionPort,
-			Host:     settings.Torrent.TransmissionHost,
-			Path:     settings.Torrent.TransmissionPath,
+			Logger:        a.Logger,
+			Username:      settings.Torrent.TransmissionUsername,
+			Password:      settings.Torrent.TransmissionPassword,
+			Port:          settings.Torrent.TransmissionPort,
+			Host:          settings.Torrent.TransmissionHost,
+			Path:          settings.Torrent.TransmissionPath,
+			CustomHeaders: settings.Torrent.CustomHeaders,
 		})
 		if err != nil && settings.Torrent.TransmissionUsername != "" && settings.Torrent.TransmissionPassword != "" { // Only log error if username and password are set
 			a.Logger.Error().Err(err).Msg("app: Failed to initialize transmission client")
@@ -530,20 +696,35 @@ func (a *App) InitOrRefreshModules() {
 			a.TorrentClientRepository.Shutdown()
 		}
 
+		// Paths of the selected client, which can run on another OS than the server
+		pathStyle, pathMappings := settings.Torrent.QBittorrentPathStyle, settings.Torrent.QBittorrentPathMappings
+		if settings.Torrent.Default == torrent_client.TransmissionClient {
+			pathStyle, pathMappings = settings.Torrent.TransmissionPathStyle, settings.Torrent.TransmissionPathMappings
+		}
+
 		// Torrent Client Repository
 		a.TorrentClientRepository = torrent_client.NewRepository(&torrent_client.NewRepositoryOptions{
-			Logger:              a.Logger,
-			QbittorrentClient:   qbit,
-			Transmission:        trans,
-			TorrentRepository:   a.TorrentRepository,
-			Provider:            settings.Torrent.Default,
-			MetadataProviderRef: a.MetadataProviderRef,
+			Logger:                a.Logger,
+			QbittorrentClient:     qbit,
+			Transmission:          trans,
+			TorrentRepository:     a.TorrentRepository,
+			Provider:              settings.Torrent.Default,
+			MetadataProviderRef:   a.MetadataProviderRef,
+			IncompleteDirOverride: settings.Torrent.IncompleteDirOverride,
+			IsPausedFunc:          a.IsTaskPaused(maintenance.TaskTorrentProgress),
+			PathStyle:             clientpath.Style(pathStyle),
+			PathMappings:          clientpath.MappingsFromMap(pathMappings),
 		})
 
 		a.TorrentClientRepository.InitActiveTorrentCount(settings.Torrent.ShowActiveTorrentCount, a.WSEventManager)
 
 		// Set AutoDownloader qBittorrent client
 		a.AutoDownloader.SetTorrentClientRepository(a.TorrentClientRepository)
+		a.BatchWatchManager.SetTorrentClientRepository(a.TorrentClientRepository)
+		a.SpeedHistory.SetTorrentClientRepository(a.TorrentClientRepository)
+
+		a.refreshPlaybackPriority(settings.Torrent)
+		a.refreshNetworkBinding(settings.Torrent)
 
 		plugin.GlobalAppContext.SetModulesPartial(plugin.AppContextModules{
 			TorrentClientRepository: a.TorrentClientRepository,
@@ -603,11 +784,16 @@ func (a *App) InitOrRefreshModules() {
 
 	a.Logger.Info().Msg("app: Refreshed modules")
 
+	return nil
 }
 
 // InitOrRefreshMediastreamSettings will initialize or refresh the mediastream settings.
 // It is called after the App instance is created and after settings are updated.
 func (a *App) InitOrRefreshMediastreamSettings() {
+	_ = a.Readiness.Run(ModuleMediastream, a.initOrRefreshMediastreamSettings)
+}
+
+func (a *App) initOrRefreshMediastreamSettings() error {
 
 	var settings *models.MediastreamSettings
 	var found bool
@@ -626,7 +812,7 @@ func (a *App) InitOrRefreshMediastreamSettings() {
 		})
 		if err != nil {
 			a.Logger.Error().Err(err).Msg("app: Failed to initialize mediastream module")
-			return
+			return err
 		}
 	}
 
@@ -644,11 +830,17 @@ func (a *App) InitOrRefreshMediastreamSettings() {
 	}()
 
 	a.SecondarySettings.Mediastream = settings
+
+	return nil
 }
 
 // InitOrRefreshTorrentstreamSettings will initialize or refresh the mediastream settings.
 // It is called after the App instance is created and after settings are updated.
 func (a *App) InitOrRefreshTorrentstreamSettings() {
+	_ = a.Readiness.Run(ModuleTorrentstream, a.initOrRefreshTorrentstreamSettings)
+}
+
+func (a *App) initOrRefreshTorrentstreamSettings() error {
 
 	var settings *models.TorrentstreamSettings
 	var found bool
@@ -676,10 +868,16 @@ func (a *App) InitOrRefreshTorrentstreamSettings() {
 		})
 		if err != nil {
 			a.Logger.Error().Err(err).Msg("app: Failed to initialize mediastream module")
-			return
+			return err
 		}
 	}
 
+	// The network binding is applied when the embedded torrent client is created,
+	// it is read here because the torrent settings may not have been loaded yet
+	if appSettings, err := a.Database.GetSettings(); err == nil && appSettings.Torrent != nil {
+		a.NetworkBinding.SetSettings(networkBindingSettings(appSettings.Torrent))
+	}
+
 	err := a.TorrentstreamRepository.InitModules(settings, a.Config.Server.Host, a.Config.Server.Port)
 	if err != nil && settings.Enabled {
 		a.Logger.Error().Err(err).Msg("app: Failed to initialize Torrent streaming module")
@@ -689,6 +887,9 @@ func (a *App) InitOrRefreshTorrentstreamSettings() {
 		//	},
 		//	Enabled: false,
 		//})
+	} else {
+		// The module is inactive when it is disabled
+		err = nil
 	}
 
 	a.Cleanups = append(a.Cleanups, func() {
@@ -698,9 +899,16 @@ func (a *App) InitOrRefreshTorrentstreamSettings() {
 	// Set torrent streaming settings in secondary settings
 	// so the client can use them
 	a.SecondarySettings.Torrentstream = settings
+	a.BumpCollectionVersion()
+
+	return err
 }
 
 func (a *App) InitOrRefreshDebridSettings() {
+	_ = a.Readiness.Run(ModuleDebrid, a.initOrRefreshDebridSettings)
+}
+
+func (a *App) initOrRefreshDebridSettings() error {
 
 	settings, found := a.Database.GetDebridSettings()
 	if !found {
@@ -719,17 +927,20 @@ func (a *App) InitOrRefreshDebridSettings() {
 		})
 		if err != nil {
 			a.Logger.Error().Err(err).Msg("app: Failed to initialize debrid module")
-			return
+			return err
 		}
 	}
 
 	a.SecondarySettings.Debrid = settings
+	a.BumpCollectionVersion()
 
 	err := a.DebridClientRepository.InitializeProvider(settings)
 	if err != nil {
 		a.Logger.Error().Err(err).Msg("app: Failed to initialize debrid provider")
-		return
+		return err
 	}
+
+	return nil
 }
 
 // InitOrRefreshAnilistData will initialize the Anilist anime collection and the account.
@@ -737,6 +948,9 @@ func (a *App) InitOrRefreshDebridSettings() {
 func (a *App) InitOrRefreshAnilistData() {
 	a.Logger.Debug().Msg("app: Fetching Anilist data")
 
+	// The module is ready once the anime collection has been fetched
+	a.Readiness.SetPending(ModuleAnilist)
+
 	var currUser *user.User
 	acc, err := a.Database.GetAccount()
 	if err != nil || acc.Username == "" {
@@ -746,6 +960,7 @@ func (a *App) InitOrRefreshAnilistData() {
 		currUser, err = user.NewUser(acc)
 		if err != nil {
 			a.Logger.Error().Err(err).Msg("app: Failed to create user from account")
+			a.Readiness.SetDone(ModuleAnilist, err)
 			return
 		}
 	}
@@ -758,10 +973,11 @@ func (a *App) InitOrRefreshAnilistData() {
 	a.Logger.Info().Msg("app: Authenticated to AniList")
 
 	go func() {
-		_, err = a.RefreshAnimeCollection()
+		_, err := a.RefreshAnimeCollection()
 		if err != nil {
 			a.Logger.Error().Err(err).Msg("app: Failed to fetch Anilist anime collection")
 		}
+		a.Readiness.SetDone(ModuleAnilist, err)
 
 		a.ServerReady = true
 		a.WSEventManager.SendEvent(events.ServerReady, nil)
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/network_binding"
+	"seanime/internal/torrent_clients/torrent_client"
+	"slices"
+	"sync"
+)
+
+const networkBindingClientTorrentClient = "torrent-client"
+
+func networkBindingSettings(settings *models.TorrentSettings) network_binding.Settings {
+	return network_binding.Settings{
+		Interface:   settings.BindInterface,
+		Address:     settings.BindAddress,
+		Socks5Proxy: settings.BindSocks5Proxy,
+		KillSwitch:  settings.BindKillSwitch,
+	}
+}
+
+// refreshNetworkBinding updates the network binding settings and applies them to the torrent clients.
+// The embedded torrent client is re-created when the binding changes.
+// The external torrent client is only configured when a binding is set, so that its own configuration is not overwritten.
+func (a *App) refreshNetworkBinding(settings *models.TorrentSettings) {
+	prev := a.NetworkBinding.GetSettings()
+	next := networkBindingSettings(settings)
+
+	if a.TorrentClientRepository != nil {
+		a.NetworkBinding.SetClient(networkBindingClientTorrentClient, &torrentClientKillSwitchClient{repository: a.TorrentClientRepository})
+	}
+	a.NetworkBinding.SetSettings(next)
+
+	if a.TorrentClientRepository != nil && next.IsBound() {
+		go func() {
+			err := a.TorrentClientRepository.SetNetworkBinding(&torrent_client.NetworkBinding{
+				Interface: next.Interface,
+				Address:   next.Address,
+			})
+			if errors.Is(err, torrent_client.ErrNetworkBindingNotSupported) {
+				a.Logger.Warn().Msg("app: The torrent client's network interface must be configured in the client, only the kill switch applies")
+			} else if err != nil {
+				a.Logger.Error().Err(err).Msg("app: Failed to set the torrent client's network interface")
+			}
+		}()
+	}
+
+	if prev != next && a.SecondarySettings.Torrentstream != nil && a.SecondarySettings.Torrentstream.Enabled {
+		go a.InitOrRefreshTorrentstreamSettings()
+	}
+}
+
+// torrentClientKillSwitchClient pauses the active torrents of the external torrent client.
+type torrentClientKillSwitchClient struct {
+	repository *torrent_client.Repository
+	mu         sync.Mutex
+	paused     []string
+}
+
+func (c *torrentClientKillSwitchClient) PauseAll() error {
+	hashes, err := c.repository.PauseActiveTorrents()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, hash := range hashes {
+		if !slices.Contains(c.paused, hash) {
+			c.paused = append(c.paused, hash)
+		}
+	}
+	return nil
+}
+
+func (c *torrentClientKillSwitchClient) ResumeAll() error {
+	c.mu.Lock()
+	hashes := c.paused
+	c.paused = nil
+	c.mu.Unlock()
+
+	if len(hashes) == 0 {
+		return nil
+	}
+	return c.repository.ResumeTorrents(hashes)
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/library/playbackmanager"
+	"seanime/internal/torrent_clients/playback_priority"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/torrentstream"
+	"time"
+)
+
+const (
+	playbackPrioritySessionID = "playback-manager"
+
+	seedingClientTorrentClient = "torrent-client"
+	seedingClientTorrentstream = "torrentstream"
+)
+
+// refreshPlaybackPriority updates the playback priority settings and torrent client.
+func (a *App) refreshPlaybackPriority(settings *models.TorrentSettings) {
+	if a.TorrentClientRepository != nil {
+		a.PlaybackPriority.SetTorrentClient(a.TorrentClientRepository)
+	}
+	a.PlaybackPriority.SetSettings(playback_priority.Settings{
+		Enabled:           settings.PlaybackPriority,
+		DownloadLimit:     settings.PlaybackPriorityDownloadLimit * 1024,
+		MinActiveTorrents: settings.PlaybackPriorityMinActiveTorrents,
+		SameDevice:        settings.PlaybackPrioritySameDevice,
+		RestoreDelay:      time.Duration(settings.PlaybackPriorityRestoreDelay) * time.Second,
+	})
+
+	if a.TorrentClientRepository != nil {
+		a.SeedingPause.SetClient(seedingClientTorrentClient, &torrentClientSeedingClient{a.TorrentClientRepository})
+	}
+	a.SeedingPause.SetSettings(playback_priority.SeedingSettings{
+		Enabled:       settings.SeedingPause,
+		LanRanges:     settings.SeedingPauseLanRanges,
+		GracePeriod:   time.Duration(settings.SeedingPauseGracePeriod) * time.Second,
+		ProtectedTags: settings.SeedingPauseProtectedTags,
+	})
+}
+
+// listenToPlaybackForPlaybackPriority registers the playback sessions of the external media players.
+// Sessions of the built-in player are registered by the direct stream manager.
+func (a *App) listenToPlaybackForPlaybackPriority() {
+	subscriber := a.PlaybackManager.SubscribeToPlaybackStatus("playback-priority")
+
+	go func() {
+		for event := range subscriber.EventCh {
+			switch e := event.(type) {
+			case playbackmanager.VideoStartedEvent:
+				a.PlaybackPriority.StartSession(playbackPrioritySessionID, "playback", e.Filepath)
+				a.PlaybackPriority.SetSessionIncognito(playbackPrioritySessionID, e.Incognito)
+			case playbackmanager.StreamStartedEvent:
+				a.PlaybackPriority.StartSession(playbackPrioritySessionID, "stream", e.Filepath)
+				a.PlaybackPriority.SetSessionIncognito(playbackPrioritySessionID, e.Incognito)
+			case playbackmanager.IncognitoChangedEvent:
+				a.PlaybackPriority.SetSessionIncognito(playbackPrioritySessionID, e.Incognito)
+			case playbackmanager.VideoStoppedEvent, playbackmanager.StreamStoppedEvent, playbackmanager.PlaybackErrorEvent:
+				a.PlaybackPriority.EndSession(playbackPrioritySessionID)
+			}
+		}
+	}()
+}
+
+// torrentClientSeedingClient pauses the seeding torrents of the external torrent client.
+type torrentClientSeedingClient struct {
+	repository *torrent_client.Repository
+}
+
+func (c *torrentClientSeedingClient) GetSeedingTorrents() ([]*playback_priority.SeedingTorrent, error) {
+	torrents, err := c.repository.GetSeedingTorrents()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*playback_priority.SeedingTorrent, 0, len(torrents))
+	for _, t := range torrents {
+		ret = append(ret, &playback_priority.SeedingTorrent{Hash: t.Hash, Name: t.Name, Tags: t.Tags})
+	}
+	return ret, nil
+}
+
+func (c *torrentClientSeedingClient) PauseSeeding(hashes []string) error {
+	return c.repository.PauseTorrents(hashes)
+}
+
+func (c *torrentClientSeedingClient) ResumeSeeding(hashes []string) error {
+	return c.repository.ResumeTorrents(hashes)
+}
+
+// torrentstreamSeedingClient stops the uploads of the completed torrents of the embedded torrent client.
+type torrentstreamSeedingClient struct {
+	repository *torrentstream.Repository
+}
+
+func (c *torrentstreamSeedingClient) GetSeedingTorrents() ([]*playback_priority.SeedingTorrent, error) {
+	torrents := c.repository.GetSeedingTorrents()
+	ret := make([]*playback_priority.SeedingTorrent, 0, len(torrents))
+	for _, t := range torrents {
+		ret = append(ret, &playback_priority.SeedingTorrent{Hash: t.InfoHash().HexString(), Name: t.Name()})
+	}
+	return ret, nil
+}
+
+func (c *torrentstreamSeedingClient) PauseSeeding(hashes []string) error {
+	return c.repository.PauseSeeding(hashes)
+}
+
+func (c *torrentstreamSeedingClient) ResumeSeeding(hashes []string) error {
+	return c.repository.ResumeSeeding(hashes)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queuedDownloadWatchInterval = 30 * time.Second
+
+// startQueuedDownloadWatcher periodically removes the AutoDownloader queue items whose torrent has started.
+// Items whose torrent failed to start, e.g. because the disk is full, are kept so that they can be downloaded again.
+func (a *App) startQueuedDownloadWatcher() {
+	go func() {
+		defer util.HandlePanicInModuleThen("core/startQueuedDownloadWatcher", func() {})
+
+		ticker := time.NewTicker(queuedDownloadWatchInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.checkQueuedDownloads()
+		}
+	}()
+}
+
+// checkQueuedDownloads removes the queue items linked to a torrent history entry once the torrent is downloading or seeding.
+func (a *App) checkQueuedDownloads() {
+	if a.TorrentClientRepository == nil {
+		return
+	}
+	entries, err := a.Database.GetQueuedTorrentResults()
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	torrents, err := a.TorrentClientRepository.GetList()
+	if err != nil {
+		return
+	}
+
+	statuses := make(map[string]torrent_client.TorrentStatus, len(torrents))
+	for _, t := range torrents {
+		statuses[strings.ToLower(t.Hash)] = t.Status
+	}
+
+	for _, entry := range entries {
+		// The item might have been removed from the queue by the user
+		if _, err := a.Database.GetAutoDownloaderItem(entry.QueuedItemId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				_ = a.Database.SetTorrentResultQueuedItem(entry.ID, 0)
+			}
+			continue
+		}
+		status, found := statuses[entry.Key]
+		if !found || (status != torrent_client.TorrentStatusDownloading && status != torrent_client.TorrentStatusSeeding) {
+			continue
+		}
+		if err := a.Database.DeleteAutoDownloaderItem(entry.QueuedItemId); err != nil {
+			a.Logger.Error().Err(err).Uint("id", entry.QueuedItemId).Msg("app: Failed to remove the queued item of a started torrent")
+			continue
+		}
+		_ = a.Database.SetTorrentResultQueuedItem(entry.ID, 0)
+		a.Logger.Debug().Uint("id", entry.QueuedItemId).Str("name", entry.Name).Msg("app: Removed the queued item of a started torrent")
+	}
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ModuleState string
+
+const (
+	ModuleStatePending ModuleState = "pending"
+	ModuleStateReady   ModuleState = "ready"
+	ModuleStateFailed  ModuleState = "failed"
+)
+
+// Modules whose initialization is tracked.
+const (
+	// ModuleSettings holds the modules initialized by App.InitOrRefreshModules (torrent client, media players...)
+	ModuleSettings      = "settings"
+	ModuleAnilist       = "anilist"
+	ModuleMediastream   = "mediastream"
+	ModuleTorrentstream = "torrentstream"
+	ModuleDebrid        = "debrid"
+	ModuleExtensions    = "extensions"
+)
+
+// ErrModuleInitializing is returned when a module is used before it is ready.
+var ErrModuleInitializing = errors.New("module is initializing")
+
+type (
+	// ModuleReadiness keeps track of the initialization state of the modules.
+	// Modules are initialized in the background so that the server can serve requests right away.
+	ModuleReadiness struct {
+		mu      sync.RWMutex
+		modules map[string]*ModuleStatus
+		// runMus serializes the initializations of the same module
+		runMus    map[string]*sync.Mutex
+		startedAt time.Time
+	}
+
+	ModuleStatus struct {
+		Name  string      `json:"name"`
+		State ModuleState `json:"state"`
+		// Error is set when the state is "failed"
+		Error string `json:"error,omitempty"`
+		// Duration is the time the last initialization took, in milliseconds
+		Duration  int64     `json:"duration"`
+		UpdatedAt time.Time `json:"updatedAt"`
+
+		pendingSince time.Time
+	}
+
+	ReadinessStatus struct {
+		// State is "pending" if a module is still initializing, "failed" if a module failed, "ready" otherwise
+		State   ModuleState     `json:"state"`
+		Modules []*ModuleStatus `json:"modules"`
+		// Uptime is the time since the app was created, in milliseconds
+		Uptime int64 `json:"uptime"`
+	}
+)
+
+func NewModuleReadiness() *ModuleReadiness {
+	return &ModuleReadiness{
+		modules:   make(map[string]*ModuleStatus),
+		runMus:    make(map[string]*sync.Mutex),
+		startedAt: time.Now(),
+	}
+}
+
+// SetPending marks the module as initializing.
+func (r *ModuleReadiness) SetPending(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.modules[name] = &ModuleStatus{
+		Name:         name,
+		State:        ModuleStatePending,
+		UpdatedAt:    now,
+		pendingSince: now,
+	}
+}
+
+// SetDone marks the module as ready, or as failed if err is not nil.
+func (r *ModuleReadiness) SetDone(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	status, ok := r.modules[name]
+	if !ok {
+		status = &ModuleStatus{Name: name, pendingSince: now}
+		r.modules[name] = status
+	}
+
+	status.State = ModuleStateReady
+	status.Error = ""
+	if err != nil {
+		status.State = ModuleStateFailed
+		status.Error = err.Error()
+	}
+	status.Duration = now.Sub(status.pendingSince).Milliseconds()
+	status.UpdatedAt = now
+}
+
+// Run initializes the module with f and updates its state.
+// Calls for the same module are serialized.
+func (r *ModuleReadiness) Run(name string, f func() error) (err error) {
+	r.mu.Lock()
+	runMu, ok := r.runMus[name]
+	if !ok {
+		runMu = &sync.Mutex{}
+		r.runMus[name] = runMu
+	}
+	r.mu.Unlock()
+
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	r.SetPending(name)
+	defer func() {
+		r.SetDone(name, err)
+	}()
+	defer util.HandlePanicInModuleWithError("core/ModuleReadiness/"+name, &err)
+
+	return f()
+}
+
+// Get returns the state of the module.
+// Modules that are not tracked are considered ready.
+func (r *ModuleReadiness) Get(name string) (ModuleState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status, ok := r.modules[name]
+	if !ok {
+		return ModuleStateReady, nil
+	}
+	if status.State == ModuleStateFailed {
+		return status.State, errors.New(status.Error)
+	}
+	return status.State, nil
+}
+
+// IsPending returns true if the module is being initialized.
+func (r *ModuleReadiness) IsPending(name string) bool {
+	state, _ := r.Get(name)
+	return state == ModuleStatePending
+}
+
+// CheckReady returns an error wrapping ErrModuleInitializing if the module is being initialized.
+func (r *ModuleReadiness) CheckReady(name string) error {
+	if r.IsPending(name) {
+		return fmt.Errorf("%s %w", name, ErrModuleInitializing)
+	}
+	return nil
+}
+
+func (r *ModuleReadiness) GetStatus() *ReadinessStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ret := &ReadinessStatus{
+		State:   ModuleStateReady,
+		Modules: make([]*ModuleStatus, 0, len(r.modules)),
+		Uptime:  time.Since(r.startedAt).Milliseconds(),
+	}
+
+	for _, status := range r.modules {
+		s := *status
+		ret.Modules = append(ret.Modules, &s)
+		switch status.State {
+		case ModuleStatePending:
+			ret.State = ModuleStatePending
+		case ModuleStateFailed:
+			if ret.State != ModuleStatePending {
+				ret.State = ModuleStateFailed
+			}
+		}
+	}
+
+	sort.Slice(ret.Modules, func(i, j int) bool {
+		return ret.Modules[i].Name < ret.Modules[j].Name
+	})
+
+	return ret
+}
+
+// initModulesInBackground initializes the modules that depend on settings concurrently.
+// Handlers that depend on a module that is not ready respond with ErrModuleInitializing.
+func (a *App) initModulesInBackground() {
+	start := time.Now()
+
+	a.Readiness.SetPending(ModuleSettings)
+	a.Readiness.SetPending(ModuleMediastream)
+	a.Readiness.SetPending(ModuleTorrentstream)
+	a.Readiness.SetPending(ModuleDebrid)
+	if !a.IsOffline() {
+		a.Readiness.SetPending(ModuleAnilist)
+	}
+
+	wg := sync.WaitGroup{}
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+
+	run(func() {
+		a.InitOrRefreshModules()
+
+		// Fetch Anilist data once the settings (e.g. the cache layer) are applied
+		if !a.IsOffline() {
+			a.InitOrRefreshAnilistData()
+		} else {
+			a.ServerReady = true
+		}
+	})
+	run(a.InitOrRefreshMediastreamSettings)
+	run(a.InitOrRefreshTorrentstreamSettings)
+	run(a.InitOrRefreshDebridSettings)
+
+	go func() {
+		wg.Wait()
+		a.Logger.Info().Dur("took", time.Since(start)).Msg("app: Initialized modules")
+
+		// Run one-time initialization actions
+		a.performActionsOnce()
+	}()
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleReadiness(t *testing.T) {
+	r := NewModuleReadiness()
+
+	// Untracked modules are ready
+	assert.NoError(t, r.CheckReady(ModuleDebrid))
+	assert.Equal(t, ModuleStateReady, r.GetStatus().State)
+
+	r.SetPending(ModuleDebrid)
+	assert.ErrorIs(t, r.CheckReady(ModuleDebrid), ErrModuleInitializing)
+	assert.Equal(t, ModuleStatePending, r.GetStatus().State)
+
+	err := r.Run(ModuleDebrid, func() error {
+		assert.True(t, r.IsPending(ModuleDebrid))
+		return errors.New("invalid api key")
+	})
+	require.Error(t, err)
+
+	state, err := r.Get(ModuleDebrid)
+	assert.Equal(t, ModuleStateFailed, state)
+	assert.EqualError(t, err, "invalid api key")
+	// Failed modules do not block requests
+	assert.NoError(t, r.CheckReady(ModuleDebrid))
+
+	status := r.GetStatus()
+	assert.Equal(t, ModuleStateFailed, status.State)
+	require.Len(t, status.Modules, 1)
+	assert.Equal(t, "invalid api key", status.Modules[0].Error)
+
+	require.NoError(t, r.Run(ModuleDebrid, func() error { return nil }))
+	assert.Equal(t, ModuleStateReady, r.GetStatus().State)
+}
+
+func TestModuleReadiness_RunRecoversPanic(t *testing.T) {
+	r := NewModuleReadiness()
+
+	err := r.Run(ModuleMediastream, func() error {
+		panic("boom")
+	})
+	assert.Error(t, err)
+
+	state, _ := r.Get(ModuleMediastream)
+	assert.Equal(t, ModuleStateFailed, state)
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"seanime/internal/api/anilist"
+	"seanime/internal/events"
+	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util"
+	"sync"
+)
+
+// sessionPlatforms holds the AniList platforms of the sessions that are logged into a different account than the main user.
+// Each platform has its own client and collection cache, so that sessions do not see each other's lists.
+type sessionPlatforms struct {
+	mu        sync.Mutex
+	platforms map[string]*sessionPlatform
+	// newPlatform creates the platform of a session
+	newPlatform func(client anilist.AnilistClient, username string) platform.Platform
+}
+
+type sessionPlatform struct {
+	// token is the token the platform was created with, the platform is replaced when the session logs into another account
+	token    string
+	platform platform.Platform
+}
+
+func newSessionPlatforms(newPlatform func(client anilist.AnilistClient, username string) platform.Platform) *sessionPlatforms {
+	return &sessionPlatforms{
+		platforms:   make(map[string]*sessionPlatform),
+		newPlatform: newPlatform,
+	}
+}
+
+// newAnilistSessionPlatform creates an AniList platform for a session.
+func (a *App) newAnilistSessionPlatform(client anilist.AnilistClient, username string) platform.Platform {
+	ret := anilist_platform.NewAnilistPlatform(util.NewRef(client), a.ExtensionBankRef, a.Logger, a.Database)
+	ret.SetUsername(username)
+	return ret
+}
+
+// get returns the platform of a session, creating it if the session has none or logged into another account.
+func (sp *sessionPlatforms) get(sessionID string, token string, client func() anilist.AnilistClient, username string) platform.Platform {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if p, ok := sp.platforms[sessionID]; ok {
+		if p.token == token {
+			return p.platform
+		}
+		p.platform.Close()
+	}
+
+	p := &sessionPlatform{
+		token:    token,
+		platform: sp.newPlatform(client(), username),
+	}
+	sp.platforms[sessionID] = p
+	return p.platform
+}
+
+// remove closes and removes the platform of a session.
+func (sp *sessionPlatforms) remove(sessionID string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if p, ok := sp.platforms[sessionID]; ok {
+		p.platform.Close()
+		delete(sp.platforms, sessionID)
+	}
+}
+
+// reconcileProgress updates the cached collection of a session after a progress update.
+// The platform is removed if it cannot update its collection, it is recreated with a fresh collection when it is needed.
+func (sp *sessionPlatforms) reconcileProgress(sessionID string, mediaID int, progress int, status *anilist.MediaListStatus) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	p, ok := sp.platforms[sessionID]
+	if !ok {
+		return
+	}
+	if reconciler, ok := p.platform.(platform.EntryProgressReconciler); ok && reconciler.ReconcileAnimeEntryProgress(mediaID, progress, status) {
+		return
+	}
+	p.platform.Close()
+	delete(sp.platforms, sessionID)
+}
+
+// prune removes the platforms of the sessions that no longer exist.
+func (sp *sessionPlatforms) prune(exists func(sessionID string) bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for sessionID, p := range sp.platforms {
+		if !exists(sessionID) {
+			p.platform.Close()
+			delete(sp.platforms, sessionID)
+		}
+	}
+}
+
+// onSessionExpired is called when a stale session is removed by the session store.
+// It closes the platform of the session and tells its connected clients so that they can ask the user to log in again.
+func (a *App) onSessionExpired(sessionID string) {
+	if a.sessionPlatforms != nil {
+		a.sessionPlatforms.remove(sessionID)
+	}
+
+	for _, clientID := range a.SessionStore.GetClientIDs(sessionID) {
+		a.WSEventManager.SendEventTo(clientID, events.SessionExpired, sessionID, true)
+	}
+}
+
+// RevokeSession removes a session, the browser using it gets a new simulated session on its next request.
+func (a *App) RevokeSession(sessionID string) {
+	a.SessionStore.DeleteSession(sessionID)
+	a.onSessionExpired(sessionID)
+}
+
+// getPlatformForSession returns the platform to use for a session.
+// It returns nil if the global platform should be used, i.e. when the session is simulated, not authenticated,
+// logged into the same account as the main user, or when the app is offline.
+func (a *App) getPlatformForSession(sessionID string) platform.Platform {
+	if sessionID == "" || a.SessionStore == nil || a.sessionPlatforms == nil || a.IsOffline() {
+		return nil
+	}
+
+	// Avoid creating a session that does not exist yet
+	if !a.SessionStore.Exists(sessionID) {
+		a.sessionPlatforms.remove(sessionID)
+		return nil
+	}
+
+	sess := a.SessionStore.GetSession(sessionID)
+	token := sess.GetToken()
+	if sess.IsSimulated || token == "" || sess.Username == "" || token == a.GetUserAnilistToken() {
+		a.sessionPlatforms.remove(sessionID)
+		return nil
+	}
+
+	a.sessionPlatforms.prune(a.SessionStore.Exists)
+
+	return a.sessionPlatforms.get(sessionID, token, func() anilist.AnilistClient {
+		return a.SessionStore.GetAnilistClient(sessionID)
+	}, sess.Username)
+}
+
+// UsesSessionPlatform returns true if the AniList lists of a session are fetched with its own account instead of the main user's.
+func (a *App) UsesSessionPlatform(sessionID string) bool {
+	return a.getPlatformForSession(sessionID) != nil
+}
+
+// GetAnimeCollectionForSession returns the anime collection of the AniList account a session is logged into.
+// Simulated and unauthenticated sessions, and sessions logged into the main user's account, get the collection of the global platform.
+func (a *App) GetAnimeCollectionForSession(ctx context.Context, sessionID string, bypassCache bool) (*anilist.AnimeCollection, error) {
+	p := a.getPlatformForSession(sessionID)
+	if p == nil {
+		if bypassCache {
+			return a.RefreshAnimeCollection()
+		}
+		return a.GetAnimeCollection(platform.CachedCollection)
+	}
+
+	ctx, cancel := a.SessionStore.WithContext(ctx, sessionID)
+	defer cancel()
+
+	if bypassCache {
+		return p.RefreshAnimeCollection(ctx)
+	}
+	return p.GetAnimeCollection(ctx, platform.CachedCollection)
+}
+
+// GetRawAnimeCollectionForSession is the same as GetAnimeCollectionForSession but returns the raw collection that includes custom lists.
+func (a *App) GetRawAnimeCollectionForSession(ctx context.Context, sessionID string, bypassCache bool) (*anilist.AnimeCollection, error) {
+	p := a.getPlatformForSession(sessionID)
+	if p == nil {
+		return a.GetRawAnimeCollection(bypassCache)
+	}
+
+	ctx, cancel := a.SessionStore.WithContext(ctx, sessionID)
+	defer cancel()
+
+	return p.GetRawAnimeCollection(ctx, bypassCache)
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"seanime/internal/api/anilist"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/session"
+	"seanime/internal/user"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCollectionPlatform returns a collection containing a single entry identifying the account
+type fakeCollectionPlatform struct {
+	platform.Platform
+	mediaID int
+	fetches int
+	closed  bool
+}
+
+func (p *fakeCollectionPlatform) collection() *anilist.AnimeCollection {
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{
+				Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{{Media: &anilist.BaseAnime{ID: p.mediaID}}},
+			}},
+		},
+	}
+}
+
+func (p *fakeCollectionPlatform) GetAnimeCollection(ctx context.Context, maxStaleness time.Duration) (*anilist.AnimeCollection, error) {
+	p.fetches++
+	return p.collection(), nil
+}
+
+func (p *fakeCollectionPlatform) RefreshAnimeCollection(ctx context.Context) (*anilist.AnimeCollection, error) {
+	p.fetches++
+	return p.collection(), nil
+}
+
+func (p *fakeCollectionPlatform) Close() {
+	p.closed = true
+}
+
+func getCollectionMediaID(t *testing.T, collection *anilist.AnimeCollection) int {
+	require.NotNil(t, collection)
+	return collection.GetMediaListCollection().GetLists()[0].GetEntries()[0].GetMedia().GetID()
+}
+
+func TestApp_GetAnimeCollectionForSession(t *testing.T) {
+	mediaIDs := map[string]int{"alice": 1, "bob": 2}
+	created := make(map[string]*fakeCollectionPlatform)
+
+	a := &App{
+		user:               &user.User{Token: "main-token"},
+		isOfflineRef:       util.NewRef(false),
+		AnilistPlatformRef: util.NewRef[platform.Platform](&fakeCollectionPlatform{mediaID: 100}),
+		SessionStore:       session.NewStore(t.TempDir(), time.Hour),
+	}
+	a.sessionPlatforms = newSessionPlatforms(func(client anilist.AnilistClient, username string) platform.Platform {
+		p := &fakeCollectionPlatform{mediaID: mediaIDs[username]}
+		created[username] = p
+		return p
+	})
+
+	a.SessionStore.Login("session-a", "token-a", "alice")
+	a.SessionStore.Login("session-b", "token-b", "bob")
+	a.SessionStore.Login("session-main", "main-token", "main")
+	a.SessionStore.GetSession("session-simulated")
+
+	ctx := context.Background()
+
+	// Each authenticated session sees its own list
+	collection, err := a.GetAnimeCollectionForSession(ctx, "session-a", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, getCollectionMediaID(t, collection))
+
+	collection, err = a.GetAnimeCollectionForSession(ctx, "session-b", true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, getCollectionMediaID(t, collection))
+
+	// The platform of a session is reused
+	_, err = a.GetAnimeCollectionForSession(ctx, "session-a", false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created["alice"].fetches)
+	assert.True(t, a.UsesSessionPlatform("session-a"))
+
+	// Simulated, unknown and main user sessions use the global platform
+	for _, sessionID := range []string{"session-simulated", "session-main", "unknown", ""} {
+		collection, err = a.GetAnimeCollectionForSession(ctx, sessionID, false)
+		require.NoError(t, err)
+		assert.Equal(t, 100, getCollectionMediaID(t, collection), sessionID)
+		assert.False(t, a.UsesSessionPlatform(sessionID), sessionID)
+	}
+	assert.False(t, a.SessionStore.Exists("unknown"))
+
+	// Logging into another account replaces the platform
+	mediaIDs["carol"] = 3
+	a.SessionStore.Login("session-a", "token-c", "carol")
+	collection, err = a.GetAnimeCollectionForSession(ctx, "session-a", false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, getCollectionMediaID(t, collection))
+	assert.True(t, created["alice"].closed)
+
+	// Logging out falls back to the global platform
+	a.SessionStore.Logout("session-b")
+	collection, err = a.GetAnimeCollectionForSession(ctx, "session-b", false)
+	require.NoError(t, err)
+	assert.Equal(t, 100, getCollectionMediaID(t, collection))
+	assert.True(t, created["bob"].closed)
+
+	// The platforms of deleted sessions are closed
+	a.SessionStore.DeleteSession("session-a")
+	a.SessionStore.Login("session-d", "token-d", "bob")
+	_, err = a.GetAnimeCollectionForSession(ctx, "session-d", false)
+	require.NoError(t, err)
+	assert.True(t, created["carol"].closed)
+}
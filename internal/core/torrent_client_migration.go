@@ -0,0 +1,59 @@
+package core
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/qbittorrent"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/torrent_clients/transmission"
+)
+
+// NewTorrentClientRepository returns a repository for the torrent client selected in the settings, without replacing the current one.
+// It is used to contact the target client of a migration.
+func (a *App) NewTorrentClientRepository(settings *models.TorrentSettings) *torrent_client.Repository {
+	qbit := qbittorrent.NewClient(&qbittorrent.NewClientOptions{
+		Logger:        a.Logger,
+		Username:      settings.QBittorrentUsername,
+		Password:      settings.QBittorrentPassword,
+		Port:          settings.QBittorrentPort,
+		Host:          settings.QBittorrentHost,
+		Path:          settings.QBittorrentPath,
+		Tags:          settings.QBittorrentTags,
+		CustomHeaders: settings.CustomHeaders,
+	})
+	if settings.Default == torrent_client.QbittorrentClient {
+		if err := qbit.Login(); err != nil {
+			a.Logger.Debug().Err(err).Msg("app: Failed to login to qBittorrent")
+		}
+	}
+
+	trans, err := transmission.New(&transmission.NewTransmissionOptions{
+		Logger:        a.Logger,
+		Username:      settings.TransmissionUsername,
+		Password:      settings.TransmissionPassword,
+		Port:          settings.TransmissionPort,
+		Host:          settings.TransmissionHost,
+		Path:          settings.TransmissionPath,
+		CustomHeaders: settings.CustomHeaders,
+	})
+	if err != nil {
+		a.Logger.Debug().Err(err).Msg("app: Failed to initialize transmission client")
+	}
+
+	pathStyle, pathMappings := settings.QBittorrentPathStyle, settings.QBittorrentPathMappings
+	if settings.Default == torrent_client.TransmissionClient {
+		pathStyle, pathMappings = settings.TransmissionPathStyle, settings.TransmissionPathMappings
+	}
+
+	return torrent_client.NewRepository(&torrent_client.NewRepositoryOptions{
+		Logger:                a.Logger,
+		QbittorrentClient:     qbit,
+		Transmission:          trans,
+		TorrentRepository:     a.TorrentRepository,
+		Provider:              settings.Default,
+		MetadataProviderRef:   a.MetadataProviderRef,
+		IncompleteDirOverride: settings.IncompleteDirOverride,
+		PathStyle:             clientpath.Style(pathStyle),
+		PathMappings:          clientpath.MappingsFromMap(pathMappings),
+	})
+}
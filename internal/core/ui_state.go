@@ -0,0 +1,60 @@
+package core
+
+import (
+	"seanime/internal/events"
+	"seanime/internal/uistate"
+	"seanime/internal/util"
+	"time"
+)
+
+const uiStatePruneInterval = 1 * time.Hour
+
+// GetUIStateOwner returns the owner of the UI state for a session.
+// Authenticated sessions share the state of their AniList user, simulated sessions have their own state.
+func (a *App) GetUIStateOwner(sessionID string) uistate.Owner {
+	if a.SessionStore != nil && sessionID != "" {
+		sess := a.SessionStore.GetSession(sessionID)
+		if sess != nil && !sess.IsSimulated && sess.Username != "" {
+			return uistate.UserOwner(sess.Username)
+		}
+		return uistate.SessionOwner(sessionID)
+	}
+	if u := a.GetUser(); !u.IsSimulated && u.Viewer != nil {
+		return uistate.UserOwner(u.Viewer.Name)
+	}
+	return uistate.SessionOwner(sessionID)
+}
+
+// BroadcastUIStateChange sends the change to the connected clients of the owner, except the client that made it.
+func (a *App) BroadcastUIStateChange(owner uistate.Owner, originClientID string, change *uistate.Change) {
+	if a.SessionStore == nil {
+		return
+	}
+
+	var sessionIDs []string
+	if owner.Persistent {
+		sessionIDs = a.SessionStore.GetSessionIDsForUser(owner.Username())
+	} else {
+		sessionIDs = []string{owner.SessionID}
+	}
+
+	for _, clientID := range a.SessionStore.GetClientIDs(sessionIDs...) {
+		if clientID == originClientID {
+			continue
+		}
+		a.WSEventManager.SendEventTo(clientID, events.UIStateChanged, change, true)
+	}
+}
+
+// startUIStatePruning periodically removes the UI state of expired sessions and inactive users.
+func (a *App) startUIStatePruning() {
+	go func() {
+		defer util.HandlePanicInModuleThen("core/startUIStatePruning", func() {})
+
+		ticker := time.NewTicker(uiStatePruneInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.UIStateStore.Prune(a.SessionStore.Exists)
+		}
+	}()
+}
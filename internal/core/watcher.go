@@ -21,7 +21,8 @@ func (a *App) initLibraryWatcher(paths []string) {
 
 	// Initialize library file watcher
 	err = watcher.InitLibraryFileWatcher(&scanner.WatchLibraryFilesOptions{
-		LibraryPaths: paths,
+		LibraryPaths:  paths,
+		ExcludedPaths: a.GetScannerExcludedPaths(),
 	})
 	if err != nil {
 		a.Logger.Error().Err(err).Msg("app: Failed to watch library files")
@@ -57,3 +58,12 @@ func (a *App) initLibraryWatcher(paths []string) {
 		})
 
 }
+
+// GetScannerExcludedPaths returns the directories that should not be scanned or watched.
+//   - The torrent client's incomplete downloads directory
+func (a *App) GetScannerExcludedPaths() []string {
+	if a.TorrentClientRepository == nil {
+		return nil
+	}
+	return a.TorrentClientRepository.GetExcludedPaths()
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/playbackmanager"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"seanime/internal/webhooks"
+	"time"
+)
+
+const webhookWatchInterval = time.Minute
+
+// listenToPlaybackForWebhooks dispatches the playback events of the external media players.
+// The started event is dispatched with the first status update of the playback, once its media is known.
+func (a *App) listenToPlaybackForWebhooks() {
+	subscriber := a.PlaybackManager.SubscribeToPlaybackStatus("webhooks")
+
+	go func() {
+		var pending *webhooks.PlaybackStartedPayload // Started, waiting for the media details
+		var current *webhooks.PlaybackStartedPayload // Started and dispatched
+
+		stop := func(reason string) {
+			if pending != nil {
+				a.Webhooks.Dispatch(webhooks.EventPlaybackStarted, pending)
+				current, pending = pending, nil
+			}
+			if current == nil {
+				return
+			}
+			a.Webhooks.Dispatch(webhooks.EventPlaybackStopped, &webhooks.PlaybackStoppedPayload{
+				Kind:          current.Kind,
+				Filename:      current.Filename,
+				MediaId:       current.MediaId,
+				MediaTitle:    current.MediaTitle,
+				EpisodeNumber: current.EpisodeNumber,
+				Reason:        reason,
+			})
+			current = nil
+		}
+
+		for event := range subscriber.EventCh {
+			switch e := event.(type) {
+			case playbackmanager.VideoStartedEvent:
+				stop("")
+				pending = &webhooks.PlaybackStartedPayload{Kind: "local", Filename: e.Filename, Incognito: e.Incognito}
+			case playbackmanager.StreamStartedEvent:
+				stop("")
+				pending = &webhooks.PlaybackStartedPayload{Kind: "stream", Filename: e.Filename, Incognito: e.Incognito}
+			case playbackmanager.PlaybackStatusChangedEvent:
+				if pending == nil {
+					continue
+				}
+				if pending.Incognito {
+					a.Webhooks.Dispatch(webhooks.EventPlaybackStarted, pending)
+					current, pending = pending, nil
+					continue
+				}
+				pending.MediaId = e.State.MediaId
+				pending.MediaTitle = e.State.MediaTitle
+				pending.EpisodeNumber = e.State.EpisodeNumber
+				a.Webhooks.Dispatch(webhooks.EventPlaybackStarted, pending)
+				current, pending = pending, nil
+			case playbackmanager.VideoStoppedEvent:
+				stop(e.Reason)
+			case playbackmanager.StreamStoppedEvent:
+				stop(e.Reason)
+			case playbackmanager.PlaybackErrorEvent:
+				stop(e.Reason)
+			}
+		}
+	}()
+}
+
+// DispatchScanFinishedWebhook dispatches the scan.finished event, automatic is true for scans started by the auto scanner.
+func (a *App) DispatchScanFinishedWebhook(lfs []*anime.LocalFile, automatic bool) {
+	if a.Webhooks == nil {
+		return
+	}
+	unmatched := 0
+	for _, lf := range lfs {
+		if lf.MediaId == 0 {
+			unmatched++
+		}
+	}
+	a.Webhooks.Dispatch(webhooks.EventScanFinished, &webhooks.ScanFinishedPayload{
+		Automatic:      automatic,
+		TotalFiles:     len(lfs),
+		UnmatchedFiles: unmatched,
+	})
+}
+
+// startWebhookWatchers periodically looks for the completed downloads and the aired episodes.
+// Nothing is checked while no subscription listens to these events.
+func (a *App) startWebhookWatchers() {
+	go func() {
+		defer util.HandlePanicInModuleThen("core/startWebhookWatchers", func() {})
+
+		startedAt := time.Now()
+		// Hashes of the torrents that were downloading the last time the client was checked
+		downloading := make(map[string]struct{})
+		// Episodes for which the aired event was dispatched
+		aired := make(map[string]struct{})
+
+		ticker := time.NewTicker(webhookWatchInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if a.Webhooks.HasSubscribers(webhooks.EventDownloadCompleted) {
+				a.checkCompletedDownloads(downloading)
+			} else {
+				clear(downloading)
+			}
+			if a.Webhooks.HasSubscribers(webhooks.EventEpisodeAired) {
+				a.checkAiredEpisodes(aired, startedAt)
+			}
+		}
+	}()
+}
+
+// checkCompletedDownloads dispatches the download.completed event for the torrents that were downloading and are now complete.
+func (a *App) checkCompletedDownloads(downloading map[string]struct{}) {
+	if a.TorrentClientRepository == nil {
+		return
+	}
+	torrents, err := a.TorrentClientRepository.GetList()
+	if err != nil {
+		return
+	}
+
+	present := make(map[string]struct{}, len(torrents))
+	for _, t := range torrents {
+		present[t.Hash] = struct{}{}
+		if t.Status == torrent_client.TorrentStatusDownloading || t.Progress < 1 {
+			downloading[t.Hash] = struct{}{}
+			continue
+		}
+		if _, found := downloading[t.Hash]; found {
+			delete(downloading, t.Hash)
+			a.Webhooks.Dispatch(webhooks.EventDownloadCompleted, &webhooks.DownloadCompletedPayload{
+				Name:        t.Name,
+				Hash:        t.Hash,
+				ContentPath: t.ContentPath,
+			})
+		}
+	}
+
+	// Forget the torrents that were removed from the client
+	for hash := range downloading {
+		if _, found := present[hash]; !found {
+			delete(downloading, hash)
+		}
+	}
+}
+
+// checkAiredEpisodes dispatches the episode.aired event for the next episodes of the collection that have aired.
+// Episodes that aired before the server started are ignored.
+func (a *App) checkAiredEpisodes(aired map[string]struct{}, since time.Time) {
+	collection, err := a.AnilistPlatformRef.Get().GetAnimeCollection(context.Background(), platform.CachedCollection)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, media := range collection.GetAllAnime() {
+		next := media.GetNextAiringEpisode()
+		if next == nil {
+			continue
+		}
+		airedAt := time.Unix(int64(next.GetAiringAt()), 0)
+		if airedAt.After(now) || airedAt.Before(since) {
+			continue
+		}
+		key := fmt.Sprintf("%d-%d", media.GetID(), next.GetEpisode())
+		if _, found := aired[key]; found {
+			continue
+		}
+		aired[key] = struct{}{}
+		a.Webhooks.Dispatch(webhooks.EventEpisodeAired, &webhooks.EpisodeAiredPayload{
+			MediaId:       media.GetID(),
+			MediaTitle:    media.GetPreferredTitle(),
+			EpisodeNumber: next.GetEpisode(),
+			AiredAt:       airedAt.UTC(),
+		})
+	}
+}
@@ -2,6 +2,7 @@ package cron
 
 import (
 	"seanime/internal/core"
+	"seanime/internal/maintenance"
 	"time"
 )
 
@@ -25,7 +26,7 @@ func RunJobs(app *core.App) {
 		for {
 			select {
 			case <-refreshAnilistTicker.C:
-				if app.IsOffline() {
+				if app.IsOffline() || app.Maintenance.ShouldSkip(maintenance.TaskMetadataRefresh) {
 					continue
 				}
 				RefreshAnilistDataJob(ctx)
@@ -38,7 +39,7 @@ func RunJobs(app *core.App) {
 		for {
 			select {
 			case <-refreshLocalDataTicker.C:
-				if app.IsOffline() {
+				if app.IsOffline() || app.Maintenance.ShouldSkip(maintenance.TaskLocalSync) {
 					continue
 				}
 				SyncLocalDataJob(ctx)
@@ -50,7 +51,7 @@ func RunJobs(app *core.App) {
 		for {
 			select {
 			case <-refetchReleaseTicker.C:
-				if app.IsOffline() {
+				if app.IsOffline() || app.Maintenance.ShouldSkip(maintenance.TaskUpdateCheck) {
 					continue
 				}
 				app.Updater.ShouldRefetchReleases()
@@ -62,7 +63,7 @@ func RunJobs(app *core.App) {
 		for {
 			select {
 			case <-refetchAnnouncementsTicker.C:
-				if app.IsOffline() {
+				if app.IsOffline() || app.Maintenance.ShouldSkip(maintenance.TaskUpdateCheck) {
 					continue
 				}
 				app.Updater.FetchAnnouncements()
@@ -15,7 +15,7 @@ func RefreshAnilistDataJob(c *JobCtx) {
 	}
 
 	// Refresh the Anilist Collection
-	animeCollection, _ := c.App.RefreshAnimeCollection()
+	animeCollection, _ := c.App.RefreshAnimeCollectionInBackground()
 
 	if c.App.Settings.GetLibrary().EnableManga {
 		mangaCollection, _ := c.App.RefreshMangaCollection()
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountUnicodeUsername(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "account_test", util.NewLogger())
+	require.NoError(t, err)
+
+	_, err = database.UpsertAccount(&models.Account{BaseModel: models.BaseModel{ID: 1}, Username: "渡辺ミク", Token: "token", Viewer: []byte("{}")})
+	require.NoError(t, err)
+
+	var acc models.Account
+	require.NoError(t, database.Gorm().Where("username = ?", "渡辺ミク").First(&acc).Error)
+	assert.Equal(t, "渡辺ミク", acc.Username)
+
+	// The comparison is exact
+	var count int64
+	require.NoError(t, database.Gorm().Model(&models.Account{}).Where("username = ?", "渡辺").Count(&count).Error)
+	assert.Zero(t, count)
+}
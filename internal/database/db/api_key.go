@@ -0,0 +1,38 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"time"
+)
+
+func (db *Database) GetApiKeys() ([]*models.ApiKey, error) {
+	var res []*models.ApiKey
+	err := db.gormdb.Order("id asc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetApiKeyByHash retrieves the API key with the SHA-256 hash.
+func (db *Database) GetApiKeyByHash(keyHash string) (*models.ApiKey, error) {
+	var res models.ApiKey
+	err := db.gormdb.Where("key_hash = ?", keyHash).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) InsertApiKey(key *models.ApiKey) error {
+	return db.gormdb.Create(key).Error
+}
+
+func (db *Database) DeleteApiKey(id uint) error {
+	return db.gormdb.Delete(&models.ApiKey{}, id).Error
+}
+
+// UpdateApiKeyLastUsedAt records when the API key was last used.
+func (db *Database) UpdateApiKeyLastUsedAt(id uint, lastUsedAt time.Time) error {
+	return db.gormdb.Model(&models.ApiKey{}).Where("id = ?", id).Update("last_used_at", lastUsedAt).Error
+}
@@ -55,3 +55,30 @@ func (db *Database) UpdateAutoDownloaderItem(id uint, item *models.AutoDownloade
 	// Save the data
 	return db.gormdb.Model(&models.AutoDownloaderItem{}).Where("id = ?", id).Updates(item).Error
 }
+
+// GetAutoDownloaderItemsAwaitingConfirmation returns the items waiting for the user to approve or reject them.
+func (db *Database) GetAutoDownloaderItemsAwaitingConfirmation() ([]*models.AutoDownloaderItem, error) {
+	var res []*models.AutoDownloaderItem
+	err := db.gormdb.Where("awaiting_confirmation = ?", true).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ConfirmAutoDownloaderItem marks an item awaiting confirmation as approved.
+func (db *Database) ConfirmAutoDownloaderItem(id uint, magnet string, downloaded bool) error {
+	// A map is used because the zero values are not updated with a struct
+	return db.gormdb.Model(&models.AutoDownloaderItem{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"awaiting_confirmation": false,
+		"candidate":             nil,
+		"expires_at":            nil,
+		"magnet":                magnet,
+		"downloaded":            downloaded,
+	}).Error
+}
+
+func (db *Database) SetAutoDownloaderItemReminderSent(id uint) error {
+	return db.gormdb.Model(&models.AutoDownloaderItem{}).Where("id = ?", id).Update("reminder_sent", true).Error
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmAutoDownloaderItem(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "autodownloader_item_test", util.NewLogger())
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	item := &models.AutoDownloaderItem{
+		RuleID:               1,
+		MediaID:              2,
+		Episode:              3,
+		Hash:                 "hash",
+		TorrentName:          "[Group] Show - 03 (1080p)",
+		AwaitingConfirmation: true,
+		Candidate:            []byte(`{}`),
+		ExpiresAt:            &expiresAt,
+	}
+	require.NoError(t, database.InsertAutoDownloaderItem(item))
+
+	pending, err := database.GetAutoDownloaderItemsAwaitingConfirmation()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	require.NoError(t, database.ConfirmAutoDownloaderItem(item.ID, "magnet:?xt=urn:btih:hash", true))
+
+	confirmed, err := database.GetAutoDownloaderItem(item.ID)
+	require.NoError(t, err)
+	assert.False(t, confirmed.AwaitingConfirmation)
+	assert.True(t, confirmed.Downloaded)
+	assert.Nil(t, confirmed.ExpiresAt)
+	assert.Empty(t, confirmed.Candidate)
+	assert.Equal(t, "magnet:?xt=urn:btih:hash", confirmed.Magnet)
+
+	pending, err = database.GetAutoDownloaderItemsAwaitingConfirmation()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
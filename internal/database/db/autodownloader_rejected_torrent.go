@@ -0,0 +1,24 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+)
+
+func (db *Database) InsertAutoDownloaderRejectedTorrent(item *models.AutoDownloaderRejectedTorrent) error {
+	return db.gormdb.Create(item).Error
+}
+
+// GetAutoDownloaderRejectedTorrents returns the torrents rejected by the user for a rule.
+func (db *Database) GetAutoDownloaderRejectedTorrents(ruleId uint) ([]*models.AutoDownloaderRejectedTorrent, error) {
+	var res []*models.AutoDownloaderRejectedTorrent
+	err := db.gormdb.Where("rule_id = ?", ruleId).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *Database) DeleteAutoDownloaderRejectedTorrentsByRuleId(ruleId uint) error {
+	return db.gormdb.Where("rule_id = ?", ruleId).Delete(&models.AutoDownloaderRejectedTorrent{}).Error
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+
+	"gorm.io/gorm"
+)
+
+// GetBatchTorrentWatches returns all the watched batch torrents.
+func (db *Database) GetBatchTorrentWatches() ([]*models.BatchTorrentWatch, error) {
+	var res []*models.BatchTorrentWatch
+	err := db.gormdb.Order("id").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetBatchTorrentWatch returns the watched batch torrent, or nil if it does not exist.
+func (db *Database) GetBatchTorrentWatch(id uint) (*models.BatchTorrentWatch, error) {
+	var res models.BatchTorrentWatch
+	err := db.gormdb.First(&res, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SaveBatchTorrentWatch inserts the watch, or updates it if its ID is set.
+// A watch with the same hash is replaced when a new watch is inserted.
+func (db *Database) SaveBatchTorrentWatch(watch *models.BatchTorrentWatch) error {
+	if watch.ID == 0 {
+		var existing models.BatchTorrentWatch
+		err := db.gormdb.Where("hash = ?", watch.Hash).First(&existing).Error
+		if err == nil {
+			watch.ID = existing.ID
+			watch.CreatedAt = existing.CreatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return db.gormdb.Save(watch).Error
+}
+
+// DeleteBatchTorrentWatch stops watching a batch torrent.
+func (db *Database) DeleteBatchTorrentWatch(id uint) error {
+	return db.gormdb.Delete(&models.BatchTorrentWatch{}, id).Error
+}
@@ -85,6 +85,10 @@ func NewDatabase(appDataDir, dbName string, logger *zerolog.Logger) (*Database,
 
 // MigrateTables performs auto migration on the database
 func migrateTables(db *gorm.DB) error {
+	// Columns that default to true need to be set on existing settings
+	addingAutoAddToCollection := db.Migrator().HasTable(&models.Settings{}) &&
+		!db.Migrator().HasColumn(&models.Settings{}, "auto_add_to_collection")
+
 	err := db.AutoMigrate(
 		&models.LocalFiles{},
 		&models.Settings{},
@@ -93,7 +97,10 @@ func migrateTables(db *gorm.DB) error {
 		&models.ScanSummary{},
 		&models.AutoDownloaderRule{},
 		&models.AutoDownloaderItem{},
+		&models.AutoDownloaderRejectedTorrent{},
 		&models.SilencedMediaEntry{},
+		&models.MediaAudioPreference{},
+		&models.UIStateEntry{},
 		&models.Theme{},
 		&models.PlaylistEntry{}, // Legacy playlists
 		&models.Playlist{},
@@ -102,6 +109,11 @@ func migrateTables(db *gorm.DB) error {
 		&models.TorrentstreamHistory{},
 		&models.MediastreamSettings{},
 		&models.MediaFiller{},
+		&models.MediaThemes{},
+		&models.LibraryCleanupCandidate{},
+		&models.LibraryCleanupExemption{},
+		&models.LibraryCleanupSnapshot{},
+		&models.LocalFileAttachment{},
 		&models.MangaMapping{},
 		&models.OnlinestreamMapping{},
 		&models.DebridSettings{},
@@ -110,6 +122,20 @@ func migrateTables(db *gorm.DB) error {
 		&models.CustomSourceCollection{},
 		&models.CustomSourceIdentifier{},
 		&models.TorrentPreMatch{},
+		&models.StoragePlacementRule{},
+		&models.SavedTorrentSearch{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.TorrentResultHistory{},
+		&models.TorrentClientMigration{},
+		&models.TorrentClientMigrationItem{},
+		&models.MediaAnnotation{},
+		&models.MediaRemap{},
+		&models.BatchTorrentWatch{},
+		&models.ReleaseObservation{},
+		&models.TorrentSpeedSample{},
+		&models.ApiKey{},
 		//&models.MangaChapterContainer{},
 	)
 	if err != nil {
@@ -117,6 +143,17 @@ func migrateTables(db *gorm.DB) error {
 		return err
 	}
 
+	if addingAutoAddToCollection {
+		err = db.Model(&models.Settings{}).Where("1 = 1").Update("auto_add_to_collection", true).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = migrateMediaAnnotationSearch(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+)
+
+// Primary result codes of the SQLite errors that retrying will not fix
+const (
+	sqlitePerm     = 3
+	sqliteReadOnly = 8
+	sqliteIOErr    = 10
+	sqliteCorrupt  = 11
+	sqliteFull     = 13
+	sqliteCantOpen = 14
+	sqliteNotADB   = 26
+)
+
+// IsNonRecoverableError returns true if the error is a SQLite error that retrying will not fix,
+// e.g. when the disk is full or the database file is read-only or corrupted.
+// Busy and locked errors are recoverable.
+func IsNonRecoverableError(err error) bool {
+	var sqliteErr interface{ Code() int }
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// Extended result codes keep the primary result code in the lower 8 bits
+	switch sqliteErr.Code() & 0xff {
+	case sqlitePerm, sqliteReadOnly, sqliteIOErr, sqliteCorrupt, sqliteFull, sqliteCantOpen, sqliteNotADB:
+		return true
+	}
+	return false
+}
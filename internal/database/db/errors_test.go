@@ -0,0 +1,39 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sqliteError struct {
+	code int
+}
+
+func (e *sqliteError) Error() string { return fmt.Sprintf("sqlite error %d", e.code) }
+func (e *sqliteError) Code() int     { return e.code }
+
+func TestIsNonRecoverableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "full", err: &sqliteError{code: 13}, expected: true},
+		{name: "read-only", err: &sqliteError{code: 8}, expected: true},
+		{name: "extended io error", err: &sqliteError{code: 10 | 3<<8}, expected: true},
+		{name: "wrapped", err: fmt.Errorf("upsert: %w", &sqliteError{code: 13}), expected: true},
+		{name: "busy", err: &sqliteError{code: 5}, expected: false},
+		{name: "locked", err: &sqliteError{code: 6}, expected: false},
+		{name: "constraint", err: &sqliteError{code: 19}, expected: false},
+		{name: "other", err: errors.New("record not found"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsNonRecoverableError(tt.err))
+		})
+	}
+}
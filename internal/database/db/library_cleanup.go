@@ -0,0 +1,93 @@
+package db
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LibraryCleanupSnapshotEntry is the list status of a media in the last evaluated collection.
+type LibraryCleanupSnapshotEntry struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+}
+
+func (db *Database) GetLibraryCleanupCandidates() ([]*models.LibraryCleanupCandidate, error) {
+	var res []*models.LibraryCleanupCandidate
+	err := db.gormdb.Order("transitioned_at asc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) InsertLibraryCleanupCandidate(candidate *models.LibraryCleanupCandidate) error {
+	return db.gormdb.Clauses(clause.OnConflict{DoNothing: true}).Create(candidate).Error
+}
+
+func (db *Database) SaveLibraryCleanupCandidate(candidate *models.LibraryCleanupCandidate) error {
+	return db.gormdb.Save(candidate).Error
+}
+
+func (db *Database) DeleteLibraryCleanupCandidate(mediaId int) error {
+	return db.gormdb.Where("media_id = ?", mediaId).Delete(&models.LibraryCleanupCandidate{}).Error
+}
+
+// GetLibraryCleanupExemptions returns the IDs of the media that are exempt from the cleanup.
+func (db *Database) GetLibraryCleanupExemptions() (map[int]struct{}, error) {
+	var res []*models.LibraryCleanupExemption
+	err := db.gormdb.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]struct{}, len(res))
+	for _, e := range res {
+		ret[e.MediaID] = struct{}{}
+	}
+	return ret, nil
+}
+
+func (db *Database) SetLibraryCleanupExemption(mediaId int, exempt bool) error {
+	if !exempt {
+		return db.gormdb.Where("media_id = ?", mediaId).Delete(&models.LibraryCleanupExemption{}).Error
+	}
+	return db.gormdb.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LibraryCleanupExemption{
+		MediaID: mediaId,
+	}).Error
+}
+
+// GetLibraryCleanupSnapshot returns the list statuses of the last evaluated collection.
+// The second return value is false if no collection has been evaluated yet.
+func (db *Database) GetLibraryCleanupSnapshot() (map[int]*LibraryCleanupSnapshotEntry, bool, error) {
+	var res models.LibraryCleanupSnapshot
+	err := db.gormdb.First(&res, 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	ret := make(map[int]*LibraryCleanupSnapshotEntry)
+	if err := json.Unmarshal(res.Value, &ret); err != nil {
+		return nil, false, err
+	}
+	return ret, true, nil
+}
+
+func (db *Database) SaveLibraryCleanupSnapshot(entries map[int]*LibraryCleanupSnapshotEntry) error {
+	value, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &models.LibraryCleanupSnapshot{
+		Value: value,
+	}
+	snapshot.ID = 1
+	return db.gormdb.Save(snapshot).Error
+}
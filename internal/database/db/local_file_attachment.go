@@ -0,0 +1,38 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+)
+
+func (db *Database) GetLocalFileAttachment(id uint) (*models.LocalFileAttachment, error) {
+	var res models.LocalFileAttachment
+	err := db.gormdb.First(&res, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) GetLocalFileAttachments(localFilePath string) ([]*models.LocalFileAttachment, error) {
+	var res []*models.LocalFileAttachment
+	err := db.gormdb.Where("local_file_path = ?", localFilePath).Order("id asc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) InsertLocalFileAttachment(attachment *models.LocalFileAttachment) error {
+	return db.gormdb.Create(attachment).Error
+}
+
+func (db *Database) DeleteLocalFileAttachment(id uint) error {
+	return db.gormdb.Delete(&models.LocalFileAttachment{}, id).Error
+}
+
+// CountLocalFileAttachmentsByPath returns the number of attachments stored at the given path.
+func (db *Database) CountLocalFileAttachmentsByPath(path string) (int64, error) {
+	var count int64
+	err := db.gormdb.Model(&models.LocalFileAttachment{}).Where("path = ?", path).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateMediaAnnotationSearch creates the full-text search table of the notes and the triggers that keep it in sync.
+// The notes that existed before the table was created are indexed.
+func migrateMediaAnnotationSearch(db *gorm.DB) error {
+	exists := db.Migrator().HasTable("media_annotations_fts")
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS media_annotations_fts USING fts5(note, content='media_annotations', content_rowid='id')`,
+		`CREATE TRIGGER IF NOT EXISTS media_annotations_ai AFTER INSERT ON media_annotations BEGIN
+			INSERT INTO media_annotations_fts(rowid, note) VALUES (new.id, new.note);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS media_annotations_ad AFTER DELETE ON media_annotations BEGIN
+			INSERT INTO media_annotations_fts(media_annotations_fts, rowid, note) VALUES ('delete', old.id, old.note);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS media_annotations_au AFTER UPDATE ON media_annotations BEGIN
+			INSERT INTO media_annotations_fts(media_annotations_fts, rowid, note) VALUES ('delete', old.id, old.note);
+			INSERT INTO media_annotations_fts(rowid, note) VALUES (new.id, new.note);
+		END`,
+	}
+	if !exists {
+		statements = append(statements, `INSERT INTO media_annotations_fts(media_annotations_fts) VALUES ('rebuild')`)
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMediaAnnotation returns the annotation of a media, or nil if the owner has none.
+func (db *Database) GetMediaAnnotation(owner string, mediaId int) (*models.MediaAnnotation, error) {
+	var res models.MediaAnnotation
+	err := db.gormdb.Where("owner = ? AND media_id = ?", owner, mediaId).First(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetMediaAnnotations returns all the annotations of an owner.
+func (db *Database) GetMediaAnnotations(owner string) ([]*models.MediaAnnotation, error) {
+	var res []*models.MediaAnnotation
+	err := db.gormdb.Where("owner = ?", owner).Order("media_id").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetAnnotatedMediaIds returns the IDs of the media that have an annotation, regardless of the owner.
+func (db *Database) GetAnnotatedMediaIds() ([]int, error) {
+	res := make([]int, 0)
+	err := db.gormdb.Model(&models.MediaAnnotation{}).Distinct().Order("media_id").Pluck("media_id", &res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpsertMediaAnnotation inserts or updates the annotation with the same owner and media.
+func (db *Database) UpsertMediaAnnotation(annotation *models.MediaAnnotation) error {
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "media_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"note", "tags", "updated_at"}),
+	}).Create(annotation).Error
+}
+
+// DeleteMediaAnnotation deletes the annotation of a media.
+func (db *Database) DeleteMediaAnnotation(owner string, mediaId int) error {
+	return db.gormdb.Where("owner = ? AND media_id = ?", owner, mediaId).Delete(&models.MediaAnnotation{}).Error
+}
+
+// SearchMediaAnnotationNotes returns the IDs of the media whose note contains all the words of the query.
+// The last word can be incomplete.
+func (db *Database) SearchMediaAnnotationNotes(owner string, query string) ([]int, error) {
+	match := toFTSQuery(query)
+	if match == "" {
+		return []int{}, nil
+	}
+
+	res := make([]int, 0)
+	err := db.gormdb.Model(&models.MediaAnnotation{}).
+		Where("owner = ? AND id IN (SELECT rowid FROM media_annotations_fts WHERE media_annotations_fts MATCH ?)", owner, match).
+		Order("media_id").
+		Pluck("media_id", &res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// toFTSQuery quotes each word of the query so that the FTS5 syntax cannot be used, and matches the last word as a prefix.
+func toFTSQuery(query string) string {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return ""
+	}
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	words[len(words)-1] += "*"
+	return strings.Join(words, " ")
+}
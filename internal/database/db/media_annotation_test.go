@@ -0,0 +1,60 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaAnnotations(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "media_annotation_test", util.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, database.UpsertMediaAnnotation(&models.MediaAnnotation{Owner: "user:a", MediaId: 1, Note: "Watch after S2 of Frieren", Tags: []string{"gym playlist"}}))
+	require.NoError(t, database.UpsertMediaAnnotation(&models.MediaAnnotation{Owner: "user:a", MediaId: 2, Note: "Dubbed version is better"}))
+	require.NoError(t, database.UpsertMediaAnnotation(&models.MediaAnnotation{Owner: "user:b", MediaId: 1, Note: "Watch with friends"}))
+
+	annotation, err := database.GetMediaAnnotation("user:a", 1)
+	require.NoError(t, err)
+	require.NotNil(t, annotation)
+	assert.Equal(t, models.StringSlice{"gym playlist"}, annotation.Tags)
+
+	annotation, err = database.GetMediaAnnotation("user:a", 3)
+	require.NoError(t, err)
+	assert.Nil(t, annotation)
+
+	// The notes of other owners are not searched, the last word is a prefix
+	ids, err := database.SearchMediaAnnotationNotes("user:a", "watch friends")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	ids, err = database.SearchMediaAnnotationNotes("user:a", "watch frie")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+
+	// The FTS5 syntax is not interpreted
+	ids, err = database.SearchMediaAnnotationNotes("user:a", `dubbed" OR "watch`)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// The index follows the updates and the deletions
+	require.NoError(t, database.UpsertMediaAnnotation(&models.MediaAnnotation{Owner: "user:a", MediaId: 1, Note: "Rewatch someday"}))
+	ids, err = database.SearchMediaAnnotationNotes("user:a", "frieren")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	ids, err = database.SearchMediaAnnotationNotes("user:a", "rewatch")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+
+	require.NoError(t, database.DeleteMediaAnnotation("user:a", 1))
+	ids, err = database.SearchMediaAnnotationNotes("user:a", "rewatch")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	annotations, err := database.GetMediaAnnotations("user:a")
+	require.NoError(t, err)
+	require.Len(t, annotations, 1)
+	assert.Equal(t, 2, annotations[0].MediaId)
+}
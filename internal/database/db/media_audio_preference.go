@@ -0,0 +1,42 @@
+package db
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetMediaAudioPreference returns the audio preference of a media.
+// It returns an empty string if no preference is set.
+func (db *Database) GetMediaAudioPreference(mId int) (string, error) {
+	var res models.MediaAudioPreference
+	err := db.gormdb.First(&res, mId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return res.Preference, nil
+}
+
+// SetMediaAudioPreference sets the audio preference of a media.
+// An empty preference removes it.
+func (db *Database) SetMediaAudioPreference(mId int, preference string) error {
+	if preference == "" {
+		return db.gormdb.Delete(&models.MediaAudioPreference{}, mId).Error
+	}
+
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(&models.MediaAudioPreference{
+		BaseModel: models.BaseModel{
+			ID: uint(mId),
+		},
+		Preference: preference,
+	}).Error
+}
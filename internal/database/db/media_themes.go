@@ -0,0 +1,85 @@
+package db
+
+import (
+	"errors"
+	"seanime/internal/api/animethemes"
+	"seanime/internal/database/models"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MediaThemesItem struct {
+	MediaId       int                  `json:"mediaId"`
+	LastFetchedAt time.Time            `json:"lastFetchedAt"`
+	Themes        []*animethemes.Theme `json:"themes"`
+}
+
+// GetMediaThemes returns the stored theme songs of the media.
+// The second return value is false if the themes have never been fetched.
+func (db *Database) GetMediaThemes(mediaId int) (*MediaThemesItem, bool, error) {
+	var res models.MediaThemes
+	err := db.gormdb.Where("media_id = ?", mediaId).First(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	themes := make([]*animethemes.Theme, 0)
+	if len(res.Data) > 0 {
+		if err := json.Unmarshal(res.Data, &themes); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return &MediaThemesItem{
+		MediaId:       res.MediaID,
+		LastFetchedAt: res.LastFetchedAt,
+		Themes:        themes,
+	}, true, nil
+}
+
+// GetFetchedMediaThemesIds returns the IDs of the media whose themes have been fetched.
+func (db *Database) GetFetchedMediaThemesIds() (map[int]time.Time, error) {
+	var res []*models.MediaThemes
+	err := db.gormdb.Select("media_id", "last_fetched_at").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]time.Time, len(res))
+	for _, mt := range res {
+		ret[mt.MediaID] = mt.LastFetchedAt
+	}
+	return ret, nil
+}
+
+// UpsertMediaThemes stores the theme songs of the media.
+// An empty list is stored so that the media is not fetched again.
+func (db *Database) UpsertMediaThemes(mediaId int, lastFetchedAt time.Time, themes []*animethemes.Theme) error {
+	if themes == nil {
+		themes = make([]*animethemes.Theme, 0)
+	}
+
+	data, err := json.Marshal(themes)
+	if err != nil {
+		return err
+	}
+
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "media_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_fetched_at", "data", "updated_at"}),
+	}).Create(&models.MediaThemes{
+		MediaID:       mediaId,
+		LastFetchedAt: lastFetchedAt,
+		Data:          data,
+	}).Error
+}
+
+func (db *Database) DeleteMediaThemes(mediaId int) error {
+	return db.gormdb.Where("media_id = ?", mediaId).Delete(&models.MediaThemes{}).Error
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"time"
+)
+
+// InsertNotification records a notification and only keeps the most recent notifications.
+func (db *Database) InsertNotification(notification *models.Notification, keep int) error {
+	if err := db.gormdb.Create(notification).Error; err != nil {
+		return err
+	}
+
+	var ids []uint
+	err := db.gormdb.Model(&models.Notification{}).
+		Order("id desc").
+		Offset(keep).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return db.gormdb.Delete(&models.Notification{}, ids).Error
+}
+
+// GetNotifications returns the notifications, most recent first.
+func (db *Database) GetNotifications(unreadOnly bool) ([]*models.Notification, error) {
+	var res []*models.Notification
+	query := db.gormdb.Order("id desc")
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) GetNotification(id uint) (*models.Notification, error) {
+	var res models.Notification
+	err := db.gormdb.First(&res, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MarkNotificationAsRead sets the read time of the notification if it is unread.
+func (db *Database) MarkNotificationAsRead(id uint, readAt time.Time) error {
+	return db.gormdb.Model(&models.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", readAt).Error
+}
+
+// DeleteReadNotifications deletes the notifications that have been read and returns their number.
+func (db *Database) DeleteReadNotifications() (int64, error) {
+	res := db.gormdb.Where("read_at IS NOT NULL").Delete(&models.Notification{})
+	return res.RowsAffected, res.Error
+}
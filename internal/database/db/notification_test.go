@@ -0,0 +1,44 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "notification_test", util.NewLogger())
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, database.InsertNotification(&models.Notification{
+			Type:    "download_started",
+			Message: "Downloading",
+			MediaId: i,
+		}, 3))
+	}
+
+	// Only the most recent notifications are kept
+	notifications, err := database.GetNotifications(false)
+	require.NoError(t, err)
+	require.Len(t, notifications, 3)
+	assert.Equal(t, 3, notifications[0].MediaId)
+
+	require.NoError(t, database.MarkNotificationAsRead(notifications[0].ID, time.Now()))
+
+	unread, err := database.GetNotifications(true)
+	require.NoError(t, err)
+	assert.Len(t, unread, 2)
+
+	deleted, err := database.DeleteReadNotifications()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	notifications, err = database.GetNotifications(false)
+	require.NoError(t, err)
+	assert.Len(t, notifications, 2)
+}
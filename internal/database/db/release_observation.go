@@ -0,0 +1,41 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// InsertReleaseObservation saves the observation of a release.
+// It returns false if the release of the episode by the release group had already been observed, the first observation is kept.
+func (db *Database) InsertReleaseObservation(obs *models.ReleaseObservation) (bool, error) {
+	res := db.gormdb.Clauses(clause.OnConflict{DoNothing: true}).Create(obs)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetReleaseObservations returns the observations of a media, most recently aired first.
+func (db *Database) GetReleaseObservations(mediaId int) ([]*models.ReleaseObservation, error) {
+	var res []*models.ReleaseObservation
+	err := db.gormdb.Where("media_id = ?", mediaId).Order("aired_at desc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PruneReleaseObservations deletes the oldest observations of a media and release group beyond the most recent maxPerGroup.
+func (db *Database) PruneReleaseObservations(mediaId int, releaseGroup string, maxPerGroup int) error {
+	var ids []uint
+	err := db.gormdb.Model(&models.ReleaseObservation{}).
+		Where("media_id = ? AND release_group = ?", mediaId, releaseGroup).
+		Order("aired_at desc").
+		Offset(maxPerGroup).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.gormdb.Delete(&models.ReleaseObservation{}, ids).Error
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+)
+
+// GetTorrentClientMigration returns the migration that has not been confirmed or discarded yet.
+// It returns gorm.ErrRecordNotFound if there is none.
+func (db *Database) GetTorrentClientMigration() (*models.TorrentClientMigration, error) {
+	var res models.TorrentClientMigration
+	err := db.gormdb.Order("id desc").First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) SaveTorrentClientMigration(migration *models.TorrentClientMigration) error {
+	return db.gormdb.Save(migration).Error
+}
+
+// GetTorrentClientMigrationItems returns the items of the migration in the order they were added.
+func (db *Database) GetTorrentClientMigrationItems(migrationId uint) ([]*models.TorrentClientMigrationItem, error) {
+	var res []*models.TorrentClientMigrationItem
+	err := db.gormdb.Where("migration_id = ?", migrationId).Order("id asc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) SaveTorrentClientMigrationItem(item *models.TorrentClientMigrationItem) error {
+	return db.gormdb.Save(item).Error
+}
+
+// DeleteTorrentClientMigrations deletes all the migrations and their items.
+func (db *Database) DeleteTorrentClientMigrations() error {
+	if err := db.gormdb.Where("1 = 1").Delete(&models.TorrentClientMigrationItem{}).Error; err != nil {
+		return err
+	}
+	return db.gormdb.Where("1 = 1").Delete(&models.TorrentClientMigration{}).Error
+}
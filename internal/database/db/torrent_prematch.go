@@ -4,10 +4,13 @@ import (
 	"seanime/internal/database/models"
 	"seanime/internal/util"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // SaveTorrentPreMatch saves a pre-match association between a destination path and media ID.
-// If a pre-match already exists for the destination, it will be updated.
+// If a pre-match already exists for the destination, it will be updated and its retention restarts.
 func (db *Database) SaveTorrentPreMatch(destination string, mediaId int) error {
 	destination = util.NormalizePath(destination)
 
@@ -16,6 +19,7 @@ func (db *Database) SaveTorrentPreMatch(destination string, mediaId int) error {
 	if err == nil {
 		// Update existing
 		existing.MediaId = mediaId
+		existing.CreatedAt = time.Now()
 		return db.gormdb.Save(&existing).Error
 	}
 
@@ -27,6 +31,39 @@ func (db *Database) SaveTorrentPreMatch(destination string, mediaId int) error {
 	return db.gormdb.Create(item).Error
 }
 
+// SaveTorrentPreMatchBatch saves multiple pre-matches in a single transaction.
+// Existing pre-matches with the same destination are updated, the creation date is kept when it is not set.
+func (db *Database) SaveTorrentPreMatchBatch(preMatches []*models.TorrentPreMatch) error {
+	return db.gormdb.Transaction(func(tx *gorm.DB) error {
+		for _, pm := range preMatches {
+			destination := util.NormalizePath(pm.Destination)
+
+			var existing models.TorrentPreMatch
+			err := tx.Where("destination = ?", destination).First(&existing).Error
+			if err == nil {
+				existing.MediaId = pm.MediaId
+				if !pm.CreatedAt.IsZero() {
+					existing.CreatedAt = pm.CreatedAt
+				}
+				if err := tx.Save(&existing).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			item := &models.TorrentPreMatch{
+				Destination: destination,
+				MediaId:     pm.MediaId,
+			}
+			item.CreatedAt = pm.CreatedAt
+			if err := tx.Create(item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetTorrentPreMatchByDestination retrieves a pre-match by destination path.
 func (db *Database) GetTorrentPreMatchByDestination(destination string) (*models.TorrentPreMatch, error) {
 	destination = util.NormalizePath(destination)
@@ -39,6 +76,28 @@ func (db *Database) GetTorrentPreMatchByDestination(destination string) (*models
 	return &res, nil
 }
 
+// GetTorrentPreMatch retrieves a pre-match by ID.
+func (db *Database) GetTorrentPreMatch(id uint) (*models.TorrentPreMatch, error) {
+	var res models.TorrentPreMatch
+	err := db.gormdb.First(&res, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UpdateTorrentPreMatchMediaId changes the media ID of a pre-match.
+func (db *Database) UpdateTorrentPreMatchMediaId(id uint, mediaId int) error {
+	res := db.gormdb.Model(&models.TorrentPreMatch{}).Where("id = ?", id).Update("media_id", mediaId)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 // GetTorrentPreMatchForFilePath checks if a file path falls under any pre-matched destination.
 // Returns the media ID if found, or 0 if no pre-match exists.
 func (db *Database) GetTorrentPreMatchForFilePath(filePath string) (int, bool) {
@@ -82,9 +141,22 @@ func (db *Database) DeleteTorrentPreMatchByDestination(destination string) error
 	return db.gormdb.Where("destination = ?", destination).Delete(&models.TorrentPreMatch{}).Error
 }
 
+// DeleteTorrentPreMatchesByDestination deletes the pre-matches of the destination paths.
+func (db *Database) DeleteTorrentPreMatchesByDestination(destinations []string) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(destinations))
+	for _, destination := range destinations {
+		normalized = append(normalized, util.NormalizePath(destination))
+	}
+	return db.gormdb.Where("destination IN ?", normalized).Delete(&models.TorrentPreMatch{}).Error
+}
+
 // CleanupOldTorrentPreMatches removes pre-match entries older than the specified number of days.
 func (db *Database) CleanupOldTorrentPreMatches(days int) error {
-	return db.gormdb.Where("created_at < datetime('now', ?)", "-"+string(rune(days))+" days").Delete(&models.TorrentPreMatch{}).Error
+	cutoff := time.Now().AddDate(0, 0, -days)
+	return db.gormdb.Where("created_at < ?", cutoff).Delete(&models.TorrentPreMatch{}).Error
 }
 
 // ClearAllTorrentPreMatches removes all pre-match entries from the database.
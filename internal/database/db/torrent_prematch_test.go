@@ -0,0 +1,174 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUpdateTorrentPreMatchMediaId(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "prematch_test", util.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, database.SaveTorrentPreMatch("/anime/Frieren", 1))
+	preMatch, err := database.GetTorrentPreMatchByDestination("/anime/Frieren")
+	require.NoError(t, err)
+
+	require.NoError(t, database.UpdateTorrentPreMatchMediaId(preMatch.ID, 154587))
+
+	updated, err := database.GetTorrentPreMatch(preMatch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 154587, updated.MediaId)
+	assert.Equal(t, preMatch.Destination, updated.Destination)
+
+	assert.ErrorIs(t, database.UpdateTorrentPreMatchMediaId(preMatch.ID+1, 1), gorm.ErrRecordNotFound)
+}
+
+func TestSaveTorrentPreMatchBatch(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "prematch_test", util.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, database.SaveTorrentPreMatch("/anime/Frieren", 1))
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, database.SaveTorrentPreMatchBatch([]*models.TorrentPreMatch{
+		{Destination: "/anime/Frieren", MediaId: 154587, BaseModel: models.BaseModel{CreatedAt: createdAt}},
+		{Destination: "/anime/Dungeon Meshi", MediaId: 153518},
+	}))
+
+	preMatches, err := database.GetAllTorrentPreMatches()
+	require.NoError(t, err)
+	require.Len(t, preMatches, 2)
+
+	frieren, err := database.GetTorrentPreMatchByDestination("/anime/Frieren")
+	require.NoError(t, err)
+	assert.Equal(t, 154587, frieren.MediaId)
+	assert.True(t, createdAt.Equal(frieren.CreatedAt))
+
+	meshi, err := database.GetTorrentPreMatchByDestination("/anime/Dungeon Meshi")
+	require.NoError(t, err)
+	assert.Equal(t, 153518, meshi.MediaId)
+	assert.False(t, meshi.CreatedAt.IsZero())
+}
+
+func TestCleanupOldTorrentPreMatches(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		days    int
+		ages    map[string]time.Duration
+		kept    []string
+		deleted []string
+	}{
+		{
+			name: "7 days",
+			days: 7,
+			ages: map[string]time.Duration{
+				"/anime/Frieren":       10 * 24 * time.Hour,
+				"/anime/Dungeon Meshi": 8 * 24 * time.Hour,
+				"/anime/Kusuriya":      6 * 24 * time.Hour,
+				"/anime/Apothecary":    time.Hour,
+			},
+			kept:    []string{"/anime/Kusuriya", "/anime/Apothecary"},
+			deleted: []string{"/anime/Frieren", "/anime/Dungeon Meshi"},
+		},
+		{
+			name: "30 days",
+			days: 30,
+			ages: map[string]time.Duration{
+				"/anime/Frieren":       40 * 24 * time.Hour,
+				"/anime/Dungeon Meshi": 8 * 24 * time.Hour,
+			},
+			kept:    []string{"/anime/Dungeon Meshi"},
+			deleted: []string{"/anime/Frieren"},
+		},
+		{
+			name: "0 days",
+			days: 0,
+			ages: map[string]time.Duration{
+				"/anime/Frieren": time.Hour,
+			},
+			deleted: []string{"/anime/Frieren"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENV", "true")
+			database, err := NewDatabase(t.TempDir(), "prematch_test", util.NewLogger())
+			require.NoError(t, err)
+			// Each connection to an in-memory database has its own database
+			sqlDB, err := database.Gorm().DB()
+			require.NoError(t, err)
+			sqlDB.SetMaxOpenConns(1)
+
+			preMatches := make([]*models.TorrentPreMatch, 0, len(tt.ages))
+			for destination, age := range tt.ages {
+				preMatches = append(preMatches, &models.TorrentPreMatch{
+					Destination: destination,
+					MediaId:     1,
+					BaseModel:   models.BaseModel{CreatedAt: now.Add(-age)},
+				})
+			}
+			require.NoError(t, database.SaveTorrentPreMatchBatch(preMatches))
+
+			require.NoError(t, database.CleanupOldTorrentPreMatches(tt.days))
+
+			for _, destination := range tt.kept {
+				_, err := database.GetTorrentPreMatchByDestination(destination)
+				assert.NoError(t, err, destination)
+			}
+			for _, destination := range tt.deleted {
+				_, err := database.GetTorrentPreMatchByDestination(destination)
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound, destination)
+			}
+		})
+	}
+}
+
+func TestDeleteTorrentPreMatchesByDestination(t *testing.T) {
+	t.Setenv("TEST_ENV", "true")
+	database, err := NewDatabase(t.TempDir(), "prematch_test", util.NewLogger())
+	require.NoError(t, err)
+	sqlDB, err := database.Gorm().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, database.SaveTorrentPreMatch("/anime/Frieren", 154587))
+	require.NoError(t, database.SaveTorrentPreMatch("/anime/Dungeon Meshi", 153518))
+
+	// The destinations matched by a scan are deleted, the others are kept until they expire
+	require.NoError(t, database.DeleteTorrentPreMatchesByDestination([]string{"/anime/Frieren", "/anime/Oshi no Ko"}))
+	require.NoError(t, database.DeleteTorrentPreMatchesByDestination(nil))
+
+	_, err = database.GetTorrentPreMatchByDestination("/anime/Frieren")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = database.GetTorrentPreMatchByDestination("/anime/Dungeon Meshi")
+	assert.NoError(t, err)
+}
+
+func TestSaveTorrentPreMatchRestartsRetention(t *testing.T) {
+	t.Setenv("TEST_ENV", "true")
+	database, err := NewDatabase(t.TempDir(), "prematch_test", util.NewLogger())
+	require.NoError(t, err)
+	sqlDB, err := database.Gorm().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, database.SaveTorrentPreMatchBatch([]*models.TorrentPreMatch{
+		{Destination: "/anime/Frieren", MediaId: 154587, BaseModel: models.BaseModel{CreatedAt: time.Now().AddDate(0, 0, -20)}},
+	}))
+
+	// A new download to the same destination restarts the retention
+	require.NoError(t, database.SaveTorrentPreMatch("/anime/Frieren", 154587))
+	require.NoError(t, database.CleanupOldTorrentPreMatches(14))
+
+	_, err = database.GetTorrentPreMatchByDestination("/anime/Frieren")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetTorrentResultHistory returns the entries of the owner with the given keys.
+func (db *Database) GetTorrentResultHistory(owner string, keys []string) ([]*models.TorrentResultHistory, error) {
+	var res []*models.TorrentResultHistory
+	if len(keys) == 0 {
+		return res, nil
+	}
+	err := db.gormdb.Where("owner = ? AND key IN ?", owner, keys).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetDismissedTorrentResults returns the dismissed results of the owner, most recently dismissed first.
+func (db *Database) GetDismissedTorrentResults(owner string) ([]*models.TorrentResultHistory, error) {
+	var res []*models.TorrentResultHistory
+	err := db.gormdb.Where("owner = ? AND dismissed_at IS NOT NULL", owner).Order("dismissed_at desc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpsertTorrentResultHistory inserts the entries or updates the entries with the same owner and key.
+// Only the given columns are updated.
+func (db *Database) UpsertTorrentResultHistory(entries []*models.TorrentResultHistory, columns []string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns(append(columns, "updated_at")),
+	}).Create(entries).Error
+}
+
+// UndismissTorrentResult clears the dismissal of a result and returns false if it was not dismissed.
+func (db *Database) UndismissTorrentResult(owner string, key string) (bool, error) {
+	res := db.gormdb.Model(&models.TorrentResultHistory{}).
+		Where("owner = ? AND key = ? AND dismissed_at IS NOT NULL", owner, key).
+		Update("dismissed_at", nil)
+	return res.RowsAffected > 0, res.Error
+}
+
+// SetTorrentResultQueuedItem links the entry to the AutoDownloader queue item downloaded with the torrent, 0 removes the link.
+func (db *Database) SetTorrentResultQueuedItem(id uint, queuedItemId uint) error {
+	return db.gormdb.Model(&models.TorrentResultHistory{}).Where("id = ?", id).Update("queued_item_id", queuedItemId).Error
+}
+
+// GetQueuedTorrentResults returns the entries linked to an AutoDownloader queue item.
+func (db *Database) GetQueuedTorrentResults() ([]*models.TorrentResultHistory, error) {
+	var res []*models.TorrentResultHistory
+	err := db.gormdb.Where("queued_item_id > 0").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PruneTorrentResultHistory deletes the entries that have not been updated since the given time,
+// and the oldest entries of the owners that have more than maxPerOwner entries.
+func (db *Database) PruneTorrentResultHistory(before time.Time, maxPerOwner int) error {
+	if err := db.gormdb.Where("updated_at < ?", before).Delete(&models.TorrentResultHistory{}).Error; err != nil {
+		return err
+	}
+
+	var owners []string
+	err := db.gormdb.Model(&models.TorrentResultHistory{}).
+		Group("owner").
+		Having("COUNT(*) > ?", maxPerOwner).
+		Pluck("owner", &owners).Error
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		var ids []uint
+		err := db.gormdb.Model(&models.TorrentResultHistory{}).
+			Where("owner = ?", owner).
+			Order("updated_at desc").
+			Offset(maxPerOwner).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			if err := db.gormdb.Delete(&models.TorrentResultHistory{}, ids).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
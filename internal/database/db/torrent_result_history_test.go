@@ -0,0 +1,47 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTorrentResultQueuedItem(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "torrent_result_history_test", util.NewLogger())
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{
+		{Owner: "user", Key: "hash1", Name: "Frieren - 01", DownloadedAt: &now},
+		{Owner: "user", Key: "hash2", Name: "Frieren - 02", DownloadedAt: &now},
+	}, []string{"downloaded_at"}))
+
+	entries, err := database.GetTorrentResultHistory("user", []string{"hash1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, database.SetTorrentResultQueuedItem(entries[0].ID, 42))
+
+	queued, err := database.GetQueuedTorrentResults()
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "hash1", queued[0].Key)
+	assert.Equal(t, uint(42), queued[0].QueuedItemId)
+
+	// A new download of the same torrent keeps the link
+	require.NoError(t, database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{
+		{Owner: "user", Key: "hash1", Name: "Frieren - 01", DownloadedAt: &now},
+	}, []string{"downloaded_at"}))
+	queued, err = database.GetQueuedTorrentResults()
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+
+	require.NoError(t, database.SetTorrentResultQueuedItem(entries[0].ID, 0))
+	queued, err = database.GetQueuedTorrentResults()
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+}
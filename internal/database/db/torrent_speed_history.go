@@ -0,0 +1,25 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// SaveTorrentSpeedSample saves the sample in its slot, replacing the sample that was there.
+func (db *Database) SaveTorrentSpeedSample(sample *models.TorrentSpeedSample) error {
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slot"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timestamp", "download_bytes_per_sec", "upload_bytes_per_sec", "updated_at"}),
+	}).Create(sample).Error
+}
+
+// GetTorrentSpeedSamples returns the most recent samples, most recent first.
+func (db *Database) GetTorrentSpeedSamples(limit int) ([]*models.TorrentSpeedSample, error) {
+	var res []*models.TorrentSpeedSample
+	err := db.gormdb.Order("timestamp desc").Limit(limit).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+
+	"gorm.io/gorm/clause"
+)
+
+func (db *Database) GetAllUIStateEntries() ([]*models.UIStateEntry, error) {
+	var res []*models.UIStateEntry
+	err := db.gormdb.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UpsertUIStateEntry inserts or updates the entry with the same owner and key.
+func (db *Database) UpsertUIStateEntry(entry *models.UIStateEntry) error {
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(entry).Error
+}
+
+func (db *Database) DeleteUIStateEntry(owner string, key string) error {
+	return db.gormdb.Where("owner = ? AND key = ?", owner, key).Delete(&models.UIStateEntry{}).Error
+}
+
+// DeleteUIStateEntries deletes all the entries of an owner.
+func (db *Database) DeleteUIStateEntries(owner string) error {
+	return db.gormdb.Where("owner = ?", owner).Delete(&models.UIStateEntry{}).Error
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIStateEntries(t *testing.T) {
+	database, err := NewDatabase(t.TempDir(), "ui_state_test", util.NewLogger())
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, database.UpsertUIStateEntry(&models.UIStateEntry{BaseModel: models.BaseModel{UpdatedAt: now}, Owner: "user:a", Key: "filters", Value: []byte(`{"a":1}`)}))
+	require.NoError(t, database.UpsertUIStateEntry(&models.UIStateEntry{BaseModel: models.BaseModel{UpdatedAt: now}, Owner: "user:b", Key: "filters", Value: []byte(`{"b":1}`)}))
+	// Same owner and key, should update the existing entry
+	require.NoError(t, database.UpsertUIStateEntry(&models.UIStateEntry{BaseModel: models.BaseModel{UpdatedAt: now.Add(time.Minute)}, Owner: "user:a", Key: "filters", Value: []byte(`{"a":2}`)}))
+
+	entries, err := database.GetAllUIStateEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		if e.Owner == "user:a" {
+			assert.Equal(t, `{"a":2}`, string(e.Value))
+			assert.WithinDuration(t, now.Add(time.Minute), e.UpdatedAt, time.Millisecond)
+		}
+	}
+
+	require.NoError(t, database.DeleteUIStateEntry("user:a", "filters"))
+	require.NoError(t, database.DeleteUIStateEntries("user:b"))
+
+	entries, err = database.GetAllUIStateEntries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+)
+
+func (db *Database) GetWebhookSubscriptions() ([]*models.WebhookSubscription, error) {
+	var res []*models.WebhookSubscription
+	err := db.gormdb.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) GetWebhookSubscription(id uint) (*models.WebhookSubscription, error) {
+	var res models.WebhookSubscription
+	err := db.gormdb.First(&res, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) InsertWebhookSubscription(sub *models.WebhookSubscription) error {
+	return db.gormdb.Create(sub).Error
+}
+
+func (db *Database) SaveWebhookSubscription(sub *models.WebhookSubscription) error {
+	return db.gormdb.Save(sub).Error
+}
+
+// DeleteWebhookSubscription deletes a subscription and its deliveries.
+func (db *Database) DeleteWebhookSubscription(id uint) error {
+	if err := db.gormdb.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return db.gormdb.Delete(&models.WebhookSubscription{}, id).Error
+}
+
+// InsertWebhookDelivery records a delivery attempt and only keeps the last attempts of the subscription.
+func (db *Database) InsertWebhookDelivery(delivery *models.WebhookDelivery, keep int) error {
+	if err := db.gormdb.Create(delivery).Error; err != nil {
+		return err
+	}
+
+	var ids []uint
+	err := db.gormdb.Model(&models.WebhookDelivery{}).
+		Where("subscription_id = ?", delivery.SubscriptionID).
+		Order("id desc").
+		Offset(keep).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return db.gormdb.Delete(&models.WebhookDelivery{}, ids).Error
+}
+
+// GetWebhookDeliveries returns the last delivery attempts of a subscription, most recent first.
+func (db *Database) GetWebhookDeliveries(subscriptionId uint, limit int) ([]*models.WebhookDelivery, error) {
+	var res []*models.WebhookDelivery
+	err := db.gormdb.Where("subscription_id = ?", subscriptionId).Order("id desc").Limit(limit).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -31,6 +31,7 @@ func GetAutoDownloaderRules(db *db.Database) ([]*anime.AutoDownloaderRule, error
 			return nil, err
 		}
 		sm.DbID = r.ID
+		sm.NormalizeEpisodeOffset()
 		rules = append(rules, &sm)
 	}
 
@@ -53,6 +54,7 @@ func GetAutoDownloaderRule(db *db.Database, id uint) (*anime.AutoDownloaderRule,
 		return nil, err
 	}
 	sm.DbID = res.ID
+	sm.NormalizeEpisodeOffset()
 
 	return &sm, nil
 }
@@ -92,6 +94,8 @@ func DeleteAutoDownloaderRule(db *db.Database, id uint) error {
 
 	CurrAutoDownloaderRules = nil
 
+	_ = db.DeleteAutoDownloaderRejectedTorrentsByRuleId(id)
+
 	return db.Gorm().Delete(&models.AutoDownloaderRule{}, id).Error
 }
 
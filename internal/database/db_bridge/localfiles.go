@@ -5,6 +5,7 @@ import (
 	"seanime/internal/database/db"
 	"seanime/internal/database/models"
 	"seanime/internal/library/anime"
+	"sync/atomic"
 
 	"github.com/goccy/go-json"
 	"github.com/samber/mo"
@@ -14,6 +15,14 @@ import (
 var CurrLocalFilesDbId uint
 var CurrLocalFiles mo.Option[[]*anime.LocalFile]
 
+// localFilesVersion is incremented every time the local files are modified.
+var localFilesVersion atomic.Uint64
+
+// GetLocalFilesVersion returns a number that changes every time the local files are saved, inserted or cleared.
+func GetLocalFilesVersion() uint64 {
+	return localFilesVersion.Load()
+}
+
 // GetLocalFiles will return the latest local files and the id of the entry.
 // If no local files exist in the database, it returns an empty slice instead of an error.
 func GetLocalFiles(db *db.Database) ([]*anime.LocalFile, uint, error) {
@@ -71,6 +80,8 @@ func SaveLocalFiles(db *db.Database, lfsId uint, lfs []*anime.LocalFile) ([]*ani
 		return nil, err
 	}
 
+	localFilesVersion.Add(1)
+
 	// Unmarshal the saved local files
 	var retLfs []*anime.LocalFile
 	if err := json.Unmarshal(ret.Value, &retLfs); err != nil {
@@ -103,6 +114,7 @@ func InsertLocalFiles(db *db.Database, lfs []*anime.LocalFile) ([]*anime.LocalFi
 
 	CurrLocalFiles = mo.Some(lfs)
 	CurrLocalFilesDbId = ret.ID
+	localFilesVersion.Add(1)
 
 	return lfs, nil
 
@@ -119,6 +131,7 @@ func ClearLocalFiles(database *db.Database) error {
 	// Clear the cache
 	CurrLocalFiles = mo.None[[]*anime.LocalFile]()
 	CurrLocalFilesDbId = 0
+	localFilesVersion.Add(1)
 
 	database.Logger.Info().Msg("db: All local files cleared")
 
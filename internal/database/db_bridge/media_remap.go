@@ -0,0 +1,239 @@
+package db_bridge
+
+import (
+	"errors"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+
+	"github.com/goccy/go-json"
+	"github.com/samber/mo"
+	"gorm.io/gorm"
+)
+
+// RemapMediaId moves the local records of a media to the media AniList merged it into, and records the remap.
+// The local files, AutoDownloader rules and items, torrent pre-matches, saved torrent searches, torrent stream history
+// and annotations are remapped in one transaction, nothing is modified if one of them fails.
+func RemapMediaId(database *db.Database, from int, to int, title string) (*anime.MediaRemap, error) {
+	ret := &anime.MediaRemap{
+		FromMediaId: from,
+		ToMediaId:   to,
+		Title:       title,
+		Files:       make([]string, 0),
+		Records:     make(map[string]int),
+	}
+
+	err := database.Gorm().Transaction(func(tx *gorm.DB) error {
+		if err := remapLocalFiles(tx, ret); err != nil {
+			return err
+		}
+		if err := remapAutoDownloaderRules(tx, ret); err != nil {
+			return err
+		}
+		if err := remapSavedTorrentSearches(tx, ret); err != nil {
+			return err
+		}
+		if err := remapMediaAnnotations(tx, ret); err != nil {
+			return err
+		}
+
+		columns := []struct {
+			kind  string
+			model interface{}
+		}{
+			{"preMatches", &models.TorrentPreMatch{}},
+			{"autoDownloaderItems", &models.AutoDownloaderItem{}},
+			{"torrentstreamHistory", &models.TorrentstreamHistory{}},
+		}
+		for _, c := range columns {
+			res := tx.Model(c.model).Where("media_id = ?", from).Update("media_id", to)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				ret.Records[c.kind] = int(res.RowsAffected)
+			}
+		}
+
+		return insertMediaRemap(tx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The cached records are outdated
+	CurrLocalFiles = mo.None[[]*anime.LocalFile]()
+	localFilesVersion.Add(1)
+	CurrAutoDownloaderRules = nil
+
+	return ret, nil
+}
+
+// InsertMediaRemap records a media that no longer exists, or a remap that was done outside RemapMediaId.
+func InsertMediaRemap(database *db.Database, remap *anime.MediaRemap) error {
+	return insertMediaRemap(database.Gorm(), remap)
+}
+
+// GetMediaRemaps returns the recorded remaps, most recent first.
+func GetMediaRemaps(database *db.Database) ([]*anime.MediaRemap, error) {
+	var res []*models.MediaRemap
+	err := database.Gorm().Order("id desc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*anime.MediaRemap, 0, len(res))
+	for _, r := range res {
+		var remap anime.MediaRemap
+		if err := json.Unmarshal(r.Value, &remap); err != nil {
+			return nil, err
+		}
+		remap.FromMediaId = r.FromMediaID
+		remap.ToMediaId = r.ToMediaID
+		remap.CreatedAt = r.CreatedAt
+		ret = append(ret, &remap)
+	}
+
+	return ret, nil
+}
+
+func insertMediaRemap(tx *gorm.DB, remap *anime.MediaRemap) error {
+	bytes, err := json.Marshal(remap)
+	if err != nil {
+		return err
+	}
+
+	row := &models.MediaRemap{
+		FromMediaID: remap.FromMediaId,
+		ToMediaID:   remap.ToMediaId,
+		Value:       bytes,
+	}
+	if err := tx.Create(row).Error; err != nil {
+		return err
+	}
+	remap.CreatedAt = row.CreatedAt
+	return nil
+}
+
+func remapLocalFiles(tx *gorm.DB, remap *anime.MediaRemap) error {
+	var res models.LocalFiles
+	err := tx.Last(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var lfs []*anime.LocalFile
+	if err := json.Unmarshal(res.Value, &lfs); err != nil {
+		return err
+	}
+	for _, lf := range lfs {
+		if lf.MediaId == remap.FromMediaId {
+			lf.MediaId = remap.ToMediaId
+			remap.Files = append(remap.Files, lf.Path)
+		}
+	}
+	if len(remap.Files) == 0 {
+		return nil
+	}
+
+	bytes, err := json.Marshal(lfs)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&models.LocalFiles{}).Where("id = ?", res.ID).Update("value", bytes).Error; err != nil {
+		return err
+	}
+	remap.Records["localFiles"] = len(remap.Files)
+	return nil
+}
+
+func remapAutoDownloaderRules(tx *gorm.DB, remap *anime.MediaRemap) error {
+	var res []*models.AutoDownloaderRule
+	if err := tx.Find(&res).Error; err != nil {
+		return err
+	}
+
+	for _, r := range res {
+		var rule anime.AutoDownloaderRule
+		if err := json.Unmarshal(r.Value, &rule); err != nil {
+			return err
+		}
+		if rule.MediaId != remap.FromMediaId {
+			continue
+		}
+		rule.MediaId = remap.ToMediaId
+		bytes, err := json.Marshal(&rule)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AutoDownloaderRule{}).Where("id = ?", r.ID).Update("value", bytes).Error; err != nil {
+			return err
+		}
+		remap.Records["autoDownloaderRules"]++
+	}
+	return nil
+}
+
+func remapSavedTorrentSearches(tx *gorm.DB, remap *anime.MediaRemap) error {
+	var res []*models.SavedTorrentSearch
+	if err := tx.Where("media_id = ?", remap.FromMediaId).Find(&res).Error; err != nil {
+		return err
+	}
+
+	for _, r := range res {
+		var search anime.SavedTorrentSearch
+		if err := json.Unmarshal(r.Value, &search); err != nil {
+			return err
+		}
+		search.MediaId = remap.ToMediaId
+		bytes, err := json.Marshal(&search)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.SavedTorrentSearch{}).Where("id = ?", r.ID).Updates(map[string]interface{}{
+			"media_id": remap.ToMediaId,
+			"value":    bytes,
+		}).Error
+		if err != nil {
+			return err
+		}
+		remap.Records["savedTorrentSearches"]++
+	}
+	return nil
+}
+
+// remapMediaAnnotations moves the annotations to the new media.
+// If an owner already has an annotation for the new media, the most recently updated one is kept.
+func remapMediaAnnotations(tx *gorm.DB, remap *anime.MediaRemap) error {
+	var res []*models.MediaAnnotation
+	if err := tx.Where("media_id = ?", remap.FromMediaId).Find(&res).Error; err != nil {
+		return err
+	}
+
+	for _, a := range res {
+		var existing models.MediaAnnotation
+		err := tx.Where("owner = ? AND media_id = ?", a.Owner, remap.ToMediaId).First(&existing).Error
+		switch {
+		case err == nil && !existing.UpdatedAt.Before(a.UpdatedAt):
+			if err := tx.Delete(&models.MediaAnnotation{}, a.ID).Error; err != nil {
+				return err
+			}
+			continue
+		case err == nil:
+			if err := tx.Delete(&models.MediaAnnotation{}, existing.ID).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if err := tx.Model(&models.MediaAnnotation{}).Where("id = ?", a.ID).Update("media_id", remap.ToMediaId).Error; err != nil {
+			return err
+		}
+		remap.Records["annotations"]++
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package db_bridge
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+
+	"github.com/goccy/go-json"
+)
+
+func GetSavedTorrentSearches(db *db.Database) ([]*anime.SavedTorrentSearch, error) {
+	var res []*models.SavedTorrentSearch
+	err := db.Gorm().Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSavedTorrentSearches(res)
+}
+
+func GetSavedTorrentSearchesByMediaId(db *db.Database, mediaId int) ([]*anime.SavedTorrentSearch, error) {
+	var res []*models.SavedTorrentSearch
+	err := db.Gorm().Where("media_id = ?", mediaId).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSavedTorrentSearches(res)
+}
+
+func GetSavedTorrentSearch(db *db.Database, id uint) (*anime.SavedTorrentSearch, error) {
+	var res models.SavedTorrentSearch
+	err := db.Gorm().First(&res, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var search anime.SavedTorrentSearch
+	if err := json.Unmarshal(res.Value, &search); err != nil {
+		return nil, err
+	}
+	search.DbID = res.ID
+
+	return &search, nil
+}
+
+func InsertSavedTorrentSearch(db *db.Database, search *anime.SavedTorrentSearch) error {
+	bytes, err := json.Marshal(search)
+	if err != nil {
+		return err
+	}
+
+	item := &models.SavedTorrentSearch{
+		MediaID: search.MediaId,
+		Value:   bytes,
+	}
+	if err := db.Gorm().Create(item).Error; err != nil {
+		return err
+	}
+	search.DbID = item.ID
+	return nil
+}
+
+func UpdateSavedTorrentSearch(db *db.Database, id uint, search *anime.SavedTorrentSearch) error {
+	bytes, err := json.Marshal(search)
+	if err != nil {
+		return err
+	}
+
+	return db.Gorm().Model(&models.SavedTorrentSearch{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"media_id": search.MediaId,
+		"value":    bytes,
+	}).Error
+}
+
+func DeleteSavedTorrentSearch(db *db.Database, id uint) error {
+	return db.Gorm().Delete(&models.SavedTorrentSearch{}, id).Error
+}
+
+func unmarshalSavedTorrentSearches(res []*models.SavedTorrentSearch) ([]*anime.SavedTorrentSearch, error) {
+	ret := make([]*anime.SavedTorrentSearch, 0, len(res))
+	for _, r := range res {
+		var search anime.SavedTorrentSearch
+		if err := json.Unmarshal(r.Value, &search); err != nil {
+			return nil, err
+		}
+		search.DbID = r.ID
+		ret = append(ret, &search)
+	}
+	return ret, nil
+}
@@ -0,0 +1,58 @@
+package db_bridge
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/library/placement"
+
+	"github.com/goccy/go-json"
+)
+
+func GetStoragePlacementRules(db *db.Database) ([]*placement.Rule, error) {
+	var res []*models.StoragePlacementRule
+	err := db.Gorm().Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*placement.Rule, 0, len(res))
+	for _, r := range res {
+		var rule placement.Rule
+		if err := json.Unmarshal(r.Value, &rule); err != nil {
+			return nil, err
+		}
+		rule.DbID = r.ID
+		rules = append(rules, &rule)
+	}
+
+	return rules, nil
+}
+
+func InsertStoragePlacementRule(db *db.Database, rule *placement.Rule) error {
+	bytes, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+
+	item := &models.StoragePlacementRule{
+		Value: bytes,
+	}
+	if err := db.Gorm().Create(item).Error; err != nil {
+		return err
+	}
+	rule.DbID = item.ID
+	return nil
+}
+
+func UpdateStoragePlacementRule(db *db.Database, id uint, rule *placement.Rule) error {
+	bytes, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+
+	return db.Gorm().Model(&models.StoragePlacementRule{}).Where("id = ?", id).Update("value", bytes).Error
+}
+
+func DeleteStoragePlacementRule(db *db.Database, id uint) error {
+	return db.Gorm().Delete(&models.StoragePlacementRule{}, id).Error
+}
@@ -6,6 +6,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	// The timezone database is embedded so that timezones can be validated on systems without it (e.g. containers)
+	_ "time/tzdata"
+
+	"github.com/goccy/go-json"
 )
 
 type BaseModel struct {
@@ -21,7 +25,10 @@ type Token struct {
 
 type Account struct {
 	BaseModel
-	Username string `gorm:"column:username" json:"username"`
+	// Username can contain any Unicode characters.
+	// SQLite stores it as UTF-8 text with the default BINARY collation, which compares the code points exactly.
+	// NOCASE must not be used, it only folds ASCII letters.
+	Username string `gorm:"column:username;type:text" json:"username"`
 	Token    string `gorm:"column:token" json:"token"`
 	Viewer   []byte `gorm:"column:viewer" json:"viewer"`
 }
@@ -51,6 +58,25 @@ type Settings struct {
 	Discord        *DiscordSettings        `gorm:"embedded" json:"discord"`
 	Notifications  *NotificationSettings   `gorm:"embedded" json:"notifications"`
 	Nakama         *NakamaSettings         `gorm:"embedded;embeddedPrefix:nakama_" json:"nakama"`
+	Server         *ServerSettings         `gorm:"embedded" json:"server"`
+}
+
+type ServerSettings struct {
+	// SessionCleanupInterval is how often stale browser sessions are removed. Defaults to 1 hour when 0.
+	SessionCleanupInterval time.Duration `gorm:"column:session_cleanup_interval" json:"sessionCleanupInterval"`
+	// SessionMaxIdle is how long a browser session can go without being accessed before it is removed. Defaults to 7 days when 0.
+	SessionMaxIdle time.Duration `gorm:"column:session_max_idle" json:"sessionMaxIdle"`
+	// SessionAdmins are the AniList usernames allowed to list and revoke sessions, in addition to the primary session
+	SessionAdmins StringSlice `gorm:"column:session_admins;type:text" json:"sessionAdmins"`
+	// PublicStatusEnabled exposes the unauthenticated status page data at /api/v1/public/status
+	PublicStatusEnabled         bool `gorm:"column:public_status_enabled" json:"publicStatusEnabled"`
+	PublicStatusShowVersion     bool `gorm:"column:public_status_show_version" json:"publicStatusShowVersion"`
+	PublicStatusShowStreaming   bool `gorm:"column:public_status_show_streaming" json:"publicStatusShowStreaming"`
+	PublicStatusShowMaintenance bool `gorm:"column:public_status_show_maintenance" json:"publicStatusShowMaintenance"`
+	// PublicStatusMaxStreams is the number of concurrent streams after which streaming is at capacity, 0 if there is no limit
+	PublicStatusMaxStreams int `gorm:"column:public_status_max_streams" json:"publicStatusMaxStreams"`
+	// PublicStatusAnnouncement is set through the announcement endpoint
+	PublicStatusAnnouncement string `gorm:"column:public_status_announcement" json:"publicStatusAnnouncement"`
 }
 
 type AnilistSettings struct {
@@ -93,6 +119,40 @@ type LibrarySettings struct {
 	// Progress update threshold (0.0-1.0), default 0.8 (80%)
 	// When playback reaches this percentage, the episode is marked as watched
 	ProgressUpdateThreshold float64 `gorm:"column:progress_update_threshold" json:"progressUpdateThreshold"`
+	// AutoAddToCollection adds the media to the AniList collection when a torrent is downloaded. Defaults to true.
+	AutoAddToCollection bool `gorm:"column:auto_add_to_collection" json:"autoAddToCollection"`
+	// DroppedCleanupPolicy is applied to the files of media that are dropped or removed from the AniList list.
+	// "" (nothing), "notify" or "trash"
+	DroppedCleanupPolicy string `gorm:"column:dropped_cleanup_policy" json:"droppedCleanupPolicy"`
+	// DroppedCleanupGraceDays is the number of days before the files are moved to the trash, it cannot be less than 7
+	DroppedCleanupGraceDays int `gorm:"column:dropped_cleanup_grace_days" json:"droppedCleanupGraceDays"`
+	// UnverifiedFileGraceDays is the number of days after which the files kept while their library path was unavailable
+	// are reported for cleanup, 0 uses the default
+	UnverifiedFileGraceDays int `gorm:"column:unverified_file_grace_days" json:"unverifiedFileGraceDays"`
+	// TorrentPreMatchRetentionDays is the number of days after which the pre-matches of torrent destinations
+	// whose files were never matched by a scan are removed, 0 uses the default
+	TorrentPreMatchRetentionDays int `gorm:"column:torrent_pre_match_retention_days" json:"torrentPreMatchRetentionDays"`
+	// DisableEpisodeThumbnails stops the extraction of a frame for the episodes that have no image
+	DisableEpisodeThumbnails bool `gorm:"column:disable_episode_thumbnails" json:"disableEpisodeThumbnails"`
+	// EpisodeThumbnailSkippedPaths are the library directories whose files should not get extracted thumbnails
+	EpisodeThumbnailSkippedPaths StringSlice `gorm:"column:episode_thumbnail_skipped_paths;type:text" json:"episodeThumbnailSkippedPaths"`
+	// Timezone is the IANA timezone of the user (e.g. "Europe/Paris"), used by the airing schedule and the daily tasks.
+	// UTC is used if it is empty
+	Timezone string `gorm:"column:timezone" json:"timezone"`
+	// LibraryRootPolicies are the scan policies of the library paths, a library path without policy is scanned with the whole library
+	LibraryRootPolicies LibraryRootPolicies `gorm:"column:library_root_policies;type:text" json:"libraryRootPolicies"`
+}
+
+// GetLocation returns the timezone of the user, or UTC if it is not set or invalid.
+func (o *LibrarySettings) GetLocation() *time.Location {
+	if o == nil || o.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
 
 func (o *LibrarySettings) GetLibraryPaths() (ret []string) {
@@ -121,6 +181,53 @@ func (o LibraryPaths) Value() (driver.Value, error) {
 	return strings.Join(o, ","), nil
 }
 
+// LibraryRootPolicy is the scan policy of a library path.
+type LibraryRootPolicy struct {
+	Path string `json:"path"`
+	// ExcludeFromFullScans removes the library path from the scans of the whole library, e.g. for a slow network mount.
+	// Its files stay in the library and it can still be scanned on its own.
+	ExcludeFromFullScans bool `json:"excludeFromFullScans"`
+	// ScanSchedule is the time of the day ("HH:MM") at which the library path is scanned on its own, empty if it is not scheduled
+	ScanSchedule string `json:"scanSchedule"`
+	// IOConcurrency is the number of directories read at the same time when retrieving the files, 0 reads them one at a time
+	IOConcurrency int `json:"ioConcurrency"`
+	// SentinelFile is a file relative to the library path that must exist for the library path to be considered available,
+	// e.g. a file at the root of a network mount. Empty if there is none.
+	SentinelFile string `json:"sentinelFile"`
+}
+
+type LibraryRootPolicies []*LibraryRootPolicy
+
+func (o *LibraryRootPolicies) Scan(src interface{}) error {
+	var bytes []byte
+	switch v := src.(type) {
+	case nil:
+		*o = nil
+		return nil
+	case string:
+		bytes = []byte(v)
+	case []byte:
+		bytes = v
+	default:
+		return errors.New("src value cannot cast to string")
+	}
+	if len(bytes) == 0 {
+		*o = nil
+		return nil
+	}
+	return json.Unmarshal(bytes, o)
+}
+func (o LibraryRootPolicies) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
 type NakamaSettings struct {
 	Enabled bool `gorm:"column:enabled" json:"enabled"`
 	// Username is the name used to identify a peer or host.
@@ -169,6 +276,8 @@ type MangaSettings struct {
 	DefaultProvider      string `gorm:"column:default_manga_provider" json:"defaultMangaProvider"`
 	AutoUpdateProgress   bool   `gorm:"column:manga_auto_update_progress" json:"mangaAutoUpdateProgress"`
 	LocalSourceDirectory string `gorm:"column:manga_local_source_directory" json:"mangaLocalSourceDirectory"`
+	// PageCacheSize is the size of the page image cache in MiB, 0 uses the default
+	PageCacheSize int `gorm:"column:manga_page_cache_size" json:"mangaPageCacheSize"`
 }
 
 type MediaPlayerSettings struct {
@@ -207,6 +316,45 @@ type TorrentSettings struct {
 	// v2.2+
 	// DEPRECATED, no longer used
 	HideTorrentList bool `gorm:"column:hide_torrent_list" json:"hideTorrentList"`
+	// IncompleteDirOverride is the directory the torrent client keeps incomplete downloads in.
+	// It is used when the client's API does not expose it.
+	IncompleteDirOverride string `gorm:"column:torrent_incomplete_dir_override" json:"incompleteDirOverride"`
+	// PlaybackPriority limits the download speed of the torrent client while something is being played
+	PlaybackPriority bool `gorm:"column:playback_priority" json:"playbackPriority"`
+	// PlaybackPriorityDownloadLimit is the download limit applied during playback, in KiB/s
+	PlaybackPriorityDownloadLimit int `gorm:"column:playback_priority_download_limit" json:"playbackPriorityDownloadLimit"`
+	// PlaybackPriorityMinActiveTorrents is the number of downloading torrents above which the limit is applied, 0 to ignore
+	PlaybackPriorityMinActiveTorrents int `gorm:"column:playback_priority_min_active_torrents" json:"playbackPriorityMinActiveTorrents"`
+	// PlaybackPrioritySameDevice applies the limit when a download is on the same device as the file being played
+	PlaybackPrioritySameDevice bool `gorm:"column:playback_priority_same_device" json:"playbackPrioritySameDevice"`
+	// PlaybackPriorityRestoreDelay is the number of seconds to wait after playback ends before restoring the full speed
+	PlaybackPriorityRestoreDelay int `gorm:"column:playback_priority_restore_delay" json:"playbackPriorityRestoreDelay"`
+	// CustomHeaders are added to every request sent to the torrent client, e.g. for reverse-proxy authentication
+	CustomHeaders StringMap `gorm:"column:torrent_client_custom_headers;type:text" json:"customHeaders"`
+	// SeedingPause pauses seeding torrents while media is streamed to a client outside the local network
+	SeedingPause bool `gorm:"column:seeding_pause" json:"seedingPause"`
+	// SeedingPauseLanRanges are the CIDR ranges of the local network, the private ranges are used when empty
+	SeedingPauseLanRanges StringSlice `gorm:"column:seeding_pause_lan_ranges;type:text" json:"seedingPauseLanRanges"`
+	// SeedingPauseGracePeriod is the number of seconds to wait after the remote stream ends before resuming the torrents
+	SeedingPauseGracePeriod int `gorm:"column:seeding_pause_grace_period" json:"seedingPauseGracePeriod"`
+	// SeedingPauseProtectedTags exempts torrents with any of these tags, categories or labels from pausing
+	SeedingPauseProtectedTags StringSlice `gorm:"column:seeding_pause_protected_tags;type:text" json:"seedingPauseProtectedTags"`
+	// BindInterface is the network interface the torrent traffic goes through, e.g. a VPN interface
+	BindInterface string `gorm:"column:torrent_bind_interface" json:"bindInterface"`
+	// BindAddress is the local IP address the torrent traffic goes through
+	BindAddress string `gorm:"column:torrent_bind_address" json:"bindAddress"`
+	// BindSocks5Proxy is the SOCKS5 proxy used by the embedded torrent client
+	BindSocks5Proxy string `gorm:"column:torrent_bind_socks5_proxy" json:"bindSocks5Proxy"`
+	// BindKillSwitch pauses all torrent activity when the bound interface loses its address
+	BindKillSwitch bool `gorm:"column:torrent_bind_kill_switch" json:"bindKillSwitch"`
+	// QBittorrentPathStyle is "posix" or "windows" when qBittorrent runs on another OS than the server, detected when empty
+	QBittorrentPathStyle string `gorm:"column:qbittorrent_path_style" json:"qbittorrentPathStyle"`
+	// QBittorrentPathMappings maps the directories as seen by qBittorrent to the directories as seen by the server
+	QBittorrentPathMappings StringMap `gorm:"column:qbittorrent_path_mappings;type:text" json:"qbittorrentPathMappings"`
+	// TransmissionPathStyle is "posix" or "windows" when Transmission runs on another OS than the server, detected when empty
+	TransmissionPathStyle string `gorm:"column:transmission_path_style" json:"transmissionPathStyle"`
+	// TransmissionPathMappings maps the directories as seen by Transmission to the directories as seen by the server
+	TransmissionPathMappings StringMap `gorm:"column:transmission_path_mappings;type:text" json:"transmissionPathMappings"`
 }
 
 type ListSyncSettings struct {
@@ -270,6 +418,22 @@ type AutoDownloaderItem struct {
 	Magnet      string `gorm:"column:magnet" json:"magnet"`
 	TorrentName string `gorm:"column:torrent_name" json:"torrentName"`
 	Downloaded  bool   `gorm:"column:downloaded" json:"downloaded"`
+	// AwaitingConfirmation is true if the item is waiting for the user to approve or reject it.
+	// The magnet is resolved once the item is approved.
+	AwaitingConfirmation bool `gorm:"column:awaiting_confirmation" json:"awaitingConfirmation"`
+	// Candidate is the JSON of the matched torrent, it is set for items awaiting confirmation
+	Candidate []byte `gorm:"column:candidate" json:"-"`
+	// ExpiresAt is when an item awaiting confirmation is dismissed
+	ExpiresAt    *time.Time `gorm:"column:expires_at" json:"expiresAt,omitempty"`
+	ReminderSent bool       `gorm:"column:reminder_sent" json:"-"`
+}
+
+// AutoDownloaderRejectedTorrent is a torrent rejected by the user for a rule, it is not matched by the rule again.
+type AutoDownloaderRejectedTorrent struct {
+	BaseModel
+	RuleID      uint   `gorm:"column:rule_id;index" json:"ruleId"`
+	Hash        string `gorm:"column:hash" json:"hash"`
+	TorrentName string `gorm:"column:torrent_name" json:"torrentName"`
 }
 
 type AutoDownloaderSettings struct {
@@ -280,6 +444,73 @@ type AutoDownloaderSettings struct {
 	EnableEnhancedQueries bool   `gorm:"column:auto_downloader_enable_enhanced_queries" json:"enableEnhancedQueries"`
 	EnableSeasonCheck     bool   `gorm:"column:auto_downloader_enable_season_check" json:"enableSeasonCheck"`
 	UseDebrid             bool   `gorm:"column:auto_downloader_use_debrid" json:"useDebrid"`
+	// AutoRetargetRules retargets rules to the sequel of their media once it has finished airing
+	AutoRetargetRules bool `gorm:"column:auto_downloader_auto_retarget_rules" json:"autoRetargetRules"`
+	// RequireConfirmation queues the matched torrents of all rules for the user's confirmation
+	RequireConfirmation bool `gorm:"column:auto_downloader_require_confirmation" json:"requireConfirmation"`
+	// ConfirmationExpiryDays is the number of days after which the items awaiting confirmation are dismissed, 0 uses the default
+	ConfirmationExpiryDays int `gorm:"column:auto_downloader_confirmation_expiry_days" json:"confirmationExpiryDays"`
+}
+
+// +---------------------+
+// |  Storage placement  |
+// +---------------------+
+
+type StoragePlacementRule struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+type SavedTorrentSearch struct {
+	BaseModel
+	MediaID int    `gorm:"column:media_id;index" json:"mediaId"`
+	Value   []byte `gorm:"column:value" json:"value"`
+}
+
+// TorrentResultHistory is what a user did with a torrent search result.
+// Key is the info hash of the torrent, or its provider and link when the hash is unknown.
+type TorrentResultHistory struct {
+	BaseModel
+	Owner    string `gorm:"column:owner;uniqueIndex:idx_torrent_result_history_owner_key" json:"owner"`
+	Key      string `gorm:"column:key;uniqueIndex:idx_torrent_result_history_owner_key" json:"key"`
+	Name     string `gorm:"column:name" json:"name"`
+	Provider string `gorm:"column:provider" json:"provider"`
+	// SeenAt is the last time the result was shown in a search
+	SeenAt *time.Time `gorm:"column:seen_at" json:"seenAt"`
+	// PreviousSeenAt is the time the result was shown in the search before the last one
+	PreviousSeenAt *time.Time `gorm:"column:previous_seen_at" json:"previousSeenAt"`
+	DismissedAt    *time.Time `gorm:"column:dismissed_at" json:"dismissedAt"`
+	DownloadedAt   *time.Time `gorm:"column:downloaded_at" json:"downloadedAt"`
+	// QueuedItemId is the AutoDownloader queue item that was downloaded with the torrent.
+	// The item is removed once the torrent starts downloading, 0 if there is none.
+	QueuedItemId uint `gorm:"column:queued_item_id;index" json:"queuedItemId"`
+}
+
+// TorrentSpeedSample is the aggregate speed of the torrent client at a point in time.
+// The samples are stored in a ring buffer, Slot is the position of the sample in the buffer.
+type TorrentSpeedSample struct {
+	BaseModel
+	Slot                int       `gorm:"column:slot;uniqueIndex" json:"slot"`
+	Timestamp           time.Time `gorm:"column:timestamp" json:"timestamp"`
+	DownloadBytesPerSec int64     `gorm:"column:download_bytes_per_sec" json:"downloadBytesPerSec"`
+	UploadBytesPerSec   int64     `gorm:"column:upload_bytes_per_sec" json:"uploadBytesPerSec"`
+}
+
+func (TorrentSpeedSample) TableName() string {
+	return "torrent_speed_history"
+}
+
+// ReleaseObservation is the first time the AutoDownloader saw the release of an episode by a release group.
+// It is used to predict when the next episodes become available.
+type ReleaseObservation struct {
+	BaseModel
+	MediaID      int       `gorm:"column:media_id;uniqueIndex:idx_release_observation_episode_group" json:"mediaId"`
+	Episode      int       `gorm:"column:episode;uniqueIndex:idx_release_observation_episode_group" json:"episode"`
+	ReleaseGroup string    `gorm:"column:release_group;uniqueIndex:idx_release_observation_episode_group" json:"releaseGroup"`
+	AiredAt      time.Time `gorm:"column:aired_at" json:"airedAt"`
+	SeenAt       time.Time `gorm:"column:seen_at" json:"seenAt"`
+	// DelaySeconds is the time between the airing of the episode and the release
+	DelaySeconds int64 `gorm:"column:delay_seconds" json:"delaySeconds"`
 }
 
 // +---------------------+
@@ -290,6 +521,48 @@ type SilencedMediaEntry struct {
 	BaseModel
 }
 
+// MediaAnnotation is a note and tags a user keeps locally for a media, they are not sent to AniList.
+// The notes are indexed in the media_annotations_fts full-text search table.
+type MediaAnnotation struct {
+	BaseModel
+	Owner   string      `gorm:"column:owner;uniqueIndex:idx_media_annotation_owner_media" json:"-"`
+	MediaId int         `gorm:"column:media_id;uniqueIndex:idx_media_annotation_owner_media" json:"mediaId"`
+	Note    string      `gorm:"column:note" json:"note"`
+	Tags    StringSlice `gorm:"column:tags;type:text" json:"tags"`
+}
+
+// MediaRemap records a media ID that AniList merged into another entry, or that no longer exists.
+type MediaRemap struct {
+	BaseModel
+	FromMediaID int `gorm:"column:from_media_id;index" json:"fromMediaId"`
+	// ToMediaID is 0 if the media no longer exists and its files have to be matched manually
+	ToMediaID int `gorm:"column:to_media_id" json:"toMediaId"`
+	// Value is the JSON of the remapped records and the affected files
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+// +---------------------+
+// |   Audio preference  |
+// +---------------------+
+
+// MediaAudioPreference is the audio preference of a media, the ID is the media ID.
+type MediaAudioPreference struct {
+	BaseModel
+	Preference string `gorm:"column:preference" json:"preference"`
+}
+
+// +---------------------+
+// |       UI state      |
+// +---------------------+
+
+// UIStateEntry is a key of the UI state of an authenticated user.
+type UIStateEntry struct {
+	BaseModel
+	Owner string `gorm:"column:owner;uniqueIndex:idx_ui_state_owner_key" json:"owner"`
+	Key   string `gorm:"column:key;uniqueIndex:idx_ui_state_owner_key" json:"key"`
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
 // +---------------------+
 // |        Theme        |
 // +---------------------+
@@ -457,6 +730,46 @@ type TorrentPreMatch struct {
 	MediaId     int    `gorm:"column:media_id" json:"mediaId"`              // The AniList media ID
 }
 
+// BatchTorrentWatch is a batch torrent whose files were selected by smart select and that is watched for new episodes.
+type BatchTorrentWatch struct {
+	BaseModel
+	// Hash is the info hash of the torrent, it changes when the torrent is replaced by a new revision
+	Hash    string `gorm:"column:hash;uniqueIndex" json:"hash"`
+	MediaId int    `gorm:"column:media_id;index" json:"mediaId"`
+	// Value is the JSON of the watch
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+// +-------------------------+
+// | TorrentClientMigration  |
+// +-------------------------+
+
+// TorrentClientMigration moves the torrents managed by Seanime from a torrent client to another one.
+// It is kept until the user confirms the report, the settings then switch to the target client.
+type TorrentClientMigration struct {
+	BaseModel
+	SourceClient string `gorm:"column:source_client" json:"sourceClient"`
+	TargetClient string `gorm:"column:target_client" json:"targetClient"`
+	// TargetSettings are the torrent settings saved when the migration is confirmed, marshaled TorrentSettings
+	TargetSettings   []byte     `gorm:"column:target_settings" json:"-"`
+	RemoveFromSource bool       `gorm:"column:remove_from_source" json:"removeFromSource"`
+	FinishedAt       *time.Time `gorm:"column:finished_at" json:"finishedAt"`
+}
+
+// TorrentClientMigrationItem is the result of the migration of a torrent.
+type TorrentClientMigrationItem struct {
+	BaseModel
+	MigrationID uint   `gorm:"column:migration_id;index" json:"migrationId"`
+	Hash        string `gorm:"column:hash" json:"hash"`
+	Name        string `gorm:"column:name" json:"name"`
+	// SourcePath and TargetPath are the directories of the torrent as seen by each client
+	SourcePath        string `gorm:"column:source_path" json:"sourcePath"`
+	TargetPath        string `gorm:"column:target_path" json:"targetPath"`
+	Status            string `gorm:"column:status" json:"status"`
+	Error             string `gorm:"column:error" json:"error"`
+	RemovedFromSource bool   `gorm:"column:removed_from_source" json:"removedFromSource"`
+}
+
 // +---------------------+
 // |        Filler       |
 // +---------------------+
@@ -470,6 +783,55 @@ type MediaFiller struct {
 	Data          []byte    `gorm:"column:data" json:"data"`
 }
 
+// MediaThemes stores the theme songs of a media fetched from AnimeThemes.
+// An empty Data list means that the media has no themes.
+type MediaThemes struct {
+	BaseModel
+	MediaID       int       `gorm:"column:media_id;uniqueIndex" json:"mediaId"`
+	LastFetchedAt time.Time `gorm:"column:last_fetched_at" json:"lastFetchedAt"`
+	Data          []byte    `gorm:"column:data" json:"data"`
+}
+
+// LibraryCleanupCandidate is a media whose files can be cleaned up because it was dropped or removed from the AniList list.
+type LibraryCleanupCandidate struct {
+	BaseModel
+	MediaID int    `gorm:"column:media_id;uniqueIndex" json:"mediaId"`
+	Title   string `gorm:"column:title" json:"title"`
+	// Reason is "dropped" or "removed"
+	Reason         string    `gorm:"column:reason" json:"reason"`
+	TransitionedAt time.Time `gorm:"column:transitioned_at" json:"transitionedAt"`
+	// ScheduledAt is the date after which the files are moved to the trash, nil if they are not scheduled
+	ScheduledAt *time.Time `gorm:"column:scheduled_at" json:"scheduledAt"`
+}
+
+// LibraryCleanupExemption marks a media whose files are never cleaned up.
+type LibraryCleanupExemption struct {
+	BaseModel
+	MediaID int `gorm:"column:media_id;uniqueIndex" json:"mediaId"`
+}
+
+// LibraryCleanupSnapshot stores the list statuses of the last AniList collection that was evaluated.
+// There is only one row.
+type LibraryCleanupSnapshot struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+// LocalFileAttachment is a subtitle or font file uploaded for a local file.
+type LocalFileAttachment struct {
+	BaseModel
+	// LocalFilePath is the path of the video file the attachment belongs to
+	LocalFilePath string `gorm:"column:local_file_path;index" json:"localFilePath"`
+	// Kind is "subtitle" or "font"
+	Kind string `gorm:"column:kind" json:"kind"`
+	// Path is where the attachment is stored
+	Path       string `gorm:"column:path" json:"path"`
+	Language   string `gorm:"column:language" json:"language"`
+	Label      string `gorm:"column:label" json:"label"`
+	Size       int64  `gorm:"column:size" json:"size"`
+	UploadedBy string `gorm:"column:uploaded_by" json:"uploadedBy"`
+}
+
 // +---------------------+
 // |        Manga        |
 // +---------------------+
@@ -551,11 +913,112 @@ type CustomSourceIdentifier struct {
 	Value       int    `gorm:"column:value;index" json:"value"`
 }
 
+// +---------------------+
+// |      Webhooks       |
+// +---------------------+
+
+// WebhookSubscription is a URL that receives the events of the selected types.
+type WebhookSubscription struct {
+	BaseModel
+	URL string `gorm:"column:url" json:"url"`
+	// Secret is used to sign the payloads
+	Secret     string      `gorm:"column:secret" json:"secret"`
+	EventTypes StringSlice `gorm:"column:event_types;type:text" json:"eventTypes"`
+	Enabled    bool        `gorm:"column:enabled" json:"enabled"`
+	// ConsecutiveFailures is the number of events that could not be delivered in a row
+	ConsecutiveFailures int `gorm:"column:consecutive_failures" json:"consecutiveFailures"`
+	// DisabledReason is set when the subscription is disabled after too many failures
+	DisabledReason string `gorm:"column:disabled_reason" json:"disabledReason"`
+}
+
+// WebhookDelivery is an attempt to deliver an event to a subscription.
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionID uint   `gorm:"column:subscription_id;index" json:"subscriptionId"`
+	EventID        string `gorm:"column:event_id" json:"eventId"`
+	EventType      string `gorm:"column:event_type" json:"eventType"`
+	Attempt        int    `gorm:"column:attempt" json:"attempt"`
+	StatusCode     int    `gorm:"column:status_code" json:"statusCode"`
+	Success        bool   `gorm:"column:success" json:"success"`
+	Error          string `gorm:"column:error" json:"error"`
+	// ResponseBody is the beginning of the response body
+	ResponseBody string `gorm:"column:response_body" json:"responseBody"`
+	DurationMs   int64  `gorm:"column:duration_ms" json:"durationMs"`
+}
+
+// +---------------------+
+// |    Notifications    |
+// +---------------------+
+
+// Notification is an event shown in the notification center of the app.
+type Notification struct {
+	BaseModel
+	// Type is one of the types of the notifications package, e.g. "download_started"
+	Type    string `gorm:"column:type;index" json:"type"`
+	Message string `gorm:"column:message" json:"message"`
+	MediaId int    `gorm:"column:media_id" json:"mediaId"`
+	// ReadAt is nil until the notification is marked as read
+	ReadAt *time.Time `gorm:"column:read_at;index" json:"readAt"`
+}
+
+// +---------------------+
+// |      API keys       |
+// +---------------------+
+
+// ApiKey authenticates the requests of scripts and other applications.
+// Only the SHA-256 hash of the key is stored, the key is shown once when it is created.
+type ApiKey struct {
+	BaseModel
+	Name    string `gorm:"column:name" json:"name"`
+	KeyHash string `gorm:"column:key_hash;uniqueIndex" json:"-"`
+	// Prefix is the beginning of the key, used to recognize it
+	Prefix string `gorm:"column:prefix" json:"prefix"`
+	// Scopes are the route groups the key can access, see the apikeys package
+	Scopes     StringSlice `gorm:"column:scopes;type:text" json:"scopes"`
+	LastUsedAt *time.Time  `gorm:"column:last_used_at" json:"lastUsedAt"`
+}
+
 ///////////////////////////////////////////////////////////////////////////
 
+type StringMap map[string]string
+
+func (o *StringMap) Scan(src interface{}) error {
+	var bytes []byte
+	switch v := src.(type) {
+	case nil:
+		*o = StringMap{}
+		return nil
+	case string:
+		bytes = []byte(v)
+	case []byte:
+		bytes = v
+	default:
+		return errors.New("src value cannot cast to string")
+	}
+	if len(bytes) == 0 {
+		*o = StringMap{}
+		return nil
+	}
+	return json.Unmarshal(bytes, o)
+}
+func (o StringMap) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return nil, nil
+	}
+	bytes, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(bytes), nil
+}
+
 type StringSlice []string
 
 func (o *StringSlice) Scan(src interface{}) error {
+	if src == nil {
+		*o = nil
+		return nil
+	}
 	str, ok := src.(string)
 	if !ok {
 		return errors.New("src value cannot cast to string")
@@ -70,6 +70,13 @@ func (s *Settings) GetNakama() *NakamaSettings {
 	return s.Nakama
 }
 
+func (s *Settings) GetServer() *ServerSettings {
+	if s == nil || s.Server == nil {
+		return &ServerSettings{}
+	}
+	return s.Server
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func (s *Settings) GetSensitiveValues() []string {
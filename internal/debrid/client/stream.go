@@ -52,6 +52,7 @@ type (
 		PlaybackType      StreamPlaybackType
 		AutoSelect        bool
 		BatchEpisodeFiles *hibiketorrent.BatchEpisodeFiles
+		Incognito         bool // Do not update the progress when using the media player, see [playbackmanager.PlaybackManager.SetIncognito]
 	}
 
 	CancelStreamOptions struct {
@@ -443,6 +444,7 @@ func (s *StreamManager) startStream(ctx context.Context, opts *StartStreamOption
 				Payload:   streamUrl,
 				UserAgent: opts.UserAgent,
 				ClientId:  opts.ClientId,
+				Incognito: opts.Incognito,
 			}, media, aniDbEpisode)
 			if err != nil {
 				go s.repository.playbackManager.UnsubscribeFromPlaybackStatus("debridstream")
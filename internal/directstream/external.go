@@ -0,0 +1,128 @@
+package directstream
+
+import (
+	"context"
+	"seanime/internal/library/sidecar"
+	"seanime/internal/mkvparser"
+	"seanime/internal/util"
+	"slices"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// externalTrackNumberBase is the base number of the subtitle tracks uploaded for a local file.
+	// The number of the track is externalTrackNumberBase + the ID of the attachment.
+	externalTrackNumberBase = 10_000
+	// externalUIDBase is the base UID of the subtitle tracks and fonts uploaded for a local file.
+	externalUIDBase = 1 << 40
+)
+
+var fontMimetypes = map[string]string{
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".ttc":   "font/collection",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+}
+
+// applyExternalAttachments replaces the subtitle tracks and fonts uploaded for the local file in the metadata.
+// The metadata is shared by every stream of the file, so the previous external attachments are removed first.
+// Returns the subtitle tracks that were not in the metadata before.
+func (m *Manager) applyExternalAttachments(localFilePath string, metadata *mkvparser.Metadata) []*mkvparser.TrackInfo {
+	if m.sidecarStore == nil || metadata == nil {
+		return nil
+	}
+
+	m.externalMu.Lock()
+	defer m.externalMu.Unlock()
+
+	isExternalTrack := func(t *mkvparser.TrackInfo, _ int) bool { return t.UID >= externalUIDBase }
+
+	previous := lo.Filter(metadata.SubtitleTracks, isExternalTrack)
+	tracks := lo.Reject(metadata.Tracks, isExternalTrack)
+	subtitleTracks := lo.Reject(metadata.SubtitleTracks, isExternalTrack)
+	attachments := lo.Reject(metadata.Attachments, func(a *mkvparser.AttachmentInfo, _ int) bool { return a.UID >= externalUIDBase })
+
+	added := make([]*mkvparser.TrackInfo, 0)
+	for _, a := range m.sidecarStore.GetByLocalFile(localFilePath) {
+		uid := uint64(externalUIDBase) + uint64(a.ID)
+
+		content, err := a.ReadContent()
+		if err != nil {
+			m.Logger.Warn().Err(err).Str("path", a.Path).Msg("directstream: Failed to read attachment")
+			continue
+		}
+
+		switch a.Kind {
+		case sidecar.KindSubtitle:
+			if existing, found := lo.Find(previous, func(t *mkvparser.TrackInfo) bool { return t.UID == uid }); found {
+				tracks = append(tracks, existing)
+				subtitleTracks = append(subtitleTracks, existing)
+				continue
+			}
+
+			converted, err := convertSubtitleToASS(a.Extension, string(content))
+			if err != nil {
+				m.Logger.Warn().Err(err).Str("path", a.Path).Msg("directstream: Failed to convert attachment")
+				continue
+			}
+
+			track := &mkvparser.TrackInfo{
+				Number:       externalTrackNumberBase + int64(a.ID),
+				UID:          uid,
+				Type:         mkvparser.TrackTypeSubtitle,
+				CodecID:      "S_TEXT/ASS",
+				Name:         a.DisplayName(),
+				Language:     lo.Ternary(a.Language != "", a.Language, "und"),
+				LanguageIETF: lo.Ternary(a.Language != "", a.Language, "und"),
+				Enabled:      true,
+				CodecPrivate: converted,
+			}
+			tracks = append(tracks, track)
+			subtitleTracks = append(subtitleTracks, track)
+			added = append(added, track)
+
+		case sidecar.KindFont:
+			attachments = append(attachments, &mkvparser.AttachmentInfo{
+				UID:      uid,
+				Filename: a.Filename,
+				Mimetype: fontMimetypes[a.Extension],
+				Size:     len(content),
+				Type:     mkvparser.AttachmentTypeFont,
+				Data:     content,
+			})
+		}
+	}
+
+	// Replace the slices instead of modifying them, they might be read by the client handlers
+	metadata.Tracks = slices.Clip(tracks)
+	metadata.SubtitleTracks = slices.Clip(subtitleTracks)
+	metadata.Attachments = slices.Clip(attachments)
+
+	return added
+}
+
+// RefreshExternalAttachments updates the cached metadata of the local file after an attachment has been added or removed.
+// If the file is being played, the new subtitle tracks are sent to the client.
+func (m *Manager) RefreshExternalAttachments(localFilePath string) {
+	parser, ok := m.parserCache.Get(util.Base64EncodeStr(localFilePath))
+	if !ok {
+		return
+	}
+
+	added := m.applyExternalAttachments(localFilePath, parser.GetMetadata(context.Background()))
+
+	stream, ok := m.currentStream.Get()
+	if !ok {
+		return
+	}
+	lfStream, ok := stream.(*LocalFileStream)
+	if !ok || lfStream.localFile == nil || util.NormalizePath(lfStream.localFile.Path) != util.NormalizePath(localFilePath) {
+		return
+	}
+
+	for _, track := range added {
+		m.nativePlayer.AddSubtitleTrack(stream.ClientId(), track)
+	}
+}
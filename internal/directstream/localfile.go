@@ -131,6 +131,9 @@ func (s *LocalFileStream) LoadPlaybackInfo() (ret *nativeplayer.PlaybackInfo, er
 				return
 			}
 
+			// Add the subtitles and fonts uploaded for the file
+			s.manager.applyExternalAttachments(s.localFile.Path, metadata)
+
 			playbackInfo.MkvMetadata = metadata
 			playbackInfo.MkvMetadataParser = mo.Some(parser)
 		}
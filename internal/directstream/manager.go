@@ -8,9 +8,11 @@ import (
 	discordrpc_presence "seanime/internal/discordrpc/presence"
 	"seanime/internal/events"
 	"seanime/internal/library/anime"
+	"seanime/internal/library/sidecar"
 	"seanime/internal/mkvparser"
 	"seanime/internal/nativeplayer"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/playback_priority"
 	"seanime/internal/util"
 	"seanime/internal/util/result"
 	"sync"
@@ -65,6 +67,11 @@ type (
 		currentSessionID             string
 		currentSessionMu             sync.Mutex
 		updateProgressForSessionFunc func(ctx context.Context, sessionID string, mediaID int, progress int, totalEpisodes *int) error
+
+		playbackPriority *playback_priority.Manager
+
+		sidecarStore *sidecar.Store // Optional, attachments uploaded for local files
+		externalMu   sync.Mutex
 	}
 
 	Settings struct {
@@ -83,6 +90,8 @@ type (
 		IsOfflineRef                 *util.Ref[bool]
 		NativePlayer                 *nativeplayer.NativePlayer
 		UpdateProgressForSessionFunc func(ctx context.Context, sessionID string, mediaID int, progress int, totalEpisodes *int) error
+		PlaybackPriority             *playback_priority.Manager // Optional
+		SidecarStore                 *sidecar.Store             // Optional
 	}
 )
 
@@ -100,6 +109,8 @@ func NewManager(options NewManagerOptions) *Manager {
 		nativePlayer:                 options.NativePlayer,
 		parserCache:                  result.NewCache[string, *mkvparser.MetadataParser](),
 		updateProgressForSessionFunc: options.UpdateProgressForSessionFunc,
+		playbackPriority:             options.PlaybackPriority,
+		sidecarStore:                 options.SidecarStore,
 	}
 
 	ret.nativePlayerSubscriber = ret.nativePlayer.Subscribe("directstream")
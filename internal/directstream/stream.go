@@ -136,6 +136,14 @@ func (m *Manager) loadStream(stream Stream) {
 	m.Logger.Debug().Msgf("directstream: Loading stream")
 	m.currentStream = mo.Some(stream)
 
+	if m.playbackPriority != nil {
+		path := ""
+		if localFileStream, ok := stream.(*LocalFileStream); ok && localFileStream.localFile != nil {
+			path = localFileStream.localFile.Path
+		}
+		m.playbackPriority.StartSession("directstream", string(stream.Type()), path)
+	}
+
 	// Create a new context
 	ctx, cancel := context.WithCancel(context.Background())
 	m.playbackCtx = ctx
@@ -327,6 +335,9 @@ func (m *Manager) unloadStream() {
 	}
 
 	m.currentStream = mo.None[Stream]()
+	if m.playbackPriority != nil {
+		m.playbackPriority.EndSession("directstream")
+	}
 	m.Logger.Debug().Msg("directstream: Stream unloaded successfully")
 }
 
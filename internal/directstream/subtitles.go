@@ -441,34 +441,14 @@ func (s *BaseStream) OnSubtitleFileUploaded(filename string, content string) {
 
 	ext := util.FileExt(filename)
 
-	newContent := content
-	if ext != ".ass" && ext != ".ssa" {
-		var err error
-		var from int
-		switch ext {
-		case ".srt":
-			from = mkvparser.SubtitleTypeSRT
-		case ".vtt":
-			from = mkvparser.SubtitleTypeWEBVTT
-		case ".ttml":
-			from = mkvparser.SubtitleTypeTTML
-		case ".stl":
-			from = mkvparser.SubtitleTypeSTL
-		case ".txt":
-			from = mkvparser.SubtitleTypeUnknown
-		default:
-			err = errors.New("unsupported subtitle format")
-		}
-		s.logger.Debug().
-			Str("filename", filename).
-			Str("ext", ext).
-			Int("detected", from).
-			Msg("directstream: Converting uploaded subtitle file")
-		newContent, err = mkvparser.ConvertToASS(content, from)
-		if err != nil {
-			s.manager.wsEventManager.SendEventTo(s.clientId, events.ErrorToast, "Failed to convert subtitle file: "+err.Error())
-			return
-		}
+	s.logger.Debug().
+		Str("filename", filename).
+		Str("ext", ext).
+		Msg("directstream: Converting uploaded subtitle file")
+	newContent, err := convertSubtitleToASS(ext, content)
+	if err != nil {
+		s.manager.wsEventManager.SendEventTo(s.clientId, events.ErrorToast, "Failed to convert subtitle file: "+err.Error())
+		return
 	}
 
 	metadata := parser.GetMetadata(context.Background())
@@ -520,3 +500,25 @@ func (s *BaseStream) OnSubtitleFileUploaded(filename string, content string) {
 
 	s.manager.nativePlayer.AddSubtitleTrack(s.clientId, track)
 }
+
+// convertSubtitleToASS converts the content of a subtitle file to ASS based on its extension.
+func convertSubtitleToASS(ext string, content string) (string, error) {
+	var from int
+	switch ext {
+	case ".ass", ".ssa":
+		return content, nil
+	case ".srt":
+		from = mkvparser.SubtitleTypeSRT
+	case ".vtt":
+		from = mkvparser.SubtitleTypeWEBVTT
+	case ".ttml":
+		from = mkvparser.SubtitleTypeTTML
+	case ".stl":
+		from = mkvparser.SubtitleTypeSTL
+	case ".txt":
+		from = mkvparser.SubtitleTypeUnknown
+	default:
+		return "", errors.New("unsupported subtitle format")
+	}
+	return mkvparser.ConvertToASS(content, from)
+}
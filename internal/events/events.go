@@ -25,10 +25,27 @@ const (
 	LibraryWatcherFileAdded         = "library-watcher-file-added"         // A new file has been added to the library
 	LibraryWatcherFileRemoved       = "library-watcher-file-removed"       // A file has been removed from the library
 	AutoDownloaderItemAdded         = "auto-downloader-item-added"         // An item has been added to the auto downloader queue
+	AutoDownloaderItemPending       = "auto-downloader-item-pending"       // A matched torrent is waiting for the user's confirmation
+	AutoDownloaderRuleSequelFound   = "auto-downloader-rule-sequel-found"  // A rule's media has finished and its sequel can be targeted
+	AutoDownloaderRuleRetargeted    = "auto-downloader-rule-retargeted"    // A rule has been retargeted or cloned to a sequel
+	LibraryCleanupCandidatesAdded   = "library-cleanup-candidates-added"   // Dropped or removed media have files that can be cleaned up
+	MediaRemapped                   = "media-remapped"                     // Media IDs merged or deleted by AniList have been remapped or flagged
 
 	AutoScanStarted   = "auto-scan-started"   // The auto scan has started
 	AutoScanCompleted = "auto-scan-completed" // The auto scan has stopped
 
+	MaintenanceModeUpdated = "maintenance-mode-updated" // Maintenance mode has been entered, extended or exited
+
+	PublicAnnouncementUpdated = "public-announcement-updated" // The announcement of the public status page has changed
+
+	NetworkBindingUpdated = "network-binding-updated" // The bound interface of the torrent clients went down or came back up
+
+	TorrentClientMigrationUpdated = "torrent-client-migration-updated" // A torrent has been processed by the torrent client migration
+
+	NotificationNew = "notification:new" // A notification has been added to the notification center
+
+	SessionExpired = "session-expired" // The session of the client has been removed after being idle for too long or revoked
+
 	PlaybackManagerProgressTrackingStarted     = "playback-manager-progress-tracking-started"      // The video progress tracking has started
 	PlaybackManagerProgressTrackingStopped     = "playback-manager-progress-tracking-stopped"      // The video progress tracking has stopped
 	PlaybackManagerProgressVideoCompleted      = "playback-manager-progress-video-completed"       // The video progress has been completed
@@ -37,6 +54,8 @@ const (
 	PlaybackManagerPlaylistState               = "playback-manager-playlist-state"                 // Dispatches the current playlist state
 	PlaybackManagerManualTrackingPlaybackState = "playback-manager-manual-tracking-playback-state" // Dispatches the current playback state
 	PlaybackManagerManualTrackingStopped       = "playback-manager-manual-tracking-stopped"        // The manual tracking has been stopped
+	PlaybackManagerIncognitoChanged            = "playback-manager-incognito-changed"              // The incognito mode of the current playback has been toggled
+	PlaybackManagerSetIncognito                = "playback-manager-set-incognito"                  // The client toggles the incognito mode of the current playback
 
 	ExternalPlayerOpenURL = "external-player-open-url" // Open a URL to send media to an external media player
 
@@ -52,7 +71,8 @@ const (
 	ChapterDownloadQueueUpdated = "chapter-download-queue-updated"
 	OfflineSnapshotCreated      = "offline-snapshot-created"
 
-	MediastreamShutdownStream = "mediastream-shutdown-stream"
+	MediastreamShutdownStream        = "mediastream-shutdown-stream"
+	MediastreamMediaContainerUpdated = "mediastream-media-container-updated" // The subtitles or fonts of the current media container have changed
 
 	ExtensionsReloaded    = "extensions-reloaded"
 	ExtensionUpdatesFound = "extension-updates-found"
@@ -77,6 +97,8 @@ const (
 	ShowIndefiniteLoader = "show-indefinite-loader"
 	HideIndefiniteLoader = "hide-indefinite-loader"
 
+	UIStateChanged = "ui-state-changed" // A key of the UI state was modified by another client of the same user
+
 	// Nakama events
 	NakamaHostStarted          = "nakama-host-started"
 	NakamaHostStopped          = "nakama-host-stopped"
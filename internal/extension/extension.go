@@ -64,12 +64,16 @@ type Extension struct {
 	PayloadURI string `json:"payloadURI,omitempty"`
 	// Plugin is the manifest of the extension if it is a plugin.
 	Plugin *PluginManifest `json:"plugin,omitempty"`
+	// Network declares the hosts the extension makes requests to.
+	// If it is not set, the extension can only make requests to the hosts of its website, manifest and payload URLs.
+	Network *NetworkManifest `json:"network,omitempty"`
 
 	// IsDevelopment is true if the extension is in development mode.
 	// If true, the extension code will be loaded from PayloadURI and allow you to edit the code from an editor and reload the extension without restarting the application.
 	IsDevelopment bool `json:"isDevelopment,omitempty"`
 
 	SavedUserConfig *SavedUserConfig `json:"-"` // Contains the saved user config for the extension
+	NetworkPolicy   *NetworkPolicy   `json:"-"` // Enforced on the requests made by the extension, set when it is loaded
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -95,6 +99,7 @@ type BaseExtension interface {
 	GetUserConfig() *UserConfig
 	GetSavedUserConfig() *SavedUserConfig
 	GetIsDevelopment() bool
+	GetNetwork() *NetworkManifest
 }
 
 type Configurable interface {
@@ -121,6 +126,7 @@ func ToExtensionData(ext BaseExtension) *Extension {
 		PayloadURI:      ext.GetPayloadURI(),
 		IsDevelopment:   ext.GetIsDevelopment(),
 		SavedUserConfig: ext.GetSavedUserConfig(),
+		Network:         ext.GetNetwork(),
 	}
 }
 
@@ -60,6 +60,10 @@ type (
 		Title string `json:"title"`
 		// e.g., "1", "1.5", "2", "3"
 		Chapter string `json:"chapter"`
+		// The volume the chapter belongs to, e.g., "1", "2".
+		// It is used to compute the volume progress.
+		// Leave it empty if the volume is unknown, e.g., the chapter has not been released in a volume yet.
+		Volume string `json:"volume,omitempty"`
 		// From 0 to n
 		Index uint `json:"index"`
 		// The scanlator that translated the chapter.
@@ -49,6 +49,23 @@ type (
 		GetSettings() AnimeProviderSettings
 	}
 
+	// AnimePaginatedProvider is implemented by providers that can return more than one page of results.
+	// Providers that do not implement it only return the first page.
+	AnimePaginatedProvider interface {
+		// SearchPage returns a page of search results and the cursor of the next page.
+		SearchPage(opts AnimeSearchOptions) (*AnimeSearchPage, error)
+		// SmartSearchPage returns a page of smart search results and the cursor of the next page.
+		SmartSearchPage(opts AnimeSmartSearchOptions) (*AnimeSearchPage, error)
+	}
+
+	// AnimeSearchPage is a page of search results.
+	AnimeSearchPage struct {
+		Torrents []*AnimeTorrent `json:"torrents"`
+		// Cursor to pass to get the next page.
+		// Leave this empty if there are no more results.
+		NextCursor string `json:"nextCursor,omitempty"`
+	}
+
 	Media struct {
 		// AniList ID of the media.
 		ID int `json:"id"`
@@ -92,6 +109,9 @@ type (
 		Media Media `json:"media"`
 		// The user search query.
 		Query string `json:"query"`
+		// The cursor returned with the previous page.
+		// This will be empty for the first page.
+		Cursor string `json:"cursor,omitempty"`
 	}
 
 	AnimeSmartSearchOptions struct {
@@ -117,6 +137,9 @@ type (
 		// Indicates whether the user wants to search for the best releases.
 		// This will be false if your extension does not support filtering by best releases.
 		BestReleases bool `json:"bestReleases"`
+		// The cursor returned with the previous page.
+		// This will be empty for the first page.
+		Cursor string `json:"cursor,omitempty"`
 	}
 
 	AnimeTorrent struct {
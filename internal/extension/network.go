@@ -0,0 +1,293 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNetworkDomainNotAllowed = errors.New("extension: domain is not in the network allowlist")
+	ErrNetworkPrivateAddress   = errors.New("extension: requests to private network addresses are not allowed")
+)
+
+// maxNetworkViolations is the number of violations kept per extension
+const maxNetworkViolations = 20
+
+type (
+	// NetworkManifest declares the hosts an extension makes requests to.
+	// It is defined in the manifest file.
+	NetworkManifest struct {
+		// Domains the extension can make requests to, e.g. "example.com".
+		// "*.example.com" matches the subdomains of example.com but not example.com itself.
+		Domains []string `json:"domains,omitempty"`
+		// AllowPrivateNetwork allows requests to loopback, link-local and private (RFC 1918) addresses.
+		AllowPrivateNetwork bool `json:"allowPrivateNetwork,omitempty"`
+	}
+
+	// NetworkOverride is set by the user to extend or tighten the network policy of an extension.
+	NetworkOverride struct {
+		// AllowedDomains are added to the domains of the manifest
+		AllowedDomains []string `json:"allowedDomains"`
+		// BlockedDomains are removed from the domains of the manifest, they take precedence over AllowedDomains
+		BlockedDomains []string `json:"blockedDomains"`
+		// AllowPrivateNetwork replaces the value of the manifest if set
+		AllowPrivateNetwork *bool `json:"allowPrivateNetwork,omitempty"`
+	}
+
+	// NetworkPolicy is the network policy enforced on the requests made by an extension.
+	// It is built from the manifest and the user override when the extension is loaded.
+	NetworkPolicy struct {
+		ExtensionID         string   `json:"extensionId"`
+		Domains             []string `json:"domains"`
+		BlockedDomains      []string `json:"blockedDomains"`
+		AllowPrivateNetwork bool     `json:"allowPrivateNetwork"`
+		// Declared is false if the manifest does not declare the network domains,
+		// in which case the domains are the hosts of the manifest URLs.
+		Declared bool `json:"declared"`
+
+		violations *NetworkViolationLog
+		// lookupIP is replaced in tests
+		lookupIP func(ctx context.Context, host string) ([]net.IP, error)
+	}
+
+	// NetworkViolation is a request that was blocked by the network policy of an extension.
+	NetworkViolation struct {
+		URL    string    `json:"url"`
+		Reason string    `json:"reason"`
+		Time   time.Time `json:"time"`
+	}
+
+	// NetworkHealth is the network status of an extension.
+	NetworkHealth struct {
+		Policy         *NetworkPolicy      `json:"policy"`
+		ViolationCount int                 `json:"violationCount"`
+		Violations     []*NetworkViolation `json:"violations"`
+		// Warning is set if the extension does not declare its network domains
+		Warning string `json:"warning,omitempty"`
+	}
+
+	// NetworkViolationLog keeps the most recent violations of each extension.
+	NetworkViolationLog struct {
+		mu         sync.Mutex
+		counts     map[string]int
+		violations map[string][]*NetworkViolation
+		// OnViolation is called after a violation is recorded, e.g. to log it
+		OnViolation func(extensionId string, v *NetworkViolation)
+	}
+)
+
+func NewNetworkViolationLog() *NetworkViolationLog {
+	return &NetworkViolationLog{
+		counts:     make(map[string]int),
+		violations: make(map[string][]*NetworkViolation),
+	}
+}
+
+// Record adds a violation of the extension.
+func (l *NetworkViolationLog) Record(extensionId string, v *NetworkViolation) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.counts[extensionId]++
+	violations := append(l.violations[extensionId], v)
+	if len(violations) > maxNetworkViolations {
+		violations = violations[len(violations)-maxNetworkViolations:]
+	}
+	l.violations[extensionId] = violations
+	onViolation := l.OnViolation
+	l.mu.Unlock()
+
+	if onViolation != nil {
+		onViolation(extensionId, v)
+	}
+}
+
+// Get returns the number of violations of the extension and the most recent ones, most recent first.
+func (l *NetworkViolationLog) Get(extensionId string) (int, []*NetworkViolation) {
+	if l == nil {
+		return 0, []*NetworkViolation{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ret := slices.Clone(l.violations[extensionId])
+	slices.Reverse(ret)
+	if ret == nil {
+		ret = []*NetworkViolation{}
+	}
+	return l.counts[extensionId], ret
+}
+
+// Clear removes the violations of the extension.
+func (l *NetworkViolationLog) Clear(extensionId string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.counts, extensionId)
+	delete(l.violations, extensionId)
+}
+
+// NewNetworkPolicy builds the network policy of the extension.
+// If the manifest does not declare the network domains, the hosts of the website, manifest and payload URLs are allowed.
+func NewNetworkPolicy(ext *Extension, override *NetworkOverride, violations *NetworkViolationLog) *NetworkPolicy {
+	ret := &NetworkPolicy{
+		ExtensionID:    ext.ID,
+		Domains:        make([]string, 0),
+		BlockedDomains: make([]string, 0),
+		violations:     violations,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+
+	if ext.Network != nil {
+		ret.Declared = true
+		ret.AllowPrivateNetwork = ext.Network.AllowPrivateNetwork
+		for _, domain := range ext.Network.Domains {
+			ret.addDomain(domain)
+		}
+	} else {
+		for _, uri := range []string{ext.Website, ext.ManifestURI, ext.PayloadURI} {
+			if u, err := url.Parse(uri); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != "" {
+				ret.addDomain(u.Hostname())
+			}
+		}
+	}
+
+	if override != nil {
+		for _, domain := range override.AllowedDomains {
+			ret.addDomain(domain)
+		}
+		for _, domain := range override.BlockedDomains {
+			if domain = normalizeNetworkDomain(domain); domain != "" && !slices.Contains(ret.BlockedDomains, domain) {
+				ret.BlockedDomains = append(ret.BlockedDomains, domain)
+			}
+		}
+		if override.AllowPrivateNetwork != nil {
+			ret.AllowPrivateNetwork = *override.AllowPrivateNetwork
+		}
+	}
+
+	return ret
+}
+
+// GetNetworkPolicy returns the network policy of the extension, nil if it was not loaded by the repository.
+func (ext *Extension) GetNetworkPolicy() *NetworkPolicy {
+	if ext == nil {
+		return nil
+	}
+	return ext.NetworkPolicy
+}
+
+// Warning returns the warning shown for the extensions that do not declare their network domains.
+func (p *NetworkPolicy) Warning() string {
+	if p.Declared {
+		return ""
+	}
+	return "The extension does not declare the domains it makes requests to, only the hosts of its manifest URLs are allowed"
+}
+
+// CheckURL returns an error if the extension is not allowed to make a request to the URL.
+// The host is resolved to make sure it does not point to a private address.
+// The violation is recorded.
+func (p *NetworkPolicy) CheckURL(ctx context.Context, u *url.URL) error {
+	err := p.checkURL(ctx, u)
+	if err != nil {
+		uri := ""
+		if u != nil {
+			uri = u.Redacted()
+		}
+		p.violations.Record(p.ExtensionID, &NetworkViolation{
+			URL:    uri,
+			Reason: err.Error(),
+			Time:   time.Now(),
+		})
+	}
+	return err
+}
+
+func (p *NetworkPolicy) checkURL(ctx context.Context, u *url.URL) error {
+	if u == nil || u.Hostname() == "" {
+		return fmt.Errorf("%w: invalid URL", ErrNetworkDomainNotAllowed)
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if !p.IsDomainAllowed(host) {
+		return fmt.Errorf("%w: %s", ErrNetworkDomainNotAllowed, host)
+	}
+
+	if p.AllowPrivateNetwork {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateNetworkIP(ip) {
+			return fmt.Errorf("%w: %s", ErrNetworkPrivateAddress, host)
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrNetworkPrivateAddress, host)
+	}
+
+	ips, err := p.lookupIP(ctx, host)
+	if err != nil {
+		// The request would fail anyway
+		return nil
+	}
+	for _, ip := range ips {
+		if IsPrivateNetworkIP(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNetworkPrivateAddress, host, ip)
+		}
+	}
+	return nil
+}
+
+// IsDomainAllowed returns true if the host matches one of the allowed domains and none of the blocked domains.
+func (p *NetworkPolicy) IsDomainAllowed(host string) bool {
+	host = normalizeNetworkDomain(host)
+	for _, domain := range p.BlockedDomains {
+		if matchNetworkDomain(domain, host) {
+			return false
+		}
+	}
+	for _, domain := range p.Domains {
+		if matchNetworkDomain(domain, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivateNetworkIP returns true if the address is a loopback, link-local, private (RFC 1918, RFC 4193) or unspecified address.
+func IsPrivateNetworkIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+func (p *NetworkPolicy) addDomain(domain string) {
+	if domain = normalizeNetworkDomain(domain); domain != "" && !slices.Contains(p.Domains, domain) {
+		p.Domains = append(p.Domains, domain)
+	}
+}
+
+func normalizeNetworkDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// matchNetworkDomain returns true if the host is the domain or, if the domain starts with "*.", one of its subdomains.
+func matchNetworkDomain(domain string, host string) bool {
+	if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == domain
+}
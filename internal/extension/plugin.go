@@ -401,3 +401,7 @@ func (m *PluginExtensionImpl) GetPayloadURI() string {
 func (m *PluginExtensionImpl) GetIsDevelopment() bool {
 	return m.ext.IsDevelopment
 }
+
+func (m *PluginExtensionImpl) GetNetwork() *NetworkManifest {
+	return m.ext.Network
+}
@@ -320,6 +320,7 @@ func (r *Repository) UninstallExternalExtension(id string) error {
 
 	go func() {
 		_ = r.deleteExtensionUserConfig(id)
+		_ = r.deleteExtensionNetworkOverride(id)
 
 		// Delete the plugin data if it was a plugin
 		if ext.Type == extension.TypePlugin {
@@ -733,6 +734,9 @@ func (r *Repository) loadExternalExtension(filePath string) {
 	// Load user config
 	configErr := r.loadUserConfig(ext)
 
+	// Restrict the network requests of the extension
+	r.loadNetworkPolicy(ext)
+
 	// If there was an error loading the user config, we add it to the InvalidExtensions list
 	// BUT we still load the extension
 	// DEVNOTE: Failure to load the user config is not a critical error
@@ -8,6 +8,7 @@ import (
 	"seanime/internal/goja/goja_runtime"
 	"seanime/internal/util"
 
+	"github.com/dop251/goja"
 	"github.com/rs/zerolog"
 )
 
@@ -27,29 +28,38 @@ func NewGojaAnimeTorrentProvider(ext *extension.Extension, language extension.La
 	return provider, provider, nil
 }
 
-func (g *GojaAnimeTorrentProvider) Search(opts hibiketorrent.AnimeSearchOptions) (ret []*hibiketorrent.AnimeTorrent, err error) {
-	defer util.HandlePanicInModuleWithError(g.ext.ID+".Search", &err)
-
-	method, err := g.callClassMethod(context.Background(), "search", structToMap(opts))
+var _ hibiketorrent.AnimePaginatedProvider = (*GojaAnimeTorrentProvider)(nil)
 
-	promiseRes, err := g.waitForPromise(method)
+func (g *GojaAnimeTorrentProvider) Search(opts hibiketorrent.AnimeSearchOptions) (ret []*hibiketorrent.AnimeTorrent, err error) {
+	page, err := g.SearchPage(opts)
 	if err != nil {
 		return nil, err
 	}
+	return page.Torrents, nil
+}
 
-	err = g.unmarshalValue(promiseRes, &ret)
+func (g *GojaAnimeTorrentProvider) SmartSearch(opts hibiketorrent.AnimeSmartSearchOptions) (ret []*hibiketorrent.AnimeTorrent, err error) {
+	page, err := g.SmartSearchPage(opts)
 	if err != nil {
 		return nil, err
 	}
+	return page.Torrents, nil
+}
 
-	for i := range ret {
-		ret[i].Provider = g.ext.ID
+func (g *GojaAnimeTorrentProvider) SearchPage(opts hibiketorrent.AnimeSearchOptions) (ret *hibiketorrent.AnimeSearchPage, err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID+".Search", &err)
+
+	method, err := g.callClassMethod(context.Background(), "search", structToMap(opts))
+
+	promiseRes, err := g.waitForPromise(method)
+	if err != nil {
+		return nil, err
 	}
 
-	return
+	return g.unmarshalSearchPage(promiseRes)
 }
 
-func (g *GojaAnimeTorrentProvider) SmartSearch(opts hibiketorrent.AnimeSmartSearchOptions) (ret []*hibiketorrent.AnimeTorrent, err error) {
+func (g *GojaAnimeTorrentProvider) SmartSearchPage(opts hibiketorrent.AnimeSmartSearchOptions) (ret *hibiketorrent.AnimeSearchPage, err error) {
 	defer util.HandlePanicInModuleWithError(g.ext.ID+".SmartSearch", &err)
 
 	method, err := g.callClassMethod(context.Background(), "smartSearch", structToMap(opts))
@@ -59,16 +69,29 @@ func (g *GojaAnimeTorrentProvider) SmartSearch(opts hibiketorrent.AnimeSmartSear
 		return nil, err
 	}
 
-	err = g.unmarshalValue(promiseRes, &ret)
+	return g.unmarshalSearchPage(promiseRes)
+}
+
+// unmarshalSearchPage reads the result of "search" or "smartSearch".
+// Extensions that support pagination return an object with the torrents and the next cursor, others return the torrents.
+func (g *GojaAnimeTorrentProvider) unmarshalSearchPage(value goja.Value) (*hibiketorrent.AnimeSearchPage, error) {
+	ret := &hibiketorrent.AnimeSearchPage{}
+
+	var err error
+	if _, isList := value.Export().([]interface{}); isList {
+		err = g.unmarshalValue(value, &ret.Torrents)
+	} else {
+		err = g.unmarshalValue(value, ret)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	for i := range ret {
-		ret[i].Provider = g.ext.ID
+	for i := range ret.Torrents {
+		ret.Torrents[i].Provider = g.ext.ID
 	}
 
-	return
+	return ret, nil
 }
 
 func (g *GojaAnimeTorrentProvider) GetTorrentInfoHash(torrent *hibiketorrent.AnimeTorrent) (ret string, err error) {
@@ -17,6 +17,7 @@ import (
 	"seanime/internal/mediaplayers/mediaplayer"
 	"seanime/internal/mediaplayers/mpv"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/plugin"
 	"seanime/internal/test_utils"
 	"seanime/internal/util"
@@ -759,7 +760,7 @@ func getPlaybackManager(t *testing.T) (*playbackmanager.PlaybackManager, *anilis
 	require.NoError(t, err)
 	anilistClient := anilist.TestGetMockAnilistClient()
 	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistClient, logger, database)
-	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), true)
+	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), platform.FreshCollection)
 	metadataProvider := metadata_provider.GetMockProvider(t, database)
 	require.NoError(t, err)
 	continuityManager := continuity.NewManager(&continuity.NewManagerOptions{
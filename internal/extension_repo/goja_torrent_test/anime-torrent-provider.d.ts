@@ -32,6 +32,8 @@ declare interface FuzzyDate {
 declare interface AnimeSearchOptions {
     media: Media
     query: string
+    // The cursor returned with the previous page, undefined for the first page.
+    cursor?: string
 }
 
 declare interface AnimeSmartSearchOptions {
@@ -43,6 +45,8 @@ declare interface AnimeSmartSearchOptions {
     anidbAID: number
     anidbEID: number
     bestReleases: boolean
+    // The cursor returned with the previous page, undefined for the first page.
+    cursor?: string
 }
 
 declare interface AnimeTorrent {
@@ -65,12 +69,20 @@ declare interface AnimeTorrent {
     confirmed: boolean
 }
 
+// A page of search results.
+// Return this instead of an array to support pagination.
+declare interface AnimeSearchPage {
+    torrents: AnimeTorrent[]
+    // The cursor to pass to get the next page, leave it undefined if there are no more results.
+    nextCursor?: string
+}
+
 declare interface AnimeTorrentProvider {
     // Returns the search results depending on the query.
-    search(opts: AnimeSearchOptions): Promise<AnimeTorrent[]>
+    search(opts: AnimeSearchOptions): Promise<AnimeTorrent[] | AnimeSearchPage>
 
     // Returns the search results depending on the search options.
-    smartSearch(opts: AnimeSmartSearchOptions): Promise<AnimeTorrent[]>
+    smartSearch(opts: AnimeSmartSearchOptions): Promise<AnimeTorrent[] | AnimeSearchPage>
 
     // Returns the info hash of the torrent.
     // This should just return the info hash without scraping the torrent page if already available.
@@ -0,0 +1,138 @@
+package extension_repo
+
+import (
+	"fmt"
+	"seanime/internal/events"
+	"seanime/internal/extension"
+	"seanime/internal/util"
+	"seanime/internal/util/filecache"
+)
+
+func getExtensionNetworkOverrideBucketKey(extId string) string {
+	return fmt.Sprintf("ext_network_override_%s", extId)
+}
+
+// loadNetworkPolicy builds the network policy of the extension from its manifest and the user override.
+// This should be called before loading the extension, the policy is enforced by the fetch binding of its VMs.
+func (r *Repository) loadNetworkPolicy(ext *extension.Extension) {
+	defer util.HandlePanicInModuleThen("extension_repo/loadNetworkPolicy", func() {})
+
+	ext.NetworkPolicy = extension.NewNetworkPolicy(ext, r.getExtensionNetworkOverride(ext.ID), r.networkViolations)
+
+	if warning := ext.NetworkPolicy.Warning(); warning != "" {
+		r.logger.Warn().Str("id", ext.ID).Strs("domains", ext.NetworkPolicy.Domains).Msg("extensions: The extension does not declare its network domains")
+	}
+}
+
+// onNetworkViolation logs the requests blocked by the network policy of an extension.
+func (r *Repository) onNetworkViolation(extensionId string, v *extension.NetworkViolation) {
+	r.logger.Warn().Str("id", extensionId).Str("url", v.URL).Msgf("extensions: Request blocked by the network policy, %s", v.Reason)
+	if r.wsEventManager != nil {
+		r.wsEventManager.SendEvent(events.ConsoleLog, fmt.Sprintf("%s (network): Blocked request to %s, %s", extensionId, v.URL, v.Reason))
+	}
+}
+
+func (r *Repository) getExtensionNetworkOverride(id string) *extension.NetworkOverride {
+	bucket := filecache.NewPermanentBucket(getExtensionNetworkOverrideBucketKey(id))
+
+	var override extension.NetworkOverride
+	found, _ := r.fileCacher.GetPerm(bucket, id, &override)
+	if !found {
+		return nil
+	}
+	return &override
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type ExtensionNetworkSettings struct {
+	// Manifest is the network declaration of the extension, nil if it does not declare one
+	Manifest *extension.NetworkManifest `json:"manifest"`
+	// Override is the user override, nil if the user did not change the policy
+	Override *extension.NetworkOverride `json:"override"`
+	Health   *extension.NetworkHealth   `json:"health"`
+}
+
+// GetExtensionNetworkSettings returns the network declaration, the user override and the network health of the extension.
+func (r *Repository) GetExtensionNetworkSettings(id string) (ret *ExtensionNetworkSettings) {
+	ret = &ExtensionNetworkSettings{
+		Override: r.getExtensionNetworkOverride(id),
+	}
+
+	defer util.HandlePanicInModuleThen("extension_repo/GetExtensionNetworkSettings", func() {})
+
+	ext, found := r.extensionBankRef.Get().Get(id)
+	if !found {
+		return
+	}
+	ret.Manifest = ext.GetNetwork()
+	if data, ok := ext.(interface{ GetExtension() *extension.Extension }); ok {
+		ret.Health = r.getNetworkHealth(data.GetExtension())
+	}
+
+	return
+}
+
+// GetExtensionNetworkHealth returns the network health of the loaded extensions that make requests through the runtime.
+func (r *Repository) GetExtensionNetworkHealth() map[string]*extension.NetworkHealth {
+	ret := make(map[string]*extension.NetworkHealth)
+	r.gojaExtensions.Range(func(id string, gojaExt GojaExtension) bool {
+		if health := r.getNetworkHealth(gojaExt.GetExtension()); health != nil {
+			ret[id] = health
+		}
+		return true
+	})
+	return ret
+}
+
+func (r *Repository) getNetworkHealth(ext *extension.Extension) *extension.NetworkHealth {
+	policy := ext.GetNetworkPolicy()
+	if policy == nil {
+		return nil
+	}
+
+	count, violations := r.networkViolations.Get(policy.ExtensionID)
+	return &extension.NetworkHealth{
+		Policy:         policy,
+		ViolationCount: count,
+		Violations:     violations,
+		Warning:        policy.Warning(),
+	}
+}
+
+// SaveExtensionNetworkOverride saves the network override of the extension and reloads it.
+// A nil override restores the policy of the manifest.
+func (r *Repository) SaveExtensionNetworkOverride(id string, override *extension.NetworkOverride) (err error) {
+	defer util.HandlePanicInModuleWithError("extension_repo/SaveExtensionNetworkOverride", &err)
+
+	if _, isBuiltIn := r.builtinExtensions.Get(id); isBuiltIn {
+		return fmt.Errorf("extension: built-in extensions do not have a network policy")
+	}
+
+	bucket := filecache.NewPermanentBucket(getExtensionNetworkOverrideBucketKey(id))
+	if override == nil {
+		err = r.fileCacher.RemovePerm(bucket.Name())
+	} else {
+		err = r.fileCacher.SetPerm(bucket, id, override)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.networkViolations.Clear(id)
+
+	// Reload the extension
+	r.reloadExtension(id)
+
+	return nil
+}
+
+// This should be called when the extension is uninstalled
+func (r *Repository) deleteExtensionNetworkOverride(id string) (err error) {
+	defer util.HandlePanicInModuleWithError("extension_repo/deleteExtensionNetworkOverride", &err)
+
+	r.networkViolations.Clear(id)
+
+	bucket := filecache.NewPermanentBucket(getExtensionNetworkOverrideBucketKey(id))
+	return r.fileCacher.RemovePerm(bucket.Name())
+}
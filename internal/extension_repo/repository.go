@@ -56,6 +56,9 @@ type (
 
 		// Called when the external extensions are loaded for the first time
 		firstExternalExtensionLoadedFunc context.CancelFunc
+
+		// Requests blocked by the network policies of the extensions
+		networkViolations *extension.NetworkViolationLog
 	}
 
 	builtinExtension struct {
@@ -144,7 +147,9 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		client:             http.DefaultClient,
 		builtinExtensions:  result.NewMap[string, *builtinExtension](),
 		updateData:         make([]UpdateData, 0),
+		networkViolations:  extension.NewNetworkViolationLog(),
 	}
+	ret.networkViolations.OnViolation = ret.onNetworkViolation
 
 	firstExtensionLoadedCtx, firstExtensionLoadedCancel := context.WithCancel(context.Background())
 	ret.firstExternalExtensionLoadedFunc = firstExtensionLoadedCancel
@@ -1,8 +1,12 @@
 package goja_bindings
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"net/http"
+	neturl "net/url"
+	"seanime/internal/extension"
 	"strings"
 	"time"
 
@@ -36,6 +40,8 @@ type Fetch struct {
 	fetchSem     chan struct{}
 	vmResponseCh chan func()
 	closed       bool
+	// policy restricts the hosts the requests and their redirects can reach, nil if unrestricted
+	policy *extension.NetworkPolicy
 }
 
 func NewFetch(vm *goja.Runtime) *Fetch {
@@ -46,6 +52,11 @@ func NewFetch(vm *goja.Runtime) *Fetch {
 	}
 }
 
+// SetNetworkPolicy restricts the requests to the hosts allowed by the network policy of the extension.
+func (f *Fetch) SetNetworkPolicy(policy *extension.NetworkPolicy) {
+	f.policy = policy
+}
+
 func (f *Fetch) ResponseChannel() <-chan func() {
 	return f.vmResponseCh
 }
@@ -80,9 +91,11 @@ type fetchResult struct {
 // BindFetch binds the fetch function to the VM.
 // The returned Fetch instance should have Close() called when the VM is no longer needed
 // to prevent goroutine leaks.
-func BindFetch(vm *goja.Runtime) *Fetch {
+// If the policy is not nil, the requests are restricted to the hosts it allows.
+func BindFetch(vm *goja.Runtime, policy *extension.NetworkPolicy) *Fetch {
 	// Create a new Fetch instance
 	f := NewFetch(vm)
+	f.SetNetworkPolicy(policy)
 	_ = vm.Set("fetch", f.Fetch)
 	// Store the Fetch instance in the VM for later cleanup
 	_ = vm.Set("__fetch_instance__", f)
@@ -225,6 +238,23 @@ func (f *Fetch) Fetch(call goja.FunctionCall) goja.Value {
 		// Create request with timeout
 		reqClient := client.Clone().SetTimeout(time.Duration(options.Timeout) * time.Second)
 
+		// Check the URL and every redirect against the network policy of the extension
+		if f.policy != nil {
+			parsedUrl, err := neturl.Parse(url)
+			if err == nil {
+				err = f.policy.CheckURL(context.Background(), parsedUrl)
+			}
+			if err != nil {
+				f.vmResponseCh <- func() {
+					_ = reject(NewError(f.vm, err))
+				}
+				return
+			}
+			reqClient.SetRedirectPolicy(req.DefaultRedirectPolicy(), func(r *http.Request, via []*http.Request) error {
+				return f.policy.CheckURL(r.Context(), r.URL)
+			})
+		}
+
 		request := reqClient.R()
 
 		// Set headers
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"seanime/internal/extension"
 	"seanime/internal/util"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -61,7 +63,7 @@ func TestFetch_ThreadSafety(t *testing.T) {
 		t.Run(fmt.Sprintf("Iteration_%d", i), func(t *testing.T) {
 			// Create a new VM for each iteration
 			vm := goja.New()
-			BindFetch(vm)
+			BindFetch(vm, nil)
 
 			// Execute the JavaScript code
 			v, err := vm.RunString(jsCode)
@@ -119,7 +121,7 @@ func TestFetch_VMIsolation(t *testing.T) {
 
 			// Create a new VM for this goroutine
 			vm := goja.New()
-			BindFetch(vm)
+			BindFetch(vm, nil)
 
 			// Create JavaScript code that makes multiple requests
 			jsCode := fmt.Sprintf(`
@@ -158,7 +160,7 @@ func TestFetch_VMIsolation(t *testing.T) {
 func TestGojaPromiseAll(t *testing.T) {
 	vm := goja.New()
 
-	BindFetch(vm)
+	BindFetch(vm, nil)
 
 	registry := new(gojarequire.Registry)
 	registry.Enable(vm)
@@ -204,7 +206,7 @@ func TestGojaPromiseAll(t *testing.T) {
 
 func TestGojaFormDataAndFetch(t *testing.T) {
 	vm := goja.New()
-	BindFetch(vm)
+	BindFetch(vm, nil)
 
 	registry := new(gojarequire.Registry)
 	registry.Enable(vm)
@@ -272,7 +274,7 @@ async function run() {
 func TestGojaFetchPostJSON(t *testing.T) {
 	vm := goja.New()
 
-	BindFetch(vm)
+	BindFetch(vm, nil)
 
 	registry := new(gojarequire.Registry)
 	registry.Enable(vm)
@@ -322,3 +324,48 @@ async function run() {
 		spew.Dump(err)
 	}
 }
+
+func TestFetch_NetworkPolicyRedirect(t *testing.T) {
+	var redirectedRequests int
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirectedRequests++
+		fmt.Fprint(w, "ok")
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Same server, but through a host that is not allowed
+			http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	violations := extension.NewNetworkViolationLog()
+	policy := extension.NewNetworkPolicy(&extension.Extension{
+		ID:      "provider",
+		Network: &extension.NetworkManifest{Domains: []string{"127.0.0.1"}, AllowPrivateNetwork: true},
+	}, nil, violations)
+
+	run := func(url string) *goja.Promise {
+		vm := goja.New()
+		BindFetch(vm, policy)
+		v, err := vm.RunString(fmt.Sprintf(`fetch(%q).then(res => res.text())`, url))
+		require.NoError(t, err)
+		promise := v.Export().(*goja.Promise)
+		for promise.State() == goja.PromiseStatePending {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return promise
+	}
+
+	assert.Equal(t, goja.PromiseStateFulfilled, run(server.URL+"/").State())
+	assert.Equal(t, goja.PromiseStateRejected, run(server.URL+"/redirect").State())
+	assert.Equal(t, goja.PromiseStateRejected, run(strings.Replace(server.URL, "127.0.0.1", "localhost", 1)).State())
+	assert.Zero(t, redirectedRequests)
+
+	count, _ := violations.Get("provider")
+	assert.Equal(t, 2, count)
+}
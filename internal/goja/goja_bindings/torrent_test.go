@@ -19,7 +19,7 @@ func TestGojaTorrentUtils(t *testing.T) {
 	gojabuffer.Enable(vm)
 	BindTorrentUtils(vm)
 	BindConsole(vm, util.NewLogger())
-	BindFetch(vm)
+	BindFetch(vm, nil)
 
 	_, err := vm.RunString(`
 async function run() {
@@ -3,13 +3,19 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"seanime/internal/api/anilist"
+	"seanime/internal/api/anilistproxy"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/platforms/shared_platform"
 	"seanime/internal/util/result"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
 )
 
 // HandleGetAnimeCollection
@@ -18,32 +24,53 @@ import (
 //	@desc Calling GET will return the cached anime collection.
 //	@desc The manga collection is also refreshed in the background, and upon completion, a WebSocket event is sent.
 //	@desc Calling POST will refetch both the anime and manga collections.
+//	@desc The collection is streamed as newline-delimited JSON if the client accepts "application/x-ndjson" or sets "format=ndjson".
+//	@desc Calling GET responds with 304 if the If-None-Match header matches the ETag of the current collection version.
 //	@returns anilist.AnimeCollection
 //	@route /api/v1/anilist/collection [GET,POST]
 func (h *Handler) HandleGetAnimeCollection(c echo.Context) error {
 
 	bypassCache := c.Request().Method == "POST"
+	format := getCollectionFormat(c)
+	sessionID := GetSessionID(c)
+	// The collection version only tracks the collection of the main user
+	versioned := !h.App.UsesSessionPlatform(sessionID)
 
 	if !bypassCache {
+		// Nothing to send if the client already has the current version
+		if versioned && h.checkCollectionETag(c, "anime-collection", format) {
+			return respondNotModified(c)
+		}
 		// Get the user's anilist collection
-		animeCollection, err := h.App.GetAnimeCollection(false)
+		animeCollection, err := h.App.GetAnimeCollectionForSession(c.Request().Context(), sessionID, false)
 		if err != nil {
 			return h.RespondWithError(c, err)
 		}
+		if format == collectionFormatNDJSON {
+			return streamAnimeCollection(c, animeCollection)
+		}
 		return h.RespondWithData(c, animeCollection)
 	}
 
-	animeCollection, err := h.App.RefreshAnimeCollection()
+	animeCollection, err := h.App.GetAnimeCollectionForSession(c.Request().Context(), sessionID, true)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
+	// Send the ETag of the refreshed collection
+	if versioned {
+		_ = h.checkCollectionETag(c, "anime-collection", format)
+	}
+
 	go func() {
 		if h.App.Settings != nil && h.App.Settings.GetLibrary().EnableManga {
 			_, _ = h.App.RefreshMangaCollection()
 		}
 	}()
 
+	if format == collectionFormatNDJSON {
+		return streamAnimeCollection(c, animeCollection)
+	}
 	return h.RespondWithData(c, animeCollection)
 }
 
@@ -58,7 +85,7 @@ func (h *Handler) HandleGetRawAnimeCollection(c echo.Context) error {
 	bypassCache := c.Request().Method == "POST"
 
 	// Get the user's anilist collection
-	animeCollection, err := h.App.GetRawAnimeCollection(bypassCache)
+	animeCollection, err := h.App.GetRawAnimeCollectionForSession(c.Request().Context(), GetSessionID(c), bypassCache)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -186,6 +213,94 @@ func (h *Handler) HandleGetAnilistStudioDetails(c echo.Context) error {
 
 //----------------------------------------------------------------------------------------------------------------------------------------------------
 
+// HandleGetStreamingEpisodes
+//
+//	@summary returns the official streaming links of an anime.
+//	@desc This is displayed as a legal viewing alternative when an episode is not downloaded.
+//	@param id - int - true - "The AniList anime ID"
+//	@returns []anilist.StreamingEpisode
+//	@route /api/v1/anilist/media/{id}/streaming-episodes [GET]
+func (h *Handler) HandleGetStreamingEpisodes(c echo.Context) error {
+
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	episodes, err := h.App.AnilistClientRef.Get().GetStreamingEpisodes(c.Request().Context(), mId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, episodes)
+}
+
+//----------------------------------------------------------------------------------------------------------------------------------------------------
+
+type AnimeTrailer struct {
+	URL       string `json:"url"`
+	Thumbnail string `json:"thumbnail"`
+	Site      string `json:"site"`
+}
+
+var animeTrailerCache = result.NewCache[int, *AnimeTrailer]()
+
+// HandleGetAnimeTrailer
+//
+//	@summary returns the official trailer of an anime.
+//	@desc Returns null if the anime has no trailer or if it is hosted on a site other than YouTube or Dailymotion.
+//	@desc The result is cached for 1 hour.
+//	@param id - int - true - "The AniList anime ID"
+//	@returns handlers.AnimeTrailer
+//	@route /api/v1/anilist/media/{id}/trailer [GET]
+func (h *Handler) HandleGetAnimeTrailer(c echo.Context) error {
+
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if trailer, ok := animeTrailerCache.Get(mId); ok {
+		return h.RespondWithData(c, trailer)
+	}
+
+	media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), mId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	var trailer *AnimeTrailer
+	if t := media.GetTrailer(); t != nil {
+		site := strings.ToLower(lo.FromPtr(t.GetSite()))
+		if trailerURL, ok := getTrailerURL(site, lo.FromPtr(t.GetID())); ok {
+			trailer = &AnimeTrailer{
+				URL:       trailerURL,
+				Thumbnail: lo.FromPtr(t.GetThumbnail()),
+				Site:      site,
+			}
+		}
+	}
+	animeTrailerCache.SetT(mId, trailer, time.Hour)
+
+	return h.RespondWithData(c, trailer)
+}
+
+// getTrailerURL returns the URL of the video on the site hosting it.
+func getTrailerURL(site string, id string) (string, bool) {
+	if id == "" {
+		return "", false
+	}
+	switch site {
+	case "youtube":
+		return "https://www.youtube.com/watch?v=" + url.QueryEscape(id), true
+	case "dailymotion":
+		return "https://www.dailymotion.com/video/" + url.PathEscape(id), true
+	}
+	return "", false
+}
+
+//----------------------------------------------------------------------------------------------------------------------------------------------------
+
 // HandleDeleteAnilistListEntry
 //
 //	@summary deletes an entry from the user's AniList list.
@@ -215,7 +330,7 @@ func (h *Handler) HandleDeleteAnilistListEntry(c echo.Context) error {
 	switch *p.Type {
 	case "anime":
 		// Get the list entry ID
-		animeCollection, err := h.App.GetAnimeCollection(false)
+		animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 		if err != nil {
 			return h.RespondWithError(c, err)
 		}
@@ -498,3 +613,43 @@ func (h *Handler) HandleToggleAnilistCacheLayerStatus(c echo.Context) error {
 	shared_platform.IsWorking.Store(!shared_platform.IsWorking.Load())
 	return h.RespondWithData(c, shared_platform.IsWorking.Load())
 }
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// HandleAnilistGraphQLProxy
+//
+//	@summary executes an AniList GraphQL query on behalf of the session.
+//	@desc Only queries of Media, AiringSchedule, Character, Staff and of these fields in Page are allowed, mutations are rejected.
+//	@desc The queries go through the rate limiter of the server and their results are cached for 5 minutes, per AniList account.
+//	@desc The X-Cache header of the response is "HIT" if the result was served from the cache, "MISS" otherwise.
+//	@desc The response is a GraphQL response, the data of the query is in "data".
+//	@route /api/v1/anilist/graphql [POST]
+//	@returns interface{}
+func (h *Handler) HandleAnilistGraphQLProxy(c echo.Context) error {
+
+	type body struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables"`
+		OperationName string                 `json:"operationName"`
+	}
+
+	p := new(body)
+	if err := c.Bind(p); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	data, cacheStatus, err := h.App.AnilistProxy.Query(&anilistproxy.Request{
+		Query:         p.Query,
+		Variables:     p.Variables,
+		OperationName: p.OperationName,
+	}, h.GetSessionAnilistToken(c))
+	c.Response().Header().Set("X-Cache", string(cacheStatus))
+	if err != nil {
+		if errors.Is(err, anilistproxy.ErrInvalidQuery) || errors.Is(err, anilistproxy.ErrOperationNotAllowed) {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse(err))
+		}
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, data)
+}
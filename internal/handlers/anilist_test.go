@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrailerURL(t *testing.T) {
+	tests := []struct {
+		site     string
+		id       string
+		expected string
+		ok       bool
+	}{
+		{site: "youtube", id: "dQw4w9WgXcQ", expected: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", ok: true},
+		{site: "dailymotion", id: "x7tgad0", expected: "https://www.dailymotion.com/video/x7tgad0", ok: true},
+		{site: "youtube", id: ""},
+		{site: "vimeo", id: "123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.site+"/"+tt.id, func(t *testing.T) {
+			url, ok := getTrailerURL(tt.site, tt.id)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
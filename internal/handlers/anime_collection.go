@@ -6,9 +6,11 @@ import (
 	"seanime/internal/customsource"
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/torrentstream"
 	"seanime/internal/util"
 	"seanime/internal/util/result"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -21,16 +23,39 @@ import (
 //	@desc This is used to get the main anime collection of the user.
 //	@desc It uses the cached Anilist anime collection for the GET method.
 //	@desc It refreshes the AniList anime collection if the POST method is used.
+//	@desc The collection is streamed as newline-delimited JSON if the client accepts "application/x-ndjson" or sets "format=ndjson".
+//	@desc Responds with 304 if the If-None-Match header matches the ETag of the current collection version.
+//	@desc The entries include the local annotations of the user. They can be filtered with the "tag" query parameter, which can be repeated,
+//	@desc and the "q" query parameter, which searches the notes.
 //	@route /api/v1/library/collection [GET,POST]
 //	@returns anime.LibraryCollection
 func (h *Handler) HandleGetLibraryCollection(c echo.Context) error {
 
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	format := getCollectionFormat(c)
+
+	filter, err := h.getLibraryCollectionFilter(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	sessionID := GetSessionID(c)
+
+	// The library of the Nakama host and the collections of the sessions logged into another account are not versioned,
+	// filtered collections are not cached by the client
+	versioned := filter == nil && !h.App.NakamaManager.IsConnectedToHost() && !h.App.UsesSessionPlatform(sessionID)
+	if versioned && h.checkCollectionETag(c, "library-collection", format) {
+		return respondNotModified(c)
+	}
+
+	animeCollection, err := h.App.GetAnimeCollectionForSession(c.Request().Context(), sessionID, false)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
 	if animeCollection == nil {
+		if format == collectionFormatNDJSON {
+			return streamLibraryCollection(c, &anime.LibraryCollection{})
+		}
 		return h.RespondWithData(c, &anime.LibraryCollection{})
 	}
 
@@ -212,14 +237,50 @@ func (h *Handler) HandleGetLibraryCollection(c echo.Context) error {
 		}
 	}
 
+	annotations, err := h.getMediaAnnotations(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	libraryCollection.HydrateAnnotations(annotations)
+	libraryCollection.FilterByAnnotation(filter)
+
 	// Hydrate total library size
 	if libraryCollection != nil && libraryCollection.Stats != nil {
 		libraryCollection.Stats.TotalSize = util.Bytes(h.App.TotalLibrarySize)
 	}
 
+	if format == collectionFormatNDJSON {
+		return streamLibraryCollection(c, libraryCollection)
+	}
 	return h.RespondWithData(c, libraryCollection)
 }
 
+// getLibraryCollectionFilter returns the annotation filter of the "tag" and "q" query parameters, nil if there is none.
+// Entries must have all the tags and a note matching the query.
+func (h *Handler) getLibraryCollectionFilter(c echo.Context) (*anime.LibraryCollectionFilter, error) {
+	tags, err := anime.NormalizeAnnotationTags(c.QueryParams()["tag"])
+	if err != nil {
+		return nil, err
+	}
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if len(tags) == 0 && query == "" {
+		return nil, nil
+	}
+
+	ret := &anime.LibraryCollectionFilter{Tags: tags}
+	if query != "" {
+		mediaIds, err := h.App.Database.SearchMediaAnnotationNotes(h.getAnnotationOwner(c), query)
+		if err != nil {
+			return nil, err
+		}
+		ret.NoteMatches = make(map[int]bool, len(mediaIds))
+		for _, mId := range mediaIds {
+			ret.NoteMatches[mId] = true
+		}
+	}
+	return ret, nil
+}
+
 //----------------------------------------------------------------------------------------------------------------------------------------------------
 
 var animeScheduleCache = result.NewCache[int, []*anime.ScheduleItem]()
@@ -231,6 +292,16 @@ var animeScheduleCache = result.NewCache[int, []*anime.ScheduleItem]()
 //	@route /api/v1/library/schedule [GET]
 //	@returns []anime.ScheduleItem
 func (h *Handler) HandleGetAnimeCollectionSchedule(c echo.Context) error {
+	ret, err := h.getAnimeScheduleItems(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// getAnimeScheduleItems returns the cached airing schedule of the anime collection.
+func (h *Handler) getAnimeScheduleItems(c echo.Context) ([]*anime.ScheduleItem, error) {
 
 	// Invalidate the cache when the Anilist collection is refreshed
 	h.App.AddOnRefreshAnilistCollectionFunc("HandleGetAnimeCollectionSchedule", func() {
@@ -238,24 +309,24 @@ func (h *Handler) HandleGetAnimeCollectionSchedule(c echo.Context) error {
 	})
 
 	if ret, ok := animeScheduleCache.Get(1); ok {
-		return h.RespondWithData(c, ret)
+		return ret, nil
 	}
 
 	animeSchedule, err := h.App.AnilistPlatformRef.Get().GetAnimeAiringSchedule(c.Request().Context())
 	if err != nil {
-		return h.RespondWithError(c, err)
+		return nil, err
 	}
 
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
-		return h.RespondWithError(c, err)
+		return nil, err
 	}
 
 	ret := anime.GetScheduleItems(animeSchedule, animeCollection)
 
 	animeScheduleCache.SetT(1, ret, 1*time.Hour)
 
-	return h.RespondWithData(c, ret)
+	return ret, nil
 }
 
 // HandleAddUnknownMedia
@@ -282,7 +353,7 @@ func (h *Handler) HandleAddUnknownMedia(c echo.Context) error {
 	}
 
 	// Bypass the cache
-	animeCollection, err := h.App.GetAnimeCollection(true)
+	animeCollection, err := h.App.GetAnimeCollection(platform.FreshCollection)
 	if err != nil {
 		return h.RespondWithError(c, errors.New("error: Anilist responded with an error, wait one minute before refreshing"))
 	}
@@ -13,7 +13,10 @@ import (
 	"seanime/internal/library/anime"
 	"seanime/internal/library/scanner"
 	"seanime/internal/library/summary"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/platforms/shared_platform"
+	"seanime/internal/syncstatus"
+	torrent_audio "seanime/internal/torrents/audio"
 	"seanime/internal/util"
 	"seanime/internal/util/limiter"
 	"seanime/internal/util/result"
@@ -63,7 +66,7 @@ func (h *Handler) getAnimeEntry(c echo.Context, lfs []*anime.LocalFile, mId int)
 	}
 
 	// Get the user's anilist collection
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollectionForSession(c.Request().Context(), GetSessionID(c), false)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +109,25 @@ func (h *Handler) getAnimeEntry(c echo.Context, lfs []*anime.LocalFile, mId int)
 		h.App.FillerManager.HydrateFillerData(fillerEvent.Entry)
 	}
 
+	// Auto-fetch the theme songs if not already cached
+	if !h.App.ThemeSongsManager.HasThemesFetched(mId) && entry.Media != nil {
+		go func() {
+			defer util.HandlePanicInModuleThen("handlers/getAnimeEntry/AutoFetchThemeSongs", func() {
+				h.App.Logger.Error().Int("mediaId", mId).Msg("handlers: Failed to auto-fetch theme songs")
+			})
+			_ = h.App.ThemeSongsManager.FetchAndStoreThemesForMedia(entry.Media)
+		}()
+	}
+	h.App.ThemeSongsManager.HydrateThemes(entry)
+
+	if !hydratedFromNakama && h.App.EpisodeThumbnails != nil {
+		h.App.EpisodeThumbnails.HydrateEntry(entry)
+	}
+
+	if savedSearches, err := db_bridge.GetSavedTorrentSearchesByMediaId(h.App.Database, mId); err == nil && len(savedSearches) > 0 {
+		entry.SavedTorrentSearches = savedSearches
+	}
+
 	if hydratedFromNakama {
 		entry.IsNakamaEntry = true
 		for _, ep := range entry.Episodes {
@@ -133,7 +155,7 @@ func (h *Handler) HandleGetAnimeEntry(c echo.Context) error {
 
 	// Check short-lived cache first to reduce load when rapidly opening tabs
 	if cachedEntry, ok := animeEntryCache.Get(mId); ok {
-		return h.RespondWithData(c, cachedEntry)
+		return h.RespondWithData(c, h.withAnimeEntryUserState(c, cachedEntry))
 	}
 
 	// Get all the local files
@@ -150,7 +172,7 @@ func (h *Handler) HandleGetAnimeEntry(c echo.Context) error {
 	// Cache for 30 seconds to handle rapid tab opening
 	animeEntryCache.SetT(mId, entry, 30*time.Second)
 
-	return h.RespondWithData(c, entry)
+	return h.RespondWithData(c, h.withAnimeEntryUserState(c, entry))
 }
 
 //----------------------------------------------------------------------------------------------------------------------
@@ -516,7 +538,7 @@ func (h *Handler) HandleGetMissingEpisodes(c echo.Context) error {
 	// Get the user's anilist collection
 	// Do not bypass the cache, since this handler might be called multiple times, and we don't want to spam the API
 	// A cron job will refresh the cache every 10 minutes
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -612,6 +634,61 @@ func (h *Handler) HandleToggleAnimeEntrySilenceStatus(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// HandleGetAnimeEntryAudioPreference
+//
+//	@summary returns the audio preference of a media entry.
+//	@desc The preference is used by the AutoDownloader and the torrent streaming auto-selection when the rule does not set one.
+//	@route /api/v1/library/anime-entry/audio-preference/{id} [GET]
+//	@param id - int - true - "AniList anime media ID"
+//	@returns string
+func (h *Handler) HandleGetAnimeEntryAudioPreference(c echo.Context) error {
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	preference, err := h.App.Database.GetMediaAudioPreference(mId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, preference)
+}
+
+// HandleSetAnimeEntryAudioPreference
+//
+//	@summary sets the audio preference of a media entry.
+//	@desc An empty preference removes it.
+//	@route /api/v1/library/anime-entry/audio-preference [POST]
+//	@returns bool
+func (h *Handler) HandleSetAnimeEntryAudioPreference(c echo.Context) error {
+
+	type body struct {
+		MediaId    int                      `json:"mediaId"`
+		Preference torrent_audio.Preference `json:"preference"`
+	}
+
+	b := new(body)
+	if err := c.Bind(b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.MediaId == 0 {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if !b.Preference.IsValid() {
+		return h.RespondWithError(c, errors.New("invalid audio preference"))
+	}
+
+	err := h.App.Database.SetMediaAudioPreference(b.MediaId, string(b.Preference))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
 //-----------------------------------------------------------------------------------------------------------------------------
 
 // HandleUpdateAnimeEntryProgress
@@ -637,8 +714,10 @@ func (h *Handler) HandleUpdateAnimeEntryProgress(c echo.Context) error {
 	}
 
 	// Update the progress on AniList
-	err := h.App.AnilistPlatformRef.Get().UpdateEntryProgress(
+	err := h.App.UpdatePlatformEntryProgress(
 		c.Request().Context(),
+		GetSessionID(c),
+		syncstatus.MediaKindAnime,
 		b.MediaId,
 		b.EpisodeNumber,
 		&b.TotalEpisodes,
@@ -686,3 +765,19 @@ func (h *Handler) HandleUpdateAnimeEntryRepeat(c echo.Context) error {
 
 	return h.RespondWithData(c, true)
 }
+
+// withAnimeEntryUserState returns a copy of the entry that includes the sync state and the annotation of the current session.
+// The entry is copied because it may be shared through animeEntryCache.
+func (h *Handler) withAnimeEntryUserState(c echo.Context, entry *anime.Entry) *anime.Entry {
+	if entry == nil {
+		return nil
+	}
+	ret := *entry
+	ret.SyncState = h.App.SyncStatusTracker.GetMediaState(h.App.GetSyncStatusUsername(GetSessionID(c)), syncstatus.MediaKindAnime, entry.MediaId)
+	annotation, err := h.App.Database.GetMediaAnnotation(h.getAnnotationOwner(c), entry.MediaId)
+	if err != nil {
+		h.App.Logger.Warn().Err(err).Int("mediaId", entry.MediaId).Msg("anime entry: Failed to get annotation")
+	}
+	ret.Annotation = toEntryAnnotation(annotation)
+	return &ret
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"seanime/internal/apikeys"
+	"seanime/internal/database/models"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorCodeMissingScope is returned when the API key of the request does not have the scope required by the route.
+const ErrorCodeMissingScope = "missing_scope"
+
+// ApiKeyMiddleware authorizes the requests sent with an API key.
+// The key must have the scope of the route group, see apikeys.RequiredScope, otherwise the response is a 403 naming the missing scope.
+// Requests with a valid key do not need the server password.
+func (h *Handler) ApiKeyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(apikeys.HeaderApiKey)
+		if key == "" {
+			return next(c)
+		}
+
+		apiKey, err := h.App.ApiKeys.Authorize(key, c.Request().Method, c.Request().URL.Path)
+		if err != nil {
+			var missingScopeErr *apikeys.MissingScopeError
+			if errors.As(err, &missingScopeErr) {
+				return c.JSON(http.StatusForbidden, SeaResponse[any]{
+					Error: err.Error(),
+					Code:  ErrorCodeMissingScope,
+				})
+			}
+			return c.JSON(http.StatusUnauthorized, SeaResponse[any]{Error: "UNAUTHENTICATED"})
+		}
+
+		c.Set("apiKey", apiKey)
+		return next(c)
+	}
+}
+
+// isApiKeyRequest returns true if the request was authorized by ApiKeyMiddleware.
+func isApiKeyRequest(c echo.Context) bool {
+	_, ok := c.Get("apiKey").(*models.ApiKey)
+	return ok
+}
+
+// HandleGetApiKeys
+//
+//	@summary returns the API keys with their scopes and the last time they were used.
+//	@desc The keys themselves are not returned, only the prefix used to recognize them.
+//	@route /api/v1/api-keys [GET]
+//	@returns []models.ApiKey
+func (h *Handler) HandleGetApiKeys(c echo.Context) error {
+	keys, err := h.App.ApiKeys.GetAll()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, keys)
+}
+
+// ApiKeyCreateResponse is returned by HandleCreateApiKey.
+type ApiKeyCreateResponse struct {
+	// Key is only returned once, it is sent in the X-Seanime-Api-Key header
+	Key    string         `json:"key"`
+	ApiKey *models.ApiKey `json:"apiKey"`
+}
+
+// HandleGetApiKeyScopes
+//
+//	@summary returns the scopes that can be given to an API key.
+//	@route /api/v1/api-keys/scopes [GET]
+//	@returns []apikeys.Scope
+func (h *Handler) HandleGetApiKeyScopes(c echo.Context) error {
+	return h.RespondWithData(c, apikeys.AllScopes)
+}
+
+// HandleCreateApiKey
+//
+//	@summary creates an API key with the scopes.
+//	@desc The key is only returned in this response, it cannot be retrieved later.
+//	@desc "admin" grants access to every route, the other scopes grant access to a group of routes.
+//	@route /api/v1/api-keys [POST]
+//	@returns handlers.ApiKeyCreateResponse
+func (h *Handler) HandleCreateApiKey(c echo.Context) error {
+
+	type body struct {
+		Name   string          `json:"name"`
+		Scopes []apikeys.Scope `json:"scopes"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	key, apiKey, err := h.App.ApiKeys.Create(b.Name, b.Scopes)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, &ApiKeyCreateResponse{
+		Key:    key,
+		ApiKey: apiKey,
+	})
+}
+
+// HandleDeleteApiKey
+//
+//	@summary deletes an API key.
+//	@desc The requests sent with the key are rejected immediately.
+//	@route /api/v1/api-keys/{id} [DELETE]
+//	@param id - int - true - "The API key ID"
+//	@returns bool
+func (h *Handler) HandleDeleteApiKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.ApiKeys.Delete(uint(id)); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
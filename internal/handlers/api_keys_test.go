@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/apikeys"
+	"seanime/internal/core"
+	"seanime/internal/database/db"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyMiddleware(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "api_keys_test", util.NewLogger())
+	require.NoError(t, err)
+
+	manager := apikeys.NewManager(&apikeys.NewManagerOptions{
+		Logger:   util.NewLogger(),
+		Database: database,
+	})
+	h := &Handler{App: &core.App{ApiKeys: manager}}
+
+	key, _, err := manager.Create("automation", []apikeys.Scope{apikeys.ScopeTorrentsWrite})
+	require.NoError(t, err)
+
+	e := echo.New()
+	do := func(method string, path string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(apikeys.HeaderApiKey, key)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		err := h.ApiKeyMiddleware(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c)
+		require.NoError(t, err)
+		return rec
+	}
+
+	// A key with torrents:write cannot modify the settings
+	rec := do(http.MethodPatch, "/api/v1/settings", key)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var res SeaResponse[any]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, ErrorCodeMissingScope, res.Code)
+	assert.Contains(t, res.Error, "settings:admin")
+
+	// Routes that are not in a group require the admin scope
+	rec = do(http.MethodGet, "/api/v1/nakama/status", key)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `\"admin\"`)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/torrent-client/download", key).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/auto-downloader/items", key).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/torrent-client/list", "sea_invalid").Code)
+
+	// Requests without a key are left to the other auth middlewares
+	assert.Equal(t, http.StatusOK, do(http.MethodPatch, "/api/v1/settings", "").Code)
+}
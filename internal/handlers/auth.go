@@ -3,32 +3,40 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
 	"seanime/internal/database/models"
 	"seanime/internal/platforms/anilist_platform"
 	"seanime/internal/platforms/simulated_platform"
+	"seanime/internal/session"
 	"seanime/internal/util"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/goccy/go-json"
 	"github.com/labstack/echo/v4"
 )
 
+// LoginBody is the request body of HandleLogin.
+type LoginBody struct {
+	Token string `json:"token"`
+}
+
 // HandleLogin
 //
 //	@summary logs in the user by saving the JWT token for the current session.
 //	@desc This is called when the JWT token is obtained from AniList after logging in with redirection on the client.
 //	@desc It also fetches the Viewer data from AniList and saves it in the session.
 //	@desc Multi-user support: Each browser tab can have a different Anilist account via session cookies.
+//	@desc The login fails if the account cannot be saved because the database is full, read-only or corrupted.
 //	@route /api/v1/auth/login [POST]
+//	@body LoginBody
 //	@returns handlers.Status
 func (h *Handler) HandleLogin(c echo.Context) error {
 
-	type body struct {
-		Token string `json:"token"`
-	}
-
-	var b body
+	var b LoginBody
 
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
@@ -44,28 +52,12 @@ func (h *Handler) HandleLogin(c echo.Context) error {
 	tempClient := anilist.NewAnilistClient(b.Token, h.App.AnilistCacheDir)
 
 	// Get viewer data from AniList using the temporary client
-	getViewer, err := tempClient.GetViewer(context.Background())
+	getViewer, err := getAnilistViewer(context.Background(), tempClient)
 	if err != nil {
-		h.App.Logger.Error().Msg("Could not authenticate to AniList")
+		h.App.Logger.Error().Err(err).Msg("Could not authenticate to AniList")
 		return h.RespondWithError(c, err)
 	}
 
-	if len(getViewer.Viewer.Name) == 0 {
-		return h.RespondWithError(c, errors.New("could not find user"))
-	}
-
-	// Store the session with the Anilist token
-	err = h.App.SessionStore.Login(sessionID, b.Token, getViewer.Viewer)
-	if err != nil {
-		return h.RespondWithError(c, err)
-	}
-
-	h.App.Logger.Info().Str("sessionID", sessionID).Str("username", getViewer.Viewer.Name).Msg("app: Session authenticated to AniList")
-
-	// Also update the global state for backward compatibility with existing features
-	// This allows the first logged-in user to be the "primary" user for server-wide features
-	h.App.UpdateAnilistClientToken(b.Token)
-
 	// Marshal viewer data
 	bytes, err := json.Marshal(getViewer.Viewer)
 	if err != nil {
@@ -84,12 +76,29 @@ func (h *Handler) HandleLogin(c echo.Context) error {
 	})
 
 	if err != nil {
+		// The session is not created if the account cannot be saved, e.g. when the disk is full,
+		// so that the Anilist data is not loaded for an account that is not persisted
+		if db.IsNonRecoverableError(err) {
+			h.App.Logger.Error().Err(err).Msg("app: Failed to save account to database")
+			return h.RespondWithError(c, fmt.Errorf("could not save the account: %w", err))
+		}
 		h.App.Logger.Warn().Err(err).Msg("Failed to save account to database (non-critical for session-based auth)")
 	}
 
+	// Store the session with the Anilist token
+	h.App.SessionStore.Login(sessionID, b.Token, getViewer.Viewer.Name)
+
+	h.App.Logger.Info().Str("sessionID", sessionID).Str("username", getViewer.Viewer.Name).Msg("app: Session authenticated to AniList")
+
+	// Also update the global state for backward compatibility with existing features
+	// This allows the first logged-in user to be the "primary" user for server-wide features
+	h.App.UpdateAnilistClientToken(b.Token)
+
 	// Update the platform
 	anilistPlatform := anilist_platform.NewAnilistPlatform(h.App.AnilistClientRef, h.App.ExtensionBankRef, h.App.Logger, h.App.Database)
 	h.App.UpdatePlatform(anilistPlatform)
+	// Remove the collections of the simulated platform so that the next read fetches the Anilist collections
+	h.App.LocalManager.ClearCollectionCache()
 
 	// Create a new status (will use session data)
 	status := h.NewStatus(c)
@@ -110,11 +119,35 @@ func (h *Handler) HandleLogin(c echo.Context) error {
 
 }
 
+// getAnilistViewer fetches the viewer with the given client.
+// It returns an error instead of a nil viewer so that callers can safely dereference the result.
+func getAnilistViewer(ctx context.Context, client anilist.AnilistClient) (*anilist.GetViewer, error) {
+	getViewer, err := client.GetViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if getViewer == nil || getViewer.Viewer == nil {
+		return nil, errors.New("empty viewer response")
+	}
+
+	// Count the runes so that names made only of Unicode spaces (e.g. U+3000) are considered empty
+	if utf8.RuneCountInString(strings.TrimSpace(getViewer.Viewer.Name)) == 0 {
+		return nil, errors.New("could not find user")
+	}
+	if !utf8.ValidString(getViewer.Viewer.Name) {
+		return nil, errors.New("invalid username")
+	}
+
+	return getViewer, nil
+}
+
 // HandleLogout
 //
 //	@summary logs out the current session from AniList.
 //	@desc It removes JWT token and Viewer data from the session.
 //	@desc Multi-user support: Only logs out the current browser tab's session.
+//	@desc If it was the primary account used by the server-wide features, the oldest remaining authenticated session becomes the primary one.
 //	@route /api/v1/auth/logout [POST]
 //	@returns handlers.Status
 func (h *Handler) HandleLogout(c echo.Context) error {
@@ -130,6 +163,19 @@ func (h *Handler) HandleLogout(c echo.Context) error {
 
 	h.App.Logger.Info().Str("sessionID", sessionID).Msg("app: Session logged out of AniList")
 
+	if err := h.refreshPrimaryAccount(c.Request().Context()); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	status := h.NewStatus(c)
+
+	return h.RespondWithData(c, status)
+}
+
+// refreshPrimaryAccount updates the account used by the server-wide features after a session stopped being authenticated.
+// The oldest remaining authenticated session becomes the primary one if the previous one is gone,
+// and the app switches to the simulated platform if there are no authenticated sessions left.
+func (h *Handler) refreshPrimaryAccount(ctx context.Context) error {
 	// Check if there are any other authenticated sessions
 	authenticatedSessions := h.App.SessionStore.GetAuthenticatedSessions()
 	
@@ -140,7 +186,7 @@ func (h *Handler) HandleLogout(c echo.Context) error {
 		// Update the platform to simulated
 		simulatedPlatform, err := simulated_platform.NewSimulatedPlatform(h.App.LocalManager, h.App.AnilistClientRef, h.App.ExtensionBankRef, h.App.Logger, h.App.Database)
 		if err != nil {
-			return h.RespondWithError(c, err)
+			return err
 		}
 		h.App.UpdatePlatform(simulatedPlatform)
 
@@ -159,11 +205,56 @@ func (h *Handler) HandleLogout(c echo.Context) error {
 			h.App.Logger.Warn().Err(err).Msg("Failed to clear account from database (non-critical)")
 		}
 
+		h.App.InitOrRefreshModules()
+		h.App.InitOrRefreshAnilistData()
+	} else if !hasAnilistToken(authenticatedSessions, h.App.Database.GetAnilistToken()) {
+		// The primary user logged out, the server-wide features switch to the account of the oldest remaining session
+		primary := h.App.SessionStore.GetPrimaryAuthenticatedSession()
+		if primary == nil {
+			return errors.New("no authenticated session found")
+		}
+
+		h.App.UpdateAnilistClientToken(primary.Token)
+
+		anilistPlatform := anilist_platform.NewAnilistPlatform(h.App.AnilistClientRef, h.App.ExtensionBankRef, h.App.Logger, h.App.Database)
+		h.App.UpdatePlatform(anilistPlatform)
+
+		// Save the new primary account (for backward compatibility)
+		var viewerBytes []byte
+		if viewer, err := primary.GetViewer(ctx); err == nil {
+			viewerBytes, _ = json.Marshal(viewer)
+		}
+		_, err := h.App.Database.UpsertAccount(&models.Account{
+			BaseModel: models.BaseModel{
+				ID:        1,
+				UpdatedAt: time.Now(),
+			},
+			Username: primary.Username,
+			Token:    primary.Token,
+			Viewer:   viewerBytes,
+		})
+		if err != nil {
+			h.App.Logger.Warn().Err(err).Msg("Failed to save account to database (non-critical)")
+		}
+
+		h.App.Logger.Info().Str("sessionID", primary.ID).Str("username", primary.Username).Msg("app: Switched the primary AniList account")
+
 		h.App.InitOrRefreshModules()
 		h.App.InitOrRefreshAnilistData()
 	}
 
-	status := h.NewStatus(c)
+	return nil
+}
 
-	return h.RespondWithData(c, status)
+// hasAnilistToken returns true if one of the sessions is logged in with the token
+func hasAnilistToken(sessions []*session.Session, token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, s := range sessions {
+		if s.Token == token {
+			return true
+		}
+	}
+	return false
 }
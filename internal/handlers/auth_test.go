@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/api/anilist"
+	"testing"
+
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewerClientStub only implements GetViewer, calling any other method will panic.
+type viewerClientStub struct {
+	anilist.AnilistClient
+	viewer *anilist.GetViewer
+	err    error
+}
+
+func (s *viewerClientStub) GetViewer(_ context.Context, _ ...clientv2.RequestInterceptor) (*anilist.GetViewer, error) {
+	return s.viewer, s.err
+}
+
+func TestGetAnilistViewer(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		client      *viewerClientStub
+		expectedErr string
+	}{
+		{
+			name:        "nil response without error",
+			client:      &viewerClientStub{},
+			expectedErr: "empty viewer response",
+		},
+		{
+			name:        "nil viewer without error",
+			client:      &viewerClientStub{viewer: &anilist.GetViewer{}},
+			expectedErr: "empty viewer response",
+		},
+		{
+			name:        "empty username",
+			client:      &viewerClientStub{viewer: &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{}}},
+			expectedErr: "could not find user",
+		},
+		{
+			name:        "ideographic space username",
+			client:      &viewerClientStub{viewer: &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{Name: "\u3000"}}},
+			expectedErr: "could not find user",
+		},
+		{
+			name:        "invalid utf-8 username",
+			client:      &viewerClientStub{viewer: &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{Name: "\xff\xfe"}}},
+			expectedErr: "invalid username",
+		},
+		{
+			name:        "client error",
+			client:      &viewerClientStub{err: errors.New("unauthorized")},
+			expectedErr: "unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret, err := getAnilistViewer(context.Background(), tt.client)
+			require.Error(t, err)
+			assert.Nil(t, ret)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+
+	ret, err := getAnilistViewer(context.Background(), &viewerClientStub{
+		viewer: &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{Name: "user"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "user", ret.Viewer.Name)
+
+	for _, name := range []string{"渡辺", "김민수", "小明", "ミク"} {
+		ret, err = getAnilistViewer(context.Background(), &viewerClientStub{
+			viewer: &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{Name: name}},
+		})
+		require.NoError(t, err, name)
+		assert.Equal(t, name, ret.Viewer.Name)
+	}
+}
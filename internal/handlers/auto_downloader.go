@@ -4,7 +4,10 @@ import (
 	"errors"
 	"path/filepath"
 	"seanime/internal/database/db_bridge"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
+	torrent_audio "seanime/internal/torrents/audio"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
@@ -63,6 +66,28 @@ func (h *Handler) HandleGetAutoDownloaderRulesByAnime(c echo.Context) error {
 	return h.RespondWithData(c, rules)
 }
 
+// HandleGetAutoDownloaderReleasePredictions
+//
+//	@summary returns the release patterns of the release groups of a media.
+//	@desc Each release group with enough history is returned with the median delay between the airing of an episode and its release, the group that releases first comes first.
+//	@route /api/v1/auto-downloader/release-predictions/{id} [GET]
+//	@param id - int - true - "The AniList anime id"
+//	@returns []releasepredictor.GroupPrediction
+func (h *Handler) HandleGetAutoDownloaderReleasePredictions(c echo.Context) error {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	predictions, err := h.App.ReleasePredictor.GetPredictions(id)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, predictions)
+}
+
 // HandleGetAutoDownloaderRules
 //
 //	@summary returns all rules.
@@ -97,6 +122,9 @@ func (h *Handler) HandleCreateAutoDownloaderRule(c echo.Context) error {
 		EpisodeType         anime.AutoDownloaderRuleEpisodeType         `json:"episodeType"`
 		EpisodeNumbers      []int                                       `json:"episodeNumbers,omitempty"`
 		Destination         string                                      `json:"destination"`
+		AudioPreference     torrent_audio.Preference                    `json:"audioPreference,omitempty"`
+		Mode                anime.AutoDownloaderRuleMode                `json:"mode,omitempty"`
+		EpisodeOffset       int                                         `json:"episodeOffset,omitempty"`
 	}
 
 	var b body
@@ -113,6 +141,14 @@ func (h *Handler) HandleCreateAutoDownloaderRule(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("destination must be an absolute path"))
 	}
 
+	if !b.AudioPreference.IsValid() {
+		return h.RespondWithError(c, errors.New("invalid audio preference"))
+	}
+
+	if !b.Mode.IsValid() {
+		return h.RespondWithError(c, errors.New("invalid mode"))
+	}
+
 	rule := &anime.AutoDownloaderRule{
 		Enabled:             b.Enabled,
 		MediaId:             b.MediaId,
@@ -124,6 +160,9 @@ func (h *Handler) HandleCreateAutoDownloaderRule(c echo.Context) error {
 		EpisodeNumbers:      b.EpisodeNumbers,
 		Destination:         b.Destination,
 		AdditionalTerms:     b.AdditionalTerms,
+		AudioPreference:     b.AudioPreference,
+		Mode:                b.Mode,
+		EpisodeOffset:       b.EpisodeOffset,
 	}
 
 	if err := db_bridge.InsertAutoDownloaderRule(h.App.Database, rule); err != nil {
@@ -160,6 +199,14 @@ func (h *Handler) HandleUpdateAutoDownloaderRule(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("invalid id"))
 	}
 
+	if !b.Rule.AudioPreference.IsValid() {
+		return h.RespondWithError(c, errors.New("invalid audio preference"))
+	}
+
+	if !b.Rule.Mode.IsValid() {
+		return h.RespondWithError(c, errors.New("invalid mode"))
+	}
+
 	// Update the rule based on its DbID (primary key)
 	if err := db_bridge.UpdateAutoDownloaderRule(h.App.Database, b.Rule.DbID, b.Rule); err != nil {
 		return h.RespondWithError(c, err)
@@ -188,22 +235,128 @@ func (h *Handler) HandleDeleteAutoDownloaderRule(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// HandleRetargetAutoDownloaderRule
+//
+//	@summary retargets a rule to the sequel of its media.
+//	@desc If 'clone' is true, a copy of the rule is created for the sequel and the original rule is kept.
+//	@desc The filters are preserved and the change is recorded in the rule's history.
+//	@desc It returns the retargeted or created rule.
+//	@route /api/v1/auto-downloader/rule/retarget [POST]
+//	@returns anime.AutoDownloaderRule
+func (h *Handler) HandleRetargetAutoDownloaderRule(c echo.Context) error {
+
+	type body struct {
+		RuleId   uint `json:"ruleId"`
+		SequelId int  `json:"sequelId"`
+		Clone    bool `json:"clone"`
+	}
+
+	var b body
+
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	rule, err := db_bridge.GetAutoDownloaderRule(h.App.Database, b.RuleId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if rule.MediaId == b.SequelId {
+		return h.RespondWithError(c, errors.New("rule already targets this media"))
+	}
+
+	prequel, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), rule.MediaId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	sequel, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), b.SequelId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	newRule, err := h.App.AutoDownloader.RetargetRule(rule, prequel, sequel, b.Clone, false)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, newRule)
+}
+
+// HandleTestAutoDownloaderRuleAgainstTorrent
+//
+//	@summary checks whether a torrent would be downloaded by a rule.
+//	@desc The torrent goes through the same checks as the scheduled runs of the AutoDownloader.
+//	@desc Nothing is downloaded or recorded. Disabled rules are also checked.
+//	@route /api/v1/auto-downloader/rules/{id}/test-torrent [POST]
+//	@param id - int - true - "The DB id of the rule"
+//	@returns autodownloader.RuleCheckResult
+func (h *Handler) HandleTestAutoDownloaderRuleAgainstTorrent(c echo.Context) error {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	var b hibiketorrent.AnimeTorrent
+
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	rule, err := db_bridge.GetAutoDownloaderRule(h.App.Database, uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret, err := h.App.AutoDownloader.CheckTorrentAgainstRule(rule, &b)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // HandleGetAutoDownloaderItems
 //
 //	@summary returns all queued items.
-//	@desc Queued items are episodes that are downloaded but not scanned or not yet downloaded.
+//	@desc Queued items are episodes that are downloaded but not scanned, not yet downloaded, or awaiting the user's confirmation.
+//	@desc The status of each item tells them apart.
 //	@desc The AutoDownloader uses these items in order to not download the same episode twice.
 //	@route /api/v1/auto-downloader/items [GET]
-//	@returns []models.AutoDownloaderItem
+//	@returns []autodownloader.QueueItem
 func (h *Handler) HandleGetAutoDownloaderItems(c echo.Context) error {
-	rules, err := h.App.Database.GetAutoDownloaderItems()
+	items, err := h.App.AutoDownloader.GetQueue()
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
-	return h.RespondWithData(c, rules)
+	return h.RespondWithData(c, items)
+}
+
+// HandleGetAutoDownloaderQueue
+//
+//	@summary returns all queued items with their media.
+//	@desc It is the same as /api/v1/auto-downloader/items, with the media (title, cover image, episode count) of each item
+//	@desc looked up in the cached anime collection. The media is omitted if it is not in the collection.
+//	@route /api/v1/auto-downloader/queue [GET]
+//	@returns []autodownloader.QueuedItemWithMedia
+func (h *Handler) HandleGetAutoDownloaderQueue(c echo.Context) error {
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
+	if err != nil {
+		// The items are still returned without their media
+		h.App.Logger.Warn().Err(err).Msg("autodownloader: Could not get the anime collection for the queue")
+	}
+
+	items, err := h.App.AutoDownloader.GetQueueWithMedia(animeCollection)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, items)
 }
 
 // HandleDeleteAutoDownloaderItem
@@ -231,3 +384,128 @@ func (h *Handler) HandleDeleteAutoDownloaderItem(c echo.Context) error {
 
 	return h.RespondWithData(c, true)
 }
+
+// HandleApproveAutoDownloaderItem
+//
+//	@summary approves a queued item awaiting confirmation.
+//	@desc The torrent is added to the torrent client or the debrid service like the AutoDownloader would have.
+//	@desc It returns the updated item.
+//	@route /api/v1/auto-downloader/item/approve [POST]
+//	@returns models.AutoDownloaderItem
+func (h *Handler) HandleApproveAutoDownloaderItem(c echo.Context) error {
+
+	type body struct {
+		ID uint `json:"id"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	item, err := h.App.AutoDownloader.ApproveItem(b.ID)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, item)
+}
+
+// HandleRejectAutoDownloaderItem
+//
+//	@summary rejects a queued item awaiting confirmation.
+//	@desc The item is removed and its torrent will not be matched by the rule again.
+//	@desc Returns 'true' if the item was rejected.
+//	@route /api/v1/auto-downloader/item/reject [POST]
+//	@returns bool
+func (h *Handler) HandleRejectAutoDownloaderItem(c echo.Context) error {
+
+	type body struct {
+		ID uint `json:"id"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.AutoDownloader.RejectItem(b.ID); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// AutoDownloaderExport holds the rules and the saved torrent searches that can be exported and imported.
+type AutoDownloaderExport struct {
+	Rules         []*anime.AutoDownloaderRule `json:"rules"`
+	SavedSearches []*anime.SavedTorrentSearch `json:"savedSearches"`
+}
+
+// HandleExportAutoDownloaderRules
+//
+//	@summary returns the rules and the saved torrent searches.
+//	@desc The response can be sent to HandleImportAutoDownloaderRules.
+//	@route /api/v1/auto-downloader/export [GET]
+//	@returns handlers.AutoDownloaderExport
+func (h *Handler) HandleExportAutoDownloaderRules(c echo.Context) error {
+	rules, err := db_bridge.GetAutoDownloaderRules(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	savedSearches, err := db_bridge.GetSavedTorrentSearches(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, AutoDownloaderExport{
+		Rules:         rules,
+		SavedSearches: savedSearches,
+	})
+}
+
+// HandleImportAutoDownloaderRules
+//
+//	@summary imports rules and saved torrent searches.
+//	@desc The imported items are added to the existing ones. Items that are not valid are skipped.
+//	@route /api/v1/auto-downloader/import [POST]
+//	@body AutoDownloaderExport
+//	@returns handlers.AutoDownloaderExport
+func (h *Handler) HandleImportAutoDownloaderRules(c echo.Context) error {
+
+	var b AutoDownloaderExport
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret := AutoDownloaderExport{
+		Rules:         make([]*anime.AutoDownloaderRule, 0, len(b.Rules)),
+		SavedSearches: make([]*anime.SavedTorrentSearch, 0, len(b.SavedSearches)),
+	}
+
+	for _, rule := range b.Rules {
+		if rule == nil || !filepath.IsAbs(rule.Destination) || !rule.AudioPreference.IsValid() || !rule.Mode.IsValid() {
+			continue
+		}
+		rule.DbID = 0
+		if err := db_bridge.InsertAutoDownloaderRule(h.App.Database, rule); err != nil {
+			return h.RespondWithError(c, err)
+		}
+		ret.Rules = append(ret.Rules, rule)
+	}
+
+	for _, search := range b.SavedSearches {
+		if search == nil || search.Validate() != nil {
+			continue
+		}
+		search.DbID = 0
+		if err := db_bridge.InsertSavedTorrentSearch(h.App.Database, search); err != nil {
+			return h.RespondWithError(c, err)
+		}
+		animeEntryCache.Delete(search.MediaId)
+		ret.SavedSearches = append(ret.SavedSearches, search)
+	}
+
+	return h.RespondWithData(c, ret)
+}
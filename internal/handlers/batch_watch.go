@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetBatchTorrentWatches
+//
+//	@summary returns the batch torrents watched for new episodes.
+//	@desc Batch torrents are watched when they are downloaded with smart select and 'watchNewEpisodes'.
+//	@route /api/v1/torrent-client/batch-watches [GET]
+//	@returns []batchwatch.Watch
+func (h *Handler) HandleGetBatchTorrentWatches(c echo.Context) error {
+	watches, err := h.App.BatchWatchManager.GetWatches()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, watches)
+}
+
+// BatchTorrentWatchUpdateBody is the request body of HandleUpdateBatchTorrentWatch.
+type BatchTorrentWatchUpdateBody struct {
+	Enabled bool `json:"enabled"`
+}
+
+// HandleUpdateBatchTorrentWatch
+//
+//	@summary enables or disables the watch of a batch torrent.
+//	@desc A disabled watch is kept, the torrent is no longer checked for new episodes.
+//	@route /api/v1/torrent-client/batch-watches/{id} [PATCH]
+//	@param id - int - true - "The watch ID"
+//	@body BatchTorrentWatchUpdateBody
+//	@returns batchwatch.Watch
+func (h *Handler) HandleUpdateBatchTorrentWatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	var b BatchTorrentWatchUpdateBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	watch, err := h.App.BatchWatchManager.SetWatchEnabled(uint(id), b.Enabled)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, watch)
+}
+
+// HandleDeleteBatchTorrentWatch
+//
+//	@summary stops watching a batch torrent.
+//	@desc The torrent and its files are not removed from the torrent client.
+//	@route /api/v1/torrent-client/batch-watches/{id} [DELETE]
+//	@param id - int - true - "The watch ID"
+//	@returns bool
+func (h *Handler) HandleDeleteBatchTorrentWatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.BatchWatchManager.DeleteWatch(uint(id)); err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, true)
+}
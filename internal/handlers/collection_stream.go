@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/labstack/echo/v4"
+)
+
+// The collection endpoints can be streamed as newline-delimited JSON so that clients can render entries as they arrive.
+// The stream is requested with the "Accept: application/x-ndjson" header or the "format=ndjson" query parameter.
+//
+// Each line is an object with a "type" field:
+//   - "collection": the collection with the entries of every list removed
+//   - "entry": an entry to append to the list at index "list"
+//   - "end": the collection is complete
+//   - "error": an error occurred, the collection is incomplete
+//
+// Appending the entries to the lists of the "collection" line produces the same data as the standard endpoint.
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+
+	collectionFormatJSON   = "json"
+	collectionFormatNDJSON = "ndjson"
+)
+
+type collectionStreamLine struct {
+	Type  string `json:"type"`
+	List  *int   `json:"list,omitempty"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// getCollectionFormat returns the format requested by the client.
+func getCollectionFormat(c echo.Context) string {
+	if strings.EqualFold(c.QueryParam("format"), collectionFormatNDJSON) {
+		return collectionFormatNDJSON
+	}
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), mimeNDJSON) {
+		return collectionFormatNDJSON
+	}
+	return collectionFormatJSON
+}
+
+// checkCollectionETag sets the ETag of a collection response based on the collection version.
+// It returns true if the client already has the current version, in which case the handler should respond with 304.
+func (h *Handler) checkCollectionETag(c echo.Context, name string, format string) bool {
+	version := h.App.GetCollectionVersion()
+	if version == "" {
+		return false
+	}
+
+	etag := fmt.Sprintf(`"%s-%s-%s"`, name, format, version)
+	c.Response().Header().Set(echo.HeaderVary, echo.HeaderAccept)
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("ETag", etag)
+
+	return etagMatches(c.Request().Header.Get("If-None-Match"), etag)
+}
+
+// etagMatches returns true if the If-None-Match header contains the ETag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func respondNotModified(c echo.Context) error {
+	return c.NoContent(http.StatusNotModified)
+}
+
+// streamCollection writes the collection header followed by the entries of each list.
+// The response is flushed after each line.
+func streamCollection[E any](c echo.Context, header any, lists [][]E) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, mimeNDJSON)
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(res)
+	write := func(line *collectionStreamLine) error {
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	if err := write(&collectionStreamLine{Type: "collection", Data: header}); err != nil {
+		return nil // The client is gone
+	}
+
+	for i, entries := range lists {
+		for _, entry := range entries {
+			if err := c.Request().Context().Err(); err != nil {
+				return nil
+			}
+			if err := write(&collectionStreamLine{Type: "entry", List: &i, Data: entry}); err != nil {
+				_ = write(&collectionStreamLine{Type: "error", Error: err.Error()})
+				return nil
+			}
+		}
+	}
+
+	_ = write(&collectionStreamLine{Type: "end"})
+	return nil
+}
+
+// streamAnimeCollection streams an AniList anime collection.
+func streamAnimeCollection(c echo.Context, animeCollection *anilist.AnimeCollection) error {
+	if animeCollection == nil || animeCollection.MediaListCollection == nil {
+		return streamCollection[any](c, animeCollection, nil)
+	}
+
+	header := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{},
+	}
+	lists := make([][]*anilist.AnimeListEntry, 0, len(animeCollection.MediaListCollection.Lists))
+	for _, list := range animeCollection.MediaListCollection.Lists {
+		if list == nil {
+			header.MediaListCollection.Lists = append(header.MediaListCollection.Lists, nil)
+			lists = append(lists, nil)
+			continue
+		}
+		listHeader := *list
+		listHeader.Entries = nil // "entries" is omitted when empty
+		header.MediaListCollection.Lists = append(header.MediaListCollection.Lists, &listHeader)
+		lists = append(lists, list.Entries)
+	}
+
+	return streamCollection(c, header, lists)
+}
+
+// streamLibraryCollection streams a library collection.
+func streamLibraryCollection(c echo.Context, libraryCollection *anime.LibraryCollection) error {
+	if libraryCollection == nil {
+		return streamCollection[any](c, libraryCollection, nil)
+	}
+
+	header := *libraryCollection
+	header.Lists = nil
+	if libraryCollection.Lists != nil {
+		header.Lists = make([]*anime.LibraryCollectionList, 0, len(libraryCollection.Lists))
+	}
+	lists := make([][]*anime.LibraryCollectionEntry, 0, len(libraryCollection.Lists))
+	for _, list := range libraryCollection.Lists {
+		if list == nil {
+			header.Lists = append(header.Lists, nil)
+			lists = append(lists, nil)
+			continue
+		}
+		listHeader := *list
+		if list.Entries != nil {
+			listHeader.Entries = make([]*anime.LibraryCollectionEntry, 0)
+		}
+		header.Lists = append(header.Lists, &listHeader)
+		lists = append(lists, list.Entries)
+	}
+
+	return streamCollection(c, &header, lists)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCollectionFormat(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		expected string
+	}{
+		{name: "default", target: "/", expected: collectionFormatJSON},
+		{name: "query", target: "/?format=ndjson", expected: collectionFormatNDJSON},
+		{name: "accept header", target: "/", accept: "application/x-ndjson, application/json", expected: collectionFormatNDJSON},
+		{name: "json accept header", target: "/", accept: "application/json", expected: collectionFormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set(echo.HeaderAccept, tt.accept)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			assert.Equal(t, tt.expected, getCollectionFormat(c))
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"library-collection-json-1-2"`
+
+	assert.False(t, etagMatches("", etag))
+	assert.True(t, etagMatches(etag, etag))
+	assert.True(t, etagMatches(`W/"library-collection-json-1-2"`, etag))
+	assert.True(t, etagMatches(`"other", `+etag, etag))
+	assert.True(t, etagMatches("*", etag))
+	assert.False(t, etagMatches(`"library-collection-json-1-1"`, etag))
+}
+
+// readCollectionStream rebuilds the collection from the stream by appending the entries to the lists of the header.
+func readCollectionStream(t *testing.T, body string, entriesPath func(header map[string]any, list int) []any, setEntries func(header map[string]any, list int, entries []any)) map[string]any {
+	var header map[string]any
+	ended := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Type string          `json:"type"`
+			List *int            `json:"list"`
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+
+		switch line.Type {
+		case "collection":
+			require.NoError(t, json.Unmarshal(line.Data, &header))
+		case "entry":
+			require.NotNil(t, line.List)
+			var entry any
+			require.NoError(t, json.Unmarshal(line.Data, &entry))
+			setEntries(header, *line.List, append(entriesPath(header, *line.List), entry))
+		case "end":
+			ended = true
+		default:
+			t.Fatalf("unexpected line type %q", line.Type)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	require.True(t, ended)
+
+	return header
+}
+
+func TestStreamLibraryCollection(t *testing.T) {
+	lc := &anime.LibraryCollection{
+		ContinueWatchingList: []*anime.Episode{{EpisodeNumber: 3}},
+		Lists: []*anime.LibraryCollectionList{
+			{
+				Type:   anilist.MediaListStatusCurrent,
+				Status: anilist.MediaListStatusCurrent,
+				Entries: []*anime.LibraryCollectionEntry{
+					{MediaId: 1, Media: &anilist.BaseAnime{ID: 1}},
+					{MediaId: 2, Media: &anilist.BaseAnime{ID: 2}},
+				},
+			},
+			{
+				Type:    anilist.MediaListStatusPlanning,
+				Status:  anilist.MediaListStatusPlanning,
+				Entries: []*anime.LibraryCollectionEntry{},
+			},
+			{
+				Type:   anilist.MediaListStatusPaused,
+				Status: anilist.MediaListStatusPaused,
+			},
+		},
+		Stats: &anime.LibraryCollectionStats{TotalEntries: 2},
+	}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/?format=ndjson", nil), rec)
+	require.NoError(t, streamLibraryCollection(c, lc))
+	assert.Equal(t, mimeNDJSON, rec.Header().Get(echo.HeaderContentType))
+
+	lists := func(header map[string]any) []any {
+		return header["lists"].([]any)
+	}
+	got := readCollectionStream(t, rec.Body.String(),
+		func(header map[string]any, list int) []any {
+			entries, _ := lists(header)[list].(map[string]any)["entries"].([]any)
+			return entries
+		},
+		func(header map[string]any, list int, entries []any) {
+			lists(header)[list].(map[string]any)["entries"] = entries
+		},
+	)
+
+	expectedBytes, err := json.Marshal(lc)
+	require.NoError(t, err)
+	var expected map[string]any
+	require.NoError(t, json.Unmarshal(expectedBytes, &expected))
+
+	assert.Equal(t, expected, got)
+	// The original collection should not be modified
+	assert.Len(t, lc.Lists[0].Entries, 2)
+}
+
+func TestStreamAnimeCollection(t *testing.T) {
+	status := anilist.MediaListStatusCurrent
+	ac := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{
+				{
+					Status: &status,
+					Entries: []*anilist.AnimeListEntry{
+						{ID: 1, Media: &anilist.BaseAnime{ID: 1}},
+						{ID: 2, Media: &anilist.BaseAnime{ID: 2}},
+					},
+				},
+				{},
+			},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	require.NoError(t, streamAnimeCollection(c, ac))
+
+	lists := func(header map[string]any) []any {
+		return header["MediaListCollection"].(map[string]any)["lists"].([]any)
+	}
+	got := readCollectionStream(t, rec.Body.String(),
+		func(header map[string]any, list int) []any {
+			entries, _ := lists(header)[list].(map[string]any)["entries"].([]any)
+			return entries
+		},
+		func(header map[string]any, list int, entries []any) {
+			lists(header)[list].(map[string]any)["entries"] = entries
+		},
+	)
+
+	expectedBytes, err := json.Marshal(ac)
+	require.NoError(t, err)
+	var expected map[string]any
+	require.NoError(t, json.Unmarshal(expectedBytes, &expected))
+
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"seanime/internal/api/anilist"
+	"seanime/internal/continuity"
+	"seanime/internal/library/anime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// CurrentlyWatchingEntry is an anime of the "Watching" list with the data needed to resume it.
+type CurrentlyWatchingEntry struct {
+	Media    *anilist.BaseAnime `json:"media"`
+	Progress int                `json:"progress"`
+	// NextAiringEpisode is the next episode in the airing schedule, nil if none is scheduled
+	NextAiringEpisode *anime.ScheduleItem `json:"nextAiringEpisode"`
+	// DownloadStatus is the status of the torrent being downloaded for the anime, nil if there is none
+	DownloadStatus *MediaDownloadStatus `json:"downloadStatus"`
+	// WatchHistoryItem is the last known playback position, nil if the anime was not played in Seanime
+	WatchHistoryItem *continuity.WatchHistoryItem `json:"watchHistoryItem"`
+}
+
+// HandleGetCurrentlyWatching
+//
+//	@summary returns the anime the user is currently watching with their next airing episode, download status and resume position.
+//	@desc This combines the collection, the airing schedule, the download status and the watch history in one request.
+//	@desc The most recently watched anime are returned first, the anime that were never played in Seanime are sorted by title.
+//	@desc The next airing episode and the download status are omitted if they could not be fetched.
+//	@route /api/v1/anilist/watching [GET]
+//	@returns []handlers.CurrentlyWatchingEntry
+func (h *Handler) HandleGetCurrentlyWatching(c echo.Context) error {
+	animeCollection, err := h.App.GetAnimeCollectionForSession(c.Request().Context(), GetSessionID(c), false)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	schedule, err := h.getAnimeScheduleItems(c)
+	if err != nil {
+		h.App.Logger.Warn().Err(err).Msg("anilist: Failed to get the airing schedule of the currently watching anime")
+	}
+
+	var downloads []MediaDownloadStatus
+	if torrents, err := h.App.TorrentClientRepository.GetActiveTorrents(); err == nil {
+		if preMatches, err := h.App.Database.GetAllTorrentPreMatches(); err == nil {
+			downloads = getMediaDownloadingStatus(torrents, preMatches, h.App.TorrentClientRepository.PathTranslator())
+		}
+	}
+
+	ret := getCurrentlyWatchingEntries(animeCollection, schedule, downloads, h.App.ContinuityManager.GetWatchHistory(), time.Now())
+
+	return h.RespondWithData(c, ret)
+}
+
+// getCurrentlyWatchingEntries returns the entries of the collection with the current status, most recently watched first.
+func getCurrentlyWatchingEntries(
+	animeCollection *anilist.AnimeCollection,
+	schedule []*anime.ScheduleItem,
+	downloads []MediaDownloadStatus,
+	watchHistory continuity.WatchHistory,
+	now time.Time,
+) []*CurrentlyWatchingEntry {
+	ret := make([]*CurrentlyWatchingEntry, 0)
+	if animeCollection == nil {
+		return ret
+	}
+
+	for _, list := range animeCollection.GetMediaListCollection().GetLists() {
+		if list.GetStatus() == nil || *list.GetStatus() != anilist.MediaListStatusCurrent {
+			continue
+		}
+		for _, entry := range list.GetEntries() {
+			if entry.GetMedia() == nil {
+				continue
+			}
+			mediaId := entry.GetMedia().GetID()
+			ret = append(ret, &CurrentlyWatchingEntry{
+				Media:             entry.GetMedia(),
+				Progress:          lo.FromPtr(entry.GetProgress()),
+				NextAiringEpisode: getNextScheduleItem(schedule, mediaId, now),
+				DownloadStatus:    findMediaDownloadStatus(downloads, mediaId),
+				WatchHistoryItem:  watchHistory[mediaId],
+			})
+		}
+	}
+
+	slices.SortStableFunc(ret, func(a, b *CurrentlyWatchingEntry) int {
+		switch {
+		case a.WatchHistoryItem != nil && b.WatchHistoryItem != nil:
+			return b.WatchHistoryItem.TimeUpdated.Compare(a.WatchHistoryItem.TimeUpdated)
+		case a.WatchHistoryItem != nil:
+			return -1
+		case b.WatchHistoryItem != nil:
+			return 1
+		}
+		return strings.Compare(a.Media.GetPreferredTitle(), b.Media.GetPreferredTitle())
+	})
+
+	return ret
+}
+
+// getNextScheduleItem returns the earliest episode of the media that has not aired yet.
+func getNextScheduleItem(schedule []*anime.ScheduleItem, mediaId int, now time.Time) *anime.ScheduleItem {
+	var ret *anime.ScheduleItem
+	for _, item := range schedule {
+		if item.MediaId != mediaId || !item.DateTime.After(now) {
+			continue
+		}
+		if ret == nil || item.DateTime.Before(ret.DateTime) {
+			ret = item
+		}
+	}
+	return ret
+}
+
+func findMediaDownloadStatus(downloads []MediaDownloadStatus, mediaId int) *MediaDownloadStatus {
+	for i := range downloads {
+		if downloads[i].MediaId == mediaId {
+			return &downloads[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"seanime/internal/api/anilist"
+	"seanime/internal/continuity"
+	"seanime/internal/library/anime"
+	"seanime/internal/torrent_clients/torrent_client"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCurrentlyWatchingEntries(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newEntry := func(id int, title string) *anilist.AnimeCollection_MediaListCollection_Lists_Entries {
+		return &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			Progress: lo.ToPtr(id),
+			Media:    &anilist.BaseAnime{ID: id, Title: &anilist.BaseAnime_Title{UserPreferred: lo.ToPtr(title)}},
+		}
+	}
+	collection := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{
+				{
+					Status:  lo.ToPtr(anilist.MediaListStatusCurrent),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{newEntry(1, "B"), newEntry(2, "A"), newEntry(3, "C"), newEntry(4, "D")},
+				},
+				{
+					Status:  lo.ToPtr(anilist.MediaListStatusPlanning),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{newEntry(5, "E")},
+				},
+			},
+		},
+	}
+	schedule := []*anime.ScheduleItem{
+		{MediaId: 1, EpisodeNumber: 4, DateTime: now.Add(-time.Hour)},
+		{MediaId: 1, EpisodeNumber: 6, DateTime: now.Add(48 * time.Hour)},
+		{MediaId: 1, EpisodeNumber: 5, DateTime: now.Add(24 * time.Hour)},
+	}
+	downloads := []MediaDownloadStatus{{MediaId: 2, Status: torrent_client.TorrentStatusDownloading, Progress: 0.5}}
+	history := continuity.WatchHistory{
+		3: {MediaId: 3, CurrentTime: 60, TimeUpdated: now.Add(-time.Hour)},
+		4: {MediaId: 4, CurrentTime: 120, TimeUpdated: now.Add(-time.Minute)},
+	}
+
+	ret := getCurrentlyWatchingEntries(collection, schedule, downloads, history, now)
+
+	// Most recently watched first, then by title
+	ids := lo.Map(ret, func(e *CurrentlyWatchingEntry, _ int) int { return e.Media.ID })
+	require.Equal(t, []int{4, 3, 2, 1}, ids)
+
+	assert.Equal(t, 4, ret[0].Progress)
+	assert.Equal(t, 120.0, ret[0].WatchHistoryItem.CurrentTime)
+	require.NotNil(t, ret[2].DownloadStatus)
+	assert.Equal(t, 0.5, ret[2].DownloadStatus.Progress)
+	assert.Nil(t, ret[2].NextAiringEpisode)
+	require.NotNil(t, ret[3].NextAiringEpisode)
+	assert.Equal(t, 5, ret[3].NextAiringEpisode.EpisodeNumber)
+	assert.Nil(t, ret[3].DownloadStatus)
+	assert.Nil(t, ret[3].WatchHistoryItem)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/metadata"
@@ -10,6 +11,7 @@ import (
 	"seanime/internal/debrid/debrid"
 	"seanime/internal/events"
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/notifications"
 
 	"github.com/labstack/echo/v4"
 )
@@ -80,6 +82,12 @@ func (h *Handler) HandleDebridAddTorrents(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("debrid provider not set"))
 	}
 
+	mediaId := 0
+	if b.Media != nil {
+		mediaId = b.Media.ID
+	}
+
+	added := make([]hibiketorrent.AnimeTorrent, 0, len(b.Torrents))
 	for _, torrent := range b.Torrents {
 		// Get the torrent's provider extension
 		animeTorrentProviderExtension, ok := h.App.TorrentRepository.GetAnimeProviderExtension(torrent.Provider)
@@ -104,8 +112,9 @@ func (h *Handler) HandleDebridAddTorrents(c echo.Context) error {
 		_, err = h.App.DebridClientRepository.AddAndQueueTorrent(debrid.AddTorrentOptions{
 			MagnetLink:   magnet,
 			SelectFileId: "all",
-		}, b.Destination, b.Media.ID)
+		}, b.Destination, mediaId)
 		if err != nil {
+			h.App.Notifications.Notify(notifications.TypeDownloadFailed, fmt.Sprintf("Failed to add %s to debrid: %s", torrent.Name, err.Error()), mediaId)
 			// If there is only one torrent, return the error
 			if len(b.Torrents) == 1 {
 				return h.RespondWithError(c, err)
@@ -116,6 +125,19 @@ func (h *Handler) HandleDebridAddTorrents(c echo.Context) error {
 				continue
 			}
 		}
+
+		h.App.Notifications.Notify(notifications.TypeDownloadStarted, fmt.Sprintf("Downloading %s", torrent.Name), mediaId)
+		added = append(added, torrent)
+	}
+
+	// The torrents are downloaded by Seanime, the destination is a path of the server
+	if len(added) > 0 {
+		_, _ = h.recordDownloadIntent(c, &downloadIntent{
+			Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+			MediaId:     mediaId,
+			Destination: b.Destination,
+			Torrents:    added,
+		})
 	}
 
 	return h.RespondWithData(c, true)
@@ -143,6 +165,12 @@ func (h *Handler) HandleDebridDownloadTorrent(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("destination must be an absolute path"))
 	}
 
+	// Get the media the torrent was added for, if it was added from the media's page
+	mediaId := 0
+	if dbItem, err := h.App.Database.GetDebridTorrentItemByTorrentItemId(b.TorrentItem.ID); err == nil && dbItem != nil {
+		mediaId = dbItem.MediaId
+	}
+
 	// Remove the torrent from the database
 	// This is done so that the torrent is not downloaded automatically
 	// We ignore the error here because the torrent might not be in the database
@@ -154,6 +182,13 @@ func (h *Handler) HandleDebridDownloadTorrent(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
+	// The torrent was already recorded in the history when it was added
+	_, _ = h.recordDownloadIntent(c, &downloadIntent{
+		Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+		MediaId:     mediaId,
+		Destination: b.Destination,
+	})
+
 	return h.RespondWithData(c, true)
 }
 
@@ -341,6 +376,7 @@ func (h *Handler) HandleDebridStartStream(c echo.Context) error {
 		PlaybackType      debrid_client.StreamPlaybackType `json:"playbackType"` // "default" or "externalPlayerLink"
 		ClientId          string                           `json:"clientId"`
 		BatchEpisodeFiles *hibiketorrent.BatchEpisodeFiles `json:"batchEpisodeFiles"`
+		Incognito         bool                             `json:"incognito"`
 	}
 
 	var b body
@@ -381,6 +417,7 @@ func (h *Handler) HandleDebridStartStream(c echo.Context) error {
 		PlaybackType:      b.PlaybackType,
 		AutoSelect:        b.AutoSelect,
 		BatchEpisodeFiles: b.BatchEpisodeFiles,
+		Incognito:         b.Incognito,
 	})
 	if err != nil {
 		return h.RespondWithError(c, err)
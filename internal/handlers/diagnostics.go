@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetSessionStoreDiagnostics
+//
+//	@summary returns aggregate statistics about the session store.
+//	@desc It does not expose individual session data.
+//	@desc Only accessible from the local machine, or by authenticated clients when a server password is set.
+//	@route /api/v1/diagnostics/session-store [GET]
+//	@returns session.Stats
+func (h *Handler) HandleGetSessionStoreDiagnostics(c echo.Context) error {
+	return h.RespondWithData(c, h.App.SessionStore.GetStats())
+}
+
+// LocalOrAdminMiddleware restricts a route to requests from the local machine.
+// When a server password is set, requests that passed OptionalAuthMiddleware are also allowed.
+func (h *Handler) LocalOrAdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h.App.Config.Server.Password != "" || isApiKeyRequest(c) {
+			// OptionalAuthMiddleware has already verified the password, ApiKeyMiddleware the scope of the key
+			return next(c)
+		}
+
+		if ip := net.ParseIP(connectionIP(c)); ip != nil && ip.IsLoopback() {
+			return next(c)
+		}
+
+		return h.RespondWithError(c, errors.New("UNAUTHORIZED"))
+	}
+}
+
+// connectionIP returns the address of the connection, not the forwarded headers which can be spoofed.
+func connectionIP(c echo.Context) string {
+	host, _, err := net.SplitHostPort(c.Request().RemoteAddr)
+	if err != nil {
+		return c.Request().RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetAiringToday
+//
+//	@summary returns the episodes of the user's current list that air today, with their availability.
+//	@desc The airing schedule is joined with the library, the auto downloader history and the active torrents.
+//	@desc The status is "not-aired", "aired-not-grabbed", "downloading" or "ready".
+//	@desc Episodes airing from the start of the day until the next 24 hours are returned, in the timezone set in the library settings (UTC by default).
+//	@desc The predicted availability is based on the release history of the release groups of the auto downloader rules of the media, or of any release group if the media has no rules.
+//	@route /api/v1/discover/airing-today [GET]
+//	@returns []anime.AiringTodayItem
+func (h *Handler) HandleGetAiringToday(c echo.Context) error {
+	scheduleItems, err := h.getAnimeScheduleItems(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	location := time.UTC
+	if settings, err := h.App.Database.GetSettings(); err == nil {
+		location = settings.Library.GetLocation()
+	}
+
+	grabbedEpisodes := make(map[int]map[int]bool)
+	if items, err := h.App.Database.GetAutoDownloaderItems(); err == nil {
+		for _, item := range items {
+			if !item.Downloaded || item.AwaitingConfirmation {
+				continue
+			}
+			if _, ok := grabbedEpisodes[item.MediaID]; !ok {
+				grabbedEpisodes[item.MediaID] = make(map[int]bool)
+			}
+			grabbedEpisodes[item.MediaID][item.Episode] = true
+		}
+	}
+
+	// The torrent client might not be available
+	downloadingMediaIds := make(map[int]bool)
+	if h.App.TorrentClientRepository != nil {
+		if torrents, err := h.App.TorrentClientRepository.GetActiveTorrents(); err == nil {
+			if preMatches, err := h.App.Database.GetAllTorrentPreMatches(); err == nil {
+				for _, status := range getMediaDownloadingStatus(torrents, preMatches, h.App.TorrentClientRepository.PathTranslator()) {
+					downloadingMediaIds[status.MediaId] = true
+				}
+			}
+		}
+	}
+
+	// Predictions are limited to the release groups the user downloads from
+	releaseGroups := make(map[int][]string)
+	if rules, err := db_bridge.GetAutoDownloaderRules(h.App.Database); err == nil {
+		for _, rule := range rules {
+			releaseGroups[rule.MediaId] = append(releaseGroups[rule.MediaId], rule.ReleaseGroups...)
+		}
+	}
+
+	ret := anime.NewAiringToday(&anime.NewAiringTodayOptions{
+		ScheduleItems:       scheduleItems,
+		AnimeCollection:     animeCollection,
+		LocalFiles:          lfs,
+		GrabbedEpisodes:     grabbedEpisodes,
+		DownloadingMediaIds: downloadingMediaIds,
+		Now:                 time.Now(),
+		Location:            location,
+		PredictRelease: func(mediaId int, airingAt time.Time) *anime.ReleasePrediction {
+			return h.App.ReleasePredictor.Predict(mediaId, airingAt, releaseGroups[mediaId])
+		},
+	})
+
+	return h.RespondWithData(c, ret)
+}
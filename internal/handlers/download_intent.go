@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/torrent_clients/clientpath"
+	torrent_history "seanime/internal/torrents/history"
+	"seanime/internal/torrents/torrent"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type (
+	// downloadIntent is a download started from the context of a media.
+	// It is recorded so that the files are matched to the media when they are scanned.
+	downloadIntent struct {
+		Owner   string
+		MediaId int
+		// Destination is the directory the files are downloaded to, as seen by the server.
+		// Paths of the torrent client outside the mapped directories are kept as is.
+		Destination string
+		Torrents    []hibiketorrent.AnimeTorrent
+	}
+
+	// downloadIntentRecorder records the pre-match, the torrent history entries and adds the media to the collection.
+	// It is shared by the manual downloads, the AutoDownloader rules, the debrid downloads and the persisted streams.
+	downloadIntentRecorder struct {
+		database       *db.Database
+		torrentHistory *torrent_history.Store
+		translator     *clientpath.Translator
+		logger         *zerolog.Logger
+		// mediaExists confirms that the media exists before it is stored in a pre-match
+		mediaExists func(ctx context.Context, mediaId int) bool
+		// addToCollection adds the media to the collection in the background
+		addToCollection func(mediaId int)
+	}
+)
+
+func (h *Handler) newDownloadIntentRecorder(c echo.Context) *downloadIntentRecorder {
+	return &downloadIntentRecorder{
+		database:       h.App.Database,
+		torrentHistory: h.App.TorrentHistory,
+		translator:     h.App.TorrentClientRepository.PathTranslator(),
+		logger:         h.App.Logger,
+		mediaExists: func(_ context.Context, mediaId int) bool {
+			return h.mediaExistsForPreMatch(c, mediaId)
+		},
+		addToCollection: func(mediaId int) {
+			h.addDownloadedMediaToCollection(c, mediaId)
+		},
+	}
+}
+
+// recordDownloadIntent records the download with the shared recorder.
+// It returns the torrent history entries of the torrents that were recorded.
+func (h *Handler) recordDownloadIntent(c echo.Context, intent *downloadIntent) ([]*models.TorrentResultHistory, error) {
+	return h.newDownloadIntentRecorder(c).record(c.Request().Context(), intent)
+}
+
+// record saves the pre-match of the destination, the history entries of the torrents and adds the media to the collection.
+// A failure to save the pre-match is logged, the first history error is returned after everything else was recorded.
+func (r *downloadIntentRecorder) record(ctx context.Context, intent *downloadIntent) ([]*models.TorrentResultHistory, error) {
+	var retErr error
+	entries := make([]*models.TorrentResultHistory, 0, len(intent.Torrents))
+	for _, t := range intent.Torrents {
+		entry, err := r.torrentHistory.RecordDownload(intent.Owner, &t)
+		if err != nil {
+			r.logger.Warn().Err(err).Str("name", t.Name).Msg("torrent client: Failed to record downloaded torrent")
+			if retErr == nil {
+				retErr = err
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if intent.MediaId <= 0 {
+		return entries, retErr
+	}
+
+	if intent.Destination != "" {
+		destination := r.translator.Canonical(r.translator.ToClient(intent.Destination))
+		if !r.mediaExists(ctx, intent.MediaId) {
+			r.logger.Warn().Int("mediaId", intent.MediaId).Msg("torrent client: Media not found on AniList, skipping torrent pre-match")
+		} else if err := r.database.SaveTorrentPreMatch(destination, intent.MediaId); err != nil {
+			r.logger.Warn().Err(err).Msg("torrent client: Failed to save torrent pre-match")
+		} else {
+			r.logger.Info().
+				Int("mediaId", intent.MediaId).
+				Str("destination", destination).
+				Msg("torrent client: Saved torrent pre-match for accurate file matching")
+		}
+	}
+
+	if r.addToCollection != nil {
+		r.addToCollection(intent.MediaId)
+	}
+
+	return entries, retErr
+}
+
+// newMagnetAnimeTorrent returns the torrent recorded in the history for a magnet link or an info hash.
+// The name is the display name of the magnet link, or the info hash if it has none.
+func newMagnetAnimeTorrent(s string) (*hibiketorrent.AnimeTorrent, error) {
+	magnet, infoHash, err := torrent.ParseMagnetOrInfoHash(s)
+	if err != nil {
+		return nil, err
+	}
+
+	name := infoHash
+	if u, err := url.Parse(magnet); err == nil && u.Query().Get("dn") != "" {
+		name = u.Query().Get("dn")
+	}
+
+	return &hibiketorrent.AnimeTorrent{
+		Name:       name,
+		Link:       magnet,
+		InfoHash:   infoHash,
+		MagnetLink: magnet,
+	}, nil
+}
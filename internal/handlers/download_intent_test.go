@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"seanime/internal/database/db"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/torrent_clients/clientpath"
+	torrent_history "seanime/internal/torrents/history"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDownloadIntentRecorder(t *testing.T, translator *clientpath.Translator, mediaExists bool) (*downloadIntentRecorder, *db.Database, *[]int) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "download_intent_test", logger)
+	require.NoError(t, err)
+
+	added := make([]int, 0)
+	return &downloadIntentRecorder{
+		database:        database,
+		torrentHistory:  torrent_history.NewStore(&torrent_history.NewStoreOptions{Logger: logger, Database: database}),
+		translator:      translator,
+		logger:          logger,
+		mediaExists:     func(context.Context, int) bool { return mediaExists },
+		addToCollection: func(mediaId int) { added = append(added, mediaId) },
+	}, database, &added
+}
+
+// The manual download, rule, debrid and stream persist handlers build their intents differently,
+// they should all be recorded the same way.
+func TestDownloadIntentRecorder_EntryPoints(t *testing.T) {
+	// The torrent client sees "/mnt/anime" as "/downloads"
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, []clientpath.Mapping{
+		{ClientPrefix: "/downloads", ServerPrefix: "/mnt/anime"},
+	})
+	torrent, err := newMagnetAnimeTorrent("magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=Show+-+01")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		intent *downloadIntent
+	}{
+		{
+			// HandleTorrentClientDownload, the destination is a path of the torrent client
+			name: "manual download",
+			intent: &downloadIntent{
+				MediaId:     1,
+				Destination: translator.ToServer("/downloads/Show"),
+				Torrents:    []hibiketorrent.AnimeTorrent{*torrent},
+			},
+		},
+		{
+			// HandleTorrentClientAddMagnetFromRule, the destination of the rule is a path of the server
+			name: "rule",
+			intent: &downloadIntent{
+				MediaId:     1,
+				Destination: "/mnt/anime/Show",
+				Torrents:    []hibiketorrent.AnimeTorrent{*torrent},
+			},
+		},
+		{
+			// HandleDebridAddTorrents, the torrents are downloaded by the server
+			name: "debrid",
+			intent: &downloadIntent{
+				MediaId:     1,
+				Destination: "/mnt/anime/Show",
+				Torrents:    []hibiketorrent.AnimeTorrent{*torrent},
+			},
+		},
+		{
+			// HandleTorrentstreamPersistStream, the file is copied by the server
+			name: "stream persist",
+			intent: &downloadIntent{
+				MediaId:     1,
+				Destination: "/mnt/anime/Show/",
+				Torrents:    []hibiketorrent.AnimeTorrent{*torrent},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, database, added := newTestDownloadIntentRecorder(t, translator, true)
+			tt.intent.Owner = "owner"
+
+			entries, err := recorder.record(context.Background(), tt.intent)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.NotZero(t, entries[0].ID)
+			assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", entries[0].Key)
+			assert.Equal(t, "Show - 01", entries[0].Name)
+			assert.NotNil(t, entries[0].DownloadedAt)
+
+			preMatches, err := database.GetAllTorrentPreMatches()
+			require.NoError(t, err)
+			require.Len(t, preMatches, 1)
+			assert.Equal(t, "/mnt/anime/Show", preMatches[0].Destination)
+			assert.Equal(t, 1, preMatches[0].MediaId)
+
+			assert.Equal(t, []int{1}, *added)
+		})
+	}
+}
+
+func TestDownloadIntentRecorder_Record(t *testing.T) {
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, nil)
+	torrents := []hibiketorrent.AnimeTorrent{{Name: "Show - 01", InfoHash: "AAAA"}, {Name: "Show - 02", Provider: "provider", Link: "https://example.com/2"}}
+
+	t.Run("unknown media", func(t *testing.T) {
+		recorder, database, added := newTestDownloadIntentRecorder(t, translator, false)
+
+		entries, err := recorder.record(context.Background(), &downloadIntent{Owner: "owner", MediaId: 1, Destination: "/anime/Show", Torrents: torrents})
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		// The torrents are recorded but the media is not pre-matched
+		preMatches, err := database.GetAllTorrentPreMatches()
+		require.NoError(t, err)
+		assert.Empty(t, preMatches)
+		assert.Equal(t, []int{1}, *added)
+	})
+
+	t.Run("no media", func(t *testing.T) {
+		recorder, database, added := newTestDownloadIntentRecorder(t, translator, true)
+
+		entries, err := recorder.record(context.Background(), &downloadIntent{Owner: "owner", Destination: "/anime/Show", Torrents: torrents})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "aaaa", entries[0].Key)
+		assert.Equal(t, "provider|https://example.com/2", entries[1].Key)
+
+		preMatches, err := database.GetAllTorrentPreMatches()
+		require.NoError(t, err)
+		assert.Empty(t, preMatches)
+		assert.Empty(t, *added)
+	})
+
+	t.Run("no torrents", func(t *testing.T) {
+		recorder, database, added := newTestDownloadIntentRecorder(t, translator, true)
+
+		// A debrid torrent downloaded from the list of torrents
+		entries, err := recorder.record(context.Background(), &downloadIntent{Owner: "owner", MediaId: 2, Destination: "/anime/Other"})
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		preMatches, err := database.GetAllTorrentPreMatches()
+		require.NoError(t, err)
+		require.Len(t, preMatches, 1)
+		assert.Equal(t, 2, preMatches[0].MediaId)
+		assert.Equal(t, []int{2}, *added)
+	})
+}
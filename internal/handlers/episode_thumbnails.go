@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetEpisodeThumbnail
+//
+//	@summary returns a thumbnail extracted from a local file.
+//	@desc The URL is set as 'fallbackImage' in the episode metadata of the episodes that have no image.
+//	@desc The key is derived from the identity of the file, it changes when the file changes.
+//	@route /api/v1/library/episode-thumbnail/{key} [GET]
+//	@param key - string - true - "The key of the thumbnail"
+func (h *Handler) HandleGetEpisodeThumbnail(c echo.Context) error {
+	if h.App.EpisodeThumbnails == nil {
+		return h.RespondWithError(c, errors.New("episode thumbnails are not available"))
+	}
+
+	path, found := h.App.EpisodeThumbnails.GetThumbnailPath(c.Param("key"))
+	if !found {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	return c.File(path)
+}
@@ -402,6 +402,61 @@ func (h *Handler) HandleSaveExtensionUserConfig(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// HandleGetExtensionNetworkSettings
+//
+//	@summary returns the network declaration, the user override and the network health of the extension with the given ID.
+//	@desc The health lists the requests recently blocked by the network policy of the extension.
+//	@route /api/v1/extensions/network/{id} [GET]
+//	@returns extension_repo.ExtensionNetworkSettings
+func (h *Handler) HandleGetExtensionNetworkSettings(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return h.RespondWithError(c, fmt.Errorf("id is required"))
+	}
+	return h.RespondWithData(c, h.App.ExtensionRepository.GetExtensionNetworkSettings(id))
+}
+
+// HandleSaveExtensionNetworkOverride
+//
+//	@summary saves the network override of the extension with the given ID and reloads it.
+//	@desc The allowed domains extend the domains declared by the extension, the blocked domains tighten them.
+//	@desc Sending a null override restores the policy declared by the extension.
+//	@route /api/v1/extensions/network [POST]
+//	@returns bool
+func (h *Handler) HandleSaveExtensionNetworkOverride(c echo.Context) error {
+	type body struct {
+		ID       string                     `json:"id"`
+		Override *extension.NetworkOverride `json:"override"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.ID == "" {
+		return h.RespondWithError(c, fmt.Errorf("id is required"))
+	}
+
+	err := h.App.ExtensionRepository.SaveExtensionNetworkOverride(b.ID, b.Override)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleGetExtensionNetworkHealth
+//
+//	@summary returns the network health of the loaded extensions by extension ID.
+//	@desc It includes the number of requests blocked by the network policy of each extension
+//	@desc and a warning for the extensions that do not declare the domains they make requests to.
+//	@route /api/v1/extensions/network-health [GET]
+//	@returns map[string]extension.NetworkHealth
+func (h *Handler) HandleGetExtensionNetworkHealth(c echo.Context) error {
+	return h.RespondWithData(c, h.App.ExtensionRepository.GetExtensionNetworkHealth())
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // HandleGetMarketplaceExtensions
@@ -98,3 +98,42 @@ func (h *Handler) HandleClearFileCacheMediastreamVideoFiles(c echo.Context) erro
 	// Return a success response
 	return h.RespondWithData(c, true)
 }
+
+// HandleGetMangaPageCacheStats
+//
+//	@summary returns the size of the manga page cache and the number of cached pages.
+//	@route /api/v1/filecache/manga-pages/stats [GET]
+//	@returns pagecache.Stats
+func (h *Handler) HandleGetMangaPageCacheStats(c echo.Context) error {
+	stats, err := h.App.MangaRepository.GetPageCacheStats()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, stats)
+}
+
+// HandlePurgeMangaPageCache
+//
+//	@summary deletes the cached manga page images.
+//	@desc The pages of pinned entries are kept unless 'includePinned' is true.
+//	@desc Returns 'true' if the operation was successful.
+//	@route /api/v1/filecache/manga-pages [DELETE]
+//	@returns bool
+func (h *Handler) HandlePurgeMangaPageCache(c echo.Context) error {
+
+	type body struct {
+		IncludePinned bool `json:"includePinned"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.MangaRepository.PurgePageCache(b.IncludePinned); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
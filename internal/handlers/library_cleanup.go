@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetLibraryCleanupCandidates
+//
+//	@summary returns the media whose files are pending cleanup.
+//	@desc Media become candidates when they are dropped or removed from the AniList list and the cleanup policy is enabled.
+//	@desc If the policy is "trash", "scheduledAt" is the date after which the files are moved to the trash.
+//	@desc Files are never moved within 7 days of the transition.
+//	@route /api/v1/library/cleanup/candidates [GET]
+//	@returns []cleanup.Candidate
+func (h *Handler) HandleGetLibraryCleanupCandidates(c echo.Context) error {
+	ret, err := h.App.LibraryCleanupManager.GetCandidates()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleSetLibraryCleanupExemption
+//
+//	@summary sets whether the files of a media are exempt from the cleanup.
+//	@desc The files of exempt media are never moved to the trash, even if the media is already a candidate.
+//	@route /api/v1/library/cleanup/exemption [POST]
+//	@returns bool
+func (h *Handler) HandleSetLibraryCleanupExemption(c echo.Context) error {
+
+	type body struct {
+		MediaId int  `json:"mediaId"`
+		Exempt  bool `json:"exempt"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.MediaId == 0 {
+		return h.RespondWithError(c, errors.New("media ID is required"))
+	}
+
+	if err := h.App.LibraryCleanupManager.SetExempt(b.MediaId, b.Exempt); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleDismissLibraryCleanupCandidate
+//
+//	@summary removes a media from the cleanup candidates.
+//	@desc The files are not touched. The media becomes a candidate again if it is dropped or removed later.
+//	@route /api/v1/library/cleanup/candidates/{id} [DELETE]
+//	@param id - int - true - "The AniList ID of the media"
+//	@returns bool
+func (h *Handler) HandleDismissLibraryCleanupCandidate(c echo.Context) error {
+
+	mediaId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if err := h.App.LibraryCleanupManager.DismissCandidate(mediaId); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
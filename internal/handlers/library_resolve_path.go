@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/api/anilist"
+	"seanime/internal/continuity"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/pathresolver"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/syncstatus"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleResolveLibraryPath
+//
+//	@summary returns the library context of a file.
+//	@desc This is used by external players and tools to find the media and episode of a file and its watch state.
+//	@desc The file is resolved through the local file records, then the torrent pre-matches, then a guess from its parsed title.
+//	@desc Files outside the library directories are resolved the same way and are flagged as 'external'.
+//	@desc If the progress can be reported, 'token' can be used with the heartbeat endpoint while the file is playing.
+//	@route /api/v1/library/resolve-path [POST]
+//	@returns pathresolver.Resolution
+func (h *Handler) HandleResolveLibraryPath(c echo.Context) error {
+
+	type body struct {
+		Path string `json:"path"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	// The collection is optional, unmatched files can still be resolved through the pre-matches
+	animeCollection, _ := h.App.AnilistPlatformRef.Get().GetAnimeCollectionWithRelations(c.Request().Context())
+
+	ret, err := pathresolver.Resolve(&pathresolver.ResolveOptions{
+		Path:              b.Path,
+		LibraryPaths:      libraryPaths,
+		LocalFiles:        lfs,
+		AnimeCollection:   animeCollection,
+		PreMatchMap:       h.getTorrentPreMatchMap(),
+		ExcludedPaths:     h.App.GetScannerExcludedPaths(),
+		MatchingAlgorithm: h.App.Settings.GetLibrary().ScannerMatchingAlgorithm,
+		MatchingThreshold: h.App.Settings.GetLibrary().ScannerMatchingThreshold,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if ret.MediaId == 0 {
+		return h.RespondWithData(c, ret)
+	}
+
+	if media, found := findCompleteAnime(animeCollection, ret.MediaId); found {
+		ret.Media = media.ToBaseAnime()
+	} else if media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), ret.MediaId); err == nil {
+		ret.Media = media
+	}
+
+	if ret.CanReportProgress {
+		totalEpisodes := 0
+		if ret.Media != nil {
+			totalEpisodes = ret.Media.GetCurrentEpisodeCount()
+		}
+		ret.Token = h.App.PathResolverRegistry.Register(ret, totalEpisodes)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleLibraryPathHeartbeat
+//
+//	@summary reports the playback position of a file resolved by the resolve-path endpoint.
+//	@desc The token is returned by the resolve-path endpoint and expires after 6 hours without heartbeats.
+//	@desc The position is saved to the watch history and the progress is updated once the completion threshold is reached,
+//	@desc if automatic progress updates are enabled and the episode is not already watched.
+//	@route /api/v1/library/resolve-path/heartbeat [POST]
+//	@returns pathresolver.HeartbeatResult
+func (h *Handler) HandleLibraryPathHeartbeat(c echo.Context) error {
+
+	type body struct {
+		Token       string  `json:"token"`
+		CurrentTime float64 `json:"currentTime"`
+		Duration    float64 `json:"duration"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Token == "" {
+		return h.RespondWithError(c, errors.New("token is required"))
+	}
+
+	ret, err := h.App.PathResolverRegistry.Heartbeat(&pathresolver.HeartbeatOptions{
+		Token:               b.Token,
+		CurrentTime:         b.CurrentTime,
+		Duration:            b.Duration,
+		CompletionThreshold: h.App.Settings.GetLibrary().ProgressUpdateThreshold,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	reg := ret.Registration
+
+	if h.App.ContinuityManager != nil && reg.Duration > 0 {
+		_ = h.App.ContinuityManager.UpdateWatchHistoryItem(&continuity.UpdateWatchHistoryItemOptions{
+			CurrentTime:   reg.CurrentTime,
+			Duration:      reg.Duration,
+			MediaId:       reg.MediaId,
+			EpisodeNumber: reg.EpisodeNumber,
+			Filepath:      reg.Path,
+			Kind:          continuity.ExternalPlayerKind,
+		})
+	}
+
+	if !ret.ShouldUpdateProgress {
+		return h.RespondWithData(c, ret)
+	}
+
+	if shouldUpdate, _ := h.App.Database.AutoUpdateProgressIsEnabled(); !shouldUpdate {
+		return h.RespondWithData(c, ret)
+	}
+
+	// Do not lower the progress of an episode that was already watched
+	if animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection); err == nil {
+		if entry, found := animeCollection.GetListEntryFromAnimeId(reg.MediaId); found && entry.Progress != nil && *entry.Progress >= reg.EpisodeNumber {
+			h.App.PathResolverRegistry.SetProgressUpdated(reg.Token)
+			return h.RespondWithData(c, ret)
+		}
+	}
+
+	var totalEpisodes *int
+	if reg.TotalEpisodes > 0 {
+		totalEpisodes = &reg.TotalEpisodes
+	}
+	err = h.App.UpdatePlatformEntryProgress(c.Request().Context(), GetSessionID(c), syncstatus.MediaKindAnime, reg.MediaId, reg.EpisodeNumber, totalEpisodes)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.PathResolverRegistry.SetProgressUpdated(reg.Token)
+	reg.ProgressUpdated = true
+
+	_, _ = h.App.RefreshAnimeCollection()
+
+	return h.RespondWithData(c, ret)
+}
+
+func findCompleteAnime(collection *anilist.AnimeCollectionWithRelations, mediaId int) (*anilist.CompleteAnime, bool) {
+	if collection == nil || collection.MediaListCollection == nil {
+		return nil, false
+	}
+	return collection.FindAnime(mediaId)
+}
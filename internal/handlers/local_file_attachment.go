@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/sidecar"
+	"seanime/internal/util"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetLocalFileAttachments
+//
+//	@summary returns the subtitle and font files uploaded for a local file.
+//	@route /api/v1/library/local-file/attachments [GET]
+//	@param path - string - true - "The path of the local file"
+//	@returns []sidecar.Attachment
+func (h *Handler) HandleGetLocalFileAttachments(c echo.Context) error {
+	path := c.QueryParam("path")
+	if path == "" {
+		return h.RespondWithError(c, errors.New("path is required"))
+	}
+
+	return h.RespondWithData(c, h.App.SidecarStore.GetByLocalFile(path))
+}
+
+// HandleUploadLocalFileAttachment
+//
+//	@summary uploads a subtitle or font file for a local file.
+//	@desc The request is a multipart form with the fields "path" (the path of the local file), "file", "language" and "label".
+//	@desc Subtitles (srt, ass, ssa, vtt) are stored next to the video file, e.g. "Episode 1.eng.srt".
+//	@desc Fonts (ttf, otf, ttc, woff, woff2) or a zip archive of fonts are stored in the "fonts" directory next to the video file.
+//	@desc Existing files are never overwritten, a number is added to the name instead.
+//	@desc The attachments are added to the active stream of the file and to future streams.
+//	@route /api/v1/library/local-file/attachment [POST]
+//	@returns []sidecar.Attachment
+func (h *Handler) HandleUploadLocalFileAttachment(c echo.Context) error {
+	path := c.FormValue("path")
+	if path == "" {
+		return h.RespondWithError(c, errors.New("path is required"))
+	}
+
+	// Only allow attachments for files in the library
+	lfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	var localFilePath string
+	for _, lf := range lfs {
+		if util.NormalizePath(lf.Path) == util.NormalizePath(path) {
+			localFilePath = lf.Path
+			break
+		}
+	}
+	if localFilePath == "" {
+		return h.RespondWithError(c, errors.New("local file not found"))
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return h.RespondWithError(c, errors.New("file is required"))
+	}
+	if fileHeader.Size > sidecar.MaxFontArchiveSize {
+		return h.RespondWithError(c, sidecar.ErrFileTooLarge)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, sidecar.MaxFontArchiveSize+1))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret, err := h.App.SidecarStore.Add(&sidecar.AddOptions{
+		LocalFilePath: localFilePath,
+		Filename:      fileHeader.Filename,
+		Content:       content,
+		Language:      c.FormValue("language"),
+		Label:         c.FormValue("label"),
+		UploadedBy:    h.App.GetSyncStatusUsername(GetSessionID(c)),
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.refreshLocalFileAttachments(localFilePath)
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleDeleteLocalFileAttachment
+//
+//	@summary deletes a subtitle or font file uploaded for a local file.
+//	@desc The file is removed from the disk and from the active and future streams.
+//	@route /api/v1/library/local-file/attachment/{id} [DELETE]
+//	@param id - int - true - "The ID of the attachment"
+//	@returns bool
+func (h *Handler) HandleDeleteLocalFileAttachment(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	a, err := h.App.SidecarStore.Delete(uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.refreshLocalFileAttachments(a.LocalFilePath)
+
+	return h.RespondWithData(c, true)
+}
+
+// refreshLocalFileAttachments updates the cached stream metadata of the local file.
+func (h *Handler) refreshLocalFileAttachments(localFilePath string) {
+	h.App.MediastreamRepository.RefreshExternalAttachments(localFilePath)
+	h.App.DirectStreamManager.RefreshExternalAttachments(localFilePath)
+}
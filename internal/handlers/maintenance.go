@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"seanime/internal/maintenance"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorCodeMaintenance is returned when the request is rejected because maintenance mode is active.
+const ErrorCodeMaintenance = "maintenance"
+
+// HandleGetMaintenanceStatus
+//
+//	@summary returns the state of maintenance mode and of the background tasks it pauses.
+//	@desc The tasks that run at a fixed time of the day are scheduled in the returned timezone, which is set in the library settings.
+//	@route /api/v1/maintenance [GET]
+//	@returns maintenance.Status
+func (h *Handler) HandleGetMaintenanceStatus(c echo.Context) error {
+	return h.RespondWithData(c, h.App.Maintenance.GetStatus())
+}
+
+// HandleSetMaintenanceMode
+//
+//	@summary enters or exits maintenance mode.
+//	@desc Maintenance mode pauses the background activity (auto downloader, auto scanner, metadata refresh, cleanup jobs, torrent polling)
+//	@desc and rejects new download and library management requests with the "maintenance" error code.
+//	@desc Browsing and playback keep working.
+//	@desc "duration" is in minutes. Maintenance mode expires after 6 hours if it is not set.
+//	@desc Only accessible from the local machine, or by authenticated clients when a server password is set.
+//	@route /api/v1/maintenance [POST]
+//	@returns maintenance.Status
+func (h *Handler) HandleSetMaintenanceMode(c echo.Context) error {
+
+	type body struct {
+		Enabled bool `json:"enabled"`
+		// Duration is in minutes
+		Duration int    `json:"duration"`
+		Reason   string `json:"reason"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if !b.Enabled {
+		return h.RespondWithData(c, h.App.Maintenance.Exit())
+	}
+
+	ret, err := h.App.Maintenance.Enter(time.Duration(b.Duration)*time.Minute, strings.TrimSpace(b.Reason))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// MaintenanceMiddleware rejects the requests that start downloads or modify the library while maintenance mode is active.
+// The response has the ErrorCodeMaintenance code.
+func (h *Handler) MaintenanceMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	type pathConfig struct {
+		PathStartsWith string
+		Methods        []string
+	}
+
+	var UpdateMethods = []string{"POST", "PUT", "DELETE", "PATCH"}
+
+	var pathConfigs = []pathConfig{
+		// downloads
+		{"/api/v1/auto-downloader/run", UpdateMethods},
+		{"/api/v1/torrent-client/download", UpdateMethods},
+		{"/api/v1/torrent-client/rule-magnet", UpdateMethods},
+		{"/api/v1/download-torrent-file", UpdateMethods},
+		{"/api/v1/debrid/torrents/download", UpdateMethods},
+		{"/api/v1/manga/download-chapters", UpdateMethods},
+		{"/api/v1/manga/download-queue/start", UpdateMethods},
+		// library management
+		{"/api/v1/library/scan", UpdateMethods},
+		{"/api/v1/library/empty-directories", UpdateMethods},
+		{"/api/v1/library/local-files", UpdateMethods},
+		{"/api/v1/library/local-file/attachment", UpdateMethods},
+		{"/api/v1/library/cleanup", UpdateMethods},
+	}
+
+	return func(c echo.Context) error {
+		if !h.App.Maintenance.IsActive() {
+			return next(c)
+		}
+
+		path := c.Request().URL.Path
+		method := strings.ToUpper(c.Request().Method)
+
+		for _, config := range pathConfigs {
+			if strings.HasPrefix(path, config.PathStartsWith) && slices.Contains(config.Methods, method) {
+				return c.JSON(http.StatusServiceUnavailable, SeaResponse[any]{
+					Error: maintenance.ErrMaintenance.Error(),
+					Code:  ErrorCodeMaintenance,
+				})
+			}
+		}
+
+		return next(c)
+	}
+}
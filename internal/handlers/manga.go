@@ -9,6 +9,7 @@ import (
 	"seanime/internal/manga"
 	manga_providers "seanime/internal/manga/providers"
 	"seanime/internal/platforms/shared_platform"
+	"seanime/internal/syncstatus"
 	"seanime/internal/util/result"
 	"strconv"
 	"strings"
@@ -124,6 +125,7 @@ func (h *Handler) HandleGetMangaEntry(c echo.Context) error {
 
 	if entry != nil {
 		baseMangaCache.SetT(entry.MediaId, entry.Media, 1*time.Hour)
+		entry.SyncState = h.App.SyncStatusTracker.GetMediaState(h.App.GetSyncStatusUsername(GetSessionID(c)), syncstatus.MediaKindManga, entry.MediaId)
 	}
 
 	return h.RespondWithData(c, entry)
@@ -269,6 +271,8 @@ func (h *Handler) HandleGetMangaEntryChapters(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
+	h.App.MangaRepository.HydrateOfflineAvailability(container)
+
 	return h.RespondWithData(c, container)
 }
 
@@ -280,6 +284,7 @@ func (h *Handler) HandleGetMangaEntryChapters(c echo.Context) error {
 //	@desc If the app is online and the chapter is not downloaded, it will return the pages from the provider.
 //	@desc If the chapter is downloaded, it will return the appropriate struct.
 //	@desc If 'double page' is requested, it will fetch image sizes and include the dimensions in the response.
+//	@desc If the provider cannot be reached, the pages saved in the page cache are returned and 'stale' is true.
 //	@route /api/v1/manga/pages [POST]
 //	@returns manga.PageContainer
 func (h *Handler) HandleGetMangaEntryPages(c echo.Context) error {
@@ -304,6 +309,65 @@ func (h *Handler) HandleGetMangaEntryPages(c echo.Context) error {
 	return h.RespondWithData(c, container)
 }
 
+// HandleGetMangaPageImage
+//
+//	@summary returns the image of a manga chapter page.
+//	@desc The image is served from the page cache if it is there, otherwise it is fetched from the provider and cached.
+//	@desc Chapters whose pages are all cached are listed in 'offlineAvailable' of the chapter container.
+//	@route /api/v1/manga/page-image [GET]
+//	@param provider - string - true - "The manga provider"
+//	@param mediaId - int - true - "AniList manga media ID"
+//	@param chapterId - string - true - "The chapter ID"
+//	@param index - int - true - "The page index"
+func (h *Handler) HandleGetMangaPageImage(c echo.Context) error {
+	provider := c.QueryParam("provider")
+	chapterId := c.QueryParam("chapterId")
+	if provider == "" || chapterId == "" {
+		return h.RespondWithError(c, errors.New("provider and chapterId are required"))
+	}
+	mediaId, err := strconv.Atoi(c.QueryParam("mediaId"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	index, err := strconv.Atoi(c.QueryParam("index"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	data, contentType, err := h.App.MangaRepository.GetPageImage(provider, mediaId, chapterId, index, h.App.IsOfflineRef())
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// HandleSetMangaPageCachePin
+//
+//	@summary keeps the cached pages of a manga entry from being evicted.
+//	@desc Pinned entries are kept when the page cache is full and when it is purged without 'includePinned'.
+//	@route /api/v1/manga/page-cache/pin [POST]
+//	@returns bool
+func (h *Handler) HandleSetMangaPageCachePin(c echo.Context) error {
+
+	type body struct {
+		MediaId int  `json:"mediaId"`
+		Pinned  bool `json:"pinned"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.MangaRepository.SetPageCachePinned(b.MediaId, b.Pinned); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
 // HandleGetMangaEntryDownloadedChapters
 //
 //	@summary returns all download chapters for a manga entry,
@@ -423,16 +487,21 @@ func (h *Handler) HandleAnilistListManga(c echo.Context) error {
 // HandleUpdateMangaProgress
 //
 //	@summary updates the progress of a manga entry.
+//	@desc The volume progress is computed from the volumes of the chapters given by the provider, or estimated from the number of chapters and volumes of the manga.
+//	@desc The volume progress is left unchanged for ongoing manga with an unknown number of volumes.
+//	@desc If the chapter numbering of the provider restarts every volume, the chapter ID is used to compute both progresses.
 //	@desc Note: MyAnimeList is not supported
 //	@route /api/v1/manga/update-progress [POST]
 //	@returns bool
 func (h *Handler) HandleUpdateMangaProgress(c echo.Context) error {
 
 	type body struct {
-		MediaId       int `json:"mediaId"`
-		MalId         int `json:"malId,omitempty"`
-		ChapterNumber int `json:"chapterNumber"`
-		TotalChapters int `json:"totalChapters"`
+		MediaId       int    `json:"mediaId"`
+		MalId         int    `json:"malId,omitempty"`
+		ChapterNumber int    `json:"chapterNumber"`
+		TotalChapters int    `json:"totalChapters"`
+		Provider      string `json:"provider,omitempty"`
+		ChapterId     string `json:"chapterId,omitempty"`
 	}
 
 	b := new(body)
@@ -440,11 +509,15 @@ func (h *Handler) HandleUpdateMangaProgress(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
+	progress := h.getMangaVolumeProgress(c, b.MediaId, b.ChapterNumber, b.Provider, b.ChapterId)
+
 	// Update the progress on AniList
-	err := h.App.AnilistPlatformRef.Get().UpdateEntryProgress(
+	err := h.App.UpdatePlatformMangaEntryProgress(
 		c.Request().Context(),
+		GetSessionID(c),
 		b.MediaId,
-		b.ChapterNumber,
+		progress.Progress,
+		progress.ProgressVolumes,
 		&b.TotalChapters,
 	)
 	if err != nil {
@@ -456,6 +529,33 @@ func (h *Handler) HandleUpdateMangaProgress(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// getMangaVolumeProgress computes the volume progress from the cached chapters of the provider and the media.
+func (h *Handler) getMangaVolumeProgress(c echo.Context, mediaId int, chapterNumber int, provider string, chapterId string) *manga.VolumeProgress {
+	opts := &manga.GetVolumeProgressOptions{
+		Progress:  chapterNumber,
+		ChapterId: chapterId,
+	}
+
+	if provider != "" {
+		if container, found := h.App.MangaRepository.GetCachedChapterContainer(provider, mediaId); found {
+			opts.Chapters = container.Chapters
+		}
+	}
+
+	if collection, err := h.App.GetMangaCollection(false); err == nil {
+		if entry, found := collection.GetListEntryFromMangaId(mediaId); found {
+			opts.Media = entry.GetMedia()
+		}
+	}
+	if opts.Media == nil {
+		if media, err := h.App.AnilistPlatformRef.Get().GetManga(c.Request().Context(), mediaId); err == nil {
+			opts.Media = media
+		}
+	}
+
+	return manga.GetVolumeProgress(opts)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // HandleMangaManualSearch
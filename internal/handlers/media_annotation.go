@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/labstack/echo/v4"
+)
+
+// localAnnotationOwner owns the annotations of the users that are not authenticated,
+// so that they are not lost when a simulated session ends.
+const localAnnotationOwner = "local"
+
+// getAnnotationOwner returns the owner of the annotations of the current user.
+func (h *Handler) getAnnotationOwner(c echo.Context) string {
+	owner := h.App.GetUIStateOwner(GetSessionID(c))
+	if !owner.Persistent {
+		return localAnnotationOwner
+	}
+	return owner.ID
+}
+
+// getMediaAnnotations returns the annotations of the current user by media ID.
+func (h *Handler) getMediaAnnotations(c echo.Context) (map[int]*anime.EntryAnnotation, error) {
+	annotations, err := h.App.Database.GetMediaAnnotations(h.getAnnotationOwner(c))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int]*anime.EntryAnnotation, len(annotations))
+	for _, annotation := range annotations {
+		ret[annotation.MediaId] = toEntryAnnotation(annotation)
+	}
+	return ret, nil
+}
+
+func toEntryAnnotation(annotation *models.MediaAnnotation) *anime.EntryAnnotation {
+	if annotation == nil {
+		return nil
+	}
+	tags := []string(annotation.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return &anime.EntryAnnotation{
+		Note:      annotation.Note,
+		Tags:      tags,
+		UpdatedAt: annotation.UpdatedAt,
+	}
+}
+
+// HandleGetMediaAnnotations
+//
+//	@summary returns the local notes and tags of the current user by media ID.
+//	@route /api/v1/media-annotations [GET]
+//	@returns map[int]anime.EntryAnnotation
+func (h *Handler) HandleGetMediaAnnotations(c echo.Context) error {
+	ret, err := h.getMediaAnnotations(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, ret)
+}
+
+// HandleGetMediaAnnotation
+//
+//	@summary returns the local note and tags of a media.
+//	@desc It returns null if the media has no annotation.
+//	@route /api/v1/media-annotation/{id} [GET]
+//	@param id - int - true - "The media ID"
+//	@returns anime.EntryAnnotation
+func (h *Handler) HandleGetMediaAnnotation(c echo.Context) error {
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	annotation, err := h.App.Database.GetMediaAnnotation(h.getAnnotationOwner(c), mId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, toEntryAnnotation(annotation))
+}
+
+// HandleSaveMediaAnnotation
+//
+//	@summary creates or replaces the local note and tags of a media.
+//	@desc Tags are trimmed and deduplicated case-insensitively, they cannot contain commas.
+//	@desc The annotation is deleted if both the note and the tags are empty.
+//	@desc The annotation is not sent to AniList, use HandlePushMediaAnnotationNote to push the note.
+//	@route /api/v1/media-annotation/{id} [PUT]
+//	@param id - int - true - "The media ID"
+//	@returns anime.EntryAnnotation
+func (h *Handler) HandleSaveMediaAnnotation(c echo.Context) error {
+
+	type body struct {
+		Note string   `json:"note"`
+		Tags []string `json:"tags"`
+	}
+
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	b := new(body)
+	if err := c.Bind(b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret, err := h.saveMediaAnnotation(h.getAnnotationOwner(c), mId, b.Note, b.Tags)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.BumpCollectionVersion()
+
+	return h.RespondWithData(c, ret)
+}
+
+// saveMediaAnnotation upserts the annotation, or deletes it if it is empty.
+func (h *Handler) saveMediaAnnotation(owner string, mediaId int, note string, tags []string) (*anime.EntryAnnotation, error) {
+	if mediaId <= 0 {
+		return nil, errors.New("invalid media id")
+	}
+
+	tags, err := anime.NormalizeAnnotationTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if note == "" && len(tags) == 0 {
+		return nil, h.App.Database.DeleteMediaAnnotation(owner, mediaId)
+	}
+
+	annotation := &models.MediaAnnotation{
+		Owner:   owner,
+		MediaId: mediaId,
+		Note:    note,
+		Tags:    tags,
+	}
+	if err := h.App.Database.UpsertMediaAnnotation(annotation); err != nil {
+		return nil, err
+	}
+	return toEntryAnnotation(annotation), nil
+}
+
+// HandleDeleteMediaAnnotation
+//
+//	@summary deletes the local note and tags of a media.
+//	@route /api/v1/media-annotation/{id} [DELETE]
+//	@param id - int - true - "The media ID"
+//	@returns bool
+func (h *Handler) HandleDeleteMediaAnnotation(c echo.Context) error {
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := h.App.Database.DeleteMediaAnnotation(h.getAnnotationOwner(c), mId); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.BumpCollectionVersion()
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleGetMediaAnnotationTags
+//
+//	@summary returns the tags of the current user with the number of media that have them.
+//	@desc This is used to autocomplete the tags. The most used tags are returned first.
+//	@route /api/v1/media-annotations/tags [GET]
+//	@returns []anime.AnnotationTagCount
+func (h *Handler) HandleGetMediaAnnotationTags(c echo.Context) error {
+	annotations, err := h.getMediaAnnotations(c)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	return h.RespondWithData(c, anime.CountAnnotationTags(annotations))
+}
+
+// HandlePushMediaAnnotationNote
+//
+//	@summary replaces the notes of the AniList entry of a media with the local note.
+//	@desc The note is only sent when this is called, local changes are never synced automatically.
+//	@desc The AniList entry is created if the media is not in the user's list.
+//	@route /api/v1/media-annotation/{id}/push-note [POST]
+//	@param id - int - true - "The media ID"
+//	@returns bool
+func (h *Handler) HandlePushMediaAnnotationNote(c echo.Context) error {
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	annotation, err := h.App.Database.GetMediaAnnotation(h.getAnnotationOwner(c), mId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	if annotation == nil {
+		return h.RespondWithError(c, errors.New("media has no annotation"))
+	}
+
+	err = anilist.UpdateMediaListEntryNotes(h.App.AnilistClientRef.Get(), mId, annotation.Note, h.App.Logger, h.GetSessionAnilistToken(c))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// MediaAnnotationExport is an annotation in the file exported by HandleExportMediaAnnotations.
+type MediaAnnotationExport struct {
+	MediaId   int       `json:"mediaId"`
+	Note      string    `json:"note"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// HandleExportMediaAnnotations
+//
+//	@summary exports the local notes and tags of the current user as a JSON file.
+//	@desc The file can be imported with HandleImportMediaAnnotations.
+//	@route /api/v1/media-annotations/export [GET]
+//	@returns []handlers.MediaAnnotationExport
+func (h *Handler) HandleExportMediaAnnotations(c echo.Context) error {
+	annotations, err := h.App.Database.GetMediaAnnotations(h.getAnnotationOwner(c))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret := make([]*MediaAnnotationExport, 0, len(annotations))
+	for _, annotation := range annotations {
+		a := toEntryAnnotation(annotation)
+		ret = append(ret, &MediaAnnotationExport{
+			MediaId:   annotation.MediaId,
+			Note:      a.Note,
+			Tags:      a.Tags,
+			UpdatedAt: a.UpdatedAt,
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(ret, "", "  ")
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	filename := fmt.Sprintf("annotations-%s.json", time.Now().Format("2006-01-02_15-04-05"))
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	return c.Blob(http.StatusOK, "application/json", jsonData)
+}
+
+// HandleImportMediaAnnotations
+//
+//	@summary imports the local notes and tags exported by HandleExportMediaAnnotations.
+//	@desc The body is the JSON array of the exported file. Existing annotations of the same media are replaced.
+//	@desc It returns the number of imported annotations.
+//	@route /api/v1/media-annotations/import [POST]
+//	@returns int
+func (h *Handler) HandleImportMediaAnnotations(c echo.Context) error {
+	var b []*MediaAnnotationExport
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	// Nothing is imported if the file has an invalid annotation
+	for _, a := range b {
+		if a == nil {
+			continue
+		}
+		if _, err := anime.NormalizeAnnotationTags(a.Tags); err != nil || a.MediaId <= 0 {
+			return h.RespondWithError(c, fmt.Errorf("invalid annotation for media %d", a.MediaId))
+		}
+	}
+
+	owner := h.getAnnotationOwner(c)
+	count := 0
+	for _, a := range b {
+		if a == nil {
+			continue
+		}
+		ret, err := h.saveMediaAnnotation(owner, a.MediaId, a.Note, a.Tags)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		if ret != nil {
+			count++
+		}
+	}
+
+	h.App.BumpCollectionVersion()
+
+	return h.RespondWithData(c, count)
+}
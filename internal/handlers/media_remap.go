@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetMediaRemaps
+//
+//	@summary returns the media that AniList merged into another entry or deleted.
+//	@desc The media IDs of the local records are checked when the AniList collection is refreshed.
+//	@desc Merged media have "toMediaId" set, their local files, pre-matches, AutoDownloader rules and items, history and annotations were moved to the new media.
+//	@desc Deleted media have "toMediaId" set to 0, their files have to be matched again manually.
+//	@route /api/v1/library/media-remaps [GET]
+//	@returns []anime.MediaRemap
+func (h *Handler) HandleGetMediaRemaps(c echo.Context) error {
+	ret, err := h.App.MediaRemapManager.GetRemaps()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
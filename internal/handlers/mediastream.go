@@ -9,6 +9,10 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// mediastreamSeedingSessionID identifies the mediastream session in the seeding pause manager.
+// Like the transcoder, only one client is supported at a time.
+const mediastreamSeedingSessionID = "mediastream"
+
 // HandleGetMediastreamSettings
 //
 //	@summary get mediastream settings.
@@ -88,6 +92,9 @@ func (h *Handler) HandleRequestMediastreamMediaContainer(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
+	// Pause seeding torrents if the stream is sent outside the local network
+	h.App.SeedingPause.StartSession(mediastreamSeedingSessionID, c.RealIP())
+
 	return h.RespondWithData(c, mediaContainer)
 }
 
@@ -171,6 +178,7 @@ func (h *Handler) HandleMediastreamTranscode(c echo.Context) error {
 func (h *Handler) HandleMediastreamShutdownTranscodeStream(c echo.Context) error {
 	client := "1"
 	h.App.MediastreamRepository.ShutdownTranscodeStream(client)
+	h.App.SeedingPause.EndSession(mediastreamSeedingSessionID)
 	return h.RespondWithData(c, true)
 }
 
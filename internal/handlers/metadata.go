@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/labstack/echo/v4"
+	"seanime/internal/platforms/platform"
 )
 
 // HandlePopulateFillerData
@@ -20,7 +21,7 @@ func (h *Handler) HandlePopulateFillerData(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
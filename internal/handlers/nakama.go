@@ -11,6 +11,7 @@ import (
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/library/anime"
 	"seanime/internal/nakama"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/util"
 	"strconv"
 	"strings"
@@ -89,7 +90,7 @@ func (h *Handler) HandleGetNakamaAnimeLibrary(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("host is not sharing its anime library"))
 	}
 
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -231,7 +232,7 @@ func (h *Handler) getFilteredLocalFiles(mediaId int) ([]*anime.LocalFile, error)
 
 // buildNakamaLocalFiles constructs a NakamaLocalFiles response with custom source mappings.
 func (h *Handler) buildNakamaLocalFiles(lfs []*anime.LocalFile) (*nakama.NakamaLocalFiles, error) {
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetNotifications
+//
+//	@summary returns the notifications of the notification center.
+//	@desc The most recent notifications are returned first.
+//	@desc If 'unread' is true, only the notifications that have not been read are returned.
+//	@desc New notifications are also sent through the 'notification:new' websocket event.
+//	@route /api/v1/notifications [GET]
+//	@param unread - bool - false - "Only return the unread notifications"
+//	@returns []models.Notification
+func (h *Handler) HandleGetNotifications(c echo.Context) error {
+	unreadOnly, _ := strconv.ParseBool(c.QueryParam("unread"))
+
+	notifications, err := h.App.Notifications.GetNotifications(unreadOnly)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, notifications)
+}
+
+// HandleMarkNotificationAsRead
+//
+//	@summary marks a notification as read.
+//	@desc It returns the updated notification.
+//	@route /api/v1/notifications/{id}/read [POST]
+//	@param id - int - true - "The id of the notification"
+//	@returns models.Notification
+func (h *Handler) HandleMarkNotificationAsRead(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	notification, err := h.App.Notifications.MarkAsRead(uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, notification)
+}
+
+// HandleDeleteReadNotifications
+//
+//	@summary deletes the notifications that have been read.
+//	@desc It returns the number of deleted notifications.
+//	@route /api/v1/notifications/read-all [DELETE]
+//	@returns int
+func (h *Handler) HandleDeleteReadNotifications(c echo.Context) error {
+	count, err := h.App.Notifications.DeleteRead()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, count)
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// The spec is generated from the handler annotations.
+// Run "go run ../../codegen/cmd/openapi -check" to verify that it is up to date.
+//
+//go:generate go run ../../codegen/cmd/openapi
+//go:embed openapi.json
+var openAPISpec []byte
+
+// HandleGetOpenAPISpec returns the OpenAPI document describing the API.
+//
+// route /api/v1/openapi.json
+func (h *Handler) HandleGetOpenAPISpec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, openAPISpec)
+}
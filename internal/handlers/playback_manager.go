@@ -10,12 +10,14 @@ import (
 //
 //	@summary plays the video with the given path using the default media player.
 //	@desc This tells the Playback Manager to play the video using the default media player and start tracking progress.
+//	@desc When 'incognito' is true, the playback does not update the AniList progress, the watch history or the Discord presence.
 //	@desc This returns 'true' if the video was successfully played.
 //	@route /api/v1/playback-manager/play [POST]
 //	@returns bool
 func (h *Handler) HandlePlaybackPlayVideo(c echo.Context) error {
 	type body struct {
-		Path string `json:"path"`
+		Path      string `json:"path"`
+		Incognito bool   `json:"incognito"`
 	}
 	b := new(body)
 	if err := c.Bind(b); err != nil {
@@ -30,6 +32,7 @@ func (h *Handler) HandlePlaybackPlayVideo(c echo.Context) error {
 		Payload:   b.Path,
 		UserAgent: c.Request().Header.Get("User-Agent"),
 		ClientId:  "",
+		Incognito: b.Incognito,
 	})
 	if err != nil {
 		return h.RespondWithError(c, err)
@@ -81,6 +84,28 @@ func (h *Handler) HandlePlaybackSyncCurrentProgress(c echo.Context) error {
 	return h.RespondWithData(c, mId)
 }
 
+// HandlePlaybackSetIncognito
+//
+//	@summary toggles the incognito mode of the current playback.
+//	@desc An incognito playback does not update the AniList progress, the watch history or the Discord presence.
+//	@desc Its position is kept for a day so that it can be resumed. The mode is reset when a new playback is started.
+//	@desc The client can also send the 'playback-manager-set-incognito' websocket event.
+//	@route /api/v1/playback-manager/incognito [POST]
+//	@returns bool
+func (h *Handler) HandlePlaybackSetIncognito(c echo.Context) error {
+	type body struct {
+		Incognito bool `json:"incognito"`
+	}
+	b := new(body)
+	if err := c.Bind(b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.PlaybackManager.SetIncognito(b.Incognito)
+
+	return h.RespondWithData(c, h.App.PlaybackManager.IsIncognito())
+}
+
 // HandlePlaybackPlayNextEpisode
 //
 //	@summary plays the next episode of the currently playing media.
@@ -4,6 +4,7 @@ import (
 	"seanime/internal/customsource"
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
@@ -134,7 +135,7 @@ func (h *Handler) HandleGetPlaylistEpisodes(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
-	animeCollection, err := h.App.GetAnimeCollection(false)
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
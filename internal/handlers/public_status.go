@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"seanime/internal/constants"
+	"seanime/internal/database/models"
+	"seanime/internal/publicstatus"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorCodeRateLimited is returned when a client has made too many requests.
+const ErrorCodeRateLimited = "rate-limited"
+
+// HandleGetPublicStatus
+//
+//	@summary returns the public status page data.
+//	@desc This route does not require authentication and does not create a session.
+//	@desc It is disabled by default and only returns the fields approved by the owner in the server settings.
+//	@desc Requests are rate-limited per IP.
+//	@route /api/v1/public/status [GET]
+//	@returns publicstatus.Status
+func (h *Handler) HandleGetPublicStatus(c echo.Context) error {
+	if !h.App.PublicStatus.IsEnabled() {
+		return c.JSON(http.StatusNotFound, NewErrorResponse(errors.New("the public status is disabled")))
+	}
+
+	if !h.App.PublicStatus.Allow(connectionIP(c)) {
+		return c.JSON(http.StatusTooManyRequests, SeaResponse[any]{
+			Error: "too many requests",
+			Code:  ErrorCodeRateLimited,
+		})
+	}
+
+	source := &publicstatus.Source{
+		Version:       constants.Version,
+		ActiveStreams: len(h.App.PlaybackPriority.GetStatus().Sessions),
+	}
+	maintenanceStatus := h.App.Maintenance.GetStatus()
+	source.MaintenanceActive = maintenanceStatus.Active
+	source.MaintenanceReason = maintenanceStatus.Reason
+	source.MaintenanceUntil = maintenanceStatus.ExpiresAt
+
+	return h.RespondWithData(c, h.App.PublicStatus.GetStatus(source))
+}
+
+// HandleSetPublicAnnouncement
+//
+//	@summary sets the announcement of the public status page.
+//	@desc An empty announcement removes it. Logged-in clients are notified when it changes.
+//	@desc Only accessible from the local machine, or by authenticated clients when a server password is set.
+//	@route /api/v1/public-status/announcement [POST]
+//	@returns models.ServerSettings
+func (h *Handler) HandleSetPublicAnnouncement(c echo.Context) error {
+
+	type body struct {
+		Announcement string `json:"announcement"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	announcement := strings.TrimSpace(b.Announcement)
+	if utf8.RuneCountInString(announcement) > publicstatus.MaxAnnouncementLength {
+		return h.RespondWithError(c, fmt.Errorf("the announcement cannot exceed %d characters", publicstatus.MaxAnnouncementLength))
+	}
+
+	settings, err := h.App.Database.GetSettings()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	if settings.Server == nil {
+		settings.Server = &models.ServerSettings{}
+	}
+	settings.Server.PublicStatusAnnouncement = announcement
+	settings.UpdatedAt = time.Now()
+
+	settings, err = h.App.Database.UpsertSettings(settings)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.InitOrRefreshModules()
+
+	return h.RespondWithData(c, settings.GetServer())
+}
@@ -4,9 +4,15 @@ package handlers
 // It is used to return data or errors.
 type SeaResponse[R any] struct {
 	Error string `json:"error,omitempty"`
-	Data  R      `json:"data,omitempty"`
+	// Code identifies errors the client can act on, e.g. ErrorCodeInitializing
+	Code string `json:"code,omitempty"`
+	Data R      `json:"data,omitempty"`
 }
 
+// ErrorCodeInitializing is returned when the request depends on a module that is still initializing.
+// The client can retry the request later.
+const ErrorCodeInitializing = "initializing"
+
 func NewDataResponse[R any](data R) SeaResponse[R] {
 	res := SeaResponse[R]{
 		Data: data,
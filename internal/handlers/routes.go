@@ -24,7 +24,7 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Cookie", "Authorization",
-			"X-Seanime-Token", "X-Seanime-Nakama-Token", "X-Seanime-Nakama-Username", "X-Seanime-Nakama-Server-Version", "X-Seanime-Nakama-Peer-Id"},
+			"X-Seanime-Token", "X-Seanime-Api-Key", "X-Seanime-Nakama-Token", "X-Seanime-Nakama-Username", "X-Seanime-Nakama-Server-Version", "X-Seanime-Nakama-Peer-Id"},
 		AllowCredentials: true,
 	}))
 
@@ -39,6 +39,7 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 		"/api/v1/mediastream/transcode/",
 		"/api/v1/torrent-client/list",
 		"/api/v1/proxy",
+		"/api/v1/theme-songs/proxy",
 		"/api/v1/directstream/stream",
 	}
 
@@ -119,8 +120,11 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	//
 	// Auth middleware
 	//
+	v1.Use(h.ApiKeyMiddleware)
 	v1.Use(h.OptionalAuthMiddleware)
 	v1.Use(h.FeaturesMiddleware)
+	v1.Use(h.ReadinessMiddleware)
+	v1.Use(h.MaintenanceMiddleware)
 
 	imageProxy := &util.ImageProxy{}
 	v1.GET("/image-proxy", imageProxy.ProxyImage)
@@ -129,6 +133,8 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.HEAD("/proxy", h.VideoProxy)
 
 	v1.GET("/status", h.HandleGetStatus)
+	v1.GET("/status/readiness", h.HandleGetReadiness)
+	v1.GET("/openapi.json", h.HandleGetOpenAPISpec)
 	v1.GET("/status/home-items", h.HandleGetHomeItems)
 	v1.POST("/status/home-items", h.HandleUpdateHomeItems)
 
@@ -143,11 +149,54 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.GET("/memory/cpu", h.HandleGetCPUProfile)
 	v1.POST("/memory/gc", h.HandleForceGC)
 
+	v1.GET("/diagnostics/session-store", h.HandleGetSessionStoreDiagnostics, h.LocalOrAdminMiddleware)
+
+	v1.GET("/maintenance", h.HandleGetMaintenanceStatus)
+	v1.POST("/maintenance", h.HandleSetMaintenanceMode, h.LocalOrAdminMiddleware)
+
+	v1.GET("/public/status", h.HandleGetPublicStatus)
+	v1.POST("/public-status/announcement", h.HandleSetPublicAnnouncement, h.LocalOrAdminMiddleware)
+
+	v1.GET("/webhooks", h.HandleGetWebhooks, h.LocalOrAdminMiddleware)
+	v1.POST("/webhooks", h.HandleCreateWebhook, h.LocalOrAdminMiddleware)
+	v1.PATCH("/webhooks/:id", h.HandleUpdateWebhook, h.LocalOrAdminMiddleware)
+	v1.DELETE("/webhooks/:id", h.HandleDeleteWebhook, h.LocalOrAdminMiddleware)
+	v1.GET("/webhooks/:id/deliveries", h.HandleGetWebhookDeliveries, h.LocalOrAdminMiddleware)
+
+	v1.GET("/api-keys", h.HandleGetApiKeys, h.LocalOrAdminMiddleware)
+	v1.GET("/api-keys/scopes", h.HandleGetApiKeyScopes, h.LocalOrAdminMiddleware)
+	v1.POST("/api-keys", h.HandleCreateApiKey, h.LocalOrAdminMiddleware)
+	v1.DELETE("/api-keys/:id", h.HandleDeleteApiKey, h.LocalOrAdminMiddleware)
+
+	v1.GET("/notifications", h.HandleGetNotifications)
+	v1.POST("/notifications/:id/read", h.HandleMarkNotificationAsRead)
+	v1.DELETE("/notifications/read-all", h.HandleDeleteReadNotifications)
+
+	v1.GET("/sync-status", h.HandleGetSyncStatus)
+	v1.POST("/sync-status/retry", h.HandleRetrySyncMutation)
+	v1.DELETE("/sync-status/pending", h.HandleDiscardSyncMutation)
+
+	v1.GET("/ui-state", h.HandleGetUIState)
+	v1.GET("/ui-state/usage", h.HandleGetUIStateUsage)
+	v1.POST("/ui-state", h.HandleSetUIState)
+	v1.DELETE("/ui-state", h.HandleDeleteUIState)
+
+	v1.GET("/media-annotations", h.HandleGetMediaAnnotations)
+	v1.GET("/media-annotations/tags", h.HandleGetMediaAnnotationTags)
+	v1.GET("/media-annotations/export", h.HandleExportMediaAnnotations)
+	v1.POST("/media-annotations/import", h.HandleImportMediaAnnotations)
+	v1.GET("/media-annotation/:id", h.HandleGetMediaAnnotation)
+	v1.PUT("/media-annotation/:id", h.HandleSaveMediaAnnotation)
+	v1.DELETE("/media-annotation/:id", h.HandleDeleteMediaAnnotation)
+	v1.POST("/media-annotation/:id/push-note", h.HandlePushMediaAnnotationNote)
+
 	v1.POST("/announcements", h.HandleGetAnnouncements)
 
 	// Auth
 	v1.POST("/auth/login", h.HandleLogin)
 	v1.POST("/auth/logout", h.HandleLogout)
+	v1.GET("/auth/sessions", h.HandleGetSessions, h.SessionAdminMiddleware)
+	v1.POST("/auth/sessions/revoke", h.HandleRevokeSession, h.SessionAdminMiddleware)
 
 	// Settings
 	v1.GET("/settings", h.HandleGetSettings)
@@ -163,9 +212,17 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.POST("/auto-downloader/rule", h.HandleCreateAutoDownloaderRule)
 	v1.PATCH("/auto-downloader/rule", h.HandleUpdateAutoDownloaderRule)
 	v1.DELETE("/auto-downloader/rule/:id", h.HandleDeleteAutoDownloaderRule)
+	v1.POST("/auto-downloader/rule/retarget", h.HandleRetargetAutoDownloaderRule)
+	v1.POST("/auto-downloader/rules/:id/test-torrent", h.HandleTestAutoDownloaderRuleAgainstTorrent)
+	v1.GET("/auto-downloader/export", h.HandleExportAutoDownloaderRules)
+	v1.POST("/auto-downloader/import", h.HandleImportAutoDownloaderRules)
 
 	v1.GET("/auto-downloader/items", h.HandleGetAutoDownloaderItems)
+	v1.GET("/auto-downloader/queue", h.HandleGetAutoDownloaderQueue)
+	v1.GET("/auto-downloader/release-predictions/:id", h.HandleGetAutoDownloaderReleasePredictions)
 	v1.DELETE("/auto-downloader/item", h.HandleDeleteAutoDownloaderItem)
+	v1.POST("/auto-downloader/item/approve", h.HandleApproveAutoDownloaderItem)
+	v1.POST("/auto-downloader/item/reject", h.HandleRejectAutoDownloaderItem)
 
 	// Other
 	v1.POST("/test-dump", h.HandleTestDump)
@@ -188,10 +245,16 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Anilist.GET("/collection/raw", h.HandleGetRawAnimeCollection)
 	v1Anilist.POST("/collection/raw", h.HandleGetRawAnimeCollection)
 
+	v1Anilist.GET("/watching", h.HandleGetCurrentlyWatching)
+
 	v1Anilist.GET("/media-details/:id", h.HandleGetAnilistAnimeDetails)
 
 	v1Anilist.GET("/studio-details/:id", h.HandleGetAnilistStudioDetails)
 
+	v1Anilist.GET("/media/:id/streaming-episodes", h.HandleGetStreamingEpisodes)
+
+	v1Anilist.GET("/media/:id/trailer", h.HandleGetAnimeTrailer)
+
 	v1Anilist.POST("/list-entry", h.HandleEditAnilistListEntry)
 
 	v1Anilist.DELETE("/list-entry", h.HandleDeleteAnilistListEntry)
@@ -204,6 +267,8 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 
 	v1Anilist.GET("/stats", h.HandleGetAniListStats)
 
+	v1Anilist.POST("/graphql", h.HandleAnilistGraphQLProxy)
+
 	v1Anilist.GET("/cache-layer/status", h.HandleGetAnilistCacheLayerStatus)
 
 	v1Anilist.POST("/cache-layer/status", h.HandleToggleAnilistCacheLayerStatus)
@@ -223,6 +288,16 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Library := v1.Group("/library")
 
 	v1Library.POST("/scan", h.HandleScanLocalFiles)
+	v1Library.GET("/scan/status", h.HandleGetScanStatus)
+	v1Library.POST("/scan/reconcile", h.HandleReconcileLibraryPaths)
+	v1Library.POST("/explain-match", h.HandleExplainLocalFileMatch)
+	v1Library.POST("/resolve-path", h.HandleResolveLibraryPath)
+	v1Library.POST("/resolve-path/heartbeat", h.HandleLibraryPathHeartbeat)
+	v1Library.GET("/episode-thumbnail/:key", h.HandleGetEpisodeThumbnail)
+	v1Library.GET("/cleanup/candidates", h.HandleGetLibraryCleanupCandidates)
+	v1Library.DELETE("/cleanup/candidates/:id", h.HandleDismissLibraryCleanupCandidate)
+	v1Library.POST("/cleanup/exemption", h.HandleSetLibraryCleanupExemption)
+	v1Library.GET("/media-remaps", h.HandleGetMediaRemaps)
 
 	v1Library.DELETE("/empty-directories", h.HandleRemoveEmptyDirectories)
 
@@ -234,6 +309,9 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Library.GET("/local-files/dump", h.HandleDumpLocalFilesToFile)
 	v1Library.POST("/local-files/import", h.HandleImportLocalFiles)
 	v1Library.PATCH("/local-file", h.HandleUpdateLocalFileData)
+	v1Library.GET("/local-file/attachments", h.HandleGetLocalFileAttachments)
+	v1Library.POST("/local-file/attachment", h.HandleUploadLocalFileAttachment)
+	v1Library.DELETE("/local-file/attachment/:id", h.HandleDeleteLocalFileAttachment)
 	v1Library.PATCH("/local-files/super-update", h.HandleSuperUpdateLocalFiles)
 
 	v1Library.GET("/collection", h.HandleGetLibraryCollection)
@@ -243,6 +321,8 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 
 	v1Library.GET("/missing-episodes", h.HandleGetMissingEpisodes)
 
+	v1.GET("/discover/airing-today", h.HandleGetAiringToday)
+
 	v1Library.GET("/anime-entry/:id", h.HandleGetAnimeEntry)
 	v1Library.POST("/anime-entry/suggestions", h.HandleFetchAnimeEntrySuggestions)
 	v1Library.POST("/anime-entry/manual-match", h.HandleAnimeEntryManualMatch)
@@ -252,9 +332,16 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Library.POST("/anime-entry/update-repeat", h.HandleUpdateAnimeEntryRepeat)
 	v1Library.GET("/anime-entry/silence/:id", h.HandleGetAnimeEntrySilenceStatus)
 	v1Library.POST("/anime-entry/silence", h.HandleToggleAnimeEntrySilenceStatus)
+	v1Library.GET("/anime-entry/audio-preference/:id", h.HandleGetAnimeEntryAudioPreference)
+	v1Library.POST("/anime-entry/audio-preference", h.HandleSetAnimeEntryAudioPreference)
 
 	v1Library.POST("/unknown-media", h.HandleAddUnknownMedia)
 
+	v1Library.GET("/placement-rules", h.HandleGetStoragePlacementRules)
+	v1Library.POST("/placement-rule", h.HandleCreateStoragePlacementRule)
+	v1Library.PATCH("/placement-rule", h.HandleUpdateStoragePlacementRule)
+	v1Library.DELETE("/placement-rule/:id", h.HandleDeleteStoragePlacementRule)
+
 	//
 	// Library Explorer
 	//
@@ -274,13 +361,44 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	//
 
 	v1.POST("/torrent/search", h.HandleSearchTorrent)
+	v1.POST("/torrent/results/dismiss", h.HandleDismissTorrentResult)
+	v1.GET("/torrent/results/dismissed", h.HandleGetDismissedTorrentResults)
+	v1.DELETE("/torrent/results/dismissed", h.HandleUndismissTorrentResult)
+	v1.GET("/torrent/saved-searches", h.HandleGetSavedTorrentSearches)
+	v1.POST("/torrent/saved-searches", h.HandleCreateSavedTorrentSearch)
+	v1.PATCH("/torrent/saved-searches", h.HandleUpdateSavedTorrentSearch)
+	v1.GET("/torrent/saved-searches/:id", h.HandleGetSavedTorrentSearch)
+	v1.DELETE("/torrent/saved-searches/:id", h.HandleDeleteSavedTorrentSearch)
+	v1.POST("/torrent/saved-searches/:id/run", h.HandleRunSavedTorrentSearch)
 	v1.POST("/torrent-client/download", h.HandleTorrentClientDownload)
+	v1.POST("/torrent-client/download-simple", h.HandleTorrentClientDownloadSimple)
+	v1.POST("/torrent-client/suggest-destination", h.HandleSuggestDownloadDestination)
 	v1.GET("/torrent-client/list", h.HandleGetActiveTorrentList)
+	v1.GET("/torrent-client/status", h.HandleGetTorrentClientStatus)
+	v1.GET("/torrent-client/playback-priority", h.HandleGetPlaybackPriorityStatus)
+	v1.GET("/torrent-client/seeding-pause", h.HandleGetSeedingPauseStatus)
+	v1.GET("/torrent-client/pieces", h.HandleGetTorrentPieceStates)
+	v1.GET("/torrent-client/speed-history", h.HandleGetSpeedHistory)
 	v1.GET("/torrent-client/media-downloading-status", h.HandleGetMediaDownloadingStatus)
 	v1.POST("/torrent-client/clear-pre-matches", h.HandleClearTorrentPreMatches)
+	v1.GET("/torrent-client/pre-matches", h.HandleGetTorrentPreMatches)
+	v1.POST("/torrent-client/pre-matches/delete", h.HandleDeleteTorrentPreMatch)
+	v1.GET("/torrent-client/pre-matches/export", h.HandleExportTorrentPreMatches)
+	v1.POST("/torrent-client/pre-matches/import", h.HandleImportTorrentPreMatches)
+	v1.PATCH("/torrent-client/pre-matches/:id", h.HandleUpdateTorrentPreMatch)
+	v1.POST("/torrent-client/pre-matches/:id/refresh", h.HandleRefreshTorrentPreMatch)
 	v1.POST("/torrent-client/action", h.HandleTorrentClientAction)
 	v1.POST("/torrent-client/get-files", h.HandleTorrentClientGetFiles)
 	v1.POST("/torrent-client/rule-magnet", h.HandleTorrentClientAddMagnetFromRule)
+	v1.GET("/torrent-client/import", h.HandleGetTorrentClientImport)
+	v1.POST("/torrent-client/import", h.HandleTorrentClientImport)
+	v1.GET("/torrent-client/batch-watches", h.HandleGetBatchTorrentWatches)
+	v1.PATCH("/torrent-client/batch-watches/:id", h.HandleUpdateBatchTorrentWatch)
+	v1.DELETE("/torrent-client/batch-watches/:id", h.HandleDeleteBatchTorrentWatch)
+	v1.GET("/torrent-client/migration", h.HandleGetTorrentClientMigration, h.LocalOrAdminMiddleware)
+	v1.POST("/torrent-client/migration", h.HandleStartTorrentClientMigration, h.LocalOrAdminMiddleware)
+	v1.POST("/torrent-client/migration/confirm", h.HandleConfirmTorrentClientMigration, h.LocalOrAdminMiddleware)
+	v1.DELETE("/torrent-client/migration", h.HandleDiscardTorrentClientMigration, h.LocalOrAdminMiddleware)
 
 	//
 	// Download
@@ -318,6 +436,7 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.POST("/playback-manager/autoplay-next-episode", h.HandlePlaybackAutoPlayNextEpisode)
 	v1.POST("/playback-manager/play", h.HandlePlaybackPlayVideo)
 	v1.POST("/playback-manager/play-random", h.HandlePlaybackPlayRandomVideo)
+	v1.POST("/playback-manager/incognito", h.HandlePlaybackSetIncognito)
 	//------------
 	v1.POST("/playback-manager/manual-tracking/start", h.HandlePlaybackStartManualTracking)
 	v1.POST("/playback-manager/manual-tracking/cancel", h.HandlePlaybackCancelManualTracking)
@@ -352,6 +471,11 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.POST("/metadata-provider/filler", h.HandlePopulateFillerData)
 	v1.DELETE("/metadata-provider/filler", h.HandleRemoveFillerData)
 
+	v1.POST("/theme-songs", h.HandlePopulateThemeSongs)
+	v1.GET("/theme-songs/proxy", h.HandleThemeSongsProxy)
+	v1.HEAD("/theme-songs/proxy", h.HandleThemeSongsProxy)
+	v1.GET("/theme-songs/:id", h.HandleGetThemeSongs)
+
 	//
 	// Manga
 	//
@@ -369,6 +493,8 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Manga.DELETE("/entry/cache", h.HandleEmptyMangaEntryCache)
 	v1Manga.POST("/chapters", h.HandleGetMangaEntryChapters)
 	v1Manga.POST("/pages", h.HandleGetMangaEntryPages)
+	v1Manga.GET("/page-image", h.HandleGetMangaPageImage)
+	v1Manga.POST("/page-cache/pin", h.HandleSetMangaPageCachePin)
 	v1Manga.POST("/update-progress", h.HandleUpdateMangaProgress)
 
 	v1Manga.GET("/downloaded-chapters/:id", h.HandleGetMangaEntryDownloadedChapters)
@@ -398,6 +524,8 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1FileCache.DELETE("/bucket", h.HandleRemoveFileCacheBucket)
 	v1FileCache.GET("/mediastream/videofiles/total-size", h.HandleGetFileCacheMediastreamVideoFilesTotalSize)
 	v1FileCache.DELETE("/mediastream/videofiles", h.HandleClearFileCacheMediastreamVideoFiles)
+	v1FileCache.GET("/manga-pages/stats", h.HandleGetMangaPageCacheStats)
+	v1FileCache.DELETE("/manga-pages", h.HandlePurgeMangaPageCache)
 
 	//
 	// Discord
@@ -442,6 +570,7 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1.POST("/torrentstream/start", h.HandleTorrentstreamStartStream)
 	v1.POST("/torrentstream/stop", h.HandleTorrentstreamStopStream)
 	v1.POST("/torrentstream/drop", h.HandleTorrentstreamDropTorrent)
+	v1.POST("/torrentstream/persist", h.HandleTorrentstreamPersistStream)
 	v1.POST("/torrentstream/torrent-file-previews", h.HandleGetTorrentstreamTorrentFilePreviews)
 	v1.POST("/torrentstream/batch-history", h.HandleGetTorrentstreamBatchHistory)
 	v1.GET("/torrentstream/stream/*", h.HandleTorrentstreamServeStream)
@@ -470,6 +599,9 @@ func InitRoutes(app *core.App, e *echo.Echo) {
 	v1Extensions.GET("/list/custom-source", h.HandleListCustomSourceExtensions)
 	v1Extensions.GET("/user-config/:id", h.HandleGetExtensionUserConfig)
 	v1Extensions.POST("/user-config", h.HandleSaveExtensionUserConfig)
+	v1Extensions.GET("/network/:id", h.HandleGetExtensionNetworkSettings)
+	v1Extensions.POST("/network", h.HandleSaveExtensionNetworkOverride)
+	v1Extensions.GET("/network-health", h.HandleGetExtensionNetworkHealth)
 	v1Extensions.GET("/marketplace", h.HandleGetMarketplaceExtensions)
 	v1Extensions.GET("/plugin-settings", h.HandleGetPluginSettings)
 	v1Extensions.POST("/plugin-settings/pinned-trays", h.HandleSetPluginSettingsPinnedTrays)
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/anime"
+	"seanime/internal/torrents/torrent"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetSavedTorrentSearches
+//
+//	@summary returns the saved torrent searches.
+//	@desc If "mediaId" is set, only the saved searches of the media are returned.
+//	@route /api/v1/torrent/saved-searches [GET]
+//	@param mediaId - int - false - "The AniList ID of the media"
+//	@returns []anime.SavedTorrentSearch
+func (h *Handler) HandleGetSavedTorrentSearches(c echo.Context) error {
+
+	if mediaIdStr := c.QueryParam("mediaId"); mediaIdStr != "" {
+		mediaId, err := strconv.Atoi(mediaIdStr)
+		if err != nil {
+			return h.RespondWithError(c, errors.New("invalid media id"))
+		}
+		ret, err := db_bridge.GetSavedTorrentSearchesByMediaId(h.App.Database, mediaId)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		return h.RespondWithData(c, ret)
+	}
+
+	ret, err := db_bridge.GetSavedTorrentSearches(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleGetSavedTorrentSearch
+//
+//	@summary returns the saved torrent search with the given DB id.
+//	@desc This can be used to deep-link a saved search.
+//	@route /api/v1/torrent/saved-searches/{id} [GET]
+//	@param id - int - true - "The DB id of the saved search"
+//	@returns anime.SavedTorrentSearch
+func (h *Handler) HandleGetSavedTorrentSearch(c echo.Context) error {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	ret, err := db_bridge.GetSavedTorrentSearch(h.App.Database, uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleCreateSavedTorrentSearch
+//
+//	@summary creates a saved torrent search.
+//	@desc It returns the created saved search.
+//	@route /api/v1/torrent/saved-searches [POST]
+//	@returns anime.SavedTorrentSearch
+func (h *Handler) HandleCreateSavedTorrentSearch(c echo.Context) error {
+
+	type body struct {
+		Name          string   `json:"name"`
+		MediaId       int      `json:"mediaId"`
+		Providers     []string `json:"providers"`
+		Type          string   `json:"type"`
+		QueryTemplate string   `json:"queryTemplate"`
+		EpisodeNumber int      `json:"episodeNumber,omitempty"`
+		Batch         bool     `json:"batch,omitempty"`
+		Resolution    string   `json:"resolution,omitempty"`
+		MinSize       int64    `json:"minSize,omitempty"`
+		MaxSize       int64    `json:"maxSize,omitempty"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	search := &anime.SavedTorrentSearch{
+		Name:          b.Name,
+		MediaId:       b.MediaId,
+		Providers:     b.Providers,
+		Type:          b.Type,
+		QueryTemplate: b.QueryTemplate,
+		EpisodeNumber: b.EpisodeNumber,
+		Batch:         b.Batch,
+		Resolution:    b.Resolution,
+		MinSize:       b.MinSize,
+		MaxSize:       b.MaxSize,
+	}
+
+	if err := search.Validate(); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := db_bridge.InsertSavedTorrentSearch(h.App.Database, search); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeEntryCache.Delete(search.MediaId)
+
+	return h.RespondWithData(c, search)
+}
+
+// HandleUpdateSavedTorrentSearch
+//
+//	@summary updates a saved torrent search.
+//	@desc The body should contain the same fields as anime.SavedTorrentSearch, including the DB id.
+//	@route /api/v1/torrent/saved-searches [PATCH]
+//	@returns anime.SavedTorrentSearch
+func (h *Handler) HandleUpdateSavedTorrentSearch(c echo.Context) error {
+
+	type body struct {
+		Search *anime.SavedTorrentSearch `json:"search"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Search == nil || b.Search.DbID == 0 {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if err := b.Search.Validate(); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	// The media of the saved search can change
+	previous, err := db_bridge.GetSavedTorrentSearch(h.App.Database, b.Search.DbID)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := db_bridge.UpdateSavedTorrentSearch(h.App.Database, b.Search.DbID, b.Search); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeEntryCache.Delete(previous.MediaId)
+	animeEntryCache.Delete(b.Search.MediaId)
+
+	return h.RespondWithData(c, b.Search)
+}
+
+// HandleDeleteSavedTorrentSearch
+//
+//	@summary deletes a saved torrent search.
+//	@route /api/v1/torrent/saved-searches/{id} [DELETE]
+//	@param id - int - true - "The DB id of the saved search"
+//	@returns bool
+func (h *Handler) HandleDeleteSavedTorrentSearch(c echo.Context) error {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	search, err := db_bridge.GetSavedTorrentSearch(h.App.Database, uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := db_bridge.DeleteSavedTorrentSearch(h.App.Database, uint(id)); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeEntryCache.Delete(search.MediaId)
+
+	return h.RespondWithData(c, true)
+}
+
+// RunSavedTorrentSearchBody is the request body of HandleRunSavedTorrentSearch.
+// The fields override the saved search for this run only.
+type RunSavedTorrentSearchBody struct {
+	EpisodeNumber *int    `json:"episodeNumber,omitempty"`
+	Resolution    *string `json:"resolution,omitempty"`
+	Batch         *bool   `json:"batch,omitempty"`
+	HideSeen      bool    `json:"hideSeen,omitempty"`
+}
+
+// HandleRunSavedTorrentSearch
+//
+//	@summary expands and runs a saved torrent search.
+//	@desc The query template is expanded with the media and the episode, and every provider of the saved search is searched.
+//	@desc The results are merged and filtered by resolution and size.
+//	@desc The body can override some fields for this run only, the saved search is not modified.
+//	@desc If "hideSeen" is true, the results dismissed or downloaded by the user are removed.
+//	@route /api/v1/torrent/saved-searches/{id}/run [POST]
+//	@param id - int - true - "The DB id of the saved search"
+//	@body RunSavedTorrentSearchBody
+//	@returns torrent.SearchData
+func (h *Handler) HandleRunSavedTorrentSearch(c echo.Context) error {
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	var b RunSavedTorrentSearchBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	search, err := db_bridge.GetSavedTorrentSearch(h.App.Database, uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.EpisodeNumber != nil {
+		search.EpisodeNumber = *b.EpisodeNumber
+	}
+	if b.Resolution != nil {
+		search.Resolution = *b.Resolution
+	}
+	if b.Batch != nil {
+		search.Batch = *b.Batch
+	}
+
+	media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), search.MediaId)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	data, err := h.App.TorrentRepository.SearchAnimeAggregated(c.Request().Context(), torrent.AggregatedSearchOptions{
+		Providers: search.Providers,
+		AnimeSearchOptions: torrent.AnimeSearchOptions{
+			Type:          torrent.AnimeSearchType(search.Type),
+			Media:         media,
+			Query:         search.ExpandQuery(media, search.EpisodeNumber),
+			Batch:         search.Batch,
+			EpisodeNumber: search.EpisodeNumber,
+			Resolution:    search.Resolution,
+		},
+		MinSize: search.MinSize,
+		MaxSize: search.MaxSize,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.setDebridInstantAvailability(data)
+	h.App.TorrentHistory.Annotate(h.App.GetUIStateOwner(GetSessionID(c)).ID, data, b.HideSeen)
+
+	return h.RespondWithData(c, data)
+}
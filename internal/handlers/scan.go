@@ -3,8 +3,9 @@ package handlers
 import (
 	"errors"
 	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/scancoordinator"
 	"seanime/internal/library/scanner"
-	"seanime/internal/library/summary"
 
 	"github.com/labstack/echo/v4"
 )
@@ -13,15 +14,19 @@ import (
 //
 //	@summary scans the user's library.
 //	@desc This will scan the user's library.
+//	@desc The library paths excluded from full scans are not scanned, their files stay in the library.
+//	@desc If 'roots' is set, only these library paths are scanned, including the ones excluded from full scans.
+//	@desc The scan is queued after the other scans of the library.
 //	@desc The response is ignored, the client should re-fetch the library after this.
 //	@route /api/v1/library/scan [POST]
 //	@returns []anime.LocalFile
 func (h *Handler) HandleScanLocalFiles(c echo.Context) error {
 
 	type body struct {
-		Enhanced         bool `json:"enhanced"`
-		SkipLockedFiles  bool `json:"skipLockedFiles"`
-		SkipIgnoredFiles bool `json:"skipIgnoredFiles"`
+		Enhanced         bool     `json:"enhanced"`
+		SkipLockedFiles  bool     `json:"skipLockedFiles"`
+		SkipIgnoredFiles bool     `json:"skipIgnoredFiles"`
+		Roots            []string `json:"roots"`
 	}
 
 	var b body
@@ -29,84 +34,132 @@ func (h *Handler) HandleScanLocalFiles(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
-	// Retrieve the user's library path
-	libraryPath, err := h.App.Database.GetLibraryPathFromSettings()
+	opts := scancoordinator.ScanOptions{
+		Enhanced:         b.Enhanced,
+		SkipLockedFiles:  b.SkipLockedFiles,
+		SkipIgnoredFiles: b.SkipIgnoredFiles,
+	}
+
+	var lfs []*anime.LocalFile
+	var err error
+	if len(b.Roots) > 0 {
+		lfs, _, err = h.App.ScanCoordinator.ScanRoots(c.Request().Context(), scancoordinator.TriggerManual, b.Roots, opts)
+	} else {
+		lfs, _, err = h.App.ScanCoordinator.ScanFull(c.Request().Context(), scancoordinator.TriggerManual, opts)
+	}
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
-	additionalLibraryPaths, err := h.App.Database.GetAdditionalLibraryPathsFromSettings()
-	if err != nil {
+
+	return h.RespondWithData(c, lfs)
+
+}
+
+// HandleReconcileLibraryPaths
+//
+//	@summary scans library paths and removes the files that are not found, even if the library paths look unavailable.
+//	@desc Scans keep the files of a library path that looks unavailable (e.g. a network mount that dropped) and mark them as unverified.
+//	@desc This is used when the files were really deleted.
+//	@desc The locks and flags of the files that are found are kept.
+//	@route /api/v1/library/scan/reconcile [POST]
+//	@returns []anime.LocalFile
+func (h *Handler) HandleReconcileLibraryPaths(c echo.Context) error {
+
+	type body struct {
+		Roots []string `json:"roots"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
 
-	// Get the latest local files
-	existingLfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
+	if len(b.Roots) == 0 {
+		return h.RespondWithError(c, errors.New("no library path to reconcile"))
+	}
+
+	lfs, _, err := h.App.ScanCoordinator.ScanRoots(c.Request().Context(), scancoordinator.TriggerManual, b.Roots, scancoordinator.ScanOptions{
+		SkipLockedFiles:  true,
+		SkipIgnoredFiles: true,
+		ForceReconcile:   true,
+	})
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
-	// +---------------------+
-	// |       Scanner       |
-	// +---------------------+
+	return h.RespondWithData(c, lfs)
+}
 
-	// Create scan summary logger
-	scanSummaryLogger := summary.NewScanSummaryLogger()
+// HandleGetScanStatus
+//
+//	@summary returns the running, queued and recent scans of the library.
+//	@desc The results of the scans are attributed to the library paths.
+//	@desc Each library path is returned with its scan policy and the result of its last scan.
+//	@route /api/v1/library/scan/status [GET]
+//	@returns scancoordinator.Status
+func (h *Handler) HandleGetScanStatus(c echo.Context) error {
+	return h.RespondWithData(c, h.App.ScanCoordinator.GetStatus())
+}
 
-	// Create a new scan logger
-	scanLogger, err := scanner.NewScanLogger(h.App.Config.Logs.Dir)
-	if err != nil {
+// HandleExplainLocalFileMatch
+//
+//	@summary explains why local files are matched to a media.
+//	@desc The path can be a file or a directory, in which case every media file inside it is explained.
+//	@desc Each explanation lists the matching signals in precedence order (locked file, torrent pre-match, fuzzy title score),
+//	@desc the one that wins and warnings when the signals disagree.
+//	@desc Only the media of the user's collection are used for the fuzzy title score, like a scan without enhanced mode.
+//	@route /api/v1/library/explain-match [POST]
+//	@returns []scanner.MatchExplanation
+func (h *Handler) HandleExplainLocalFileMatch(c echo.Context) error {
+
+	type body struct {
+		Path            string `json:"path"`
+		SkipLockedFiles bool   `json:"skipLockedFiles"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
-	defer scanLogger.Done()
-
-	// Build pre-match map from database for accurate torrent file matching
-	preMatchMap := make(map[string]int)
-	if preMatches, err := h.App.Database.GetAllTorrentPreMatches(); err == nil {
-		for _, pm := range preMatches {
-			preMatchMap[pm.Destination] = pm.MediaId
-		}
-	}
-
-	// Create a new scanner
-	sc := scanner.Scanner{
-		DirPath:             libraryPath,
-		OtherDirPaths:       additionalLibraryPaths,
-		Enhanced:            b.Enhanced,
-		PlatformRef:         h.App.AnilistPlatformRef,
-		Logger:              h.App.Logger,
-		WSEventManager:      h.App.WSEventManager,
-		ExistingLocalFiles:  existingLfs,
-		SkipLockedFiles:     b.SkipLockedFiles,
-		SkipIgnoredFiles:    b.SkipIgnoredFiles,
-		ScanSummaryLogger:   scanSummaryLogger,
-		ScanLogger:          scanLogger,
-		MetadataProviderRef: h.App.MetadataProviderRef,
-		MatchingAlgorithm:   h.App.Settings.GetLibrary().ScannerMatchingAlgorithm,
-		MatchingThreshold:   h.App.Settings.GetLibrary().ScannerMatchingThreshold,
-		PreMatchMap:         preMatchMap,
-	}
-
-	// Scan the library
-	allLfs, err := sc.Scan(c.Request().Context())
+
+	if b.Path == "" {
+		return h.RespondWithError(c, errors.New("path is required"))
+	}
+
+	libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings()
 	if err != nil {
-		if errors.Is(err, scanner.ErrNoLocalFiles) {
-			return h.RespondWithData(c, []interface{}{})
-		} else {
-			return h.RespondWithError(c, err)
-		}
+		return h.RespondWithError(c, err)
 	}
 
-	// Insert the local files
-	lfs, err := db_bridge.InsertLocalFiles(h.App.Database, allLfs)
+	existingLfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
-	// Save the scan summary
-	_ = db_bridge.InsertScanSummary(h.App.Database, scanSummaryLogger.GenerateSummary())
+	animeCollection, err := h.App.AnilistPlatformRef.Get().GetAnimeCollectionWithRelations(c.Request().Context())
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
 
-	go h.App.AutoDownloader.CleanUpDownloadedItems()
+	ret, err := scanner.ExplainMatches(&scanner.ExplainMatchesOptions{
+		Path:               b.Path,
+		LibraryPaths:       libraryPaths,
+		ExistingLocalFiles: existingLfs,
+		AnimeCollection:    animeCollection,
+		PreMatchMap:        h.getTorrentPreMatchMap(),
+		SkipLockedFiles:    b.SkipLockedFiles,
+		ExcludedPaths:      h.App.GetScannerExcludedPaths(),
+		MatchingAlgorithm:  h.App.Settings.GetLibrary().ScannerMatchingAlgorithm,
+		MatchingThreshold:  h.App.Settings.GetLibrary().ScannerMatchingThreshold,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
 
-	return h.RespondWithData(c, lfs)
+	return h.RespondWithData(c, ret)
+}
 
+// getTorrentPreMatchMap builds the pre-match map from the database for accurate torrent file matching.
+func (h *Handler) getTorrentPreMatchMap() map[string]int {
+	return h.App.GetTorrentPreMatchMap()
 }
@@ -9,7 +9,7 @@ import (
 
 func (h *Handler) OptionalAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		if h.App.Config.Server.Password == "" {
+		if h.App.Config.Server.Password == "" || isApiKeyRequest(c) {
 			return next(c)
 		}
 
@@ -20,6 +20,7 @@ func (h *Handler) OptionalAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 		if path == "/api/v1/auth/login" || // for auth
 			path == "/api/v1/auth/logout" || // for auth
 			path == "/api/v1/status" || // for interface
+			path == "/api/v1/public/status" || // public status page, disabled by default
 			path == "/events" || // for server events
 			strings.HasPrefix(path, "/api/v1/directstream") || // ID & path based
 			strings.HasPrefix(path, "/api/v1/mediastream/att/") || // used by media players
@@ -27,6 +28,7 @@ func (h *Handler) OptionalAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 			strings.HasPrefix(path, "/api/v1/mediastream/transcode/") || // used by media players
 			strings.HasPrefix(path, "/api/v1/mediastream/subs/") || // path-based
 			strings.HasPrefix(path, "/api/v1/manga/local-page") || // Path-based
+			strings.HasPrefix(path, "/api/v1/library/episode-thumbnail/") || // Hash-based, used in image tags
 			strings.HasPrefix(path, "/api/v1/torrentstream/stream/") || // accessible by media players
 			strings.HasPrefix(path, "/api/v1/nakama/stream") { // ID-based
 
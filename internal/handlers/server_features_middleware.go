@@ -93,6 +93,7 @@ func (h *Handler) FeaturesMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			// proxy
 			{"/api/v1/proxy", h.App.FeatureManager.IsDisabled(core.Proxy), Empty, Empty},
 			{"/api/v1/image-proxy", h.App.FeatureManager.IsDisabled(core.Proxy), Empty, Empty},
+			{"/api/v1/theme-songs/proxy", h.App.FeatureManager.IsDisabled(core.Proxy), Empty, Empty},
 			// logs
 			{"/api/v1/log", h.App.FeatureManager.IsDisabled(core.ViewLogs), Empty, Empty},
 			{"/api/v1/logs", h.App.FeatureManager.IsDisabled(core.ViewLogs), Empty, Empty},
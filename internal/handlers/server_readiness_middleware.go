@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"seanime/internal/core"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReadinessMiddleware rejects the requests that depend on a module that is still initializing.
+// The response has the ErrorCodeInitializing code so that the client can retry.
+func (h *Handler) ReadinessMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	type pathModuleConfig struct {
+		PathStartsWith string
+		Module         string
+	}
+
+	var pathModuleConfigs = []pathModuleConfig{
+		// modules initialized by App.InitOrRefreshModules
+		{"/api/v1/torrent-client", core.ModuleSettings},
+		{"/api/v1/media-player", core.ModuleSettings},
+		{"/api/v1/playback-manager", core.ModuleSettings},
+		// streaming
+		{"/api/v1/mediastream", core.ModuleMediastream},
+		{"/api/v1/torrentstream", core.ModuleTorrentstream},
+		{"/api/v1/debrid", core.ModuleDebrid},
+		// extensions
+		{"/api/v1/extensions", core.ModuleExtensions},
+		{"/api/v1/onlinestream", core.ModuleExtensions},
+	}
+
+	return func(c echo.Context) error {
+		path := c.Request().URL.Path
+
+		for _, config := range pathModuleConfigs {
+			if !strings.HasPrefix(path, config.PathStartsWith) {
+				continue
+			}
+			if err := h.App.Readiness.CheckReady(config.Module); err != nil {
+				return c.JSON(http.StatusServiceUnavailable, SeaResponse[any]{
+					Error: err.Error(),
+					Code:  ErrorCodeInitializing,
+				})
+			}
+		}
+
+		return next(c)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"seanime/internal/session"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,12 +15,19 @@ const (
 	SessionCookieName = "Seanime-Session-Id"
 	SessionContextKey = "session"
 	SessionIDKey      = "sessionID"
+
+	// publicRoutesPrefix is the prefix of the unauthenticated routes that must not create sessions
+	publicRoutesPrefix = "/api/v1/public/"
 )
 
 // SessionMiddleware extracts or creates a session for each request
 // This enables multi-user support where different browser tabs can have different Anilist accounts
 func (h *Handler) SessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if strings.HasPrefix(c.Request().URL.Path, publicRoutesPrefix) {
+			return next(c)
+		}
+
 		sessionID := ""
 		
 		// Try to get session ID from cookie
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/session"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// HandleGetSessions
+//
+//	@summary returns the active sessions.
+//	@desc The tokens and full IDs of the sessions are never exposed, sessions are identified by the prefix of their ID.
+//	@desc Only the primary session, or sessions logged into an account listed in the session admins setting, can call it.
+//	@route /api/v1/auth/sessions [GET]
+//	@returns []session.Info
+func (h *Handler) HandleGetSessions(c echo.Context) error {
+	return h.RespondWithData(c, h.App.SessionStore.GetInfos())
+}
+
+// RevokeSessionBody is the request body of HandleRevokeSession.
+type RevokeSessionBody struct {
+	// SessionID is the ID or ID prefix of the session
+	SessionID string `json:"sessionId"`
+}
+
+// HandleRevokeSession
+//
+//	@summary removes a session.
+//	@desc The browser using the session is logged out and gets a new simulated session on its next request.
+//	@desc If the session was the primary account used by the server-wide features, the oldest remaining authenticated session becomes the primary one.
+//	@desc Only the primary session, or sessions logged into an account listed in the session admins setting, can call it.
+//	@route /api/v1/auth/sessions/revoke [POST]
+//	@body RevokeSessionBody
+//	@returns []session.Info
+func (h *Handler) HandleRevokeSession(c echo.Context) error {
+	var b RevokeSessionBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	sessionID, err := h.App.SessionStore.ResolveIDPrefix(strings.TrimSpace(b.SessionID))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	wasAuthenticated := lo.ContainsBy(h.App.SessionStore.GetAuthenticatedSessions(), func(s *session.Session) bool {
+		return s.ID == sessionID
+	})
+
+	h.App.RevokeSession(sessionID)
+
+	h.App.Logger.Info().Str("sessionID", sessionID).Msg("app: Session revoked")
+
+	if wasAuthenticated {
+		if err := h.refreshPrimaryAccount(c.Request().Context()); err != nil {
+			return h.RespondWithError(c, err)
+		}
+	}
+
+	return h.RespondWithData(c, h.App.SessionStore.GetInfos())
+}
+
+// SessionAdminMiddleware restricts a route to the primary authenticated session
+// and the sessions logged into an account listed in the session admins setting.
+func (h *Handler) SessionAdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if isSessionAdmin(h.App.SessionStore, GetSessionFromContext(c), h.App.Settings.GetServer().SessionAdmins) {
+			return next(c)
+		}
+		return h.RespondWithError(c, errors.New("UNAUTHORIZED"))
+	}
+}
+
+// isSessionAdmin returns true if the session is the primary authenticated session or is logged into one of the admin accounts
+func isSessionAdmin(store *session.Store, sess *session.Session, admins []string) bool {
+	if sess == nil || sess.IsSimulated || sess.Token == "" {
+		return false
+	}
+
+	if primary := store.GetPrimaryAuthenticatedSession(); primary != nil && primary.ID == sess.ID {
+		return true
+	}
+
+	for _, admin := range admins {
+		if admin != "" && strings.EqualFold(admin, sess.Username) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"seanime/internal/session"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSessionAdmin(t *testing.T) {
+	store := session.NewStore(t.TempDir(), 0)
+
+	now := time.Now()
+	store.SetSession(&session.Session{ID: "primary", Token: "token-a", Username: "alice", CreatedAt: now.Add(-2 * time.Hour)})
+	store.SetSession(&session.Session{ID: "member", Token: "token-b", Username: "Bob", CreatedAt: now.Add(-time.Hour)})
+	store.SetSession(&session.Session{ID: "guest", IsSimulated: true, CreatedAt: now.Add(-3 * time.Hour)})
+
+	assert.True(t, isSessionAdmin(store, store.GetSession("primary"), nil))
+	assert.False(t, isSessionAdmin(store, store.GetSession("member"), nil))
+	assert.True(t, isSessionAdmin(store, store.GetSession("member"), []string{"bob"}))
+	assert.False(t, isSessionAdmin(store, store.GetSession("guest"), []string{""}))
+	assert.False(t, isSessionAdmin(store, nil, nil))
+
+	// The next oldest authenticated session becomes the primary one
+	store.DeleteSession("primary")
+	assert.True(t, isSessionAdmin(store, store.GetSession("member"), nil))
+}
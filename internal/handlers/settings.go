@@ -6,8 +6,15 @@ import (
 	"path/filepath"
 	"runtime"
 	"seanime/internal/database/models"
+	"seanime/internal/library/cleanup"
+	"seanime/internal/library/scancoordinator"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/network_binding"
+	"seanime/internal/torrent_clients/playback_priority"
+	"seanime/internal/torrent_clients/torrent_client"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/util"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -50,12 +57,14 @@ func (h *Handler) HandleGettingStarted(c echo.Context) error {
 		Manga                  models.MangaSettings        `json:"manga"`
 		Notifications          models.NotificationSettings `json:"notifications"`
 		Nakama                 models.NakamaSettings       `json:"nakama"`
+		Server                 models.ServerSettings       `json:"server"`
 		EnableTranscode        bool                        `json:"enableTranscode"`
 		EnableTorrentStreaming bool                        `json:"enableTorrentStreaming"`
 		DebridProvider         string                      `json:"debridProvider"`
 		DebridApiKey           string                      `json:"debridApiKey"`
 	}
 	var b body
+	b.Library.AutoAddToCollection = true // Default to true if the client does not send it
 
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
@@ -82,6 +91,7 @@ func (h *Handler) HandleGettingStarted(c echo.Context) error {
 		Manga:         &b.Manga,
 		Notifications: &b.Notifications,
 		Nakama:        &b.Nakama,
+		Server:        &b.Server,
 		AutoDownloader: &models.AutoDownloaderSettings{
 			Provider:              b.Library.TorrentProvider,
 			Interval:              20,
@@ -160,8 +170,10 @@ func (h *Handler) HandleSaveSettings(c echo.Context) error {
 		Manga         models.MangaSettings        `json:"manga"`
 		Notifications models.NotificationSettings `json:"notifications"`
 		Nakama        models.NakamaSettings       `json:"nakama"`
+		Server        models.ServerSettings       `json:"server"`
 	}
 	var b body
+	b.Library.AutoAddToCollection = true // Default to true if the client does not send it
 
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
@@ -202,11 +214,74 @@ func (h *Handler) HandleSaveSettings(c echo.Context) error {
 		}
 	}
 
+	if b.Server.SessionCleanupInterval < 0 || (b.Server.SessionCleanupInterval > 0 && b.Server.SessionCleanupInterval < time.Minute) {
+		return h.RespondWithError(c, errors.New("session cleanup interval must be at least 1 minute"))
+	}
+
+	if b.Server.SessionMaxIdle < 0 || (b.Server.SessionMaxIdle > 0 && b.Server.SessionMaxIdle < time.Hour) {
+		return h.RespondWithError(c, errors.New("session max idle duration must be at least 1 hour"))
+	}
+
+	if b.Server.PublicStatusMaxStreams < 0 {
+		return h.RespondWithError(c, errors.New("the maximum number of streams cannot be negative"))
+	}
+
+	switch b.Library.DroppedCleanupPolicy {
+	case cleanup.PolicyNone, cleanup.PolicyNotify, cleanup.PolicyTrash:
+	default:
+		return h.RespondWithError(c, errors.New("invalid dropped cleanup policy"))
+	}
+	if b.Library.DroppedCleanupGraceDays != 0 && time.Duration(b.Library.DroppedCleanupGraceDays)*24*time.Hour < cleanup.MinGracePeriod {
+		return h.RespondWithError(c, errors.New("the cleanup grace period must be at least 7 days"))
+	}
+	if b.Library.Timezone != "" {
+		if _, err := time.LoadLocation(b.Library.Timezone); err != nil {
+			return h.RespondWithError(c, errors.New("invalid timezone"))
+		}
+	}
+	for _, policy := range b.Library.LibraryRootPolicies {
+		if policy == nil {
+			continue
+		}
+		if policy.ScanSchedule != "" {
+			if _, _, err := scancoordinator.ParseScanSchedule(policy.ScanSchedule); err != nil {
+				return h.RespondWithError(c, err)
+			}
+		}
+		if policy.IOConcurrency < 0 {
+			return h.RespondWithError(c, errors.New("the IO concurrency of a library path cannot be negative"))
+		}
+		if policy.SentinelFile != "" && !filepath.IsLocal(policy.SentinelFile) {
+			return h.RespondWithError(c, errors.New("the sentinel file of a library path must be relative to it"))
+		}
+	}
+
+	if b.Library.UnverifiedFileGraceDays < 0 {
+		return h.RespondWithError(c, errors.New("the grace period of the unverified files cannot be negative"))
+	}
+
+	if b.Library.TorrentPreMatchRetentionDays < 0 {
+		return h.RespondWithError(c, errors.New("the retention of the torrent pre-matches cannot be negative"))
+	}
+
+	if err := validateTorrentSettings(&b.Torrent); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Manga.PageCacheSize < 0 {
+		return h.RespondWithError(c, errors.New("the manga page cache size cannot be negative"))
+	}
+
 	autoDownloaderSettings := models.AutoDownloaderSettings{}
 	prevSettings, err := h.App.Database.GetSettings()
 	if err == nil && prevSettings.AutoDownloader != nil {
 		autoDownloaderSettings = *prevSettings.AutoDownloader
 	}
+	// The announcement is set through its own endpoint
+	b.Server.PublicStatusAnnouncement = ""
+	if err == nil && prevSettings.Server != nil {
+		b.Server.PublicStatusAnnouncement = prevSettings.Server.PublicStatusAnnouncement
+	}
 	// Disable auto-downloader if the torrent provider is set to none
 	if b.Library.TorrentProvider == torrent.ProviderNone && autoDownloaderSettings.Enabled {
 		h.App.Logger.Debug().Msg("app: Disabling auto-downloader because the torrent provider is set to none")
@@ -226,6 +301,7 @@ func (h *Handler) HandleSaveSettings(c echo.Context) error {
 		Discord:        &b.Discord,
 		Notifications:  &b.Notifications,
 		Nakama:         &b.Nakama,
+		Server:         &b.Server,
 		AutoDownloader: &autoDownloaderSettings,
 	})
 
@@ -243,6 +319,31 @@ func (h *Handler) HandleSaveSettings(c echo.Context) error {
 	return h.RespondWithData(c, status)
 }
 
+// validateTorrentSettings checks the torrent client settings before they are saved or used to contact a client.
+func validateTorrentSettings(settings *models.TorrentSettings) error {
+	if err := torrent_client.ValidateCustomHeaders(settings.CustomHeaders); err != nil {
+		return err
+	}
+
+	if err := clientpath.ValidateSettings(clientpath.Style(settings.QBittorrentPathStyle), settings.QBittorrentPathMappings); err != nil {
+		return err
+	}
+	if err := clientpath.ValidateSettings(clientpath.Style(settings.TransmissionPathStyle), settings.TransmissionPathMappings); err != nil {
+		return err
+	}
+
+	if _, err := playback_priority.ParseLanRanges(settings.SeedingPauseLanRanges); err != nil {
+		return err
+	}
+
+	settings.BindInterface = strings.TrimSpace(settings.BindInterface)
+	settings.BindAddress = strings.TrimSpace(settings.BindAddress)
+	return network_binding.ValidateSettings(network_binding.Settings{
+		Address:     settings.BindAddress,
+		Socks5Proxy: settings.BindSocks5Proxy,
+	})
+}
+
 // HandleSaveAutoDownloaderSettings
 //
 //	@summary updates the auto-downloader settings.
@@ -251,12 +352,15 @@ func (h *Handler) HandleSaveSettings(c echo.Context) error {
 func (h *Handler) HandleSaveAutoDownloaderSettings(c echo.Context) error {
 
 	type body struct {
-		Interval              int  `json:"interval"`
-		Enabled               bool `json:"enabled"`
-		DownloadAutomatically bool `json:"downloadAutomatically"`
-		EnableEnhancedQueries bool `json:"enableEnhancedQueries"`
-		EnableSeasonCheck     bool `json:"enableSeasonCheck"`
-		UseDebrid             bool `json:"useDebrid"`
+		Interval               int  `json:"interval"`
+		Enabled                bool `json:"enabled"`
+		DownloadAutomatically  bool `json:"downloadAutomatically"`
+		EnableEnhancedQueries  bool `json:"enableEnhancedQueries"`
+		EnableSeasonCheck      bool `json:"enableSeasonCheck"`
+		UseDebrid              bool `json:"useDebrid"`
+		AutoRetargetRules      bool `json:"autoRetargetRules"`
+		RequireConfirmation    bool `json:"requireConfirmation"`
+		ConfirmationExpiryDays int  `json:"confirmationExpiryDays"`
 	}
 
 	var b body
@@ -275,14 +379,21 @@ func (h *Handler) HandleSaveAutoDownloaderSettings(c echo.Context) error {
 		return h.RespondWithError(c, errors.New("interval must be at least 15 minutes"))
 	}
 
+	if b.ConfirmationExpiryDays < 0 {
+		return h.RespondWithError(c, errors.New("confirmation expiry must be positive"))
+	}
+
 	autoDownloaderSettings := &models.AutoDownloaderSettings{
-		Provider:              currSettings.Library.TorrentProvider,
-		Interval:              b.Interval,
-		Enabled:               b.Enabled,
-		DownloadAutomatically: b.DownloadAutomatically,
-		EnableEnhancedQueries: b.EnableEnhancedQueries,
-		EnableSeasonCheck:     b.EnableSeasonCheck,
-		UseDebrid:             b.UseDebrid,
+		Provider:               currSettings.Library.TorrentProvider,
+		Interval:               b.Interval,
+		Enabled:                b.Enabled,
+		DownloadAutomatically:  b.DownloadAutomatically,
+		EnableEnhancedQueries:  b.EnableEnhancedQueries,
+		EnableSeasonCheck:      b.EnableSeasonCheck,
+		UseDebrid:              b.UseDebrid,
+		AutoRetargetRules:      b.AutoRetargetRules,
+		RequireConfirmation:    b.RequireConfirmation,
+		ConfirmationExpiryDays: b.ConfirmationExpiryDays,
 	}
 
 	currSettings.AutoDownloader = autoDownloaderSettings
@@ -12,6 +12,7 @@ import (
 	"seanime/internal/database/models"
 	"seanime/internal/user"
 	"seanime/internal/util"
+	"seanime/internal/util/coalesce"
 	"seanime/internal/util/result"
 	"slices"
 	"strconv"
@@ -46,6 +47,8 @@ type Status struct {
 	DisabledFeatures      []core.FeatureKey             `json:"disabledFeatures"`
 	ServerReady           bool                          `json:"serverReady"`
 	ServerHasPassword     bool                          `json:"serverHasPassword"`
+	// AnimeCollectionRefresh is the state of the anime collection refresh coordinator
+	AnimeCollectionRefresh *coalesce.Status `json:"animeCollectionRefresh"`
 }
 
 var clientInfoCache = result.NewMap[string, util.ClientInfo]()
@@ -62,7 +65,7 @@ func (h *Handler) NewStatus(c echo.Context) *Status {
 	sess := GetSessionFromContext(c)
 	if sess != nil && !sess.IsSimulated {
 		// Use session-based user data
-		currentUser = sess.ToUser()
+		currentUser = sess.ToUser(c.Request().Context())
 	} else {
 		// Fall back to database account for backward compatibility
 		if dbAcc, _ := h.App.Database.GetAccount(); dbAcc != nil {
@@ -115,6 +118,10 @@ func (h *Handler) NewStatus(c echo.Context) *Status {
 		DisabledFeatures:      h.App.FeatureManager.DisabledFeatures,
 	}
 
+	if h.App.AnimeCollectionRefresher != nil {
+		status.AnimeCollectionRefresh = h.App.AnimeCollectionRefresher.Status()
+	}
+
 	if c.Get("unauthenticated") != nil && c.Get("unauthenticated").(bool) {
 		// If the user is unauthenticated, return a status with no user data
 		status.OS = ""
@@ -148,6 +155,17 @@ func (h *Handler) HandleGetStatus(c echo.Context) error {
 
 }
 
+// HandleGetReadiness
+//
+//	@summary returns the initialization state of the modules.
+//	@desc Modules are initialized in the background after the server starts.
+//	@desc Requests that depend on a module that is still initializing fail with the "initializing" error code and can be retried.
+//	@route /api/v1/status/readiness [GET]
+//	@returns core.ReadinessStatus
+func (h *Handler) HandleGetReadiness(c echo.Context) error {
+	return h.RespondWithData(c, h.App.Readiness.GetStatus())
+}
+
 func (h *Handler) HandleGetLogContent(c echo.Context) error {
 	if h.App.Config == nil || h.App.Config.Logs.Dir == "" {
 		return h.RespondWithData(c, "")
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/events"
+	"seanime/internal/library/placement"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetStoragePlacementRules
+//
+//	@summary returns all storage placement rules.
+//	@desc It returns an empty slice if there are no rules.
+//	@route /api/v1/library/placement-rules [GET]
+//	@returns []placement.Rule
+func (h *Handler) HandleGetStoragePlacementRules(c echo.Context) error {
+	rules, err := db_bridge.GetStoragePlacementRules(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, rules)
+}
+
+// HandleCreateStoragePlacementRule
+//
+//	@summary creates a new storage placement rule.
+//	@desc The body should contain the same fields as placement.Rule.
+//	@desc It returns the created rule.
+//	@route /api/v1/library/placement-rule [POST]
+//	@returns placement.Rule
+func (h *Handler) HandleCreateStoragePlacementRule(c echo.Context) error {
+
+	var b placement.Rule
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := validateStoragePlacementRule(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	b.DbID = 0
+	if err := db_bridge.InsertStoragePlacementRule(h.App.Database, &b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, b)
+}
+
+// HandleUpdateStoragePlacementRule
+//
+//	@summary updates a storage placement rule.
+//	@desc It returns the updated rule.
+//	@route /api/v1/library/placement-rule [PATCH]
+//	@returns placement.Rule
+func (h *Handler) HandleUpdateStoragePlacementRule(c echo.Context) error {
+
+	type body struct {
+		Rule *placement.Rule `json:"rule"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Rule == nil {
+		return h.RespondWithError(c, errors.New("invalid rule"))
+	}
+
+	if b.Rule.DbID == 0 {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if err := validateStoragePlacementRule(b.Rule); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if err := db_bridge.UpdateStoragePlacementRule(h.App.Database, b.Rule.DbID, b.Rule); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, b.Rule)
+}
+
+// HandleDeleteStoragePlacementRule
+//
+//	@summary deletes a storage placement rule.
+//	@desc It returns 'true' if the rule was deleted.
+//	@route /api/v1/library/placement-rule/{id} [DELETE]
+//	@param id - int - true - "The DB id of the rule"
+//	@returns bool
+func (h *Handler) HandleDeleteStoragePlacementRule(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if err := db_bridge.DeleteStoragePlacementRule(h.App.Database, uint(id)); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleSuggestDownloadDestination
+//
+//	@summary returns the suggested download destination for a media.
+//	@desc The library root is chosen by evaluating the storage placement rules against the media and the estimated size.
+//	@desc The response explains which rule fired, or why the fallback (root with the most free space) was used.
+//	@route /api/v1/torrent-client/suggest-destination [POST]
+//	@returns placement.Result
+func (h *Handler) HandleSuggestDownloadDestination(c echo.Context) error {
+
+	type body struct {
+		Media         *anilist.BaseAnime `json:"media"`
+		EstimatedSize int64              `json:"estimatedSize"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Media == nil {
+		return h.RespondWithError(c, errors.New("media is required"))
+	}
+
+	res, err := h.resolveStoragePlacement(b.Media, b.EstimatedSize)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, res)
+}
+
+// resolveStoragePlacement picks the library root for the media and builds the destination under it.
+// A warning toast is sent when the fallback root was used because of missing space.
+func (h *Handler) resolveStoragePlacement(media *anilist.BaseAnime, estimatedSize int64) (*placement.Result, error) {
+	rules, err := db_bridge.GetStoragePlacementRules(h.App.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rule roots are also candidates even if they are not library paths
+	paths := make([]string, 0, len(libraryPaths)+len(rules))
+	paths = append(paths, libraryPaths...)
+	for _, rule := range rules {
+		if rule.Enabled {
+			paths = append(paths, rule.Root)
+		}
+	}
+
+	res, err := placement.Resolve(&placement.ResolveOptions{
+		Media:         media,
+		EstimatedSize: estimatedSize,
+		Rules:         rules,
+		Roots:         placement.GetRoots(paths),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res.Destination = placement.GetDestination(res.Root, media)
+
+	if res.Warning != "" {
+		h.App.Logger.Warn().Str("root", res.Root).Msgf("placement: %s", res.Warning)
+		h.App.WSEventManager.SendEvent(events.WarningToast, res.Warning)
+	}
+
+	return res, nil
+}
+
+func validateStoragePlacementRule(rule *placement.Rule) error {
+	if rule.Root == "" {
+		return errors.New("root is required")
+	}
+	if !filepath.IsAbs(rule.Root) {
+		return errors.New("root must be an absolute path")
+	}
+	rule.Root = filepath.ToSlash(filepath.Clean(rule.Root))
+	return nil
+}
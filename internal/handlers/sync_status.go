@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"seanime/internal/platforms/platform"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetSyncStatus
+//
+//	@summary returns the sync status of the current user's AniList progress updates.
+//	@desc It includes the pending mutations, the recent failures and the time of the last successful update.
+//	@desc It also includes the age of the cached anime collection and the last time it was validated against AniList.
+//	@route /api/v1/sync-status [GET]
+//	@returns syncstatus.Status
+func (h *Handler) HandleGetSyncStatus(c echo.Context) error {
+	username := h.App.GetSyncStatusUsername(GetSessionID(c))
+	status := h.App.SyncStatusTracker.GetStatus(username, !h.App.IsOffline())
+	if validator, ok := h.App.AnilistPlatformRef.Get().(platform.CacheValidator); ok {
+		status.AnimeCollectionCache = validator.GetAnimeCollectionCacheHealth()
+	}
+	return h.RespondWithData(c, status)
+}
+
+// HandleRetrySyncMutation
+//
+//	@summary retries a pending mutation.
+//	@desc The mutation is removed from the pending list if the retry succeeds.
+//	@route /api/v1/sync-status/retry [POST]
+//	@returns syncstatus.Status
+func (h *Handler) HandleRetrySyncMutation(c echo.Context) error {
+
+	type body struct {
+		ID string `json:"id"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	username := h.App.GetSyncStatusUsername(GetSessionID(c))
+
+	if err := h.App.SyncStatusTracker.Retry(c.Request().Context(), username, b.ID); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, h.App.SyncStatusTracker.GetStatus(username, !h.App.IsOffline()))
+}
+
+// HandleDiscardSyncMutation
+//
+//	@summary discards a pending mutation.
+//	@desc The change will not be sent to AniList.
+//	@route /api/v1/sync-status/pending [DELETE]
+//	@returns syncstatus.Status
+func (h *Handler) HandleDiscardSyncMutation(c echo.Context) error {
+
+	type body struct {
+		ID string `json:"id"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	username := h.App.GetSyncStatusUsername(GetSessionID(c))
+
+	if err := h.App.SyncStatusTracker.Discard(username, b.ID); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, h.App.SyncStatusTracker.GetStatus(username, !h.App.IsOffline()))
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"seanime/internal/api/animethemes"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var themeSongsProxyClient = &http.Client{
+	Timeout: 5 * time.Minute,
+}
+
+// HandlePopulateThemeSongs
+//
+//	@summary fetches and caches the theme songs of the given media.
+//	@desc This will fetch the openings and endings from AnimeThemes, even if they have already been fetched.
+//	@returns bool
+//	@route /api/v1/theme-songs [POST]
+func (h *Handler) HandlePopulateThemeSongs(c echo.Context) error {
+	type body struct {
+		MediaId int `json:"mediaId"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	media, found := animeCollection.FindAnime(b.MediaId)
+	if !found {
+		media, err = h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), b.MediaId)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+	}
+
+	err = h.App.ThemeSongsManager.FetchAndStoreThemesForMedia(media)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeEntryCache.Delete(b.MediaId)
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleGetThemeSongs
+//
+//	@summary returns the cached theme songs of the given media.
+//	@desc Returns an empty list if the themes have not been fetched or if the media has none.
+//	@route /api/v1/theme-songs/{id} [GET]
+//	@param id - int - true - "AniList anime media ID"
+//	@returns []animethemes.Theme
+func (h *Handler) HandleGetThemeSongs(c echo.Context) error {
+	mId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret := h.App.ThemeSongsManager.GetThemes(mId)
+	if ret == nil {
+		ret = make([]*animethemes.Theme, 0)
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleThemeSongsProxy
+//
+//	@summary streams a theme song video or audio file from AnimeThemes.
+//	@desc Only AnimeThemes files can be proxied. Range requests are forwarded.
+//	@route /api/v1/theme-songs/proxy [GET]
+func (h *Handler) HandleThemeSongsProxy(c echo.Context) (err error) {
+	defer util.HandlePanicInModuleWithError("handlers/HandleThemeSongsProxy", &err)
+
+	url := c.QueryParam("url")
+	if !animethemes.IsMediaUrl(url) {
+		return h.RespondWithError(c, errors.New("invalid theme song url"))
+	}
+
+	req, err := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	req.Header.Set("User-Agent", proxyUA)
+	if rangeHeader := c.Request().Header.Get("Range"); rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	resp, err := themeSongsProxyClient.Do(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway)
+	}
+	defer resp.Body.Close()
+
+	for _, k := range []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "Cache-Control", "Last-Modified", "ETag"} {
+		if v := resp.Header.Get(k); v != "" {
+			c.Response().Header().Set(k, v)
+		}
+	}
+
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "video/webm"
+		if strings.HasSuffix(url, ".ogg") {
+			contentType = "audio/ogg"
+		}
+	}
+
+	return c.Stream(resp.StatusCode, contentType, resp.Body)
+}
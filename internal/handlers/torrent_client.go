@@ -1,25 +1,44 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"seanime/internal/api/anilist"
 	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
 	"seanime/internal/events"
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/library/scanner"
+	"seanime/internal/notifications"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/batchwatch"
+	"seanime/internal/torrent_clients/clientpath"
 	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/torrents/torrent"
 	"seanime/internal/util"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
 )
 
 // HandleGetActiveTorrentList
 //
 //	@summary returns all active torrents.
 //	@desc This handler is used by the client to display the active torrents.
+//	@desc Passkeys and authentication tokens in the tracker URLs are replaced with '[REDACTED]'.
+//	@desc If 'groupByMedia' is true, the torrents inside the destination of a pre-match are grouped by media
+//	@desc and the response is a handlers.ActiveTorrentGroups instead of the list of torrents.
 //
 //	@route /api/v1/torrent-client/list [GET]
+//	@param groupByMedia - bool - false - "Group the torrents by the media of their pre-match"
 //	@returns []torrent_client.Torrent
 func (h *Handler) HandleGetActiveTorrentList(c echo.Context) error {
 
@@ -35,25 +54,129 @@ func (h *Handler) HandleGetActiveTorrentList(c echo.Context) error {
 		res, err = h.App.TorrentClientRepository.GetActiveTorrents()
 	}
 
+	if groupByMedia, _ := strconv.ParseBool(c.QueryParam("groupByMedia")); groupByMedia && err == nil {
+		preMatches, err := h.App.Database.GetAllTorrentPreMatches()
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		animeCollection, _ := h.App.GetAnimeCollection(platform.CachedCollection)
+		return h.RespondWithData(c, groupTorrentsByMedia(res, preMatches, h.App.TorrentClientRepository.PathTranslator(), func(mediaId int) string {
+			if animeCollection == nil {
+				return ""
+			}
+			if media, found := animeCollection.FindAnime(mediaId); found {
+				return media.GetPreferredTitle()
+			}
+			return ""
+		}))
+	}
+
 	return h.RespondWithData(c, res)
 
 }
 
+type (
+	// ActiveTorrentGroups is returned by HandleGetActiveTorrentList when the torrents are grouped by media.
+	ActiveTorrentGroups struct {
+		Groups []*AnimeDownloadGroup `json:"groups"`
+		// Other are the torrents that are not inside the destination of a pre-match
+		Other []*torrent_client.Torrent `json:"other"`
+	}
+
+	// AnimeDownloadGroup are the active torrents of a media, e.g. the files of a batch downloaded separately.
+	AnimeDownloadGroup struct {
+		MediaId int `json:"mediaId"`
+		// MediaTitle is empty if the media is not in the collection
+		MediaTitle string                    `json:"mediaTitle"`
+		Torrents   []*torrent_client.Torrent `json:"torrents"`
+		// AggregateProgress is the average progress of the torrents, between 0 and 1
+		AggregateProgress float64 `json:"aggregateProgress"`
+	}
+)
+
+// groupTorrentsByMedia groups the torrents by the media of the most specific pre-match whose destination contains them.
+// Groups are in the order of their first torrent.
+func groupTorrentsByMedia(torrents []*torrent_client.Torrent, preMatches []*models.TorrentPreMatch, translator *clientpath.Translator, getTitle func(mediaId int) string) *ActiveTorrentGroups {
+	ret := &ActiveTorrentGroups{
+		Groups: make([]*AnimeDownloadGroup, 0),
+		Other:  make([]*torrent_client.Torrent, 0),
+	}
+
+	groups := make(map[int]*AnimeDownloadGroup)
+	for _, t := range torrents {
+		var match *models.TorrentPreMatch
+		for _, pm := range preMatches {
+			if pm.MediaId > 0 && translator.IsInDestination(pm.Destination, t.ContentPath) && (match == nil || len(pm.Destination) > len(match.Destination)) {
+				match = pm
+			}
+		}
+		if match == nil {
+			ret.Other = append(ret.Other, t)
+			continue
+		}
+
+		group, ok := groups[match.MediaId]
+		if !ok {
+			group = &AnimeDownloadGroup{
+				MediaId:    match.MediaId,
+				MediaTitle: getTitle(match.MediaId),
+				Torrents:   make([]*torrent_client.Torrent, 0),
+			}
+			groups[match.MediaId] = group
+			ret.Groups = append(ret.Groups, group)
+		}
+		group.Torrents = append(group.Torrents, t)
+	}
+
+	for _, group := range ret.Groups {
+		total := 0.0
+		for _, t := range group.Torrents {
+			total += t.Progress
+		}
+		group.AggregateProgress = total / float64(len(group.Torrents))
+	}
+
+	return ret
+}
+
+// HandleGetTorrentClientStatus
+//
+//	@summary returns the status of the torrent client.
+//	@desc It includes the directory the client keeps incomplete downloads in, which is excluded from scans and the library watcher,
+//	@desc and the state of the network binding and of the kill switch.
+//	@route /api/v1/torrent-client/status [GET]
+//	@returns torrent_client.Status
+func (h *Handler) HandleGetTorrentClientStatus(c echo.Context) error {
+	if h.App.TorrentClientRepository == nil {
+		return h.RespondWithError(c, errors.New("torrent client is not initialized"))
+	}
+
+	ret := h.App.TorrentClientRepository.GetStatus()
+	ret.NetworkBinding = h.App.NetworkBinding.GetStatus()
+
+	return h.RespondWithData(c, ret)
+}
+
+// TorrentClientActionBody is the request body of HandleTorrentClientAction.
+type TorrentClientActionBody struct {
+	Hash   string `json:"hash"`
+	Action string `json:"action"`
+	Dir    string `json:"dir"`
+	// LimitBytesPerSec is the limit of the "set-download-limit" and "set-upload-limit" actions, 0 removes the limit
+	LimitBytesPerSec int64 `json:"limitBytesPerSec"`
+}
+
 // HandleTorrentClientAction
 //
 //	@summary performs an action on a torrent.
 //	@desc This handler is used to pause, resume or remove a torrent.
+//	@desc The "set-download-limit" and "set-upload-limit" actions set the speed limit of the torrent in bytes per second, a limit of 0 removes it.
 //	@route /api/v1/torrent-client/action [POST]
+//	@body TorrentClientActionBody
 //	@returns bool
 func (h *Handler) HandleTorrentClientAction(c echo.Context) error {
 
-	type body struct {
-		Hash   string `json:"hash"`
-		Action string `json:"action"`
-		Dir    string `json:"dir"`
-	}
-
-	var b body
+	var b TorrentClientActionBody
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -83,26 +206,44 @@ func (h *Handler) HandleTorrentClientAction(c echo.Context) error {
 			return h.RespondWithError(c, errors.New("directory not found"))
 		}
 		OpenDirInExplorer(b.Dir)
+	case "set-download-limit":
+		if b.LimitBytesPerSec < 0 {
+			return h.RespondWithError(c, errors.New("invalid limit"))
+		}
+		err := h.App.TorrentClientRepository.SetDownloadLimit(b.Hash, b.LimitBytesPerSec)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+	case "set-upload-limit":
+		if b.LimitBytesPerSec < 0 {
+			return h.RespondWithError(c, errors.New("invalid limit"))
+		}
+		err := h.App.TorrentClientRepository.SetUploadLimit(b.Hash, b.LimitBytesPerSec)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
 	}
 
 	return h.RespondWithData(c, true)
 
 }
 
+// TorrentClientGetFilesBody is the request body of HandleTorrentClientGetFiles.
+type TorrentClientGetFilesBody struct {
+	Torrent  *hibiketorrent.AnimeTorrent `json:"torrent"`
+	Provider string                      `json:"provider"`
+}
+
 // HandleTorrentClientGetFiles
 //
 //	@summary gets the files of a torrent.
 //	@desc This handler is used to get the files of a torrent.
 //	@route /api/v1/torrent-client/get-files [POST]
+//	@body TorrentClientGetFilesBody
 //	@returns []string
 func (h *Handler) HandleTorrentClientGetFiles(c echo.Context) error {
 
-	type body struct {
-		Torrent  *hibiketorrent.AnimeTorrent `json:"torrent"`
-		Provider string                      `json:"provider"`
-	}
-
-	var b body
+	var b TorrentClientGetFilesBody
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -127,6 +268,12 @@ func (h *Handler) HandleTorrentClientGetFiles(c echo.Context) error {
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
+	// Some extensions return the URL of the torrent file instead of a magnet
+	if !strings.HasPrefix(magnet, "magnet:?") {
+		err = fmt.Errorf("provider %s returned invalid magnet: %s", providerExtension.GetName(), magnet)
+		h.App.Logger.Error().Err(err).Msg("torrent client: Invalid magnet link")
+		return c.JSON(http.StatusBadGateway, NewErrorResponse(err))
+	}
 
 	exists := h.App.TorrentClientRepository.TorrentExists(b.Torrent.InfoHash)
 
@@ -153,82 +300,258 @@ func (h *Handler) HandleTorrentClientGetFiles(c echo.Context) error {
 	return h.RespondWithData(c, files)
 }
 
+// TorrentClientDownloadBody is the request body of HandleTorrentClientDownload.
+type TorrentClientDownloadBody struct {
+	Torrents    []hibiketorrent.AnimeTorrent `json:"torrents"`
+	Destination string                       `json:"destination"`
+	SmartSelect TorrentClientSmartSelect     `json:"smartSelect"`
+	Deselect    TorrentClientDeselect        `json:"deselect,omitempty"`
+	Media       *anilist.BaseAnime           `json:"media"`
+}
+
+// TorrentClientSmartSelect selects the files of the missing episodes in a batch torrent.
+type TorrentClientSmartSelect struct {
+	Enabled               bool  `json:"enabled"`
+	MissingEpisodeNumbers []int `json:"missingEpisodeNumbers"`
+	// WatchNewEpisodes watches the torrent and selects the files of new episodes when the batch is updated or replaced
+	WatchNewEpisodes bool `json:"watchNewEpisodes"`
+}
+
+// TorrentClientDeselect deselects the files at the given indices.
+type TorrentClientDeselect struct {
+	Enabled bool  `json:"enabled"`
+	Indices []int `json:"indices"`
+}
+
+// TorrentClientDownloadResponse is returned by HandleTorrentClientDownload.
+type TorrentClientDownloadResponse struct {
+	Success bool `json:"success"`
+	// Warnings are non-fatal issues that did not prevent the download
+	Warnings []string `json:"warnings"`
+	// Skipped are the indices of the torrents whose provider returned an empty magnet link
+	Skipped []int `json:"skipped"`
+	// Results are the torrents that were added, with their name if the torrent client resolved it in time
+	Results []*TorrentClientDownloadResult `json:"results"`
+	// AlreadyDownloading are the info hashes of the torrents that were not added because they are already in the torrent client
+	AlreadyDownloading []string `json:"alreadyDownloading"`
+}
+
+// TorrentClientDownloadResult is a torrent added by HandleTorrentClientDownload.
+type TorrentClientDownloadResult struct {
+	InfoHash string `json:"infoHash"`
+	// Name is the name reported by the torrent client, empty if the metadata of the torrent was not resolved yet
+	Name         string `json:"name"`
+	NameResolved bool   `json:"nameResolved"`
+}
+
+// torrentNameTimeout is how long the torrent client is polled for the names of the added torrents.
+const torrentNameTimeout = 5 * time.Second
+
+// getTorrentDownloadResults looks up the names of the added torrents once.
+// The names that could not be retrieved before the timeout are left empty.
+func getTorrentDownloadResults(hashes []string, getTorrent func(hash string) (*torrent_client.Torrent, error), timeout time.Duration) []*TorrentClientDownloadResult {
+	ret := make([]*TorrentClientDownloadResult, len(hashes))
+	for i, hash := range hashes {
+		ret[i] = &TorrentClientDownloadResult{InfoHash: hash}
+	}
+
+	resolved := make(chan []*TorrentClientDownloadResult, 1)
+	go func() {
+		defer util.HandlePanicInModuleThen("handlers/getTorrentDownloadResults", func() {})
+		res := make([]*TorrentClientDownloadResult, len(hashes))
+		for i, hash := range hashes {
+			res[i] = &TorrentClientDownloadResult{InfoHash: hash}
+			t, err := getTorrent(hash)
+			// The client reports the info hash (or "N/A") as the name until the metadata is resolved
+			if err != nil || t == nil || t.Name == "" || t.Name == "N/A" || strings.EqualFold(t.Name, hash) {
+				continue
+			}
+			res[i].Name = t.Name
+			res[i].NameResolved = true
+		}
+		resolved <- res
+	}()
+
+	select {
+	case res := <-resolved:
+		return res
+	case <-time.After(timeout):
+		return ret
+	}
+}
+
+// isInLibraryPaths returns true if the path is one of the library paths or is inside one of them.
+func isInLibraryPaths(libraryPaths []string, path string) bool {
+	for _, libraryPath := range libraryPaths {
+		if libraryPath != "" && util.IsSubpath(libraryPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorCodeTorrentClientUnavailable is returned when the torrent client could not be started or contacted.
+// The response has a torrentClientStatus field explaining why.
+const ErrorCodeTorrentClientUnavailable = "torrent_client_unavailable"
+
+// TorrentClientErrorResponse is returned instead of SeaResponse when the torrent client could not be started.
+type TorrentClientErrorResponse struct {
+	Error               string                                 `json:"error"`
+	Code                string                                 `json:"code"`
+	TorrentClientStatus torrent_client.TorrentClientDiagnostic `json:"torrentClientStatus"`
+}
+
+// respondWithTorrentClientStartError diagnoses why the torrent client could not be started and returns it with the error.
+func (h *Handler) respondWithTorrentClientStartError(c echo.Context, err error) error {
+	return respondWithTorrentClientError(c, err, h.App.TorrentClientRepository)
+}
+
+// respondWithTorrentClientError diagnoses why the given torrent client could not be contacted and returns it with the error.
+func respondWithTorrentClientError(c echo.Context, err error, repository *torrent_client.Repository) error {
+	return c.JSON(http.StatusInternalServerError, TorrentClientErrorResponse{
+		Error:               err.Error(),
+		Code:                ErrorCodeTorrentClientUnavailable,
+		TorrentClientStatus: repository.DiagnoseStartFailure(),
+	})
+}
+
 // HandleTorrentClientDownload
 //
 //	@summary adds torrents to the torrent client.
 //	@desc It fetches the magnets from the provided URLs and adds them to the torrent client.
 //	@desc If smart select is enabled, it will try to select the best torrent based on the missing episodes.
+//	@desc If 'watchNewEpisodes' is also set, the torrent is watched and the files of new episodes are selected when it is updated or replaced.
+//	@desc The destination is validated with the path semantics of the torrent client, which can run on another OS than the server.
+//	@desc Paths of the server inside the mapped directories are translated to the paths of the torrent client.
+//	@desc If no destination is provided, it is resolved from the storage placement rules.
+//	@desc The pre-match of the media is only saved if the media exists on AniList.
+//	@desc Non-fatal issues, e.g. when the full anime relations could not be fetched, are returned as warnings.
+//	@desc A warning is also returned if the destination is not inside a library path, the download is not blocked.
+//	@desc Torrents whose provider returns an empty magnet link are skipped and their indices are returned in 'skipped'.
+//	@desc If no torrent has a magnet link, it responds with a 422 status.
+//	@desc Torrents that are already in the torrent client are not added again, their info hashes are returned in 'alreadyDownloading'.
+//	@desc The added torrents are returned in 'results' with the name reported by the torrent client.
+//	@desc The client is polled once for up to 5 seconds, 'nameResolved' is false if the metadata of the torrent was not resolved yet.
+//	@desc If the torrent client could not be contacted, the error response has the "torrent_client_unavailable" code
+//	@desc and a "torrentClientStatus" field explaining why (connection_refused, auth_failed, not_configured, timeout).
 //	@route /api/v1/torrent-client/download [POST]
-//	@returns bool
+//	@body TorrentClientDownloadBody
+//	@returns handlers.TorrentClientDownloadResponse
 func (h *Handler) HandleTorrentClientDownload(c echo.Context) error {
 
-	type body struct {
-		Torrents    []hibiketorrent.AnimeTorrent `json:"torrents"`
-		Destination string                       `json:"destination"`
-		SmartSelect struct {
-			Enabled               bool  `json:"enabled"`
-			MissingEpisodeNumbers []int `json:"missingEpisodeNumbers"`
-		} `json:"smartSelect"`
-		Deselect struct {
-			Enabled bool  `json:"enabled"`
-			Indices []int `json:"indices"`
-		} `json:"deselect,omitempty"`
-		Media *anilist.BaseAnime `json:"media"`
-	}
-
-	var b body
+	var b TorrentClientDownloadBody
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
 
+	// Resolve the destination from the storage placement rules if the client did not provide one
+	if b.Destination == "" && b.Media != nil {
+		var estimatedSize int64
+		for _, t := range b.Torrents {
+			estimatedSize += t.Size
+		}
+		res, err := h.resolveStoragePlacement(b.Media, estimatedSize)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		b.Destination = res.Destination
+	}
+
 	if b.Destination == "" {
 		return h.RespondWithError(c, errors.New("destination not found"))
 	}
 
-	if !filepath.IsAbs(b.Destination) {
-		return h.RespondWithError(c, errors.New("destination path must be absolute"))
+	// Do not add torrents while the kill switch is tripped
+	if err := h.App.NetworkBinding.CheckAllowed(); err != nil {
+		return h.RespondWithError(c, err)
 	}
 
-	// Check that the destination path is a library path
-	//libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings()
-	//if err != nil {
-	//	return h.RespondWithError(c, err)
-	//}
-	//isInLibrary := util.IsSubdirectoryOfAny(libraryPaths, b.Destination)
-	//if !isInLibrary {
-	//	return h.RespondWithError(c, errors.New("destination path is not a library path"))
-	//}
-
 	// try to start torrent client if it's not running
 	ok := h.App.TorrentClientRepository.Start()
 	if !ok {
-		return h.RespondWithError(c, errors.New("could not contact torrent client, verify your settings or make sure it's running"))
+		return h.respondWithTorrentClientStartError(c, errors.New("could not contact torrent client, verify your settings or make sure it's running"))
 	}
 
-	var completeAnime *anilist.CompleteAnime
-	var err error
-	completeAnime, err = h.App.AnilistPlatformRef.Get().GetAnimeWithRelations(c.Request().Context(), b.Media.ID)
+	// Validate the destination with the path semantics of the torrent client, which can run on another OS
+	translator := h.App.TorrentClientRepository.PathTranslator()
+	destination, err := translator.ResolveDestination(b.Destination)
 	if err != nil {
-		completeAnime = b.Media.ToCompleteAnime()
+		return h.RespondWithError(c, err)
+	}
+	b.Destination = destination
+
+	warnings := make([]string, 0)
+	skipped := make([]int, 0)
+	alreadyDownloading := make([]string, 0)
+	// Indices of the torrents that are already in the torrent client
+	existing := make([]int, 0)
+	// Info hashes of the added torrents
+	hashes := make([]string, 0, len(b.Torrents))
+
+	// The destination does not have to be a library path, but the files will not be found by the scanner otherwise
+	if libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings(); err == nil && !isInLibraryPaths(libraryPaths, translator.ToServer(b.Destination)) {
+		warnings = append(warnings, "destination is not a configured library path — automatic scanning may not pick up files")
+	}
+
+	mediaId := 0
+	if b.Media != nil {
+		mediaId = b.Media.ID
+	}
+
+	var completeAnime *anilist.CompleteAnime
+	if b.Media != nil {
+		completeAnime, err = h.App.AnilistPlatformRef.Get().GetAnimeWithRelations(c.Request().Context(), b.Media.ID)
+		if err != nil {
+			h.App.Logger.Warn().Err(err).Int("mediaId", b.Media.ID).Msg("torrent client: Could not fetch full anime relations")
+			warnings = append(warnings, fmt.Sprintf("could not fetch full anime relations: %v", err))
+			completeAnime = b.Media.ToCompleteAnime()
+		}
 	}
 
 	if b.SmartSelect.Enabled {
 		if len(b.Torrents) > 1 {
 			return h.RespondWithError(c, errors.New("smart select is not supported for multiple torrents"))
 		}
+		if completeAnime == nil {
+			return h.RespondWithError(c, errors.New("media is required for smart select"))
+		}
 
 		// smart select
-		err = h.App.TorrentClientRepository.SmartSelect(&torrent_client.SmartSelectParams{
+		res, err := h.App.TorrentClientRepository.SmartSelect(&torrent_client.SmartSelectParams{
 			Torrent:          &b.Torrents[0],
 			EpisodeNumbers:   b.SmartSelect.MissingEpisodeNumbers,
 			Media:            completeAnime,
 			Destination:      b.Destination,
 			PlatformRef:      h.App.AnilistPlatformRef,
 			ShouldAddTorrent: true,
+			IsOngoing:        torrent_client.IsOngoingMedia(completeAnime),
 		})
 		if err != nil {
+			// Tell the user which episodes are missing so they can pick a torrent that covers them
+			if res != nil && len(res.UnmatchedEpisodes) > 0 {
+				return h.RespondWithError(c, fmt.Errorf("smart select could not find files for episodes %v in this torrent", res.UnmatchedEpisodes))
+			}
 			return h.RespondWithError(c, err)
 		}
+
+		if b.SmartSelect.WatchNewEpisodes {
+			_, err := h.App.BatchWatchManager.AddWatch(&batchwatch.Watch{
+				Hash:             b.Torrents[0].InfoHash,
+				MediaId:          mediaId,
+				Name:             b.Torrents[0].Name,
+				Provider:         b.Torrents[0].Provider,
+				Destination:      b.Destination,
+				SelectedEpisodes: b.SmartSelect.MissingEpisodeNumbers,
+			})
+			if err != nil {
+				h.App.Logger.Warn().Err(err).Msg("torrent client: Failed to watch batch torrent")
+				warnings = append(warnings, fmt.Sprintf("could not watch the torrent for new episodes: %v", err))
+			}
+		}
+	}
+
+	if (b.SmartSelect.Enabled || b.Deselect.Enabled) && b.Torrents[0].InfoHash != "" {
+		hashes = append(hashes, strings.ToLower(b.Torrents[0].InfoHash))
 	}
 
 	if b.Deselect.Enabled {
@@ -245,7 +568,7 @@ func (h *Handler) HandleTorrentClientDownload(c echo.Context) error {
 
 		// Get magnets
 		magnets := make([]string, 0)
-		for _, t := range b.Torrents {
+		for i, t := range b.Torrents {
 			// Get the torrent's provider extension
 			providerExtension, ok := h.App.TorrentRepository.GetAnimeProviderExtension(t.Provider)
 			if !ok {
@@ -257,74 +580,237 @@ func (h *Handler) HandleTorrentClientDownload(c echo.Context) error {
 				return h.RespondWithError(c, err)
 			}
 
+			// Some provider extensions return an empty magnet link instead of an error
+			if strings.TrimSpace(magnet) == "" {
+				h.App.Logger.Warn().Str("name", t.Name).Str("provider", t.Provider).Msg("torrent client: Provider returned an empty magnet link")
+				skipped = append(skipped, i)
+				continue
+			}
+
+			infoHash := ""
+			if _, hash, err := torrent.ParseMagnetOrInfoHash(magnet); err == nil {
+				infoHash = hash
+			} else if t.InfoHash != "" {
+				infoHash = strings.ToLower(t.InfoHash)
+			}
+
+			// Do not re-add a torrent that is already in the client, e.g. when the user clicks download twice
+			if infoHash != "" && h.App.TorrentClientRepository.TorrentExists(infoHash) {
+				h.App.Logger.Debug().Str("hash", infoHash).Msg("torrent client: Torrent is already in the torrent client, skipping")
+				alreadyDownloading = append(alreadyDownloading, infoHash)
+				existing = append(existing, i)
+				continue
+			}
+
 			magnets = append(magnets, magnet)
+			if infoHash != "" {
+				hashes = append(hashes, infoHash)
+			}
+		}
+
+		if len(magnets) == 0 && len(alreadyDownloading) == 0 {
+			return c.JSON(http.StatusUnprocessableEntity, NewErrorResponse(errors.New("the provider did not return a magnet link for any of the torrents")))
 		}
 
 		// try to add torrents to client, on error return error
 		err = h.App.TorrentClientRepository.AddMagnets(magnets, b.Destination)
 		if err != nil {
+			h.App.Notifications.Notify(notifications.TypeDownloadFailed, fmt.Sprintf("Failed to add torrents to the torrent client: %s", err.Error()), mediaId)
 			return h.RespondWithError(c, err)
 		}
 	}
 
-	// Save pre-match association so the scanner can directly match files to this anime
-	// This avoids false positives from fuzzy title matching
-	if b.Media != nil && b.Media.ID > 0 {
-		err = h.App.Database.SaveTorrentPreMatch(b.Destination, b.Media.ID)
-		if err != nil {
-			h.App.Logger.Warn().Err(err).Msg("torrent client: Failed to save torrent pre-match")
-		} else {
-			h.App.Logger.Info().
-				Int("mediaId", b.Media.ID).
-				Str("destination", b.Destination).
-				Msg("torrent client: Saved torrent pre-match for accurate file matching")
+	downloaded := make([]hibiketorrent.AnimeTorrent, 0, len(b.Torrents))
+	for i, t := range b.Torrents {
+		if !lo.Contains(skipped, i) && !lo.Contains(existing, i) {
+			downloaded = append(downloaded, t)
+			h.App.Notifications.Notify(notifications.TypeDownloadStarted, fmt.Sprintf("Downloading %s", t.Name), mediaId)
 		}
 	}
+	// The destination is a path of the torrent client
+	_, _ = h.recordDownloadIntent(c, &downloadIntent{
+		Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+		MediaId:     mediaId,
+		Destination: translator.ToServer(b.Destination),
+		Torrents:    downloaded,
+	})
+
+	return h.RespondWithData(c, &TorrentClientDownloadResponse{
+		Success:            true,
+		Warnings:           warnings,
+		Skipped:            skipped,
+		Results:            getTorrentDownloadResults(hashes, h.App.TorrentClientRepository.GetTorrentByHash, torrentNameTimeout),
+		AlreadyDownloading: alreadyDownloading,
+	})
+
+}
 
-	// Add the media to the collection (if it wasn't already)
+// addDownloadedMediaToCollection adds the media to the collection (if it wasn't already) in the background.
+func (h *Handler) addDownloadedMediaToCollection(c echo.Context, mediaId int) {
 	go func() {
-		defer util.HandlePanicInModuleThen("handlers/HandleTorrentClientDownload", func() {})
-		if b.Media != nil {
-			// Check if the media is already in the collection
-			animeCollection, err := h.App.GetAnimeCollection(false)
-			if err != nil {
-				return
-			}
-			_, found := animeCollection.FindAnime(b.Media.ID)
-			if found {
-				return
-			}
-			// Add the media to the collection
-			err = h.App.AnilistPlatformRef.Get().AddMediaToCollection(c.Request().Context(), []int{b.Media.ID})
-			if err != nil {
-				h.App.Logger.Error().Err(err).Msg("anilist: Failed to add media to collection")
-			}
-			ac, _ := h.App.RefreshAnimeCollection()
-			h.App.WSEventManager.SendEvent(events.RefreshedAnilistAnimeCollection, ac)
+		defer util.HandlePanicInModuleThen("handlers/addDownloadedMediaToCollection", func() {})
+		// Do not add the media if the user manages their collection manually
+		if h.App.Settings != nil && h.App.Settings.Library != nil && !h.App.Settings.Library.AutoAddToCollection {
+			return
+		}
+		// Check if the media is already in the collection
+		animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
+		if err != nil {
+			return
 		}
+		_, found := animeCollection.FindAnime(mediaId)
+		if found {
+			return
+		}
+		// Add the media to the collection
+		err = h.App.AnilistPlatformRef.Get().AddMediaToCollection(c.Request().Context(), []int{mediaId})
+		if err != nil {
+			h.App.Logger.Error().Err(err).Msg("anilist: Failed to add media to collection")
+		}
+		ac, _ := h.App.RefreshAnimeCollectionInBackground()
+		h.App.WSEventManager.SendEvent(events.RefreshedAnilistAnimeCollection, ac)
 	}()
+}
 
-	return h.RespondWithData(c, true)
+// TorrentClientDownloadSimpleBody is the request body of HandleTorrentClientDownloadSimple.
+type TorrentClientDownloadSimpleBody struct {
+	// Magnet is a magnet link or the info hash of the torrent
+	Magnet string `json:"magnet"`
+	// MediaId is the optional AniList media ID of the torrent
+	MediaId int `json:"mediaId"`
+	// Destination is optional if a media ID is provided
+	Destination string `json:"destination"`
+}
 
+// TorrentClientDownloadSimpleResponse is returned by HandleTorrentClientDownloadSimple.
+type TorrentClientDownloadSimpleResponse struct {
+	Destination string `json:"destination"`
+	InfoHash    string `json:"infoHash"`
+	// HistoryId is the ID of the torrent history entry of the download
+	HistoryId uint `json:"historyId"`
+	// Warnings are non-fatal issues that did not prevent the download
+	Warnings []string `json:"warnings"`
+}
+
+// HandleTorrentClientDownloadSimple
+//
+//	@summary adds a magnet link or an info hash to the torrent client.
+//	@desc This is a simpler version of HandleTorrentClientDownload for scripts and external tools.
+//	@desc If no destination is provided, it is resolved from the storage placement rules of the media.
+//	@desc The pre-match, torrent history and collection are updated as with HandleTorrentClientDownload.
+//	@desc It responds with a 400 status if the magnet link or info hash is invalid or if no destination can be resolved,
+//	@desc and with a 404 status if the media does not exist on AniList.
+//	@route /api/v1/torrent-client/download-simple [POST]
+//	@body TorrentClientDownloadSimpleBody
+//	@returns handlers.TorrentClientDownloadSimpleResponse
+func (h *Handler) HandleTorrentClientDownloadSimple(c echo.Context) error {
+
+	var b TorrentClientDownloadSimpleBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	t, err := newMagnetAnimeTorrent(b.Magnet)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, NewErrorResponse(err))
+	}
+
+	var media *anilist.BaseAnime
+	if b.MediaId != 0 {
+		media, err = h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), b.MediaId)
+		if err != nil || media == nil {
+			return c.JSON(http.StatusNotFound, NewErrorResponse(fmt.Errorf("unknown media ID %d", b.MediaId)))
+		}
+	}
+
+	// Resolve the destination from the storage placement rules if the client did not provide one
+	if b.Destination == "" && media != nil {
+		res, err := h.resolveStoragePlacement(media, 0)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		b.Destination = res.Destination
+	}
+
+	if b.Destination == "" {
+		return c.JSON(http.StatusBadRequest, NewErrorResponse(errors.New("a destination is required when no media ID is provided")))
+	}
+
+	// Do not add torrents while the kill switch is tripped
+	if err := h.App.NetworkBinding.CheckAllowed(); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ok := h.App.TorrentClientRepository.Start()
+	if !ok {
+		return h.respondWithTorrentClientStartError(c, errors.New("could not contact torrent client, verify your settings or make sure it's running"))
+	}
+
+	translator := h.App.TorrentClientRepository.PathTranslator()
+	destination, err := translator.ResolveDestination(b.Destination)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, NewErrorResponse(err))
+	}
+
+	warnings := make([]string, 0)
+	if libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings(); err == nil && !isInLibraryPaths(libraryPaths, translator.ToServer(destination)) {
+		warnings = append(warnings, "destination is not a configured library path — automatic scanning may not pick up files")
+	}
+
+	err = h.App.TorrentClientRepository.AddMagnets([]string{t.MagnetLink}, destination)
+	if err != nil {
+		h.App.Notifications.Notify(notifications.TypeDownloadFailed, fmt.Sprintf("Failed to add torrents to the torrent client: %s", err.Error()), b.MediaId)
+		return h.RespondWithError(c, err)
+	}
+	h.App.Notifications.Notify(notifications.TypeDownloadStarted, fmt.Sprintf("Downloading %s", t.Name), b.MediaId)
+
+	entries, err := h.recordDownloadIntent(c, &downloadIntent{
+		Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+		MediaId:     b.MediaId,
+		Destination: translator.ToServer(destination),
+		Torrents:    []hibiketorrent.AnimeTorrent{*t},
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, &TorrentClientDownloadSimpleResponse{
+		Destination: destination,
+		InfoHash:    t.InfoHash,
+		HistoryId:   entries[0].ID,
+		Warnings:    warnings,
+	})
+}
+
+// TorrentClientAddMagnetFromRuleBody is the request body of HandleTorrentClientAddMagnetFromRule.
+type TorrentClientAddMagnetFromRuleBody struct {
+	MagnetUrl    string `json:"magnetUrl"`
+	RuleId       uint   `json:"ruleId"`
+	QueuedItemId uint   `json:"queuedItemId"`
+}
+
+// mediaExistsForPreMatch confirms that the media ID sent by the client exists on AniList before it is stored in a pre-match.
+// The media of simulated users is not checked.
+func (h *Handler) mediaExistsForPreMatch(c echo.Context, mediaId int) bool {
+	if h.App.GetUser().IsSimulated {
+		return true
+	}
+	media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), mediaId)
+	return err == nil && media != nil
 }
 
 // HandleTorrentClientAddMagnetFromRule
 //
 //	@summary adds magnets to the torrent client based on the AutoDownloader item.
 //	@desc This is used to download torrents that were queued by the AutoDownloader.
-//	@desc The item will be removed from the queue if the magnet was added successfully.
+//	@desc The item will be removed from the queue once the torrent starts downloading, it is kept if the torrent fails to start.
 //	@desc The AutoDownloader items should be re-fetched after this.
 //	@route /api/v1/torrent-client/rule-magnet [POST]
+//	@body TorrentClientAddMagnetFromRuleBody
 //	@returns bool
 func (h *Handler) HandleTorrentClientAddMagnetFromRule(c echo.Context) error {
 
-	type body struct {
-		MagnetUrl    string `json:"magnetUrl"`
-		RuleId       uint   `json:"ruleId"`
-		QueuedItemId uint   `json:"queuedItemId"`
-	}
-
-	var b body
+	var b TorrentClientAddMagnetFromRuleBody
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -351,9 +837,26 @@ func (h *Handler) HandleTorrentClientAddMagnetFromRule(c echo.Context) error {
 		return h.RespondWithError(c, err)
 	}
 
+	// Save pre-match association so the scanner can directly match files to the rule's anime
+	intent := &downloadIntent{
+		Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+		MediaId:     rule.MediaId,
+		Destination: rule.Destination,
+	}
+	if t, err := newMagnetAnimeTorrent(b.MagnetUrl); err == nil {
+		intent.Torrents = []hibiketorrent.AnimeTorrent{*t}
+	}
+	entries, _ := h.recordDownloadIntent(c, intent)
+
 	if b.QueuedItemId > 0 {
-		// the magnet was added successfully, remove the item from the queue
-		err = h.App.Database.DeleteAutoDownloaderItem(b.QueuedItemId)
+		// The item is removed from the queue by the App once the torrent is downloading or seeding
+		if len(entries) > 0 {
+			err = h.App.Database.SetTorrentResultQueuedItem(entries[0].ID, b.QueuedItemId)
+		}
+		// The torrent cannot be followed without its history entry, remove the item now
+		if len(entries) == 0 || err != nil {
+			err = h.App.Database.DeleteAutoDownloaderItem(b.QueuedItemId)
+		}
 	}
 
 	return h.RespondWithData(c, true)
@@ -382,6 +885,321 @@ func (h *Handler) HandleClearTorrentPreMatches(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// TorrentPreMatchItem is a torrent pre-match with the title of its media.
+type TorrentPreMatchItem struct {
+	*models.TorrentPreMatch
+	// MediaTitle is empty if the media could not be found
+	MediaTitle string `json:"mediaTitle"`
+	// ExpiresAt is when the pre-match is removed if its files have not been matched by a scan
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// HandleGetTorrentPreMatches
+//
+//	@summary returns all torrent pre-matches.
+//	@desc The title of the media is taken from the user's anime collection, or fetched from AniList if the media is not in it.
+//	@desc The title is empty if the media could not be found.
+//	@route /api/v1/torrent-client/pre-matches [GET]
+//	@returns []handlers.TorrentPreMatchItem
+func (h *Handler) HandleGetTorrentPreMatches(c echo.Context) error {
+	preMatches, err := h.App.Database.GetAllTorrentPreMatches()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	titles := make(map[int]string)
+	animeCollection, _ := h.App.GetAnimeCollection(platform.CachedCollection)
+	getTitle := func(mediaId int) string {
+		if title, ok := titles[mediaId]; ok {
+			return title
+		}
+		title := ""
+		if media, found := animeCollection.FindAnime(mediaId); found {
+			title = media.GetPreferredTitle()
+		} else if media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), mediaId); err == nil && media != nil {
+			title = media.GetPreferredTitle()
+		}
+		titles[mediaId] = title
+		return title
+	}
+
+	retentionDays := h.App.GetTorrentPreMatchRetentionDays()
+
+	ret := make([]*TorrentPreMatchItem, 0, len(preMatches))
+	for _, pm := range preMatches {
+		ret = append(ret, &TorrentPreMatchItem{
+			TorrentPreMatch: pm,
+			MediaTitle:      getTitle(pm.MediaId),
+			ExpiresAt:       pm.CreatedAt.AddDate(0, 0, retentionDays),
+		})
+	}
+
+	return h.RespondWithData(c, ret)
+}
+
+// HandleDeleteTorrentPreMatch
+//
+//	@summary deletes a torrent pre-match.
+//	@desc This is used to remove a single wrong association without clearing all pre-matches.
+//	@route /api/v1/torrent-client/pre-matches/delete [POST]
+//	@returns bool
+func (h *Handler) HandleDeleteTorrentPreMatch(c echo.Context) error {
+
+	type body struct {
+		ID uint `json:"id"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if _, err := h.App.Database.GetTorrentPreMatch(b.ID); err != nil {
+		return h.RespondWithError(c, errors.New("pre-match not found"))
+	}
+
+	if err := h.App.Database.DeleteTorrentPreMatch(b.ID); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.Logger.Info().Uint("id", b.ID).Msg("torrent client: Deleted torrent pre-match")
+	return h.RespondWithData(c, true)
+}
+
+// TorrentPreMatchExport is an exported torrent pre-match.
+type TorrentPreMatchExport struct {
+	Destination string    `json:"destination"`
+	MediaId     int       `json:"mediaId"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// ExpiresAt is when the pre-match is removed if its files have not been matched by a scan
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// HandleExportTorrentPreMatches
+//
+//	@summary exports all torrent pre-matches as a JSON file.
+//	@desc The file can be imported with HandleImportTorrentPreMatches. Internal IDs are not exported.
+//	@route /api/v1/torrent-client/pre-matches/export [GET]
+//	@returns []handlers.TorrentPreMatchExport
+func (h *Handler) HandleExportTorrentPreMatches(c echo.Context) error {
+	preMatches, err := h.App.Database.GetAllTorrentPreMatches()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	retentionDays := h.App.GetTorrentPreMatchRetentionDays()
+
+	ret := make([]*TorrentPreMatchExport, 0, len(preMatches))
+	for _, pm := range preMatches {
+		expiresAt := pm.CreatedAt.AddDate(0, 0, retentionDays)
+		ret = append(ret, &TorrentPreMatchExport{
+			Destination: pm.Destination,
+			MediaId:     pm.MediaId,
+			CreatedAt:   pm.CreatedAt,
+			ExpiresAt:   &expiresAt,
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(ret, "", "  ")
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	filename := fmt.Sprintf("pre-matches-%s.json", time.Now().Format("2006-01-02_15-04-05"))
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	return c.Blob(http.StatusOK, "application/json", jsonData)
+}
+
+// HandleImportTorrentPreMatches
+//
+//	@summary imports torrent pre-matches exported by HandleExportTorrentPreMatches.
+//	@desc The body is the JSON array of the exported file. Existing pre-matches with the same destination are replaced.
+//	@desc Expired entries are skipped.
+//	@desc It returns the number of imported pre-matches.
+//	@route /api/v1/torrent-client/pre-matches/import [POST]
+//	@returns int
+func (h *Handler) HandleImportTorrentPreMatches(c echo.Context) error {
+	var b []*TorrentPreMatchExport
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	now := time.Now()
+	preMatches := make([]*models.TorrentPreMatch, 0, len(b))
+	for _, pm := range b {
+		if pm == nil || pm.ExpiresAt != nil && pm.ExpiresAt.Before(now) {
+			continue
+		}
+		if strings.TrimSpace(pm.Destination) == "" || pm.MediaId <= 0 {
+			return h.RespondWithError(c, fmt.Errorf("invalid pre-match for destination %q", pm.Destination))
+		}
+		preMatch := &models.TorrentPreMatch{
+			Destination: pm.Destination,
+			MediaId:     pm.MediaId,
+		}
+		preMatch.CreatedAt = pm.CreatedAt
+		preMatches = append(preMatches, preMatch)
+	}
+
+	if err := h.App.Database.SaveTorrentPreMatchBatch(preMatches); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.Logger.Info().Int("count", len(preMatches)).Msg("torrent client: Imported torrent pre-matches")
+	return h.RespondWithData(c, len(preMatches))
+}
+
+// TorrentPreMatchRefreshResponse is returned by HandleRefreshTorrentPreMatch.
+type TorrentPreMatchRefreshResponse struct {
+	StoredMediaId int `json:"storedMediaId"`
+	// SuggestedMediaId is 0 if no file could be matched
+	SuggestedMediaId int `json:"suggestedMediaId"`
+	// Confidence is between 0 and 1
+	Confidence   float64 `json:"confidence"`
+	MatchedFiles int     `json:"matchedFiles"`
+	TotalFiles   int     `json:"totalFiles"`
+	// TorrentNames are the torrents found in the destination
+	TorrentNames []string `json:"torrentNames"`
+}
+
+// HandleRefreshTorrentPreMatch
+//
+//	@summary re-runs the matching on the files of a pre-matched torrent.
+//	@desc The files of the torrents downloaded to the pre-match destination are matched with the fuzzy title matching
+//	@desc and the suggested media is compared to the stored one. The pre-match is not modified.
+//	@route /api/v1/torrent-client/pre-matches/{id}/refresh [POST]
+//	@param id - int - true - "The pre-match ID"
+//	@returns handlers.TorrentPreMatchRefreshResponse
+func (h *Handler) HandleRefreshTorrentPreMatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	preMatch, err := h.App.Database.GetTorrentPreMatch(uint(id))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("pre-match not found"))
+	}
+
+	// Find the torrents downloaded to the destination
+	torrents, err := h.App.TorrentClientRepository.GetList()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	translator := h.App.TorrentClientRepository.PathTranslator()
+	torrents = lo.Filter(torrents, func(t *torrent_client.Torrent, _ int) bool {
+		return translator.IsInDestination(preMatch.Destination, t.ContentPath)
+	})
+	if len(torrents) == 0 {
+		return h.RespondWithError(c, errors.New("torrent not found in the torrent client"))
+	}
+
+	ret := &TorrentPreMatchRefreshResponse{
+		StoredMediaId: preMatch.MediaId,
+		TorrentNames:  make([]string, 0, len(torrents)),
+	}
+
+	paths := make([]string, 0)
+	for _, t := range torrents {
+		files, err := h.App.TorrentClientRepository.GetFiles(t.Hash)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		for _, f := range files {
+			paths = append(paths, filepath.Join(preMatch.Destination, f))
+		}
+		ret.TorrentNames = append(ret.TorrentNames, t.Name)
+	}
+
+	libraryPaths, err := h.App.Database.GetAllLibraryPathsFromSettings()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeCollection, err := h.App.AnilistPlatformRef.Get().GetAnimeCollectionWithRelations(c.Request().Context())
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	suggestion, err := scanner.SuggestMedia(&scanner.SuggestMediaOptions{
+		Paths:             paths,
+		LibraryPaths:      libraryPaths,
+		AnimeCollection:   animeCollection,
+		MatchingAlgorithm: h.App.Settings.GetLibrary().ScannerMatchingAlgorithm,
+		MatchingThreshold: h.App.Settings.GetLibrary().ScannerMatchingThreshold,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret.SuggestedMediaId = suggestion.MediaId
+	ret.Confidence = suggestion.Confidence
+	ret.MatchedFiles = suggestion.MatchedFiles
+	ret.TotalFiles = suggestion.TotalFiles
+
+	return h.RespondWithData(c, ret)
+}
+
+// TorrentPreMatchUpdateBody is the request body of HandleUpdateTorrentPreMatch.
+type TorrentPreMatchUpdateBody struct {
+	MediaId int `json:"mediaId"`
+}
+
+// HandleUpdateTorrentPreMatch
+//
+//	@summary changes the media ID of a torrent pre-match.
+//	@desc This is used to correct a pre-match that assigns the files of a torrent to the wrong media.
+//	@desc The media must be in the user's AniList collection or be a valid AniList anime.
+//	@route /api/v1/torrent-client/pre-matches/{id} [PATCH]
+//	@param id - int - true - "The pre-match ID"
+//	@body TorrentPreMatchUpdateBody
+//	@returns models.TorrentPreMatch
+func (h *Handler) HandleUpdateTorrentPreMatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	var b TorrentPreMatchUpdateBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.MediaId <= 0 {
+		return h.RespondWithError(c, errors.New("invalid media ID"))
+	}
+
+	if _, err := h.App.Database.GetTorrentPreMatch(uint(id)); err != nil {
+		return h.RespondWithError(c, errors.New("pre-match not found"))
+	}
+
+	// Check that the media exists, in the collection first to avoid a request
+	found := false
+	if animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection); err == nil && animeCollection != nil {
+		_, found = animeCollection.FindAnime(b.MediaId)
+	}
+	if !found {
+		media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), b.MediaId)
+		if err != nil || media == nil {
+			return h.RespondWithError(c, fmt.Errorf("media %d not found on AniList", b.MediaId))
+		}
+	}
+
+	if err := h.App.Database.UpdateTorrentPreMatchMediaId(uint(id), b.MediaId); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	ret, err := h.App.Database.GetTorrentPreMatch(uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.Logger.Info().Uint("id", ret.ID).Int("mediaId", ret.MediaId).Msg("torrent client: Updated torrent pre-match")
+
+	return h.RespondWithData(c, ret)
+}
+
 // HandleGetMediaDownloadingStatus
 //
 //	@summary returns the download status of media items that are currently downloading.
@@ -404,35 +1222,117 @@ func (h *Handler) HandleGetMediaDownloadingStatus(c echo.Context) error {
 		return h.RespondWithData(c, result)
 	}
 
-	// Create a map of destination paths to media IDs
-	destToMediaId := make(map[string]int)
-	for _, pm := range preMatches {
-		destToMediaId[util.NormalizePath(pm.Destination)] = pm.MediaId
-	}
+	return h.RespondWithData(c, getMediaDownloadingStatus(torrents, preMatches, h.App.TorrentClientRepository.PathTranslator()))
+}
+
+// getMediaDownloadingStatus matches the torrents to the media of the pre-matches whose destination contains them.
+// The content paths of the torrents are compared with the path semantics of the torrent client.
+func getMediaDownloadingStatus(torrents []*torrent_client.Torrent, preMatches []*models.TorrentPreMatch, translator *clientpath.Translator) []MediaDownloadStatus {
+	result := make([]MediaDownloadStatus, 0)
 
 	// Track which media IDs we've already added (to avoid duplicates)
 	addedMediaIds := make(map[int]bool)
 
 	// Match torrents to media IDs based on content path
 	for _, torrent := range torrents {
-		contentPath := util.NormalizePath(torrent.ContentPath)
-
-		// Check if the torrent's content path matches any pre-match destination
-		for destPath, mediaId := range destToMediaId {
-			// Check if content path starts with or equals the destination path
-			if len(contentPath) >= len(destPath) && contentPath[:len(destPath)] == destPath {
-				if !addedMediaIds[mediaId] {
+		// Check if the torrent's content path is inside any pre-match destination
+		for _, pm := range preMatches {
+			if translator.IsInDestination(pm.Destination, torrent.ContentPath) {
+				if !addedMediaIds[pm.MediaId] {
 					result = append(result, MediaDownloadStatus{
-						MediaId:  mediaId,
+						MediaId:  pm.MediaId,
 						Status:   torrent.Status,
 						Progress: torrent.Progress,
 					})
-					addedMediaIds[mediaId] = true
+					addedMediaIds[pm.MediaId] = true
 				}
 				break
 			}
 		}
 	}
 
-	return h.RespondWithData(c, result)
+	return result
+}
+
+// HandleGetPlaybackPriorityStatus
+//
+//	@summary returns the state of the playback priority mode.
+//	@desc While something is being played, the download speed of the torrent client can be limited to avoid disk contention.
+//	@desc It returns the active playback sessions and the last state transitions.
+//	@route /api/v1/torrent-client/playback-priority [GET]
+//	@returns playback_priority.Status
+func (h *Handler) HandleGetPlaybackPriorityStatus(c echo.Context) error {
+	return h.RespondWithData(c, h.App.PlaybackPriority.GetStatus())
+}
+
+// HandleGetSeedingPauseStatus
+//
+//	@summary returns the state of the seeding pause.
+//	@desc While media is streamed to a client outside the local network, seeding torrents can be paused to free the upload bandwidth.
+//	@desc It returns the remote sessions, the paused torrents and the trail of paused and resumed torrents.
+//	@route /api/v1/torrent-client/seeding-pause [GET]
+//	@returns playback_priority.SeedingStatus
+func (h *Handler) HandleGetSeedingPauseStatus(c echo.Context) error {
+	return h.RespondWithData(c, h.App.SeedingPause.GetStatus())
+}
+
+type TorrentClientPieceStatesResponse struct {
+	Hash string `json:"hash"`
+	// PieceCount is the number of pieces of the torrent
+	PieceCount int `json:"pieceCount"`
+	// Pieces is the base64-encoded state of each piece.
+	// Each byte is 0 (not downloaded), 1 (downloading) or 2 (downloaded).
+	Pieces string `json:"pieces"`
+}
+
+// HandleGetTorrentPieceStates
+//
+//	@summary returns the state of each piece of a torrent.
+//	@desc This is used to display a progress bar with piece-level granularity.
+//	@desc Transmission does not report the pieces that are being downloaded.
+//	@route /api/v1/torrent-client/pieces [GET]
+//	@param hash query string true "The info hash of the torrent."
+//	@returns handlers.TorrentClientPieceStatesResponse
+func (h *Handler) HandleGetTorrentPieceStates(c echo.Context) error {
+	hash := c.QueryParam("hash")
+	if hash == "" {
+		return h.RespondWithError(c, errors.New("hash is required"))
+	}
+
+	pieces, err := h.App.TorrentClientRepository.GetTorrentPieceStates(hash)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, &TorrentClientPieceStatesResponse{
+		Hash:       hash,
+		PieceCount: len(pieces),
+		Pieces:     base64.StdEncoding.EncodeToString(pieces),
+	})
+}
+
+// HandleGetSpeedHistory
+//
+//	@summary returns the recent aggregate download and upload speeds of the torrent client.
+//	@desc A sample is recorded every minute and the last 24 hours are kept, minutes during which the torrent client was unreachable have no sample.
+//	@desc This is used to draw a speed graph, the samples are returned oldest first.
+//	@route /api/v1/torrent-client/speed-history [GET]
+//	@param samples query int false "The number of samples to return, 60 by default and 1440 at most."
+//	@returns []speedhistory.Sample
+func (h *Handler) HandleGetSpeedHistory(c echo.Context) error {
+	count := 60
+	if c.QueryParam("samples") != "" {
+		var err error
+		count, err = strconv.Atoi(c.QueryParam("samples"))
+		if err != nil || count <= 0 {
+			return h.RespondWithError(c, errors.New("invalid number of samples"))
+		}
+	}
+
+	samples, err := h.App.SpeedHistory.GetSamples(count)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, samples)
 }
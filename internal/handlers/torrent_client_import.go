@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/database/db_bridge"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/client_import"
+	"seanime/internal/torrent_clients/torrent_client"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetTorrentClientImport
+//
+//	@summary returns the torrents of the torrent client that Seanime does not know, with a proposed media.
+//	@desc The media is proposed from the files of the library inside the torrent, or from the name of the torrent.
+//	@desc Torrents that were downloaded with Seanime, imported, skipped or that are inside the destination of a pre-match are not returned.
+//	@desc Torrents that could not be matched are returned with a media ID of 0.
+//	@route /api/v1/torrent-client/import [GET]
+//	@returns []client_import.Proposal
+func (h *Handler) HandleGetTorrentClientImport(c echo.Context) error {
+	if !h.App.TorrentClientRepository.Start() {
+		return h.respondWithTorrentClientStartError(c, errors.New("could not contact torrent client, verify your settings or make sure it's running"))
+	}
+
+	torrents, err := h.App.TorrentClientRepository.GetList()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	knownHashes, err := h.getKnownTorrentHashes(c, torrents)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	preMatches, err := h.App.Database.GetAllTorrentPreMatches()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(h.App.Database)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	animeCollection, err := h.App.GetAnimeCollection(platform.CachedCollection)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, client_import.Plan(&client_import.PlanOptions{
+		Torrents:    torrents,
+		KnownHashes: knownHashes,
+		PreMatches:  preMatches,
+		LocalFiles:  lfs,
+		Collection:  animeCollection,
+		Translator:  h.App.TorrentClientRepository.PathTranslator(),
+	}))
+}
+
+// getKnownTorrentHashes returns the hashes of the torrents downloaded or skipped by the user and of the AutoDownloader items.
+func (h *Handler) getKnownTorrentHashes(c echo.Context, torrents []*torrent_client.Torrent) (map[string]struct{}, error) {
+	hashes := make([]string, 0, len(torrents))
+	for _, t := range torrents {
+		hashes = append(hashes, strings.ToLower(t.Hash))
+	}
+
+	ret := make(map[string]struct{})
+	entries, err := h.App.Database.GetTorrentResultHistory(h.App.GetUIStateOwner(GetSessionID(c)).ID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.DownloadedAt != nil || e.DismissedAt != nil {
+			ret[e.Key] = struct{}{}
+		}
+	}
+
+	items, err := h.App.Database.GetAutoDownloaderItems()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.Hash != "" {
+			ret[strings.ToLower(item.Hash)] = struct{}{}
+		}
+	}
+
+	return ret, nil
+}
+
+// TorrentClientImportBody is the request body of HandleTorrentClientImport.
+type TorrentClientImportBody struct {
+	Items []TorrentClientImportItem `json:"items"`
+}
+
+// TorrentClientImportItem is the decision of the user for a torrent of the client.
+type TorrentClientImportItem struct {
+	Hash string `json:"hash"`
+	// MediaId is the proposed or manually assigned media
+	MediaId int `json:"mediaId"`
+	// Skip records the torrent so that it is not proposed again, without a media
+	Skip bool `json:"skip"`
+}
+
+// TorrentClientImportResponse is returned by HandleTorrentClientImport.
+type TorrentClientImportResponse struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	// Errors are the torrents that could not be imported
+	Errors []string `json:"errors"`
+}
+
+// HandleTorrentClientImport
+//
+//	@summary imports torrents of the torrent client into the history and the pre-matches.
+//	@desc The accepted torrents are recorded as downloaded and their content path is pre-matched to the media, like torrents downloaded with Seanime.
+//	@desc Skipped torrents are recorded as dismissed, they are not proposed again.
+//	@route /api/v1/torrent-client/import [POST]
+//	@body TorrentClientImportBody
+//	@returns handlers.TorrentClientImportResponse
+func (h *Handler) HandleTorrentClientImport(c echo.Context) error {
+	var b TorrentClientImportBody
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if !h.App.TorrentClientRepository.Start() {
+		return h.respondWithTorrentClientStartError(c, errors.New("could not contact torrent client, verify your settings or make sure it's running"))
+	}
+
+	owner := h.App.GetUIStateOwner(GetSessionID(c)).ID
+	translator := h.App.TorrentClientRepository.PathTranslator()
+
+	ret := &TorrentClientImportResponse{Errors: make([]string, 0)}
+	for _, item := range b.Items {
+		if !item.Skip && item.MediaId <= 0 {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("%s: no media assigned", item.Hash))
+			continue
+		}
+
+		t, err := h.App.TorrentClientRepository.GetTorrentByHash(strings.ToLower(item.Hash))
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("%s: %v", item.Hash, err))
+			continue
+		}
+		animeTorrent := &hibiketorrent.AnimeTorrent{Name: t.Name, InfoHash: strings.ToLower(t.Hash)}
+
+		if item.Skip {
+			if _, err := h.App.TorrentHistory.Dismiss(owner, animeTorrent); err != nil {
+				ret.Errors = append(ret.Errors, fmt.Sprintf("%s: %v", t.Name, err))
+				continue
+			}
+			ret.Skipped++
+			continue
+		}
+
+		// The content path is pre-matched, it is the directory of the torrent or its only file
+		_, err = h.recordDownloadIntent(c, &downloadIntent{
+			Owner:       owner,
+			MediaId:     item.MediaId,
+			Destination: translator.ToServer(t.ContentPath),
+			Torrents:    []hibiketorrent.AnimeTorrent{*animeTorrent},
+		})
+		if err != nil {
+			ret.Errors = append(ret.Errors, fmt.Sprintf("%s: %v", t.Name, err))
+			continue
+		}
+		ret.Imported++
+	}
+
+	h.App.Logger.Info().Int("imported", ret.Imported).Int("skipped", ret.Skipped).Msg("torrent client: Imported torrents")
+
+	return h.RespondWithData(c, ret)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/client_migration"
+	"seanime/internal/torrent_clients/torrent_client"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// torrentClientMigrationCategory is the category, tag or label of the torrents added by Seanime
+const torrentClientMigrationCategory = "seanime"
+
+// HandleStartTorrentClientMigration
+//
+//	@summary migrates the torrents managed by Seanime to another torrent client.
+//	@desc The torrent settings are validated like when they are saved, and the target client is contacted before the migration starts.
+//	@desc The target client is the 'defaultTorrentClient' of the settings.
+//	@desc The torrents in the directories of the pre-matches or with the 'seanime' category, tag or label are added to the target client
+//	@desc with the same paths and selected files. The hash check is only skipped if the current client has verified all the data.
+//	@desc If 'removeFromSource' is true, each torrent is removed from the current client once it is verified in the target client. The files are kept.
+//	@desc The migration runs in the background, its report is sent with the "torrent-client-migration-updated" event.
+//	@desc Calling it again resumes an interrupted migration, migrated torrents are kept and failed ones are retried.
+//	@desc The settings are only switched to the target client when the report is confirmed.
+//	@route /api/v1/torrent-client/migration [POST]
+//	@returns client_migration.Report
+func (h *Handler) HandleStartTorrentClientMigration(c echo.Context) error {
+
+	type body struct {
+		Torrent          models.TorrentSettings `json:"torrent"`
+		RemoveFromSource bool                   `json:"removeFromSource"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Torrent.Default != torrent_client.QbittorrentClient && b.Torrent.Default != torrent_client.TransmissionClient {
+		return h.RespondWithError(c, errors.New("select qBittorrent or Transmission as the target client"))
+	}
+	if err := validateTorrentSettings(&b.Torrent); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	source := h.App.TorrentClientRepository
+	if source == nil {
+		return h.RespondWithError(c, errors.New("torrent client is not initialized"))
+	}
+	if ok := source.Start(); !ok {
+		return h.respondWithTorrentClientStartError(c, errors.New("could not contact the current torrent client"))
+	}
+
+	target := h.App.NewTorrentClientRepository(&b.Torrent)
+	if ok := target.Start(); !ok {
+		return respondWithTorrentClientError(c, errors.New("could not start the target torrent client"), target)
+	}
+	// Start does not contact qBittorrent when it is not started by Seanime
+	if _, err := target.GetDefaultDownloadDir(); err != nil {
+		return respondWithTorrentClientError(c, err, target)
+	}
+
+	preMatches, err := h.App.Database.GetAllTorrentPreMatches()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+	destinations := make([]string, 0, len(preMatches))
+	for _, preMatch := range preMatches {
+		destinations = append(destinations, preMatch.Destination)
+	}
+
+	managedTags := []string{torrentClientMigrationCategory}
+	if settings, err := h.App.Database.GetSettings(); err == nil && settings.Torrent != nil && source.GetProvider() == torrent_client.QbittorrentClient {
+		for _, tag := range strings.Split(settings.Torrent.QBittorrentTags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				managedTags = append(managedTags, tag)
+			}
+		}
+	}
+
+	report, err := h.App.TorrentClientMigrator.Start(&client_migration.StartOptions{
+		Source:           source,
+		Target:           target,
+		TargetSettings:   &b.Torrent,
+		RemoveFromSource: b.RemoveFromSource,
+		Destinations:     destinations,
+		ManagedTags:      managedTags,
+	})
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, report)
+}
+
+// HandleGetTorrentClientMigration
+//
+//	@summary returns the report of the torrent client migration.
+//	@desc It returns null if there is no migration to confirm.
+//	@route /api/v1/torrent-client/migration [GET]
+//	@returns client_migration.Report
+func (h *Handler) HandleGetTorrentClientMigration(c echo.Context) error {
+	report, err := h.App.TorrentClientMigrator.GetReport()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, report)
+}
+
+// HandleConfirmTorrentClientMigration
+//
+//	@summary confirms the report of the torrent client migration and switches to the target client.
+//	@desc The torrent settings sent when the migration was started are saved and the modules are refreshed.
+//	@desc It fails if the migration is running or if some torrents have not been processed.
+//	@desc The client should re-fetch the server status after this.
+//	@route /api/v1/torrent-client/migration/confirm [POST]
+//	@returns handlers.Status
+func (h *Handler) HandleConfirmTorrentClientMigration(c echo.Context) error {
+	settings, err := h.App.Database.GetSettings()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	torrentSettings, err := h.App.TorrentClientMigrator.Confirm()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	updated := *settings
+	updated.Torrent = torrentSettings
+	updated.UpdatedAt = time.Now()
+	settings, err = h.App.Database.UpsertSettings(&updated)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.WSEventManager.SendEvent("settings", settings)
+
+	status := h.NewStatus(c)
+
+	// Refresh modules that depend on the settings
+	h.App.InitOrRefreshModules()
+
+	return h.RespondWithData(c, status)
+}
+
+// HandleDiscardTorrentClientMigration
+//
+//	@summary discards the torrent client migration without switching clients.
+//	@desc The torrents already added to the target client are kept.
+//	@route /api/v1/torrent-client/migration [DELETE]
+//	@returns bool
+func (h *Handler) HandleDiscardTorrentClientMigration(c echo.Context) error {
+	if err := h.App.TorrentClientMigrator.Discard(); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
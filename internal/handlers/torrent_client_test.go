@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/torrent_client"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMediaDownloadingStatus(t *testing.T) {
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, nil)
+	preMatches := []*models.TorrentPreMatch{
+		{Destination: "/downloads/Anime", MediaId: 1},
+		{Destination: "/downloads/Anime 2", MediaId: 2},
+	}
+
+	// "/downloads/Anime 2" starts with "/downloads/Anime" but is not inside it
+	torrents := []*torrent_client.Torrent{
+		{ContentPath: "/downloads/Anime 2/Episode 1.mkv", Status: torrent_client.TorrentStatusDownloading, Progress: 0.5},
+		{ContentPath: "/downloads/Other/Episode 1.mkv", Status: torrent_client.TorrentStatusDownloading},
+	}
+
+	result := getMediaDownloadingStatus(torrents, preMatches, translator)
+	require.Len(t, result, 1)
+	assert.Equal(t, 2, result[0].MediaId)
+	assert.Equal(t, 0.5, result[0].Progress)
+
+	torrents = append(torrents, &torrent_client.Torrent{ContentPath: "/downloads/Anime", Status: torrent_client.TorrentStatusSeeding})
+	result = getMediaDownloadingStatus(torrents, preMatches, translator)
+	require.Len(t, result, 2)
+	assert.Equal(t, 1, result[1].MediaId)
+}
+
+func TestGetMediaDownloadingStatus_WindowsClient(t *testing.T) {
+	// Linux server, qBittorrent running on Windows
+	translator := clientpath.NewTranslator(clientpath.StyleWindows, clientpath.StylePosix, []clientpath.Mapping{
+		{ClientPrefix: `D:\Anime`, ServerPrefix: "/mnt/anime"},
+	})
+
+	dest, err := translator.ResolveDestination("/mnt/anime/Show")
+	require.NoError(t, err)
+	assert.Equal(t, `D:\Anime\Show`, dest)
+
+	preMatches := []*models.TorrentPreMatch{
+		{Destination: translator.Canonical(dest), MediaId: 1},
+		{Destination: translator.Canonical(`E:\Other`), MediaId: 2},
+	}
+	assert.Equal(t, "/mnt/anime/Show", preMatches[0].Destination)
+
+	torrents := []*torrent_client.Torrent{
+		{ContentPath: `D:\ANIME\Show\Episode 1.mkv`, Status: torrent_client.TorrentStatusDownloading},
+		{ContentPath: `E:\OTHER\Episode 1.mkv`, Status: torrent_client.TorrentStatusSeeding},
+	}
+
+	result := getMediaDownloadingStatus(torrents, preMatches, translator)
+	require.Len(t, result, 2)
+	assert.Equal(t, 1, result[0].MediaId)
+	assert.Equal(t, 2, result[1].MediaId)
+}
+
+func TestGetMediaDownloadingStatus_LinuxClient(t *testing.T) {
+	// Windows server, qBittorrent running in Docker
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StyleWindows, []clientpath.Mapping{
+		{ClientPrefix: "/downloads", ServerPrefix: `\\nas\downloads`},
+	})
+
+	dest, err := translator.ResolveDestination(`\\NAS\Downloads\Show`)
+	require.NoError(t, err)
+	assert.Equal(t, "/downloads/Show", dest)
+
+	preMatches := []*models.TorrentPreMatch{
+		{Destination: translator.Canonical(dest), MediaId: 1},
+	}
+
+	torrents := []*torrent_client.Torrent{
+		{ContentPath: "/downloads/Show/Episode 1.mkv", Status: torrent_client.TorrentStatusDownloading},
+		{ContentPath: "/downloads/Show 2/Episode 1.mkv", Status: torrent_client.TorrentStatusDownloading},
+	}
+
+	result := getMediaDownloadingStatus(torrents, preMatches, translator)
+	require.Len(t, result, 1)
+	assert.Equal(t, 1, result[0].MediaId)
+}
+
+func TestIsInLibraryPaths(t *testing.T) {
+	libraryPaths := []string{"/mnt/anime", ""}
+
+	assert.True(t, isInLibraryPaths(libraryPaths, "/mnt/anime"))
+	assert.True(t, isInLibraryPaths(libraryPaths, "/mnt/anime/Frieren"))
+	assert.False(t, isInLibraryPaths(libraryPaths, "/mnt/anime2/Frieren"))
+	assert.False(t, isInLibraryPaths(libraryPaths, "/downloads"))
+}
+
+func TestGetTorrentDownloadResults(t *testing.T) {
+	torrents := map[string]*torrent_client.Torrent{
+		"aaaa": {Name: "[Group] Show - 01", Hash: "aaaa"},
+		// The metadata was not resolved yet
+		"bbbb": {Name: "bbbb", Hash: "bbbb"},
+		"cccc": {Name: "N/A", Hash: "cccc"},
+	}
+	getTorrent := func(hash string) (*torrent_client.Torrent, error) {
+		if t, ok := torrents[hash]; ok {
+			return t, nil
+		}
+		return nil, torrent_client.ErrTorrentNotFound
+	}
+
+	res := getTorrentDownloadResults([]string{"aaaa", "bbbb", "cccc", "dddd"}, getTorrent, time.Second)
+	assert.Equal(t, []*TorrentClientDownloadResult{
+		{InfoHash: "aaaa", Name: "[Group] Show - 01", NameResolved: true},
+		{InfoHash: "bbbb"},
+		{InfoHash: "cccc"},
+		{InfoHash: "dddd"},
+	}, res)
+
+	// The torrent client does not respond in time
+	slow := func(hash string) (*torrent_client.Torrent, error) {
+		time.Sleep(time.Second)
+		return getTorrent(hash)
+	}
+	res = getTorrentDownloadResults([]string{"aaaa"}, slow, 10*time.Millisecond)
+	assert.Equal(t, []*TorrentClientDownloadResult{{InfoHash: "aaaa"}}, res)
+}
+
+func TestGroupTorrentsByMedia(t *testing.T) {
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, nil)
+	preMatches := []*models.TorrentPreMatch{
+		{Destination: "/downloads/Anime", MediaId: 1},
+		{Destination: "/downloads/Anime/Season 2", MediaId: 2},
+	}
+
+	torrents := []*torrent_client.Torrent{
+		{Hash: "a", ContentPath: "/downloads/Anime/Episode 1.mkv", Progress: 1},
+		{Hash: "b", ContentPath: "/downloads/Other/Episode 1.mkv"},
+		{Hash: "c", ContentPath: "/downloads/Anime/Season 2/Episode 1.mkv", Progress: 0.2},
+		{Hash: "d", ContentPath: "/downloads/Anime/Episode 2.mkv", Progress: 0.5},
+	}
+
+	res := groupTorrentsByMedia(torrents, preMatches, translator, func(mediaId int) string {
+		if mediaId == 1 {
+			return "Anime"
+		}
+		return ""
+	})
+
+	require.Len(t, res.Groups, 2)
+	assert.Equal(t, 1, res.Groups[0].MediaId)
+	assert.Equal(t, "Anime", res.Groups[0].MediaTitle)
+	assert.Equal(t, []string{"a", "d"}, lo.Map(res.Groups[0].Torrents, func(t *torrent_client.Torrent, _ int) string { return t.Hash }))
+	assert.Equal(t, 0.75, res.Groups[0].AggregateProgress)
+
+	// The most specific pre-match is used
+	assert.Equal(t, 2, res.Groups[1].MediaId)
+	assert.Empty(t, res.Groups[1].MediaTitle)
+	require.Len(t, res.Groups[1].Torrents, 1)
+	assert.Equal(t, 0.2, res.Groups[1].AggregateProgress)
+
+	require.Len(t, res.Other, 1)
+	assert.Equal(t, "b", res.Other[0].Hash)
+}
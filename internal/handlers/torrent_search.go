@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"seanime/internal/api/anilist"
 	"seanime/internal/debrid/debrid"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/util/result"
 	"strings"
@@ -12,29 +14,39 @@ import (
 
 var debridInstantAvailabilityCache = result.NewCache[string, map[string]debrid.TorrentItemInstantAvailability]()
 
+// SearchTorrentBody is the request body of HandleSearchTorrent.
+type SearchTorrentBody struct {
+	// "smart" or "simple"
+	Type           string            `json:"type,omitempty"`
+	Provider       string            `json:"provider,omitempty"`
+	Query          string            `json:"query,omitempty"`
+	EpisodeNumber  int               `json:"episodeNumber,omitempty"`
+	Batch          bool              `json:"batch,omitempty"`
+	Media          anilist.BaseAnime `json:"media,omitempty"`
+	AbsoluteOffset int               `json:"absoluteOffset,omitempty"`
+	Resolution     string            `json:"resolution,omitempty"`
+	BestRelease    bool              `json:"bestRelease,omitempty"`
+	// Cursor returned by the previous search to load more results
+	Cursor string `json:"cursor,omitempty"`
+	// HideSeen removes the results dismissed or downloaded by the user
+	HideSeen bool `json:"hideSeen,omitempty"`
+}
+
 // HandleSearchTorrent
 //
 //	@summary searches torrents and returns a list of torrents and their previews.
 //	@desc This will search for torrents and return a list of torrents with previews.
 //	@desc If smart search is enabled, it will filter the torrents based on search parameters.
+//	@desc If the provider supports pagination, "nextCursor" can be sent back as "cursor" to load more results.
+//	@desc The results of the previous pages are included in the response.
+//	@desc If "hideSeen" is true, the results dismissed or downloaded by the user are removed.
+//	@desc The results shown to the user in a previous search are listed in "previouslySeen".
 //	@route /api/v1/torrent/search [POST]
+//	@body SearchTorrentBody
 //	@returns torrent.SearchData
 func (h *Handler) HandleSearchTorrent(c echo.Context) error {
 
-	type body struct {
-		// "smart" or "simple"
-		Type           string            `json:"type,omitempty"`
-		Provider       string            `json:"provider,omitempty"`
-		Query          string            `json:"query,omitempty"`
-		EpisodeNumber  int               `json:"episodeNumber,omitempty"`
-		Batch          bool              `json:"batch,omitempty"`
-		Media          anilist.BaseAnime `json:"media,omitempty"`
-		AbsoluteOffset int               `json:"absoluteOffset,omitempty"`
-		Resolution     string            `json:"resolution,omitempty"`
-		BestRelease    bool              `json:"bestRelease,omitempty"`
-	}
-
-	var b body
+	var b SearchTorrentBody
 	if err := c.Bind(&b); err != nil {
 		return h.RespondWithError(c, err)
 	}
@@ -48,34 +60,110 @@ func (h *Handler) HandleSearchTorrent(c echo.Context) error {
 		EpisodeNumber: b.EpisodeNumber,
 		BestReleases:  b.BestRelease,
 		Resolution:    b.Resolution,
+		Cursor:        b.Cursor,
 	})
 	if err != nil {
 		return h.RespondWithError(c, err)
 	}
 
-	//
-	// Debrid torrent instant availability
-	//
-	if h.App.SecondarySettings.Debrid.Enabled {
-		hashes := make([]string, 0)
-		for _, t := range data.Torrents {
-			if t.InfoHash == "" {
-				continue
-			}
-			hashes = append(hashes, t.InfoHash)
+	h.setDebridInstantAvailability(data)
+	h.App.TorrentHistory.Annotate(h.App.GetUIStateOwner(GetSessionID(c)).ID, data, b.HideSeen)
+
+	return h.RespondWithData(c, data)
+}
+
+// HandleDismissTorrentResult
+//
+//	@summary dismisses a torrent search result.
+//	@desc The result is removed from the searches of the current user that set "hideSeen".
+//	@desc Results are identified by their info hash, or by their provider and link when the hash is unknown.
+//	@route /api/v1/torrent/results/dismiss [POST]
+//	@returns models.TorrentResultHistory
+func (h *Handler) HandleDismissTorrentResult(c echo.Context) error {
+
+	type body struct {
+		Torrent hibiketorrent.AnimeTorrent `json:"torrent"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if b.Torrent.InfoHash == "" && b.Torrent.Link == "" {
+		return h.RespondWithError(c, errors.New("the torrent has no info hash or link"))
+	}
+
+	entry, err := h.App.TorrentHistory.Dismiss(h.App.GetUIStateOwner(GetSessionID(c)).ID, &b.Torrent)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, entry)
+}
+
+// HandleGetDismissedTorrentResults
+//
+//	@summary returns the torrent search results dismissed by the current user.
+//	@desc The most recently dismissed results are returned first.
+//	@route /api/v1/torrent/results/dismissed [GET]
+//	@returns []models.TorrentResultHistory
+func (h *Handler) HandleGetDismissedTorrentResults(c echo.Context) error {
+	entries, err := h.App.TorrentHistory.GetDismissed(h.App.GetUIStateOwner(GetSessionID(c)).ID)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, entries)
+}
+
+// HandleUndismissTorrentResult
+//
+//	@summary shows a dismissed torrent search result again.
+//	@desc It returns 'false' if the result was not dismissed.
+//	@route /api/v1/torrent/results/dismissed [DELETE]
+//	@returns bool
+func (h *Handler) HandleUndismissTorrentResult(c echo.Context) error {
+
+	type body struct {
+		Key string `json:"key"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	undismissed, err := h.App.TorrentHistory.Undismiss(h.App.GetUIStateOwner(GetSessionID(c)).ID, b.Key)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, undismissed)
+}
+
+// setDebridInstantAvailability sets the debrid instant availability of the torrents if debrid is enabled.
+func (h *Handler) setDebridInstantAvailability(data *torrent.SearchData) {
+	if !h.App.SecondarySettings.Debrid.Enabled {
+		return
+	}
+
+	hashes := make([]string, 0)
+	for _, t := range data.Torrents {
+		if t.InfoHash == "" {
+			continue
 		}
-		hashesKey := strings.Join(hashes, ",")
-		var found bool
-		data.DebridInstantAvailability, found = debridInstantAvailabilityCache.Get(hashesKey)
-		if !found {
-			provider, err := h.App.DebridClientRepository.GetProvider()
-			if err == nil {
-				instantAvail := provider.GetInstantAvailability(hashes)
-				data.DebridInstantAvailability = instantAvail
-				debridInstantAvailabilityCache.Set(hashesKey, instantAvail)
-			}
+		hashes = append(hashes, t.InfoHash)
+	}
+	hashesKey := strings.Join(hashes, ",")
+	var found bool
+	data.DebridInstantAvailability, found = debridInstantAvailabilityCache.Get(hashesKey)
+	if !found {
+		provider, err := h.App.DebridClientRepository.GetProvider()
+		if err == nil {
+			instantAvail := provider.GetInstantAvailability(hashes)
+			data.DebridInstantAvailability = instantAvail
+			debridInstantAvailabilityCache.Set(hashesKey, instantAvail)
 		}
 	}
-
-	return h.RespondWithData(c, data)
 }
@@ -123,6 +123,7 @@ func (h *Handler) HandleGetTorrentstreamTorrentFilePreviews(c echo.Context) erro
 //
 //	@summary starts a torrent stream.
 //	@desc This starts the entire streaming process.
+//	@desc When 'incognito' is true, playback with the media player does not update the AniList progress, the watch history or the Discord presence.
 //	@returns bool
 //	@route /api/v1/torrentstream/start [POST]
 func (h *Handler) HandleTorrentstreamStartStream(c echo.Context) error {
@@ -137,6 +138,7 @@ func (h *Handler) HandleTorrentstreamStartStream(c echo.Context) error {
 		PlaybackType      torrentstream.PlaybackType       `json:"playbackType"` // "default" or "externalPlayerLink"
 		ClientId          string                           `json:"clientId"`
 		BatchEpisodeFiles *hibiketorrent.BatchEpisodeFiles `json:"batchEpisodeFiles,omitempty"`
+		Incognito         bool                             `json:"incognito"`
 	}
 
 	var b body
@@ -162,6 +164,7 @@ func (h *Handler) HandleTorrentstreamStartStream(c echo.Context) error {
 		ClientId:          b.ClientId,
 		PlaybackType:      b.PlaybackType,
 		BatchEpisodeFiles: b.BatchEpisodeFiles,
+		Incognito:         b.Incognito,
 	})
 	if err != nil {
 		return h.RespondWithError(c, err)
@@ -204,6 +207,63 @@ func (h *Handler) HandleTorrentstreamDropTorrent(c echo.Context) error {
 	return h.RespondWithData(c, true)
 }
 
+// HandleTorrentstreamPersistStream
+//
+//	@summary copies the last streamed episode to the library.
+//	@desc This copies the file of the last stream from the download directory to the destination, it must have been fully downloaded.
+//	@desc If no destination is provided, it is resolved from the storage placement rules of the media.
+//	@desc The pre-match, torrent history and collection are updated as with a torrent client download so that the file is matched when scanned.
+//	@route /api/v1/torrentstream/persist [POST]
+//	@returns torrentstream.PersistedStreamFile
+func (h *Handler) HandleTorrentstreamPersistStream(c echo.Context) error {
+
+	type body struct {
+		Destination string `json:"destination"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	session, ok := h.App.TorrentstreamRepository.GetLastStreamSession()
+	if !ok {
+		return h.RespondWithError(c, torrentstream.ErrNoStreamSession)
+	}
+
+	// Resolve the destination from the storage placement rules if the client did not provide one
+	if b.Destination == "" {
+		media, err := h.App.AnilistPlatformRef.Get().GetAnime(c.Request().Context(), session.MediaId)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		res, err := h.resolveStoragePlacement(media, 0)
+		if err != nil {
+			return h.RespondWithError(c, err)
+		}
+		b.Destination = res.Destination
+	}
+
+	if b.Destination == "" {
+		return h.RespondWithError(c, errors.New("destination not found"))
+	}
+
+	persisted, err := h.App.TorrentstreamRepository.PersistLastStream(b.Destination)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	// The file is copied by Seanime, the destination is a path of the server
+	_, _ = h.recordDownloadIntent(c, &downloadIntent{
+		Owner:       h.App.GetUIStateOwner(GetSessionID(c)).ID,
+		MediaId:     session.MediaId,
+		Destination: b.Destination,
+		Torrents:    []hibiketorrent.AnimeTorrent{*session.Torrent},
+	})
+
+	return h.RespondWithData(c, persisted)
+}
+
 // HandleGetTorrentstreamBatchHistory
 //
 //	@summary returns the most recent batch selected.
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"seanime/internal/uistate"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetUIState
+//
+//	@summary returns the UI state of the current user.
+//	@desc Authenticated users share their state across sessions, simulated users have a state scoped to their session.
+//	@desc If the "key" query parameter is set, only that key is returned, or null if it does not exist.
+//	@route /api/v1/ui-state [GET]
+//	@returns []uistate.Entry
+func (h *Handler) HandleGetUIState(c echo.Context) error {
+	owner := h.App.GetUIStateOwner(GetSessionID(c))
+
+	if key := c.QueryParam("key"); key != "" {
+		entry, found := h.App.UIStateStore.Get(owner, key)
+		if !found {
+			return h.RespondWithData(c, nil)
+		}
+		return h.RespondWithData(c, entry)
+	}
+
+	return h.RespondWithData(c, h.App.UIStateStore.GetAll(owner))
+}
+
+// HandleGetUIStateUsage
+//
+//	@summary returns the space used by the UI state of the current user.
+//	@route /api/v1/ui-state/usage [GET]
+//	@returns uistate.Usage
+func (h *Handler) HandleGetUIStateUsage(c echo.Context) error {
+	owner := h.App.GetUIStateOwner(GetSessionID(c))
+	return h.RespondWithData(c, h.App.UIStateStore.GetUsage(owner))
+}
+
+// HandleSetUIState
+//
+//	@summary sets a key of the UI state of the current user.
+//	@desc The value can be any JSON value.
+//	@desc If "baseUpdatedAt" is set and the key was modified after it, the key is not modified and the current entry is returned in the error response.
+//	@desc The other clients of the user are notified of the change.
+//	@route /api/v1/ui-state [POST]
+//	@returns uistate.Entry
+func (h *Handler) HandleSetUIState(c echo.Context) error {
+
+	type body struct {
+		Key           string          `json:"key"`
+		Value         json.RawMessage `json:"value"`
+		BaseUpdatedAt *time.Time      `json:"baseUpdatedAt,omitempty"`
+		ClientId      string          `json:"clientId"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	owner := h.App.GetUIStateOwner(GetSessionID(c))
+
+	entry, err := h.App.UIStateStore.Set(owner, b.Key, b.Value, b.BaseUpdatedAt)
+	if err != nil {
+		if errors.Is(err, uistate.ErrConflict) {
+			return c.JSON(http.StatusConflict, SeaResponse[*uistate.Entry]{
+				Error: err.Error(),
+				Data:  entry,
+			})
+		}
+		return h.RespondWithError(c, err)
+	}
+
+	h.App.BroadcastUIStateChange(owner, b.ClientId, &uistate.Change{
+		Key:       entry.Key,
+		Value:     entry.Value,
+		UpdatedAt: entry.UpdatedAt,
+	})
+
+	return h.RespondWithData(c, entry)
+}
+
+// HandleDeleteUIState
+//
+//	@summary deletes a key of the UI state of the current user.
+//	@desc The other clients of the user are notified of the change.
+//	@route /api/v1/ui-state [DELETE]
+//	@returns bool
+func (h *Handler) HandleDeleteUIState(c echo.Context) error {
+
+	type body struct {
+		Key      string `json:"key"`
+		ClientId string `json:"clientId"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	owner := h.App.GetUIStateOwner(GetSessionID(c))
+
+	deleted, err := h.App.UIStateStore.Delete(owner, b.Key)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	if deleted {
+		h.App.BroadcastUIStateChange(owner, b.ClientId, &uistate.Change{
+			Key:       b.Key,
+			Deleted:   true,
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	return h.RespondWithData(c, deleted)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/webhooks"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetWebhooks
+//
+//	@summary returns the webhook subscriptions.
+//	@desc The secret of each subscription is used to verify the signature of the events it receives.
+//	@desc Only accessible from the local machine, or by authenticated clients when a server password is set.
+//	@route /api/v1/webhooks [GET]
+//	@returns []models.WebhookSubscription
+func (h *Handler) HandleGetWebhooks(c echo.Context) error {
+	subs, err := h.App.Webhooks.GetSubscriptions()
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, subs)
+}
+
+// HandleCreateWebhook
+//
+//	@summary creates a webhook subscription.
+//	@desc The events of the selected types are sent to the URL with a POST request.
+//	@desc A secret is generated to sign the events, the signature is sent in the 'X-Seanime-Signature' header.
+//	@desc It returns the created subscription.
+//	@route /api/v1/webhooks [POST]
+//	@returns models.WebhookSubscription
+func (h *Handler) HandleCreateWebhook(c echo.Context) error {
+
+	type body struct {
+		URL        string               `json:"url"`
+		EventTypes []webhooks.EventType `json:"eventTypes"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	sub, err := h.App.Webhooks.CreateSubscription(b.URL, b.EventTypes)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, sub)
+}
+
+// HandleUpdateWebhook
+//
+//	@summary updates a webhook subscription.
+//	@desc Re-enabling a subscription that was disabled after too many failures resets its failures.
+//	@desc It returns the updated subscription.
+//	@route /api/v1/webhooks/{id} [PATCH]
+//	@param id - int - true - "The id of the subscription"
+//	@returns models.WebhookSubscription
+func (h *Handler) HandleUpdateWebhook(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	type body struct {
+		URL        string               `json:"url"`
+		EventTypes []webhooks.EventType `json:"eventTypes"`
+		Enabled    bool                 `json:"enabled"`
+	}
+
+	var b body
+	if err := c.Bind(&b); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	sub, err := h.App.Webhooks.UpdateSubscription(uint(id), b.URL, b.EventTypes, b.Enabled)
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, sub)
+}
+
+// HandleDeleteWebhook
+//
+//	@summary deletes a webhook subscription and its delivery history.
+//	@route /api/v1/webhooks/{id} [DELETE]
+//	@param id - int - true - "The id of the subscription"
+//	@returns bool
+func (h *Handler) HandleDeleteWebhook(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	if err := h.App.Webhooks.DeleteSubscription(uint(id)); err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, true)
+}
+
+// HandleGetWebhookDeliveries
+//
+//	@summary returns the last delivery attempts of a webhook subscription.
+//	@desc The most recent attempts are returned first.
+//	@route /api/v1/webhooks/{id}/deliveries [GET]
+//	@param id - int - true - "The id of the subscription"
+//	@returns []models.WebhookDelivery
+func (h *Handler) HandleGetWebhookDeliveries(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return h.RespondWithError(c, errors.New("invalid id"))
+	}
+
+	deliveries, err := h.App.Webhooks.GetDeliveries(uint(id))
+	if err != nil {
+		return h.RespondWithError(c, err)
+	}
+
+	return h.RespondWithData(c, deliveries)
+}
@@ -35,6 +35,12 @@ func (h *Handler) webSocketEventHandler(c echo.Context) error {
 	h.App.WSEventManager.AddConn(id, ws)
 	h.App.Logger.Debug().Str("id", id).Msg("ws: Client connected")
 
+	// Associate the connection with the session so that session-specific events can be sent to it
+	if cookie, err := c.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		h.App.SessionStore.RegisterClient(id, cookie.Value)
+		defer h.App.SessionStore.UnregisterClient(id)
+	}
+
 	for {
 		_, msg, err := ws.ReadMessage()
 		if err != nil {
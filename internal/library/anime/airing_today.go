@@ -0,0 +1,127 @@
+package anime
+
+import (
+	"seanime/internal/api/anilist"
+	"sort"
+	"time"
+)
+
+const (
+	AiringTodayNotAired    AiringTodayStatus = "not-aired"
+	AiringTodayNotGrabbed  AiringTodayStatus = "aired-not-grabbed"
+	AiringTodayDownloading AiringTodayStatus = "downloading"
+	AiringTodayReady       AiringTodayStatus = "ready"
+)
+
+type (
+	// AiringTodayStatus is the availability of an episode airing today.
+	AiringTodayStatus string
+
+	// AiringTodayItem is an episode of the user's current list that airs today.
+	AiringTodayItem struct {
+		MediaId       int    `json:"mediaId"`
+		Title         string `json:"title"`
+		Image         string `json:"image"`
+		EpisodeNumber int    `json:"episodeNumber"`
+		// AiringAt is in the timezone of the user
+		AiringAt time.Time `json:"airingAt"`
+		// Time is in 15:04 format, in the timezone of the user
+		Time   string            `json:"time"`
+		Status AiringTodayStatus `json:"status"`
+		// Prediction is when the episode is expected to be released, nil if there is not enough history
+		Prediction *ReleasePrediction `json:"prediction,omitempty"`
+		// IsLate is true if the episode has not been grabbed after the time its release is usually available
+		IsLate bool `json:"isLate"`
+	}
+
+	// ReleasePrediction is the predicted availability of an episode, based on the past releases of the media.
+	ReleasePrediction struct {
+		// ReleaseGroup is the release group that usually releases the episodes first
+		ReleaseGroup string    `json:"releaseGroup"`
+		ExpectedAt   time.Time `json:"expectedAt"`
+		// LateAt is the time after which the release is late relative to the history of the release group
+		LateAt time.Time `json:"lateAt"`
+	}
+
+	NewAiringTodayOptions struct {
+		ScheduleItems   []*ScheduleItem
+		AnimeCollection *anilist.AnimeCollection
+		LocalFiles      []*LocalFile
+		// GrabbedEpisodes are the episodes that have been downloaded, by media ID
+		GrabbedEpisodes map[int]map[int]bool
+		// DownloadingMediaIds are the media that have a torrent downloading
+		DownloadingMediaIds map[int]bool
+		Now                 time.Time
+		// Location is the timezone of the user, UTC is used if it is nil
+		Location *time.Location
+		// PredictRelease returns the predicted availability of an episode, optional
+		PredictRelease func(mediaId int, airingAt time.Time) *ReleasePrediction
+	}
+)
+
+// NewAiringToday returns the episodes of the user's current list that air between the start of the day and the next 24 hours,
+// in the timezone of the user, with their availability.
+func NewAiringToday(opts *NewAiringTodayOptions) []*AiringTodayItem {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := opts.Now.In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	end := now.Add(24 * time.Hour)
+
+	libraryEntries := joinLibraryEntries(opts.AnimeCollection, opts.LocalFiles, anilist.MediaListStatusCurrent)
+
+	ret := make([]*AiringTodayItem, 0)
+	for _, item := range opts.ScheduleItems {
+		if item == nil || item.DateTime.Before(startOfDay) || item.DateTime.After(end) {
+			continue
+		}
+		le, ok := libraryEntries[item.MediaId]
+		if !ok {
+			continue
+		}
+
+		airingAt := item.DateTime.In(loc)
+		todayItem := &AiringTodayItem{
+			MediaId:       item.MediaId,
+			Title:         item.Title,
+			Image:         item.Image,
+			EpisodeNumber: item.EpisodeNumber,
+			AiringAt:      airingAt,
+			Time:          airingAt.Format("15:04"),
+			Status:        getAiringTodayStatus(item, le, now, opts),
+		}
+		if opts.PredictRelease != nil {
+			if prediction := opts.PredictRelease(item.MediaId, item.DateTime); prediction != nil {
+				prediction.ExpectedAt = prediction.ExpectedAt.In(loc)
+				prediction.LateAt = prediction.LateAt.In(loc)
+				todayItem.Prediction = prediction
+				todayItem.IsLate = todayItem.Status == AiringTodayNotGrabbed && now.After(prediction.LateAt)
+			}
+		}
+		ret = append(ret, todayItem)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].AiringAt.Before(ret[j].AiringAt)
+	})
+
+	return ret
+}
+
+func getAiringTodayStatus(item *ScheduleItem, le *libraryEntry, now time.Time, opts *NewAiringTodayOptions) AiringTodayStatus {
+	if item.DateTime.After(now) {
+		return AiringTodayNotAired
+	}
+	for _, lf := range le.LocalFiles {
+		if lf.IsMain() && lf.GetEpisodeNumber() == item.EpisodeNumber {
+			return AiringTodayReady
+		}
+	}
+	// A grabbed episode that is not in the library yet is still being downloaded or scanned
+	if opts.GrabbedEpisodes[item.MediaId][item.EpisodeNumber] || opts.DownloadingMediaIds[item.MediaId] {
+		return AiringTodayDownloading
+	}
+	return AiringTodayNotGrabbed
+}
@@ -0,0 +1,90 @@
+package anime_test
+
+import (
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAiringToday(t *testing.T) {
+	newEntry := func(mId int, status anilist.MediaListStatus) *anilist.AnimeCollection_MediaListCollection_Lists_Entries {
+		return &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			Status: lo.ToPtr(status),
+			Media:  &anilist.BaseAnime{ID: mId},
+		}
+	}
+	collection := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{
+				{
+					Status: lo.ToPtr(anilist.MediaListStatusCurrent),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+						newEntry(1, anilist.MediaListStatusCurrent),
+						newEntry(2, anilist.MediaListStatusCurrent),
+						newEntry(3, anilist.MediaListStatusCurrent),
+						newEntry(4, anilist.MediaListStatusCurrent),
+						newEntry(5, anilist.MediaListStatusCurrent),
+					},
+				},
+				{
+					Status:  lo.ToPtr(anilist.MediaListStatusPlanning),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{newEntry(6, anilist.MediaListStatusPlanning)},
+				},
+			},
+		},
+	}
+
+	// 01:30 in Tokyo, the previous day in UTC
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Date(2024, 4, 10, 16, 30, 0, 0, time.UTC)
+
+	scheduleItems := []*anime.ScheduleItem{
+		{MediaId: 1, EpisodeNumber: 5, DateTime: now.Add(2 * time.Hour)},
+		{MediaId: 2, EpisodeNumber: 3, DateTime: now.Add(-1 * time.Hour)},
+		{MediaId: 3, EpisodeNumber: 7, DateTime: now.Add(-1 * time.Hour)},
+		{MediaId: 4, EpisodeNumber: 2, DateTime: now.Add(-1 * time.Hour)},
+		{MediaId: 5, EpisodeNumber: 9, DateTime: now.Add(-1 * time.Hour)},
+		// Planning
+		{MediaId: 6, EpisodeNumber: 1, DateTime: now.Add(-1 * time.Hour)},
+		// Aired before the start of the day in Tokyo
+		{MediaId: 1, EpisodeNumber: 4, DateTime: now.Add(-3 * time.Hour)},
+	}
+
+	lfs := []*anime.LocalFile{
+		{MediaId: 2, Metadata: &anime.LocalFileMetadata{Episode: 3, Type: anime.LocalFileTypeMain}},
+		{MediaId: 5, Metadata: &anime.LocalFileMetadata{Episode: 9, Type: anime.LocalFileTypeSpecial}},
+	}
+
+	ret := anime.NewAiringToday(&anime.NewAiringTodayOptions{
+		ScheduleItems:       scheduleItems,
+		AnimeCollection:     collection,
+		LocalFiles:          lfs,
+		GrabbedEpisodes:     map[int]map[int]bool{3: {7: true}},
+		DownloadingMediaIds: map[int]bool{4: true},
+		Now:                 now,
+		Location:            tokyo,
+	})
+
+	statuses := make(map[int]anime.AiringTodayStatus)
+	for _, item := range ret {
+		statuses[item.MediaId] = item.Status
+	}
+	assert.Equal(t, map[int]anime.AiringTodayStatus{
+		1: anime.AiringTodayNotAired,
+		2: anime.AiringTodayReady,
+		3: anime.AiringTodayDownloading,
+		4: anime.AiringTodayDownloading,
+		5: anime.AiringTodayNotGrabbed,
+	}, statuses)
+
+	// Sorted by airing time, in the timezone of the user
+	require.Len(t, ret, 5)
+	assert.Equal(t, 1, ret[4].MediaId)
+	assert.Equal(t, "03:30", ret[4].Time)
+}
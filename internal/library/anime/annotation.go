@@ -0,0 +1,141 @@
+package anime
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAnnotationTag is returned when a tag contains a comma, which is used to store the tags.
+var ErrInvalidAnnotationTag = errors.New("tags cannot contain commas")
+
+type (
+	// EntryAnnotation is the local note and tags of a media.
+	// It is stored by Seanime and only sent to AniList on demand.
+	EntryAnnotation struct {
+		Note      string    `json:"note"`
+		Tags      []string  `json:"tags"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	// AnnotationTagCount is a tag used in the annotations and the number of media that have it.
+	AnnotationTagCount struct {
+		Tag   string `json:"tag"`
+		Count int    `json:"count"`
+	}
+
+	// LibraryCollectionFilter filters the entries of the library collection by their annotation.
+	LibraryCollectionFilter struct {
+		// Tags that the annotation must all have, case-insensitive
+		Tags []string
+		// MediaIds whose note matches the search query, nil if there is no query
+		NoteMatches map[int]bool
+	}
+)
+
+// NormalizeAnnotationTags trims the tags and removes the empty ones and the case-insensitive duplicates, keeping the first occurrence.
+func NormalizeAnnotationTags(tags []string) ([]string, error) {
+	ret := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if strings.Contains(tag, ",") {
+			return nil, ErrInvalidAnnotationTag
+		}
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ret = append(ret, tag)
+	}
+	return ret, nil
+}
+
+// CountAnnotationTags returns the tags of the annotations with the number of media that have them,
+// most used first. Tags that only differ in case are counted together under their first spelling.
+func CountAnnotationTags(annotations map[int]*EntryAnnotation) []*AnnotationTagCount {
+	counts := make(map[string]*AnnotationTagCount)
+	for _, annotation := range annotations {
+		if annotation == nil {
+			continue
+		}
+		for _, tag := range annotation.Tags {
+			key := strings.ToLower(tag)
+			if _, ok := counts[key]; !ok {
+				counts[key] = &AnnotationTagCount{Tag: tag}
+			}
+			counts[key].Count++
+		}
+	}
+
+	ret := make([]*AnnotationTagCount, 0, len(counts))
+	for _, count := range counts {
+		ret = append(ret, count)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return strings.ToLower(ret[i].Tag) < strings.ToLower(ret[j].Tag)
+	})
+	return ret
+}
+
+// HydrateAnnotations sets the annotation of the entries of the collection.
+func (lc *LibraryCollection) HydrateAnnotations(annotations map[int]*EntryAnnotation) {
+	if lc == nil {
+		return
+	}
+	for _, list := range lc.Lists {
+		for _, entry := range list.Entries {
+			entry.Annotation = annotations[entry.MediaId]
+		}
+	}
+}
+
+// FilterByAnnotation removes the entries of the lists that do not match the filter.
+// It should be called after HydrateAnnotations.
+func (lc *LibraryCollection) FilterByAnnotation(filter *LibraryCollectionFilter) {
+	if lc == nil || filter == nil {
+		return
+	}
+	for _, list := range lc.Lists {
+		entries := make([]*LibraryCollectionEntry, 0, len(list.Entries))
+		for _, entry := range list.Entries {
+			if filter.matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		list.Entries = entries
+	}
+}
+
+func (f *LibraryCollectionFilter) matches(entry *LibraryCollectionEntry) bool {
+	if f.NoteMatches != nil && !f.NoteMatches[entry.MediaId] {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	if entry.Annotation == nil {
+		return false
+	}
+	for _, tag := range f.Tags {
+		found := false
+		for _, t := range entry.Annotation.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package anime_test
+
+import (
+	"seanime/internal/library/anime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAnnotationTags(t *testing.T) {
+	tags, err := anime.NormalizeAnnotationTags([]string{" Gym playlist ", "", "gym PLAYLIST", "comfy"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Gym playlist", "comfy"}, tags)
+
+	_, err = anime.NormalizeAnnotationTags([]string{"a,b"})
+	assert.ErrorIs(t, err, anime.ErrInvalidAnnotationTag)
+}
+
+func TestLibraryCollection_FilterByAnnotation(t *testing.T) {
+	annotations := map[int]*anime.EntryAnnotation{
+		1: {Note: "rewatch", Tags: []string{"comfy", "Gym"}},
+		2: {Tags: []string{"comfy"}},
+	}
+	newCollection := func() *anime.LibraryCollection {
+		lc := &anime.LibraryCollection{
+			Lists: []*anime.LibraryCollectionList{
+				{Entries: []*anime.LibraryCollectionEntry{{MediaId: 1}, {MediaId: 2}, {MediaId: 3}}},
+			},
+		}
+		lc.HydrateAnnotations(annotations)
+		return lc
+	}
+	mediaIds := func(lc *anime.LibraryCollection) []int {
+		ret := make([]int, 0)
+		for _, entry := range lc.Lists[0].Entries {
+			ret = append(ret, entry.MediaId)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name     string
+		filter   *anime.LibraryCollectionFilter
+		expected []int
+	}{
+		{name: "no filter", filter: nil, expected: []int{1, 2, 3}},
+		{name: "one tag", filter: &anime.LibraryCollectionFilter{Tags: []string{"COMFY"}}, expected: []int{1, 2}},
+		{name: "all tags", filter: &anime.LibraryCollectionFilter{Tags: []string{"comfy", "gym"}}, expected: []int{1}},
+		{name: "note", filter: &anime.LibraryCollectionFilter{NoteMatches: map[int]bool{2: true}}, expected: []int{2}},
+		{name: "no note matches", filter: &anime.LibraryCollectionFilter{NoteMatches: map[int]bool{}}, expected: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := newCollection()
+			lc.FilterByAnnotation(tt.filter)
+			assert.Equal(t, tt.expected, mediaIds(lc))
+		})
+	}
+}
+
+func TestCountAnnotationTags(t *testing.T) {
+	counts := anime.CountAnnotationTags(map[int]*anime.EntryAnnotation{
+		1: {Tags: []string{"comfy", "gym"}},
+		2: {Tags: []string{"Comfy"}},
+		3: nil,
+	})
+	require.Len(t, counts, 2)
+	assert.Equal(t, 2, counts[0].Count)
+	assert.Equal(t, "gym", counts[1].Tag)
+	assert.Equal(t, 1, counts[1].Count)
+}
@@ -1,5 +1,10 @@
 package anime
 
+import (
+	torrent_audio "seanime/internal/torrents/audio"
+	"time"
+)
+
 // DEVNOTE: The structs are defined in this file because they are imported by both the autodownloader package and the db package.
 // Defining them in the autodownloader package would create a circular dependency because the db package imports these structs.
 
@@ -13,9 +18,24 @@ const (
 	AutoDownloaderRuleEpisodeSelected AutoDownloaderRuleEpisodeType = "selected"
 )
 
+const (
+	AutoDownloaderRuleHistoryRetargeted AutoDownloaderRuleHistoryEventType = "retargeted"
+	AutoDownloaderRuleHistoryCloned     AutoDownloaderRuleHistoryEventType = "cloned"
+)
+
+const (
+	// AutoDownloaderRuleModeDownload adds the matched torrents directly, even if the settings require confirmation.
+	// Rules without a mode follow the settings.
+	AutoDownloaderRuleModeDownload AutoDownloaderRuleMode = "download"
+	// AutoDownloaderRuleModeConfirm queues the matched torrents until the user approves or rejects them.
+	AutoDownloaderRuleModeConfirm AutoDownloaderRuleMode = "confirm"
+)
+
 type (
 	AutoDownloaderRuleTitleComparisonType string
 	AutoDownloaderRuleEpisodeType         string
+	AutoDownloaderRuleHistoryEventType    string
+	AutoDownloaderRuleMode                string
 
 	// AutoDownloaderRule is a rule that is used to automatically download media.
 	// The structs are sent to the client, thus adding `dbId` to facilitate mutations.
@@ -31,5 +51,55 @@ type (
 		EpisodeNumbers      []int                                 `json:"episodeNumbers,omitempty"`
 		Destination         string                                `json:"destination"`
 		AdditionalTerms     []string                              `json:"additionalTerms"`
+		// AudioPreference filters releases by their audio, it takes precedence over the media's preference.
+		AudioPreference torrent_audio.Preference `json:"audioPreference,omitempty"`
+		// Mode defines whether matched torrents are downloaded or wait for the user's confirmation.
+		Mode AutoDownloaderRuleMode `json:"mode,omitempty"`
+		// EpisodeOffset is added to the parsed episode number before it is compared with the episodes of the media. Defaults to 0.
+		//  - A positive offset maps the episodes of a second cour numbered from 1 to the numbering of the media, e.g. 13 for a cour starting at episode 14.
+		//  - A negative offset is only applied to absolute episode numbers when the metadata provider does not know the offset.
+		//    It is set when the rule is retargeted to a sequel that release groups number continuously.
+		EpisodeOffset int                               `json:"episodeOffset,omitempty"`
+		History       []*AutoDownloaderRuleHistoryEvent `json:"history,omitempty"`
+	}
+
+	// AutoDownloaderRuleHistoryEvent records a change made to a rule by the AutoDownloader.
+	AutoDownloaderRuleHistoryEvent struct {
+		Type          AutoDownloaderRuleHistoryEventType `json:"type"`
+		FromMediaId   int                                `json:"fromMediaId"`
+		ToMediaId     int                                `json:"toMediaId"`
+		EpisodeOffset int                                `json:"episodeOffset"`
+		Automatic     bool                               `json:"automatic"`
+		Date          time.Time                          `json:"date"`
 	}
 )
+
+// IsRetargeted returns true if the rule was retargeted or cloned from another media.
+func (r *AutoDownloaderRule) IsRetargeted() bool {
+	return len(r.History) > 0
+}
+
+// IsValid returns true if the mode is known. An empty mode follows the settings.
+func (m AutoDownloaderRuleMode) IsValid() bool {
+	switch m {
+	case "", AutoDownloaderRuleModeDownload, AutoDownloaderRuleModeConfirm:
+		return true
+	}
+	return false
+}
+
+// NormalizeEpisodeOffset converts the offset of the rules retargeted before the offset was added to the episode number.
+// These rules stored the number of episodes of the prequels as a positive offset that was subtracted.
+func (r *AutoDownloaderRule) NormalizeEpisodeOffset() {
+	if r.EpisodeOffset <= 0 || len(r.History) == 0 {
+		return
+	}
+	if last := r.History[len(r.History)-1]; last.EpisodeOffset == r.EpisodeOffset {
+		r.EpisodeOffset = -r.EpisodeOffset
+		for _, event := range r.History {
+			if event.EpisodeOffset > 0 {
+				event.EpisodeOffset = -event.EpisodeOffset
+			}
+		}
+	}
+}
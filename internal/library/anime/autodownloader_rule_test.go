@@ -0,0 +1,53 @@
+package anime_test
+
+import (
+	"seanime/internal/library/anime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoDownloaderRule_NormalizeEpisodeOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     *anime.AutoDownloaderRule
+		expected int
+	}{
+		{
+			name:     "cour offset",
+			rule:     &anime.AutoDownloaderRule{EpisodeOffset: 13},
+			expected: 13,
+		},
+		{
+			name: "retargeted before the offset was signed",
+			rule: &anime.AutoDownloaderRule{EpisodeOffset: 12, History: []*anime.AutoDownloaderRuleHistoryEvent{
+				{EpisodeOffset: 12},
+			}},
+			expected: -12,
+		},
+		{
+			name: "retargeted",
+			rule: &anime.AutoDownloaderRule{EpisodeOffset: -12, History: []*anime.AutoDownloaderRuleHistoryEvent{
+				{EpisodeOffset: -12},
+			}},
+			expected: -12,
+		},
+		{
+			name: "cour offset set after the rule was retargeted",
+			rule: &anime.AutoDownloaderRule{EpisodeOffset: 13, History: []*anime.AutoDownloaderRuleHistoryEvent{
+				{EpisodeOffset: -12},
+			}},
+			expected: 13,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.NormalizeEpisodeOffset()
+			assert.Equal(t, tt.expected, tt.rule.EpisodeOffset)
+			for _, event := range tt.rule.History {
+				assert.LessOrEqual(t, event.EpisodeOffset, 0)
+			}
+		})
+	}
+}
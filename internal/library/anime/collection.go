@@ -68,6 +68,7 @@ type (
 		EntryLibraryData       *EntryLibraryData       `json:"libraryData"`                 // Library data
 		NakamaEntryLibraryData *NakamaEntryLibraryData `json:"nakamaLibraryData,omitempty"` // Library data from Nakama
 		EntryListData          *EntryListData          `json:"listData"`                    // AniList list data
+		Annotation             *EntryAnnotation        `json:"annotation,omitempty"`        // Local note and tags, hydrated by the route handler
 	}
 
 	// UnmatchedGroup holds the data for a group of unmatched local files.
@@ -5,6 +5,7 @@ import (
 	"seanime/internal/api/metadata"
 	"seanime/internal/library/anime"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/test_utils"
 	"seanime/internal/util"
 	"testing"
@@ -21,7 +22,7 @@ func TestNewLibraryCollection(t *testing.T) {
 	anilistClient := anilist.TestGetMockAnilistClient()
 	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistClient, logger)
 
-	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), false)
+	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), platform.CachedCollection)
 
 	if assert.NoError(t, err) {
 
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"seanime/internal/api/anilist"
+	"seanime/internal/api/animethemes"
 	"seanime/internal/api/metadata"
 	"seanime/internal/api/metadata_provider"
 	"seanime/internal/hook"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/syncstatus"
 	"seanime/internal/util"
 	"sort"
 
@@ -32,6 +34,16 @@ type (
 
 		IsNakamaEntry     bool                    `json:"_isNakamaEntry"`
 		NakamaLibraryData *NakamaEntryLibraryData `json:"nakamaLibraryData,omitempty"`
+
+		// SyncState is set when the user has a pending or failed AniList update for this media
+		SyncState *syncstatus.MediaState `json:"syncState,omitempty"`
+
+		// Themes holds the openings and endings of the media, nil if they are not available
+		Themes []*animethemes.Theme `json:"themes,omitempty"`
+		// SavedTorrentSearches are the saved torrent searches of the media
+		SavedTorrentSearches []*SavedTorrentSearch `json:"savedTorrentSearches,omitempty"`
+		// Annotation is the local note and tags of the current user, nil if there are none
+		Annotation *EntryAnnotation `json:"annotation,omitempty"`
 	}
 
 	// EntryListData holds the details of the AniList entry.
@@ -5,6 +5,7 @@ import (
 	"seanime/internal/api/metadata"
 	"seanime/internal/library/anime"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/test_utils"
 	"seanime/internal/util"
 	"testing"
@@ -72,7 +73,7 @@ func TestNewAnimeEntry(t *testing.T) {
 
 	anilistClient := anilist.TestGetMockAnilistClient()
 	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistClient, logger)
-	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), false)
+	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), platform.CachedCollection)
 	if err != nil {
 		t.Fatal(err)
 	}
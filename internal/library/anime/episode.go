@@ -40,6 +40,8 @@ type (
 		Overview string `json:"overview,omitempty"`
 		IsFiller bool   `json:"isFiller,omitempty"`
 		HasImage bool   `json:"hasImage,omitempty"` // Indicates if the episode has a real image
+		// FallbackImage is a frame extracted from the local file, set when the episode has no real image
+		FallbackImage string `json:"fallbackImage,omitempty"`
 	}
 )
 
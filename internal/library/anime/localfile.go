@@ -2,6 +2,7 @@ package anime
 
 import (
 	"seanime/internal/library/filesystem"
+	"time"
 
 	"github.com/5rahim/habari"
 )
@@ -25,6 +26,9 @@ type (
 		Locked           bool                   `json:"locked"`
 		Ignored          bool                   `json:"ignored"` // Unused for now
 		MediaId          int                    `json:"mediaId"`
+		// UnverifiedSince is set when the library path of the file was unavailable during a scan.
+		// The file is kept until a scan finds it again or the library path is reconciled.
+		UnverifiedSince *time.Time `json:"unverifiedSince,omitempty"`
 	}
 
 	// LocalFileMetadata holds metadata related to a media episode.
@@ -82,6 +82,11 @@ func (f *LocalFile) IsIgnored() bool {
 	return f.Ignored
 }
 
+// IsUnverified returns true if the file was kept while its library path was unavailable
+func (f *LocalFile) IsUnverified() bool {
+	return f.UnverifiedSince != nil
+}
+
 // GetNormalizedPath returns the lowercase path of the LocalFile.
 // Use this for comparison.
 func (f *LocalFile) GetNormalizedPath() string {
@@ -0,0 +1,25 @@
+package anime
+
+import "time"
+
+type (
+	// MediaRemap is a media ID that AniList merged into another entry, or that no longer exists.
+	// When the media was merged, the local records pointing at the old ID were moved to the new one.
+	MediaRemap struct {
+		FromMediaId int `json:"fromMediaId"`
+		// ToMediaId is 0 if the media no longer exists, the files have to be matched manually
+		ToMediaId int `json:"toMediaId"`
+		// Title is the title of the new media, empty if the media no longer exists
+		Title string `json:"title"`
+		// Files are the paths of the local files of the old media
+		Files []string `json:"files"`
+		// Records is the number of remapped records by kind (e.g. "localFiles", "preMatches")
+		Records   map[string]int `json:"records"`
+		CreatedAt time.Time      `json:"createdAt"`
+	}
+)
+
+// IsVanished returns true if the media no longer exists on AniList.
+func (r *MediaRemap) IsVanished() bool {
+	return r.ToMediaId == 0
+}
@@ -58,21 +58,18 @@ func NewMissingEpisodes(opts *NewMissingEpisodesOptions) *MissingEpisodes {
 		return event.MissingEpisodes
 	}
 
-	groupedLfs := GroupLocalFilesByMediaID(opts.LocalFiles)
+	// Skip the entries that are dropped or completed
+	libraryEntries := joinLibraryEntries(opts.AnimeCollection, opts.LocalFiles,
+		anilist.MediaListStatusCurrent, anilist.MediaListStatusPlanning, anilist.MediaListStatusPaused, anilist.MediaListStatusRepeating)
 
 	rateLimiter := limiter.NewLimiter(time.Second, 20)
 	p := pool.NewWithResults[[]*EntryDownloadEpisode]()
-	for mId, lfs := range groupedLfs {
+	for _, le := range libraryEntries {
+		if len(le.LocalFiles) == 0 {
+			continue
+		}
 		p.Go(func() []*EntryDownloadEpisode {
-			entry, found := opts.AnimeCollection.GetListEntryFromAnimeId(mId)
-			if !found {
-				return nil
-			}
-
-			// Skip if the status is nil, dropped or completed
-			if entry.Status == nil || *entry.Status == anilist.MediaListStatusDropped || *entry.Status == anilist.MediaListStatusCompleted {
-				return nil
-			}
+			entry, lfs := le.Entry, le.LocalFiles
 
 			latestLf, found := FindLatestLocalFileFromGroup(lfs)
 			if !found {
@@ -154,3 +151,31 @@ func NewMissingEpisodes(opts *NewMissingEpisodesOptions) *MissingEpisodes {
 
 	return event.MissingEpisodes
 }
+
+// libraryEntry is an entry of the collection with the local files of its media.
+type libraryEntry struct {
+	Entry      *anilist.AnimeListEntry
+	LocalFiles []*LocalFile
+}
+
+// joinLibraryEntries joins the local files with the entries of the collection that have one of the statuses.
+// Entries without local files are included, with no local files.
+// It is shared by the missing episodes and the episodes airing today.
+func joinLibraryEntries(collection *anilist.AnimeCollection, lfs []*LocalFile, statuses ...anilist.MediaListStatus) map[int]*libraryEntry {
+	groupedLfs := GroupLocalFilesByMediaID(lfs)
+
+	ret := make(map[int]*libraryEntry)
+	for _, list := range collection.GetMediaListCollection().GetLists() {
+		for _, entry := range list.GetEntries() {
+			if entry.GetMedia() == nil || entry.Status == nil || !lo.Contains(statuses, *entry.Status) {
+				continue
+			}
+			mId := entry.GetMedia().GetID()
+			ret[mId] = &libraryEntry{
+				Entry:      entry,
+				LocalFiles: groupedLfs[mId],
+			}
+		}
+	}
+	return ret
+}
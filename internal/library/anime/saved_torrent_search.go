@@ -0,0 +1,82 @@
+package anime
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/api/anilist"
+	"strconv"
+	"strings"
+)
+
+// DEVNOTE: The struct is defined in this package for the same reason as AutoDownloaderRule.
+
+const (
+	SavedTorrentSearchTypeSmart  = "smart"
+	SavedTorrentSearchTypeSimple = "simple"
+)
+
+type (
+	// SavedTorrentSearch is a torrent search that the user runs often for a media.
+	// The query is a template that is expanded with the media and the episode when the search is executed.
+	SavedTorrentSearch struct {
+		DbID    uint   `json:"dbId"` // Will be set when fetched from the database
+		Name    string `json:"name"`
+		MediaId int    `json:"mediaId"`
+		// Providers are the torrent provider extension IDs, the results are aggregated
+		Providers []string `json:"providers"`
+		// Type is "simple" or "smart"
+		Type string `json:"type"`
+		// QueryTemplate can contain the variables {title}, {romaji}, {english}, {episode}, {episode2} and {year}
+		QueryTemplate string `json:"queryTemplate"`
+		// EpisodeNumber is the default episode, it can be overridden when the search is executed
+		EpisodeNumber int  `json:"episodeNumber,omitempty"`
+		Batch         bool `json:"batch,omitempty"`
+		// Resolution filters the results, e.g. "1080"
+		Resolution string `json:"resolution,omitempty"`
+		// MinSize and MaxSize filter the results by size in bytes, 0 means no limit
+		MinSize int64 `json:"minSize,omitempty"`
+		MaxSize int64 `json:"maxSize,omitempty"`
+	}
+)
+
+func (s *SavedTorrentSearch) Validate() error {
+	if s.MediaId == 0 {
+		return errors.New("media ID is required")
+	}
+	if len(s.Providers) == 0 {
+		return errors.New("at least one provider is required")
+	}
+	if s.Type != SavedTorrentSearchTypeSmart && s.Type != SavedTorrentSearchTypeSimple {
+		return fmt.Errorf("invalid search type %q", s.Type)
+	}
+	if s.Type == SavedTorrentSearchTypeSimple && strings.TrimSpace(s.QueryTemplate) == "" {
+		return errors.New("query template is required for simple searches")
+	}
+	if s.MinSize < 0 || s.MaxSize < 0 || (s.MaxSize > 0 && s.MinSize > s.MaxSize) {
+		return errors.New("invalid size range")
+	}
+	return nil
+}
+
+// ExpandQuery replaces the variables of the query template.
+func (s *SavedTorrentSearch) ExpandQuery(media *anilist.BaseAnime, episodeNumber int) string {
+	if s.QueryTemplate == "" {
+		return ""
+	}
+
+	year := ""
+	if media.GetStartDate() != nil && media.GetStartDate().GetYear() != nil {
+		year = strconv.Itoa(*media.GetStartDate().GetYear())
+	}
+
+	replacer := strings.NewReplacer(
+		"{title}", media.GetTitleSafe(),
+		"{romaji}", media.GetRomajiTitleSafe(),
+		"{english}", media.GetEnglishTitleSafe(),
+		"{episode}", strconv.Itoa(episodeNumber),
+		"{episode2}", fmt.Sprintf("%02d", episodeNumber),
+		"{year}", year,
+	)
+
+	return strings.Join(strings.Fields(replacer.Replace(s.QueryTemplate)), " ")
+}
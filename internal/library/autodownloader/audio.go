@@ -0,0 +1,73 @@
+package autodownloader
+
+import (
+	"fmt"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifier"
+	torrent_audio "seanime/internal/torrents/audio"
+	"slices"
+)
+
+// getAudioPreference returns the audio preference of the rule, falling back to the preference set for the media.
+func (ad *AutoDownloader) getAudioPreference(rule *anime.AutoDownloaderRule) torrent_audio.Preference {
+	if rule.AudioPreference != torrent_audio.PreferenceNone || ad.database == nil {
+		return rule.AudioPreference
+	}
+
+	mediaPreference, err := ad.database.GetMediaAudioPreference(rule.MediaId)
+	if err != nil {
+		ad.logger.Debug().Err(err).Int("mediaId", rule.MediaId).Msg("autodownloader: Failed to get media audio preference")
+		return rule.AudioPreference
+	}
+
+	return torrent_audio.Resolve(rule.AudioPreference, torrent_audio.Preference(mediaPreference))
+}
+
+// notifyAwaitingDub notifies the user when a rule requiring dubbed audio only found sub releases for an episode.
+// Nothing is downloaded for these episodes, the AutoDownloader waits for a dub release.
+// The user is only notified once per rule and episode.
+func (ad *AutoDownloader) notifyAwaitingDub(
+	rule *anime.AutoDownloaderRule,
+	listEntry *anilist.AnimeListEntry,
+	rejectedEpisodes map[int]struct{},
+	torrentsToDownload []*tmpTorrentToDownload,
+) {
+	episodes := make([]int, 0, len(rejectedEpisodes))
+	ad.mu.Lock()
+	for ep := range rejectedEpisodes {
+		// Skip episodes for which a dubbed release was found
+		if slices.ContainsFunc(torrentsToDownload, func(t *tmpTorrentToDownload) bool { return t.episode == ep }) {
+			continue
+		}
+		key := fmt.Sprintf("%d-%d-%d", rule.DbID, rule.MediaId, ep)
+		if _, ok := ad.awaitingDub[key]; ok {
+			continue
+		}
+		ad.awaitingDub[key] = struct{}{}
+		episodes = append(episodes, ep)
+	}
+	ad.mu.Unlock()
+
+	if len(episodes) == 0 {
+		return
+	}
+	slices.Sort(episodes)
+
+	ad.logger.Info().Uint("ruleId", rule.DbID).Ints("episodes", episodes).Msg("autodownloader: Only sub releases found, waiting for a dub")
+	notifier.GlobalNotifier.Notify(
+		notifier.AutoDownloader,
+		fmt.Sprintf("Only sub releases were found for %s (episode %s). Waiting for a dub release.", listEntry.GetMedia().GetRomajiTitleSafe(), joinEpisodes(episodes)),
+	)
+}
+
+func joinEpisodes(episodes []int) string {
+	ret := ""
+	for i, ep := range episodes {
+		if i > 0 {
+			ret += ", "
+		}
+		ret += fmt.Sprintf("%d", ep)
+	}
+	return ret
+}
@@ -1,6 +1,7 @@
 package autodownloader
 
 import (
+	"errors"
 	"fmt"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/metadata"
@@ -14,8 +15,12 @@ import (
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/hook"
 	"seanime/internal/library/anime"
+	"seanime/internal/library/releasepredictor"
+	"seanime/internal/notifications"
 	"seanime/internal/notifier"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/torrent_clients/torrent_client"
+	torrent_audio "seanime/internal/torrents/audio"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/util"
 	"seanime/internal/util/comparison"
@@ -36,6 +41,8 @@ const (
 	ComparisonThreshold = 0.8
 )
 
+var errTorrentExists = errors.New("autodownloader: torrent already added")
+
 type (
 	AutoDownloader struct {
 		logger                  *zerolog.Logger
@@ -45,6 +52,7 @@ type (
 		database                *db.Database
 		animeCollection         mo.Option[*anilist.AnimeCollection]
 		wsEventManager          events.WSEventManagerInterface
+		notifications           *notifications.Manager
 		settings                *models.AutoDownloaderSettings
 		metadataProviderRef     *util.Ref[metadata_provider.Provider]
 		settingsUpdatedCh       chan struct{}
@@ -53,6 +61,18 @@ type (
 		debugTrace              bool
 		mu                      sync.Mutex
 		isOfflineRef            *util.Ref[bool]
+		platformRef             *util.Ref[platform.Platform]
+		isPausedFunc            func() bool // Returns true if the scheduled checks should be skipped (maintenance mode)
+		// sequelProposals maps rule IDs to the sequel that has been proposed, so that users are only notified once
+		sequelProposals map[uint]int
+		// retargetedMedia caches the media of retargeted rules whose sequel is not in the collection
+		retargetedMedia map[int]*anilist.BaseAnime
+		// awaitingDub keeps track of the episodes for which the user has been notified that only sub releases were found
+		awaitingDub map[string]struct{}
+		// releasePredictor records when releases are first seen and predicts when the next ones are available
+		releasePredictor *releasepredictor.Predictor
+		// overdueNotified keeps track of the late episodes for which the user has been notified
+		overdueNotified map[string]struct{}
 	}
 
 	NewAutoDownloaderOptions struct {
@@ -64,6 +84,10 @@ type (
 		MetadataProviderRef     *util.Ref[metadata_provider.Provider]
 		DebridClientRepository  *debrid_client.Repository
 		IsOfflineRef            *util.Ref[bool]
+		PlatformRef             *util.Ref[platform.Platform]
+		IsPausedFunc            func() bool                 // Optional
+		Notifications           *notifications.Manager      // Optional
+		ReleasePredictor        *releasepredictor.Predictor // Optional
 	}
 
 	tmpTorrentToDownload struct {
@@ -79,6 +103,7 @@ func New(opts *NewAutoDownloaderOptions) *AutoDownloader {
 		torrentRepository:       opts.TorrentRepository,
 		database:                opts.Database,
 		wsEventManager:          opts.WSEventManager,
+		notifications:           opts.Notifications,
 		animeCollection:         mo.None[*anilist.AnimeCollection](),
 		metadataProviderRef:     opts.MetadataProviderRef,
 		debridClientRepository:  opts.DebridClientRepository,
@@ -95,6 +120,13 @@ func New(opts *NewAutoDownloaderOptions) *AutoDownloader {
 		debugTrace:        true,
 		mu:                sync.Mutex{},
 		isOfflineRef:      opts.IsOfflineRef,
+		platformRef:       opts.PlatformRef,
+		isPausedFunc:      opts.IsPausedFunc,
+		sequelProposals:   make(map[uint]int),
+		retargetedMedia:   make(map[int]*anilist.BaseAnime),
+		awaitingDub:       make(map[string]struct{}),
+		releasePredictor:  opts.ReleasePredictor,
+		overdueNotified:   make(map[string]struct{}),
 	}
 }
 
@@ -217,11 +249,13 @@ func (ad *AutoDownloader) start() {
 		case <-ad.stopCh:
 
 		case <-ad.startCh:
+			ad.checkItemsAwaitingConfirmation()
 			if ad.settings.Enabled {
 				ad.logger.Debug().Msg("autodownloader: Auto Downloader started")
 				ad.checkForNewEpisodes()
 			}
 		case <-ticker.C:
+			ad.checkItemsAwaitingConfirmation()
 			if ad.settings.Enabled {
 				ad.checkForNewEpisodes()
 			}
@@ -239,6 +273,11 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 		return
 	}
 
+	if ad.isPausedFunc != nil && ad.isPausedFunc() {
+		ad.logger.Debug().Msg("autodownloader: Skipping check for new episodes. Maintenance mode is active.")
+		return
+	}
+
 	ad.mu.Lock()
 	if ad.torrentRepository == nil || !ad.settings.Enabled || ad.settings.Provider == "" || ad.settings.Provider == torrent.ProviderNone {
 		ad.logger.Warn().Msg("autodownloader: Could not check for new episodes. AutoDownloader is not enabled or provider is not set.")
@@ -278,6 +317,9 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 	}
 	rules = _filteredRules
 
+	// Propose or apply retargeting for rules whose media has a sequel
+	rules = ad.checkForSequels(rules)
+
 	// Event
 	event := &AutoDownloaderRunStartedEvent{
 		Rules: rules,
@@ -336,6 +378,10 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 		}
 	}
 
+	// Airing times of the episodes, used to record when the releases are first seen
+	now := time.Now()
+	times := ad.getAiringTimes()
+
 	downloaded := 0
 	mu := sync.Mutex{}
 
@@ -370,8 +416,18 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 				items = make([]*models.AutoDownloaderItem, 0)
 			}
 
+			audioPreference := ad.getAudioPreference(rule)
+
+			// Torrents rejected by the user are not matched again
+			rejectedTorrents, err := ad.database.GetAutoDownloaderRejectedTorrents(rule.DbID)
+			if err != nil {
+				rejectedTorrents = make([]*models.AutoDownloaderRejectedTorrent, 0)
+			}
+
 			// Get all torrents that follow the rule
 			torrentsToDownload := make([]*tmpTorrentToDownload, 0)
+			// Episodes that had matching torrents rejected because of their audio
+			rejectedAudioEpisodes := make(map[int]struct{})
 		outer:
 			for _, t := range torrents {
 				// If the torrent is already added, skip it
@@ -380,6 +436,9 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 						continue outer // Skip the torrent
 					}
 				}
+				if isRejectedTorrent(rejectedTorrents, t) {
+					continue outer // Skip the torrent
+				}
 
 				episode, ok := ad.torrentFollowsRule(t, rule, listEntry, localEntry, items)
 				event := &AutoDownloaderMatchVerifiedEvent{
@@ -403,6 +462,15 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 					continue outer // Skip the torrent
 				}
 
+				if ok {
+					ad.recordRelease(t, listEntry.GetMedia().GetID(), episode, times, now)
+				}
+
+				if ok && !audioPreference.Accepts(t.Audio) {
+					rejectedAudioEpisodes[episode] = struct{}{}
+					continue outer // Skip the torrent
+				}
+
 				if ok {
 					torrentsToDownload = append(torrentsToDownload, &tmpTorrentToDownload{
 						torrent: t,
@@ -411,6 +479,12 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 				}
 			}
 
+			if audioPreference == torrent_audio.PreferenceDubRequired {
+				ad.notifyAwaitingDub(rule, listEntry, rejectedAudioEpisodes, torrentsToDownload)
+			}
+
+			ad.notifyOverdueEpisodes(rule, listEntry, localEntry, items, torrentsToDownload, times, now)
+
 			// Download the torrent if there's only one
 			if len(torrentsToDownload) == 1 {
 				t := torrentsToDownload[0]
@@ -458,6 +532,10 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 				sort.Slice(torrents, func(i, j int) bool {
 					return torrents[i].torrent.Seeders > torrents[j].torrent.Seeders
 				})
+				// Sort by audio preference
+				sort.SliceStable(torrents, func(i, j int) bool {
+					return audioPreference.Score(torrents[i].torrent.Audio) > audioPreference.Score(torrents[j].torrent.Audio)
+				})
 
 				ok := ad.downloadTorrent(torrents[0].torrent, rule, ep)
 				if ok {
@@ -493,30 +571,45 @@ func (ad *AutoDownloader) torrentFollowsRule(
 	localEntry *anime.LocalFileWrapperEntry,
 	items []*models.AutoDownloaderItem,
 ) (int, bool) {
-	defer util.HandlePanicInModuleThen("autodownloader/torrentFollowsRule", func() {})
+	episode, reason := ad.matchTorrentToRule(t, rule, listEntry, localEntry, items)
+	return episode, reason == ""
+}
+
+// matchTorrentToRule returns the episode of the torrent if it follows the rule.
+// If it does not, it returns the reason why, the reason is empty when the torrent matches.
+func (ad *AutoDownloader) matchTorrentToRule(
+	t *NormalizedTorrent,
+	rule *anime.AutoDownloaderRule,
+	listEntry *anilist.AnimeListEntry,
+	localEntry *anime.LocalFileWrapperEntry,
+	items []*models.AutoDownloaderItem,
+) (episode int, reason string) {
+	defer util.HandlePanicInModuleThen("autodownloader/matchTorrentToRule", func() {
+		episode, reason = -1, "An error occurred while matching the torrent"
+	})
 
 	if ok := ad.isReleaseGroupMatch(t.ParsedData.ReleaseGroup, rule); !ok {
-		return -1, false
+		return -1, "The release group does not match"
 	}
 
 	if ok := ad.isResolutionMatch(t.ParsedData.VideoResolution, rule); !ok {
-		return -1, false
+		return -1, "The resolution does not match"
 	}
 
 	if ok := ad.isTitleMatch(t.ParsedData, t.Name, rule, listEntry); !ok {
-		return -1, false
+		return -1, "The title does not match"
 	}
 
 	if ok := ad.isAdditionalTermsMatch(t.Name, rule); !ok {
-		return -1, false
+		return -1, "The additional terms do not match"
 	}
 
 	episode, ok := ad.isSeasonAndEpisodeMatch(t.ParsedData, rule, listEntry, localEntry, items)
 	if !ok {
-		return -1, false
+		return -1, "The season or episode does not match, or the episode is already downloaded, queued or watched"
 	}
 
-	return episode, true
+	return episode, ""
 }
 
 func (ad *AutoDownloader) downloadTorrent(t *NormalizedTorrent, rule *anime.AutoDownloaderRule, episode int) bool {
@@ -551,15 +644,70 @@ func (ad *AutoDownloader) downloadTorrent(t *NormalizedTorrent, rule *anime.Auto
 		return false
 	}
 
+	// Wait for the user's confirmation, nothing is added until the item is approved
+	if ad.requiresConfirmation(rule) {
+		ad.queueForConfirmation(t, rule, episode)
+		return false
+	}
+
+	magnet, downloaded, err := ad.addTorrent(t, rule, ad.settings.DownloadAutomatically)
+	if err != nil {
+		if !errors.Is(err, errTorrentExists) {
+			ad.logger.Error().Err(err).Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to download torrent")
+			ad.notifications.Notify(notifications.TypeDownloadFailed, fmt.Sprintf("Failed to download %s: %s", t.Name, err.Error()), rule.MediaId)
+		}
+		return false
+	}
+
+	ad.logger.Info().Str("name", t.Name).Msg("autodownloader: Added torrent")
+	ad.wsEventManager.SendEvent(events.AutoDownloaderItemAdded, t.Name)
+	ad.notifyAddedTorrent(t, rule, downloaded)
+
+	// Add the torrent to the database
+	item := &models.AutoDownloaderItem{
+		RuleID:      rule.DbID,
+		MediaID:     rule.MediaId,
+		Episode:     episode,
+		Link:        t.Link,
+		Hash:        t.InfoHash,
+		Magnet:      magnet,
+		TorrentName: t.Name,
+		Downloaded:  downloaded,
+	}
+	_ = ad.database.InsertAutoDownloaderItem(item)
+
+	// Event
+	afterEvent := &AutoDownloaderAfterDownloadTorrentEvent{
+		Torrent: t,
+		Rule:    rule,
+	}
+	_ = hook.GlobalHookManager.OnAutoDownloaderAfterDownloadTorrent().Trigger(afterEvent)
+
+	return true
+}
+
+// notifyAddedTorrent adds a notification for a torrent matched by the rule.
+// Torrents that were not added to the torrent client are waiting for the user to download them.
+func (ad *AutoDownloader) notifyAddedTorrent(t *NormalizedTorrent, rule *anime.AutoDownloaderRule, downloaded bool) {
+	if downloaded {
+		ad.notifications.Notify(notifications.TypeDownloadStarted, fmt.Sprintf("Downloading %s", t.Name), rule.MediaId)
+		return
+	}
+	ad.notifications.Notify(notifications.TypeRuleMatched, fmt.Sprintf("%s has been added to the queue", t.Name), rule.MediaId)
+}
+
+// addTorrent resolves the magnet of the torrent and adds it to the debrid service or the torrent client.
+// If automatic is false, the torrent is not added to the torrent client, only its magnet is returned so that the user can add it later.
+// It returns the magnet and whether the torrent was added to the torrent client.
+// ad.mu should be locked.
+func (ad *AutoDownloader) addTorrent(t *NormalizedTorrent, rule *anime.AutoDownloaderRule, automatic bool) (magnet string, downloaded bool, err error) {
 	providerExtension, found := ad.torrentRepository.GetDefaultAnimeProviderExtension()
 	if !found {
-		ad.logger.Warn().Msg("autodownloader: Could not download torrent. Default provider not found")
-		return false
+		return "", false, errors.New("default provider not found")
 	}
 
 	if ad.torrentClientRepository == nil {
-		ad.logger.Error().Msg("autodownloader: torrent client not found")
-		return false
+		return "", false, errors.New("torrent client not found")
 	}
 
 	useDebrid := false
@@ -567,42 +715,37 @@ func (ad *AutoDownloader) downloadTorrent(t *NormalizedTorrent, rule *anime.Auto
 	if ad.settings.UseDebrid {
 		// Check if the debrid provider is enabled
 		if !ad.debridClientRepository.HasProvider() || !ad.debridClientRepository.GetSettings().Enabled {
-			ad.logger.Error().Msg("autodownloader: Debrid provider not found or not enabled")
 			// We return instead of falling back to torrent client
-			return false
+			return "", false, errors.New("debrid provider not found or not enabled")
 		}
 		useDebrid = true
 	}
 
 	// Get torrent magnet
-	magnet, err := t.GetMagnet(providerExtension.GetProvider())
+	magnet, err = t.GetMagnet(providerExtension.GetProvider())
 	if err != nil {
-		ad.logger.Error().Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to get magnet link for torrent")
-		return false
+		return "", false, fmt.Errorf("failed to get magnet link: %w", err)
 	}
 
-	downloaded := false
-
 	if useDebrid {
 		//
 		// Debrid
 		//
 
-		if ad.settings.DownloadAutomatically {
+		if automatic {
 			// Add the torrent to the debrid provider and queue it
 			_, err := ad.debridClientRepository.AddAndQueueTorrent(debrid.AddTorrentOptions{
 				MagnetLink:   magnet,
 				SelectFileId: "all", // RD-only, select all files
 			}, rule.Destination, rule.MediaId)
 			if err != nil {
-				ad.logger.Error().Err(err).Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to add torrent to debrid")
-				return false
+				return "", false, fmt.Errorf("failed to add torrent to debrid: %w", err)
 			}
+			ad.savePreMatch(rule)
 		} else {
 			debridProvider, err := ad.debridClientRepository.GetProvider()
 			if err != nil {
-				ad.logger.Error().Err(err).Msg("autodownloader: Failed to get debrid provider")
-				return false
+				return "", false, fmt.Errorf("failed to get debrid provider: %w", err)
 			}
 
 			// Add the torrent to the debrid provider
@@ -611,29 +754,27 @@ func (ad *AutoDownloader) downloadTorrent(t *NormalizedTorrent, rule *anime.Auto
 				SelectFileId: "all", // RD-only, select all files
 			})
 			if err != nil {
-				ad.logger.Error().Err(err).Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to add torrent to debrid")
-				return false
+				return "", false, fmt.Errorf("failed to add torrent to debrid: %w", err)
 			}
 		}
 
 	} else {
 		// Pause the torrent when it's added
-		if ad.settings.DownloadAutomatically {
+		if automatic {
 
 			//
 			// Torrent client
 			//
 			started := ad.torrentClientRepository.Start() // Start torrent client if it's not running
 			if !started {
-				ad.logger.Error().Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to download torrent. torrent client is not running.")
-				return false
+				return "", false, errors.New("torrent client is not running")
 			}
 
 			// Return if the torrent is already added
 			torrentExists := ad.torrentClientRepository.TorrentExists(t.InfoHash)
 			if torrentExists {
 				//ad.Logger.Debug().Str("name", t.Name).Msg("autodownloader: Torrent already added")
-				return false
+				return magnet, false, errTorrentExists
 			}
 
 			ad.logger.Debug().Msgf("autodownloader: Downloading torrent: %s", t.Name)
@@ -641,38 +782,27 @@ func (ad *AutoDownloader) downloadTorrent(t *NormalizedTorrent, rule *anime.Auto
 			// Add the torrent to torrent client
 			err := ad.torrentClientRepository.AddMagnets([]string{magnet}, rule.Destination)
 			if err != nil {
-				ad.logger.Error().Err(err).Str("link", t.Link).Str("name", t.Name).Msg("autodownloader: Failed to add torrent to torrent client")
-				return false
+				return "", false, fmt.Errorf("failed to add torrent to torrent client: %w", err)
 			}
 
+			ad.savePreMatch(rule)
 			downloaded = true
 		}
 	}
 
-	ad.logger.Info().Str("name", t.Name).Msg("autodownloader: Added torrent")
-	ad.wsEventManager.SendEvent(events.AutoDownloaderItemAdded, t.Name)
+	return magnet, downloaded, nil
+}
 
-	// Add the torrent to the database
-	item := &models.AutoDownloaderItem{
-		RuleID:      rule.DbID,
-		MediaID:     rule.MediaId,
-		Episode:     episode,
-		Link:        t.Link,
-		Hash:        t.InfoHash,
-		Magnet:      magnet,
-		TorrentName: t.Name,
-		Downloaded:  downloaded,
+// savePreMatch associates the destination of the rule with its media so that the scanner can match the files directly.
+func (ad *AutoDownloader) savePreMatch(rule *anime.AutoDownloaderRule) {
+	if rule.MediaId == 0 || rule.Destination == "" {
+		return
 	}
-	_ = ad.database.InsertAutoDownloaderItem(item)
-
-	// Event
-	afterEvent := &AutoDownloaderAfterDownloadTorrentEvent{
-		Torrent: t,
-		Rule:    rule,
+	// Store the destination as seen by the server, the torrent client can run on another OS
+	translator := ad.torrentClientRepository.PathTranslator()
+	if err := ad.database.SaveTorrentPreMatch(translator.Canonical(translator.ToClient(rule.Destination)), rule.MediaId); err != nil {
+		ad.logger.Warn().Err(err).Msg("autodownloader: Failed to save torrent pre-match")
 	}
-	_ = hook.GlobalHookManager.OnAutoDownloaderAfterDownloadTorrent().Trigger(afterEvent)
-
-	return true
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -921,6 +1051,11 @@ func (ad *AutoDownloader) isSeasonAndEpisodeMatch(
 
 	hasAbsoluteEpisode := false
 
+	// Releases of a second cour numbered from 1
+	if rule.EpisodeOffset > 0 {
+		episode += rule.EpisodeOffset
+	}
+
 	// Handle ABSOLUTE episode numbers
 	if listEntry.GetMedia().GetCurrentEpisodeCount() != -1 && episode > listEntry.GetMedia().GetCurrentEpisodeCount() {
 		// Fetch the Animap media in order to normalize the episode number
@@ -930,6 +1065,10 @@ func (ad *AutoDownloader) isSeasonAndEpisodeMatch(
 		if err == nil && animeMetadata.GetOffset() > 0 {
 			hasAbsoluteEpisode = true
 			episode = episode - animeMetadata.GetOffset()
+		} else if rule.EpisodeOffset < 0 && episode > -rule.EpisodeOffset {
+			// Fall back to the offset recorded when the rule was retargeted
+			hasAbsoluteEpisode = true
+			episode = episode + rule.EpisodeOffset
 		}
 		ad.mu.Unlock()
 	}
@@ -1008,6 +1147,10 @@ func (ad *AutoDownloader) getRuleListEntry(rule *anime.AutoDownloaderRule) (*ani
 
 	listEntry, found := ad.animeCollection.MustGet().GetListEntryFromAnimeId(rule.MediaId)
 	if !found {
+		// Retargeted rules can follow a sequel that has not been added to the collection yet
+		if rule.IsRetargeted() {
+			return ad.getRetargetedRuleListEntry(rule)
+		}
 		return nil, false
 	}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/library/anime"
+	torrent_audio "seanime/internal/torrents/audio"
 	"sync"
 
 	"github.com/5rahim/habari"
@@ -15,8 +16,9 @@ type (
 	// It is used to normalize the data from different providers so that it can be used by the AutoDownloader.
 	NormalizedTorrent struct {
 		hibiketorrent.AnimeTorrent
-		ParsedData *habari.Metadata `json:"parsedData"`
-		magnet     string           // Access using GetMagnet()
+		ParsedData *habari.Metadata   `json:"parsedData"`
+		Audio      torrent_audio.Kind `json:"audio"`
+		magnet     string             // Access using GetMagnet()
 	}
 )
 
@@ -73,6 +75,7 @@ func (ad *AutoDownloader) getLatestTorrents(rules []*anime.AutoDownloaderRule) (
 		ret = append(ret, &NormalizedTorrent{
 			AnimeTorrent: *t,
 			ParsedData:   parsedData,
+			Audio:        torrent_audio.Detect(t.Name, parsedData),
 		})
 	}
 
@@ -0,0 +1,308 @@
+package autodownloader
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/hook"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifications"
+	"seanime/internal/notifier"
+	"seanime/internal/util"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// DefaultConfirmationExpiryDays is used when no expiry is set in the settings.
+	DefaultConfirmationExpiryDays = 7
+	// confirmationReminderWindow is how long before the expiry the user is reminded of the items awaiting confirmation
+	confirmationReminderWindow = 24 * time.Hour
+)
+
+const (
+	// QueueItemStatusAwaitingConfirmation is an item that waits for the user to approve or reject it.
+	QueueItemStatusAwaitingConfirmation QueueItemStatus = "awaiting-confirmation"
+	// QueueItemStatusQueued is an item that has not been added to the torrent client yet.
+	QueueItemStatusQueued QueueItemStatus = "queued"
+	// QueueItemStatusAdded is an item that has been added to the torrent client and waits for the next scan.
+	QueueItemStatusAdded QueueItemStatus = "added"
+)
+
+var ErrItemNotAwaitingConfirmation = errors.New("autodownloader: item is not awaiting confirmation")
+
+type (
+	QueueItemStatus string
+
+	// QueueItem is an item of the AutoDownloader queue.
+	QueueItem struct {
+		*models.AutoDownloaderItem
+		Status QueueItemStatus `json:"status"`
+		// Candidate is the matched torrent of items awaiting confirmation
+		Candidate *NormalizedTorrent `json:"candidate,omitempty"`
+	}
+
+	// QueuedItemWithMedia is a queued item with the media of the anime collection it belongs to.
+	QueuedItemWithMedia struct {
+		*QueueItem
+		// Media is nil if the media is not in the collection
+		Media *anilist.BaseAnime `json:"media,omitempty"`
+	}
+)
+
+// GetQueue returns the queued items and their status.
+func (ad *AutoDownloader) GetQueue() ([]*QueueItem, error) {
+	items, err := ad.database.GetAutoDownloaderItems()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*QueueItem, 0, len(items))
+	for _, item := range items {
+		queueItem := &QueueItem{AutoDownloaderItem: item}
+		switch {
+		case item.AwaitingConfirmation:
+			queueItem.Status = QueueItemStatusAwaitingConfirmation
+			queueItem.Candidate, _ = getItemCandidate(item)
+		case item.Downloaded:
+			queueItem.Status = QueueItemStatusAdded
+		default:
+			queueItem.Status = QueueItemStatusQueued
+		}
+		ret = append(ret, queueItem)
+	}
+
+	return ret, nil
+}
+
+// GetQueueWithMedia returns the queued items with their media, looked up in the given anime collection.
+func (ad *AutoDownloader) GetQueueWithMedia(animeCollection *anilist.AnimeCollection) ([]*QueuedItemWithMedia, error) {
+	items, err := ad.GetQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	media := make(map[int]*anilist.BaseAnime)
+	for _, list := range animeCollection.GetMediaListCollection().GetLists() {
+		for _, entry := range list.GetEntries() {
+			if entry.GetMedia() != nil {
+				media[entry.GetMedia().GetID()] = entry.GetMedia()
+			}
+		}
+	}
+
+	ret := make([]*QueuedItemWithMedia, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, &QueuedItemWithMedia{
+			QueueItem: item,
+			Media:     media[item.MediaID],
+		})
+	}
+
+	return ret, nil
+}
+
+// ApproveItem adds the torrent of an item awaiting confirmation, the same way the scheduled runs do.
+// The torrent is added even if the AutoDownloader does not download automatically.
+func (ad *AutoDownloader) ApproveItem(id uint) (*models.AutoDownloaderItem, error) {
+	item, err := ad.database.GetAutoDownloaderItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if !item.AwaitingConfirmation {
+		return nil, ErrItemNotAwaitingConfirmation
+	}
+
+	t, err := getItemCandidate(item)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := db_bridge.GetAutoDownloaderRule(ad.database, item.RuleID)
+	if err != nil {
+		return nil, fmt.Errorf("autodownloader: rule not found: %w", err)
+	}
+
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	magnet, downloaded, err := ad.addTorrent(t, rule, true)
+	if err != nil {
+		if !errors.Is(err, errTorrentExists) {
+			return nil, fmt.Errorf("autodownloader: %w", err)
+		}
+		downloaded = true
+	}
+
+	if err := ad.database.ConfirmAutoDownloaderItem(item.ID, magnet, downloaded); err != nil {
+		return nil, err
+	}
+
+	ad.logger.Info().Str("name", t.Name).Msg("autodownloader: Added approved torrent")
+	ad.wsEventManager.SendEvent(events.AutoDownloaderItemAdded, t.Name)
+	ad.notifyAddedTorrent(t, rule, downloaded)
+
+	// Event
+	afterEvent := &AutoDownloaderAfterDownloadTorrentEvent{
+		Torrent: t,
+		Rule:    rule,
+	}
+	_ = hook.GlobalHookManager.OnAutoDownloaderAfterDownloadTorrent().Trigger(afterEvent)
+
+	return ad.database.GetAutoDownloaderItem(item.ID)
+}
+
+// RejectItem dismisses an item awaiting confirmation.
+// The torrent will not be matched by the rule again, other releases of the episode can still be matched.
+func (ad *AutoDownloader) RejectItem(id uint) error {
+	item, err := ad.database.GetAutoDownloaderItem(id)
+	if err != nil {
+		return err
+	}
+	if !item.AwaitingConfirmation {
+		return ErrItemNotAwaitingConfirmation
+	}
+
+	ad.logger.Debug().Str("name", item.TorrentName).Msg("autodownloader: Rejected torrent")
+	return ad.dismissItem(item)
+}
+
+// requiresConfirmation returns true if the torrents matched by the rule should wait for the user's confirmation.
+// The mode of the rule takes precedence over the settings.
+func (ad *AutoDownloader) requiresConfirmation(rule *anime.AutoDownloaderRule) bool {
+	switch rule.Mode {
+	case anime.AutoDownloaderRuleModeConfirm:
+		return true
+	case anime.AutoDownloaderRuleModeDownload:
+		return false
+	}
+	return ad.settings.RequireConfirmation
+}
+
+func (ad *AutoDownloader) getConfirmationExpiry() time.Duration {
+	days := DefaultConfirmationExpiryDays
+	if ad.settings.ConfirmationExpiryDays > 0 {
+		days = ad.settings.ConfirmationExpiryDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// queueForConfirmation records the torrent as an item awaiting confirmation and notifies the user.
+// ad.mu should be locked.
+func (ad *AutoDownloader) queueForConfirmation(t *NormalizedTorrent, rule *anime.AutoDownloaderRule, episode int) {
+	candidate, err := json.Marshal(t)
+	if err != nil {
+		ad.logger.Error().Err(err).Str("name", t.Name).Msg("autodownloader: Failed to marshal candidate")
+		return
+	}
+
+	expiresAt := time.Now().Add(ad.getConfirmationExpiry())
+	item := &models.AutoDownloaderItem{
+		RuleID:               rule.DbID,
+		MediaID:              rule.MediaId,
+		Episode:              episode,
+		Link:                 t.Link,
+		Hash:                 t.InfoHash,
+		TorrentName:          t.Name,
+		AwaitingConfirmation: true,
+		Candidate:            candidate,
+		ExpiresAt:            &expiresAt,
+	}
+	if err := ad.database.InsertAutoDownloaderItem(item); err != nil {
+		ad.logger.Error().Err(err).Str("name", t.Name).Msg("autodownloader: Failed to queue torrent for confirmation")
+		return
+	}
+
+	ad.logger.Info().Str("name", t.Name).Msg("autodownloader: Torrent awaiting confirmation")
+	ad.wsEventManager.SendEvent(events.AutoDownloaderItemPending, t.Name)
+	ad.notifications.Notify(notifications.TypeRuleMatched, fmt.Sprintf("%s is awaiting your confirmation", t.Name), rule.MediaId)
+	notifier.GlobalNotifier.Notify(
+		notifier.AutoDownloader,
+		fmt.Sprintf("%s is awaiting your confirmation.", t.Name),
+	)
+}
+
+// checkItemsAwaitingConfirmation dismisses the expired items and reminds the user of the items that are about to expire.
+// Expired torrents are not matched again, like rejected ones.
+func (ad *AutoDownloader) checkItemsAwaitingConfirmation() {
+	defer util.HandlePanicInModuleThen("autodownloader/checkItemsAwaitingConfirmation", func() {})
+
+	items, err := ad.database.GetAutoDownloaderItemsAwaitingConfirmation()
+	if err != nil {
+		ad.logger.Error().Err(err).Msg("autodownloader: Failed to fetch items awaiting confirmation")
+		return
+	}
+
+	ad.mu.Lock()
+	reminderWindow := min(confirmationReminderWindow, ad.getConfirmationExpiry()/2)
+	ad.mu.Unlock()
+
+	now := time.Now()
+	expiringSoon := 0
+	for _, item := range items {
+		if item.ExpiresAt == nil {
+			continue
+		}
+		if now.After(*item.ExpiresAt) {
+			ad.logger.Debug().Str("name", item.TorrentName).Msg("autodownloader: Item awaiting confirmation expired")
+			_ = ad.dismissItem(item)
+			continue
+		}
+		if !item.ReminderSent && item.ExpiresAt.Sub(now) <= reminderWindow {
+			expiringSoon++
+			_ = ad.database.SetAutoDownloaderItemReminderSent(item.ID)
+		}
+	}
+
+	if expiringSoon > 0 {
+		notifier.GlobalNotifier.Notify(
+			notifier.AutoDownloader,
+			fmt.Sprintf("%d %s awaiting your confirmation will expire soon.", expiringSoon, util.Pluralize(expiringSoon, "torrent", "torrents")),
+		)
+	}
+}
+
+// dismissItem removes the item from the queue and rejects its torrent for the rule.
+func (ad *AutoDownloader) dismissItem(item *models.AutoDownloaderItem) error {
+	if err := ad.database.InsertAutoDownloaderRejectedTorrent(&models.AutoDownloaderRejectedTorrent{
+		RuleID:      item.RuleID,
+		Hash:        item.Hash,
+		TorrentName: item.TorrentName,
+	}); err != nil {
+		return err
+	}
+	return ad.database.DeleteAutoDownloaderItem(item.ID)
+}
+
+func getItemCandidate(item *models.AutoDownloaderItem) (*NormalizedTorrent, error) {
+	if len(item.Candidate) == 0 {
+		return nil, errors.New("autodownloader: item has no candidate")
+	}
+	var ret NormalizedTorrent
+	if err := json.Unmarshal(item.Candidate, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// isRejectedTorrent returns true if the torrent has been rejected.
+// Torrents are compared by their hash, or by their name if the hash is unknown.
+func isRejectedTorrent(rejected []*models.AutoDownloaderRejectedTorrent, t *NormalizedTorrent) bool {
+	for _, r := range rejected {
+		if r.Hash != "" && t.InfoHash != "" {
+			if strings.EqualFold(r.Hash, t.InfoHash) {
+				return true
+			}
+			continue
+		}
+		if r.TorrentName == t.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package autodownloader
+
+import (
+	"context"
+	"fmt"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifications"
+	"slices"
+	"strings"
+	"time"
+)
+
+// overdueWindow is how long after airing an episode that has not been released can be reported as late
+const overdueWindow = 7 * 24 * time.Hour
+
+// airingTimes maps media IDs to the airing time of their episodes
+type airingTimes map[int]map[int]time.Time
+
+func (at airingTimes) get(mediaId int, episode int) (time.Time, bool) {
+	ret, ok := at[mediaId][episode]
+	return ret, ok
+}
+
+// getAiringTimes returns the airing times of the recent and upcoming episodes of the collection.
+// It returns an empty map if the release predictor is not set or the schedule cannot be fetched.
+func (ad *AutoDownloader) getAiringTimes() airingTimes {
+	ret := make(airingTimes)
+	if ad.releasePredictor == nil || ad.platformRef == nil || ad.platformRef.IsAbsent() || ad.animeCollection.IsAbsent() {
+		return ret
+	}
+
+	schedule, err := ad.platformRef.Get().GetAnimeAiringSchedule(context.Background())
+	if err != nil {
+		ad.logger.Warn().Err(err).Msg("autodownloader: Failed to fetch the airing schedule, releases will not be recorded")
+		return ret
+	}
+
+	for _, item := range anime.GetScheduleItems(schedule, ad.animeCollection.MustGet()) {
+		if _, ok := ret[item.MediaId]; !ok {
+			ret[item.MediaId] = make(map[int]time.Time)
+		}
+		ret[item.MediaId][item.EpisodeNumber] = item.DateTime
+	}
+	return ret
+}
+
+// recordRelease records the first time a release matching a rule was seen, relative to the airing of its episode.
+// Releases published long before they were seen are not recorded, the AutoDownloader was not running when they were released.
+func (ad *AutoDownloader) recordRelease(t *NormalizedTorrent, mediaId int, episode int, times airingTimes, now time.Time) {
+	if ad.releasePredictor == nil || t.ParsedData == nil || t.ParsedData.ReleaseGroup == "" {
+		return
+	}
+
+	airedAt, ok := times.get(mediaId, episode)
+	if !ok {
+		return
+	}
+
+	if publishedAt, err := time.Parse(time.RFC3339, t.Date); err == nil {
+		ad.mu.Lock()
+		maxLag := 2 * time.Duration(max(ad.settings.Interval, 1)) * time.Minute
+		ad.mu.Unlock()
+		if now.Sub(publishedAt) > maxLag {
+			return
+		}
+	}
+
+	ad.releasePredictor.Record(mediaId, episode, t.ParsedData.ReleaseGroup, airedAt, now)
+}
+
+// notifyOverdueEpisodes notifies the user once of each aired episode of a rule that has not been released
+// after the time its release is usually available, relative to the history of the release groups of the rule.
+func (ad *AutoDownloader) notifyOverdueEpisodes(
+	rule *anime.AutoDownloaderRule,
+	listEntry *anilist.AnimeListEntry,
+	localEntry *anime.LocalFileWrapperEntry,
+	items []*models.AutoDownloaderItem,
+	torrentsToDownload []*tmpTorrentToDownload,
+	times airingTimes,
+	now time.Time,
+) {
+	if ad.releasePredictor == nil {
+		return
+	}
+
+	mediaId := listEntry.GetMedia().GetID()
+	for episode, airedAt := range times[mediaId] {
+		if airedAt.After(now) || now.Sub(airedAt) > overdueWindow {
+			continue
+		}
+		if listEntry.Progress != nil && *listEntry.GetProgress() >= episode {
+			continue
+		}
+		if localEntry != nil {
+			if _, found := localEntry.FindLocalFileWithEpisodeNumber(episode); found {
+				continue
+			}
+		}
+		if slices.ContainsFunc(items, func(item *models.AutoDownloaderItem) bool { return item.Episode == episode }) ||
+			slices.ContainsFunc(torrentsToDownload, func(t *tmpTorrentToDownload) bool { return t.episode == episode }) {
+			continue
+		}
+
+		prediction := ad.releasePredictor.Predict(mediaId, airedAt, rule.ReleaseGroups)
+		if prediction == nil || !now.After(prediction.LateAt) {
+			continue
+		}
+
+		key := fmt.Sprintf("%d-%d", mediaId, episode)
+		ad.mu.Lock()
+		_, notified := ad.overdueNotified[key]
+		ad.overdueNotified[key] = struct{}{}
+		ad.mu.Unlock()
+		if notified {
+			continue
+		}
+
+		ad.logger.Info().Int("mediaId", mediaId).Int("episode", episode).Str("releaseGroup", prediction.ReleaseGroup).Msg("autodownloader: Episode is late")
+		ad.notifications.Notify(
+			notifications.TypeEpisodeOverdue,
+			fmt.Sprintf("Episode %d of %s is late, %s usually releases it %s after airing", episode, listEntry.GetMedia().GetRomajiTitleSafe(), prediction.ReleaseGroup, formatDelay(prediction.ExpectedAt.Sub(airedAt))),
+			mediaId,
+		)
+	}
+}
+
+// formatDelay formats a delay rounded to the minute, e.g. "2h", "1h30m" or "45m".
+func formatDelay(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d <= 0 {
+		return "right"
+	}
+	ret := d.String()
+	ret = strings.TrimSuffix(ret, "0s")
+	ret = strings.TrimSuffix(ret, "0m")
+	return ret
+}
@@ -0,0 +1,275 @@
+package autodownloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/placement"
+	"seanime/internal/notifier"
+	"seanime/internal/util"
+	"strings"
+	"time"
+)
+
+const (
+	// SequelImminentWindow is how far in the future a sequel that is not yet released can start to be proposed.
+	SequelImminentWindow = 14 * 24 * time.Hour
+)
+
+type (
+	// SequelProposal is sent to the client when a rule's media has finished and its sequel can be targeted.
+	SequelProposal struct {
+		Rule    *anime.AutoDownloaderRule `json:"rule"`
+		Prequel *anilist.BaseAnime        `json:"prequel"`
+		Sequel  *anilist.BaseAnime        `json:"sequel"`
+	}
+)
+
+// checkForSequels goes through the rules and looks for rules whose media has finished and has a sequel that is airing or about to air.
+// If AutoRetargetRules is enabled, the rules are retargeted to the sequel, otherwise the user is notified.
+// It returns the rules with the retargeted ones replaced.
+func (ad *AutoDownloader) checkForSequels(rules []*anime.AutoDownloaderRule) []*anime.AutoDownloaderRule {
+	defer util.HandlePanicInModuleThen("autodownloader/checkForSequels", func() {})
+
+	if ad.platformRef == nil || ad.platformRef.IsAbsent() || ad.animeCollection.IsAbsent() {
+		return rules
+	}
+
+	// Media that are already targeted by a rule
+	targeted := make(map[int]struct{}, len(rules))
+	for _, rule := range rules {
+		targeted[rule.MediaId] = struct{}{}
+	}
+
+	for i, rule := range rules {
+		listEntry, found := ad.animeCollection.MustGet().GetListEntryFromAnimeId(rule.MediaId)
+		if !found || listEntry.GetMedia() == nil {
+			continue
+		}
+		prequel := listEntry.GetMedia()
+		if prequel.GetStatus() == nil || *prequel.GetStatus() != anilist.MediaStatusFinished {
+			continue
+		}
+
+		media, err := ad.platformRef.Get().GetAnimeWithRelations(context.Background(), rule.MediaId)
+		if err != nil {
+			ad.logger.Debug().Err(err).Int("mediaId", rule.MediaId).Msg("autodownloader: Failed to fetch relations")
+			continue
+		}
+
+		sequel, found := findUpcomingSequel(media, time.Now())
+		if !found {
+			continue
+		}
+		if _, ok := targeted[sequel.GetID()]; ok {
+			continue // A rule already exists for the sequel
+		}
+
+		if !ad.settings.AutoRetargetRules {
+			ad.proposeSequel(rule, prequel, sequel)
+			continue
+		}
+
+		newRule, err := ad.RetargetRule(rule, prequel, sequel, false, true)
+		if err != nil {
+			ad.logger.Error().Err(err).Uint("ruleId", rule.DbID).Msg("autodownloader: Failed to retarget rule")
+			continue
+		}
+		targeted[sequel.GetID()] = struct{}{}
+		rules[i] = newRule
+	}
+
+	return rules
+}
+
+// proposeSequel notifies the user that a rule can be retargeted to the sequel of its media.
+// The user is only notified once per rule and sequel.
+func (ad *AutoDownloader) proposeSequel(rule *anime.AutoDownloaderRule, prequel *anilist.BaseAnime, sequel *anilist.BaseAnime) {
+	ad.mu.Lock()
+	if ad.sequelProposals[rule.DbID] == sequel.GetID() {
+		ad.mu.Unlock()
+		return
+	}
+	ad.sequelProposals[rule.DbID] = sequel.GetID()
+	ad.mu.Unlock()
+
+	ad.logger.Info().Uint("ruleId", rule.DbID).Int("sequelId", sequel.GetID()).Msg("autodownloader: Found sequel for rule")
+	ad.wsEventManager.SendEvent(events.AutoDownloaderRuleSequelFound, &SequelProposal{
+		Rule:    rule,
+		Prequel: prequel,
+		Sequel:  sequel,
+	})
+	notifier.GlobalNotifier.Notify(
+		notifier.AutoDownloader,
+		fmt.Sprintf("%s has finished. The rule can be retargeted to %s.", prequel.GetRomajiTitleSafe(), sequel.GetRomajiTitleSafe()),
+	)
+}
+
+// RetargetRule moves the rule to the sequel, or creates a copy of it for the sequel if clone is true.
+// The filters are preserved, the destination is adjusted if it was derived from the prequel's title,
+// and the episode offset is increased by the prequel's episode count so that continuous numbering still matches.
+func (ad *AutoDownloader) RetargetRule(rule *anime.AutoDownloaderRule, prequel *anilist.BaseAnime, sequel *anilist.BaseAnime, clone bool, automatic bool) (*anime.AutoDownloaderRule, error) {
+	if rule == nil || prequel == nil || sequel == nil {
+		return nil, errors.New("invalid rule or media")
+	}
+
+	newRule := getRetargetedRule(rule, prequel, sequel, clone, automatic, time.Now())
+
+	var err error
+	if clone {
+		err = db_bridge.InsertAutoDownloaderRule(ad.database, newRule)
+	} else {
+		err = db_bridge.UpdateAutoDownloaderRule(ad.database, newRule.DbID, newRule)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ad.mu.Lock()
+	delete(ad.sequelProposals, rule.DbID)
+	ad.retargetedMedia[sequel.GetID()] = sequel
+	ad.mu.Unlock()
+
+	action := "retargeted"
+	if clone {
+		action = "cloned"
+	}
+	ad.logger.Info().Uint("ruleId", newRule.DbID).Int("fromMediaId", prequel.GetID()).Int("toMediaId", sequel.GetID()).Msgf("autodownloader: Rule %s to sequel", action)
+	ad.wsEventManager.SendEvent(events.AutoDownloaderRuleRetargeted, newRule)
+	notifier.GlobalNotifier.Notify(
+		notifier.AutoDownloader,
+		fmt.Sprintf("The rule for %s has been %s to %s.", prequel.GetRomajiTitleSafe(), action, sequel.GetRomajiTitleSafe()),
+	)
+
+	return newRule, nil
+}
+
+// getRetargetedRule returns a copy of the rule targeting the sequel.
+func getRetargetedRule(rule *anime.AutoDownloaderRule, prequel *anilist.BaseAnime, sequel *anilist.BaseAnime, clone bool, automatic bool, now time.Time) *anime.AutoDownloaderRule {
+	newRule := *rule
+	newRule.MediaId = sequel.GetID()
+	newRule.ReleaseGroups = append([]string(nil), rule.ReleaseGroups...)
+	newRule.Resolutions = append([]string(nil), rule.Resolutions...)
+	newRule.AdditionalTerms = append([]string(nil), rule.AdditionalTerms...)
+	newRule.History = append([]*anime.AutoDownloaderRuleHistoryEvent(nil), rule.History...)
+
+	// Selected episodes belong to the prequel
+	if newRule.EpisodeType == anime.AutoDownloaderRuleEpisodeSelected {
+		newRule.EpisodeType = anime.AutoDownloaderRuleEpisodeRecent
+		newRule.EpisodeNumbers = nil
+	}
+
+	// Update the comparison title only if it was the prequel's title
+	if rule.ComparisonTitle == "" || isMediaTitle(rule.ComparisonTitle, prequel) {
+		newRule.ComparisonTitle = sequel.GetRomajiTitleSafe()
+	}
+
+	// Adjust the destination only if it was derived from the prequel's title
+	if filepath.Base(filepath.Clean(rule.Destination)) == placement.SanitizeDirectoryName(prequel.GetRomajiTitleSafe()) {
+		newRule.Destination = placement.GetDestination(filepath.Dir(filepath.Clean(rule.Destination)), sequel)
+	}
+
+	// Groups that keep absolute numbering continue from the prequel's last episode
+	// The offset of a cour numbered from 1 does not apply to the sequel
+	newRule.EpisodeOffset = min(rule.EpisodeOffset, 0) - max(prequel.GetTotalEpisodeCount(), 0)
+
+	eventType := anime.AutoDownloaderRuleHistoryRetargeted
+	if clone {
+		eventType = anime.AutoDownloaderRuleHistoryCloned
+		newRule.DbID = 0
+	}
+	newRule.History = append(newRule.History, &anime.AutoDownloaderRuleHistoryEvent{
+		Type:          eventType,
+		FromMediaId:   prequel.GetID(),
+		ToMediaId:     sequel.GetID(),
+		EpisodeOffset: newRule.EpisodeOffset,
+		Automatic:     automatic,
+		Date:          now,
+	})
+
+	return &newRule
+}
+
+// findUpcomingSequel returns the sequel of the media if it is releasing or starts soon.
+func findUpcomingSequel(media *anilist.CompleteAnime, now time.Time) (*anilist.BaseAnime, bool) {
+	if media == nil || media.GetRelations() == nil {
+		return nil, false
+	}
+
+	for _, edge := range media.GetRelations().GetEdges() {
+		if edge.GetRelationType() == nil || *edge.GetRelationType() != anilist.MediaRelationSequel {
+			continue
+		}
+		node := edge.GetNode()
+		if node == nil || node.GetStatus() == nil {
+			continue
+		}
+		// Ignore specials and movies, they are not continuations of the series
+		if node.GetFormat() != nil && *node.GetFormat() != anilist.MediaFormatTv && *node.GetFormat() != anilist.MediaFormatOna {
+			continue
+		}
+
+		switch *node.GetStatus() {
+		case anilist.MediaStatusReleasing:
+			return node, true
+		case anilist.MediaStatusNotYetReleased:
+			if isImminent(node, now) {
+				return node, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// isImminent returns true if the media starts airing within SequelImminentWindow.
+func isImminent(media *anilist.BaseAnime, now time.Time) bool {
+	if media.GetNextAiringEpisode() != nil && media.GetNextAiringEpisode().GetAiringAt() > 0 {
+		airingAt := time.Unix(int64(media.GetNextAiringEpisode().GetAiringAt()), 0)
+		return airingAt.Sub(now) <= SequelImminentWindow
+	}
+
+	startDate := media.GetStartDate()
+	if startDate == nil || startDate.GetYear() == nil || startDate.GetMonth() == nil || startDate.GetDay() == nil {
+		return false
+	}
+	start := time.Date(*startDate.GetYear(), time.Month(*startDate.GetMonth()), *startDate.GetDay(), 0, 0, 0, 0, time.UTC)
+	return start.Sub(now) <= SequelImminentWindow
+}
+
+func isMediaTitle(title string, media *anilist.BaseAnime) bool {
+	for _, t := range media.GetAllTitles() {
+		if t != nil && strings.EqualFold(strings.TrimSpace(*t), strings.TrimSpace(title)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRetargetedRuleListEntry returns a list entry for a retargeted rule whose media is not in the collection.
+func (ad *AutoDownloader) getRetargetedRuleListEntry(rule *anime.AutoDownloaderRule) (*anilist.AnimeListEntry, bool) {
+	ad.mu.Lock()
+	media, found := ad.retargetedMedia[rule.MediaId]
+	ad.mu.Unlock()
+
+	if !found {
+		if ad.platformRef == nil || ad.platformRef.IsAbsent() {
+			return nil, false
+		}
+		var err error
+		media, err = ad.platformRef.Get().GetAnime(context.Background(), rule.MediaId)
+		if err != nil || media == nil {
+			return nil, false
+		}
+		ad.mu.Lock()
+		ad.retargetedMedia[rule.MediaId] = media
+		ad.mu.Unlock()
+	}
+
+	return &anilist.AnimeListEntry{Media: media}, true
+}
@@ -0,0 +1,97 @@
+package autodownloader
+
+import (
+	"fmt"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/library/anime"
+	torrent_audio "seanime/internal/torrents/audio"
+
+	"github.com/5rahim/habari"
+)
+
+type (
+	// RuleCheckResult tells whether a torrent would be downloaded by a rule.
+	RuleCheckResult struct {
+		Matched bool `json:"matched"`
+		// Reason explains why the torrent does not match, or which episode it matches
+		Reason string `json:"reason"`
+		// ResolvedDestination is the directory the torrent would be downloaded to
+		ResolvedDestination string `json:"resolvedDestination"`
+	}
+)
+
+// CheckTorrentAgainstRule runs the same checks as the scheduled runs against a single torrent.
+// Nothing is downloaded or recorded, and the hooks are not triggered.
+// Unlike the scheduled runs, disabled rules are checked so that they can be verified before being enabled.
+func (ad *AutoDownloader) CheckTorrentAgainstRule(rule *anime.AutoDownloaderRule, t *hibiketorrent.AnimeTorrent) (*RuleCheckResult, error) {
+	if rule == nil || t == nil {
+		return nil, fmt.Errorf("autodownloader: rule and torrent are required")
+	}
+	if t.Name == "" {
+		return nil, fmt.Errorf("autodownloader: torrent name is required")
+	}
+
+	ret := &RuleCheckResult{
+		ResolvedDestination: rule.Destination,
+	}
+
+	listEntry, found := ad.getRuleListEntry(rule)
+	if !found {
+		ret.Reason = "The anime of the rule is not in your AniList collection"
+		return ret, nil
+	}
+
+	parsedData := habari.Parse(t.Name)
+	normalized := &NormalizedTorrent{
+		AnimeTorrent: *t,
+		ParsedData:   parsedData,
+		Audio:        torrent_audio.Detect(t.Name, parsedData),
+	}
+
+	// Skip torrents that are already in the torrent client
+	if ad.torrentClientRepository != nil && normalized.InfoHash != "" {
+		existingTorrents, err := ad.torrentClientRepository.GetList()
+		if err == nil {
+			for _, et := range existingTorrents {
+				if et.Hash == normalized.InfoHash {
+					ret.Reason = "The torrent has already been added to the torrent client"
+					return ret, nil
+				}
+			}
+		}
+	}
+
+	rejectedTorrents, err := ad.database.GetAutoDownloaderRejectedTorrents(rule.DbID)
+	if err == nil && isRejectedTorrent(rejectedTorrents, normalized) {
+		ret.Reason = "The torrent has been rejected for this rule"
+		return ret, nil
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(ad.database)
+	if err != nil {
+		return nil, err
+	}
+	localEntry, _ := anime.NewLocalFileWrapper(lfs).GetLocalEntryById(listEntry.GetMedia().GetID())
+
+	items, err := ad.database.GetAutoDownloaderItemByMediaId(listEntry.GetMedia().GetID())
+	if err != nil {
+		items = make([]*models.AutoDownloaderItem, 0)
+	}
+
+	episode, reason := ad.matchTorrentToRule(normalized, rule, listEntry, localEntry, items)
+	if reason != "" {
+		ret.Reason = reason
+		return ret, nil
+	}
+
+	if audioPreference := ad.getAudioPreference(rule); !audioPreference.Accepts(normalized.Audio) {
+		ret.Reason = fmt.Sprintf("The audio of the torrent (%s) is not accepted by the audio preference (%s)", normalized.Audio, audioPreference)
+		return ret, nil
+	}
+
+	ret.Matched = true
+	ret.Reason = fmt.Sprintf("The torrent matches episode %d", episode)
+	return ret, nil
+}
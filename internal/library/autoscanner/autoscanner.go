@@ -8,6 +8,7 @@ import (
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/database/models"
 	"seanime/internal/events"
+	"seanime/internal/library/anime"
 	"seanime/internal/library/autodownloader"
 	"seanime/internal/library/scanner"
 	"seanime/internal/library/summary"
@@ -37,6 +38,10 @@ type (
 		autoDownloader      *autodownloader.AutoDownloader // AutoDownloader instance is required to refresh queue.
 		metadataProviderRef *util.Ref[metadata_provider.Provider]
 		logsDir             string
+		excludedPathsFunc   func() []string
+		isPausedFunc        func() bool // Returns true if the scans should be skipped (maintenance mode)
+		onScannedFunc       func(lfs []*anime.LocalFile)
+		scanFunc            func() ([]*anime.LocalFile, error)
 	}
 	NewAutoScannerOptions struct {
 		Database            *db.Database
@@ -48,6 +53,15 @@ type (
 		WaitTime            time.Duration
 		MetadataProviderRef *util.Ref[metadata_provider.Provider]
 		LogsDir             string
+		// ExcludedPathsFunc returns the directories that should not be scanned
+		ExcludedPathsFunc func() []string
+		// IsPausedFunc returns true if the scans should be skipped
+		IsPausedFunc func() bool
+		// OnScannedFunc is called with the local files after a successful scan
+		OnScannedFunc func(lfs []*anime.LocalFile)
+		// ScanFunc replaces the built-in scan, e.g. to queue it with the other scans of the library.
+		// It is responsible for saving the local files and the scan summary, OnScannedFunc is not called.
+		ScanFunc func() ([]*anime.LocalFile, error)
 	}
 )
 
@@ -72,6 +86,10 @@ func New(opts *NewAutoScannerOptions) *AutoScanner {
 		autoDownloader:      opts.AutoDownloader,
 		metadataProviderRef: opts.MetadataProviderRef,
 		logsDir:             opts.LogsDir,
+		excludedPathsFunc:   opts.ExcludedPathsFunc,
+		isPausedFunc:        opts.IsPausedFunc,
+		onScannedFunc:       opts.OnScannedFunc,
+		scanFunc:            opts.ScanFunc,
 	}
 }
 
@@ -179,6 +197,11 @@ func (as *AutoScanner) scan() {
 		as.logger.Error().Msg("autoscanner: Recovered from panic")
 	})
 
+	if as.isPausedFunc != nil && as.isPausedFunc() {
+		as.logger.Debug().Msg("autoscanner: Skipping scan, maintenance mode is active")
+		return
+	}
+
 	// Create scan summary logger
 	scanSummaryLogger := summary.NewScanSummaryLogger()
 
@@ -186,6 +209,15 @@ func (as *AutoScanner) scan() {
 	as.wsEventManager.SendEvent(events.AutoScanStarted, nil)
 	defer as.wsEventManager.SendEvent(events.AutoScanCompleted, nil)
 
+	if as.scanFunc != nil {
+		if _, err := as.scanFunc(); err != nil {
+			as.logger.Error().Err(err).Msg("autoscanner: Failed to scan library")
+			return
+		}
+		notifier.GlobalNotifier.Notify(notifier.AutoScanner, "Your library has been scanned.")
+		return
+	}
+
 	settings, err := as.db.GetSettings()
 	if err != nil || settings == nil {
 		as.logger.Error().Err(err).Msg("autoscanner: Failed to get settings")
@@ -223,6 +255,11 @@ func (as *AutoScanner) scan() {
 		}
 	}
 
+	var excludedPaths []string
+	if as.excludedPathsFunc != nil {
+		excludedPaths = as.excludedPathsFunc()
+	}
+
 	// Create a new scanner
 	sc := scanner.Scanner{
 		DirPath:             settings.Library.LibraryPath,
@@ -240,6 +277,7 @@ func (as *AutoScanner) scan() {
 		MatchingThreshold:   as.settings.ScannerMatchingThreshold,
 		MatchingAlgorithm:   as.settings.ScannerMatchingAlgorithm,
 		PreMatchMap:         preMatchMap,
+		ExcludedPaths:       excludedPaths,
 	}
 
 	allLfs, err := sc.Scan(context.Background())
@@ -262,6 +300,13 @@ func (as *AutoScanner) scan() {
 			return
 		}
 
+		if err := as.db.DeleteTorrentPreMatchesByDestination(sc.MatchedPreMatches); err != nil {
+			as.logger.Error().Err(err).Msg("autoscanner: Failed to delete matched torrent pre-matches")
+		}
+
+		if as.onScannedFunc != nil {
+			as.onScannedFunc(allLfs)
+		}
 	}
 
 	// Save the scan summary
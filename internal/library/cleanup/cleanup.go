@@ -0,0 +1,485 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifier"
+	"seanime/internal/util"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+)
+
+const (
+	PolicyNone   = ""
+	PolicyNotify = "notify"
+	PolicyTrash  = "trash"
+
+	ReasonDropped = "dropped"
+	ReasonRemoved = "removed"
+
+	// MinGracePeriod is the minimum time between the transition and the moment the files are moved to the trash.
+	MinGracePeriod = 7 * 24 * time.Hour
+)
+
+type (
+	// Manager applies the cleanup policy to the files of media that are dropped or removed from the AniList list.
+	//
+	// The cleanup is done in two phases:
+	//  1. When the collection is refreshed, the media that transitioned to DROPPED or were removed from the list become candidates.
+	//  2. If the policy is "trash", the files of the candidates are moved to the trash once their grace period is over.
+	//
+	// The files are never deleted, they are moved to the trash directory so that the action can be undone manually.
+	Manager struct {
+		db             *db.Database
+		logger         *zerolog.Logger
+		wsEventManager events.WSEventManagerInterface
+		trashDir       string
+
+		mu       sync.Mutex
+		settings Settings
+		// now is replaced in tests
+		now func() time.Time
+	}
+
+	Settings struct {
+		Policy      string
+		GracePeriod time.Duration
+	}
+
+	NewManagerOptions struct {
+		DB             *db.Database
+		Logger         *zerolog.Logger
+		WSEventManager events.WSEventManagerInterface
+		// TrashDir is the directory the files are moved to
+		TrashDir string
+	}
+
+	// Candidate is a media whose files are pending cleanup.
+	Candidate struct {
+		MediaId        int       `json:"mediaId"`
+		Title          string    `json:"title"`
+		Reason         string    `json:"reason"`
+		TransitionedAt time.Time `json:"transitionedAt"`
+		// ScheduledAt is the date after which the files are moved to the trash, nil if they are not scheduled
+		ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+		Exempt      bool       `json:"exempt"`
+		FileCount   int        `json:"fileCount"`
+		// Size is the total size of the files in bytes
+		Size  int64    `json:"size"`
+		Paths []string `json:"paths"`
+	}
+
+	// transition is a change of list status detected between two collections.
+	transition struct {
+		mediaId int
+		title   string
+		reason  string
+	}
+)
+
+func New(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		db:             opts.DB,
+		logger:         opts.Logger,
+		wsEventManager: opts.WSEventManager,
+		trashDir:       opts.TrashDir,
+		now:            time.Now,
+	}
+}
+
+// SetSettings updates the policy. The grace period cannot be less than MinGracePeriod.
+func (m *Manager) SetSettings(settings *models.LibrarySettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if settings == nil {
+		m.settings = Settings{}
+		return
+	}
+
+	m.settings = Settings{
+		Policy:      settings.DroppedCleanupPolicy,
+		GracePeriod: max(time.Duration(settings.DroppedCleanupGraceDays)*24*time.Hour, MinGracePeriod),
+	}
+}
+
+// Evaluate detects the media that were dropped or removed since the last evaluated collection and applies the policy.
+// It should be called every time the collection is refreshed.
+func (m *Manager) Evaluate(collection *anilist.AnimeCollection) {
+	defer util.HandlePanicInModuleThen("library/cleanup/Evaluate", func() {})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := getSnapshot(collection)
+	// An empty collection is most likely the result of a failed request, not of the user removing everything
+	if len(current) == 0 {
+		return
+	}
+
+	previous, found, err := m.db.GetLibraryCleanupSnapshot()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("cleanup: Failed to get the previous snapshot")
+		return
+	}
+	if err := m.db.SaveLibraryCleanupSnapshot(current); err != nil {
+		m.logger.Error().Err(err).Msg("cleanup: Failed to save the snapshot")
+		return
+	}
+	// Nothing to compare on the first run
+	if !found {
+		return
+	}
+
+	candidates, err := m.db.GetLibraryCleanupCandidates()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("cleanup: Failed to get the candidates")
+		return
+	}
+
+	// Cancel the candidates whose media are back in the list
+	for _, c := range candidates {
+		if entry, ok := current[c.MediaID]; ok && entry.Status != string(anilist.MediaListStatusDropped) {
+			m.logger.Debug().Int("mediaId", c.MediaID).Msg("cleanup: Media is back in the list, cancelling cleanup")
+			_ = m.db.DeleteLibraryCleanupCandidate(c.MediaID)
+		}
+	}
+
+	if m.settings.Policy != PolicyNotify && m.settings.Policy != PolicyTrash {
+		return
+	}
+
+	m.addCandidates(detectTransitions(previous, current))
+	m.schedule()
+	m.trashDueCandidates(current)
+}
+
+func (m *Manager) addCandidates(transitions []*transition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	exemptions, err := m.db.GetLibraryCleanupExemptions()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("cleanup: Failed to get the exemptions")
+		return
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(m.db)
+	if err != nil {
+		m.logger.Error().Err(err).Msg("cleanup: Failed to get the local files")
+		return
+	}
+	filesByMedia := lo.GroupBy(lfs, func(lf *anime.LocalFile) int { return lf.MediaId })
+
+	now := m.now()
+	added := make([]string, 0)
+	for _, t := range transitions {
+		if _, ok := exemptions[t.mediaId]; ok {
+			continue
+		}
+		// Nothing to clean up
+		if len(filesByMedia[t.mediaId]) == 0 {
+			continue
+		}
+
+		err := m.db.InsertLibraryCleanupCandidate(&models.LibraryCleanupCandidate{
+			MediaID:        t.mediaId,
+			Title:          t.title,
+			Reason:         t.reason,
+			TransitionedAt: now,
+		})
+		if err != nil {
+			m.logger.Error().Err(err).Int("mediaId", t.mediaId).Msg("cleanup: Failed to add candidate")
+			continue
+		}
+		m.logger.Info().Int("mediaId", t.mediaId).Str("reason", t.reason).Msg("cleanup: Media is a cleanup candidate")
+		added = append(added, t.title)
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	m.wsEventManager.SendEvent(events.LibraryCleanupCandidatesAdded, nil)
+	message := fmt.Sprintf("%s was dropped or removed from your list, its files can be cleaned up.", added[0])
+	if len(added) > 1 {
+		message = fmt.Sprintf("%d anime were dropped or removed from your list, their files can be cleaned up.", len(added))
+	}
+	notifier.GlobalNotifier.Notify(notifier.LibraryCleanup, message)
+}
+
+// schedule sets the deletion date of the candidates if the policy is "trash", or clears it otherwise.
+// Candidates that were added before the policy was set to "trash" are scheduled MinGracePeriod from now at the earliest.
+func (m *Manager) schedule() {
+	candidates, err := m.db.GetLibraryCleanupCandidates()
+	if err != nil {
+		return
+	}
+
+	now := m.now()
+	for _, c := range candidates {
+		if m.settings.Policy != PolicyTrash {
+			if c.ScheduledAt != nil {
+				c.ScheduledAt = nil
+				_ = m.db.SaveLibraryCleanupCandidate(c)
+			}
+			continue
+		}
+		if c.ScheduledAt != nil {
+			continue
+		}
+		scheduledAt := c.TransitionedAt.Add(m.settings.GracePeriod)
+		if earliest := now.Add(MinGracePeriod); c.TransitionedAt.Before(now) && scheduledAt.Before(earliest) {
+			scheduledAt = earliest
+		}
+		c.ScheduledAt = &scheduledAt
+		_ = m.db.SaveLibraryCleanupCandidate(c)
+	}
+}
+
+// trashDueCandidates moves the files of the candidates whose grace period is over to the trash.
+func (m *Manager) trashDueCandidates(current map[int]*db.LibraryCleanupSnapshotEntry) {
+	if m.settings.Policy != PolicyTrash {
+		return
+	}
+
+	candidates, err := m.db.GetLibraryCleanupCandidates()
+	if err != nil {
+		return
+	}
+	exemptions, err := m.db.GetLibraryCleanupExemptions()
+	if err != nil {
+		return
+	}
+
+	now := m.now()
+	for _, c := range candidates {
+		if !isDue(c, now) {
+			continue
+		}
+		if _, ok := exemptions[c.MediaID]; ok {
+			continue
+		}
+		// Make sure that the media is still dropped or removed
+		if entry, ok := current[c.MediaID]; ok && entry.Status != string(anilist.MediaListStatusDropped) {
+			continue
+		}
+
+		if err := m.trashMediaFiles(c.MediaID); err != nil {
+			m.logger.Error().Err(err).Int("mediaId", c.MediaID).Msg("cleanup: Failed to move the files to the trash")
+			continue
+		}
+		_ = m.db.DeleteLibraryCleanupCandidate(c.MediaID)
+		m.logger.Info().Int("mediaId", c.MediaID).Msg("cleanup: Moved the files to the trash")
+	}
+}
+
+func (m *Manager) trashMediaFiles(mediaId int) error {
+	lfs, lfsId, err := db_bridge.GetLocalFiles(m.db)
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(m.trashDir, fmt.Sprintf("%d-%s", mediaId, m.now().Format("20060102-150405")))
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return err
+	}
+
+	trashed := make(map[string]struct{})
+	var errs []error
+	for _, lf := range lfs {
+		if lf.MediaId != mediaId {
+			continue
+		}
+		if err := moveFile(lf.Path, filepath.Join(dest, filepath.Base(lf.Path))); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		trashed[lf.Path] = struct{}{}
+	}
+
+	if len(trashed) > 0 {
+		lfs = lo.Filter(lfs, func(lf *anime.LocalFile, _ int) bool {
+			_, ok := trashed[lf.Path]
+			return !ok
+		})
+		if _, err := db_bridge.SaveLocalFiles(m.db, lfsId, lfs); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TrashDue moves the files of the candidates whose grace period is over to the trash.
+// The candidates are also handled when the collection is evaluated, this runs daily so that they are moved while the collection cannot be refreshed.
+func (m *Manager) TrashDue() {
+	defer util.HandlePanicInModuleThen("library/cleanup/TrashDue", func() {})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, found, err := m.db.GetLibraryCleanupSnapshot()
+	if err != nil || !found {
+		return
+	}
+	m.trashDueCandidates(current)
+}
+
+// GetCandidates returns the media whose files are pending cleanup.
+func (m *Manager) GetCandidates() ([]*Candidate, error) {
+	candidates, err := m.db.GetLibraryCleanupCandidates()
+	if err != nil {
+		return nil, err
+	}
+	exemptions, err := m.db.GetLibraryCleanupExemptions()
+	if err != nil {
+		return nil, err
+	}
+	lfs, _, err := db_bridge.GetLocalFiles(m.db)
+	if err != nil {
+		return nil, err
+	}
+	filesByMedia := lo.GroupBy(lfs, func(lf *anime.LocalFile) int { return lf.MediaId })
+
+	ret := make([]*Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		_, exempt := exemptions[c.MediaID]
+		candidate := &Candidate{
+			MediaId:        c.MediaID,
+			Title:          c.Title,
+			Reason:         c.Reason,
+			TransitionedAt: c.TransitionedAt,
+			ScheduledAt:    c.ScheduledAt,
+			Exempt:         exempt,
+			Paths:          make([]string, 0),
+		}
+		for _, lf := range filesByMedia[c.MediaID] {
+			candidate.FileCount++
+			candidate.Paths = append(candidate.Paths, lf.Path)
+			if info, err := os.Stat(lf.Path); err == nil {
+				candidate.Size += info.Size()
+			}
+		}
+		ret = append(ret, candidate)
+	}
+
+	return ret, nil
+}
+
+// SetExempt sets whether the files of the media are never cleaned up.
+func (m *Manager) SetExempt(mediaId int, exempt bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.db.SetLibraryCleanupExemption(mediaId, exempt)
+}
+
+// DismissCandidate removes the media from the candidates without touching its files.
+func (m *Manager) DismissCandidate(mediaId int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.db.DeleteLibraryCleanupCandidate(mediaId)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+func getSnapshot(collection *anilist.AnimeCollection) map[int]*db.LibraryCleanupSnapshotEntry {
+	ret := make(map[int]*db.LibraryCleanupSnapshotEntry)
+	if collection == nil || collection.GetMediaListCollection() == nil {
+		return ret
+	}
+	for _, list := range collection.GetMediaListCollection().GetLists() {
+		for _, entry := range list.GetEntries() {
+			if entry.GetMedia() == nil {
+				continue
+			}
+			status := ""
+			if entry.GetStatus() != nil {
+				status = string(*entry.GetStatus())
+			}
+			ret[entry.GetMedia().GetID()] = &db.LibraryCleanupSnapshotEntry{
+				Status: status,
+				Title:  entry.GetMedia().GetTitleSafe(),
+			}
+		}
+	}
+	return ret
+}
+
+// detectTransitions returns the media that were dropped or removed from the list.
+func detectTransitions(previous map[int]*db.LibraryCleanupSnapshotEntry, current map[int]*db.LibraryCleanupSnapshotEntry) []*transition {
+	ret := make([]*transition, 0)
+	for mediaId, prev := range previous {
+		if prev == nil {
+			continue
+		}
+		curr, ok := current[mediaId]
+		switch {
+		case !ok:
+			ret = append(ret, &transition{mediaId: mediaId, title: prev.Title, reason: ReasonRemoved})
+		case curr.Status == string(anilist.MediaListStatusDropped) && prev.Status != string(anilist.MediaListStatusDropped):
+			ret = append(ret, &transition{mediaId: mediaId, title: curr.Title, reason: ReasonDropped})
+		}
+	}
+	return ret
+}
+
+// isDue returns true if the files of the candidate can be moved to the trash.
+// It is never the case within MinGracePeriod of the transition, regardless of the scheduled date.
+func isDue(c *models.LibraryCleanupCandidate, now time.Time) bool {
+	if c.ScheduledAt == nil || now.Before(*c.ScheduledAt) {
+		return false
+	}
+	return !now.Before(c.TransitionedAt.Add(MinGracePeriod))
+}
+
+// moveFile renames the file, or copies it and removes the original if it is on another device.
+func moveFile(src, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		dest = dest + "." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	} else if errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+
+	_ = in.Close()
+	return os.Remove(src)
+}
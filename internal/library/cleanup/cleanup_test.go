@@ -0,0 +1,166 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollection(statuses map[int]anilist.MediaListStatus) *anilist.AnimeCollection {
+	entries := make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0, len(statuses))
+	for mediaId, status := range statuses {
+		entries = append(entries, &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			Status: lo.ToPtr(status),
+			Media: &anilist.BaseAnime{
+				ID:    mediaId,
+				Title: &anilist.BaseAnime_Title{UserPreferred: lo.ToPtr("Anime")},
+			},
+		})
+	}
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{Entries: entries}},
+		},
+	}
+}
+
+func TestDetectTransitions(t *testing.T) {
+	previous := map[int]*db.LibraryCleanupSnapshotEntry{
+		1: {Status: "CURRENT"},
+		2: {Status: "CURRENT"},
+		3: {Status: "DROPPED"},
+		4: {Status: "PAUSED", Title: "Removed"},
+	}
+	current := map[int]*db.LibraryCleanupSnapshotEntry{
+		1: {Status: "CURRENT"},
+		2: {Status: "DROPPED"},
+		3: {Status: "DROPPED"},
+		5: {Status: "DROPPED"},
+	}
+
+	transitions := detectTransitions(previous, current)
+	require.Len(t, transitions, 2)
+
+	byId := lo.KeyBy(transitions, func(t *transition) int { return t.mediaId })
+	assert.Equal(t, ReasonDropped, byId[2].reason)
+	assert.Equal(t, ReasonRemoved, byId[4].reason)
+	assert.Equal(t, "Removed", byId[4].title)
+}
+
+func TestIsDue(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+
+	// Never due within 7 days of the transition, even if scheduled
+	assert.False(t, isDue(&models.LibraryCleanupCandidate{TransitionedAt: now.Add(-6 * 24 * time.Hour), ScheduledAt: &past}, now))
+	assert.True(t, isDue(&models.LibraryCleanupCandidate{TransitionedAt: now.Add(-8 * 24 * time.Hour), ScheduledAt: &past}, now))
+	assert.False(t, isDue(&models.LibraryCleanupCandidate{TransitionedAt: now.Add(-8 * 24 * time.Hour)}, now))
+}
+
+func TestEvaluate(t *testing.T) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "cleanup_test", logger)
+	require.NoError(t, err)
+
+	libraryDir := t.TempDir()
+	trashDir := t.TempDir()
+
+	filePath := filepath.Join(libraryDir, "Anime - 01.mkv")
+	require.NoError(t, os.WriteFile(filePath, []byte("video"), 0644))
+	exemptPath := filepath.Join(libraryDir, "Other - 01.mkv")
+	require.NoError(t, os.WriteFile(exemptPath, []byte("video"), 0644))
+
+	_, err = db_bridge.InsertLocalFiles(database, []*anime.LocalFile{
+		{Path: filePath, MediaId: 1},
+		{Path: exemptPath, MediaId: 2},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	m := New(&NewManagerOptions{
+		DB:             database,
+		Logger:         logger,
+		WSEventManager: events.NewMockWSEventManager(logger),
+		TrashDir:       trashDir,
+	})
+	m.now = func() time.Time { return now }
+	m.SetSettings(&models.LibrarySettings{DroppedCleanupPolicy: PolicyTrash, DroppedCleanupGraceDays: 1})
+
+	require.NoError(t, m.SetExempt(2, true))
+
+	// First run only saves the snapshot
+	m.Evaluate(newTestCollection(map[int]anilist.MediaListStatus{1: anilist.MediaListStatusCurrent, 2: anilist.MediaListStatusCurrent}))
+	candidates, err := m.GetCandidates()
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	dropped := newTestCollection(map[int]anilist.MediaListStatus{1: anilist.MediaListStatusDropped, 2: anilist.MediaListStatusDropped})
+	m.Evaluate(dropped)
+
+	candidates, err = m.GetCandidates()
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, 1, candidates[0].MediaId)
+	assert.Equal(t, int64(5), candidates[0].Size)
+	// The grace period cannot be less than 7 days
+	require.NotNil(t, candidates[0].ScheduledAt)
+	assert.WithinDuration(t, now.Add(MinGracePeriod), *candidates[0].ScheduledAt, time.Second)
+
+	// Not due yet
+	now = now.Add(6 * 24 * time.Hour)
+	m.Evaluate(dropped)
+	assert.FileExists(t, filePath)
+
+	now = now.Add(2 * 24 * time.Hour)
+	m.Evaluate(dropped)
+	assert.NoFileExists(t, filePath)
+	assert.FileExists(t, exemptPath)
+
+	candidates, err = m.GetCandidates()
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	lfs, _, err := db_bridge.GetLocalFiles(database)
+	require.NoError(t, err)
+	require.Len(t, lfs, 1)
+	assert.Equal(t, exemptPath, lfs[0].Path)
+
+	trashed, err := filepath.Glob(filepath.Join(trashDir, "1-*", "Anime - 01.mkv"))
+	require.NoError(t, err)
+	assert.Len(t, trashed, 1)
+}
+
+func TestEvaluate_BackInList(t *testing.T) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "cleanup_test", logger)
+	require.NoError(t, err)
+
+	m := New(&NewManagerOptions{
+		DB:             database,
+		Logger:         logger,
+		WSEventManager: events.NewMockWSEventManager(logger),
+		TrashDir:       t.TempDir(),
+	})
+	m.SetSettings(&models.LibrarySettings{DroppedCleanupPolicy: PolicyNotify})
+
+	require.NoError(t, database.InsertLibraryCleanupCandidate(&models.LibraryCleanupCandidate{MediaID: 1, Reason: ReasonDropped, TransitionedAt: time.Now()}))
+	require.NoError(t, database.SaveLibraryCleanupSnapshot(map[int]*db.LibraryCleanupSnapshotEntry{1: {Status: "DROPPED"}}))
+
+	m.Evaluate(newTestCollection(map[int]anilist.MediaListStatus{1: anilist.MediaListStatusCurrent}))
+
+	candidates, err := database.GetLibraryCleanupCandidates()
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+}
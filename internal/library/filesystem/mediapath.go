@@ -9,6 +9,9 @@ import (
 	"seanime/internal/util"
 	"sort"
 	"strings"
+	"sync"
+
+	"github.com/samber/lo"
 )
 
 type SeparatedFilePath struct {
@@ -185,6 +188,68 @@ func GetMediaFilePathsFromDirS(oDirPath string) ([]string, error) {
 	return filePaths, nil
 }
 
+// GetMediaFilePathsFromDirC is the same as GetMediaFilePathsFromDirS but reads the subdirectories of the directory concurrently.
+// concurrency is the number of subdirectories read at the same time, the directory is read by GetMediaFilePathsFromDirS if it is less than 2.
+func GetMediaFilePathsFromDirC(oDirPath string, concurrency int) ([]string, error) {
+	if concurrency < 2 {
+		return GetMediaFilePathsFromDirS(oDirPath)
+	}
+
+	dirPath, err := filepath.Abs(oDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve path: %w", err)
+	}
+	if resolvedPath, err := filepath.EvalSymlinks(dirPath); err == nil {
+		dirPath = resolvedPath
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not traverse directory %s: %w", dirPath, err)
+	}
+
+	filePaths := make([]string, 0)
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, concurrency)
+
+	for _, entry := range entries {
+		path := filepath.Join(dirPath, entry.Name())
+
+		// Symlinks are resolved by GetMediaFilePathsFromDirS
+		if !entry.IsDir() && entry.Type()&os.ModeSymlink == 0 {
+			ext := strings.ToLower(filepath.Ext(path))
+			if util.IsValidMediaFile(path) && util.IsValidVideoExtension(ext) {
+				filePaths = append(filePaths, path)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Unreadable subdirectories are skipped like in GetMediaFilePathsFromDirS
+			subPaths, err := GetMediaFilePathsFromDirS(path)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			filePaths = append(filePaths, subPaths...)
+			mu.Unlock()
+		}(path)
+	}
+	wg.Wait()
+
+	// Subdirectories can link to the same files
+	filePaths = lo.Uniq(filePaths)
+	sort.Strings(filePaths)
+
+	return filePaths, nil
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 
 func FileExists(filePath string) bool {
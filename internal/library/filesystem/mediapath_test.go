@@ -124,6 +124,35 @@ func TestGetVideoFilePathsFromDir_WithSymlinks(t *testing.T) {
 	}
 }
 
+func TestGetMediaFilePathsFromDirC(t *testing.T) {
+	libDir := t.TempDir()
+
+	createFile(t, filepath.Join(libDir, "Movie.mkv"))
+	createFile(t, filepath.Join(libDir, "notes.txt"))
+	for i := 1; i <= 5; i++ {
+		dir := filepath.Join(libDir, fmt.Sprintf("Anime%d", i))
+		if err := os.MkdirAll(filepath.Join(dir, "Season 1"), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %s", err)
+		}
+		createFile(t, filepath.Join(dir, fmt.Sprintf("Anime%d_1.mkv", i)))
+		createFile(t, filepath.Join(dir, "Season 1", fmt.Sprintf("Anime%d_S1_1.mp4", i)))
+	}
+
+	expected, err := GetMediaFilePathsFromDirS(libDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	assert.Len(t, expected, 11)
+
+	for _, concurrency := range []int{0, 1, 2, 8} {
+		filePaths, err := GetMediaFilePathsFromDirC(libDir, concurrency)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.ElementsMatch(t, expected, filePaths, "concurrency %d", concurrency)
+	}
+}
+
 func createFile(t *testing.T, path string) {
 	file, err := os.Create(path)
 	if err != nil {
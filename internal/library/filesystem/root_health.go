@@ -0,0 +1,40 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrRootUnavailable is returned when a library path looks unavailable, e.g. a network mount that dropped
+var ErrRootUnavailable = errors.New("library path is unavailable")
+
+// CheckRootHealth returns an error wrapping ErrRootUnavailable if a library path cannot be trusted to list its files.
+//   - hadFiles: true if the library path had files, it is unavailable if it is now empty.
+//   - sentinel: path of a file relative to the library path that must exist, ignored if empty.
+func CheckRootHealth(root string, hadFiles bool, sentinel string) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRootUnavailable, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrRootUnavailable, root)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRootUnavailable, err)
+	}
+	if len(entries) == 0 && hadFiles {
+		return fmt.Errorf("%w: %s is empty", ErrRootUnavailable, root)
+	}
+
+	if sentinel != "" {
+		if _, err := os.Stat(filepath.Join(root, sentinel)); err != nil {
+			return fmt.Errorf("%w: sentinel file %s not found", ErrRootUnavailable, sentinel)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRootHealth(t *testing.T) {
+	root := t.TempDir()
+	empty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".mounted"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ep1.mkv"), nil, 0644))
+
+	tests := []struct {
+		name      string
+		root      string
+		hadFiles  bool
+		sentinel  string
+		available bool
+	}{
+		{name: "healthy", root: root, hadFiles: true, available: true},
+		{name: "sentinel found", root: root, hadFiles: true, sentinel: ".mounted", available: true},
+		{name: "sentinel missing", root: root, hadFiles: true, sentinel: ".missing", available: false},
+		{name: "missing directory", root: filepath.Join(root, "missing"), available: false},
+		{name: "file", root: filepath.Join(root, "ep1.mkv"), available: false},
+		{name: "empty after having files", root: empty, hadFiles: true, available: false},
+		{name: "new empty library path", root: empty, hadFiles: false, available: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRootHealth(tt.root, tt.hadFiles, tt.sentinel)
+			if tt.available {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrRootUnavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package mediaremap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/continuity"
+	"seanime/internal/customsource"
+	"seanime/internal/database/db"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifications"
+	"seanime/internal/util"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxChecksPerRun is the maximum number of media looked up on AniList each time the collection is refreshed
+	maxChecksPerRun = 20
+	// checkInterval is the time before a media that still exists is looked up again
+	checkInterval = 7 * 24 * time.Hour
+	// maxListedFiles is the number of files listed in the notification of a media that no longer exists
+	maxListedFiles = 5
+)
+
+type (
+	// Manager detects the media of the local records that AniList merged into another entry or deleted.
+	//
+	// When the collection is refreshed, the media IDs used by the local files, pre-matches, AutoDownloader rules and items,
+	// and annotations that are not in the collection are looked up on AniList.
+	//  - AniList returns the new media when the entry was merged, the records are remapped to the new ID.
+	//  - Media that no longer exist are recorded and the user is notified so that their files can be matched again.
+	Manager struct {
+		db                *db.Database
+		logger            *zerolog.Logger
+		wsEventManager    events.WSEventManagerInterface
+		anilistClientRef  *util.Ref[anilist.AnilistClient]
+		continuityManager *continuity.Manager
+		notifications     *notifications.Manager
+		onRemapped        func()
+
+		mu sync.Mutex
+		// checkedAt is the last time a media was found to still exist
+		checkedAt map[int]time.Time
+		// fetchMedia and now are replaced in tests
+		fetchMedia func(ctx context.Context, id int) (*anilist.BaseAnime, error)
+		now        func() time.Time
+	}
+
+	NewManagerOptions struct {
+		DB                *db.Database
+		Logger            *zerolog.Logger
+		WSEventManager    events.WSEventManagerInterface
+		AnilistClientRef  *util.Ref[anilist.AnilistClient]
+		ContinuityManager *continuity.Manager
+		Notifications     *notifications.Manager
+		// OnRemapped is called after media have been remapped or flagged, e.g. to invalidate the collections
+		OnRemapped func()
+	}
+)
+
+func New(opts *NewManagerOptions) *Manager {
+	ret := &Manager{
+		db:                opts.DB,
+		logger:            opts.Logger,
+		wsEventManager:    opts.WSEventManager,
+		anilistClientRef:  opts.AnilistClientRef,
+		continuityManager: opts.ContinuityManager,
+		notifications:     opts.Notifications,
+		onRemapped:        opts.OnRemapped,
+		checkedAt:         make(map[int]time.Time),
+		now:               time.Now,
+	}
+	ret.fetchMedia = ret.fetchAnilistMedia
+	return ret
+}
+
+// Check looks up the media of the local records that are not in the collection and remaps or flags the ones AniList merged or deleted.
+// It should be called every time the collection is refreshed. It returns immediately if a check is already running.
+func (m *Manager) Check(collection *anilist.AnimeCollection) {
+	defer util.HandlePanicInModuleThen("library/mediaremap/Check", func() {})
+
+	if !m.mu.TryLock() {
+		return
+	}
+	defer m.mu.Unlock()
+
+	inCollection := getCollectionMediaIds(collection)
+	// An empty collection is most likely the result of a failed request
+	if len(inCollection) == 0 {
+		return
+	}
+
+	mediaIds, err := m.getUnknownMediaIds(inCollection)
+	if err != nil {
+		m.logger.Error().Err(err).Msg("mediaremap: Failed to get the media IDs of the local records")
+		return
+	}
+
+	changed := false
+	for _, mediaId := range mediaIds {
+		media, err := m.fetchMedia(context.Background(), mediaId)
+		switch {
+		case anilist.IsNotFoundError(err):
+			if m.flagVanished(mediaId) {
+				changed = true
+			}
+		case err != nil:
+			// Other errors are most likely temporary, the media is looked up again on the next refresh
+			m.logger.Warn().Err(err).Int("mediaId", mediaId).Msg("mediaremap: Failed to look up media")
+		case media == nil:
+		case media.GetID() != mediaId:
+			if m.remap(mediaId, media) {
+				changed = true
+			}
+		default:
+			m.checkedAt[mediaId] = m.now()
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	if m.onRemapped != nil {
+		m.onRemapped()
+	}
+	m.wsEventManager.SendEvent(events.MediaRemapped, nil)
+}
+
+// GetRemaps returns the media that were remapped or flagged, most recent first.
+func (m *Manager) GetRemaps() ([]*anime.MediaRemap, error) {
+	return db_bridge.GetMediaRemaps(m.db)
+}
+
+func (m *Manager) remap(from int, media *anilist.BaseAnime) bool {
+	remap, err := db_bridge.RemapMediaId(m.db, from, media.GetID(), media.GetPreferredTitle())
+	if err != nil {
+		m.logger.Error().Err(err).Int("from", from).Int("to", media.GetID()).Msg("mediaremap: Failed to remap media")
+		return false
+	}
+
+	// The watch history is not stored in the database, it cannot be part of the transaction
+	if m.continuityManager != nil {
+		if err := m.continuityManager.RemapWatchHistoryItem(from, media.GetID()); err != nil {
+			m.logger.Warn().Err(err).Int("from", from).Msg("mediaremap: Failed to remap the watch history")
+		}
+	}
+
+	count := 0
+	for _, n := range remap.Records {
+		count += n
+	}
+	m.logger.Info().Int("from", from).Int("to", media.GetID()).Int("records", count).Msg("mediaremap: Remapped media merged by AniList")
+	m.notifications.Notify(notifications.TypeMediaRemapped,
+		fmt.Sprintf("AniList merged media %d into %s, %d local records were moved to it", from, media.GetPreferredTitle(), count),
+		media.GetID())
+	return true
+}
+
+func (m *Manager) flagVanished(mediaId int) bool {
+	lfs, _, err := db_bridge.GetLocalFiles(m.db)
+	if err != nil {
+		m.logger.Error().Err(err).Msg("mediaremap: Failed to get the local files")
+		return false
+	}
+
+	remap := &anime.MediaRemap{
+		FromMediaId: mediaId,
+		Files:       make([]string, 0),
+		Records:     make(map[string]int),
+	}
+	for _, lf := range lfs {
+		if lf.MediaId == mediaId {
+			remap.Files = append(remap.Files, lf.Path)
+		}
+	}
+
+	if err := db_bridge.InsertMediaRemap(m.db, remap); err != nil {
+		m.logger.Error().Err(err).Int("mediaId", mediaId).Msg("mediaremap: Failed to record media")
+		return false
+	}
+
+	m.logger.Warn().Int("mediaId", mediaId).Int("files", len(remap.Files)).Msg("mediaremap: Media no longer exists on AniList")
+	m.notifications.Notify(notifications.TypeMediaVanished, getVanishedMessage(remap), mediaId)
+	return true
+}
+
+// getUnknownMediaIds returns the media IDs of the local records that are not in the collection and need to be looked up.
+func (m *Manager) getUnknownMediaIds(inCollection map[int]struct{}) ([]int, error) {
+	mediaIds := make(map[int]struct{})
+
+	lfs, _, err := db_bridge.GetLocalFiles(m.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, lf := range lfs {
+		mediaIds[lf.MediaId] = struct{}{}
+	}
+
+	preMatches, err := m.db.GetAllTorrentPreMatches()
+	if err != nil {
+		return nil, err
+	}
+	for _, pm := range preMatches {
+		mediaIds[pm.MediaId] = struct{}{}
+	}
+
+	rules, err := db_bridge.GetAutoDownloaderRules(m.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		mediaIds[rule.MediaId] = struct{}{}
+	}
+
+	items, err := m.db.GetAutoDownloaderItems()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		mediaIds[item.MediaID] = struct{}{}
+	}
+
+	annotated, err := m.db.GetAnnotatedMediaIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, mediaId := range annotated {
+		mediaIds[mediaId] = struct{}{}
+	}
+
+	// Media that were already flagged are not looked up again
+	remaps, err := db_bridge.GetMediaRemaps(m.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range remaps {
+		if r.IsVanished() {
+			delete(mediaIds, r.FromMediaId)
+		}
+	}
+
+	now := m.now()
+	ret := make([]int, 0)
+	for mediaId := range mediaIds {
+		if mediaId <= 0 || customsource.IsExtensionId(mediaId) {
+			continue
+		}
+		if _, ok := inCollection[mediaId]; ok {
+			continue
+		}
+		if checkedAt, ok := m.checkedAt[mediaId]; ok && now.Sub(checkedAt) < checkInterval {
+			continue
+		}
+		ret = append(ret, mediaId)
+	}
+	slices.Sort(ret)
+
+	if len(ret) > maxChecksPerRun {
+		ret = ret[:maxChecksPerRun]
+	}
+	return ret, nil
+}
+
+func (m *Manager) fetchAnilistMedia(ctx context.Context, id int) (*anilist.BaseAnime, error) {
+	res, err := m.anilistClientRef.Get().BaseAnimeByID(ctx, &id)
+	if err != nil {
+		return nil, err
+	}
+	return res.GetMedia(), nil
+}
+
+func getCollectionMediaIds(collection *anilist.AnimeCollection) map[int]struct{} {
+	ret := make(map[int]struct{})
+	if collection == nil || collection.GetMediaListCollection() == nil {
+		return ret
+	}
+	for _, list := range collection.GetMediaListCollection().GetLists() {
+		for _, entry := range list.GetEntries() {
+			if entry.GetMedia() != nil {
+				ret[entry.GetMedia().GetID()] = struct{}{}
+			}
+		}
+	}
+	return ret
+}
+
+// getVanishedMessage lists the files of a media that no longer exists.
+func getVanishedMessage(remap *anime.MediaRemap) string {
+	if len(remap.Files) == 0 {
+		return fmt.Sprintf("Media %d no longer exists on AniList", remap.FromMediaId)
+	}
+
+	names := make([]string, 0, maxListedFiles)
+	for _, path := range remap.Files {
+		if len(names) == maxListedFiles {
+			break
+		}
+		names = append(names, filepath.Base(path))
+	}
+	message := fmt.Sprintf("Media %d no longer exists on AniList, %d files have to be matched again: %s",
+		remap.FromMediaId, len(remap.Files), strings.Join(names, ", "))
+	if len(remap.Files) > maxListedFiles {
+		message += fmt.Sprintf(" and %d more", len(remap.Files)-maxListedFiles)
+	}
+	return message
+}
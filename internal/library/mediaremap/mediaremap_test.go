@@ -0,0 +1,166 @@
+package mediaremap
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/notifications"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollection(mediaIds ...int) *anilist.AnimeCollection {
+	entries := make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0, len(mediaIds))
+	for _, mediaId := range mediaIds {
+		entries = append(entries, &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			Media: &anilist.BaseAnime{ID: mediaId},
+		})
+	}
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{Entries: entries}},
+		},
+	}
+}
+
+func newTestManager(t *testing.T) (*Manager, *db.Database) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "mediaremap_test", logger)
+	require.NoError(t, err)
+
+	wsEventManager := events.NewMockWSEventManager(logger)
+	m := New(&NewManagerOptions{
+		DB:             database,
+		Logger:         logger,
+		WSEventManager: wsEventManager,
+		Notifications: notifications.NewManager(&notifications.NewManagerOptions{
+			Logger:         logger,
+			Database:       database,
+			WSEventManager: wsEventManager,
+		}),
+	})
+	return m, database
+}
+
+func TestCheck(t *testing.T) {
+	m, database := newTestManager(t)
+
+	_, err := db_bridge.InsertLocalFiles(database, []*anime.LocalFile{
+		{Path: "/anime/Merged - 01.mkv", MediaId: 1},
+		{Path: "/anime/Deleted - 01.mkv", MediaId: 2},
+		{Path: "/anime/Listed - 01.mkv", MediaId: 3},
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.SaveTorrentPreMatch("/downloads/Merged", 1))
+	require.NoError(t, db_bridge.InsertAutoDownloaderRule(database, &anime.AutoDownloaderRule{MediaId: 1, ComparisonTitle: "Merged"}))
+	require.NoError(t, database.UpsertMediaAnnotation(&models.MediaAnnotation{Owner: "local", MediaId: 1, Note: "Merged note"}))
+
+	lookups := make([]int, 0)
+	m.fetchMedia = func(ctx context.Context, id int) (*anilist.BaseAnime, error) {
+		lookups = append(lookups, id)
+		switch id {
+		case 1:
+			return &anilist.BaseAnime{ID: 10, Title: &anilist.BaseAnime_Title{UserPreferred: lo.ToPtr("New")}}, nil
+		case 2:
+			return nil, &clientv2.ErrorResponse{NetworkError: &clientv2.HTTPError{Code: 404}}
+		}
+		return &anilist.BaseAnime{ID: id}, nil
+	}
+
+	m.Check(newTestCollection(3, 10))
+	// The media in the collection are not looked up
+	assert.Equal(t, []int{1, 2}, lookups)
+
+	lfs, _, err := db_bridge.GetLocalFiles(database)
+	require.NoError(t, err)
+	mediaIds := lo.Map(lfs, func(lf *anime.LocalFile, _ int) int { return lf.MediaId })
+	// The files of the deleted media are not modified, they are flagged
+	assert.Equal(t, []int{10, 2, 3}, mediaIds)
+
+	preMatch, ok := database.GetTorrentPreMatchForFilePath("/downloads/Merged/Merged - 01.mkv")
+	require.True(t, ok)
+	assert.Equal(t, 10, preMatch)
+
+	rules, err := db_bridge.GetAutoDownloaderRules(database)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, 10, rules[0].MediaId)
+
+	annotation, err := database.GetMediaAnnotation("local", 10)
+	require.NoError(t, err)
+	require.NotNil(t, annotation)
+	assert.Equal(t, "Merged note", annotation.Note)
+
+	remaps, err := m.GetRemaps()
+	require.NoError(t, err)
+	require.Len(t, remaps, 2)
+	byMedia := lo.KeyBy(remaps, func(r *anime.MediaRemap) int { return r.FromMediaId })
+	assert.Equal(t, 10, byMedia[1].ToMediaId)
+	assert.Equal(t, "New", byMedia[1].Title)
+	assert.Equal(t, map[string]int{"localFiles": 1, "preMatches": 1, "autoDownloaderRules": 1, "annotations": 1}, byMedia[1].Records)
+	assert.True(t, byMedia[2].IsVanished())
+	assert.Equal(t, []string{"/anime/Deleted - 01.mkv"}, byMedia[2].Files)
+
+	notifs, err := database.GetNotifications(false)
+	require.NoError(t, err)
+	assert.Len(t, notifs, 2)
+
+	// Flagged media are not looked up again
+	lookups = lookups[:0]
+	m.Check(newTestCollection(3, 10))
+	assert.Empty(t, lookups)
+}
+
+func TestCheck_TemporaryError(t *testing.T) {
+	m, database := newTestManager(t)
+
+	_, err := db_bridge.InsertLocalFiles(database, []*anime.LocalFile{{Path: "/anime/A - 01.mkv", MediaId: 1}})
+	require.NoError(t, err)
+
+	m.fetchMedia = func(ctx context.Context, id int) (*anilist.BaseAnime, error) {
+		return nil, errors.New("connection refused")
+	}
+	m.Check(newTestCollection(3))
+
+	remaps, err := m.GetRemaps()
+	require.NoError(t, err)
+	assert.Empty(t, remaps)
+}
+
+func TestRemapMediaId_Transactional(t *testing.T) {
+	_, database := newTestManager(t)
+
+	_, err := db_bridge.InsertLocalFiles(database, []*anime.LocalFile{{Path: "/anime/A - 01.mkv", MediaId: 1}})
+	require.NoError(t, err)
+	require.NoError(t, database.SaveTorrentPreMatch("/downloads/A", 1))
+	// A rule that cannot be read makes the remap fail after the local files were modified
+	require.NoError(t, database.Gorm().Create(&models.AutoDownloaderRule{Value: []byte("{")}).Error)
+
+	_, err = db_bridge.RemapMediaId(database, 1, 10, "New")
+	require.Error(t, err)
+
+	db_bridge.CurrLocalFiles = mo.None[[]*anime.LocalFile]()
+	lfs, _, err := db_bridge.GetLocalFiles(database)
+	require.NoError(t, err)
+	require.Len(t, lfs, 1)
+	assert.Equal(t, 1, lfs[0].MediaId)
+
+	preMatch, ok := database.GetTorrentPreMatchForFilePath("/downloads/A/A - 01.mkv")
+	require.True(t, ok)
+	assert.Equal(t, 1, preMatch)
+
+	remaps, err := db_bridge.GetMediaRemaps(database)
+	require.NoError(t, err)
+	assert.Empty(t, remaps)
+}
@@ -0,0 +1,142 @@
+package pathresolver
+
+import (
+	"errors"
+	"seanime/internal/util"
+	"sync"
+	"time"
+)
+
+const (
+	// registrationTTL is how long a registration is kept without heartbeats
+	registrationTTL = 6 * time.Hour
+
+	defaultCompletionThreshold = 0.8
+)
+
+var ErrRegistrationNotFound = errors.New("playback registration not found or expired")
+
+type (
+	// Registry keeps the playback registrations handed out to external players.
+	// A registration lets a player report the position of a resolved file without going through the playback manager.
+	Registry struct {
+		mu            sync.Mutex
+		registrations map[string]*Registration
+		now           func() time.Time
+	}
+
+	Registration struct {
+		Token         string `json:"token"`
+		Path          string `json:"path"`
+		MediaId       int    `json:"mediaId"`
+		EpisodeNumber int    `json:"episodeNumber"`
+		// TotalEpisodes is 0 if unknown
+		TotalEpisodes int     `json:"totalEpisodes"`
+		CurrentTime   float64 `json:"currentTime"`
+		Duration      float64 `json:"duration"`
+		// ProgressUpdated is true once the progress has been reported for the episode
+		ProgressUpdated bool      `json:"progressUpdated"`
+		LastHeartbeat   time.Time `json:"lastHeartbeat"`
+		ExpiresAt       time.Time `json:"expiresAt"`
+	}
+
+	HeartbeatOptions struct {
+		Token string
+		// CurrentTime and Duration are in seconds
+		CurrentTime float64
+		Duration    float64
+		// CompletionThreshold is the ratio after which the episode is watched, 0.8 if 0
+		CompletionThreshold float64
+	}
+
+	HeartbeatResult struct {
+		Registration    *Registration `json:"registration"`
+		CompletionRatio float64       `json:"completionRatio"`
+		// ShouldUpdateProgress is true when the threshold is reached and the progress has not been reported yet
+		ShouldUpdateProgress bool `json:"-"`
+	}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		registrations: make(map[string]*Registration),
+		now:           time.Now,
+	}
+}
+
+// Register creates a registration for a resolved file and returns its token.
+func (r *Registry) Register(res *Resolution, totalEpisodes int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeExpired()
+
+	now := r.now()
+	token := util.GenerateCryptoID()
+	r.registrations[token] = &Registration{
+		Token:         token,
+		Path:          res.Path,
+		MediaId:       res.MediaId,
+		EpisodeNumber: res.EpisodeNumber,
+		TotalEpisodes: totalEpisodes,
+		LastHeartbeat: now,
+		ExpiresAt:     now.Add(registrationTTL),
+	}
+	return token
+}
+
+// Heartbeat records the playback position of a registration and extends it.
+func (r *Registry) Heartbeat(opts *HeartbeatOptions) (*HeartbeatResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeExpired()
+
+	reg, found := r.registrations[opts.Token]
+	if !found {
+		return nil, ErrRegistrationNotFound
+	}
+	if opts.CurrentTime < 0 || opts.Duration < 0 {
+		return nil, errors.New("currentTime and duration cannot be negative")
+	}
+
+	now := r.now()
+	reg.CurrentTime = opts.CurrentTime
+	reg.Duration = opts.Duration
+	reg.LastHeartbeat = now
+	reg.ExpiresAt = now.Add(registrationTTL)
+
+	threshold := opts.CompletionThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultCompletionThreshold
+	}
+
+	ret := &HeartbeatResult{}
+	if reg.Duration > 0 {
+		ret.CompletionRatio = min(reg.CurrentTime/reg.Duration, 1)
+	}
+	ret.ShouldUpdateProgress = !reg.ProgressUpdated && ret.CompletionRatio >= threshold
+
+	cpy := *reg
+	ret.Registration = &cpy
+	return ret, nil
+}
+
+// SetProgressUpdated marks the progress of a registration as reported, so that it is only reported once.
+func (r *Registry) SetProgressUpdated(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reg, found := r.registrations[token]; found {
+		reg.ProgressUpdated = true
+	}
+}
+
+func (r *Registry) removeExpired() {
+	now := r.now()
+	for token, reg := range r.registrations {
+		if now.After(reg.ExpiresAt) {
+			delete(r.registrations, token)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package pathresolver
+
+import (
+	"errors"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/scanner"
+	"seanime/internal/util"
+
+	"github.com/samber/lo"
+)
+
+const (
+	SourceLocalFile Source = "local-file" // The file is in the local file records
+	SourcePreMatch  Source = "pre-match"  // The file is inside the destination of a torrent downloaded from an anime's page
+	SourceGuess     Source = "guess"      // The parsed title of the file matches a media of the collection
+)
+
+type (
+	Source string
+
+	ResolveOptions struct {
+		// Path is the absolute path of the file
+		Path         string
+		LibraryPaths []string
+		LocalFiles   []*anime.LocalFile
+		// AnimeCollection is used to guess the media of unmatched files and to read the watch state
+		AnimeCollection   *anilist.AnimeCollectionWithRelations
+		PreMatchMap       map[string]int
+		ExcludedPaths     []string
+		MatchingAlgorithm string
+		MatchingThreshold float64
+	}
+
+	// Resolution is the library context of a file.
+	Resolution struct {
+		Path string `json:"path"`
+		// External is true if the file is outside all library directories
+		External bool `json:"external"`
+		// Source is empty if the file could not be matched
+		Source  Source `json:"source"`
+		MediaId int    `json:"mediaId"`
+		// Media is set by the caller, it is nil if the file could not be matched
+		Media         *anilist.BaseAnime  `json:"media"`
+		EpisodeNumber int                 `json:"episodeNumber"`
+		AniDBEpisode  string              `json:"aniDBEpisode"`
+		Type          anime.LocalFileType `json:"type"`
+		WatchState    *WatchState         `json:"watchState"`
+		// CanReportProgress is true if the file is a main episode of a matched media
+		CanReportProgress bool `json:"canReportProgress"`
+		// Reason explains why the progress cannot be reported
+		Reason string `json:"reason,omitempty"`
+		// Token is used with the heartbeat endpoint, empty if the progress cannot be reported
+		Token string `json:"token"`
+	}
+
+	// WatchState is the list entry of the media, nil if the media is not in the user's list.
+	WatchState struct {
+		Status   anilist.MediaListStatus `json:"status"`
+		Progress int                     `json:"progress"`
+		// Watched is true if the list progress includes the episode
+		Watched bool `json:"watched"`
+	}
+)
+
+// Resolve finds the media and episode of a file through the local file records, the torrent pre-matches
+// and, when the file is not matched, a guess from its parsed title.
+// Files outside the library directories are resolved the same way but are flagged as external.
+func Resolve(opts *ResolveOptions) (*Resolution, error) {
+	if opts.Path == "" || !filepath.IsAbs(opts.Path) {
+		return nil, errors.New("path must be absolute")
+	}
+
+	ret := &Resolution{
+		Path:     opts.Path,
+		External: !util.IsSubdirectoryOfAny(opts.LibraryPaths, opts.Path),
+	}
+
+	normalizedPath := util.NormalizePath(opts.Path)
+	lf, found := lo.Find(opts.LocalFiles, func(lf *anime.LocalFile) bool {
+		return lf.GetNormalizedPath() == normalizedPath
+	})
+
+	switch {
+	case found && lf.MediaId != 0 && lf.Metadata != nil:
+		ret.Source = SourceLocalFile
+		ret.MediaId = lf.MediaId
+		ret.EpisodeNumber = lf.GetEpisodeNumber()
+		ret.AniDBEpisode = lf.GetAniDBEpisode()
+		ret.Type = lf.GetType()
+	default:
+		if err := guess(opts, ret); err != nil {
+			return nil, err
+		}
+	}
+
+	hydrateWatchState(opts.AnimeCollection, ret)
+	return ret, nil
+}
+
+// guess matches the file like a scan would, the torrent pre-match takes precedence over the parsed title.
+func guess(opts *ResolveOptions, ret *Resolution) error {
+	lf := anime.NewLocalFileS(opts.Path, opts.LibraryPaths)
+	if lf.ParsedData != nil {
+		if ep, ok := util.StringToInt(lf.ParsedData.Episode); ok {
+			ret.EpisodeNumber = ep
+			ret.AniDBEpisode = lf.ParsedData.Episode
+		}
+	}
+	ret.Type = anime.LocalFileTypeMain
+
+	if opts.AnimeCollection == nil && len(opts.PreMatchMap) == 0 {
+		return nil
+	}
+
+	explanations, err := scanner.ExplainMatches(&scanner.ExplainMatchesOptions{
+		Path:              opts.Path,
+		LibraryPaths:      opts.LibraryPaths,
+		AnimeCollection:   orEmptyCollection(opts.AnimeCollection),
+		PreMatchMap:       opts.PreMatchMap,
+		ExcludedPaths:     opts.ExcludedPaths,
+		MatchingAlgorithm: opts.MatchingAlgorithm,
+		MatchingThreshold: opts.MatchingThreshold,
+	})
+	if err != nil {
+		return err
+	}
+	if len(explanations) == 0 {
+		return nil
+	}
+
+	explanation := explanations[0]
+	switch explanation.Winner {
+	case scanner.MatchSignalPreMatch:
+		ret.Source = SourcePreMatch
+	case scanner.MatchSignalFuzzyTitle:
+		ret.Source = SourceGuess
+	default:
+		return nil
+	}
+	ret.MediaId = explanation.MediaId
+	return nil
+}
+
+// orEmptyCollection returns an empty collection if there is none, so that pre-matches still work.
+func orEmptyCollection(collection *anilist.AnimeCollectionWithRelations) *anilist.AnimeCollectionWithRelations {
+	if collection != nil && collection.MediaListCollection != nil {
+		return collection
+	}
+	return &anilist.AnimeCollectionWithRelations{
+		MediaListCollection: &anilist.AnimeCollectionWithRelations_MediaListCollection{},
+	}
+}
+
+func hydrateWatchState(collection *anilist.AnimeCollectionWithRelations, ret *Resolution) {
+	switch {
+	case ret.MediaId == 0:
+		ret.Reason = "The file could not be matched to a media"
+	case ret.Type != anime.LocalFileTypeMain:
+		ret.Reason = "The file is not a main episode"
+	case ret.EpisodeNumber <= 0:
+		ret.Reason = "The episode number could not be determined"
+	default:
+		ret.CanReportProgress = true
+	}
+
+	if ret.MediaId == 0 {
+		return
+	}
+	entry, found := collection.GetListEntryFromMediaId(ret.MediaId)
+	if !found {
+		return
+	}
+
+	ret.WatchState = &WatchState{}
+	if entry.Status != nil {
+		ret.WatchState.Status = *entry.Status
+	}
+	if entry.Progress != nil {
+		ret.WatchState.Progress = *entry.Progress
+	}
+	ret.WatchState.Watched = ret.CanReportProgress && ret.WatchState.Progress >= ret.EpisodeNumber
+}
@@ -0,0 +1,125 @@
+package pathresolver
+
+import (
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollection(mediaId int, progress int) *anilist.AnimeCollectionWithRelations {
+	status := anilist.MediaListStatusCurrent
+	return &anilist.AnimeCollectionWithRelations{
+		MediaListCollection: &anilist.AnimeCollectionWithRelations_MediaListCollection{
+			Lists: []*anilist.AnimeCollectionWithRelations_MediaListCollection_Lists{
+				{
+					Entries: []*anilist.AnimeCollectionWithRelations_MediaListCollection_Lists_Entries{
+						{
+							Progress: &progress,
+							Status:   &status,
+							Media:    &anilist.CompleteAnime{ID: mediaId},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestResolve_LocalFile(t *testing.T) {
+	libraryPath := filepath.Join(t.TempDir(), "Anime")
+	path := filepath.Join(libraryPath, "Show", "[Group] Show - 03.mkv")
+
+	lf := anime.NewLocalFileS(path, []string{libraryPath})
+	lf.MediaId = 1
+	lf.Metadata = &anime.LocalFileMetadata{Episode: 3, AniDBEpisode: "3", Type: anime.LocalFileTypeMain}
+
+	ret, err := Resolve(&ResolveOptions{
+		Path:            path,
+		LibraryPaths:    []string{libraryPath},
+		LocalFiles:      []*anime.LocalFile{lf},
+		AnimeCollection: newTestCollection(1, 3),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, SourceLocalFile, ret.Source)
+	assert.False(t, ret.External)
+	assert.Equal(t, 1, ret.MediaId)
+	assert.Equal(t, 3, ret.EpisodeNumber)
+	assert.True(t, ret.CanReportProgress)
+	require.NotNil(t, ret.WatchState)
+	assert.Equal(t, anilist.MediaListStatusCurrent, ret.WatchState.Status)
+	assert.True(t, ret.WatchState.Watched)
+}
+
+func TestResolve_ExternalPreMatch(t *testing.T) {
+	libraryPath := filepath.Join(t.TempDir(), "Anime")
+	downloadDir := filepath.Join(t.TempDir(), "Downloads", "[Group] Show")
+	require.NoError(t, os.MkdirAll(downloadDir, 0755))
+	path := filepath.Join(downloadDir, "[Group] Show - 05.mkv")
+	require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+
+	ret, err := Resolve(&ResolveOptions{
+		Path:         path,
+		LibraryPaths: []string{libraryPath},
+		PreMatchMap:  map[string]int{downloadDir: 2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, SourcePreMatch, ret.Source)
+	assert.True(t, ret.External)
+	assert.Equal(t, 2, ret.MediaId)
+	assert.Equal(t, 5, ret.EpisodeNumber)
+	assert.True(t, ret.CanReportProgress)
+	assert.Nil(t, ret.WatchState)
+}
+
+func TestResolve_Unmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "[Group] Unknown - 01.mkv")
+	require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+
+	ret, err := Resolve(&ResolveOptions{Path: path})
+	require.NoError(t, err)
+
+	assert.Empty(t, ret.Source)
+	assert.True(t, ret.External)
+	assert.False(t, ret.CanReportProgress)
+	assert.NotEmpty(t, ret.Reason)
+
+	_, err = Resolve(&ResolveOptions{Path: "relative/path.mkv"})
+	assert.Error(t, err)
+}
+
+func TestRegistry_Heartbeat(t *testing.T) {
+	r := NewRegistry()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	token := r.Register(&Resolution{Path: "/anime/show/03.mkv", MediaId: 1, EpisodeNumber: 3}, 12)
+
+	ret, err := r.Heartbeat(&HeartbeatOptions{Token: token, CurrentTime: 600, Duration: 1440})
+	require.NoError(t, err)
+	assert.False(t, ret.ShouldUpdateProgress)
+	assert.Equal(t, 3, ret.Registration.EpisodeNumber)
+
+	ret, err = r.Heartbeat(&HeartbeatOptions{Token: token, CurrentTime: 1200, Duration: 1440, CompletionThreshold: 0.8})
+	require.NoError(t, err)
+	assert.True(t, ret.ShouldUpdateProgress)
+
+	// The progress is only reported once
+	r.SetProgressUpdated(token)
+	ret, err = r.Heartbeat(&HeartbeatOptions{Token: token, CurrentTime: 1300, Duration: 1440})
+	require.NoError(t, err)
+	assert.False(t, ret.ShouldUpdateProgress)
+	assert.True(t, ret.Registration.ProgressUpdated)
+
+	// Registrations expire without heartbeats
+	now = now.Add(registrationTTL + time.Minute)
+	_, err = r.Heartbeat(&HeartbeatOptions{Token: token, CurrentTime: 1300, Duration: 1440})
+	assert.ErrorIs(t, err, ErrRegistrationNotFound)
+}
@@ -0,0 +1,217 @@
+package placement
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"seanime/internal/api/anilist"
+	"seanime/internal/util"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Rule is a user-defined storage placement rule.
+// Rules are evaluated by ascending priority, the first rule whose conditions match
+// and whose root has enough free space decides the library root of a download.
+type Rule struct {
+	DbID     uint   `json:"dbId"`
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Priority int    `json:"priority"`
+	// Root is the library root the download should be placed in.
+	Root string `json:"root"`
+	// MediaStatuses restricts the rule to media with these statuses (e.g. RELEASING, FINISHED). Empty matches any status.
+	MediaStatuses []anilist.MediaStatus `json:"mediaStatuses"`
+	// MediaFormats restricts the rule to media with these formats (e.g. TV, MOVIE). Empty matches any format.
+	MediaFormats []anilist.MediaFormat `json:"mediaFormats"`
+	// MinFreeBytes is the amount of space that must remain free on the root after the download.
+	MinFreeBytes uint64 `json:"minFreeBytes"`
+}
+
+// Root is a candidate library root along with its disk usage.
+type Root struct {
+	Path       string `json:"path"`
+	FreeBytes  uint64 `json:"freeBytes"`
+	TotalBytes uint64 `json:"totalBytes"`
+	// Available is false when the disk usage of the root could not be read (e.g. unmounted drive).
+	Available bool `json:"available"`
+}
+
+type ResolveOptions struct {
+	Media *anilist.BaseAnime
+	// EstimatedSize is the estimated size of the download in bytes, 0 if unknown.
+	EstimatedSize int64
+	Rules         []*Rule
+	Roots         []*Root
+}
+
+// Result explains which root was chosen and why.
+type Result struct {
+	Root        string `json:"root"`
+	Destination string `json:"destination"`
+	// Rule is the rule that fired, nil when the fallback was used.
+	Rule       *Rule  `json:"rule,omitempty"`
+	Reason     string `json:"reason"`
+	IsFallback bool   `json:"isFallback"`
+	// Warning is set when the fallback was used because the matching rule's root lacked space
+	// or when the chosen root itself might not fit the download.
+	Warning string `json:"warning,omitempty"`
+}
+
+var ErrNoRoots = errors.New("placement: no library root available")
+
+// GetRoots reads the disk usage of each path.
+// Duplicate paths are ignored.
+func GetRoots(paths []string) []*Root {
+	ret := make([]*Root, 0, len(paths))
+	seen := make(map[string]struct{})
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		key := util.NormalizePath(p)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		root := &Root{Path: p}
+		if usage, err := util.GetDiskUsage(p); err == nil {
+			root.FreeBytes = usage.FreeBytes
+			root.TotalBytes = usage.TotalBytes
+			root.Available = true
+		}
+		ret = append(ret, root)
+	}
+	return ret
+}
+
+// Resolve evaluates the rules against the media and returns the chosen root.
+// When no rule matches or the matching rule's root lacks space, the root with the most free space is returned.
+func Resolve(opts *ResolveOptions) (*Result, error) {
+	rootsByPath := make(map[string]*Root, len(opts.Roots))
+	for _, r := range opts.Roots {
+		rootsByPath[util.NormalizePath(r.Path)] = r
+	}
+
+	rules := make([]*Rule, 0, len(opts.Rules))
+	for _, r := range opts.Rules {
+		if r != nil && r.Enabled && r.Root != "" {
+			rules = append(rules, r)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+
+	var skipped *Rule
+	for _, rule := range rules {
+		if !rule.Matches(opts.Media) {
+			continue
+		}
+
+		root, ok := rootsByPath[util.NormalizePath(rule.Root)]
+		if !ok || !root.Available {
+			if skipped == nil {
+				skipped = rule
+			}
+			continue
+		}
+
+		if !hasSpace(root, opts.EstimatedSize, rule.MinFreeBytes) {
+			if skipped == nil {
+				skipped = rule
+			}
+			continue
+		}
+
+		return &Result{
+			Root:   root.Path,
+			Rule:   rule,
+			Reason: fmt.Sprintf("Rule \"%s\" matched", rule.GetDisplayName()),
+		}, nil
+	}
+
+	// Fallback to the root with the most free space
+	var best *Root
+	for _, r := range opts.Roots {
+		if !r.Available {
+			continue
+		}
+		if best == nil || r.FreeBytes > best.FreeBytes {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, ErrNoRoots
+	}
+
+	ret := &Result{
+		Root:       best.Path,
+		IsFallback: true,
+		Reason:     "No rule matched, using the root with the most free space",
+	}
+	if skipped != nil {
+		ret.Reason = fmt.Sprintf("Rule \"%s\" matched but its root is unavailable or lacks space, using the root with the most free space", skipped.GetDisplayName())
+		ret.Warning = fmt.Sprintf("Not enough space on %s, download was placed in %s", skipped.Root, best.Path)
+	}
+	if !hasSpace(best, opts.EstimatedSize, 0) {
+		ret.Warning = fmt.Sprintf("%s may not have enough free space for this download", best.Path)
+	}
+
+	return ret, nil
+}
+
+// Matches returns true if the media satisfies the rule's conditions.
+func (r *Rule) Matches(media *anilist.BaseAnime) bool {
+	if len(r.MediaStatuses) > 0 {
+		if media == nil || media.GetStatus() == nil || !slices.Contains(r.MediaStatuses, *media.GetStatus()) {
+			return false
+		}
+	}
+	if len(r.MediaFormats) > 0 {
+		if media == nil || media.GetFormat() == nil || !slices.Contains(r.MediaFormats, *media.GetFormat()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Rule) GetDisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Root
+}
+
+func hasSpace(root *Root, size int64, minFree uint64) bool {
+	if size <= 0 {
+		return root.FreeBytes > minFree
+	}
+	return root.FreeBytes >= uint64(size)+minFree
+}
+
+var disallowedDirChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1F]`)
+var multipleSpaces = regexp.MustCompile(`\s+`)
+
+// SanitizeDirectoryName mirrors the client's directory name sanitization.
+func SanitizeDirectoryName(name string) string {
+	ret := disallowedDirChars.ReplaceAllString(name, " ")
+	ret = strings.Trim(strings.TrimSpace(ret), ".")
+	ret = multipleSpaces.ReplaceAllString(ret, " ")
+	if ret == "" {
+		return "Untitled"
+	}
+	return ret
+}
+
+// GetDestination returns the download destination for the media under the given root.
+func GetDestination(root string, media *anilist.BaseAnime) string {
+	title := ""
+	if media != nil {
+		title = media.GetRomajiTitleSafe()
+	}
+	return filepath.ToSlash(filepath.Join(root, SanitizeDirectoryName(title)))
+}
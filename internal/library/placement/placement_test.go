@@ -0,0 +1,133 @@
+package placement
+
+import (
+	"seanime/internal/api/anilist"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+
+	ssd := &Root{Path: "/mnt/ssd", FreeBytes: 50 << 30, Available: true}
+	hdd := &Root{Path: "/mnt/hdd", FreeBytes: 2000 << 30, Available: true}
+	unmounted := &Root{Path: "/mnt/usb", Available: false}
+
+	rules := []*Rule{
+		{
+			Name:          "Archive",
+			Enabled:       true,
+			Priority:      2,
+			Root:          "/mnt/hdd",
+			MediaStatuses: []anilist.MediaStatus{anilist.MediaStatusFinished},
+		},
+		{
+			Name:          "Airing",
+			Enabled:       true,
+			Priority:      1,
+			Root:          "/mnt/ssd",
+			MediaStatuses: []anilist.MediaStatus{anilist.MediaStatusReleasing},
+			MediaFormats:  []anilist.MediaFormat{anilist.MediaFormatTv},
+		},
+		{
+			Name:         "Movies",
+			Enabled:      true,
+			Priority:     0,
+			Root:         "/mnt/usb",
+			MediaFormats: []anilist.MediaFormat{anilist.MediaFormatMovie},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		status        anilist.MediaStatus
+		format        anilist.MediaFormat
+		estimatedSize int64
+		expectedRoot  string
+		expectedRule  string
+		isFallback    bool
+		hasWarning    bool
+	}{
+		{
+			name:          "Airing TV show goes to SSD",
+			status:        anilist.MediaStatusReleasing,
+			format:        anilist.MediaFormatTv,
+			estimatedSize: 1 << 30,
+			expectedRoot:  "/mnt/ssd",
+			expectedRule:  "Airing",
+		},
+		{
+			name:          "Finished show goes to HDD",
+			status:        anilist.MediaStatusFinished,
+			format:        anilist.MediaFormatTv,
+			estimatedSize: 30 << 30,
+			expectedRoot:  "/mnt/hdd",
+			expectedRule:  "Archive",
+		},
+		{
+			name:          "Airing batch too large for SSD falls back with warning",
+			status:        anilist.MediaStatusReleasing,
+			format:        anilist.MediaFormatTv,
+			estimatedSize: 100 << 30,
+			expectedRoot:  "/mnt/hdd",
+			isFallback:    true,
+			hasWarning:    true,
+		},
+		{
+			name:          "Movie rule root is unavailable",
+			status:        anilist.MediaStatusFinished,
+			format:        anilist.MediaFormatMovie,
+			estimatedSize: 10 << 30,
+			expectedRoot:  "/mnt/hdd",
+			expectedRule:  "Archive",
+		},
+		{
+			name:          "No rule matches",
+			status:        anilist.MediaStatusHiatus,
+			format:        anilist.MediaFormatOna,
+			estimatedSize: 1 << 30,
+			expectedRoot:  "/mnt/hdd",
+			isFallback:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media := &anilist.BaseAnime{
+				Status: lo.ToPtr(tt.status),
+				Format: lo.ToPtr(tt.format),
+			}
+
+			res, err := Resolve(&ResolveOptions{
+				Media:         media,
+				EstimatedSize: tt.estimatedSize,
+				Rules:         rules,
+				Roots:         []*Root{ssd, hdd, unmounted},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedRoot, res.Root)
+			assert.Equal(t, tt.isFallback, res.IsFallback)
+			assert.Equal(t, tt.hasWarning, res.Warning != "")
+			if tt.expectedRule != "" {
+				require.NotNil(t, res.Rule)
+				assert.Equal(t, tt.expectedRule, res.Rule.Name)
+			} else {
+				assert.Nil(t, res.Rule)
+			}
+		})
+	}
+
+	_, err := Resolve(&ResolveOptions{Roots: []*Root{unmounted}})
+	assert.ErrorIs(t, err, ErrNoRoots)
+}
+
+func TestGetDestination(t *testing.T) {
+	media := &anilist.BaseAnime{
+		Title: &anilist.BaseAnime_Title{Romaji: lo.ToPtr("Re:Zero kara Hajimeru Isekai Seikatsu")},
+	}
+	assert.Equal(t, "/mnt/ssd/Re Zero kara Hajimeru Isekai Seikatsu", GetDestination("/mnt/ssd", media))
+	assert.Equal(t, "/mnt/ssd/Untitled", GetDestination("/mnt/ssd", nil))
+}
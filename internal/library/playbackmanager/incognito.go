@@ -0,0 +1,84 @@
+package playbackmanager
+
+import (
+	"encoding/json"
+	"seanime/internal/events"
+	"seanime/internal/util"
+)
+
+// IncognitoChangedEvent is sent to the subscribers when the incognito mode of the current playback is toggled.
+type IncognitoChangedEvent struct {
+	Incognito bool
+}
+
+// SetIncognito toggles the incognito mode of the current playback.
+// An incognito playback does not update the AniList progress, the watch history or the Discord presence.
+// Its position is still tracked so that it can be resumed, see [continuity.Manager.SetIncognito].
+// The mode is reset when a new playback is started.
+func (pm *PlaybackManager) SetIncognito(incognito bool) {
+	if pm.incognito.Swap(incognito) == incognito {
+		return
+	}
+
+	pm.Logger.Debug().Bool("incognito", incognito).Msg("playback manager: Incognito mode changed")
+
+	pm.continuityManager.SetIncognito(incognito)
+
+	// Hide the current activity
+	if incognito && pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+		go pm.discordPresence.Close()
+	}
+
+	pm.wsEventManager.SendEvent(events.PlaybackManagerIncognitoChanged, incognito)
+
+	go func() {
+		pm.playbackStatusSubscribers.Range(func(key string, value *PlaybackStatusSubscriber) bool {
+			if value.Canceled.Load() {
+				return true
+			}
+			value.EventCh <- IncognitoChangedEvent{Incognito: incognito}
+			return true
+		})
+	}()
+}
+
+// IsIncognito returns true if the current playback is incognito.
+func (pm *PlaybackManager) IsIncognito() bool {
+	return pm.incognito.Load()
+}
+
+func (pm *PlaybackManager) shouldUpdateDiscordPresence() bool {
+	return pm.discordPresence != nil && !pm.isOfflineRef.Get() && !pm.incognito.Load()
+}
+
+type setIncognitoPayload struct {
+	Incognito bool `json:"incognito"`
+}
+
+// listenToClientEvents listens for the incognito toggle sent by the client during a playback.
+func (pm *PlaybackManager) listenToClientEvents() {
+	if pm.wsEventManager == nil {
+		return
+	}
+	subscriber := pm.wsEventManager.SubscribeToClientEvents("playbackmanager")
+
+	go func() {
+		defer util.HandlePanicInModuleThen("library/playbackmanager/listenToClientEvents", func() {})
+
+		for event := range subscriber.Channel {
+			if event.Type != events.PlaybackManagerSetIncognito {
+				continue
+			}
+			marshaled, err := json.Marshal(event.Payload)
+			if err != nil {
+				continue
+			}
+			var payload setIncognitoPayload
+			if err := json.Unmarshal(marshaled, &payload); err != nil {
+				pm.Logger.Error().Err(err).Msg("playback manager: Failed to unmarshal incognito payload")
+				continue
+			}
+			pm.SetIncognito(payload.Incognito)
+		}
+	}()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"seanime/internal/api/anilist"
 	"seanime/internal/events"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/util"
 	"time"
 
@@ -57,7 +58,7 @@ func (pm *PlaybackManager) StartManualProgressTracking(opts *StartManualProgress
 
 	// Get the media
 	// - Find the media in the collection
-	animeCollection, err := pm.platformRef.Get().GetAnimeCollection(ctx, false)
+	animeCollection, err := pm.platformRef.Get().GetAnimeCollection(ctx, platform.CachedCollection)
 	if err != nil {
 		return err
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
 
 	"github.com/samber/lo"
 )
@@ -24,7 +25,7 @@ func (pm *PlaybackManager) StartRandomVideo(opts *StartRandomVideoOptions) error
 		return err
 	}
 
-	animeCollection, err := pm.platformRef.Get().GetAnimeCollection(context.Background(), false)
+	animeCollection, err := pm.platformRef.Get().GetAnimeCollection(context.Background(), platform.CachedCollection)
 	if err != nil {
 		return err
 	}
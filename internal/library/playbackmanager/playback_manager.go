@@ -102,6 +102,9 @@ type (
 
 		isPlaylistActive atomic.Bool
 
+		// incognito suppresses the progress updates, the watch history and the Discord presence of the current playback
+		incognito atomic.Bool
+
 		// Session-aware progress update
 		currentSessionID                 string                                                                     // The session ID of the user who initiated the current playback
 		updateProgressForSessionFunc     func(ctx context.Context, sessionID string, mediaID int, progress int, totalEpisodes *int) error // Session-aware progress update function
@@ -139,8 +142,9 @@ type (
 	}
 
 	VideoStartedEvent struct {
-		Filename string
-		Filepath string
+		Filename  string
+		Filepath  string
+		Incognito bool
 	}
 
 	VideoStoppedEvent struct {
@@ -153,8 +157,9 @@ type (
 
 	// Stream playback events
 	StreamStartedEvent struct {
-		Filename string
-		Filepath string
+		Filename  string
+		Filepath  string
+		Incognito bool
 	}
 
 	StreamStoppedEvent struct {
@@ -180,6 +185,7 @@ type (
 		CanPlayNext          bool    `json:"canPlayNext"`          // Whether the next episode can be played
 		ProgressUpdated      bool    `json:"progressUpdated"`      // Whether the progress has been updated
 		MediaId              int     `json:"mediaId"`              // The media ID
+		Incognito            bool    `json:"incognito"`            // Whether the playback is incognito, see [incognito.go]
 	}
 
 	NewPlaybackManagerOptions struct {
@@ -210,6 +216,7 @@ func (e StreamStartedEvent) Type() string         { return "stream_started" }
 func (e StreamStoppedEvent) Type() string         { return "stream_stopped" }
 func (e StreamCompletedEvent) Type() string       { return "stream_completed" }
 func (e PlaybackStartingEvent) Type() string      { return "playback_starting" }
+func (e IncognitoChangedEvent) Type() string      { return "incognito_changed" }
 
 func New(opts *NewPlaybackManagerOptions) *PlaybackManager {
 	pm := &PlaybackManager{
@@ -240,6 +247,8 @@ func New(opts *NewPlaybackManagerOptions) *PlaybackManager {
 		updateProgressForSessionFunc: opts.UpdateProgressForSessionFunc,
 	}
 
+	pm.listenToClientEvents()
+
 	return pm
 }
 
@@ -300,6 +309,7 @@ type StartPlayingOptions struct {
 	Payload   string // url or path
 	UserAgent string
 	ClientId  string
+	Incognito bool // Do not update the progress, the watch history and the Discord presence
 }
 
 func (pm *PlaybackManager) StartPlayingUsingMediaPlayer(opts *StartPlayingOptions) error {
@@ -327,6 +337,8 @@ func (pm *PlaybackManager) StartPlayingUsingMediaPlayer(opts *StartPlayingOption
 		pm.manualTrackingCtxCancel()
 	}
 
+	pm.SetIncognito(opts.Incognito)
+
 	// Send the media file to the media player
 	err = pm.MediaPlayerRepository.Play(opts.Payload)
 	if err != nil {
@@ -446,6 +458,8 @@ func (pm *PlaybackManager) StartStreamingUsingMediaPlayer(windowTitle string, op
 		pm.manualTrackingCtxCancel()
 	}
 
+	pm.SetIncognito(opts.Incognito)
+
 	pm.currentStreamMedia = mo.Some(event.Media)
 	episodeNumber := 0
 
@@ -642,7 +656,7 @@ func (pm *PlaybackManager) checkOrLoadAnimeCollection() (err error) {
 
 	if pm.animeCollection.IsAbsent() {
 		// If the anime collection is not present, we retrieve it from the platform
-		collection, err := pm.platformRef.Get().GetAnimeCollection(context.Background(), false)
+		collection, err := pm.platformRef.Get().GetAnimeCollection(context.Background(), platform.CachedCollection)
 		if err != nil {
 			return err
 		}
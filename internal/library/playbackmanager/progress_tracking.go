@@ -17,6 +17,7 @@ import (
 var (
 	ErrProgressUpdateAnilist = errors.New("playback manager: Failed to update progress on AniList")
 	ErrProgressUpdateMAL     = errors.New("playback manager: Failed to update progress on MyAnimeList")
+	ErrIncognitoPlayback     = errors.New("playback manager: Progress is not updated during an incognito playback")
 )
 
 func (pm *PlaybackManager) listenToMediaPlayerEvents(ctx context.Context) {
@@ -84,7 +85,7 @@ func (pm *PlaybackManager) handleTrackingStarted(status *mediaplayer.PlaybackSta
 				return true
 			}
 			value.EventCh <- PlaybackStatusChangedEvent{Status: *status, State: _ps}
-			value.EventCh <- VideoStartedEvent{Filename: status.Filename, Filepath: status.Filepath}
+			value.EventCh <- VideoStartedEvent{Filename: status.Filename, Filepath: status.Filepath, Incognito: pm.incognito.Load()}
 			return true
 		})
 	}()
@@ -124,7 +125,7 @@ func (pm *PlaybackManager) handleTrackingStarted(status *mediaplayer.PlaybackSta
 	}
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.SetAnimeActivity(&discordrpc_presence.AnimeActivity{
 			ID:                  pm.currentMediaListEntry.MustGet().GetMedia().GetID(),
 			Title:               pm.currentMediaListEntry.MustGet().GetMedia().GetPreferredTitle(),
@@ -208,7 +209,7 @@ func (pm *PlaybackManager) handleTrackingStopped(reason string) {
 	}
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.Close()
 	}
 }
@@ -244,7 +245,7 @@ func (pm *PlaybackManager) handlePlaybackStatus(status *mediaplayer.PlaybackStat
 	pm.wsEventManager.SendEvent(events.PlaybackManagerProgressPlaybackState, _ps)
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.UpdateAnimeActivity(int(pm.currentMediaPlaybackStatus.CurrentTimeInSeconds), int(pm.currentMediaPlaybackStatus.DurationInSeconds), !pm.currentMediaPlaybackStatus.Playing)
 	}
 }
@@ -295,7 +296,7 @@ func (pm *PlaybackManager) handleStreamingTrackingStarted(status *mediaplayer.Pl
 				return true
 			}
 			value.EventCh <- PlaybackStatusChangedEvent{Status: *status, State: _ps}
-			value.EventCh <- StreamStartedEvent{Filename: status.Filename, Filepath: status.Filepath}
+			value.EventCh <- StreamStartedEvent{Filename: status.Filename, Filepath: status.Filepath, Incognito: pm.incognito.Load()}
 			return true
 		})
 	}()
@@ -312,7 +313,7 @@ func (pm *PlaybackManager) handleStreamingTrackingStarted(status *mediaplayer.Pl
 	})
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.SetAnimeActivity(&discordrpc_presence.AnimeActivity{
 			ID:                  pm.currentStreamMedia.MustGet().GetID(),
 			Title:               pm.currentStreamMedia.MustGet().GetPreferredTitle(),
@@ -362,7 +363,7 @@ func (pm *PlaybackManager) handleStreamingPlaybackStatus(status *mediaplayer.Pla
 	pm.wsEventManager.SendEvent(events.PlaybackManagerProgressPlaybackState, _ps)
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.UpdateAnimeActivity(int(pm.currentMediaPlaybackStatus.CurrentTimeInSeconds), int(pm.currentMediaPlaybackStatus.DurationInSeconds), !pm.currentMediaPlaybackStatus.Playing)
 	}
 }
@@ -432,7 +433,7 @@ func (pm *PlaybackManager) handleStreamingTrackingStopped(reason string) {
 	pm.wsEventManager.SendEvent(events.PlaybackManagerProgressTrackingStopped, reason)
 
 	// ------- Discord ------- //
-	if pm.discordPresence != nil && !pm.isOfflineRef.Get() {
+	if pm.shouldUpdateDiscordPresence() {
 		go pm.discordPresence.Close()
 	}
 }
@@ -474,6 +475,7 @@ func (pm *PlaybackManager) getLocalFilePlaybackState(status *mediaplayer.Playbac
 		Filename:             status.Filename,
 		CompletionPercentage: status.CompletionPercentage,
 		CanPlayNext:          canPlayNext,
+		Incognito:            pm.incognito.Load(),
 	}
 }
 
@@ -511,6 +513,7 @@ func (pm *PlaybackManager) getStreamPlaybackState(status *mediaplayer.PlaybackSt
 		Filename:             cmp.Or(status.Filename, "Stream"),
 		CompletionPercentage: status.CompletionPercentage,
 		CanPlayNext:          false, // DEVNOTE: This is not used for streams
+		Incognito:            pm.incognito.Load(),
 	}
 }
 
@@ -520,6 +523,11 @@ func (pm *PlaybackManager) getStreamPlaybackState(status *mediaplayer.PlaybackSt
 // This is called once when a "video complete" event is heard.
 func (pm *PlaybackManager) autoSyncCurrentProgress(_ps *PlaybackState) {
 
+	if pm.IsIncognito() {
+		pm.Logger.Debug().Msg("playback manager: Incognito playback, not updating progress")
+		return
+	}
+
 	shouldUpdate, err := pm.Database.AutoUpdateProgressIsEnabled()
 	if err != nil {
 		pm.Logger.Error().Err(err).Msg("playback manager: Failed to check if auto update progress is enabled")
@@ -666,6 +674,10 @@ func (pm *PlaybackManager) updateProgress() (err error) {
 		return errors.New("media ID not found")
 	}
 
+	if pm.IsIncognito() {
+		return ErrIncognitoPlayback
+	}
+
 	// Update the progress on AniList using session-aware function if available
 	sessionID := pm.GetCurrentSessionID()
 	if pm.updateProgressForSessionFunc != nil {
@@ -0,0 +1,205 @@
+package releasepredictor
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+)
+
+const (
+	// maxObservationsPerGroup is the number of recent releases of a media by a release group used for the rolling median
+	maxObservationsPerGroup = 8
+	// minSamples is the number of releases needed before availability is predicted
+	minSamples = 2
+	// minDelay and maxDelay bound the delays that are recorded.
+	// Releases seen long before airing or long after are not part of the weekly pattern, e.g. leaks or batches.
+	minDelay = -24 * time.Hour
+	maxDelay = 7 * 24 * time.Hour
+	// minLateMargin is the minimum time after the expected availability before a release is late
+	minLateMargin = time.Hour
+)
+
+type (
+	// Predictor records when release groups publish the episodes of a media relative to their airing,
+	// and predicts when the next episodes become available from the rolling median of these delays.
+	Predictor struct {
+		logger   *zerolog.Logger
+		database *db.Database
+	}
+
+	NewPredictorOptions struct {
+		Logger   *zerolog.Logger
+		Database *db.Database
+	}
+
+	// GroupPrediction is the release pattern of a release group for a media.
+	GroupPrediction struct {
+		ReleaseGroup string `json:"releaseGroup"`
+		// MedianDelaySeconds is the median time between the airing of an episode and its release
+		MedianDelaySeconds int64 `json:"medianDelaySeconds"`
+		// LateAfterSeconds is the time after the airing of an episode after which its release is late relative to the history of the group
+		LateAfterSeconds int64     `json:"lateAfterSeconds"`
+		SampleCount      int       `json:"sampleCount"`
+		LastSeenAt       time.Time `json:"lastSeenAt"`
+	}
+)
+
+func New(opts *NewPredictorOptions) *Predictor {
+	return &Predictor{
+		logger:   opts.Logger,
+		database: opts.Database,
+	}
+}
+
+// Record saves the time a release of an episode was first seen.
+// Only the first observation of an episode by a release group is kept.
+func (p *Predictor) Record(mediaId int, episode int, releaseGroup string, airedAt time.Time, seenAt time.Time) {
+	if p == nil || p.database == nil || releaseGroup == "" || airedAt.IsZero() {
+		return
+	}
+
+	delay := seenAt.Sub(airedAt)
+	if delay < minDelay || delay > maxDelay {
+		return
+	}
+
+	inserted, err := p.database.InsertReleaseObservation(&models.ReleaseObservation{
+		MediaID:      mediaId,
+		Episode:      episode,
+		ReleaseGroup: releaseGroup,
+		AiredAt:      airedAt,
+		SeenAt:       seenAt,
+		DelaySeconds: int64(delay.Seconds()),
+	})
+	if err != nil {
+		p.logger.Error().Err(err).Int("mediaId", mediaId).Msg("release predictor: Failed to record release")
+		return
+	}
+	if !inserted {
+		return
+	}
+
+	p.logger.Debug().Int("mediaId", mediaId).Int("episode", episode).Str("releaseGroup", releaseGroup).Dur("delay", delay).Msg("release predictor: Recorded release")
+
+	if err := p.database.PruneReleaseObservations(mediaId, releaseGroup, maxObservationsPerGroup); err != nil {
+		p.logger.Error().Err(err).Int("mediaId", mediaId).Msg("release predictor: Failed to prune releases")
+	}
+}
+
+// GetPredictions returns the release patterns of the release groups of a media that have enough history,
+// the group that releases first comes first.
+func (p *Predictor) GetPredictions(mediaId int) ([]*GroupPrediction, error) {
+	observations, err := p.database.GetReleaseObservations(mediaId)
+	if err != nil {
+		return nil, err
+	}
+	return getPredictions(observations), nil
+}
+
+// Predict returns the predicted availability of the episode of a media airing at airingAt,
+// from the release group that usually releases it first.
+// If releaseGroups is not empty, only these release groups are considered.
+// It returns nil if no release group has enough history.
+func (p *Predictor) Predict(mediaId int, airingAt time.Time, releaseGroups []string) *anime.ReleasePrediction {
+	if p == nil || p.database == nil {
+		return nil
+	}
+
+	predictions, err := p.GetPredictions(mediaId)
+	if err != nil {
+		p.logger.Error().Err(err).Int("mediaId", mediaId).Msg("release predictor: Failed to get releases")
+		return nil
+	}
+
+	return predict(predictions, airingAt, releaseGroups)
+}
+
+func predict(predictions []*GroupPrediction, airingAt time.Time, releaseGroups []string) *anime.ReleasePrediction {
+	for _, prediction := range predictions {
+		if len(releaseGroups) > 0 && !slices.ContainsFunc(releaseGroups, func(g string) bool {
+			return strings.EqualFold(g, prediction.ReleaseGroup)
+		}) {
+			continue
+		}
+		return &anime.ReleasePrediction{
+			ReleaseGroup: prediction.ReleaseGroup,
+			ExpectedAt:   airingAt.Add(time.Duration(prediction.MedianDelaySeconds) * time.Second),
+			LateAt:       airingAt.Add(time.Duration(prediction.LateAfterSeconds) * time.Second),
+		}
+	}
+	return nil
+}
+
+func getPredictions(observations []*models.ReleaseObservation) []*GroupPrediction {
+	// Release groups are compared case-insensitively, the observations are sorted from the most recently aired
+	groups := lo.GroupBy(observations, func(obs *models.ReleaseObservation) string {
+		return strings.ToLower(obs.ReleaseGroup)
+	})
+
+	ret := make([]*GroupPrediction, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].AiredAt.After(group[j].AiredAt)
+		})
+		if len(group) > maxObservationsPerGroup {
+			group = group[:maxObservationsPerGroup]
+		}
+		if len(group) < minSamples {
+			continue
+		}
+
+		delays := lo.Map(group, func(obs *models.ReleaseObservation, _ int) int64 {
+			return obs.DelaySeconds
+		})
+		median := getMedian(delays)
+
+		// The margin is twice the median absolute deviation so that groups with irregular releases are late later
+		deviations := lo.Map(delays, func(d int64, _ int) int64 {
+			if d > median {
+				return d - median
+			}
+			return median - d
+		})
+		margin := 2 * getMedian(deviations)
+		if margin < int64(minLateMargin.Seconds()) {
+			margin = int64(minLateMargin.Seconds())
+		}
+
+		ret = append(ret, &GroupPrediction{
+			ReleaseGroup:       group[0].ReleaseGroup,
+			MedianDelaySeconds: median,
+			LateAfterSeconds:   median + margin,
+			SampleCount:        len(group),
+			LastSeenAt:         lo.MaxBy(group, func(a, b *models.ReleaseObservation) bool { return a.SeenAt.After(b.SeenAt) }).SeenAt,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].MedianDelaySeconds == ret[j].MedianDelaySeconds {
+			return ret[i].ReleaseGroup < ret[j].ReleaseGroup
+		}
+		return ret[i].MedianDelaySeconds < ret[j].MedianDelaySeconds
+	})
+
+	return ret
+}
+
+func getMedian(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,147 @@
+package releasepredictor
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPredictions(t *testing.T) {
+	airedAt := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+
+	observe := func(group string, episode int, delay time.Duration) *models.ReleaseObservation {
+		aired := airedAt.Add(time.Duration(episode) * 7 * 24 * time.Hour)
+		return &models.ReleaseObservation{
+			MediaID:      1,
+			Episode:      episode,
+			ReleaseGroup: group,
+			AiredAt:      aired,
+			SeenAt:       aired.Add(delay),
+			DelaySeconds: int64(delay.Seconds()),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		obs      []*models.ReleaseObservation
+		expected []*GroupPrediction
+	}{
+		{
+			name: "median and margin",
+			obs: []*models.ReleaseObservation{
+				observe("SubsPlease", 1, 2*time.Hour),
+				observe("SubsPlease", 2, 2*time.Hour+10*time.Minute),
+				observe("SubsPlease", 3, 2*time.Hour-10*time.Minute),
+				observe("Erai-raws", 1, 5*time.Hour),
+				observe("Erai-raws", 2, 3*time.Hour),
+				observe("Erai-raws", 3, 8*time.Hour),
+			},
+			expected: []*GroupPrediction{
+				{ReleaseGroup: "SubsPlease", MedianDelaySeconds: 7200, LateAfterSeconds: 7200 + 3600, SampleCount: 3},
+				{ReleaseGroup: "Erai-raws", MedianDelaySeconds: 18000, LateAfterSeconds: 18000 + 2*2*3600, SampleCount: 3},
+			},
+		},
+		{
+			name: "not enough samples",
+			obs: []*models.ReleaseObservation{
+				observe("SubsPlease", 1, 2*time.Hour),
+				observe("Erai-raws", 1, 5*time.Hour),
+				observe("erai-raws", 2, 7*time.Hour),
+			},
+			expected: []*GroupPrediction{
+				{ReleaseGroup: "erai-raws", MedianDelaySeconds: 21600, LateAfterSeconds: 21600 + 2*3600, SampleCount: 2},
+			},
+		},
+		{
+			name: "only recent releases",
+			obs: []*models.ReleaseObservation{
+				observe("SubsPlease", 1, 10*time.Hour),
+				observe("SubsPlease", 2, 10*time.Hour),
+				observe("SubsPlease", 3, 1*time.Hour),
+				observe("SubsPlease", 4, 1*time.Hour),
+				observe("SubsPlease", 5, 1*time.Hour),
+				observe("SubsPlease", 6, 1*time.Hour),
+				observe("SubsPlease", 7, 1*time.Hour),
+				observe("SubsPlease", 8, 1*time.Hour),
+				observe("SubsPlease", 9, 1*time.Hour),
+				observe("SubsPlease", 10, 1*time.Hour),
+			},
+			expected: []*GroupPrediction{
+				{ReleaseGroup: "SubsPlease", MedianDelaySeconds: 3600, LateAfterSeconds: 7200, SampleCount: maxObservationsPerGroup},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predictions := getPredictions(tt.obs)
+			require.Len(t, predictions, len(tt.expected))
+			for i, expected := range tt.expected {
+				assert.Equal(t, expected.ReleaseGroup, predictions[i].ReleaseGroup)
+				assert.Equal(t, expected.MedianDelaySeconds, predictions[i].MedianDelaySeconds)
+				assert.Equal(t, expected.LateAfterSeconds, predictions[i].LateAfterSeconds)
+				assert.Equal(t, expected.SampleCount, predictions[i].SampleCount)
+			}
+		})
+	}
+}
+
+func TestPredict(t *testing.T) {
+	airingAt := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+	predictions := []*GroupPrediction{
+		{ReleaseGroup: "SubsPlease", MedianDelaySeconds: 3600, LateAfterSeconds: 7200},
+		{ReleaseGroup: "Erai-raws", MedianDelaySeconds: 7200, LateAfterSeconds: 14400},
+	}
+
+	prediction := predict(predictions, airingAt, nil)
+	require.NotNil(t, prediction)
+	assert.Equal(t, "SubsPlease", prediction.ReleaseGroup)
+	assert.Equal(t, airingAt.Add(time.Hour), prediction.ExpectedAt)
+	assert.Equal(t, airingAt.Add(2*time.Hour), prediction.LateAt)
+
+	prediction = predict(predictions, airingAt, []string{"erai-raws"})
+	require.NotNil(t, prediction)
+	assert.Equal(t, "Erai-raws", prediction.ReleaseGroup)
+	assert.Equal(t, airingAt.Add(4*time.Hour), prediction.LateAt)
+
+	assert.Nil(t, predict(predictions, airingAt, []string{"ASW"}))
+}
+
+func TestPredictor_Record(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "releasepredictor_test", util.NewLogger())
+	require.NoError(t, err)
+
+	p := New(&NewPredictorOptions{
+		Logger:   util.NewLogger(),
+		Database: database,
+	})
+
+	airedAt := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+	for episode := 1; episode <= maxObservationsPerGroup+2; episode++ {
+		aired := airedAt.Add(time.Duration(episode) * 7 * 24 * time.Hour)
+		p.Record(1, episode, "SubsPlease", aired, aired.Add(time.Hour))
+	}
+	// Only the first observation of an episode is kept
+	aired := airedAt.Add(time.Duration(maxObservationsPerGroup+2) * 7 * 24 * time.Hour)
+	p.Record(1, maxObservationsPerGroup+2, "SubsPlease", aired, aired.Add(5*time.Hour))
+	// Releases outside the weekly pattern are ignored
+	p.Record(1, 20, "SubsPlease", aired, aired.Add(30*24*time.Hour))
+
+	observations, err := database.GetReleaseObservations(1)
+	require.NoError(t, err)
+	require.Len(t, observations, maxObservationsPerGroup)
+	assert.Equal(t, maxObservationsPerGroup+2, observations[0].Episode)
+	assert.Equal(t, int64(3600), observations[0].DelaySeconds)
+
+	predictions, err := p.GetPredictions(1)
+	require.NoError(t, err)
+	require.Len(t, predictions, 1)
+	assert.Equal(t, int64(3600), predictions[0].MedianDelaySeconds)
+
+	assert.Nil(t, p.Predict(2, aired, nil))
+}
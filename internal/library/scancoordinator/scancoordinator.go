@@ -0,0 +1,468 @@
+package scancoordinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
+	"seanime/internal/util"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+)
+
+const (
+	TriggerManual   = "manual"
+	TriggerAuto     = "auto"
+	TriggerSchedule = "schedule"
+
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+
+	// maxRecentJobs is the number of finished jobs kept for the status
+	maxRecentJobs = 20
+	// taskPrefix is the prefix of the maintenance tasks of the scheduled library path scans
+	taskPrefix = "library-scan:"
+)
+
+var (
+	// ErrNoEligibleRoot is returned when every library path is excluded from full scans
+	ErrNoEligibleRoot = errors.New("every library path is excluded from full scans")
+	// ErrNotLibraryPath is returned when a targeted scan is requested for a path that is not a library path
+	ErrNotLibraryPath = errors.New("not a library path")
+)
+
+type (
+	// Coordinator queues the scans of the library so that they run one at a time.
+	//
+	// A full scan only covers the library paths that are not excluded from full scans.
+	// The library paths with a schedule are scanned on their own every day, as separate jobs.
+	// The local files of the library paths that are not covered by a job are kept unchanged.
+	Coordinator struct {
+		logger    *zerolog.Logger
+		scanFunc  ScanFunc
+		scheduler Scheduler
+
+		mu       sync.Mutex
+		settings *models.LibrarySettings
+		queue    []*Job
+		current  *Job
+		recent   []*Job
+		nextID   int
+		// scheduled are the maintenance tasks of the scheduled library paths, by task name
+		scheduled map[string]string
+		wakeCh    chan struct{}
+	}
+
+	// ScanFunc scans the library paths of a job and returns the local files of the whole library.
+	ScanFunc func(ctx context.Context, job *Job) ([]*anime.LocalFile, error)
+
+	// Scheduler runs the scheduled scans, it is implemented by maintenance.Manager.
+	Scheduler interface {
+		RegisterTask(name string, description string)
+		ScheduleDaily(task string, hour int, minute int, run func()) error
+		RemoveTask(name string)
+	}
+
+	NewCoordinatorOptions struct {
+		Logger    *zerolog.Logger
+		ScanFunc  ScanFunc
+		Scheduler Scheduler
+	}
+
+	// ScanOptions are the options of a scan job.
+	ScanOptions struct {
+		Enhanced         bool `json:"enhanced"`
+		SkipLockedFiles  bool `json:"skipLockedFiles"`
+		SkipIgnoredFiles bool `json:"skipIgnoredFiles"`
+		// ForceReconcile removes the files that are not found even if their library path looks unavailable
+		ForceReconcile bool `json:"forceReconcile"`
+	}
+
+	// Job is a queued scan of some of the library paths.
+	Job struct {
+		ID      int         `json:"id"`
+		Trigger string      `json:"trigger"`
+		Options ScanOptions `json:"options"`
+		// Roots are the library paths scanned by the job
+		Roots      []string   `json:"roots"`
+		Status     string     `json:"status"`
+		Error      string     `json:"error,omitempty"`
+		QueuedAt   time.Time  `json:"queuedAt"`
+		StartedAt  *time.Time `json:"startedAt,omitempty"`
+		FinishedAt *time.Time `json:"finishedAt,omitempty"`
+		// Results are the files of each scanned library path after the scan
+		Results []*summary.ScanSummaryRoot `json:"results,omitempty"`
+
+		lfs  []*anime.LocalFile
+		err  error
+		done chan struct{}
+	}
+
+	// Status is the state of the scan queue.
+	Status struct {
+		Current *Job   `json:"current,omitempty"`
+		Queued  []*Job `json:"queued"`
+		// Recent are the last finished jobs, the most recent first
+		Recent []*Job        `json:"recent"`
+		Roots  []*RootStatus `json:"roots"`
+	}
+
+	// RootStatus is the scan policy of a library path and the result of its last scan.
+	RootStatus struct {
+		Path                 string `json:"path"`
+		ExcludeFromFullScans bool   `json:"excludeFromFullScans"`
+		ScanSchedule         string `json:"scanSchedule,omitempty"`
+		IOConcurrency        int    `json:"ioConcurrency"`
+		SentinelFile         string `json:"sentinelFile,omitempty"`
+		// LastScan is the result of the last finished job that scanned the library path
+		LastScan   *summary.ScanSummaryRoot `json:"lastScan,omitempty"`
+		LastScanAt *time.Time               `json:"lastScanAt,omitempty"`
+		LastJobId  int                      `json:"lastJobId,omitempty"`
+	}
+)
+
+func New(opts *NewCoordinatorOptions) *Coordinator {
+	c := &Coordinator{
+		logger:    opts.Logger,
+		scanFunc:  opts.ScanFunc,
+		scheduler: opts.Scheduler,
+		queue:     make([]*Job, 0),
+		recent:    make([]*Job, 0),
+		scheduled: make(map[string]string),
+		wakeCh:    make(chan struct{}, 1),
+	}
+	go c.run()
+	return c
+}
+
+// SetSettings updates the library paths and their policies, the schedules of the library paths are replaced.
+func (c *Coordinator) SetSettings(settings *models.LibrarySettings) {
+	c.mu.Lock()
+	c.settings = settings
+	previous := c.scheduled
+	c.scheduled = make(map[string]string)
+	c.mu.Unlock()
+
+	if c.scheduler == nil {
+		return
+	}
+
+	for _, policy := range c.getPolicies() {
+		if policy.ScanSchedule == "" {
+			continue
+		}
+		hour, minute, err := ParseScanSchedule(policy.ScanSchedule)
+		if err != nil {
+			c.logger.Error().Err(err).Str("path", policy.Path).Msg("scan coordinator: Invalid scan schedule")
+			continue
+		}
+
+		root := policy.Path
+		task := taskPrefix + root
+		c.scheduler.RegisterTask(task, fmt.Sprintf("Scans the library path %s", root))
+		if err := c.scheduler.ScheduleDaily(task, hour, minute, func() {
+			if _, err := c.Enqueue(TriggerSchedule, []string{root}, ScanOptions{SkipLockedFiles: true, SkipIgnoredFiles: true}); err != nil {
+				c.logger.Error().Err(err).Str("path", root).Msg("scan coordinator: Failed to queue the scheduled scan")
+			}
+		}); err != nil {
+			c.logger.Error().Err(err).Str("path", root).Msg("scan coordinator: Failed to schedule the scan")
+			continue
+		}
+
+		c.mu.Lock()
+		c.scheduled[task] = root
+		c.mu.Unlock()
+	}
+
+	// Stop the schedules that were removed
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for task := range previous {
+		if _, ok := c.scheduled[task]; !ok {
+			c.scheduler.RemoveTask(task)
+		}
+	}
+}
+
+// ParseScanSchedule parses a time of the day formatted as "HH:MM".
+func ParseScanSchedule(schedule string) (hour int, minute int, err error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(schedule))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid scan schedule %q, expected HH:MM", schedule)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// GetLibraryPaths returns every library path.
+func (c *Coordinator) GetLibraryPaths() []string {
+	c.mu.Lock()
+	settings := c.settings
+	c.mu.Unlock()
+
+	if settings == nil {
+		return nil
+	}
+	return lo.Filter(settings.GetLibraryPaths(), func(p string, _ int) bool {
+		return p != ""
+	})
+}
+
+// GetRootPolicy returns the scan policy of a library path, the default policy if it has none.
+func (c *Coordinator) GetRootPolicy(libraryPath string) *models.LibraryRootPolicy {
+	for _, policy := range c.getPolicies() {
+		if isSamePath(policy.Path, libraryPath) {
+			return policy
+		}
+	}
+	return &models.LibraryRootPolicy{Path: libraryPath}
+}
+
+// GetIOConcurrency returns the number of directories of a library path that are read at the same time.
+func (c *Coordinator) GetIOConcurrency(libraryPath string) int {
+	return c.GetRootPolicy(libraryPath).IOConcurrency
+}
+
+// GetSentinelFile returns the file that must exist in a library path for it to be considered available.
+func (c *Coordinator) GetSentinelFile(libraryPath string) string {
+	return c.GetRootPolicy(libraryPath).SentinelFile
+}
+
+// GetFullScanRoots returns the library paths that are covered by full scans.
+func (c *Coordinator) GetFullScanRoots() []string {
+	return lo.Filter(c.GetLibraryPaths(), func(p string, _ int) bool {
+		return !c.GetRootPolicy(p).ExcludeFromFullScans
+	})
+}
+
+// getPolicies returns the policies of the current library paths.
+func (c *Coordinator) getPolicies() []*models.LibraryRootPolicy {
+	c.mu.Lock()
+	settings := c.settings
+	c.mu.Unlock()
+
+	if settings == nil {
+		return nil
+	}
+	libraryPaths := c.GetLibraryPaths()
+	return lo.Filter(settings.LibraryRootPolicies, func(policy *models.LibraryRootPolicy, _ int) bool {
+		return policy != nil && lo.ContainsBy(libraryPaths, func(p string) bool {
+			return isSamePath(p, policy.Path)
+		})
+	})
+}
+
+// ScanFull queues a scan of the library paths that are not excluded from full scans and waits for it.
+func (c *Coordinator) ScanFull(ctx context.Context, trigger string, opts ScanOptions) ([]*anime.LocalFile, *Job, error) {
+	roots := c.GetFullScanRoots()
+	if len(roots) == 0 {
+		return nil, nil, ErrNoEligibleRoot
+	}
+	job, err := c.Enqueue(trigger, roots, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Wait(ctx, job)
+}
+
+// ScanRoots queues a scan of the given library paths, including the ones excluded from full scans, and waits for it.
+func (c *Coordinator) ScanRoots(ctx context.Context, trigger string, roots []string, opts ScanOptions) ([]*anime.LocalFile, *Job, error) {
+	job, err := c.Enqueue(trigger, roots, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Wait(ctx, job)
+}
+
+// Enqueue adds a scan of the given library paths to the queue.
+// If an identical scan is already queued, it is returned instead.
+func (c *Coordinator) Enqueue(trigger string, roots []string, opts ScanOptions) (*Job, error) {
+	libraryPaths := c.GetLibraryPaths()
+
+	resolved := make([]string, 0, len(roots))
+	for _, root := range roots {
+		libraryPath, found := lo.Find(libraryPaths, func(p string) bool {
+			return isSamePath(p, root)
+		})
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrNotLibraryPath, root)
+		}
+		if !slices.Contains(resolved, libraryPath) {
+			resolved = append(resolved, libraryPath)
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, ErrNoEligibleRoot
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, queued := range c.queue {
+		if queued.Options == opts && slices.Equal(queued.Roots, resolved) {
+			return queued, nil
+		}
+	}
+
+	c.nextID++
+	job := &Job{
+		ID:       c.nextID,
+		Trigger:  trigger,
+		Options:  opts,
+		Roots:    resolved,
+		Status:   JobStatusQueued,
+		QueuedAt: time.Now(),
+		done:     make(chan struct{}),
+	}
+	c.queue = append(c.queue, job)
+
+	select {
+	case c.wakeCh <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Wait waits for a job to finish and returns the local files of the whole library.
+// The job keeps running if the context is cancelled.
+func (c *Coordinator) Wait(ctx context.Context, job *Job) ([]*anime.LocalFile, *Job, error) {
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, job.copy(), ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return job.lfs, job.copy(), job.err
+}
+
+func (c *Coordinator) run() {
+	for {
+		c.mu.Lock()
+		if len(c.queue) == 0 {
+			c.mu.Unlock()
+			<-c.wakeCh
+			continue
+		}
+		job := c.queue[0]
+		c.queue = c.queue[1:]
+		now := time.Now()
+		job.Status = JobStatusRunning
+		job.StartedAt = &now
+		c.current = job
+		c.mu.Unlock()
+
+		c.logger.Debug().Int("id", job.ID).Str("trigger", job.Trigger).Strs("roots", job.Roots).Msg("scan coordinator: Starting scan")
+
+		lfs, err := c.runJob(job)
+
+		c.mu.Lock()
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+		job.lfs = lfs
+		job.err = err
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = JobStatusCompleted
+			job.Results = summary.NewRootSummaries(job.Roots, lfs)
+		}
+		c.current = nil
+		c.recent = append([]*Job{job}, c.recent...)
+		if len(c.recent) > maxRecentJobs {
+			c.recent = c.recent[:maxRecentJobs]
+		}
+		close(job.done)
+		c.mu.Unlock()
+
+		if err != nil {
+			c.logger.Error().Err(err).Int("id", job.ID).Strs("roots", job.Roots).Msg("scan coordinator: Scan failed")
+		} else {
+			c.logger.Debug().Int("id", job.ID).Msg("scan coordinator: Scan completed")
+		}
+	}
+}
+
+func (c *Coordinator) runJob(job *Job) (lfs []*anime.LocalFile, err error) {
+	defer util.HandlePanicWithError(&err)
+	return c.scanFunc(context.Background(), job)
+}
+
+// GetStatus returns the queued, running and recent scans, and the results of the last scan of each library path.
+func (c *Coordinator) GetStatus() *Status {
+	libraryPaths := c.GetLibraryPaths()
+	policies := make([]*models.LibraryRootPolicy, 0, len(libraryPaths))
+	for _, p := range libraryPaths {
+		policies = append(policies, c.GetRootPolicy(p))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &Status{
+		Queued: make([]*Job, 0, len(c.queue)),
+		Recent: make([]*Job, 0, len(c.recent)),
+		Roots:  make([]*RootStatus, 0, len(policies)),
+	}
+	if c.current != nil {
+		ret.Current = c.current.copy()
+	}
+	for _, job := range c.queue {
+		ret.Queued = append(ret.Queued, job.copy())
+	}
+	for _, job := range c.recent {
+		ret.Recent = append(ret.Recent, job.copy())
+	}
+
+	for _, policy := range policies {
+		rs := &RootStatus{
+			Path:                 policy.Path,
+			ExcludeFromFullScans: policy.ExcludeFromFullScans,
+			ScanSchedule:         policy.ScanSchedule,
+			IOConcurrency:        policy.IOConcurrency,
+			SentinelFile:         policy.SentinelFile,
+		}
+		// The recent jobs are sorted from the most recent
+		for _, job := range c.recent {
+			result, found := lo.Find(job.Results, func(r *summary.ScanSummaryRoot) bool {
+				return isSamePath(r.Path, policy.Path)
+			})
+			if !found {
+				continue
+			}
+			rs.LastScan = result
+			rs.LastScanAt = job.FinishedAt
+			rs.LastJobId = job.ID
+			break
+		}
+		ret.Roots = append(ret.Roots, rs)
+	}
+
+	return ret
+}
+
+func isSamePath(a string, b string) bool {
+	return path.Clean(util.NormalizePath(a)) == path.Clean(util.NormalizePath(b))
+}
+
+// copy returns a copy of the job that can be read without the lock.
+func (j *Job) copy() *Job {
+	ret := *j
+	ret.Roots = slices.Clone(j.Roots)
+	ret.Results = slices.Clone(j.Results)
+	return &ret
+}
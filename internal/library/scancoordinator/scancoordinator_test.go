@@ -0,0 +1,220 @@
+package scancoordinator
+
+import (
+	"context"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeScheduler struct {
+	mu      sync.Mutex
+	tasks   map[string]func()
+	removed []string
+}
+
+func (s *fakeScheduler) RegisterTask(name string, description string) {}
+
+func (s *fakeScheduler) ScheduleDaily(task string, hour int, minute int, run func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[task] = run
+	return nil
+}
+
+func (s *fakeScheduler) RemoveTask(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tasks, name)
+	s.removed = append(s.removed, name)
+}
+
+// fakeLibrary is a library whose files are matched if their name starts with "matched"
+type fakeLibrary struct {
+	mu      sync.Mutex
+	files   map[string][]string
+	running int
+	// overlapped is true if two scans ran at the same time
+	overlapped bool
+	scanned    [][]string
+	release    chan struct{}
+}
+
+func (l *fakeLibrary) scan(ctx context.Context, job *Job) ([]*anime.LocalFile, error) {
+	l.mu.Lock()
+	l.running++
+	l.overlapped = l.overlapped || l.running > 1
+	l.scanned = append(l.scanned, job.Roots)
+	l.mu.Unlock()
+
+	if l.release != nil {
+		<-l.release
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+
+	// The whole library is returned, including the library paths that were not scanned
+	ret := make([]*anime.LocalFile, 0)
+	for root, names := range l.files {
+		for _, name := range names {
+			lf := &anime.LocalFile{Path: filepath.Join(root, name)}
+			if name[:len("matched")] == "matched" {
+				lf.MediaId = 1
+			}
+			ret = append(ret, lf)
+		}
+	}
+	return ret, nil
+}
+
+func newTestCoordinator(t *testing.T) (*Coordinator, *fakeLibrary, *fakeScheduler) {
+	library := &fakeLibrary{
+		files: map[string][]string{
+			"/anime":  {"matched1.mkv", "matched2.mkv", "unmatch1.mkv"},
+			"/movies": {"matched3.mkv"},
+			"/cloud":  {"matched4.mkv", "unmatch2.mkv"},
+		},
+	}
+	scheduler := &fakeScheduler{tasks: make(map[string]func())}
+	c := New(&NewCoordinatorOptions{
+		Logger:    util.NewLogger(),
+		ScanFunc:  library.scan,
+		Scheduler: scheduler,
+	})
+	c.SetSettings(&models.LibrarySettings{
+		LibraryPath:  "/anime",
+		LibraryPaths: []string{"/movies", "/cloud"},
+		LibraryRootPolicies: models.LibraryRootPolicies{
+			{Path: "/cloud", ExcludeFromFullScans: true, ScanSchedule: "03:30", IOConcurrency: 2},
+			// Not a library path
+			{Path: "/old", ScanSchedule: "04:00"},
+		},
+	})
+	return c, library, scheduler
+}
+
+func TestCoordinator_ScanFull(t *testing.T) {
+	c, library, _ := newTestCoordinator(t)
+
+	assert.Equal(t, []string{"/anime", "/movies"}, c.GetFullScanRoots())
+	assert.Equal(t, 2, c.GetIOConcurrency("/cloud"))
+	assert.Equal(t, 0, c.GetIOConcurrency("/anime"))
+
+	lfs, job, err := c.ScanFull(context.Background(), TriggerManual, ScanOptions{Enhanced: true})
+	require.NoError(t, err)
+	// The files of the excluded library path are still in the library
+	assert.Len(t, lfs, 6)
+	assert.Equal(t, [][]string{{"/anime", "/movies"}}, library.scanned)
+
+	assert.Equal(t, JobStatusCompleted, job.Status)
+	require.Len(t, job.Results, 2)
+	assert.Equal(t, "/anime", job.Results[0].Path)
+	assert.Equal(t, 3, job.Results[0].FileCount)
+	assert.Equal(t, 2, job.Results[0].MatchedCount)
+	assert.Equal(t, 1, job.Results[0].UnmatchedCount)
+	assert.Equal(t, "/movies", job.Results[1].Path)
+	assert.Equal(t, 1, job.Results[1].FileCount)
+
+	// The excluded library path can still be scanned on its own
+	_, job, err = c.ScanRoots(context.Background(), TriggerManual, []string{"/cloud/"}, ScanOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/cloud"}, job.Roots)
+	require.Len(t, job.Results, 1)
+	assert.Equal(t, 2, job.Results[0].FileCount)
+
+	_, _, err = c.ScanRoots(context.Background(), TriggerManual, []string{"/other"}, ScanOptions{})
+	assert.ErrorIs(t, err, ErrNotLibraryPath)
+
+	status := c.GetStatus()
+	assert.Nil(t, status.Current)
+	assert.Empty(t, status.Queued)
+	require.Len(t, status.Recent, 2)
+	assert.Equal(t, 2, status.Recent[0].ID)
+	require.Len(t, status.Roots, 3)
+	for _, root := range status.Roots {
+		require.NotNil(t, root.LastScan, root.Path)
+		switch root.Path {
+		case "/cloud":
+			assert.True(t, root.ExcludeFromFullScans)
+			assert.Equal(t, 2, root.LastJobId)
+		default:
+			assert.Equal(t, 1, root.LastJobId)
+		}
+	}
+}
+
+func TestCoordinator_NoEligibleRoot(t *testing.T) {
+	c, _, _ := newTestCoordinator(t)
+	c.SetSettings(&models.LibrarySettings{
+		LibraryPath:         "/cloud",
+		LibraryRootPolicies: models.LibraryRootPolicies{{Path: "/cloud", ExcludeFromFullScans: true}},
+	})
+
+	_, _, err := c.ScanFull(context.Background(), TriggerManual, ScanOptions{})
+	assert.ErrorIs(t, err, ErrNoEligibleRoot)
+}
+
+func TestCoordinator_ScheduledScans(t *testing.T) {
+	c, library, scheduler := newTestCoordinator(t)
+	library.release = make(chan struct{})
+
+	// Only the library paths are scheduled
+	require.Len(t, scheduler.tasks, 1)
+	run, ok := scheduler.tasks["library-scan:/cloud"]
+	require.True(t, ok)
+
+	// A full scan is running when the scheduled scan is triggered
+	go func() {
+		_, _, _ = c.ScanFull(context.Background(), TriggerManual, ScanOptions{})
+	}()
+	require.Eventually(t, func() bool {
+		return c.GetStatus().Current != nil
+	}, time.Second, 10*time.Millisecond)
+
+	run()
+	// The same scan is not queued twice
+	run()
+
+	status := c.GetStatus()
+	require.Len(t, status.Queued, 1)
+	assert.Equal(t, TriggerSchedule, status.Queued[0].Trigger)
+	assert.Equal(t, []string{"/cloud"}, status.Queued[0].Roots)
+
+	library.release <- struct{}{}
+	library.release <- struct{}{}
+	require.Eventually(t, func() bool {
+		return len(c.GetStatus().Recent) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.False(t, library.overlapped)
+	assert.Equal(t, [][]string{{"/anime", "/movies"}, {"/cloud"}}, library.scanned)
+
+	// Removing the schedule removes the task
+	c.SetSettings(&models.LibrarySettings{
+		LibraryPath:  "/anime",
+		LibraryPaths: []string{"/movies", "/cloud"},
+	})
+	assert.Empty(t, scheduler.tasks)
+	assert.Equal(t, []string{"library-scan:/cloud"}, scheduler.removed)
+}
+
+func TestParseScanSchedule(t *testing.T) {
+	hour, minute, err := ParseScanSchedule("03:30")
+	require.NoError(t, err)
+	assert.Equal(t, 3, hour)
+	assert.Equal(t, 30, minute)
+
+	for _, schedule := range []string{"3h", "25:00", "12:60", ""} {
+		_, _, err := ParseScanSchedule(schedule)
+		assert.Error(t, err, schedule)
+	}
+}
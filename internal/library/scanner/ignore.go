@@ -1,3 +1,37 @@
 package scanner
 
+import (
+	"path/filepath"
+	"seanime/internal/util"
+	"strings"
+)
+
 // .seaignore
+
+// PartialFileExtensions are the extensions torrent clients append to files that are still downloading.
+var PartialFileExtensions = []string{".!qB", ".part", ".tmp"}
+
+// IsPartialFile returns true if the file is still being downloaded by a torrent client.
+func IsPartialFile(path string) bool {
+	for _, ext := range PartialFileExtensions {
+		if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExcludedPath returns true if the path is one of the excluded directories or is inside one of them.
+func IsExcludedPath(path string, excludedPaths []string) bool {
+	path = util.NormalizePath(filepath.Clean(path))
+	for _, excluded := range excludedPaths {
+		if excluded == "" {
+			continue
+		}
+		excluded = util.NormalizePath(filepath.Clean(excluded))
+		if path == excluded || strings.HasPrefix(path, strings.TrimSuffix(excluded, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,381 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/filesystem"
+	"seanime/internal/util"
+	"seanime/internal/util/comparison"
+	"slices"
+	"strings"
+
+	"github.com/adrg/strutil/metrics"
+	"github.com/samber/lo"
+	lop "github.com/samber/lo/parallel"
+)
+
+const (
+	MatchSignalLocked     MatchSignalType = "locked"      // The file is locked to a media and is not matched again
+	MatchSignalPreMatch   MatchSignalType = "pre-match"   // The file is inside the destination of a torrent downloaded from an anime's page
+	MatchSignalFuzzyTitle MatchSignalType = "fuzzy-title" // The parsed title of the file is compared against the media titles
+)
+
+type (
+	MatchSignalType string
+
+	ExplainMatchesOptions struct {
+		// Path is a file or a directory
+		Path               string
+		LibraryPaths       []string
+		ExistingLocalFiles []*anime.LocalFile
+		AnimeCollection    *anilist.AnimeCollectionWithRelations
+		PreMatchMap        map[string]int
+		SkipLockedFiles    bool
+		ExcludedPaths      []string
+		MatchingAlgorithm  string
+		MatchingThreshold  float64
+	}
+
+	// MatchExplanation describes why a local file is matched to a media.
+	MatchExplanation struct {
+		Path string `json:"path"`
+		// Signals are listed in precedence order, the first accepted signal wins
+		Signals []*MatchSignal `json:"signals"`
+		// Winner is the type of the signal that decides the match, empty if the file would not be matched
+		Winner MatchSignalType `json:"winner"`
+		// MediaId is the media the file would be matched to, 0 if the file would not be matched
+		MediaId  int      `json:"mediaId"`
+		Warnings []string `json:"warnings"`
+	}
+
+	MatchSignal struct {
+		Type    MatchSignalType `json:"type"`
+		MediaId int             `json:"mediaId"`
+		// Accepted is false when the signal is too weak to decide the match, e.g. a fuzzy rating below the threshold
+		Accepted bool   `json:"accepted"`
+		Detail   string `json:"detail"`
+		// TitleMatch is only set for the fuzzy title signal
+		TitleMatch *TitleMatch `json:"titleMatch,omitempty"`
+	}
+
+	// TitleMatch is the result of the fuzzy comparison between the title variations of a local file and the media titles.
+	TitleMatch struct {
+		Algorithm string `json:"algorithm"`
+		// Title is the title variation of the local file that produced the best match
+		Title string `json:"title"`
+		// MatchedTitle is the closest media title or synonym
+		MatchedTitle string `json:"matchedTitle"`
+		// Rating is the final rating compared against the threshold
+		Rating float64 `json:"rating"`
+		// Distance is only set when using Levenshtein
+		Distance  int     `json:"distance,omitempty"`
+		Threshold float64 `json:"threshold"`
+		// MediaId is 0 if no media has the matched title
+		MediaId int `json:"mediaId"`
+		// Candidates are the best matches for each title variation, best first
+		Candidates []*MatchCandidate `json:"candidates"`
+
+		media *anime.NormalizedMedia
+	}
+
+	MatchCandidate struct {
+		Title        string  `json:"title"`
+		MatchedTitle string  `json:"matchedTitle"`
+		MediaId      int     `json:"mediaId"`
+		Rating       float64 `json:"rating"`
+		Distance     int     `json:"distance,omitempty"`
+	}
+
+	// titleComparison is the best media title for a title variation.
+	titleComparison struct {
+		original *string
+		value    *string
+		rating   float64
+		distance int
+	}
+)
+
+// ExplainMatches explains the match of each media file in the path, using the media of the user's collection.
+func ExplainMatches(opts *ExplainMatchesOptions) ([]*MatchExplanation, error) {
+	if opts.AnimeCollection == nil {
+		return nil, errors.New("anime collection not found")
+	}
+
+	info, err := os.Stat(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{opts.Path}
+	if info.IsDir() {
+		paths, err = filesystem.GetMediaFilePathsFromDirS(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	paths = lo.Filter(paths, func(path string, _ int) bool {
+		return !IsPartialFile(path) && !IsExcludedPath(path, opts.ExcludedPaths)
+	})
+
+	lockedLfs := make([]*anime.LocalFile, 0)
+	if opts.SkipLockedFiles {
+		lockedLfs = lo.Filter(opts.ExistingLocalFiles, func(lf *anime.LocalFile, _ int) bool {
+			return lf.IsLocked()
+		})
+	}
+
+	matcher := &Matcher{
+		LocalFiles: lo.Map(paths, func(path string, _ int) *anime.LocalFile {
+			return anime.NewLocalFileS(path, opts.LibraryPaths)
+		}),
+		MediaContainer: NewMediaContainer(&MediaContainerOptions{
+			AllMedia: opts.AnimeCollection.GetAllAnime(),
+		}),
+		Algorithm:        opts.MatchingAlgorithm,
+		Threshold:        opts.MatchingThreshold,
+		PreMatchMap:      opts.PreMatchMap,
+		LockedLocalFiles: lockedLfs,
+	}
+
+	return matcher.ExplainLocalFiles(), nil
+}
+
+// ExplainLocalFiles explains the match of each local file without modifying them.
+func (m *Matcher) ExplainLocalFiles() []*MatchExplanation {
+	if m.Threshold == 0 {
+		m.Threshold = 0.5
+	}
+	return lop.Map(m.LocalFiles, func(lf *anime.LocalFile, _ int) *MatchExplanation {
+		return m.explainLocalFile(lf)
+	})
+}
+
+// explainLocalFile collects every matching signal for the local file, in the order the matcher applies them,
+// and reports the conflicts between them.
+func (m *Matcher) explainLocalFile(lf *anime.LocalFile) *MatchExplanation {
+	ret := &MatchExplanation{
+		Path:     lf.Path,
+		Signals:  make([]*MatchSignal, 0),
+		Warnings: make([]string, 0),
+	}
+
+	normalizedPath := util.NormalizePath(lf.Path)
+
+	if locked, found := lo.Find(m.LockedLocalFiles, func(l *anime.LocalFile) bool {
+		return l.GetNormalizedPath() == normalizedPath
+	}); found && locked.MediaId != 0 {
+		ret.Signals = append(ret.Signals, &MatchSignal{
+			Type:     MatchSignalLocked,
+			MediaId:  locked.MediaId,
+			Accepted: true,
+			Detail:   "The file is locked",
+		})
+	}
+
+	if destPath, mediaId, found := m.findPreMatch(lf); found {
+		ret.Signals = append(ret.Signals, &MatchSignal{
+			Type:     MatchSignalPreMatch,
+			MediaId:  mediaId,
+			Accepted: true,
+			Detail:   fmt.Sprintf("The file is inside %s", destPath),
+		})
+	}
+
+	if titleVariations := lf.GetTitleVariations(); lf.GetParsedTitle() != "" && len(titleVariations) > 0 {
+		if titleMatch := m.matchTitles(titleVariations); titleMatch != nil {
+			signal := &MatchSignal{
+				Type:       MatchSignalFuzzyTitle,
+				MediaId:    titleMatch.MediaId,
+				Accepted:   titleMatch.media != nil && titleMatch.Rating >= m.Threshold,
+				TitleMatch: titleMatch,
+			}
+			switch {
+			case titleMatch.media == nil:
+				signal.Detail = fmt.Sprintf("No media found for the title %q", titleMatch.MatchedTitle)
+			case signal.Accepted:
+				signal.Detail = fmt.Sprintf("%q matches %q with a rating of %.2f", titleMatch.Title, titleMatch.MatchedTitle, titleMatch.Rating)
+			default:
+				signal.Detail = fmt.Sprintf("%q matches %q with a rating of %.2f, below the threshold of %.2f", titleMatch.Title, titleMatch.MatchedTitle, titleMatch.Rating, m.Threshold)
+			}
+			ret.Signals = append(ret.Signals, signal)
+		}
+	}
+
+	if winner, found := lo.Find(ret.Signals, func(s *MatchSignal) bool { return s.Accepted }); found {
+		ret.Winner = winner.Type
+		ret.MediaId = winner.MediaId
+	}
+
+	// Report the signals that disagree with the winner
+	for _, s := range ret.Signals {
+		if !s.Accepted || s.Type == ret.Winner || s.MediaId == ret.MediaId {
+			continue
+		}
+		ret.Warnings = append(ret.Warnings, fmt.Sprintf("The %s signal says %s but the %s signal wins with %s",
+			s.Type, m.describeMedia(s.MediaId), ret.Winner, m.describeMedia(ret.MediaId)))
+	}
+
+	// Report the locked files in the same directory that are matched to another media
+	if ret.MediaId != 0 {
+		dir := filepath.Dir(normalizedPath)
+		otherMediaIds := make([]int, 0)
+		for _, locked := range m.LockedLocalFiles {
+			lockedPath := locked.GetNormalizedPath()
+			if lockedPath == normalizedPath || filepath.Dir(lockedPath) != dir {
+				continue
+			}
+			if locked.MediaId != 0 && locked.MediaId != ret.MediaId && !slices.Contains(otherMediaIds, locked.MediaId) {
+				otherMediaIds = append(otherMediaIds, locked.MediaId)
+			}
+		}
+		for _, id := range otherMediaIds {
+			ret.Warnings = append(ret.Warnings, fmt.Sprintf("Locked files in the same folder are matched to %s but the %s signal matches this file to %s",
+				m.describeMedia(id), ret.Winner, m.describeMedia(ret.MediaId)))
+		}
+	}
+
+	return ret
+}
+
+// findPreMatch returns the pre-matched destination that contains the local file.
+func (m *Matcher) findPreMatch(lf *anime.LocalFile) (string, int, bool) {
+	if len(m.PreMatchMap) == 0 {
+		return "", 0, false
+	}
+	normalizedPath := util.NormalizePath(lf.Path)
+	for destPath, mediaId := range m.PreMatchMap {
+		if strings.HasPrefix(normalizedPath, destPath) {
+			return destPath, mediaId, true
+		}
+	}
+	return "", 0, false
+}
+
+// matchTitles compares the title variations against the media titles using the selected algorithm.
+// It returns nil if no title could be compared.
+func (m *Matcher) matchTitles(titleVariations []*string) *TitleMatch {
+	algorithm := "Levenshtein"
+	switch m.Algorithm {
+	case "jaccard":
+		algorithm = "Jaccard"
+	case "sorensen-dice":
+		algorithm = "Sorensen-Dice"
+	}
+
+	// Get the best match for each title variation
+	compResults := lop.Map(titleVariations, func(title *string, _ int) *titleComparison {
+		var best *titleComparison
+		for _, titles := range [][]*string{m.MediaContainer.engTitles, m.MediaContainer.romTitles, m.MediaContainer.synonyms} {
+			if len(titles) == 0 {
+				continue
+			}
+			if comp, found := m.compareTitle(title, titles); found && (best == nil || !m.isBetter(best, comp)) {
+				best = comp
+			}
+		}
+		return best
+	})
+	compResults = lo.Filter(compResults, func(c *titleComparison, _ int) bool {
+		return c != nil
+	})
+	if len(compResults) == 0 {
+		return nil
+	}
+
+	// Retrieve the match from all the title variations results
+	best := compResults[0]
+	for _, comp := range compResults[1:] {
+		if !m.isBetter(best, comp) {
+			best = comp
+		}
+	}
+
+	ret := &TitleMatch{
+		Algorithm:    algorithm,
+		Title:        *best.original,
+		MatchedTitle: *best.value,
+		Rating:       m.finalRating(best),
+		Distance:     best.distance,
+		Threshold:    m.Threshold,
+		Candidates:   make([]*MatchCandidate, 0, len(compResults)),
+	}
+	if media, found := m.MediaContainer.GetMediaFromTitleOrSynonym(best.value); found {
+		ret.media = media
+		ret.MediaId = media.ID
+	}
+
+	slices.SortStableFunc(compResults, func(a, b *titleComparison) int {
+		if m.isBetter(a, b) {
+			return -1
+		}
+		if m.isBetter(b, a) {
+			return 1
+		}
+		return 0
+	})
+	for _, comp := range compResults {
+		candidate := &MatchCandidate{
+			Title:        *comp.original,
+			MatchedTitle: *comp.value,
+			Rating:       m.finalRating(comp),
+			Distance:     comp.distance,
+		}
+		if media, found := m.MediaContainer.GetMediaFromTitleOrSynonym(comp.value); found {
+			candidate.MediaId = media.ID
+		}
+		ret.Candidates = append(ret.Candidates, candidate)
+	}
+
+	return ret
+}
+
+// compareTitle returns the closest title using the selected algorithm.
+func (m *Matcher) compareTitle(title *string, titles []*string) (*titleComparison, bool) {
+	switch m.Algorithm {
+	case "jaccard":
+		if res, found := comparison.FindBestMatchWithJaccard(title, titles); found {
+			return &titleComparison{original: res.OriginalValue, value: res.Value, rating: res.Rating}, true
+		}
+	case "sorensen-dice":
+		if res, found := comparison.FindBestMatchWithSorensenDice(title, titles); found {
+			return &titleComparison{original: res.OriginalValue, value: res.Value, rating: res.Rating}, true
+		}
+	default:
+		if res, found := comparison.FindBestMatchWithLevenshtein(title, titles); found {
+			return &titleComparison{original: res.OriginalValue, value: res.Value, distance: res.Distance}, true
+		}
+	}
+	return nil, false
+}
+
+// isBetter returns true if a is strictly better than b.
+// Levenshtein compares distances, the other algorithms compare ratings.
+func (m *Matcher) isBetter(a, b *titleComparison) bool {
+	if m.Algorithm == "jaccard" || m.Algorithm == "sorensen-dice" {
+		return a.rating > b.rating
+	}
+	return a.distance < b.distance
+}
+
+// finalRating returns the rating compared against the threshold.
+// Levenshtein distances are converted to a Sorensen-Dice rating.
+func (m *Matcher) finalRating(comp *titleComparison) float64 {
+	if m.Algorithm == "jaccard" || m.Algorithm == "sorensen-dice" {
+		return comp.rating
+	}
+	dice := metrics.NewSorensenDice()
+	dice.CaseSensitive = false
+	dice.NgramSize = 1
+	return dice.Compare(*comp.original, *comp.value)
+}
+
+func (m *Matcher) describeMedia(mediaId int) string {
+	if media, found := m.MediaContainer.GetMediaFromId(mediaId); found {
+		return fmt.Sprintf("%q (%d)", media.GetTitleSafe(), mediaId)
+	}
+	return fmt.Sprintf("media %d", mediaId)
+}
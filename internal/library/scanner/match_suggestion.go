@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"errors"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+
+	"github.com/samber/lo"
+)
+
+type (
+	SuggestMediaOptions struct {
+		// Paths are the paths of the files, they do not need to exist on disk
+		Paths             []string
+		LibraryPaths      []string
+		AnimeCollection   *anilist.AnimeCollectionWithRelations
+		MatchingAlgorithm string
+		MatchingThreshold float64
+	}
+
+	// MediaSuggestion is the media that most files are matched to using the fuzzy title matching.
+	MediaSuggestion struct {
+		// MediaId is 0 if no file could be matched
+		MediaId int `json:"mediaId"`
+		// Confidence is the sum of the ratings of the files matched to the media divided by the number of files.
+		// It is between 0 and 1 and is low when the files are not all matched to the same media.
+		Confidence   float64 `json:"confidence"`
+		MatchedFiles int     `json:"matchedFiles"`
+		TotalFiles   int     `json:"totalFiles"`
+	}
+)
+
+// SuggestMedia runs the fuzzy title matching on the video files and returns the media most of them are matched to.
+// Locked files and pre-matches are ignored.
+func SuggestMedia(opts *SuggestMediaOptions) (*MediaSuggestion, error) {
+	if opts.AnimeCollection == nil {
+		return nil, errors.New("anime collection not found")
+	}
+
+	paths := lo.Filter(opts.Paths, func(path string, _ int) bool {
+		return util.IsValidVideoExtension(filepath.Ext(path)) && util.IsValidMediaFile(filepath.Base(path)) && !IsPartialFile(path)
+	})
+
+	ret := &MediaSuggestion{
+		TotalFiles: len(paths),
+	}
+	if len(paths) == 0 {
+		return ret, nil
+	}
+
+	matcher := &Matcher{
+		LocalFiles: lo.Map(paths, func(path string, _ int) *anime.LocalFile {
+			return anime.NewLocalFileS(path, opts.LibraryPaths)
+		}),
+		MediaContainer: NewMediaContainer(&MediaContainerOptions{
+			AllMedia: opts.AnimeCollection.GetAllAnime(),
+		}),
+		Algorithm: opts.MatchingAlgorithm,
+		Threshold: opts.MatchingThreshold,
+	}
+
+	// Sum the ratings of the files for each media
+	ratings := make(map[int]float64)
+	counts := make(map[int]int)
+	for _, explanation := range matcher.ExplainLocalFiles() {
+		if explanation.MediaId == 0 {
+			continue
+		}
+		signal, found := lo.Find(explanation.Signals, func(s *MatchSignal) bool {
+			return s.Type == MatchSignalFuzzyTitle && s.TitleMatch != nil
+		})
+		if !found {
+			continue
+		}
+		ratings[explanation.MediaId] += signal.TitleMatch.Rating
+		counts[explanation.MediaId]++
+	}
+
+	for mediaId, count := range counts {
+		if count > ret.MatchedFiles || (count == ret.MatchedFiles && ratings[mediaId] > ratings[ret.MediaId]) {
+			ret.MediaId = mediaId
+			ret.MatchedFiles = count
+		}
+	}
+
+	if ret.MediaId != 0 {
+		ret.Confidence = min(ratings[ret.MediaId]/float64(ret.TotalFiles), 1)
+	}
+
+	return ret, nil
+}
@@ -12,7 +12,6 @@ import (
 	"seanime/internal/util/comparison"
 	"time"
 
-	"github.com/adrg/strutil/metrics"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
@@ -31,6 +30,8 @@ type Matcher struct {
 	// PreMatchMap maps normalized destination paths to media IDs for pre-matching torrents
 	// This allows skipping fuzzy matching for files downloaded from an anime's page
 	PreMatchMap map[string]int
+	// LockedLocalFiles are the locked files that are kept as is, used to explain matches and report conflicts
+	LockedLocalFiles []*anime.LocalFile
 }
 
 var (
@@ -132,26 +133,44 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 		return
 	}
 
-	// Check for pre-match from torrent download
-	// This allows us to skip fuzzy matching for files downloaded from an anime's page
-	if m.PreMatchMap != nil && len(m.PreMatchMap) > 0 {
-		normalizedPath := util.NormalizePath(lf.Path)
-		for destPath, mediaId := range m.PreMatchMap {
-			if len(normalizedPath) >= len(destPath) && normalizedPath[:len(destPath)] == destPath {
-				// Pre-match found - use the media ID directly
-				// We trust the pre-match since it was set when the user downloaded from a specific anime page
-				lf.MediaId = mediaId
-				if m.ScanLogger != nil {
-					m.ScanLogger.LogMatcher(zerolog.InfoLevel).
-						Str("filename", lf.Name).
-						Int("mediaId", mediaId).
-						Str("destination", destPath).
-						Msg("File pre-matched from torrent download")
-				}
-				m.ScanSummaryLogger.LogSuccessfullyMatched(lf, mediaId)
-				return
-			}
+	// Collect the matching signals
+	explanation := m.explainLocalFile(lf)
+	for _, warning := range explanation.Warnings {
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.WarnLevel).
+				Str("filename", lf.Name).
+				Msg(warning)
+		}
+		m.ScanSummaryLogger.LogMatchConflict(lf, warning)
+	}
+
+	switch explanation.Winner {
+	case MatchSignalLocked:
+		// The file is locked, keep the media ID
+		lf.MediaId = explanation.MediaId
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+				Str("filename", lf.Name).
+				Int("mediaId", explanation.MediaId).
+				Msg("File is locked")
+		}
+		m.ScanSummaryLogger.LogSuccessfullyMatched(lf, explanation.MediaId)
+		return
+	case MatchSignalPreMatch:
+		// Check for pre-match from torrent download
+		// This allows us to skip fuzzy matching for files downloaded from an anime's page
+		// We trust the pre-match since it was set when the user downloaded from a specific anime page
+		destPath, _, _ := m.findPreMatch(lf)
+		lf.MediaId = explanation.MediaId
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.InfoLevel).
+				Str("filename", lf.Name).
+				Int("mediaId", explanation.MediaId).
+				Str("destination", destPath).
+				Msg("File pre-matched from torrent download")
 		}
+		m.ScanSummaryLogger.LogSuccessfullyMatched(lf, explanation.MediaId)
+		return
 	}
 
 	// Check if the local file has a title
@@ -190,194 +209,40 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 
 	//------------------
 
-	var levMatch *comparison.LevenshteinResult
-	var sdMatch *comparison.SorensenDiceResult
-	var jaccardMatch *comparison.JaccardResult
-
-	if m.Algorithm == "jaccard" {
-		// Using Jaccard
-		// Get the matchs for each title variation
-		compResults := lop.Map(titleVariations, func(title *string, _ int) *comparison.JaccardResult {
-			comps := make([]*comparison.JaccardResult, 0)
-			if len(m.MediaContainer.engTitles) > 0 {
-				if eng, found := comparison.FindBestMatchWithJaccard(title, m.MediaContainer.engTitles); found {
-					comps = append(comps, eng)
-				}
-			}
-			if len(m.MediaContainer.romTitles) > 0 {
-				if rom, found := comparison.FindBestMatchWithJaccard(title, m.MediaContainer.romTitles); found {
-					comps = append(comps, rom)
-				}
-			}
-			if len(m.MediaContainer.synonyms) > 0 {
-				if syn, found := comparison.FindBestMatchWithJaccard(title, m.MediaContainer.synonyms); found {
-					comps = append(comps, syn)
-				}
-			}
-			var res *comparison.JaccardResult
-			if len(comps) > 1 {
-				res = lo.Reduce(comps, func(prev *comparison.JaccardResult, curr *comparison.JaccardResult, _ int) *comparison.JaccardResult {
-					if prev.Rating > curr.Rating {
-						return prev
-					} else {
-						return curr
-					}
-				}, comps[0])
-			} else if len(comps) == 1 {
-				return comps[0]
-			}
-			return res
-		})
-
-		// Retrieve the match from all the title variations results
-		jaccardMatch = lo.Reduce(compResults, func(prev *comparison.JaccardResult, curr *comparison.JaccardResult, _ int) *comparison.JaccardResult {
-			if prev.Rating > curr.Rating {
-				return prev
-			} else {
-				return curr
-			}
-		}, compResults[0])
-
-		if m.ScanLogger != nil {
-			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
-				Str("filename", lf.Name).
-				Interface("match", jaccardMatch).
-				Interface("results", compResults).
-				Msg("Jaccard match")
-		}
-		m.ScanSummaryLogger.LogComparison(lf, "Jaccard", *jaccardMatch.Value, "Rating", util.InlineSpewT(jaccardMatch.Rating))
-
-	} else if m.Algorithm == "sorensen-dice" {
-		// Using Sorensen-Dice
-		// Get the matchs for each title variation
-		compResults := lop.Map(titleVariations, func(title *string, _ int) *comparison.SorensenDiceResult {
-			comps := make([]*comparison.SorensenDiceResult, 0)
-			if len(m.MediaContainer.engTitles) > 0 {
-				if eng, found := comparison.FindBestMatchWithSorensenDice(title, m.MediaContainer.engTitles); found {
-					comps = append(comps, eng)
-				}
-			}
-			if len(m.MediaContainer.romTitles) > 0 {
-				if rom, found := comparison.FindBestMatchWithSorensenDice(title, m.MediaContainer.romTitles); found {
-					comps = append(comps, rom)
-				}
-			}
-			if len(m.MediaContainer.synonyms) > 0 {
-				if syn, found := comparison.FindBestMatchWithSorensenDice(title, m.MediaContainer.synonyms); found {
-					comps = append(comps, syn)
-				}
-			}
-			var res *comparison.SorensenDiceResult
-			if len(comps) > 1 {
-				res = lo.Reduce(comps, func(prev *comparison.SorensenDiceResult, curr *comparison.SorensenDiceResult, _ int) *comparison.SorensenDiceResult {
-					if prev.Rating > curr.Rating {
-						return prev
-					} else {
-						return curr
-					}
-				}, comps[0])
-			} else if len(comps) == 1 {
-				return comps[0]
-			}
-			return res
-		})
-
-		// Retrieve the match from all the title variations results
-		sdMatch = lo.Reduce(compResults, func(prev *comparison.SorensenDiceResult, curr *comparison.SorensenDiceResult, _ int) *comparison.SorensenDiceResult {
-			if prev.Rating > curr.Rating {
-				return prev
-			} else {
-				return curr
-			}
-		}, compResults[0])
-
-		//util.Spew(compResults)
-
+	signal, ok := lo.Find(explanation.Signals, func(s *MatchSignal) bool {
+		return s.Type == MatchSignalFuzzyTitle
+	})
+	if !ok {
 		if m.ScanLogger != nil {
-			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+			m.ScanLogger.LogMatcher(zerolog.WarnLevel).
 				Str("filename", lf.Name).
-				Interface("match", sdMatch).
-				Interface("results", compResults).
-				Msg("Sorensen-Dice match")
+				Msg("No comparison result")
 		}
-		m.ScanSummaryLogger.LogComparison(lf, "Sorensen-Dice", *sdMatch.Value, "Rating", util.InlineSpewT(sdMatch.Rating))
+		m.ScanSummaryLogger.LogFileNotMatched(lf, "No comparison result")
+		return
+	}
+	titleMatch := signal.TitleMatch
 
+	if m.ScanLogger != nil {
+		m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+			Str("filename", lf.Name).
+			Interface("match", titleMatch.MatchedTitle).
+			Interface("results", titleMatch.Candidates).
+			Int("distance", titleMatch.Distance).
+			Msg(titleMatch.Algorithm + " match")
+	}
+	if titleMatch.Algorithm == "Levenshtein" {
+		m.ScanSummaryLogger.LogComparison(lf, titleMatch.Algorithm, titleMatch.MatchedTitle, "Distance", util.InlineSpewT(titleMatch.Distance))
+		m.ScanSummaryLogger.LogComparison(lf, "Sorensen-Dice", titleMatch.MatchedTitle, "Final rating", util.InlineSpewT(titleMatch.Rating))
 	} else {
-		// Using Levenshtein
-		// Get the matches for each title variation
-		levCompResults := lop.Map(titleVariations, func(title *string, _ int) *comparison.LevenshteinResult {
-			comps := make([]*comparison.LevenshteinResult, 0)
-			if len(m.MediaContainer.engTitles) > 0 {
-				if eng, found := comparison.FindBestMatchWithLevenshtein(title, m.MediaContainer.engTitles); found {
-					comps = append(comps, eng)
-				}
-			}
-			if len(m.MediaContainer.romTitles) > 0 {
-				if rom, found := comparison.FindBestMatchWithLevenshtein(title, m.MediaContainer.romTitles); found {
-					comps = append(comps, rom)
-				}
-			}
-			if len(m.MediaContainer.synonyms) > 0 {
-				if syn, found := comparison.FindBestMatchWithLevenshtein(title, m.MediaContainer.synonyms); found {
-					comps = append(comps, syn)
-				}
-			}
-			var res *comparison.LevenshteinResult
-			if len(comps) > 1 {
-				res = lo.Reduce(comps, func(prev *comparison.LevenshteinResult, curr *comparison.LevenshteinResult, _ int) *comparison.LevenshteinResult {
-					if prev.Distance < curr.Distance {
-						return prev
-					} else {
-						return curr
-					}
-				}, comps[0])
-			} else if len(comps) == 1 {
-				return comps[0]
-			}
-			return res
-		})
-
-		levMatch = lo.Reduce(levCompResults, func(prev *comparison.LevenshteinResult, curr *comparison.LevenshteinResult, _ int) *comparison.LevenshteinResult {
-			if prev.Distance < curr.Distance {
-				return prev
-			} else {
-				return curr
-			}
-		}, levCompResults[0])
-
-		if m.ScanLogger != nil {
-			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
-				Str("filename", lf.Name).
-				Interface("match", levMatch).
-				Interface("results", levCompResults).
-				Int("distance", levMatch.Distance).
-				Msg("Levenshtein match")
-		}
-		m.ScanSummaryLogger.LogComparison(lf, "Levenshtein", *levMatch.Value, "Distance", util.InlineSpewT(levMatch.Distance))
+		m.ScanSummaryLogger.LogComparison(lf, titleMatch.Algorithm, titleMatch.MatchedTitle, "Rating", util.InlineSpewT(titleMatch.Rating))
 	}
 
 	//------------------
 
-	var mediaMatch *anime.NormalizedMedia
-	var found bool
-	finalRating := 0.0
-
-	if sdMatch != nil {
-		finalRating = sdMatch.Rating
-		mediaMatch, found = m.MediaContainer.GetMediaFromTitleOrSynonym(sdMatch.Value)
-
-	} else if jaccardMatch != nil {
-		finalRating = jaccardMatch.Rating
-		mediaMatch, found = m.MediaContainer.GetMediaFromTitleOrSynonym(jaccardMatch.Value)
-
-	} else {
-		dice := metrics.NewSorensenDice()
-		dice.CaseSensitive = false
-		dice.NgramSize = 1
-		finalRating = dice.Compare(*levMatch.OriginalValue, *levMatch.Value)
-		m.ScanSummaryLogger.LogComparison(lf, "Sorensen-Dice", *levMatch.Value, "Final rating", util.InlineSpewT(finalRating))
-		mediaMatch, found = m.MediaContainer.GetMediaFromTitleOrSynonym(levMatch.Value)
-	}
+	mediaMatch := titleMatch.media
+	found := mediaMatch != nil
+	finalRating := titleMatch.Rating
 
 	// After setting the mediaId, add the hook invocation
 	// Invoke ScanLocalFileMatched hook
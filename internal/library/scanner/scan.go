@@ -3,6 +3,7 @@ package scanner
 import (
 	"context"
 	"errors"
+	pathpkg "path"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/metadata_provider"
 	"seanime/internal/events"
@@ -13,6 +14,8 @@ import (
 	"seanime/internal/platforms/platform"
 	"seanime/internal/util"
 	"seanime/internal/util/limiter"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +24,9 @@ import (
 	lop "github.com/samber/lo/parallel"
 )
 
+// ErrNoLibraryPathToScan is returned when none of the roots of a scan is a library path
+var ErrNoLibraryPathToScan = errors.New("scanner: none of the paths to scan is a library path")
+
 type Scanner struct {
 	DirPath             string
 	OtherDirPaths       []string
@@ -39,6 +45,23 @@ type Scanner struct {
 	// PreMatchMap maps normalized destination paths to media IDs for pre-matching torrents
 	// This allows skipping fuzzy matching for files downloaded from an anime's page
 	PreMatchMap map[string]int
+	// ExcludedPaths are directories that are not scanned, e.g. the torrent client's incomplete downloads directory
+	ExcludedPaths []string
+	// Roots are the library paths to scan, the local files of the other library paths are kept unchanged.
+	// Every library path is scanned if it is empty.
+	Roots []string
+	// IOConcurrency returns the number of directories of a library path that are read at the same time, they are read one at a time if it is nil
+	IOConcurrency func(libraryPath string) int
+	// SentinelFile returns the file that must exist in a library path for it to be considered available, nil if there is none
+	SentinelFile func(libraryPath string) string
+	// ForceReconcile removes the files that are not found even if their library path looks unavailable
+	ForceReconcile bool
+	// UnavailableRoots are the library paths that were skipped because they looked unavailable, set by Scan.
+	// Their files are kept and marked as unverified.
+	UnavailableRoots []string
+	// MatchedPreMatches are the destinations of the pre-matches whose files were matched to their media, set by Scan.
+	// They are no longer needed once their files are in the library.
+	MatchedPreMatches []string
 }
 
 // Scan will scan the directory and return a list of anime.LocalFile.
@@ -101,6 +124,30 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 
 	libraryPaths := append([]string{scn.DirPath}, scn.OtherDirPaths...)
 
+	scannedPaths, keptLfs, err := scn.getScannedPaths(libraryPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the library paths that look unavailable so that their files are not removed
+	scn.UnavailableRoots = make([]string, 0)
+	if !scn.ForceReconcile {
+		scannedPaths = lo.Filter(scannedPaths, func(root string, _ int) bool {
+			sentinel := ""
+			if scn.SentinelFile != nil {
+				sentinel = scn.SentinelFile(root)
+			}
+			hadFiles := lo.ContainsBy(scn.ExistingLocalFiles, func(lf *anime.LocalFile) bool {
+				return getOwner(libraryPaths, lf.Path) == root
+			})
+			if err := filesystem.CheckRootHealth(root, hadFiles, sentinel); err != nil {
+				scn.markUnavailable(root, err, libraryPaths, keptLfs)
+				return false
+			}
+			return true
+		})
+	}
+
 	// Create a map of local file paths used to avoid duplicates
 	retrievedPathMap := make(map[string]struct{})
 
@@ -109,15 +156,24 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 	logMu := sync.Mutex{}
 	wg := sync.WaitGroup{}
 
-	wg.Add(len(libraryPaths))
+	failedPaths := make(map[string]error)
+
+	wg.Add(len(scannedPaths))
 
 	// Get local files from all directories
-	for i, dirPath := range libraryPaths {
+	for i, dirPath := range scannedPaths {
 		go func(dirPath string, i int) {
 			defer wg.Done()
-			retrievedPaths, err := filesystem.GetMediaFilePathsFromDirS(dirPath)
+			concurrency := 0
+			if scn.IOConcurrency != nil {
+				concurrency = scn.IOConcurrency(dirPath)
+			}
+			retrievedPaths, err := filesystem.GetMediaFilePathsFromDirC(dirPath, concurrency)
 			if err != nil {
 				scn.Logger.Error().Msgf("scanner: An error occurred while retrieving local files from directory: %s", err)
+				mu.Lock()
+				failedPaths[dirPath] = err
+				mu.Unlock()
 				return
 			}
 
@@ -136,6 +192,10 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 			}
 
 			for _, path := range retrievedPaths {
+				// Skip files that are still being downloaded
+				if IsPartialFile(path) || IsExcludedPath(path, scn.ExcludedPaths) {
+					continue
+				}
 				if _, ok := retrievedPathMap[util.NormalizePath(path)]; !ok {
 					mu.Lock()
 					paths = append(paths, path)
@@ -147,6 +207,18 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 
 	wg.Wait()
 
+	// The files of the library paths that could not be read are kept
+	if !scn.ForceReconcile {
+		for dirPath, err := range failedPaths {
+			scn.markUnavailable(dirPath, err, libraryPaths, keptLfs)
+		}
+		scannedPaths = lo.Filter(scannedPaths, func(root string, _ int) bool {
+			_, failed := failedPaths[root]
+			return !failed
+		})
+	}
+	scn.ScanSummaryLogger.Roots = scannedPaths
+
 	if scn.ScanLogger != nil {
 		scn.ScanLogger.logger.Info().
 			Any("count", len(paths)).
@@ -168,11 +240,16 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 
 	// Get skipped files depending on options
 	skippedLfs := make(map[string]*anime.LocalFile)
+	lockedLfs := make([]*anime.LocalFile, 0)
 	if (scn.SkipLockedFiles || scn.SkipIgnoredFiles) && scn.ExistingLocalFiles != nil {
 		// Retrieve skipped files from existing local files
 		for _, lf := range scn.ExistingLocalFiles {
+			if _, ok := keptLfs[lf.GetNormalizedPath()]; ok {
+				continue
+			}
 			if scn.SkipLockedFiles && lf.IsLocked() {
 				skippedLfs[lf.GetNormalizedPath()] = lf
+				lockedLfs = append(lockedLfs, lf)
 			} else if scn.SkipIgnoredFiles && lf.IsIgnored() {
 				skippedLfs[lf.GetNormalizedPath()] = lf
 			}
@@ -245,10 +322,12 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 		if len(skippedLfs) > 0 {
 			for _, sf := range skippedLfs {
 				if filesystem.FileExists(sf.Path) { // Verify that the file still exists
+					sf.UnverifiedSince = nil
 					localFiles = append(localFiles, sf)
 				}
 			}
 		}
+		localFiles = appendKeptLocalFiles(localFiles, keptLfs)
 		scn.Logger.Debug().Msg("scanner: Scan completed")
 		scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
 		scn.WSEventManager.SendEvent(events.EventScanStatus, "Scan completed")
@@ -323,6 +402,7 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 		Algorithm:          scn.MatchingAlgorithm,
 		Threshold:          scn.MatchingThreshold,
 		PreMatchMap:        scn.PreMatchMap,
+		LockedLocalFiles:   lockedLfs,
 	}
 
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 60)
@@ -359,6 +439,8 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 	}
 	hydrator.HydrateMetadata()
 
+	scn.MatchedPreMatches = getMatchedPreMatches(scn.PreMatchMap, localFiles)
+
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 80)
 
 	// +---------------------+
@@ -398,6 +480,7 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 				defer wg.Done()
 				if filesystem.FileExists(skippedLf.Path) {
 					mu.Lock()
+					skippedLf.UnverifiedSince = nil
 					localFiles = append(localFiles, skippedLf)
 					mu.Unlock()
 				}
@@ -406,6 +489,9 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 		wg.Wait()
 	}
 
+	// Add the files of the library paths that were not scanned
+	localFiles = appendKeptLocalFiles(localFiles, keptLfs)
+
 	scn.Logger.Info().Msg("scanner: Scan completed")
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
 	scn.WSEventManager.SendEvent(events.EventScanStatus, "Scan completed")
@@ -428,6 +514,100 @@ func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error
 	return localFiles, nil
 }
 
+// getScannedPaths returns the library paths to scan and the existing local files of the library paths that are not scanned.
+// The kept local files are not verified, the library paths that are not scanned can be slow to access.
+func (scn *Scanner) getScannedPaths(libraryPaths []string) ([]string, map[string]*anime.LocalFile, error) {
+	keptLfs := make(map[string]*anime.LocalFile)
+	if len(scn.Roots) == 0 {
+		return libraryPaths, keptLfs, nil
+	}
+
+	isScanned := func(libraryPath string) bool {
+		return lo.ContainsBy(scn.Roots, func(root string) bool {
+			return pathpkg.Clean(util.NormalizePath(root)) == pathpkg.Clean(util.NormalizePath(libraryPath))
+		})
+	}
+
+	scannedPaths := lo.Filter(libraryPaths, func(libraryPath string, _ int) bool {
+		return libraryPath != "" && isScanned(libraryPath)
+	})
+	if len(scannedPaths) == 0 {
+		return nil, nil, ErrNoLibraryPathToScan
+	}
+
+	for _, lf := range scn.ExistingLocalFiles {
+		if owner := getOwner(libraryPaths, lf.Path); owner != "" && !isScanned(owner) {
+			keptLfs[lf.GetNormalizedPath()] = lf
+		}
+	}
+
+	return scannedPaths, keptLfs, nil
+}
+
+// getOwner returns the most specific library path a file is in, or an empty string if it is in none.
+func getOwner(libraryPaths []string, path string) string {
+	ret := ""
+	for _, libraryPath := range libraryPaths {
+		if libraryPath != "" && util.IsSubpath(libraryPath, path) && len(libraryPath) > len(ret) {
+			ret = libraryPath
+		}
+	}
+	return ret
+}
+
+// markUnavailable keeps the existing local files of a library path that looks unavailable instead of removing them.
+// The files are marked as unverified until a scan finds them again.
+func (scn *Scanner) markUnavailable(root string, err error, libraryPaths []string, keptLfs map[string]*anime.LocalFile) {
+	scn.Logger.Warn().Err(err).Str("path", root).Msg("scanner: Library path is unavailable, its files are kept")
+	if scn.ScanLogger != nil {
+		scn.ScanLogger.logger.Warn().Err(err).Str("path", root).Msg("Library path is unavailable, its files are kept")
+	}
+
+	scn.UnavailableRoots = append(scn.UnavailableRoots, root)
+
+	now := time.Now()
+	for _, lf := range scn.ExistingLocalFiles {
+		if getOwner(libraryPaths, lf.Path) != root {
+			continue
+		}
+		if lf.UnverifiedSince == nil {
+			lf.UnverifiedSince = &now
+		}
+		keptLfs[lf.GetNormalizedPath()] = lf
+	}
+}
+
+// appendKeptLocalFiles adds the kept local files that were not scanned again.
+// getMatchedPreMatches returns the destinations of the pre-matches that contain a file matched to their media.
+func getMatchedPreMatches(preMatchMap map[string]int, lfs []*anime.LocalFile) []string {
+	ret := make([]string, 0)
+	for destPath, mediaId := range preMatchMap {
+		if slices.ContainsFunc(lfs, func(lf *anime.LocalFile) bool {
+			return lf.MediaId == mediaId && strings.HasPrefix(util.NormalizePath(lf.Path), destPath)
+		}) {
+			ret = append(ret, destPath)
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+func appendKeptLocalFiles(lfs []*anime.LocalFile, keptLfs map[string]*anime.LocalFile) []*anime.LocalFile {
+	if len(keptLfs) == 0 {
+		return lfs
+	}
+	scanned := make(map[string]struct{}, len(lfs))
+	for _, lf := range lfs {
+		scanned[lf.GetNormalizedPath()] = struct{}{}
+	}
+	for path, lf := range keptLfs {
+		if _, ok := scanned[path]; !ok {
+			lfs = append(lfs, lf)
+		}
+	}
+	return lfs
+}
+
 // InLibrariesOnly removes files are not under the library paths
 func (scn *Scanner) InLibrariesOnly(lfs []*anime.LocalFile) {
 
@@ -16,6 +16,7 @@ type Watcher struct {
 	Logger         *zerolog.Logger
 	WSEventManager events.WSEventManagerInterface
 	TotalSize      string
+	excludedPaths  []string
 }
 
 type NewWatcherOptions struct {
@@ -41,10 +42,14 @@ func NewWatcher(opts *NewWatcherOptions) (*Watcher, error) {
 
 type WatchLibraryFilesOptions struct {
 	LibraryPaths []string
+	// ExcludedPaths are directories that are not watched, e.g. the torrent client's incomplete downloads directory
+	ExcludedPaths []string
 }
 
 // InitLibraryFileWatcher starts watching the specified directory and its subdirectories for file system events
 func (w *Watcher) InitLibraryFileWatcher(opts *WatchLibraryFilesOptions) error {
+	w.excludedPaths = opts.ExcludedPaths
+
 	// Define a function to add directories and their subdirectories to the watcher
 	watchDir := func(dir string) error {
 		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
@@ -52,6 +57,9 @@ func (w *Watcher) InitLibraryFileWatcher(opts *WatchLibraryFilesOptions) error {
 				return nil
 			}
 			if info.IsDir() {
+				if IsExcludedPath(path, w.excludedPaths) {
+					return filepath.SkipDir
+				}
 				return w.Watcher.Add(path)
 			}
 			return nil
@@ -67,6 +75,9 @@ func (w *Watcher) InitLibraryFileWatcher(opts *WatchLibraryFilesOptions) error {
 	}
 
 	w.Logger.Info().Msgf("watcher: Watching directories: %+v", opts.LibraryPaths)
+	if len(opts.ExcludedPaths) > 0 {
+		w.Logger.Info().Msgf("watcher: Excluded directories: %+v", opts.ExcludedPaths)
+	}
 
 	return nil
 }
@@ -84,7 +95,11 @@ func (w *Watcher) StartWatching(
 				}
 				//if event.Op&fsnotify.Write == fsnotify.Write {
 				//}
-				if strings.Contains(event.Name, ".part") || strings.Contains(event.Name, ".tmp") {
+				if strings.Contains(event.Name, ".part") || strings.Contains(event.Name, ".tmp") || IsPartialFile(event.Name) {
+					continue
+				}
+				// Files moved out of an excluded directory are picked up from their final location
+				if IsExcludedPath(event.Name, w.excludedPaths) {
 					continue
 				}
 				if event.Op&fsnotify.Create == fsnotify.Create {
@@ -0,0 +1,405 @@
+package sidecar
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	KindSubtitle = "subtitle"
+	KindFont     = "font"
+
+	// MaxSubtitleSize is the maximum size of an uploaded subtitle file
+	MaxSubtitleSize = 10 << 20
+	// MaxFontSize is the maximum size of a font file, or of a font once extracted from an archive
+	MaxFontSize = 30 << 20
+	// MaxFontArchiveSize is the maximum size of a font archive, compressed and extracted
+	MaxFontArchiveSize = 100 << 20
+
+	// FontsDirName is the name of the sidecar directory the fonts are stored in, next to the video file
+	FontsDirName = "fonts"
+)
+
+var (
+	subtitleExtensions = map[string]struct{}{".srt": {}, ".ass": {}, ".ssa": {}, ".vtt": {}}
+	fontExtensions     = map[string]struct{}{".ttf": {}, ".otf": {}, ".ttc": {}, ".woff": {}, ".woff2": {}}
+
+	ErrUnsupportedExtension = errors.New("sidecar: Unsupported file extension")
+	ErrFileTooLarge         = errors.New("sidecar: File is too large")
+
+	unsafeNameChars = regexp.MustCompile(`[^\p{L}\p{N}\-_ ]+`)
+)
+
+type (
+	// Store saves the subtitle and font files uploaded for local files.
+	//
+	// Subtitles are written next to the video file, e.g. "Episode 1.mkv" -> "Episode 1.eng.srt", so that other players can pick them up.
+	// Fonts are written to the FontsDirName directory next to the video file.
+	// Existing files are never overwritten.
+	Store struct {
+		db     *db.Database
+		logger *zerolog.Logger
+		mu     sync.Mutex
+	}
+
+	NewStoreOptions struct {
+		DB     *db.Database
+		Logger *zerolog.Logger
+	}
+
+	// Attachment is a subtitle or font file uploaded for a local file.
+	Attachment struct {
+		ID            uint   `json:"id"`
+		LocalFilePath string `json:"localFilePath"`
+		// Kind is "subtitle" or "font"
+		Kind string `json:"kind"`
+		// Path is where the attachment is stored
+		Path string `json:"path"`
+		// Filename is the base name of Path
+		Filename string `json:"filename"`
+		// Extension is the lowercase extension of the file, e.g. ".srt"
+		Extension  string    `json:"extension"`
+		Language   string    `json:"language"`
+		Label      string    `json:"label"`
+		Size       int64     `json:"size"`
+		UploadedBy string    `json:"uploadedBy,omitempty"`
+		CreatedAt  time.Time `json:"createdAt"`
+	}
+
+	AddOptions struct {
+		// LocalFilePath is the path of the video file
+		LocalFilePath string
+		// Filename is the name of the uploaded file, used to get the extension
+		Filename string
+		Content  []byte
+		// Language and Label are only used for subtitles
+		Language   string
+		Label      string
+		UploadedBy string
+	}
+)
+
+func NewStore(opts *NewStoreOptions) *Store {
+	return &Store{
+		db:     opts.DB,
+		logger: opts.Logger,
+	}
+}
+
+// IsSubtitleExtension returns true if the extension (e.g. ".srt") is a supported subtitle format.
+func IsSubtitleExtension(ext string) bool {
+	_, ok := subtitleExtensions[strings.ToLower(ext)]
+	return ok
+}
+
+// IsFontExtension returns true if the extension (e.g. ".ttf") is a supported font format.
+func IsFontExtension(ext string) bool {
+	_, ok := fontExtensions[strings.ToLower(ext)]
+	return ok
+}
+
+// Add validates and stores the uploaded file.
+// A font archive (.zip) is extracted, and every font it contains becomes an attachment.
+func (s *Store) Add(opts *AddOptions) ([]*Attachment, error) {
+	if opts.LocalFilePath == "" {
+		return nil, errors.New("sidecar: Local file path is required")
+	}
+
+	ext := strings.ToLower(filepath.Ext(opts.Filename))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case IsSubtitleExtension(ext):
+		if len(opts.Content) > MaxSubtitleSize {
+			return nil, ErrFileTooLarge
+		}
+		if len(bytes.TrimSpace(opts.Content)) == 0 {
+			return nil, errors.New("sidecar: Subtitle file is empty")
+		}
+		a, err := s.addSubtitle(opts, ext)
+		if err != nil {
+			return nil, err
+		}
+		return []*Attachment{a}, nil
+
+	case IsFontExtension(ext):
+		if len(opts.Content) > MaxFontSize {
+			return nil, ErrFileTooLarge
+		}
+		a, err := s.addFont(opts, filepath.Base(opts.Filename), opts.Content)
+		if err != nil {
+			return nil, err
+		}
+		return []*Attachment{a}, nil
+
+	case ext == ".zip":
+		if len(opts.Content) > MaxFontArchiveSize {
+			return nil, ErrFileTooLarge
+		}
+		return s.addFontArchive(opts)
+	}
+
+	return nil, ErrUnsupportedExtension
+}
+
+func (s *Store) addSubtitle(opts *AddOptions, ext string) (*Attachment, error) {
+	videoBase := strings.TrimSuffix(filepath.Base(opts.LocalFilePath), filepath.Ext(opts.LocalFilePath))
+
+	lang := sanitizeName(opts.Language)
+	if lang == "" {
+		lang = "und"
+	}
+
+	// e.g. "Episode 1.eng.srt", "Episode 1.eng.2.srt"
+	dir := filepath.Dir(opts.LocalFilePath)
+	path, err := createFile(dir, videoBase+"."+lang, ext, opts.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.insert(opts, KindSubtitle, path, int64(len(opts.Content)))
+}
+
+func (s *Store) addFont(opts *AddOptions, filename string, content []byte) (*Attachment, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	name := sanitizeName(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	if name == "" {
+		name = "font"
+	}
+
+	dir := filepath.Join(filepath.Dir(opts.LocalFilePath), FontsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// The same font is often shared by every episode, reuse it if it is identical
+	existingPath := filepath.Join(dir, name+ext)
+	if existing, err := os.ReadFile(existingPath); err == nil && bytes.Equal(existing, content) {
+		return s.insert(opts, KindFont, existingPath, int64(len(content)))
+	}
+
+	path, err := createFile(dir, name, ext, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.insert(opts, KindFont, path, int64(len(content)))
+}
+
+func (s *Store) addFontArchive(opts *AddOptions) ([]*Attachment, error) {
+	reader, err := zip.NewReader(bytes.NewReader(opts.Content), int64(len(opts.Content)))
+	if err != nil {
+		return nil, fmt.Errorf("sidecar: Invalid archive: %w", err)
+	}
+
+	ret := make([]*Attachment, 0)
+	var total int64
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() || !IsFontExtension(filepath.Ext(f.Name)) {
+			continue
+		}
+		if f.UncompressedSize64 > MaxFontSize {
+			return ret, ErrFileTooLarge
+		}
+
+		content, err := readZipFile(f, MaxFontSize)
+		if err != nil {
+			return ret, err
+		}
+		total += int64(len(content))
+		if total > MaxFontArchiveSize {
+			return ret, ErrFileTooLarge
+		}
+
+		a, err := s.addFont(opts, filepath.Base(filepath.ToSlash(f.Name)), content)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, a)
+	}
+
+	if len(ret) == 0 {
+		return nil, errors.New("sidecar: The archive does not contain any font")
+	}
+
+	return ret, nil
+}
+
+func (s *Store) insert(opts *AddOptions, kind string, path string, size int64) (*Attachment, error) {
+	item := &models.LocalFileAttachment{
+		LocalFilePath: opts.LocalFilePath,
+		Kind:          kind,
+		Path:          path,
+		Size:          size,
+		UploadedBy:    opts.UploadedBy,
+	}
+	if kind == KindSubtitle {
+		item.Language = strings.TrimSpace(opts.Language)
+		item.Label = strings.TrimSpace(opts.Label)
+	}
+
+	if err := s.db.InsertLocalFileAttachment(item); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug().Str("path", path).Str("localFile", opts.LocalFilePath).Msg("sidecar: Added attachment")
+
+	return toAttachment(item), nil
+}
+
+// Get returns the attachment with the given ID.
+func (s *Store) Get(id uint) (*Attachment, error) {
+	item, err := s.db.GetLocalFileAttachment(id)
+	if err != nil {
+		return nil, err
+	}
+	return toAttachment(item), nil
+}
+
+// GetByLocalFile returns the attachments of the local file.
+func (s *Store) GetByLocalFile(localFilePath string) []*Attachment {
+	items, err := s.db.GetLocalFileAttachments(localFilePath)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("sidecar: Failed to get attachments")
+		return []*Attachment{}
+	}
+
+	ret := make([]*Attachment, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, toAttachment(item))
+	}
+	return ret
+}
+
+// Delete removes the attachment from the disk and from the database.
+// A font file is only removed from the disk if no other attachment uses it.
+func (s *Store) Delete(id uint) (*Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.db.GetLocalFileAttachment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.DeleteLocalFileAttachment(id); err != nil {
+		return nil, err
+	}
+
+	// Identical fonts are shared by the attachments of the files in the same directory
+	if count, err := s.db.CountLocalFileAttachmentsByPath(item.Path); err == nil && count == 0 {
+		if err := os.Remove(item.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	s.logger.Debug().Str("path", item.Path).Msg("sidecar: Deleted attachment")
+
+	return toAttachment(item), nil
+}
+
+// ReadContent returns the content of the attachment.
+func (a *Attachment) ReadContent() ([]byte, error) {
+	return os.ReadFile(a.Path)
+}
+
+// DisplayName returns the label of the subtitle, or the language if there is no label.
+func (a *Attachment) DisplayName() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	if a.Language != "" {
+		return a.Language
+	}
+	return a.Filename
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+func toAttachment(item *models.LocalFileAttachment) *Attachment {
+	return &Attachment{
+		ID:            item.ID,
+		LocalFilePath: item.LocalFilePath,
+		Kind:          item.Kind,
+		Path:          item.Path,
+		Filename:      filepath.Base(item.Path),
+		Extension:     strings.ToLower(filepath.Ext(item.Path)),
+		Language:      item.Language,
+		Label:         item.Label,
+		Size:          item.Size,
+		UploadedBy:    item.UploadedBy,
+		CreatedAt:     item.CreatedAt,
+	}
+}
+
+// sanitizeName removes the characters that should not be used in a filename.
+func sanitizeName(name string) string {
+	name = unsafeNameChars.ReplaceAllString(strings.TrimSpace(name), "")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return strings.TrimSpace(name)
+}
+
+// createFile writes the content to "<dir>/<name><ext>" without overwriting an existing file.
+// If the file exists, a number is added to the name, e.g. "<name>.2<ext>".
+func createFile(dir string, name string, ext string, content []byte) (string, error) {
+	for i := 1; i <= 100; i++ {
+		path := filepath.Join(dir, name+ext)
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s.%d%s", name, i, ext))
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		_, err = f.Write(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	return "", errors.New("sidecar: Too many files with the same name")
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// The size in the header cannot be trusted
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, ErrFileTooLarge
+	}
+	return content, nil
+}
@@ -0,0 +1,139 @@
+package sidecar
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/db"
+	"seanime/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSrt = "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+
+func newTestStore(t *testing.T) (*Store, string) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "sidecar_test", logger)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	videoPath := filepath.Join(dir, "Anime - 01.mkv")
+	require.NoError(t, os.WriteFile(videoPath, []byte("video"), 0644))
+
+	return NewStore(&NewStoreOptions{DB: database, Logger: logger}), videoPath
+}
+
+func TestStore_AddSubtitle(t *testing.T) {
+	store, videoPath := newTestStore(t)
+	dir := filepath.Dir(videoPath)
+
+	// Existing sidecar file that should not be overwritten
+	existingPath := filepath.Join(dir, "Anime - 01.eng.srt")
+	require.NoError(t, os.WriteFile(existingPath, []byte("existing"), 0644))
+
+	ret, err := store.Add(&AddOptions{
+		LocalFilePath: videoPath,
+		Filename:      "better.SRT",
+		Content:       []byte(testSrt),
+		Language:      "eng",
+		Label:         "Fansub",
+		UploadedBy:    "user",
+	})
+	require.NoError(t, err)
+	require.Len(t, ret, 1)
+
+	a := ret[0]
+	assert.Equal(t, KindSubtitle, a.Kind)
+	assert.Equal(t, filepath.Join(dir, "Anime - 01.eng.2.srt"), a.Path)
+	assert.Equal(t, ".srt", a.Extension)
+	assert.Equal(t, "Fansub", a.DisplayName())
+	assert.Equal(t, "user", a.UploadedBy)
+
+	existing, err := os.ReadFile(existingPath)
+	require.NoError(t, err)
+	assert.Equal(t, "existing", string(existing))
+
+	attachments := store.GetByLocalFile(videoPath)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, a.ID, attachments[0].ID)
+
+	deleted, err := store.Delete(a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, videoPath, deleted.LocalFilePath)
+	assert.NoFileExists(t, a.Path)
+	assert.FileExists(t, existingPath)
+	assert.Empty(t, store.GetByLocalFile(videoPath))
+}
+
+func TestStore_AddValidation(t *testing.T) {
+	store, videoPath := newTestStore(t)
+
+	_, err := store.Add(&AddOptions{LocalFilePath: videoPath, Filename: "sub.exe", Content: []byte("x")})
+	assert.ErrorIs(t, err, ErrUnsupportedExtension)
+
+	_, err = store.Add(&AddOptions{LocalFilePath: videoPath, Filename: "sub.srt", Content: make([]byte, MaxSubtitleSize+1)})
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	_, err = store.Add(&AddOptions{LocalFilePath: videoPath, Filename: "sub.srt", Content: []byte("  \n")})
+	assert.Error(t, err)
+
+	// The language cannot be used to write outside the directory
+	ret, err := store.Add(&AddOptions{LocalFilePath: videoPath, Filename: "sub.ass", Content: []byte(testSrt), Language: "../../x"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Dir(videoPath), filepath.Dir(ret[0].Path))
+}
+
+func TestStore_AddFontArchive(t *testing.T) {
+	store, videoPath := newTestStore(t)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"fonts/Arial.ttf": "arial",
+		"Other.otf":       "other",
+		"readme.txt":      "ignored",
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	ret, err := store.Add(&AddOptions{LocalFilePath: videoPath, Filename: "fonts.zip", Content: buf.Bytes()})
+	require.NoError(t, err)
+	require.Len(t, ret, 2)
+
+	fontsDir := filepath.Join(filepath.Dir(videoPath), FontsDirName)
+	for _, a := range ret {
+		assert.Equal(t, KindFont, a.Kind)
+		assert.Equal(t, fontsDir, filepath.Dir(a.Path))
+		assert.FileExists(t, a.Path)
+	}
+
+	// The same font uploaded for another episode reuses the file
+	otherVideoPath := filepath.Join(filepath.Dir(videoPath), "Anime - 02.mkv")
+	other, err := store.Add(&AddOptions{LocalFilePath: otherVideoPath, Filename: "Arial.ttf", Content: []byte("arial")})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(fontsDir, "Arial.ttf"), other[0].Path)
+
+	// A different font with the same name does not overwrite it
+	different, err := store.Add(&AddOptions{LocalFilePath: otherVideoPath, Filename: "Arial.ttf", Content: []byte("different")})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(fontsDir, "Arial.2.ttf"), different[0].Path)
+
+	// The shared file is kept until the last attachment using it is deleted
+	_, err = store.Delete(other[0].ID)
+	require.NoError(t, err)
+	assert.FileExists(t, other[0].Path)
+
+	for _, a := range ret {
+		_, err = store.Delete(a.ID)
+		require.NoError(t, err)
+	}
+	assert.NoFileExists(t, other[0].Path)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"seanime/internal/api/anilist"
 	"seanime/internal/library/anime"
+	"seanime/internal/util"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,7 @@ const (
 	LogMetadataHydrated
 	LogPanic
 	LogDebug
+	LogMatchConflict
 )
 
 type (
@@ -37,6 +39,8 @@ type (
 		LocalFiles      []*anime.LocalFile
 		AllMedia        []*anime.NormalizedMedia
 		AnimeCollection *anilist.AnimeCollectionWithRelations
+		// Roots are the library paths that were scanned, the summary attributes the files to them
+		Roots []string
 	}
 
 	ScanSummaryLog struct { // Holds a log entry. The log entry will then be used to generate a ScanSummary.
@@ -50,6 +54,18 @@ type (
 		ID             string              `json:"id"`
 		Groups         []*ScanSummaryGroup `json:"groups"`
 		UnmatchedFiles []*ScanSummaryFile  `json:"unmatchedFiles"`
+		// Roots are the results of the scan for each scanned library path
+		Roots []*ScanSummaryRoot `json:"roots,omitempty"`
+	}
+
+	// ScanSummaryRoot is the result of a scan for a library path.
+	ScanSummaryRoot struct {
+		Path           string `json:"path"`
+		FileCount      int    `json:"fileCount"`
+		MatchedCount   int    `json:"matchedCount"`
+		UnmatchedCount int    `json:"unmatchedCount"`
+		// UnverifiedCount is the number of files kept while the library path was unavailable
+		UnverifiedCount int `json:"unverifiedCount"`
 	}
 
 	ScanSummaryFile struct {
@@ -156,9 +172,47 @@ func (l *ScanSummaryLogger) GenerateSummary() *ScanSummary {
 		})
 	}
 
+	summary.Roots = NewRootSummaries(l.Roots, l.LocalFiles)
+
 	return summary
 }
 
+// NewRootSummaries attributes the local files to the library paths they are in.
+// A file in nested library paths is attributed to the most specific one.
+func NewRootSummaries(roots []string, lfs []*anime.LocalFile) []*ScanSummaryRoot {
+	if len(roots) == 0 {
+		return nil
+	}
+
+	ret := make([]*ScanSummaryRoot, 0, len(roots))
+	for _, root := range roots {
+		ret = append(ret, &ScanSummaryRoot{Path: root})
+	}
+
+	for _, lf := range lfs {
+		var best *ScanSummaryRoot
+		for _, r := range ret {
+			if util.IsSubpath(r.Path, lf.GetPath()) && (best == nil || len(r.Path) > len(best.Path)) {
+				best = r
+			}
+		}
+		if best == nil {
+			continue
+		}
+		best.FileCount++
+		if lf.MediaId == 0 {
+			best.UnmatchedCount++
+		} else {
+			best.MatchedCount++
+		}
+		if lf.IsUnverified() {
+			best.UnverifiedCount++
+		}
+	}
+
+	return ret
+}
+
 func (l *ScanSummaryLogger) LogComparison(lf *anime.LocalFile, algo string, bestTitle string, ratingType string, rating string) {
 	if l == nil {
 		return
@@ -200,6 +254,14 @@ func (l *ScanSummaryLogger) LogMatchValidated(lf *anime.LocalFile, mediaId int)
 	l.logType(LogMatchValidated, lf, msg)
 }
 
+func (l *ScanSummaryLogger) LogMatchConflict(lf *anime.LocalFile, warning string) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Matching conflict: %s", warning)
+	l.logType(LogMatchConflict, lf, msg)
+}
+
 func (l *ScanSummaryLogger) LogUnmatched(lf *anime.LocalFile, reason string) {
 	if l == nil {
 		return
@@ -334,6 +396,8 @@ func (l *ScanSummaryLogger) logType(logType LogType, lf *anime.LocalFile, messag
 		l.log(lf, "error", message)
 	case LogDebug:
 		l.log(lf, "info", message)
+	case LogMatchConflict:
+		l.log(lf, "warning", message)
 	}
 }
 
@@ -0,0 +1,198 @@
+package themesongs
+
+import (
+	"errors"
+	"net/url"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/animethemes"
+	"seanime/internal/database/db"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"seanime/internal/util/limiter"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// ProxyEndpoint is the endpoint used to play the video and audio previews
+	ProxyEndpoint = "/api/v1/theme-songs/proxy"
+	// staleAfter is the duration after which the stored themes are re-fetched
+	staleAfter = 30 * 24 * time.Hour
+)
+
+type (
+	// Manager fetches the theme songs (OP/ED) of anime from AnimeThemes and stores them in the database.
+	Manager struct {
+		db      *db.Database
+		logger  *zerolog.Logger
+		client  *animethemes.Client
+		limiter *limiter.Limiter
+		// fetching holds the media IDs that are being fetched
+		fetching sync.Map
+		// isPopulating is true while the collection is being populated in the background
+		isPopulating atomic.Bool
+	}
+
+	NewManagerOptions struct {
+		DB     *db.Database
+		Logger *zerolog.Logger
+	}
+)
+
+func New(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		db:     opts.DB,
+		logger: opts.Logger,
+		client: animethemes.NewClient(opts.Logger),
+		// AnimeThemes allows 90 requests per minute
+		limiter: limiter.NewLimiter(time.Minute, 60),
+	}
+}
+
+// HasThemesFetched returns true if the themes of the media have been fetched and are not stale.
+func (m *Manager) HasThemesFetched(mediaId int) bool {
+	item, found, err := m.db.GetMediaThemes(mediaId)
+	if err != nil || !found {
+		return false
+	}
+	return time.Since(item.LastFetchedAt) < staleAfter
+}
+
+// FetchAndStoreThemes fetches the themes of the media and stores them.
+// Media that do not exist on AnimeThemes are stored with no themes so that they are not fetched again.
+func (m *Manager) FetchAndStoreThemes(mediaId int, malId int) error {
+
+	defer util.HandlePanicInModuleThen("library/themesongs/FetchAndStoreThemes", func() {
+	})
+
+	if _, loaded := m.fetching.LoadOrStore(mediaId, struct{}{}); loaded {
+		return nil
+	}
+	defer m.fetching.Delete(mediaId)
+
+	m.limiter.Wait()
+
+	m.logger.Debug().Int("mediaId", mediaId).Msg("themesongs: Fetching themes")
+
+	themes, err := m.client.GetThemes(mediaId, malId)
+	if err != nil && !errors.Is(err, animethemes.ErrNotFound) {
+		return err
+	}
+
+	return m.db.UpsertMediaThemes(mediaId, time.Now(), themes)
+}
+
+// FetchAndStoreThemesForMedia is the same as FetchAndStoreThemes but takes the media.
+func (m *Manager) FetchAndStoreThemesForMedia(media *anilist.BaseAnime) error {
+	if media == nil {
+		return errors.New("themesongs: Media is nil")
+	}
+	malId := 0
+	if media.GetIDMal() != nil {
+		malId = *media.GetIDMal()
+	}
+	return m.FetchAndStoreThemes(media.GetID(), malId)
+}
+
+// RemoveThemes removes the stored themes of the media.
+func (m *Manager) RemoveThemes(mediaId int) error {
+	return m.db.DeleteMediaThemes(mediaId)
+}
+
+// GetThemes returns the stored themes of the media with the preview URLs pointing to the proxy.
+// It returns nil if the themes have not been fetched or if the media has no themes.
+func (m *Manager) GetThemes(mediaId int) []*animethemes.Theme {
+	if m == nil {
+		return nil
+	}
+
+	item, found, err := m.db.GetMediaThemes(mediaId)
+	if err != nil {
+		m.logger.Error().Err(err).Int("mediaId", mediaId).Msg("themesongs: Failed to get themes")
+		return nil
+	}
+	if !found || len(item.Themes) == 0 {
+		return nil
+	}
+
+	for _, theme := range item.Themes {
+		for _, entry := range theme.Entries {
+			for _, video := range entry.Videos {
+				video.ProxyVideoUrl = GetProxyUrl(video.VideoUrl)
+				video.ProxyAudioUrl = GetProxyUrl(video.AudioUrl)
+			}
+		}
+	}
+
+	return item.Themes
+}
+
+// HydrateThemes sets the themes of the entry.
+func (m *Manager) HydrateThemes(e *anime.Entry) {
+	if m == nil || e == nil || e.Media == nil {
+		return
+	}
+	e.Themes = m.GetThemes(e.MediaId)
+}
+
+// PopulateCollection fetches the themes of the anime in the collection that have not been fetched yet.
+// It runs in the background and is a no-op if it is already running.
+func (m *Manager) PopulateCollection(collection *anilist.AnimeCollection) {
+	if m == nil || collection == nil {
+		return
+	}
+
+	if !m.isPopulating.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer m.isPopulating.Store(false)
+		defer util.HandlePanicInModuleThen("library/themesongs/PopulateCollection", func() {
+			m.logger.Error().Msg("themesongs: Failed to populate collection")
+		})
+
+		fetched, err := m.db.GetFetchedMediaThemesIds()
+		if err != nil {
+			m.logger.Error().Err(err).Msg("themesongs: Failed to get fetched media")
+			return
+		}
+
+		toFetch := make([]*anilist.BaseAnime, 0)
+		for _, media := range collection.GetAllAnime() {
+			if media == nil {
+				continue
+			}
+			if lastFetchedAt, ok := fetched[media.GetID()]; ok && time.Since(lastFetchedAt) < staleAfter {
+				continue
+			}
+			toFetch = append(toFetch, media)
+		}
+
+		if len(toFetch) == 0 {
+			return
+		}
+
+		m.logger.Debug().Int("count", len(toFetch)).Msg("themesongs: Populating collection")
+
+		for _, media := range toFetch {
+			if err := m.FetchAndStoreThemesForMedia(media); err != nil {
+				m.logger.Warn().Err(err).Int("mediaId", media.GetID()).Msg("themesongs: Failed to fetch themes")
+			}
+		}
+
+		m.logger.Debug().Msg("themesongs: Populated collection")
+	}()
+}
+
+// GetProxyUrl returns the URL used to play the AnimeThemes file through the proxy.
+// It returns an empty string if the URL is empty.
+func GetProxyUrl(u string) string {
+	if u == "" {
+		return ""
+	}
+	return ProxyEndpoint + "?url=" + url.QueryEscape(u)
+}
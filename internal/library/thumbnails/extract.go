@@ -0,0 +1,210 @@
+package thumbnails
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"math"
+	"seanime/internal/api/aniskip"
+	"seanime/internal/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPosition is where the frame is taken when the opening is unknown, as a ratio of the duration
+	defaultPosition = 0.25
+	// afterOpeningDelay is how far past the end of the opening the frame is taken, in seconds
+	afterOpeningDelay = 30
+	// uniformThreshold is the standard deviation of the luminance under which a frame is considered blank
+	uniformThreshold = 12.0
+	// thumbnailWidth is the width of the extracted frames
+	thumbnailWidth = 480
+	commandTimeout = 30 * time.Second
+)
+
+// extract grabs a representative frame of the file.
+// Frames that are nearly uniform (e.g. black transitions) are retried at other positions, the most detailed frame is kept.
+func (m *Manager) extract(job *Job) ([]byte, error) {
+	duration, err := m.probeDuration(job.Path)
+	if err != nil {
+		m.logger.Trace().Err(err).Str("path", job.Path).Msg("thumbnails: Could not get the duration")
+	}
+
+	var best []byte
+	bestDetail := -1.0
+	var lastErr error
+	for _, position := range getPositions(duration, m.getOpeningEnd(job, duration)) {
+		data, err := m.extractFrame(job.Path, position)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		detail, err := getFrameDetail(data)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if detail > bestDetail {
+			best = data
+			bestDetail = detail
+		}
+		if detail >= uniformThreshold {
+			break
+		}
+	}
+
+	if best == nil {
+		if lastErr == nil {
+			lastErr = errors.New("thumbnails: No frame could be extracted")
+		}
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// getOpeningEnd returns the end of the opening from AniSkip, 0 if it is unknown.
+func (m *Manager) getOpeningEnd(job *Job, duration float64) float64 {
+	if job.MalId == 0 || job.EpisodeNumber <= 0 {
+		return 0
+	}
+	skipTimes, err := m.aniskipClient.GetSkipTimes(job.MalId, job.EpisodeNumber, duration)
+	if err != nil {
+		return 0
+	}
+	if op, found := aniskip.GetOpening(skipTimes); found {
+		return op.EndTime
+	}
+	return 0
+}
+
+// getPositions returns the positions, in seconds, at which a frame should be taken, in order of preference.
+// The first one is past the opening if its end is known, or at 25% of the file.
+func getPositions(duration float64, openingEnd float64) []float64 {
+	if duration <= 0 {
+		if openingEnd > 0 {
+			return []float64{openingEnd + afterOpeningDelay, openingEnd + 3*afterOpeningDelay}
+		}
+		return []float64{120, 300, 30}
+	}
+
+	first := duration * defaultPosition
+	if openingEnd > 0 && openingEnd+afterOpeningDelay < duration*0.9 {
+		first = openingEnd + afterOpeningDelay
+	}
+
+	ret := make([]float64, 0, 4)
+	for _, position := range []float64{first, duration * 0.4, duration * 0.6, duration * 0.15} {
+		duplicate := false
+		for _, p := range ret {
+			if math.Abs(p-position) < 5 {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			ret = append(ret, position)
+		}
+	}
+	return ret
+}
+
+// getFrameDetail returns the standard deviation of the luminance of the frame.
+// Nearly uniform frames have a low value.
+func getFrameDetail(data []byte) (float64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	bounds := img.Bounds()
+	// Sample around 10,000 pixels
+	step := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/10000)))
+
+	var sum, sumSq float64
+	count := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			luma := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			sum += luma
+			sumSq += luma * luma
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, errors.New("thumbnails: Empty frame")
+	}
+
+	mean := sum / float64(count)
+	return math.Sqrt(max(0, sumSq/float64(count)-mean*mean)), nil
+}
+
+func (m *Manager) probeDuration(path string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := util.NewCmdCtx(ctx, m.getFfprobePath(),
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+// extractFrame returns the frame at the position as a JPEG image.
+func (m *Manager) extractFrame(path string, position float64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	// DEVNOTE: Seeking before the input is fast, a single thread keeps the extraction in the background
+	cmd := util.NewCmdCtx(ctx, m.getFfmpegPath(),
+		"-v", "error",
+		"-threads", "1",
+		"-ss", strconv.FormatFloat(position, 'f', 2, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", thumbnailWidth),
+		"-q:v", "4",
+		"-f", "image2",
+		"-c:v", "mjpeg",
+		"pipe:1",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("thumbnails: ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("thumbnails: No frame at %.0fs", position)
+	}
+	return out, nil
+}
+
+func (m *Manager) getFfmpegPath() string {
+	if m.ffmpegPathFunc != nil {
+		if p := m.ffmpegPathFunc(); p != "" {
+			return p
+		}
+	}
+	return "ffmpeg"
+}
+
+func (m *Manager) getFfprobePath() string {
+	if m.ffprobePathFunc != nil {
+		if p := m.ffprobePathFunc(); p != "" {
+			return p
+		}
+	}
+	return "ffprobe"
+}
@@ -0,0 +1,356 @@
+package thumbnails
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"seanime/internal/api/aniskip"
+	"seanime/internal/api/metadata"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Endpoint serves the extracted thumbnails, the key of the file is appended to it
+	Endpoint = "/api/v1/library/episode-thumbnail/"
+
+	// defaultJobInterval is the pause between two extractions, so that the generation stays in the background
+	defaultJobInterval = 2 * time.Second
+	// pausedPollInterval is how often a paused queue checks whether it can resume
+	pausedPollInterval = 30 * time.Second
+)
+
+var keyRegex = regexp.MustCompile(`^[0-9a-f]{40}_[0-9a-f]{16}$`)
+
+type (
+	// Manager extracts a representative frame of the local files whose episode has no image in the metadata.
+	// The frames are stored in the cache directory, keyed by the identity of the file (path, size and modification time),
+	// so that they are regenerated when the file changes.
+	//
+	// Extractions are queued and run one at a time.
+	Manager struct {
+		logger          *zerolog.Logger
+		dir             string
+		aniskipClient   *aniskip.Client
+		ffmpegPathFunc  func() string
+		ffprobePathFunc func() string
+		isPausedFunc    func() bool
+
+		mu       sync.Mutex
+		settings *Settings
+		queue    []*Job
+		queued   map[string]struct{} // Normalized paths of the queued files
+		failed   map[string]struct{} // Keys of the files that could not be extracted
+		running  bool
+		// extractFunc and jobInterval are replaced in tests
+		extractFunc func(job *Job) ([]byte, error)
+		jobInterval time.Duration
+	}
+
+	NewManagerOptions struct {
+		Logger *zerolog.Logger
+		// Dir is the directory where the thumbnails are stored
+		Dir string
+		// FfmpegPathFunc and FfprobePathFunc return the paths of the binaries set in the media streaming settings
+		FfmpegPathFunc  func() string
+		FfprobePathFunc func() string
+		// IsPausedFunc returns true if the extractions should wait (e.g. during playback)
+		IsPausedFunc func() bool
+	}
+
+	Settings struct {
+		Enabled bool
+		// SkippedPaths are the library directories whose files should not get thumbnails
+		SkippedPaths []string
+	}
+
+	// Job is a local file whose thumbnail should be extracted.
+	Job struct {
+		Path    string
+		MediaId int
+		// MalId and EpisodeNumber are used to skip the opening, they are 0 if unknown
+		MalId         int
+		EpisodeNumber int
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	_ = os.MkdirAll(opts.Dir, 0755)
+	m := &Manager{
+		logger:          opts.Logger,
+		dir:             opts.Dir,
+		aniskipClient:   aniskip.NewClient(opts.Logger),
+		ffmpegPathFunc:  opts.FfmpegPathFunc,
+		ffprobePathFunc: opts.FfprobePathFunc,
+		isPausedFunc:    opts.IsPausedFunc,
+		settings:        &Settings{},
+		queued:          make(map[string]struct{}),
+		failed:          make(map[string]struct{}),
+		jobInterval:     defaultJobInterval,
+	}
+	m.extractFunc = m.extract
+	return m
+}
+
+func (m *Manager) SetSettings(settings *Settings) {
+	if settings == nil {
+		settings = &Settings{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings = settings
+	if !settings.Enabled {
+		m.queue = nil
+		clear(m.queued)
+	}
+}
+
+// GetThumbnailPath returns the path of a thumbnail from its key.
+func (m *Manager) GetThumbnailPath(key string) (string, bool) {
+	if !keyRegex.MatchString(key) {
+		return "", false
+	}
+	ret := filepath.Join(m.dir, key+".jpg")
+	if _, err := os.Stat(ret); err != nil {
+		return "", false
+	}
+	return ret, true
+}
+
+// HydrateEntry sets the fallback image of the downloaded episodes that have no real image.
+// The thumbnails that do not exist yet, or whose file has changed, are queued.
+func (m *Manager) HydrateEntry(entry *anime.Entry) {
+	if entry == nil {
+		return
+	}
+
+	malId := 0
+	if entry.Media != nil && entry.Media.GetIDMal() != nil {
+		malId = *entry.Media.GetIDMal()
+	}
+
+	jobs := make([]*Job, 0)
+	for _, ep := range entry.Episodes {
+		if ep == nil || ep.LocalFile == nil || ep.EpisodeMetadata == nil || ep.EpisodeMetadata.HasImage {
+			continue
+		}
+		if !m.shouldProcess(ep.LocalFile.GetPath()) {
+			continue
+		}
+		key, err := getFileKey(ep.LocalFile.GetPath())
+		if err != nil {
+			continue
+		}
+		if _, found := m.GetThumbnailPath(key); found {
+			ep.EpisodeMetadata.FallbackImage = Endpoint + key
+			continue
+		}
+		if m.hasFailed(key) {
+			continue
+		}
+		jobs = append(jobs, &Job{
+			Path:          ep.LocalFile.GetPath(),
+			MediaId:       entry.MediaId,
+			MalId:         malId,
+			EpisodeNumber: ep.EpisodeNumber,
+		})
+	}
+
+	m.Enqueue(jobs...)
+}
+
+// QueueLocalFiles queues the matched local files whose episode has no image in the metadata.
+// getMetadata returns the metadata of a media, the files of media whose metadata cannot be fetched are not queued.
+func (m *Manager) QueueLocalFiles(lfs []*anime.LocalFile, getMetadata func(mediaId int) (*metadata.AnimeMetadata, error)) {
+	if !m.isEnabled() {
+		return
+	}
+
+	jobs := make([]*Job, 0)
+	for mediaId, group := range anime.GroupLocalFilesByMediaID(lfs) {
+		if mediaId == 0 {
+			continue
+		}
+		animeMetadata, err := getMetadata(mediaId)
+		if err != nil || animeMetadata == nil {
+			continue
+		}
+		for _, lf := range group {
+			if lf.Metadata == nil || lf.GetType() == anime.LocalFileTypeNC {
+				continue
+			}
+			if ep, found := animeMetadata.FindEpisode(lf.GetAniDBEpisode()); found && ep.HasImage {
+				continue
+			}
+			jobs = append(jobs, &Job{
+				Path:          lf.GetPath(),
+				MediaId:       mediaId,
+				MalId:         animeMetadata.GetMappings().MalId,
+				EpisodeNumber: lf.GetEpisodeNumber(),
+			})
+		}
+	}
+
+	m.Enqueue(jobs...)
+}
+
+// Enqueue adds the files to the queue. Files that are already queued, or that are in a skipped library directory, are ignored.
+func (m *Manager) Enqueue(jobs ...*Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range jobs {
+		if !m.shouldProcessLocked(job.Path) {
+			continue
+		}
+		normalizedPath := util.NormalizePath(job.Path)
+		if _, found := m.queued[normalizedPath]; found {
+			continue
+		}
+		m.queued[normalizedPath] = struct{}{}
+		m.queue = append(m.queue, job)
+	}
+
+	if len(m.queue) > 0 && !m.running {
+		m.running = true
+		go m.run()
+	}
+}
+
+// QueueLength returns the number of files waiting for their thumbnail.
+func (m *Manager) QueueLength() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+func (m *Manager) run() {
+	defer util.HandlePanicInModuleThen("library/thumbnails/run", func() {
+		m.mu.Lock()
+		m.running = false
+		m.mu.Unlock()
+	})
+
+	for {
+		for m.isPausedFunc != nil && m.isPausedFunc() {
+			time.Sleep(pausedPollInterval)
+		}
+
+		m.mu.Lock()
+		if len(m.queue) == 0 {
+			m.running = false
+			m.mu.Unlock()
+			return
+		}
+		job := m.queue[0]
+		m.queue = m.queue[1:]
+		m.mu.Unlock()
+
+		m.process(job)
+
+		m.mu.Lock()
+		delete(m.queued, util.NormalizePath(job.Path))
+		m.mu.Unlock()
+
+		time.Sleep(m.jobInterval)
+	}
+}
+
+func (m *Manager) process(job *Job) {
+	if !m.shouldProcess(job.Path) {
+		return
+	}
+
+	key, err := getFileKey(job.Path)
+	if err != nil {
+		return
+	}
+	if _, found := m.GetThumbnailPath(key); found {
+		return
+	}
+
+	if m.hasFailed(key) {
+		return
+	}
+
+	data, err := m.extractFunc(job)
+	if err != nil {
+		m.logger.Debug().Err(err).Str("path", job.Path).Msg("thumbnails: Could not extract thumbnail")
+		m.mu.Lock()
+		m.failed[key] = struct{}{}
+		m.mu.Unlock()
+		return
+	}
+
+	if err := m.save(key, data); err != nil {
+		m.logger.Error().Err(err).Str("path", job.Path).Msg("thumbnails: Could not save thumbnail")
+		return
+	}
+
+	m.logger.Trace().Str("path", job.Path).Msg("thumbnails: Extracted thumbnail")
+}
+
+// save writes the thumbnail and removes the thumbnails of previous versions of the file.
+func (m *Manager) save(key string, data []byte) error {
+	pathHash, _, _ := strings.Cut(key, "_")
+	if previous, err := filepath.Glob(filepath.Join(m.dir, pathHash+"_*.jpg")); err == nil {
+		for _, p := range previous {
+			_ = os.Remove(p)
+		}
+	}
+
+	dest := filepath.Join(m.dir, key+".jpg")
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+func (m *Manager) hasFailed(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, found := m.failed[key]
+	return found
+}
+
+func (m *Manager) isEnabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settings.Enabled
+}
+
+func (m *Manager) shouldProcess(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shouldProcessLocked(path)
+}
+
+func (m *Manager) shouldProcessLocked(path string) bool {
+	if !m.settings.Enabled || path == "" {
+		return false
+	}
+	return !util.IsSubdirectoryOfAny(m.settings.SkippedPaths, path)
+}
+
+// getFileKey returns the identity of a file, it changes when the file is replaced or modified.
+func getFileKey(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("thumbnails: %s is a directory", path)
+	}
+	pathHash := sha1.Sum([]byte(util.NormalizePath(path)))
+	versionHash := sha1.Sum([]byte(fmt.Sprintf("%d|%d", info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(pathHash[:]) + "_" + hex.EncodeToString(versionHash[:])[:16], nil
+}
@@ -0,0 +1,170 @@
+package thumbnails
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeFrame(t *testing.T, fill func(x, y int) color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 36))
+	for y := 0; y < 36; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func TestGetFrameDetail(t *testing.T) {
+	black := encodeFrame(t, func(x, y int) color.Color { return color.Black })
+	detail, err := getFrameDetail(black)
+	require.NoError(t, err)
+	assert.Less(t, detail, uniformThreshold)
+
+	stripes := encodeFrame(t, func(x, y int) color.Color {
+		if (x/8)%2 == 0 {
+			return color.White
+		}
+		return color.Black
+	})
+	detail, err = getFrameDetail(stripes)
+	require.NoError(t, err)
+	assert.Greater(t, detail, uniformThreshold)
+
+	_, err = getFrameDetail([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestGetPositions(t *testing.T) {
+	// 25% into the file when the opening is unknown
+	assert.Equal(t, []float64{360, 576, 864, 216}, getPositions(1440, 0))
+	// Past the opening
+	assert.Equal(t, 120.0, getPositions(1440, 90)[0])
+	// An opening that ends near the end of the file is ignored
+	assert.Equal(t, 360.0, getPositions(1440, 1400)[0])
+	// Unknown duration
+	assert.Equal(t, []float64{120, 300, 30}, getPositions(0, 0))
+}
+
+func newTestManager(t *testing.T) *Manager {
+	m := NewManager(&NewManagerOptions{
+		Logger: util.NewLogger(),
+		Dir:    t.TempDir(),
+	})
+	m.jobInterval = 0
+	return m
+}
+
+func waitForQueue(t *testing.T, m *Manager) {
+	require.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return !m.running
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestManager_HydrateEntry(t *testing.T) {
+	libraryDir := t.TempDir()
+	skippedDir := t.TempDir()
+	path := filepath.Join(libraryDir, "Show - 01.mkv")
+	skippedPath := filepath.Join(skippedDir, "Show - 02.mkv")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(skippedPath, []byte("v1"), 0644))
+
+	m := newTestManager(t)
+	m.SetSettings(&Settings{Enabled: true, SkippedPaths: []string{skippedDir}})
+
+	frame := encodeFrame(t, func(x, y int) color.Color { return color.White })
+	extracted := make(chan string, 10)
+	m.extractFunc = func(job *Job) ([]byte, error) {
+		extracted <- job.Path
+		return frame, nil
+	}
+
+	newEntry := func() *anime.Entry {
+		return &anime.Entry{
+			MediaId: 1,
+			Episodes: []*anime.Episode{
+				{EpisodeNumber: 1, LocalFile: &anime.LocalFile{Path: path}, EpisodeMetadata: &anime.EpisodeMetadata{}},
+				{EpisodeNumber: 2, LocalFile: &anime.LocalFile{Path: skippedPath}, EpisodeMetadata: &anime.EpisodeMetadata{}},
+				{EpisodeNumber: 3, LocalFile: &anime.LocalFile{Path: path}, EpisodeMetadata: &anime.EpisodeMetadata{HasImage: true}},
+			},
+		}
+	}
+
+	// The missing thumbnail is queued
+	entry := newEntry()
+	m.HydrateEntry(entry)
+	assert.Empty(t, entry.Episodes[0].EpisodeMetadata.FallbackImage)
+	waitForQueue(t, m)
+	assert.Equal(t, path, <-extracted)
+	assert.Len(t, extracted, 0, "files in skipped directories should not be extracted")
+
+	entry = newEntry()
+	m.HydrateEntry(entry)
+	key, err := getFileKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, Endpoint+key, entry.Episodes[0].EpisodeMetadata.FallbackImage)
+	assert.Empty(t, entry.Episodes[1].EpisodeMetadata.FallbackImage)
+	assert.Empty(t, entry.Episodes[2].EpisodeMetadata.FallbackImage)
+	_, found := m.GetThumbnailPath(key)
+	assert.True(t, found)
+
+	// The thumbnail is regenerated when the file changes
+	require.NoError(t, os.WriteFile(path, []byte("version 2"), 0644))
+	entry = newEntry()
+	m.HydrateEntry(entry)
+	assert.Empty(t, entry.Episodes[0].EpisodeMetadata.FallbackImage)
+	waitForQueue(t, m)
+	assert.Equal(t, path, <-extracted)
+
+	newKey, err := getFileKey(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, newKey)
+	_, found = m.GetThumbnailPath(key)
+	assert.False(t, found, "the thumbnail of the previous version should be removed")
+	_, found = m.GetThumbnailPath(newKey)
+	assert.True(t, found)
+
+	_, found = m.GetThumbnailPath("../" + newKey)
+	assert.False(t, found)
+}
+
+func TestManager_FailedExtractionIsNotRetried(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Show - 01.mkv")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0644))
+
+	m := newTestManager(t)
+	m.SetSettings(&Settings{Enabled: true})
+
+	calls := 0
+	m.extractFunc = func(job *Job) ([]byte, error) {
+		calls++
+		return nil, errors.New("no video stream")
+	}
+
+	m.Enqueue(&Job{Path: path})
+	waitForQueue(t, m)
+	m.Enqueue(&Job{Path: path})
+	waitForQueue(t, m)
+	assert.Equal(t, 1, calls)
+
+	// Nothing is queued when disabled
+	m.SetSettings(&Settings{})
+	m.Enqueue(&Job{Path: filepath.Join(t.TempDir(), "other.mkv")})
+	assert.Equal(t, 0, m.QueueLength())
+}
@@ -69,6 +69,7 @@ func migrateTables(db *gorm.DB) error {
 		&AnimeSnapshot{},
 		&MangaSnapshot{},
 		&TrackedMedia{},
+		&AnimeCollectionEntry{},
 	)
 	if err != nil {
 		return err
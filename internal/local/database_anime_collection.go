@@ -0,0 +1,99 @@
+package local
+
+import (
+	"seanime/internal/api/anilist"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewAnimeCollectionEntry creates a mirror entry from an anime collection entry.
+func NewAnimeCollectionEntry(entry *anilist.AnimeListEntry) *AnimeCollectionEntry {
+	ret := &AnimeCollectionEntry{
+		MediaId:     entry.GetMedia().GetID(),
+		TitleRomaji: entry.GetMedia().GetRomajiTitleSafe(),
+		CoverImage:  entry.GetMedia().GetCoverImageSafe(),
+		UpdatedAt:   time.Now(),
+	}
+	if entry.GetStatus() != nil {
+		ret.Status = string(*entry.GetStatus())
+	}
+	if entry.GetProgress() != nil {
+		ret.Progress = *entry.GetProgress()
+	}
+	if entry.GetScore() != nil {
+		ret.Score = *entry.GetScore()
+	}
+	return ret
+}
+
+// SaveAnimeCollectionEntries replaces the mirrored entries with the entries of the collection.
+func (ldb *Database) SaveAnimeCollectionEntries(ac *anilist.AnimeCollection) error {
+	entries := make([]*AnimeCollectionEntry, 0)
+	seen := make(map[int]struct{})
+	if ac != nil && ac.MediaListCollection != nil {
+		for _, list := range ac.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if entry.GetMedia() == nil {
+					continue
+				}
+				if _, ok := seen[entry.GetMedia().GetID()]; ok {
+					continue
+				}
+				seen[entry.GetMedia().GetID()] = struct{}{}
+				entries = append(entries, NewAnimeCollectionEntry(entry))
+			}
+		}
+	}
+
+	return ldb.gormdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&AnimeCollectionEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(entries, 100).Error
+	})
+}
+
+// SaveAnimeCollectionEntry inserts or updates a mirrored entry.
+func (ldb *Database) SaveAnimeCollectionEntry(entry *AnimeCollectionEntry) error {
+	entry.UpdatedAt = time.Now()
+	return ldb.gormdb.Save(entry).Error
+}
+
+// GetAnimeCollectionEntryFromAnimeId returns the mirrored entry for the media.
+func (ldb *Database) GetAnimeCollectionEntryFromAnimeId(mediaId int) (*AnimeCollectionEntry, bool) {
+	var entry AnimeCollectionEntry
+	err := ldb.gormdb.Where("media_id = ?", mediaId).First(&entry).Error
+	return &entry, err == nil
+}
+
+// GetAllAnimeCollectionEntries returns all mirrored entries.
+func (ldb *Database) GetAllAnimeCollectionEntries() ([]*AnimeCollectionEntry, bool) {
+	var entries []*AnimeCollectionEntry
+	err := ldb.gormdb.Order("title_romaji").Find(&entries).Error
+	return entries, err == nil
+}
+
+// SearchAnimeCollectionEntries returns the mirrored entries whose title contains the query.
+// If status is not empty, only entries with that status are returned.
+func (ldb *Database) SearchAnimeCollectionEntries(query string, status anilist.MediaListStatus) ([]*AnimeCollectionEntry, bool) {
+	var entries []*AnimeCollectionEntry
+	tx := ldb.gormdb.Model(&AnimeCollectionEntry{})
+	if query = strings.TrimSpace(query); query != "" {
+		tx = tx.Where("LOWER(title_romaji) LIKE ?", "%"+strings.ToLower(query)+"%")
+	}
+	if status != "" {
+		tx = tx.Where("status = ?", string(status))
+	}
+	err := tx.Order("title_romaji").Find(&entries).Error
+	return entries, err == nil
+}
+
+// DeleteAnimeCollectionEntry removes the mirrored entry for the media.
+func (ldb *Database) DeleteAnimeCollectionEntry(mediaId int) error {
+	return ldb.gormdb.Where("media_id = ?", mediaId).Delete(&AnimeCollectionEntry{}).Error
+}
@@ -66,6 +66,21 @@ type MangaSnapshot struct {
 	ReferenceKey string `gorm:"column:reference_key" json:"referenceKey"`
 }
 
+// AnimeCollectionEntry mirrors an entry of the anime collection so that it can be queried offline.
+type AnimeCollectionEntry struct {
+	MediaId     int       `gorm:"column:media_id;primaryKey;autoIncrement:false" json:"mediaId"`
+	Status      string    `gorm:"column:status;index" json:"status"`
+	Progress    int       `gorm:"column:progress" json:"progress"`
+	Score       float64   `gorm:"column:score" json:"score"`
+	TitleRomaji string    `gorm:"column:title_romaji" json:"titleRomaji"`
+	CoverImage  string    `gorm:"column:cover_image" json:"coverImage"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (AnimeCollectionEntry) TableName() string {
+	return "anime_collection_entries"
+}
+
 // +---------------------+
 // |      Simulated      |
 // +---------------------+
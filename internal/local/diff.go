@@ -312,9 +312,10 @@ func GetAnimeListDataKey(entry *anilist.AnimeListEntry) string {
 }
 
 func GetMangaListDataKey(entry *anilist.MangaListEntry) string {
-	return fmt.Sprintf("%s-%d-%f-%d-%v-%v-%v-%v-%v-%v",
+	return fmt.Sprintf("%s-%d-%d-%f-%d-%v-%v-%v-%v-%v-%v",
 		MediaListStatusPointerValue(entry.GetStatus()),
 		IntPointerValue(entry.GetProgress()),
+		IntPointerValue(entry.GetProgressVolumes()),
 		Float64PointerValue(entry.GetScore()),
 		IntPointerValue(entry.GetRepeat()),
 		IntPointerValue(entry.GetStartedAt().GetYear()),
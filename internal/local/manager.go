@@ -34,6 +34,9 @@ type Manager interface {
 	SetAnimeCollection(ac *anilist.AnimeCollection)
 	// SetMangaCollection updates the online manga collection in the manager.
 	SetMangaCollection(mc *anilist.MangaCollection)
+	// ClearCollectionCache removes the anime and manga collections set in the manager.
+	// It should be called when the platform changes so that the collections of the previous platform are not used.
+	ClearCollectionCache()
 	// GetLocalAnimeCollection returns the local anime collection stored in the local database.
 	GetLocalAnimeCollection() mo.Option[*anilist.AnimeCollection]
 	// GetLocalMangaCollection returns the local manga collection stored in the local database.
@@ -249,6 +252,11 @@ func (m *ManagerImpl) SetMangaCollection(mc *anilist.MangaCollection) {
 	}
 }
 
+func (m *ManagerImpl) ClearCollectionCache() {
+	m.SetAnimeCollection(nil)
+	m.SetMangaCollection(nil)
+}
+
 func (m *ManagerImpl) GetLocalAnimeCollection() mo.Option[*anilist.AnimeCollection] {
 	return m.localAnimeCollection
 }
@@ -895,6 +903,10 @@ func (m *ManagerImpl) SynchronizeAnilistToSimulatedCollection() error {
 
 	if animeCollection, ok := m.animeCollection.Get(); ok {
 		m.SaveSimulatedAnimeCollection(animeCollection)
+		// Keep the queryable mirror in sync
+		if err := m.localDb.SaveAnimeCollectionEntries(animeCollection); err != nil {
+			m.logger.Error().Err(err).Msg("local manager: Failed to save anime collection entries")
+		}
 	}
 
 	if mangaCollection, ok := m.mangaCollection.Get(); ok {
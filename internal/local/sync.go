@@ -380,16 +380,17 @@ func (q *Syncer) synchronizeCollections() (err error) {
 					}
 
 					entry := &anilist.MangaListEntry{
-						ID:          _mangaEntry.GetID(),
-						Score:       ToNewPointer(_mangaEntry.GetScore()),
-						Progress:    ToNewPointer(_mangaEntry.GetProgress()),
-						Status:      ToNewPointer(_mangaEntry.GetStatus()),
-						Notes:       ToNewPointer(_mangaEntry.GetNotes()),
-						Repeat:      ToNewPointer(_mangaEntry.GetRepeat()),
-						Private:     ToNewPointer(_mangaEntry.GetPrivate()),
-						StartedAt:   startedAt,
-						CompletedAt: completedAt,
-						Media:       editedManga,
+						ID:              _mangaEntry.GetID(),
+						Score:           ToNewPointer(_mangaEntry.GetScore()),
+						Progress:        ToNewPointer(_mangaEntry.GetProgress()),
+						ProgressVolumes: ToNewPointer(_mangaEntry.GetProgressVolumes()),
+						Status:          ToNewPointer(_mangaEntry.GetStatus()),
+						Notes:           ToNewPointer(_mangaEntry.GetNotes()),
+						Repeat:          ToNewPointer(_mangaEntry.GetRepeat()),
+						Private:         ToNewPointer(_mangaEntry.GetPrivate()),
+						StartedAt:       startedAt,
+						CompletedAt:     completedAt,
+						Media:           editedManga,
 					}
 					list.Entries = append(list.Entries, entry)
 					break
@@ -6,6 +6,7 @@ import (
 	"seanime/internal/api/anilist"
 	"seanime/internal/database/db"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/test_utils"
 	"seanime/internal/util"
 	"testing"
@@ -24,7 +25,7 @@ func testSetupManager(t *testing.T) (Manager, *anilist.AnimeCollection, *anilist
 	anilistClient := anilist.NewAnilistClient(test_utils.ConfigData.Provider.AnilistJwt, "")
 	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistClient, logger, database)
 	anilistPlatform.SetUsername(test_utils.ConfigData.Provider.AnilistUsername)
-	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), true)
+	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), platform.FreshCollection)
 	require.NoError(t, err)
 	mangaCollection, err := anilistPlatform.GetMangaCollection(t.Context(), true)
 	require.NoError(t, err)
@@ -0,0 +1,285 @@
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/events"
+	"seanime/internal/notifier"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultDuration is used when maintenance mode is entered without a duration, so that it cannot be forgotten.
+	DefaultDuration = 6 * time.Hour
+	// MaxDuration is the longest maintenance mode can last before it expires.
+	MaxDuration = 7 * 24 * time.Hour
+)
+
+// Background tasks that are paused during maintenance mode.
+const (
+	TaskAutoDownloader  = "auto-downloader"
+	TaskAutoScanner     = "auto-scanner"
+	TaskMetadataRefresh = "metadata-refresh"
+	TaskLocalSync       = "local-sync"
+	TaskLibraryCleanup  = "library-cleanup"
+	TaskTorrentProgress = "torrent-progress"
+	TaskUpdateCheck     = "update-check"
+	TaskBatchWatch      = "batch-watch"
+)
+
+// ErrMaintenance is returned when an action is rejected because maintenance mode is active.
+var ErrMaintenance = errors.New("maintenance mode is active")
+
+type (
+	// Manager pauses the background activity of the app while maintenance mode is active (e.g. during a backup of the library).
+	//
+	// Background tasks are registered so that their state can be displayed.
+	// They should call ShouldSkip before running and do nothing if it returns true.
+	Manager struct {
+		logger         *zerolog.Logger
+		wsEventManager events.WSEventManagerInterface
+
+		mu        sync.Mutex
+		active    bool
+		reason    string
+		startedAt time.Time
+		expiresAt time.Time
+		timer     *time.Timer
+		tasks     map[string]*TaskStatus
+		onExit    []func()
+		now       func() time.Time
+		// location is the timezone of the daily schedules, UTC if it is not set
+		location  *time.Location
+		schedules map[string]*dailySchedule
+	}
+
+	NewManagerOptions struct {
+		Logger         *zerolog.Logger
+		WSEventManager events.WSEventManagerInterface
+	}
+
+	TaskStatus struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		// PausedDueToMaintenance is true while maintenance mode is active
+		PausedDueToMaintenance bool `json:"pausedDueToMaintenance"`
+		// SkippedRuns is the number of runs skipped during the current or last maintenance
+		SkippedRuns   int        `json:"skippedRuns"`
+		LastSkippedAt *time.Time `json:"lastSkippedAt,omitempty"`
+		// Schedule describes when the task runs if it runs at a fixed time of the day (e.g. "daily at 03:00")
+		Schedule  string     `json:"schedule,omitempty"`
+		NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+		LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	}
+
+	Status struct {
+		Active    bool          `json:"active"`
+		Reason    string        `json:"reason,omitempty"`
+		StartedAt *time.Time    `json:"startedAt,omitempty"`
+		ExpiresAt *time.Time    `json:"expiresAt,omitempty"`
+		Tasks     []*TaskStatus `json:"tasks"`
+		// Timezone is the effective timezone of the scheduled tasks
+		Timezone string `json:"timezone"`
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		logger:         opts.Logger,
+		wsEventManager: opts.WSEventManager,
+		tasks:          make(map[string]*TaskStatus),
+		schedules:      make(map[string]*dailySchedule),
+		now:            time.Now,
+	}
+}
+
+// RegisterTask adds a background task to the registry.
+func (m *Manager) RegisterTask(name string, description string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tasks[name]; ok {
+		return
+	}
+	m.tasks[name] = &TaskStatus{
+		Name:        name,
+		Description: description,
+	}
+}
+
+// RemoveTask removes a task from the registry and stops its daily schedule.
+func (m *Manager) RemoveTask(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.schedules[name]; ok {
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		// A timer that already fired does nothing
+		s.generation++
+		delete(m.schedules, name)
+	}
+	delete(m.tasks, name)
+}
+
+// OnExit registers a function that is called when maintenance mode ends, e.g. to catch up on the skipped runs.
+func (m *Manager) OnExit(f func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExit = append(m.onExit, f)
+}
+
+// IsActive returns true if maintenance mode is active.
+func (m *Manager) IsActive() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// ShouldSkip returns true if the task should not run because maintenance mode is active.
+// The skipped run is recorded in the registry.
+func (m *Manager) ShouldSkip(task string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.active {
+		return false
+	}
+
+	if t, ok := m.tasks[task]; ok {
+		now := m.now()
+		t.SkippedRuns++
+		t.LastSkippedAt = &now
+	}
+
+	m.logger.Debug().Str("task", task).Msg("maintenance: Skipped background task")
+
+	return true
+}
+
+// Enter activates maintenance mode for the given duration.
+// If duration is not positive, DefaultDuration is used. Entering again extends or shortens the current maintenance.
+func (m *Manager) Enter(duration time.Duration, reason string) (*Status, error) {
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	if duration > MaxDuration {
+		return nil, fmt.Errorf("maintenance: Duration cannot exceed %s", MaxDuration)
+	}
+
+	m.mu.Lock()
+	now := m.now()
+	wasActive := m.active
+	if !wasActive {
+		m.startedAt = now
+		for _, t := range m.tasks {
+			t.SkippedRuns = 0
+			t.LastSkippedAt = nil
+		}
+	}
+	m.active = true
+	m.reason = reason
+	m.expiresAt = now.Add(duration)
+
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	m.timer = time.AfterFunc(duration, m.expire)
+	m.mu.Unlock()
+
+	m.logger.Info().Dur("duration", duration).Str("reason", reason).Msg("maintenance: Maintenance mode is active")
+
+	status := m.GetStatus()
+	m.wsEventManager.SendEvent(events.MaintenanceModeUpdated, status)
+	if !wasActive {
+		notifier.GlobalNotifier.Notify(notifier.Maintenance, fmt.Sprintf("Maintenance mode is active until %s. Background activity is paused.", status.ExpiresAt.Format(time.Kitchen)))
+	}
+
+	return status, nil
+}
+
+// Exit deactivates maintenance mode and resumes the background tasks.
+func (m *Manager) Exit() *Status {
+	m.exit(false)
+	return m.GetStatus()
+}
+
+func (m *Manager) expire() {
+	m.mu.Lock()
+	// The timer might have fired while maintenance mode was being extended
+	expired := m.active && !m.now().Before(m.expiresAt)
+	m.mu.Unlock()
+
+	if expired {
+		m.exit(true)
+	}
+}
+
+func (m *Manager) exit(expired bool) {
+	m.mu.Lock()
+	if !m.active {
+		m.mu.Unlock()
+		return
+	}
+	m.active = false
+	m.reason = ""
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	onExit := m.onExit
+	m.mu.Unlock()
+
+	m.logger.Info().Bool("expired", expired).Msg("maintenance: Maintenance mode has ended")
+
+	m.wsEventManager.SendEvent(events.MaintenanceModeUpdated, m.GetStatus())
+	if expired {
+		notifier.GlobalNotifier.Notify(notifier.Maintenance, "Maintenance mode has expired. Background activity has resumed.")
+	} else {
+		notifier.GlobalNotifier.Notify(notifier.Maintenance, "Maintenance mode has ended. Background activity has resumed.")
+	}
+
+	for _, f := range onExit {
+		go f()
+	}
+}
+
+// GetStatus returns the state of maintenance mode and of the registered tasks.
+func (m *Manager) GetStatus() *Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := &Status{
+		Active:   m.active,
+		Reason:   m.reason,
+		Tasks:    make([]*TaskStatus, 0, len(m.tasks)),
+		Timezone: m.getLocation().String(),
+	}
+	if m.active {
+		startedAt, expiresAt := m.startedAt, m.expiresAt
+		ret.StartedAt = &startedAt
+		ret.ExpiresAt = &expiresAt
+	}
+
+	for _, t := range m.tasks {
+		task := *t
+		task.PausedDueToMaintenance = m.active
+		ret.Tasks = append(ret.Tasks, &task)
+	}
+	sort.Slice(ret.Tasks, func(i, j int) bool {
+		return ret.Tasks[i].Name < ret.Tasks[j].Name
+	})
+
+	return ret
+}
@@ -0,0 +1,106 @@
+package maintenance
+
+import (
+	"seanime/internal/events"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager() *Manager {
+	logger := util.NewLogger()
+	m := NewManager(&NewManagerOptions{
+		Logger:         logger,
+		WSEventManager: events.NewMockWSEventManager(logger),
+	})
+	m.RegisterTask(TaskAutoDownloader, "")
+	m.RegisterTask(TaskAutoScanner, "")
+	return m
+}
+
+func TestManager_EnterExit(t *testing.T) {
+	m := newTestManager()
+
+	exited := make(chan struct{}, 1)
+	m.OnExit(func() { exited <- struct{}{} })
+
+	assert.False(t, m.ShouldSkip(TaskAutoDownloader))
+
+	status, err := m.Enter(time.Hour, "backup")
+	require.NoError(t, err)
+	assert.True(t, status.Active)
+	assert.Equal(t, "backup", status.Reason)
+	require.NotNil(t, status.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *status.ExpiresAt, time.Second)
+
+	assert.True(t, m.ShouldSkip(TaskAutoDownloader))
+	assert.True(t, m.ShouldSkip(TaskAutoDownloader))
+
+	status = m.GetStatus()
+	require.Len(t, status.Tasks, 2)
+	for _, task := range status.Tasks {
+		assert.True(t, task.PausedDueToMaintenance)
+		if task.Name == TaskAutoDownloader {
+			assert.Equal(t, 2, task.SkippedRuns)
+			assert.NotNil(t, task.LastSkippedAt)
+		} else {
+			assert.Zero(t, task.SkippedRuns)
+		}
+	}
+
+	status = m.Exit()
+	assert.False(t, status.Active)
+	assert.Nil(t, status.ExpiresAt)
+	assert.False(t, status.Tasks[0].PausedDueToMaintenance)
+	assert.False(t, m.ShouldSkip(TaskAutoDownloader))
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("OnExit function was not called")
+	}
+}
+
+func TestManager_Expire(t *testing.T) {
+	m := newTestManager()
+
+	_, err := m.Enter(50*time.Millisecond, "")
+	require.NoError(t, err)
+	assert.True(t, m.IsActive())
+
+	assert.Eventually(t, func() bool { return !m.IsActive() }, time.Second, 10*time.Millisecond)
+}
+
+func TestManager_Extend(t *testing.T) {
+	m := newTestManager()
+
+	_, err := m.Enter(50*time.Millisecond, "")
+	require.NoError(t, err)
+	_, err = m.Enter(time.Hour, "")
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, m.IsActive())
+}
+
+func TestManager_Duration(t *testing.T) {
+	m := newTestManager()
+
+	_, err := m.Enter(MaxDuration+time.Minute, "")
+	assert.Error(t, err)
+	assert.False(t, m.IsActive())
+
+	status, err := m.Enter(0, "")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(DefaultDuration), *status.ExpiresAt, time.Second)
+	m.Exit()
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	assert.False(t, m.IsActive())
+	assert.False(t, m.ShouldSkip(TaskAutoScanner))
+}
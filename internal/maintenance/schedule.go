@@ -0,0 +1,155 @@
+package maintenance
+
+import (
+	"fmt"
+	"time"
+)
+
+// dailySchedule runs a task once a day at a time of the application timezone.
+type dailySchedule struct {
+	task   string
+	hour   int
+	minute int
+	run    func()
+	timer  *time.Timer
+	// generation is incremented every time the schedule is reset, a timer of a previous generation does nothing
+	generation int
+}
+
+// SetLocation changes the timezone of the daily schedules, the pending runs are rescheduled.
+func (m *Manager) SetLocation(loc *time.Location) {
+	if m == nil || loc == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.location != nil && m.location.String() == loc.String() {
+		return
+	}
+	m.location = loc
+
+	for _, s := range m.schedules {
+		m.resetSchedule(s)
+	}
+
+	m.logger.Debug().Str("timezone", loc.String()).Msg("maintenance: Timezone updated")
+}
+
+// ScheduleDaily runs the registered task every day at the given time of the application timezone.
+// The run is skipped while maintenance mode is active. Scheduling the task again replaces its schedule.
+func (m *Manager) ScheduleDaily(task string, hour int, minute int, run func()) error {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return fmt.Errorf("maintenance: Invalid time %02d:%02d", hour, minute)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[task]
+	if !ok {
+		return fmt.Errorf("maintenance: Task %s is not registered", task)
+	}
+	t.Schedule = fmt.Sprintf("daily at %02d:%02d", hour, minute)
+
+	if prev, ok := m.schedules[task]; ok && prev.timer != nil {
+		prev.timer.Stop()
+	}
+	s := &dailySchedule{
+		task:   task,
+		hour:   hour,
+		minute: minute,
+		run:    run,
+	}
+	m.schedules[task] = s
+	m.resetSchedule(s)
+
+	return nil
+}
+
+// resetSchedule computes the next run of the schedule and starts its timer.
+func (m *Manager) resetSchedule(s *dailySchedule) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.generation++
+
+	t := m.tasks[s.task]
+	now := m.now()
+	next := nextDailyRun(now, t.LastRunAt, m.getLocation(), s.hour, s.minute)
+	t.NextRunAt = &next
+
+	generation := s.generation
+	s.timer = time.AfterFunc(next.Sub(now), func() {
+		m.runSchedule(s, generation)
+	})
+}
+
+func (m *Manager) runSchedule(s *dailySchedule, generation int) {
+	m.mu.Lock()
+	if s.generation != generation {
+		m.mu.Unlock()
+		return
+	}
+
+	t := m.tasks[s.task]
+	now := m.now()
+	// The timer might fire slightly early
+	if t.NextRunAt != nil && now.Before(*t.NextRunAt) {
+		m.resetSchedule(s)
+		m.mu.Unlock()
+		return
+	}
+
+	skip := m.active
+	if skip {
+		t.SkippedRuns++
+		t.LastSkippedAt = &now
+	} else {
+		t.LastRunAt = &now
+	}
+	m.resetSchedule(s)
+	m.mu.Unlock()
+
+	if skip {
+		m.logger.Debug().Str("task", s.task).Msg("maintenance: Skipped scheduled task")
+		return
+	}
+
+	m.logger.Debug().Str("task", s.task).Msg("maintenance: Running scheduled task")
+	s.run()
+}
+
+func (m *Manager) getLocation() *time.Location {
+	if m.location == nil {
+		return time.UTC
+	}
+	return m.location
+}
+
+// nextDailyRun returns the next time after now at which a daily task should run, in the given location.
+//
+// Days are counted in the location so that daylight saving time transitions do not cause double or skipped runs:
+//   - A time that does not exist on the day the clocks go forward is normalized by time.Date to a time of the same day.
+//   - A time that occurs twice on the day the clocks go back is only run once, the task does not run twice on the same day.
+func nextDailyRun(now time.Time, lastRunAt *time.Time, loc *time.Location, hour int, minute int) time.Time {
+	now = now.In(loc)
+
+	var lastRunDay time.Time
+	if lastRunAt != nil {
+		l := lastRunAt.In(loc)
+		lastRunDay = time.Date(l.Year(), l.Month(), l.Day(), 0, 0, 0, 0, loc)
+	}
+
+	for i := 0; ; i++ {
+		day := time.Date(now.Year(), now.Month(), now.Day()+i, 0, 0, 0, 0, loc)
+		if !lastRunDay.IsZero() && !day.After(lastRunDay) {
+			continue
+		}
+		at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+		if at.Before(now) {
+			continue
+		}
+		return at
+	}
+}
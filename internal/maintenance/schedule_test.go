@@ -0,0 +1,143 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextDailyRun(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		lastRunAt *time.Time
+		loc       *time.Location
+		hour      int
+		minute    int
+		expected  time.Time
+	}{
+		{
+			name:     "later today",
+			now:      time.Date(2026, 1, 10, 1, 0, 0, 0, paris),
+			loc:      paris,
+			hour:     3,
+			expected: time.Date(2026, 1, 10, 3, 0, 0, 0, paris),
+		},
+		{
+			name:     "tomorrow",
+			now:      time.Date(2026, 1, 10, 4, 0, 0, 0, paris),
+			loc:      paris,
+			hour:     3,
+			expected: time.Date(2026, 1, 11, 3, 0, 0, 0, paris),
+		},
+		{
+			// 03:00 in Paris is 02:00 UTC, not 03:00 UTC
+			name:     "server time is UTC",
+			now:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			loc:      paris,
+			hour:     3,
+			expected: time.Date(2026, 1, 10, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			// The clocks go from 02:00 to 03:00, 02:30 does not exist but the task still runs that day
+			name:     "spring forward",
+			now:      time.Date(2026, 3, 29, 0, 0, 0, 0, paris),
+			loc:      paris,
+			hour:     2,
+			minute:   30,
+			expected: time.Date(2026, 3, 29, 3, 30, 0, 0, paris),
+		},
+		{
+			// The clocks go from 02:00 back to 01:00, the second 01:30 is on the day the task already ran
+			name:      "fall back",
+			now:       time.Date(2026, 11, 1, 5, 31, 0, 0, time.UTC), // 01:31 EDT
+			lastRunAt: ptr(time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)),
+			loc:       newYork,
+			hour:      1,
+			minute:    30,
+			expected:  time.Date(2026, 11, 2, 1, 30, 0, 0, newYork),
+		},
+		{
+			name:      "already ran today",
+			now:       time.Date(2026, 1, 10, 2, 0, 0, 0, paris),
+			lastRunAt: ptr(time.Date(2026, 1, 10, 1, 0, 0, 0, paris)),
+			loc:       paris,
+			hour:      3,
+			expected:  time.Date(2026, 1, 11, 3, 0, 0, 0, paris),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := nextDailyRun(tt.now, tt.lastRunAt, tt.loc, tt.hour, tt.minute)
+			assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+}
+
+func TestManager_ScheduleDaily(t *testing.T) {
+	m := newTestManager()
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	assert.Error(t, m.ScheduleDaily("unknown", 3, 0, func() {}))
+	assert.Error(t, m.ScheduleDaily(TaskAutoScanner, 24, 0, func() {}))
+
+	ran := make(chan struct{}, 2)
+	require.NoError(t, m.ScheduleDaily(TaskAutoScanner, 3, 0, func() { ran <- struct{}{} }))
+
+	status := m.GetStatus()
+	assert.Equal(t, "UTC", status.Timezone)
+	task := findTask(status, TaskAutoScanner)
+	require.NotNil(t, task)
+	assert.Equal(t, "daily at 03:00", task.Schedule)
+	assert.True(t, time.Date(2026, 1, 10, 3, 0, 0, 0, time.UTC).Equal(*task.NextRunAt))
+
+	// Changing the timezone reschedules the pending run
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	m.SetLocation(tokyo)
+	status = m.GetStatus()
+	assert.Equal(t, "Asia/Tokyo", status.Timezone)
+	assert.True(t, time.Date(2026, 1, 10, 18, 0, 0, 0, time.UTC).Equal(*findTask(status, TaskAutoScanner).NextRunAt))
+
+	// Run the schedule as if the timer fired
+	now = time.Date(2026, 1, 10, 18, 0, 0, 0, time.UTC)
+	s := m.schedules[TaskAutoScanner]
+	m.runSchedule(s, s.generation)
+	<-ran
+	// A timer of a previous generation does nothing
+	m.runSchedule(s, s.generation-1)
+	assert.Len(t, ran, 0)
+
+	task = findTask(m.GetStatus(), TaskAutoScanner)
+	assert.True(t, now.Equal(*task.LastRunAt))
+	assert.True(t, time.Date(2026, 1, 11, 18, 0, 0, 0, time.UTC).Equal(*task.NextRunAt))
+
+	// Skipped during maintenance
+	_, err = m.Enter(time.Hour, "")
+	require.NoError(t, err)
+	now = now.Add(24 * time.Hour)
+	m.runSchedule(s, s.generation)
+	assert.Len(t, ran, 0)
+	assert.Equal(t, 1, findTask(m.GetStatus(), TaskAutoScanner).SkippedRuns)
+	m.Exit()
+}
+
+func findTask(status *Status, name string) *TaskStatus {
+	for _, t := range status.Tasks {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
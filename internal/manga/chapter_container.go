@@ -31,6 +31,9 @@ type (
 		MediaId  int                           `json:"mediaId"`
 		Provider string                        `json:"provider"`
 		Chapters []*hibikemanga.ChapterDetails `json:"chapters"`
+		// OfflineAvailable are the IDs of the chapters whose pages are all in the page cache.
+		// It is not cached, see [Repository.HydrateOfflineAvailability].
+		OfflineAvailable []string `json:"offlineAvailable"`
 	}
 )
 
@@ -47,6 +50,17 @@ type GetMangaChapterContainerOptions struct {
 	Year     int
 }
 
+// GetCachedChapterContainer returns the cached ChapterContainer of a manga entry for the provider.
+// Unlike GetMangaChapterContainer, it never searches the provider.
+func (r *Repository) GetCachedChapterContainer(provider string, mediaId int) (*ChapterContainer, bool) {
+	var container *ChapterContainer
+	containerBucket := r.getFcProviderBucket(provider, mediaId, bucketTypeChapter)
+	if found, _ := r.fileCacher.Get(containerBucket, getMangaChapterContainerCacheKey(provider, mediaId), &container); !found || container == nil {
+		return nil, false
+	}
+	return container, true
+}
+
 // GetMangaChapterContainer returns the ChapterContainer for a manga entry based on the provider.
 // If it isn't cached, it will search for the manga, create a ChapterContainer and cache it.
 func (r *Repository) GetMangaChapterContainer(opts *GetMangaChapterContainerOptions) (ret *ChapterContainer, err error) {
@@ -19,6 +19,8 @@ type (
 		Pages          []*hibikemanga.ChapterPage `json:"pages"`
 		PageDimensions map[int]*PageDimension     `json:"pageDimensions"` // Indexed by page number
 		IsDownloaded   bool                       `json:"isDownloaded"`   // TODO remove
+		// Stale is true if the provider could not be reached and the pages were read from the page cache
+		Stale bool `json:"stale"`
 	}
 
 	// PageDimension is used to store the dimensions of a page.
@@ -54,6 +56,9 @@ func (r *Repository) GetMangaPageContainer(
 	if isOfflineRef.Get() && !isLocalProvider && extensionExists {
 		ret, err = r.getDownloadedMangaPageContainer(provider, mediaId, chapterId)
 		if err != nil {
+			if stale, ok := r.getStalePageContainer(provider, mediaId, chapterId); ok {
+				return stale, nil
+			}
 			return nil, err
 		}
 		return ret, nil
@@ -102,6 +107,8 @@ func (r *Repository) GetMangaPageContainer(
 		pageDimensions, _ := r.getPageDimensions(doublePage, provider, mediaId, chapterId, container.Pages)
 		container.PageDimensions = pageDimensions
 
+		r.rememberChapterPages(provider, mediaId, chapterId, container.Pages)
+
 		r.logger.Debug().Str("key", pageContainerKey).Msg("manga: Page Container Cache HIT")
 		return container, nil
 	}
@@ -117,6 +124,9 @@ func (r *Repository) GetMangaPageContainer(
 
 	var chapterContainer *ChapterContainer
 	if found, _ := r.fileCacher.Get(containerBucket, chapterContainerKey, &chapterContainer); !found {
+		if stale, ok := r.getStalePageContainer(provider, mediaId, chapterId); ok {
+			return stale, nil
+		}
 		r.logger.Error().Msg("manga: Chapter Container not found")
 		return nil, ErrNoChapters
 	}
@@ -140,6 +150,10 @@ func (r *Repository) GetMangaPageContainer(
 
 	pages, err = providerExtension.GetProvider().FindChapterPages(chapter.ID)
 	if err != nil {
+		if stale, ok := r.getStalePageContainer(provider, mediaId, chapterId); ok && !isLocalProvider {
+			r.logger.Warn().Err(err).Msg("manga: Could not get chapter pages, using the page cache")
+			return stale, nil
+		}
 		r.logger.Error().Err(err).Msg("manga: Could not get chapter pages")
 		return nil, err
 	}
@@ -171,6 +185,7 @@ func (r *Repository) GetMangaPageContainer(
 		if err != nil {
 			r.logger.Warn().Err(err).Msg("manga: Failed to populate cache")
 		}
+		r.rememberChapterPages(provider, mediaId, chapterId, pages)
 	}
 
 	r.logger.Debug().Str("key", pageContainerKey).Msg("manga: Retrieved pages")
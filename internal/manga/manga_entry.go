@@ -6,6 +6,7 @@ import (
 	"seanime/internal/api/anilist"
 	"seanime/internal/hook"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/syncstatus"
 	"seanime/internal/util"
 	"seanime/internal/util/filecache"
 
@@ -18,15 +19,19 @@ type (
 		MediaId       int                `json:"mediaId"`
 		Media         *anilist.BaseManga `json:"media"`
 		EntryListData *EntryListData     `json:"listData,omitempty"`
+		// SyncState is set when the user has a pending or failed AniList update for this media
+		SyncState *syncstatus.MediaState `json:"syncState,omitempty"`
 	}
 
 	EntryListData struct {
-		Progress    int                      `json:"progress,omitempty"`
-		Score       float64                  `json:"score,omitempty"`
-		Status      *anilist.MediaListStatus `json:"status,omitempty"`
-		Repeat      int                      `json:"repeat,omitempty"`
-		StartedAt   string                   `json:"startedAt,omitempty"`
-		CompletedAt string                   `json:"completedAt,omitempty"`
+		Progress int `json:"progress,omitempty"`
+		// ProgressVolumes is the number of volumes read
+		ProgressVolumes int                      `json:"progressVolumes,omitempty"`
+		Score           float64                  `json:"score,omitempty"`
+		Status          *anilist.MediaListStatus `json:"status,omitempty"`
+		Repeat          int                      `json:"repeat,omitempty"`
+		StartedAt       string                   `json:"startedAt,omitempty"`
+		CompletedAt     string                   `json:"completedAt,omitempty"`
 	}
 )
 
@@ -94,12 +99,13 @@ func NewEntry(ctx context.Context, opts *NewEntryOptions) (entry *Entry, err err
 		}
 		entry.Media = mangaEvent.Manga
 		entry.EntryListData = &EntryListData{
-			Progress:    *anilistEntry.Progress,
-			Score:       *anilistEntry.Score,
-			Status:      anilistEntry.Status,
-			Repeat:      anilistEntry.GetRepeatSafe(),
-			StartedAt:   anilist.FuzzyDateToString(anilistEntry.StartedAt),
-			CompletedAt: anilist.FuzzyDateToString(anilistEntry.CompletedAt),
+			Progress:        *anilistEntry.Progress,
+			ProgressVolumes: anilistEntry.GetProgressVolumesSafe(),
+			Score:           *anilistEntry.Score,
+			Status:          anilistEntry.Status,
+			Repeat:          anilistEntry.GetRepeatSafe(),
+			StartedAt:       anilist.FuzzyDateToString(anilistEntry.StartedAt),
+			CompletedAt:     anilist.FuzzyDateToString(anilistEntry.CompletedAt),
 		}
 	}
 
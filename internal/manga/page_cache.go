@@ -0,0 +1,142 @@
+package manga
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	hibikemanga "seanime/internal/extension/hibike/manga"
+	"seanime/internal/manga/pagecache"
+	manga_providers "seanime/internal/manga/providers"
+	"seanime/internal/util"
+)
+
+var ErrPageCacheDisabled = errors.New("manga: Page cache is not available")
+
+// rememberChapterPages saves the page list of a chapter in the page cache,
+// so that the chapter can be read from the cached images when the provider is unreachable.
+func (r *Repository) rememberChapterPages(provider string, mediaId int, chapterId string, pages []*hibikemanga.ChapterPage) {
+	if r.pageCache == nil || len(pages) == 0 {
+		return
+	}
+	if err := r.pageCache.SetChapter(provider, mediaId, chapterId, len(pages), pages); err != nil {
+		r.logger.Warn().Err(err).Msg("manga: Failed to save chapter pages to the page cache")
+	}
+}
+
+// getStalePageContainer returns the page list saved in the page cache.
+// It is used when the provider is unreachable, the container is marked as stale.
+func (r *Repository) getStalePageContainer(provider string, mediaId int, chapterId string) (*PageContainer, bool) {
+	if r.pageCache == nil {
+		return nil, false
+	}
+
+	var pages []*hibikemanga.ChapterPage
+	found, err := r.pageCache.GetChapter(provider, chapterId, &pages)
+	if err != nil || !found || len(pages) == 0 {
+		return nil, false
+	}
+
+	r.logger.Debug().Str("provider", provider).Str("chapterId", chapterId).Msg("manga: Serving stale pages from the page cache")
+
+	return &PageContainer{
+		MediaId:   mediaId,
+		Provider:  provider,
+		ChapterId: chapterId,
+		Pages:     pages,
+		Stale:     true,
+	}, true
+}
+
+// GetPageImage returns the image of a chapter page and its content type.
+// Cached images are returned first, otherwise the image is fetched from the provider and cached.
+func (r *Repository) GetPageImage(provider string, mediaId int, chapterId string, index int, isOfflineRef *util.Ref[bool]) ([]byte, string, error) {
+	if r.pageCache == nil {
+		return nil, "", ErrPageCacheDisabled
+	}
+
+	if data, contentType, found := r.pageCache.GetPage(provider, chapterId, index); found {
+		return data, contentType, nil
+	}
+
+	container, err := r.GetMangaPageContainer(provider, mediaId, chapterId, false, isOfflineRef)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var page *hibikemanga.ChapterPage
+	for _, p := range container.Pages {
+		if p.Index == index {
+			page = p
+			break
+		}
+	}
+	if page == nil {
+		return nil, "", fmt.Errorf("manga: Page %d not found", index)
+	}
+
+	var data []byte
+	switch {
+	case container.IsDownloaded:
+		// Downloaded chapters are already on disk
+		data, err = os.ReadFile(filepath.Join(r.downloadDir, page.URL))
+	case page.Buf != nil:
+		data = page.Buf
+	default:
+		data, err = manga_providers.GetImageByProxy(page.URL, page.Headers)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := http.DetectContentType(data)
+
+	if !container.IsDownloaded && page.Buf == nil {
+		if err := r.pageCache.SetPage(provider, mediaId, chapterId, index, data, contentType); err != nil {
+			r.logger.Warn().Err(err).Int("index", index).Msg("manga: Failed to cache page image")
+		}
+	}
+
+	return data, contentType, nil
+}
+
+// HydrateOfflineAvailability sets the chapters of the container whose pages are all in the page cache.
+func (r *Repository) HydrateOfflineAvailability(container *ChapterContainer) {
+	if container == nil {
+		return
+	}
+	if r.pageCache == nil {
+		container.OfflineAvailable = make([]string, 0)
+		return
+	}
+	container.OfflineAvailable = r.pageCache.GetAvailableChapterIds(container.Provider, container.MediaId)
+}
+
+// SetPageCachePinned keeps the cached pages of a series from being evicted.
+func (r *Repository) SetPageCachePinned(mediaId int, pinned bool) error {
+	if r.pageCache == nil {
+		return ErrPageCacheDisabled
+	}
+	r.pageCache.SetPinned(mediaId, pinned)
+	return nil
+}
+
+// IsPageCachePinned returns true if the cached pages of a series are never evicted.
+func (r *Repository) IsPageCachePinned(mediaId int) bool {
+	return r.pageCache != nil && r.pageCache.IsPinned(mediaId)
+}
+
+func (r *Repository) GetPageCacheStats() (*pagecache.Stats, error) {
+	if r.pageCache == nil {
+		return nil, ErrPageCacheDisabled
+	}
+	return r.pageCache.GetStats(), nil
+}
+
+// PurgePageCache removes the cached page images, pinned series are kept unless includePinned is true.
+func (r *Repository) PurgePageCache(includePinned bool) error {
+	if r.pageCache == nil {
+		return ErrPageCacheDisabled
+	}
+	return r.pageCache.Purge(includePinned)
+}
@@ -0,0 +1,409 @@
+package pagecache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultMaxSize is used when no size is set
+	DefaultMaxSize int64 = 2 << 30 // 2 GiB
+
+	indexFileName = "index.json"
+)
+
+type (
+	// Store is a persistent, size-capped cache of manga page images.
+	// Pages are keyed by provider, chapter and page index, and are evicted in least recently used order
+	// when the cache exceeds its size, unless the series is pinned.
+	// The page list of each chapter is kept as well so that cached chapters can be read when the provider is unreachable.
+	Store struct {
+		logger  *zerolog.Logger
+		dir     string
+		mu      sync.Mutex
+		maxSize int64
+		index   *index
+		now     func() time.Time
+	}
+
+	NewStoreOptions struct {
+		Logger *zerolog.Logger
+		// Dir is created if it does not exist
+		Dir string
+		// MaxSize is in bytes, DefaultMaxSize is used if it is 0
+		MaxSize int64
+	}
+
+	index struct {
+		Pages    map[string]*Page    `json:"pages"`    // Key: pageKey
+		Chapters map[string]*Chapter `json:"chapters"` // Key: chapterKey
+		// Pinned are the media IDs whose pages are never evicted
+		Pinned    []int `json:"pinned"`
+		TotalSize int64 `json:"totalSize"`
+	}
+
+	Page struct {
+		Provider    string    `json:"provider"`
+		MediaId     int       `json:"mediaId"`
+		ChapterId   string    `json:"chapterId"`
+		Index       int       `json:"index"`
+		Size        int64     `json:"size"`
+		ContentType string    `json:"contentType"`
+		LastAccess  time.Time `json:"lastAccess"`
+	}
+
+	// Chapter is the page list of a chapter, saved when the pages are fetched from the provider.
+	Chapter struct {
+		Provider  string          `json:"provider"`
+		MediaId   int             `json:"mediaId"`
+		ChapterId string          `json:"chapterId"`
+		PageCount int             `json:"pageCount"`
+		Data      json.RawMessage `json:"data"`
+		UpdatedAt time.Time       `json:"updatedAt"`
+	}
+
+	Stats struct {
+		TotalSize int64 `json:"totalSize"`
+		MaxSize   int64 `json:"maxSize"`
+		Pages     int   `json:"pages"`
+		// AvailableChapters are the chapters whose pages are all cached
+		AvailableChapters int   `json:"availableChapters"`
+		PinnedMediaIds    []int `json:"pinnedMediaIds"`
+	}
+)
+
+func NewStore(opts *NewStoreOptions) *Store {
+	ret := &Store{
+		logger:  opts.Logger,
+		dir:     opts.Dir,
+		maxSize: opts.MaxSize,
+		index:   newIndex(),
+		now:     time.Now,
+	}
+	if ret.maxSize <= 0 {
+		ret.maxSize = DefaultMaxSize
+	}
+
+	_ = os.MkdirAll(ret.dir, 0755)
+	ret.load()
+
+	return ret
+}
+
+func newIndex() *index {
+	return &index{
+		Pages:    make(map[string]*Page),
+		Chapters: make(map[string]*Chapter),
+		Pinned:   make([]int, 0),
+	}
+}
+
+func pageKey(provider string, chapterId string, pageIndex int) string {
+	return provider + "$" + chapterId + "$" + strconv.Itoa(pageIndex)
+}
+
+func chapterKey(provider string, chapterId string) string {
+	return provider + "$" + chapterId
+}
+
+// pagePath hashes the key since chapter IDs can contain any character.
+func (s *Store) pagePath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// SetMaxSize changes the size of the cache and evicts pages if it is exceeded.
+func (s *Store) SetMaxSize(maxSize int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	s.maxSize = maxSize
+	if s.evict() {
+		s.save()
+	}
+}
+
+// GetPage returns a cached page image and its content type.
+func (s *Store) GetPage(provider string, chapterId string, pageIndex int) ([]byte, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pageKey(provider, chapterId, pageIndex)
+	page, found := s.index.Pages[key]
+	if !found {
+		return nil, "", false
+	}
+
+	data, err := os.ReadFile(s.pagePath(key))
+	if err != nil {
+		// The file was removed outside the store
+		s.removePage(key)
+		s.save()
+		return nil, "", false
+	}
+
+	page.LastAccess = s.now()
+	return data, page.ContentType, true
+}
+
+// SetPage caches a page image and evicts the least recently used pages if the cache is full.
+func (s *Store) SetPage(provider string, mediaId int, chapterId string, pageIndex int, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(len(data))
+	if size > s.maxSize {
+		return fmt.Errorf("pagecache: Page is larger than the cache")
+	}
+
+	key := pageKey(provider, chapterId, pageIndex)
+	if err := os.WriteFile(s.pagePath(key), data, 0644); err != nil {
+		return err
+	}
+
+	if prev, found := s.index.Pages[key]; found {
+		s.index.TotalSize -= prev.Size
+	}
+	s.index.Pages[key] = &Page{
+		Provider:    provider,
+		MediaId:     mediaId,
+		ChapterId:   chapterId,
+		Index:       pageIndex,
+		Size:        size,
+		ContentType: contentType,
+		LastAccess:  s.now(),
+	}
+	s.index.TotalSize += size
+
+	s.evict()
+	s.save()
+	return nil
+}
+
+// SetChapter saves the page list of a chapter.
+// The data is returned by GetChapter when the provider is unreachable.
+func (s *Store) SetChapter(provider string, mediaId int, chapterId string, pageCount int, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index.Chapters[chapterKey(provider, chapterId)] = &Chapter{
+		Provider:  provider,
+		MediaId:   mediaId,
+		ChapterId: chapterId,
+		PageCount: pageCount,
+		Data:      b,
+		UpdatedAt: s.now(),
+	}
+	s.save()
+	return nil
+}
+
+// GetChapter decodes the saved page list of a chapter into out.
+func (s *Store) GetChapter(provider string, chapterId string, out interface{}) (bool, error) {
+	s.mu.Lock()
+	chapter, found := s.index.Chapters[chapterKey(provider, chapterId)]
+	s.mu.Unlock()
+
+	if !found {
+		return false, nil
+	}
+	if err := json.Unmarshal(chapter.Data, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsChapterAvailable returns true if all the pages of the chapter are cached.
+func (s *Store) IsChapterAvailable(provider string, chapterId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isChapterAvailable(s.index.Chapters[chapterKey(provider, chapterId)])
+}
+
+func (s *Store) isChapterAvailable(chapter *Chapter) bool {
+	if chapter == nil || chapter.PageCount == 0 {
+		return false
+	}
+	for i := 0; i < chapter.PageCount; i++ {
+		if _, found := s.index.Pages[pageKey(chapter.Provider, chapter.ChapterId, i)]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetAvailableChapterIds returns the IDs of the chapters of a provider whose pages are all cached.
+func (s *Store) GetAvailableChapterIds(provider string, mediaId int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]string, 0)
+	for _, chapter := range s.index.Chapters {
+		if chapter.Provider != provider || chapter.MediaId != mediaId {
+			continue
+		}
+		if s.isChapterAvailable(chapter) {
+			ret = append(ret, chapter.ChapterId)
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// SetPinned exempts the pages of a series from eviction, or removes the exemption.
+func (s *Store) SetPinned(mediaId int, pinned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index.Pinned = slices.DeleteFunc(s.index.Pinned, func(id int) bool { return id == mediaId })
+	if pinned {
+		s.index.Pinned = append(s.index.Pinned, mediaId)
+		slices.Sort(s.index.Pinned)
+	} else {
+		s.evict()
+	}
+	s.save()
+}
+
+// IsPinned returns true if the pages of the series are never evicted.
+func (s *Store) IsPinned(mediaId int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.index.Pinned, mediaId)
+}
+
+// GetStats returns the size of the cache and the number of cached pages and chapters.
+func (s *Store) GetStats() *Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := &Stats{
+		TotalSize:      s.index.TotalSize,
+		MaxSize:        s.maxSize,
+		Pages:          len(s.index.Pages),
+		PinnedMediaIds: slices.Clone(s.index.Pinned),
+	}
+	for _, chapter := range s.index.Chapters {
+		if s.isChapterAvailable(chapter) {
+			ret.AvailableChapters++
+		}
+	}
+	return ret
+}
+
+// Purge removes all cached pages and chapters.
+// The pages of pinned series are kept unless includePinned is true.
+func (s *Store) Purge(includePinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, page := range s.index.Pages {
+		if !includePinned && slices.Contains(s.index.Pinned, page.MediaId) {
+			continue
+		}
+		s.removePage(key)
+	}
+	for key, chapter := range s.index.Chapters {
+		if !includePinned && slices.Contains(s.index.Pinned, chapter.MediaId) {
+			continue
+		}
+		delete(s.index.Chapters, key)
+	}
+	if includePinned {
+		s.index.Pinned = make([]int, 0)
+	}
+
+	s.logger.Info().Bool("includePinned", includePinned).Msg("pagecache: Purged manga page cache")
+	s.save()
+	return nil
+}
+
+// evict removes the least recently used pages of unpinned series until the cache fits its size.
+// It returns true if pages were removed.
+func (s *Store) evict() bool {
+	if s.index.TotalSize <= s.maxSize {
+		return false
+	}
+
+	candidates := make([]string, 0, len(s.index.Pages))
+	for key, page := range s.index.Pages {
+		if !slices.Contains(s.index.Pinned, page.MediaId) {
+			candidates = append(candidates, key)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b string) int {
+		return s.index.Pages[a].LastAccess.Compare(s.index.Pages[b].LastAccess)
+	})
+
+	evicted := 0
+	for _, key := range candidates {
+		if s.index.TotalSize <= s.maxSize {
+			break
+		}
+		s.removePage(key)
+		evicted++
+	}
+
+	if s.index.TotalSize > s.maxSize {
+		s.logger.Warn().Int64("size", s.index.TotalSize).Int64("maxSize", s.maxSize).Msg("pagecache: Pinned series exceed the cache size")
+	}
+	s.logger.Debug().Int("evicted", evicted).Msg("pagecache: Evicted pages")
+	return evicted > 0
+}
+
+func (s *Store) removePage(key string) {
+	page, found := s.index.Pages[key]
+	if !found {
+		return
+	}
+	_ = os.Remove(s.pagePath(key))
+	s.index.TotalSize -= page.Size
+	delete(s.index.Pages, key)
+}
+
+func (s *Store) load() {
+	b, err := os.ReadFile(filepath.Join(s.dir, indexFileName))
+	if err != nil {
+		return
+	}
+	idx := newIndex()
+	if err := json.Unmarshal(b, idx); err != nil {
+		s.logger.Warn().Err(err).Msg("pagecache: Failed to read the index, starting with an empty cache")
+		return
+	}
+	if idx.Pages == nil {
+		idx.Pages = make(map[string]*Page)
+	}
+	if idx.Chapters == nil {
+		idx.Chapters = make(map[string]*Chapter)
+	}
+	s.index = idx
+}
+
+func (s *Store) save() {
+	b, err := json.Marshal(s.index)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, indexFileName), b, 0644); err != nil {
+		s.logger.Warn().Err(err).Msg("pagecache: Failed to save the index")
+	}
+}
@@ -0,0 +1,134 @@
+package pagecache
+
+import (
+	"bytes"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPage struct {
+	URL   string `json:"url"`
+	Index int    `json:"index"`
+}
+
+func newTestStore(t *testing.T, dir string, maxSize int64) *Store {
+	s := NewStore(&NewStoreOptions{
+		Logger:  util.NewLogger(),
+		Dir:     dir,
+		MaxSize: maxSize,
+	})
+	// Advance the clock on each access so that the LRU order is deterministic
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return s
+}
+
+func page(size int) []byte {
+	return bytes.Repeat([]byte{0xff}, size)
+}
+
+func TestStore_ChapterAvailability(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), 1000)
+
+	pages := []*testPage{{URL: "https://example.com/0.jpg", Index: 0}, {URL: "https://example.com/1.jpg", Index: 1}}
+	require.NoError(t, s.SetChapter("comick", 1, "ch-1", len(pages), pages))
+
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 0, page(10), "image/jpeg"))
+	assert.False(t, s.IsChapterAvailable("comick", "ch-1"))
+	assert.Empty(t, s.GetAvailableChapterIds("comick", 1))
+
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 1, page(10), "image/jpeg"))
+	assert.True(t, s.IsChapterAvailable("comick", "ch-1"))
+	assert.Equal(t, []string{"ch-1"}, s.GetAvailableChapterIds("comick", 1))
+	assert.Empty(t, s.GetAvailableChapterIds("mangadex", 1))
+
+	data, contentType, found := s.GetPage("comick", "ch-1", 1)
+	require.True(t, found)
+	assert.Len(t, data, 10)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	var saved []*testPage
+	found, err := s.GetChapter("comick", "ch-1", &saved)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, pages, saved)
+}
+
+func TestStore_LRUEviction(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), 30)
+
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 0, page(10), "image/jpeg"))
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 1, page(10), "image/jpeg"))
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 2, page(10), "image/jpeg"))
+
+	// Page 0 becomes the most recently used
+	_, _, found := s.GetPage("comick", "ch-1", 0)
+	require.True(t, found)
+
+	require.NoError(t, s.SetPage("comick", 1, "ch-2", 0, page(10), "image/jpeg"))
+
+	_, _, found = s.GetPage("comick", "ch-1", 1)
+	assert.False(t, found, "least recently used page should be evicted")
+	_, _, found = s.GetPage("comick", "ch-1", 0)
+	assert.True(t, found)
+	assert.Equal(t, int64(30), s.GetStats().TotalSize)
+
+	assert.Error(t, s.SetPage("comick", 1, "ch-3", 0, page(31), "image/jpeg"))
+}
+
+func TestStore_PinnedSeriesAreNotEvicted(t *testing.T) {
+	s := newTestStore(t, t.TempDir(), 30)
+
+	s.SetPinned(1, true)
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 0, page(10), "image/jpeg"))
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 1, page(10), "image/jpeg"))
+	require.NoError(t, s.SetPage("comick", 2, "ch-a", 0, page(10), "image/jpeg"))
+	require.NoError(t, s.SetPage("comick", 2, "ch-a", 1, page(10), "image/jpeg"))
+
+	_, _, found := s.GetPage("comick", "ch-1", 0)
+	assert.True(t, found, "pinned page should not be evicted")
+	_, _, found = s.GetPage("comick", "ch-a", 0)
+	assert.False(t, found)
+
+	// Purging keeps the pinned series
+	require.NoError(t, s.Purge(false))
+	stats := s.GetStats()
+	assert.Equal(t, 2, stats.Pages)
+	assert.Equal(t, []int{1}, stats.PinnedMediaIds)
+
+	require.NoError(t, s.Purge(true))
+	stats = s.GetStats()
+	assert.Equal(t, 0, stats.Pages)
+	assert.Equal(t, int64(0), stats.TotalSize)
+	assert.Empty(t, stats.PinnedMediaIds)
+}
+
+func TestStore_Persistence(t *testing.T) {
+	dir := t.TempDir()
+
+	s := newTestStore(t, dir, 1000)
+	require.NoError(t, s.SetChapter("comick", 1, "ch-1", 1, []*testPage{{Index: 0}}))
+	require.NoError(t, s.SetPage("comick", 1, "ch-1", 0, page(10), "image/png"))
+	s.SetPinned(1, true)
+
+	s = newTestStore(t, dir, 1000)
+	assert.True(t, s.IsChapterAvailable("comick", "ch-1"))
+	assert.True(t, s.IsPinned(1))
+
+	data, contentType, found := s.GetPage("comick", "ch-1", 0)
+	require.True(t, found)
+	assert.Len(t, data, 10)
+	assert.Equal(t, "image/png", contentType)
+
+	// Shrinking the cache evicts unpinned pages only
+	s.SetPinned(1, false)
+	s.SetMaxSize(5)
+	assert.Equal(t, 0, s.GetStats().Pages)
+}
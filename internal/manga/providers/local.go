@@ -188,6 +188,7 @@ func (p *Local) FindChapters(mangaID string) (res []*hibikemanga.ChapterDetails,
 		if !ok {
 			continue
 		}
+		volume := getChapterVolume(mangaID, entry.RelativePath, scannedEntry)
 
 		if len(scannedEntry.Chapter) != 1 {
 			// Handle one-shots (no chapter number and only one entry)
@@ -202,6 +203,7 @@ func (p *Local) FindChapters(mangaID string) (res []*hibikemanga.ChapterDetails,
 					URL:        "",
 					Title:      chapterTitle,
 					Chapter:    "1",
+					Volume:     volume,
 					Index:      0, // placeholder, will be set later
 					LocalIsPDF: scannedEntry.IsPDF,
 				})
@@ -218,6 +220,7 @@ func (p *Local) FindChapters(mangaID string) (res []*hibikemanga.ChapterDetails,
 					Title:    chapterTitle,
 					// Use the last chapter number as the chapter for progress tracking
 					Chapter:    cleanChapter(scannedEntry.Chapter[1]),
+					Volume:     volume,
 					Index:      0, // placeholder, will be set later
 					LocalIsPDF: scannedEntry.IsPDF,
 				})
@@ -237,6 +240,7 @@ func (p *Local) FindChapters(mangaID string) (res []*hibikemanga.ChapterDetails,
 			URL:        "",
 			Title:      chapterTitle,
 			Chapter:    ch,
+			Volume:     volume,
 			Index:      0, // placeholder, will be set later
 			LocalIsPDF: scannedEntry.IsPDF,
 		})
@@ -380,6 +384,24 @@ func cleanChapter(ch string) string {
 	return ch
 }
 
+// getChapterVolume returns the volume of a chapter from its filename, or from the name of its parent folder.
+// e.g. "Series/Vol 1/Chapter 1.cbz" -> "1"
+func getChapterVolume(mangaID string, relativePath string, scannedEntry *ScannedChapterFile) string {
+	if len(scannedEntry.Volume) > 0 {
+		return cleanChapter(scannedEntry.Volume[0])
+	}
+
+	dir := filepath.Dir(relativePath)
+	if dir == "." || filepath.Clean(dir) == filepath.Clean(mangaID) {
+		return ""
+	}
+	scannedDir, ok := scanChapterFilename(filepath.Base(dir))
+	if !ok || len(scannedDir.Volume) == 0 {
+		return ""
+	}
+	return cleanChapter(scannedDir.Volume[0])
+}
+
 // FindChapterPages will extract the images
 func (p *Local) FindChapterPages(id string) (ret []*hibikemanga.ChapterPage, err error) {
 	if p.dir == "" {
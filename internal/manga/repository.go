@@ -7,10 +7,12 @@ import (
 	_ "image/jpeg" // Register JPEG format
 	_ "image/png"  // Register PNG format
 	"net/http"
+	"path/filepath"
 	"seanime/internal/database/db"
 	"seanime/internal/database/models"
 	"seanime/internal/events"
 	"seanime/internal/extension"
+	"seanime/internal/manga/pagecache"
 	"seanime/internal/util"
 	"seanime/internal/util/filecache"
 	"strconv"
@@ -43,6 +45,7 @@ type (
 		mu               sync.Mutex
 		downloadDir      string
 		db               *db.Database
+		pageCache        *pagecache.Store
 
 		settings *models.Settings
 	}
@@ -70,6 +73,12 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		extensionBankRef: opts.ExtensionBankRef,
 		db:               opts.Database,
 	}
+	if opts.CacheDir != "" {
+		r.pageCache = pagecache.NewStore(&pagecache.NewStoreOptions{
+			Logger: opts.Logger,
+			Dir:    filepath.Join(opts.CacheDir, "manga-pages"),
+		})
+	}
 	return r
 }
 
@@ -77,6 +86,9 @@ func (r *Repository) SetSettings(settings *models.Settings) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.settings = settings
+	if r.pageCache != nil && settings != nil && settings.Manga != nil {
+		r.pageCache.SetMaxSize(int64(settings.Manga.PageCacheSize) << 20)
+	}
 }
 
 func (r *Repository) RemoveProvider(id string) {
@@ -0,0 +1,171 @@
+package manga
+
+import (
+	"math"
+	"seanime/internal/api/anilist"
+	hibikemanga "seanime/internal/extension/hibike/manga"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type (
+	// GetVolumeProgressOptions are the options for GetVolumeProgress.
+	GetVolumeProgressOptions struct {
+		// Progress is the chapter progress sent by the client
+		Progress int
+		// ChapterId is the ID of the chapter that was read, it is required when the chapter numbering restarts every volume
+		ChapterId string
+		// Chapters are the chapters of the provider, they can be empty
+		Chapters []*hibikemanga.ChapterDetails
+		Media    *anilist.BaseManga
+	}
+
+	// VolumeProgress is the chapter and volume progress of a manga entry.
+	VolumeProgress struct {
+		// Progress is the chapter progress.
+		// It differs from the progress sent by the client if the chapter numbering of the provider restarts every volume.
+		Progress int `json:"progress"`
+		// ProgressVolumes is nil if the volume progress could not be computed, it should be left unchanged
+		ProgressVolumes *int `json:"progressVolumes,omitempty"`
+	}
+)
+
+// GetVolumeProgress computes the volume progress of a manga entry after reading up to a chapter.
+//
+// The volumes of the chapters given by the provider are used first, a volume is read when all of its chapters are read.
+// Otherwise, the volume progress is estimated from the number of chapters and volumes of the media.
+// No volume progress is returned for ongoing manga whose total number of volumes is unknown.
+func GetVolumeProgress(opts *GetVolumeProgressOptions) *VolumeProgress {
+	ret := &VolumeProgress{Progress: opts.Progress}
+
+	totalVolumes := 0
+	if opts.Media != nil && opts.Media.GetVolumes() != nil {
+		totalVolumes = *opts.Media.GetVolumes()
+	}
+
+	volumes, ok, hasVolumes := getProviderVolumeProgress(opts, ret)
+	// Only estimate if the provider does not give volumes, an ambiguous chapter leaves the volume progress unchanged
+	if !hasVolumes {
+		volumes, ok = estimateVolumeProgress(ret.Progress, opts.Media)
+	}
+	if !ok {
+		return ret
+	}
+
+	if totalVolumes > 0 && volumes > totalVolumes {
+		volumes = totalVolumes
+	}
+	ret.ProgressVolumes = &volumes
+	return ret
+}
+
+type volumeChapter struct {
+	chapter *hibikemanga.ChapterDetails
+	number  float64
+	volume  float64
+}
+
+// getProviderVolumeProgress returns the last volume of which all chapters are read, using the volumes given by the provider.
+// If the chapter numbering restarts every volume, the chapter progress is corrected to the number of chapters read.
+// hasVolumes is false if the provider does not give the volumes of the chapters.
+func getProviderVolumeProgress(opts *GetVolumeProgressOptions, ret *VolumeProgress) (volumes int, ok bool, hasVolumes bool) {
+	chapters := make([]*volumeChapter, 0, len(opts.Chapters))
+	for _, c := range opts.Chapters {
+		number, err := strconv.ParseFloat(strings.TrimSpace(c.Chapter), 64)
+		if err != nil {
+			continue
+		}
+		// Chapters that are not in a volume yet have a volume of -1
+		volume := -1.0
+		if v, err := strconv.ParseFloat(strings.TrimSpace(c.Volume), 64); err == nil {
+			volume = v
+			hasVolumes = true
+		}
+		chapters = append(chapters, &volumeChapter{chapter: c, number: number, volume: volume})
+	}
+	if !hasVolumes {
+		return 0, false, false
+	}
+
+	// Reading order
+	slices.SortStableFunc(chapters, func(a, b *volumeChapter) int {
+		return int(a.chapter.Index) - int(b.chapter.Index)
+	})
+
+	// The numbering restarts if a chapter of a later volume has a lower number than a chapter of a previous volume
+	restarts := false
+	maxNumber := math.Inf(-1)
+	prevVolume := math.Inf(-1)
+	for _, c := range chapters {
+		if c.volume < 0 {
+			continue
+		}
+		if c.volume > prevVolume && c.number < maxNumber {
+			restarts = true
+			break
+		}
+		prevVolume = c.volume
+		maxNumber = math.Max(maxNumber, c.number)
+	}
+
+	// isRead returns true if the chapter at position i is read
+	var isRead func(i int) bool
+	if restarts {
+		// The chapter number is ambiguous, the read chapter has to be identified by its ID
+		position := slices.IndexFunc(chapters, func(c *volumeChapter) bool {
+			return opts.ChapterId != "" && c.chapter.ID == opts.ChapterId
+		})
+		if position == -1 {
+			return 0, false, true
+		}
+		isRead = func(i int) bool {
+			return i <= position
+		}
+
+		// The chapter progress is the number of chapters read, duplicates from other scanlators or languages are counted once
+		read := make(map[[2]float64]struct{})
+		for i := 0; i <= position; i++ {
+			read[[2]float64{chapters[i].volume, chapters[i].number}] = struct{}{}
+		}
+		ret.Progress = len(read)
+	} else {
+		isRead = func(i int) bool {
+			return chapters[i].number <= float64(ret.Progress)
+		}
+	}
+
+	// A volume is read if all of its chapters are read
+	volumeRead := make(map[float64]bool)
+	for i, c := range chapters {
+		if c.volume < 0 {
+			continue
+		}
+		read, found := volumeRead[c.volume]
+		volumeRead[c.volume] = (read || !found) && isRead(i)
+	}
+
+	for volume, read := range volumeRead {
+		if read && int(volume) > volumes {
+			volumes = int(volume)
+		}
+	}
+	return volumes, true, true
+}
+
+// estimateVolumeProgress estimates the volume progress from the number of chapters and volumes of the media.
+func estimateVolumeProgress(progress int, media *anilist.BaseManga) (int, bool) {
+	if media == nil || media.GetVolumes() == nil || media.GetChapters() == nil {
+		return 0, false
+	}
+	totalVolumes := *media.GetVolumes()
+	totalChapters := *media.GetChapters()
+	if totalVolumes <= 0 || totalChapters <= 0 {
+		return 0, false
+	}
+
+	if progress >= totalChapters {
+		return totalVolumes, true
+	}
+	return progress * totalVolumes / totalChapters, true
+}
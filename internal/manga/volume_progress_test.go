@@ -0,0 +1,149 @@
+package manga
+
+import (
+	"seanime/internal/api/anilist"
+	hibikemanga "seanime/internal/extension/hibike/manga"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestChapters returns chapters in reading order, volumes[i] is the volume of the chapter number numbers[i]
+func newTestChapters(numbers []string, volumes []string) []*hibikemanga.ChapterDetails {
+	ret := make([]*hibikemanga.ChapterDetails, 0, len(numbers))
+	for i, number := range numbers {
+		ret = append(ret, &hibikemanga.ChapterDetails{
+			ID:      "ch-" + strconv.Itoa(i),
+			Chapter: number,
+			Volume:  volumes[i],
+			Index:   uint(i),
+		})
+	}
+	return ret
+}
+
+func newTestManga(chapters *int, volumes *int) *anilist.BaseManga {
+	return &anilist.BaseManga{ID: 1, Chapters: chapters, Volumes: volumes}
+}
+
+func TestGetVolumeProgress(t *testing.T) {
+	ptr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name                    string
+		opts                    *GetVolumeProgressOptions
+		expectedProgress        int
+		expectedProgressVolumes *int
+	}{
+		{
+			name: "provider volumes",
+			opts: &GetVolumeProgressOptions{
+				Progress: 5,
+				Chapters: newTestChapters(
+					[]string{"1", "2", "3", "4", "5", "6", "7"},
+					[]string{"1", "1", "1", "2", "2", "2", ""},
+				),
+				Media: newTestManga(nil, nil),
+			},
+			expectedProgress:        5,
+			expectedProgressVolumes: ptr(1),
+		},
+		{
+			name: "provider volumes, last chapter of a volume",
+			opts: &GetVolumeProgressOptions{
+				Progress: 7,
+				Chapters: newTestChapters(
+					[]string{"1", "2", "3", "4", "5", "6", "7", "8"},
+					[]string{"1", "1", "1", "2", "2", "2", "", ""},
+				),
+				Media: newTestManga(nil, nil),
+			},
+			// Chapters that are not in a volume yet do not count
+			expectedProgress:        7,
+			expectedProgressVolumes: ptr(2),
+		},
+		{
+			name: "numbering restarts every volume",
+			opts: &GetVolumeProgressOptions{
+				Progress:  2,
+				ChapterId: "ch-4",
+				Chapters: newTestChapters(
+					[]string{"1", "2", "3", "1", "2", "3", "1"},
+					[]string{"1", "1", "1", "2", "2", "2", "3"},
+				),
+				Media: newTestManga(nil, nil),
+			},
+			expectedProgress:        5,
+			expectedProgressVolumes: ptr(1),
+		},
+		{
+			name: "numbering restarts every volume, unknown chapter",
+			opts: &GetVolumeProgressOptions{
+				Progress: 2,
+				Chapters: newTestChapters(
+					[]string{"1", "2", "1", "2"},
+					[]string{"1", "1", "2", "2"},
+				),
+				Media: newTestManga(ptr(40), ptr(20)),
+			},
+			// The volume progress is left unchanged, an estimation would be wrong since the chapter progress is ambiguous
+			expectedProgress:        2,
+			expectedProgressVolumes: nil,
+		},
+		{
+			name: "estimated from the media",
+			opts: &GetVolumeProgressOptions{
+				Progress: 45,
+				Media:    newTestManga(ptr(100), ptr(10)),
+			},
+			expectedProgress:        45,
+			expectedProgressVolumes: ptr(4),
+		},
+		{
+			name: "estimated from the media, finished",
+			opts: &GetVolumeProgressOptions{
+				Progress: 120,
+				Media:    newTestManga(ptr(100), ptr(10)),
+			},
+			expectedProgress:        120,
+			expectedProgressVolumes: ptr(10),
+		},
+		{
+			name: "ongoing manga without volumes",
+			opts: &GetVolumeProgressOptions{
+				Progress: 45,
+				Media:    newTestManga(nil, nil),
+			},
+			expectedProgress:        45,
+			expectedProgressVolumes: nil,
+		},
+		{
+			name: "capped at the number of volumes",
+			opts: &GetVolumeProgressOptions{
+				Progress: 6,
+				Chapters: newTestChapters(
+					[]string{"1", "2", "3", "4", "5", "6"},
+					[]string{"1", "1", "2", "2", "3", "3"},
+				),
+				Media: newTestManga(ptr(6), ptr(2)),
+			},
+			expectedProgress:        6,
+			expectedProgressVolumes: ptr(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret := GetVolumeProgress(tt.opts)
+			assert.Equal(t, tt.expectedProgress, ret.Progress)
+			if tt.expectedProgressVolumes == nil {
+				assert.Nil(t, ret.ProgressVolumes)
+				return
+			}
+			require.NotNil(t, ret.ProgressVolumes)
+			assert.Equal(t, *tt.expectedProgressVolumes, *ret.ProgressVolumes)
+		})
+	}
+}
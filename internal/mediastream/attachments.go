@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"seanime/internal/events"
 	"seanime/internal/mediastream/videofile"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -31,6 +32,10 @@ func (r *Repository) ServeEchoExtractedSubtitles(c echo.Context) error {
 		return errors.New("no file has been loaded")
 	}
 
+	if strings.HasPrefix(subFilePath, externalPrefix) {
+		return r.serveExternalAttachment(c, mediaContainer, subFilePath)
+	}
+
 	retPath := videofile.GetFileSubsCacheDir(r.cacheDir, mediaContainer.Hash)
 
 	if retPath == "" {
@@ -62,13 +67,17 @@ func (r *Repository) ServeEchoExtractedAttachments(c echo.Context) error {
 		return errors.New("no file has been loaded")
 	}
 
+	subFilePath, _ = url.PathUnescape(subFilePath)
+
+	if strings.HasPrefix(subFilePath, externalPrefix) {
+		return r.serveExternalAttachment(c, mediaContainer, subFilePath)
+	}
+
 	retPath := videofile.GetFileAttCacheDir(r.cacheDir, mediaContainer.Hash)
 
 	if retPath == "" {
 		return errors.New("could not find subtitles")
 	}
 
-	subFilePath, _ = url.PathUnescape(subFilePath)
-
 	return c.File(filepath.Join(retPath, subFilePath))
 }
@@ -0,0 +1,42 @@
+package mediastream
+
+import (
+	"fmt"
+	"path/filepath"
+	"seanime/internal/events"
+	"seanime/internal/mediastream/videofile"
+	torrent_audio "seanime/internal/torrents/audio"
+
+	"github.com/5rahim/habari"
+)
+
+// validateAudio checks the audio tracks probed by ffprobe against the audio the file name advertises.
+// A warning is sent to the client if a file labeled as dubbed or dual audio does not have the expected tracks.
+func (p *PlaybackManager) validateAudio(path string, mediaInfo *videofile.MediaInfo) {
+	if mediaInfo == nil {
+		return
+	}
+
+	name := filepath.Base(path)
+	kind := torrent_audio.Detect(name, habari.Parse(name))
+	if kind == torrent_audio.KindSub {
+		return
+	}
+
+	languages := make([]string, 0, len(mediaInfo.Audios))
+	for _, audio := range mediaInfo.Audios {
+		if audio.Language != nil {
+			languages = append(languages, *audio.Language)
+		} else {
+			languages = append(languages, "")
+		}
+	}
+
+	ok, reason := torrent_audio.ValidateLanguages(kind, languages)
+	if ok {
+		return
+	}
+
+	p.logger.Warn().Str("file", name).Strs("languages", languages).Msgf("mediastream: Audio mismatch, %s", reason)
+	p.repository.wsEventManager.SendEvent(events.WarningToast, fmt.Sprintf("Audio mismatch: %s", reason))
+}
@@ -0,0 +1,114 @@
+package mediastream
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"seanime/internal/events"
+	"seanime/internal/library/sidecar"
+	"seanime/internal/mediastream/videofile"
+	"seanime/internal/util"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// externalPrefix is the prefix of the links and font names of the attachments uploaded for a file.
+// e.g. "/external/12.srt" and "external/13.ttf"
+const externalPrefix = "external/"
+
+// withExternalAttachments returns a copy of the media info with the subtitles and fonts uploaded for the file.
+// The previous external attachments are replaced.
+func (r *Repository) withExternalAttachments(filePath string, mi *videofile.MediaInfo) *videofile.MediaInfo {
+	if mi == nil {
+		return nil
+	}
+
+	ret := *mi
+	ret.Subtitles = lo.Filter(mi.Subtitles, func(s videofile.Subtitle, _ int) bool {
+		return s.Link == nil || !strings.HasPrefix(*s.Link, "/"+externalPrefix)
+	})
+	ret.Fonts = lo.Filter(mi.Fonts, func(f string, _ int) bool {
+		return !strings.HasPrefix(f, externalPrefix)
+	})
+
+	if r.sidecarStore == nil {
+		return &ret
+	}
+
+	// Index the external subtitles after the embedded ones
+	var index uint32
+	for _, s := range ret.Subtitles {
+		index = max(index, s.Index+1)
+	}
+
+	for _, a := range r.sidecarStore.GetByLocalFile(filePath) {
+		name := fmt.Sprintf("%s%d%s", externalPrefix, a.ID, a.Extension)
+		switch a.Kind {
+		case sidecar.KindSubtitle:
+			ret.Subtitles = append(ret.Subtitles, videofile.Subtitle{
+				Index:      index,
+				Title:      lo.ToPtr(a.DisplayName()),
+				Language:   lo.EmptyableToPtr(a.Language),
+				Codec:      strings.TrimPrefix(a.Extension, "."),
+				Extension:  lo.ToPtr(strings.TrimPrefix(a.Extension, ".")),
+				IsExternal: true,
+				Link:       lo.ToPtr("/" + name),
+			})
+			index++
+		case sidecar.KindFont:
+			ret.Fonts = append(ret.Fonts, name)
+		}
+	}
+
+	ret.Subtitles = slices.Clip(ret.Subtitles)
+	ret.Fonts = slices.Clip(ret.Fonts)
+
+	return &ret
+}
+
+// RefreshExternalAttachments updates the media containers of the file after an attachment has been added or removed.
+// The client is notified if the file is currently being played.
+func (r *Repository) RefreshExternalAttachments(filePath string) {
+	if r.playbackManager == nil {
+		return
+	}
+
+	r.playbackManager.mediaContainers.Range(func(hash string, mc *MediaContainer) bool {
+		if util.NormalizePath(mc.Filepath) == util.NormalizePath(filePath) {
+			mc.MediaInfo = r.withExternalAttachments(mc.Filepath, mc.MediaInfo)
+		}
+		return true
+	})
+
+	mc, found := r.playbackManager.currentMediaContainer.Get()
+	if !found || util.NormalizePath(mc.Filepath) != util.NormalizePath(filePath) {
+		return
+	}
+	mc.MediaInfo = r.withExternalAttachments(mc.Filepath, mc.MediaInfo)
+
+	r.wsEventManager.SendEvent(events.MediastreamMediaContainerUpdated, mc)
+}
+
+// serveExternalAttachment serves a subtitle or font uploaded for the current file.
+func (r *Repository) serveExternalAttachment(c echo.Context, mc *MediaContainer, name string) error {
+	if r.sidecarStore == nil {
+		return errors.New("attachment not found")
+	}
+
+	name = strings.TrimPrefix(name, externalPrefix)
+	id, err := strconv.ParseUint(strings.TrimSuffix(name, filepath.Ext(name)), 10, 64)
+	if err != nil {
+		return errors.New("invalid attachment")
+	}
+
+	a, err := r.sidecarStore.Get(uint(id))
+	if err != nil || util.NormalizePath(a.LocalFilePath) != util.NormalizePath(mc.Filepath) {
+		return errors.New("attachment not found")
+	}
+
+	return c.File(a.Path)
+}
@@ -134,6 +134,9 @@ func (p *PlaybackManager) newMediaContainer(filepath string, streamType StreamTy
 		return nil, err
 	}
 
+	// Make sure the file has the audio its name advertises
+	p.validateAudio(filepath, ret.MediaInfo)
+
 	p.logger.Debug().Msg("mediastream: Extracted media info, extracting attachments")
 
 	// Extract the attachments from the file.
@@ -145,6 +148,9 @@ func (p *PlaybackManager) newMediaContainer(filepath string, streamType StreamTy
 
 	p.logger.Debug().Msg("mediastream: Extracted attachments")
 
+	// Add the subtitles and fonts uploaded for the file
+	ret.MediaInfo = p.repository.withExternalAttachments(filepath, ret.MediaInfo)
+
 	streamUrl := ""
 	switch streamType {
 	case StreamTypeDirect:
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"seanime/internal/database/models"
 	"seanime/internal/events"
+	"seanime/internal/library/sidecar"
 	"seanime/internal/mediastream/optimizer"
 	"seanime/internal/mediastream/transcoder"
 	"seanime/internal/mediastream/videofile"
@@ -26,6 +27,7 @@ type (
 		logger             *zerolog.Logger
 		wsEventManager     events.WSEventManagerInterface
 		fileCacher         *filecache.Cacher
+		sidecarStore       *sidecar.Store // optional, attachments uploaded for local files
 		reqMu              sync.Mutex
 		cacheDir           string // where attachments are stored
 		transcodeDir       string // where stream segments are stored
@@ -35,6 +37,7 @@ type (
 		Logger         *zerolog.Logger
 		WSEventManager events.WSEventManagerInterface
 		FileCacher     *filecache.Cacher
+		SidecarStore   *sidecar.Store
 	}
 )
 
@@ -49,6 +52,7 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		transcoder:         mo.None[*transcoder.Transcoder](),
 		wsEventManager:     opts.WSEventManager,
 		fileCacher:         opts.FileCacher,
+		sidecarStore:       opts.SidecarStore,
 		mediaInfoExtractor: videofile.NewMediaInfoExtractor(opts.FileCacher, opts.Logger),
 	}
 	ret.playbackManager = NewPlaybackManager(ret)
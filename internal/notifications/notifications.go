@@ -0,0 +1,102 @@
+package notifications
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// TypeRuleMatched is sent when an AutoDownloader rule matched a torrent that was not added to the torrent client.
+	TypeRuleMatched Type = "rule_matched"
+	// TypeDownloadStarted is sent when a torrent has been added to the torrent client or the debrid service.
+	TypeDownloadStarted Type = "download_started"
+	// TypeDownloadFailed is sent when a torrent could not be added.
+	TypeDownloadFailed Type = "download_failed"
+	// TypeMediaRemapped is sent when the local records of a media were moved to the media AniList merged it into.
+	TypeMediaRemapped Type = "media_remapped"
+	// TypeMediaVanished is sent when a media of the library no longer exists on AniList.
+	TypeMediaVanished Type = "media_vanished"
+	// TypeBatchTorrentUpdated is sent when the files of new episodes were selected in a watched batch torrent.
+	TypeBatchTorrentUpdated Type = "batch_torrent_updated"
+	// TypeLibraryPathUnavailable is sent when a scan skipped a library path that looked unavailable.
+	TypeLibraryPathUnavailable Type = "library_path_unavailable"
+	// TypeUnverifiedFiles is sent when files kept while their library path was unavailable have not been found for too long.
+	TypeUnverifiedFiles Type = "unverified_files"
+	// TypeEpisodeOverdue is sent when an episode has not been released after the time its release is usually available.
+	TypeEpisodeOverdue Type = "episode_overdue"
+
+	// notificationsKept is the number of notifications kept in the database
+	notificationsKept = 500
+)
+
+type (
+	Type string
+
+	// Manager records the notifications shown in the notification center of the app.
+	// New notifications are pushed to the clients.
+	Manager struct {
+		logger         *zerolog.Logger
+		database       *db.Database
+		wsEventManager events.WSEventManagerInterface
+	}
+
+	NewManagerOptions struct {
+		Logger         *zerolog.Logger
+		Database       *db.Database
+		WSEventManager events.WSEventManagerInterface
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		logger:         opts.Logger,
+		database:       opts.Database,
+		wsEventManager: opts.WSEventManager,
+	}
+}
+
+// Notify records a notification and sends it to the clients.
+// mediaId can be 0 if the notification is not related to a media.
+func (m *Manager) Notify(t Type, message string, mediaId int) {
+	if m == nil || m.database == nil {
+		return
+	}
+
+	notification := &models.Notification{
+		Type:    string(t),
+		Message: message,
+		MediaId: mediaId,
+	}
+	if err := m.database.InsertNotification(notification, notificationsKept); err != nil {
+		m.logger.Error().Err(err).Str("type", string(t)).Msg("notifications: Failed to save notification")
+		return
+	}
+
+	m.wsEventManager.SendEvent(events.NotificationNew, notification)
+}
+
+// GetNotifications returns the notifications, most recent first.
+func (m *Manager) GetNotifications(unreadOnly bool) ([]*models.Notification, error) {
+	return m.database.GetNotifications(unreadOnly)
+}
+
+// MarkAsRead marks a notification as read and returns it.
+// Notifications that have already been read keep their read time.
+func (m *Manager) MarkAsRead(id uint) (*models.Notification, error) {
+	if _, err := m.database.GetNotification(id); err != nil {
+		return nil, err
+	}
+	if err := m.database.MarkNotificationAsRead(id, time.Now()); err != nil {
+		return nil, err
+	}
+	return m.database.GetNotification(id)
+}
+
+// DeleteRead deletes the notifications that have been read and returns their number.
+func (m *Manager) DeleteRead() (int64, error) {
+	return m.database.DeleteReadNotifications()
+}
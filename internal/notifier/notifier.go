@@ -25,6 +25,11 @@ const (
 	AutoDownloader Notification = "Auto Downloader"
 	AutoScanner    Notification = "Auto Scanner"
 	Debrid         Notification = "Debrid"
+	Seeding        Notification = "Seeding"
+	LibraryCleanup Notification = "Library Cleanup"
+	Maintenance    Notification = "Maintenance"
+	NetworkBinding Notification = "Network Binding"
+	Webhooks       Notification = "Webhooks"
 )
 
 var GlobalNotifier = NewNotifier()
@@ -18,25 +18,32 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	"github.com/samber/mo"
+	"golang.org/x/sync/singleflight"
 )
 
 type (
 	AnilistPlatform struct {
-		logger                 *zerolog.Logger
-		username               mo.Option[string]
-		anilistClient          anilist.AnilistClient
-		animeCollection        mo.Option[*anilist.AnimeCollection]
-		rawAnimeCollection     mo.Option[*anilist.AnimeCollection]
-		mangaCollection        mo.Option[*anilist.MangaCollection]
-		rawMangaCollection     mo.Option[*anilist.MangaCollection]
-		isOffline              bool
-		offlinePlatformEnabled bool
-		helper                 *shared_platform.PlatformHelper
-		db                     *db.Database
-		extensionBankRef       *util.Ref[*extension.UnifiedBank]
+		logger                   *zerolog.Logger
+		username                 mo.Option[string]
+		anilistClient            anilist.AnilistClient
+		animeCollection          mo.Option[*anilist.AnimeCollection]
+		animeCollectionFetchedAt time.Time
+		rawAnimeCollection       mo.Option[*anilist.AnimeCollection]
+		mangaCollection          mo.Option[*anilist.MangaCollection]
+		rawMangaCollection       mo.Option[*anilist.MangaCollection]
+		isOffline                bool
+		offlinePlatformEnabled   bool
+		helper                   *shared_platform.PlatformHelper
+		db                       *db.Database
+		extensionBankRef         *util.Ref[*extension.UnifiedBank]
+		// refreshGroup deduplicates concurrent fetches of the collections
+		refreshGroup singleflight.Group
+		cacheHealth  *collectionCacheHealth
 	}
 )
 
+const animeCollectionRefreshKey = "anime-collection"
+
 func NewAnilistPlatform(anilistClientRef *util.Ref[anilist.AnilistClient], extensionBankRef *util.Ref[*extension.UnifiedBank], logger *zerolog.Logger, db *db.Database) platform.Platform {
 	ap := &AnilistPlatform{
 		anilistClient:      shared_platform.NewCacheLayer(anilistClientRef),
@@ -49,6 +56,7 @@ func NewAnilistPlatform(anilistClientRef *util.Ref[anilist.AnilistClient], exten
 		extensionBankRef:   extensionBankRef,
 		helper:             shared_platform.NewPlatformHelper(extensionBankRef, db, logger),
 		db:                 db,
+		cacheHealth:        newCollectionCacheHealth(time.Now),
 	}
 
 	return ap
@@ -95,8 +103,19 @@ func (ap *AnilistPlatform) UpdateEntry(ctx context.Context, mediaID int, status
 func (ap *AnilistPlatform) UpdateEntryProgress(ctx context.Context, mediaID int, progress int, totalCount *int) error {
 	ap.logger.Trace().Msg("anilist platform: Updating entry progress")
 
+	return ap.updateEntryProgress(ctx, mediaID, progress, nil, totalCount)
+}
+
+func (ap *AnilistPlatform) UpdateMangaEntryProgress(ctx context.Context, mediaID int, progress int, progressVolumes *int, totalChapters *int) error {
+	ap.logger.Trace().Msg("anilist platform: Updating manga entry progress")
+
+	return ap.updateEntryProgress(ctx, mediaID, progress, progressVolumes, totalChapters)
+}
+
+// updateEntryProgress updates the progress of an entry, the volume progress is only written if progressVolumes is not nil.
+func (ap *AnilistPlatform) updateEntryProgress(ctx context.Context, mediaID int, progress int, progressVolumes *int, totalCount *int) error {
 	// Use shared hook handling
-	return ap.helper.TriggerUpdateEntryProgressHooks(ctx, mediaID, progress, totalCount, func(event *platform.PreUpdateEntryProgressEvent) error {
+	return ap.helper.TriggerUpdateEntryProgressHooks(ctx, mediaID, progress, progressVolumes, totalCount, func(event *platform.PreUpdateEntryProgressEvent) error {
 		// Check if this is a custom source entry (after hooks have been triggered)
 		if handled, err := ap.helper.HandleCustomSourceUpdateEntryProgress(ctx, mediaID, *event.Progress, event.TotalCount); handled {
 			return err
@@ -107,21 +126,10 @@ func (ap *AnilistPlatform) UpdateEntryProgress(ctx context.Context, mediaID int,
 			realTotalCount = *totalCount
 		}
 
-		// Check if the anime is in the repeating list
+		// Check if the media is in the repeating list
 		// If it is, set the status to repeating
-		if ap.rawAnimeCollection.IsPresent() {
-			for _, list := range ap.rawAnimeCollection.MustGet().MediaListCollection.Lists {
-				if list.Status != nil && *list.Status == anilist.MediaListStatusRepeating {
-					if list.Entries != nil {
-						for _, entry := range list.Entries {
-							if entry.GetMedia().GetID() == mediaID {
-								*event.Status = anilist.MediaListStatusRepeating
-								break
-							}
-						}
-					}
-				}
-			}
+		if ap.isRepeating(mediaID) {
+			*event.Status = anilist.MediaListStatusRepeating
 		}
 		if realTotalCount > 0 && *event.Progress >= realTotalCount {
 			*event.Status = anilist.MediaListStatusCompleted
@@ -131,16 +139,61 @@ func (ap *AnilistPlatform) UpdateEntryProgress(ctx context.Context, mediaID int,
 			*event.Progress = realTotalCount
 		}
 
+		if event.ProgressVolumes != nil {
+			_, err := ap.anilistClient.UpdateMangaListEntryProgress(
+				ctx,
+				event.MediaID,
+				event.Progress,
+				event.ProgressVolumes,
+				event.Status,
+			)
+			return err
+		}
+
 		_, err := ap.anilistClient.UpdateMediaListEntryProgress(
 			ctx,
 			event.MediaID,
 			event.Progress,
 			event.Status,
 		)
-		return err
+		if err != nil {
+			return err
+		}
+
+		ap.ReconcileAnimeEntryProgress(*event.MediaID, *event.Progress, event.Status)
+		return nil
 	})
 }
 
+// isRepeating returns true if the media is in the repeating list of the anime or manga collection.
+func (ap *AnilistPlatform) isRepeating(mediaID int) bool {
+	if ap.rawAnimeCollection.IsPresent() {
+		for _, list := range ap.rawAnimeCollection.MustGet().MediaListCollection.Lists {
+			if list.Status == nil || *list.Status != anilist.MediaListStatusRepeating {
+				continue
+			}
+			for _, entry := range list.Entries {
+				if entry.GetMedia().GetID() == mediaID {
+					return true
+				}
+			}
+		}
+	}
+	if ap.rawMangaCollection.IsPresent() {
+		for _, list := range ap.rawMangaCollection.MustGet().MediaListCollection.Lists {
+			if list.Status == nil || *list.Status != anilist.MediaListStatusRepeating {
+				continue
+			}
+			for _, entry := range list.Entries {
+				if entry.GetMedia().GetID() == mediaID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (ap *AnilistPlatform) UpdateEntryRepeat(ctx context.Context, mediaID int, repeat int) error {
 	ap.logger.Trace().Msg("anilist platform: Updating entry repeat")
 
@@ -326,8 +379,8 @@ func (ap *AnilistPlatform) GetMangaDetails(ctx context.Context, mediaID int) (*a
 	return ret.GetMedia(), nil
 }
 
-func (ap *AnilistPlatform) GetAnimeCollection(ctx context.Context, bypassCache bool) (*anilist.AnimeCollection, error) {
-	if !bypassCache && ap.animeCollection.IsPresent() {
+func (ap *AnilistPlatform) GetAnimeCollection(ctx context.Context, maxStaleness time.Duration) (*anilist.AnimeCollection, error) {
+	if ap.animeCollection.IsPresent() && platform.IsFresh(ap.animeCollectionFetchedAt, maxStaleness) {
 		event := new(platform.GetCachedAnimeCollectionEvent)
 		event.AnimeCollection = ap.animeCollection.MustGet()
 		err := hook.GlobalHookManager.OnGetCachedAnimeCollection().Trigger(event)
@@ -417,17 +470,34 @@ func (ap *AnilistPlatform) RefreshAnimeCollection(ctx context.Context) (*anilist
 	return event.AnimeCollection, nil
 }
 
+// refreshAnimeCollection fetches the anime collection from AniList.
+// Concurrent calls wait for the fetch in progress and share its result instead of querying AniList again.
 func (ap *AnilistPlatform) refreshAnimeCollection(ctx context.Context) error {
 	if ap.username.IsAbsent() {
 		return errors.New("anilist: Username is not set")
 	}
 
-	// Else, get the collection from Anilist
+	// The fetch is shared, it should not be canceled when the caller that started it goes away
+	ctx = context.WithoutCancel(ctx)
+
+	_, err, shared := ap.refreshGroup.Do(animeCollectionRefreshKey, func() (interface{}, error) {
+		return nil, ap.fetchAnimeCollection(ctx)
+	})
+	if shared {
+		ap.logger.Trace().Msg("anilist platform: Shared anime collection fetch")
+	}
+	return err
+}
+
+func (ap *AnilistPlatform) fetchAnimeCollection(ctx context.Context) error {
+	// Get the collection from Anilist
 	collection, err := ap.anilistClient.AnimeCollection(ctx, ap.username.ToPointer())
 	if err != nil {
 		return err
 	}
 
+	ap.cacheHealth.recordFetch(collection)
+
 	// Merge the custom entries into the collection
 	ap.helper.MergeCustomSourceAnimeEntries(collection)
 
@@ -445,6 +515,7 @@ func (ap *AnilistPlatform) refreshAnimeCollection(ctx context.Context) error {
 
 	// Save the collection to App
 	ap.animeCollection = mo.Some(collection)
+	ap.animeCollectionFetchedAt = time.Now()
 
 	return nil
 }
@@ -678,7 +749,7 @@ func (ap *AnilistPlatform) GetAnimeAiringSchedule(ctx context.Context) (*anilist
 		return nil, errors.New("anilist: Username is not set")
 	}
 
-	collection, err := ap.GetAnimeCollection(ctx, false)
+	collection, err := ap.GetAnimeCollection(ctx, platform.CachedCollection)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,141 @@
+package anilist_platform
+
+import (
+	"context"
+	"seanime/internal/api/anilist"
+	"seanime/internal/platforms/platform"
+	"sync"
+	"time"
+)
+
+var _ platform.CacheValidator = (*AnilistPlatform)(nil)
+
+// cacheIncoherenceThreshold is how much more recent than the cached collection the latest update on AniList can be
+// before the cached collection is considered out of date.
+const cacheIncoherenceThreshold = time.Minute
+
+// collectionCacheHealth tracks whether the cached anime collection is coherent with AniList.
+type collectionCacheHealth struct {
+	mu  sync.Mutex
+	now func() time.Time
+	// fetchedAt is the time the cached collection was fetched
+	fetchedAt time.Time
+	// latestUpdatedAt is the most recent update time of the entries of the cached collection
+	latestUpdatedAt   time.Time
+	lastValidatedAt   time.Time
+	lastIncoherenceAt time.Time
+	incoherenceCount  int
+}
+
+func newCollectionCacheHealth(now func() time.Time) *collectionCacheHealth {
+	return &collectionCacheHealth{now: now}
+}
+
+// recordFetch records the update time of the most recently modified entry of a fetched collection.
+func (h *collectionCacheHealth) recordFetch(collection *anilist.AnimeCollection) {
+	latest := int64(0)
+	for _, list := range collection.GetMediaListCollection().GetLists() {
+		for _, entry := range list.GetEntries() {
+			if entry.GetUpdatedAt() != nil && int64(*entry.GetUpdatedAt()) > latest {
+				latest = int64(*entry.GetUpdatedAt())
+			}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fetchedAt = h.now()
+	h.latestUpdatedAt = time.Time{}
+	if latest > 0 {
+		h.latestUpdatedAt = time.Unix(latest, 0)
+	}
+}
+
+// check compares the latest update on AniList with the cached collection.
+// It returns how much more recent the latest update is and whether it exceeds the threshold.
+func (h *collectionCacheHealth) check(remoteUpdatedAt time.Time) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	h.lastValidatedAt = now
+
+	// Nothing is cached or the list is empty on AniList
+	if h.fetchedAt.IsZero() || remoteUpdatedAt.IsZero() {
+		return 0, false
+	}
+
+	divergence := remoteUpdatedAt.Sub(h.latestUpdatedAt)
+	if divergence <= cacheIncoherenceThreshold {
+		return divergence, false
+	}
+
+	h.lastIncoherenceAt = now
+	h.incoherenceCount++
+	return divergence, true
+}
+
+func (h *collectionCacheHealth) snapshot() *platform.CacheHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ret := &platform.CacheHealth{
+		IncoherenceCount: h.incoherenceCount,
+	}
+	timePtr := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	ret.FetchedAt = timePtr(h.fetchedAt)
+	ret.LatestEntryUpdatedAt = timePtr(h.latestUpdatedAt)
+	ret.LastValidatedAt = timePtr(h.lastValidatedAt)
+	ret.LastIncoherenceAt = timePtr(h.lastIncoherenceAt)
+	if !h.fetchedAt.IsZero() {
+		ret.AgeSeconds = h.now().Sub(h.fetchedAt).Seconds()
+	}
+	return ret
+}
+
+// ValidateAnimeCollectionCache compares the cached anime collection with the most recently updated entry of the anime list on AniList.
+// It returns true if an entry was updated on AniList after the cached collection, e.g. from the website.
+func (ap *AnilistPlatform) ValidateAnimeCollectionCache(ctx context.Context) (bool, error) {
+	if ap.username.IsAbsent() || ap.animeCollection.IsAbsent() {
+		return false, nil
+	}
+
+	res, err := ap.anilistClient.AnimeListLatestUpdate(ctx, ap.username.ToPointer())
+	if err != nil {
+		return false, err
+	}
+
+	var latest *anilist.AnimeListLatestUpdate_Page_MediaList
+	if mediaList := res.GetPage().GetMediaList(); len(mediaList) > 0 {
+		latest = mediaList[0]
+	}
+	remoteUpdatedAt := time.Time{}
+	if latest.GetUpdatedAt() != nil && *latest.GetUpdatedAt() > 0 {
+		remoteUpdatedAt = time.Unix(int64(*latest.GetUpdatedAt()), 0)
+	}
+
+	divergence, incoherent := ap.cacheHealth.check(remoteUpdatedAt)
+	if incoherent {
+		health := ap.cacheHealth.snapshot()
+		ap.logger.Warn().
+			Int("mediaId", latest.GetMediaID()).
+			Time("remoteUpdatedAt", remoteUpdatedAt).
+			Any("cachedUpdatedAt", health.LatestEntryUpdatedAt).
+			Any("fetchedAt", health.FetchedAt).
+			Dur("divergence", divergence).
+			Int("incoherenceCount", health.IncoherenceCount).
+			Msg("anilist platform: Cached anime collection is incoherent with AniList")
+	}
+
+	return incoherent, nil
+}
+
+// GetAnimeCollectionCacheHealth returns the age of the cached anime collection and the results of its validation.
+func (ap *AnilistPlatform) GetAnimeCollectionCacheHealth() *platform.CacheHealth {
+	return ap.cacheHealth.snapshot()
+}
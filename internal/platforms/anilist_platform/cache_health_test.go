@@ -0,0 +1,123 @@
+package anilist_platform
+
+import (
+	"context"
+	"seanime/internal/api/anilist"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/samber/mo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLatestUpdateClient only implements AnimeListLatestUpdate
+type fakeLatestUpdateClient struct {
+	anilist.AnilistClient
+	updatedAt *int
+	calls     int
+}
+
+func (c *fakeLatestUpdateClient) AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*anilist.AnimeListLatestUpdate, error) {
+	c.calls++
+	ret := &anilist.AnimeListLatestUpdate{Page: &anilist.AnimeListLatestUpdate_Page{}}
+	if c.updatedAt != nil {
+		ret.Page.MediaList = []*anilist.AnimeListLatestUpdate_Page_MediaList{{ID: 1, MediaID: 21, UpdatedAt: c.updatedAt}}
+	}
+	return ret, nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestAnimeCollection(updatedAt ...int) *anilist.AnimeCollection {
+	entries := make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0, len(updatedAt))
+	for i := range updatedAt {
+		entries = append(entries, &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			ID:        i + 1,
+			UpdatedAt: &updatedAt[i],
+			Media:     &anilist.BaseAnime{ID: i + 1},
+		})
+	}
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{Entries: entries}},
+		},
+	}
+}
+
+func TestValidateAnimeCollectionCache(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	client := &fakeLatestUpdateClient{}
+
+	ap := &AnilistPlatform{
+		logger:          util.NewLogger(),
+		username:        mo.Some("user"),
+		anilistClient:   client,
+		animeCollection: mo.None[*anilist.AnimeCollection](),
+		cacheHealth:     newCollectionCacheHealth(clock.Now),
+	}
+
+	// Nothing is cached yet
+	incoherent, err := ap.ValidateAnimeCollectionCache(context.Background())
+	require.NoError(t, err)
+	assert.False(t, incoherent)
+	assert.Zero(t, client.calls)
+
+	collection := newTestAnimeCollection(1_699_990_000, 1_699_999_000)
+	ap.animeCollection = mo.Some(collection)
+	ap.cacheHealth.recordFetch(collection)
+
+	// The latest update on AniList is in the cached collection
+	clock.now = clock.now.Add(10 * time.Minute)
+	client.updatedAt = new(int)
+	*client.updatedAt = 1_699_999_000
+	incoherent, err = ap.ValidateAnimeCollectionCache(context.Background())
+	require.NoError(t, err)
+	assert.False(t, incoherent)
+
+	health := ap.GetAnimeCollectionCacheHealth()
+	require.NotNil(t, health.FetchedAt)
+	require.NotNil(t, health.LastValidatedAt)
+	require.NotNil(t, health.LatestEntryUpdatedAt)
+	assert.Equal(t, int64(1_699_999_000), health.LatestEntryUpdatedAt.Unix())
+	assert.Equal(t, clock.now, *health.LastValidatedAt)
+	assert.InDelta(t, 600, health.AgeSeconds, 0.001)
+	assert.Nil(t, health.LastIncoherenceAt)
+
+	// An entry was updated on the website, within the threshold
+	*client.updatedAt = 1_699_999_030
+	incoherent, err = ap.ValidateAnimeCollectionCache(context.Background())
+	require.NoError(t, err)
+	assert.False(t, incoherent)
+
+	// Beyond the threshold
+	clock.now = clock.now.Add(10 * time.Minute)
+	*client.updatedAt = 1_700_000_500
+	incoherent, err = ap.ValidateAnimeCollectionCache(context.Background())
+	require.NoError(t, err)
+	assert.True(t, incoherent)
+
+	health = ap.GetAnimeCollectionCacheHealth()
+	require.NotNil(t, health.LastIncoherenceAt)
+	assert.Equal(t, clock.now, *health.LastIncoherenceAt)
+	assert.Equal(t, 1, health.IncoherenceCount)
+
+	// The refreshed collection contains the update
+	clock.now = clock.now.Add(time.Minute)
+	collection = newTestAnimeCollection(1_699_990_000, 1_700_000_500)
+	ap.animeCollection = mo.Some(collection)
+	ap.cacheHealth.recordFetch(collection)
+
+	incoherent, err = ap.ValidateAnimeCollectionCache(context.Background())
+	require.NoError(t, err)
+	assert.False(t, incoherent)
+	assert.Zero(t, ap.GetAnimeCollectionCacheHealth().AgeSeconds)
+}
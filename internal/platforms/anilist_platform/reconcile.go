@@ -0,0 +1,46 @@
+package anilist_platform
+
+import (
+	"seanime/internal/api/anilist"
+	"time"
+)
+
+// ReconcileAnimeEntryProgress updates the entry of the cached anime collection with the progress and status saved on AniList.
+// The entry is moved to the list of its new status.
+func (ap *AnilistPlatform) ReconcileAnimeEntryProgress(mediaID int, progress int, status *anilist.MediaListStatus) bool {
+	if ap.animeCollection.IsAbsent() {
+		return false
+	}
+	return reconcileAnimeEntry(ap.animeCollection.MustGet(), mediaID, progress, status, time.Now())
+}
+
+func reconcileAnimeEntry(collection *anilist.AnimeCollection, mediaID int, progress int, status *anilist.MediaListStatus, now time.Time) bool {
+	lists := collection.GetMediaListCollection().GetLists()
+	for _, list := range lists {
+		for i, entry := range list.GetEntries() {
+			if entry.GetMedia().GetID() != mediaID {
+				continue
+			}
+
+			entry.Progress = &progress
+			updatedAt := int(now.Unix())
+			entry.UpdatedAt = &updatedAt
+
+			if status == nil || (entry.Status != nil && *entry.Status == *status) {
+				return true
+			}
+			newStatus := *status
+			entry.Status = &newStatus
+
+			for _, target := range lists {
+				if target != list && target.Status != nil && *target.Status == newStatus {
+					list.Entries = append(list.Entries[:i:i], list.Entries[i+1:]...)
+					target.Entries = append(target.Entries, entry)
+					break
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
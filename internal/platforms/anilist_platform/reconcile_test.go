@@ -0,0 +1,49 @@
+package anilist_platform
+
+import (
+	"seanime/internal/api/anilist"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileAnimeEntry(t *testing.T) {
+	now := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+	collection := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{
+				{
+					Status: lo.ToPtr(anilist.MediaListStatusCurrent),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+						{ID: 1, Progress: lo.ToPtr(3), Status: lo.ToPtr(anilist.MediaListStatusCurrent), Media: &anilist.BaseAnime{ID: 10}},
+						{ID: 2, Progress: lo.ToPtr(11), Status: lo.ToPtr(anilist.MediaListStatusCurrent), Media: &anilist.BaseAnime{ID: 20}},
+					},
+				},
+				{
+					Status:  lo.ToPtr(anilist.MediaListStatusCompleted),
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{},
+				},
+			},
+		},
+	}
+	current, completed := collection.MediaListCollection.Lists[0], collection.MediaListCollection.Lists[1]
+
+	// Same status
+	require.True(t, reconcileAnimeEntry(collection, 10, 4, lo.ToPtr(anilist.MediaListStatusCurrent), now))
+	assert.Equal(t, 4, *current.Entries[0].Progress)
+	assert.Equal(t, int(now.Unix()), *current.Entries[0].UpdatedAt)
+	assert.Len(t, current.Entries, 2)
+
+	// The entry is moved to the list of its new status
+	require.True(t, reconcileAnimeEntry(collection, 20, 12, lo.ToPtr(anilist.MediaListStatusCompleted), now))
+	require.Len(t, current.Entries, 1)
+	assert.Equal(t, 10, current.Entries[0].Media.ID)
+	require.Len(t, completed.Entries, 1)
+	assert.Equal(t, 12, *completed.Entries[0].Progress)
+	assert.Equal(t, anilist.MediaListStatusCompleted, *completed.Entries[0].Status)
+
+	assert.False(t, reconcileAnimeEntry(collection, 30, 1, nil, now))
+}
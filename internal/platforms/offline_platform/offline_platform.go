@@ -7,6 +7,7 @@ import (
 	"seanime/internal/local"
 	"seanime/internal/platforms/platform"
 	"seanime/internal/util"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
@@ -271,6 +272,36 @@ func (lp *OfflinePlatform) UpdateEntryProgress(ctx context.Context, mediaID int,
 	return ErrMediaNotFound
 }
 
+func (lp *OfflinePlatform) UpdateMangaEntryProgress(ctx context.Context, mediaID int, progress int, progressVolumes *int, totalChapters *int) error {
+	if lp.localManager.GetLocalMangaCollection().IsPresent() {
+		mangaCollection := lp.localManager.GetLocalMangaCollection().MustGet()
+
+		// Find the entry
+		for _, list := range mangaCollection.MediaListCollection.Lists {
+			for _, entry := range list.Entries {
+				if entry.GetMedia().GetID() == mediaID {
+					// Update the entry
+					entry.Progress = &progress
+					if progressVolumes != nil {
+						entry.ProgressVolumes = progressVolumes
+					}
+					if totalChapters != nil {
+						entry.Media.Chapters = totalChapters
+					}
+
+					// Save the collection
+					rearrangeMangaCollectionLists(mangaCollection)
+					lp.localManager.UpdateLocalMangaCollection(mangaCollection)
+					lp.localManager.SetHasLocalChanges(true)
+					return nil
+				}
+			}
+		}
+	}
+
+	return ErrMediaNotFound
+}
+
 func (lp *OfflinePlatform) UpdateEntryRepeat(ctx context.Context, mediaID int, repeat int) error {
 	if lp.localManager.GetLocalAnimeCollection().IsPresent() {
 		animeCollection := lp.localManager.GetLocalAnimeCollection().MustGet()
@@ -386,7 +417,7 @@ func (lp *OfflinePlatform) GetMangaDetails(ctx context.Context, mediaID int) (*a
 	return &anilist.MangaDetailsById_Media{}, nil
 }
 
-func (lp *OfflinePlatform) GetAnimeCollection(ctx context.Context, bypassCache bool) (*anilist.AnimeCollection, error) {
+func (lp *OfflinePlatform) GetAnimeCollection(ctx context.Context, maxStaleness time.Duration) (*anilist.AnimeCollection, error) {
 	if lp.localManager.GetLocalAnimeCollection().IsPresent() {
 		return lp.localManager.GetLocalAnimeCollection().MustGet(), nil
 	} else {
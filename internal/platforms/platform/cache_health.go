@@ -0,0 +1,35 @@
+package platform
+
+import (
+	"context"
+	"seanime/internal/api/anilist"
+	"time"
+)
+
+// CacheHealth describes how up to date the cached anime collection of a platform is.
+type CacheHealth struct {
+	// FetchedAt is the time the cached collection was fetched
+	FetchedAt  *time.Time `json:"fetchedAt,omitempty"`
+	AgeSeconds float64    `json:"ageSeconds"`
+	// LatestEntryUpdatedAt is the time the most recently modified entry of the cached collection was updated
+	LatestEntryUpdatedAt *time.Time `json:"latestEntryUpdatedAt,omitempty"`
+	// LastValidatedAt is the last time the cached collection was compared with AniList
+	LastValidatedAt *time.Time `json:"lastValidatedAt,omitempty"`
+	// LastIncoherenceAt is the last time the cached collection was found to be out of date
+	LastIncoherenceAt *time.Time `json:"lastIncoherenceAt,omitempty"`
+	IncoherenceCount  int        `json:"incoherenceCount"`
+}
+
+// CacheValidator is implemented by platforms that can check their cached anime collection against AniList.
+type CacheValidator interface {
+	// ValidateAnimeCollectionCache returns true if the cached anime collection is out of date and should be refreshed
+	ValidateAnimeCollectionCache(ctx context.Context) (bool, error)
+	GetAnimeCollectionCacheHealth() *CacheHealth
+}
+
+// EntryProgressReconciler is implemented by platforms that can update their cached anime collection after a progress update,
+// without waiting for the next refresh of the collection.
+type EntryProgressReconciler interface {
+	// ReconcileAnimeEntryProgress returns false if the media is not in the cached collection
+	ReconcileAnimeEntryProgress(mediaID int, progress int, status *anilist.MediaListStatus) bool
+}
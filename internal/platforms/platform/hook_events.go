@@ -98,6 +98,8 @@ type PreUpdateEntryProgressEvent struct {
 	MediaID    *int `json:"mediaId"`
 	Progress   *int `json:"progress"`
 	TotalCount *int `json:"totalCount"`
+	// ProgressVolumes is the volume progress of a manga entry, it is nil if the volume progress is not updated
+	ProgressVolumes *int `json:"progressVolumes,omitempty"`
 	// Defaults to anilist.MediaListStatusCurrent
 	Status *anilist.MediaListStatus `json:"status"`
 }
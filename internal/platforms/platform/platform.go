@@ -2,15 +2,35 @@ package platform
 
 import (
 	"context"
+	"math"
 	"seanime/internal/api/anilist"
+	"time"
 )
 
+const (
+	// FreshCollection is the staleness to use to always re-fetch the collection
+	FreshCollection time.Duration = 0
+	// CachedCollection is the staleness to use to never re-fetch the collection if it is cached
+	CachedCollection time.Duration = math.MaxInt64
+)
+
+// IsFresh returns true if data fetched at fetchedAt is not older than maxStaleness.
+func IsFresh(fetchedAt time.Time, maxStaleness time.Duration) bool {
+	if maxStaleness <= 0 {
+		return false
+	}
+	return time.Since(fetchedAt) <= maxStaleness
+}
+
 type Platform interface {
 	SetUsername(username string)
 	// UpdateEntry updates the entry for the given media ID
 	UpdateEntry(context context.Context, mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, startedAt *anilist.FuzzyDateInput, completedAt *anilist.FuzzyDateInput) error
 	// UpdateEntryProgress updates the entry progress for the given media ID
 	UpdateEntryProgress(context context.Context, mediaID int, progress int, totalEpisodes *int) error
+	// UpdateMangaEntryProgress updates the chapter and volume progress of the manga entry for the given media ID
+	// The volume progress is left unchanged if progressVolumes is nil
+	UpdateMangaEntryProgress(context context.Context, mediaID int, progress int, progressVolumes *int, totalChapters *int) error
 	// UpdateEntryRepeat updates the entry repeat number for the given media ID
 	UpdateEntryRepeat(context context.Context, mediaID int, repeat int) error
 	// DeleteEntry deletes the entry for the given media ID
@@ -29,7 +49,8 @@ type Platform interface {
 	GetManga(context context.Context, mediaID int) (*anilist.BaseManga, error)
 	// GetAnimeCollection gets the anime collection without custom lists
 	// This should not make any API calls and instead should be based on GetRawAnimeCollection
-	GetAnimeCollection(context context.Context, bypassCache bool) (*anilist.AnimeCollection, error)
+	// The collection is re-fetched if the cached one is older than maxStaleness, see FreshCollection and CachedCollection
+	GetAnimeCollection(context context.Context, maxStaleness time.Duration) (*anilist.AnimeCollection, error)
 	// GetRawAnimeCollection gets the anime collection with custom lists
 	GetRawAnimeCollection(context context.Context, bypassCache bool) (*anilist.AnimeCollection, error)
 	// GetMangaDetails gets the manga details for the given media ID
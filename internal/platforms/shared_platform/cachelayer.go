@@ -125,6 +125,7 @@ const (
 	ListMangaBucket                = "list-manga"
 	SearchBaseAnimeByIdsBucket     = "search-base-anime-by-ids"
 	CustomQueryBucket              = "custom-query"
+	StreamingEpisodesBucket        = "streaming-episodes"
 
 	maxNonCollectionCacheEntries      = 10
 	maxNonCollectionMediaCacheEntries = 50
@@ -219,6 +220,7 @@ func NewCacheLayer(anilistClientRef *util.Ref[anilist.AnilistClient]) anilist.An
 	buckets[ListMangaBucket] = filecache.NewPermanentBucket(ListMangaBucket)
 	buckets[SearchBaseAnimeByIdsBucket] = filecache.NewPermanentBucket(SearchBaseAnimeByIdsBucket)
 	buckets[CustomQueryBucket] = filecache.NewPermanentBucket(CustomQueryBucket)
+	buckets[StreamingEpisodesBucket] = filecache.NewPermanentBucket(StreamingEpisodesBucket)
 
 	logger := util.NewLogger()
 
@@ -793,6 +795,13 @@ func (c *CacheLayer) AnimeCollectionWithRelations(ctx context.Context, userName
 	return result, err
 }
 
+// AnimeListLatestUpdate is not cached, it is used to check that the cached collection is up to date.
+func (c *CacheLayer) AnimeListLatestUpdate(ctx context.Context, userName *string, interceptors ...clientv2.RequestInterceptor) (*anilist.AnimeListLatestUpdate, error) {
+	result, err := c.anilistClientRef.Get().AnimeListLatestUpdate(ctx, userName, interceptors...)
+	c.checkAndUpdateWorkingState(err)
+	return result, err
+}
+
 func (c *CacheLayer) BaseAnimeByMalID(ctx context.Context, id *int, interceptors ...clientv2.RequestInterceptor) (*anilist.BaseAnimeByMalID, error) {
 	if id == nil {
 		return c.anilistClientRef.Get().BaseAnimeByMalID(ctx, id, interceptors...)
@@ -935,6 +944,24 @@ func (c *CacheLayer) UpdateMediaListEntryProgress(ctx context.Context, mediaID *
 	return result, err
 }
 
+func (c *CacheLayer) UpdateMangaListEntryProgress(ctx context.Context, mediaID *int, progress *int, progressVolumes *int, status *anilist.MediaListStatus, interceptors ...clientv2.RequestInterceptor) (*anilist.UpdateMangaListEntryProgress, error) {
+	// Mutations require the API to be working
+	if !IsWorking.Load() {
+		return nil, fmt.Errorf("anilist cache: API client is not working, mutation operations are not available")
+	}
+
+	result, err := c.anilistClientRef.Get().UpdateMangaListEntryProgress(ctx, mediaID, progress, progressVolumes, status, interceptors...)
+	c.checkAndUpdateWorkingState(err)
+
+	// Invalidate relevant caches on successful mutation
+	if err == nil && mediaID != nil {
+		c.invalidateMediaCaches(*mediaID)
+		c.invalidateCollectionCaches()
+	}
+
+	return result, err
+}
+
 func (c *CacheLayer) UpdateMediaListEntryRepeat(ctx context.Context, mediaID *int, repeat *int, interceptors ...clientv2.RequestInterceptor) (*anilist.UpdateMediaListEntryRepeat, error) {
 	// Mutations require the API to be working
 	if !IsWorking.Load() {
@@ -1088,3 +1115,28 @@ func (c *CacheLayer) AnimeAiringScheduleRaw(ctx context.Context, ids []*int, int
 		return c.anilistClientRef.Get().AnimeAiringScheduleRaw(ctx, ids, interceptors...)
 	})
 }
+
+func (c *CacheLayer) GetStreamingEpisodes(ctx context.Context, id int) ([]*anilist.StreamingEpisode, error) {
+	cacheKey := c.generateCacheKey(id)
+	ret, err := networkFirstGet(c, StreamingEpisodesBucket, cacheKey, func() (*[]*anilist.StreamingEpisode, error) {
+		episodes, err := c.anilistClientRef.Get().GetStreamingEpisodes(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &episodes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return *ret, nil
+}
+
+// GetEntryProgress is never served from the cache, it is used to check the progress before a mutation.
+func (c *CacheLayer) GetEntryProgress(ctx context.Context, mediaID int) (*anilist.EntryProgress, error) {
+	if !IsWorking.Load() {
+		return nil, fmt.Errorf("anilist cache: API client is not working")
+	}
+	ret, err := c.anilistClientRef.Get().GetEntryProgress(ctx, mediaID)
+	c.checkAndUpdateWorkingState(err)
+	return ret, err
+}
@@ -419,11 +419,12 @@ func (h *PlatformHelper) TriggerUpdateEntryHooks(ctx context.Context, mediaID in
 }
 
 // TriggerUpdateEntryProgressHooks triggers pre and post update entry progress hooks
-func (h *PlatformHelper) TriggerUpdateEntryProgressHooks(ctx context.Context, mediaID int, progress int, totalCount *int, updateFunc func(event *platform.PreUpdateEntryProgressEvent) error) error {
+func (h *PlatformHelper) TriggerUpdateEntryProgressHooks(ctx context.Context, mediaID int, progress int, progressVolumes *int, totalCount *int, updateFunc func(event *platform.PreUpdateEntryProgressEvent) error) error {
 	// Trigger pre-update hook
 	event := new(platform.PreUpdateEntryProgressEvent)
 	event.MediaID = &mediaID
 	event.Progress = &progress
+	event.ProgressVolumes = progressVolumes
 	event.TotalCount = totalCount
 	currentStatus := anilist.MediaListStatusCurrent
 	event.Status = &currentStatus
@@ -49,6 +49,24 @@ func (cw *CollectionWrapper) UpdateEntryProgress(mediaId int, progress int, tota
 	return cw.UpdateEntry(mediaId, &status, nil, &progress, nil, nil)
 }
 
+// UpdateMangaEntryProgress updates the chapter and volume progress of a manga entry
+// The volume progress is left unchanged if progressVolumes is nil
+func (cw *CollectionWrapper) UpdateMangaEntryProgress(mediaId int, progress int, progressVolumes *int, totalCount *int) error {
+	if cw.isAnime {
+		return errors.New("volume progress is only tracked for manga")
+	}
+
+	if progressVolumes != nil {
+		entry, err := cw.findMangaEntry(mediaId)
+		if err != nil {
+			return err
+		}
+		entry.ProgressVolumes = progressVolumes
+	}
+
+	return cw.UpdateEntryProgress(mediaId, progress, totalCount)
+}
+
 // DeleteEntry removes an entry from the collection
 func (cw *CollectionWrapper) DeleteEntry(mediaId int, isEntryId ...bool) error {
 	if cw.isAnime {
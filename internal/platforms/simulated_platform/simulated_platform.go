@@ -33,6 +33,7 @@ type SimulatedPlatform struct {
 
 	// Cache for collections
 	animeCollection                *anilist.AnimeCollection
+	animeCollectionLoadedAt        time.Time // when animeCollection was loaded from the database
 	mangaCollection                *anilist.MangaCollection
 	mu                             sync.RWMutex
 	collectionMu                   sync.RWMutex // used to protect access to collections
@@ -140,7 +141,7 @@ func (sp *SimulatedPlatform) UpdateEntry(ctx context.Context, mediaID int, statu
 func (sp *SimulatedPlatform) UpdateEntryProgress(ctx context.Context, mediaID int, progress int, totalEpisodes *int) error {
 	sp.logger.Trace().Int("mediaID", mediaID).Int("progress", progress).Msg("simulated platform: Updating entry progress")
 
-	return sp.helper.TriggerUpdateEntryProgressHooks(ctx, mediaID, progress, totalEpisodes, func(event *platform.PreUpdateEntryProgressEvent) error {
+	return sp.helper.TriggerUpdateEntryProgressHooks(ctx, mediaID, progress, nil, totalEpisodes, func(event *platform.PreUpdateEntryProgressEvent) error {
 		// Check if this is a custom source entry (after hooks have been triggered)
 		if handled, err := sp.helper.HandleCustomSourceUpdateEntryProgress(ctx, mediaID, *event.Progress, event.TotalCount); handled {
 			return err
@@ -193,6 +194,41 @@ func (sp *SimulatedPlatform) UpdateEntryProgress(ctx context.Context, mediaID in
 	})
 }
 
+func (sp *SimulatedPlatform) UpdateMangaEntryProgress(ctx context.Context, mediaID int, progress int, progressVolumes *int, totalChapters *int) error {
+	sp.logger.Trace().Int("mediaID", mediaID).Int("progress", progress).Msg("simulated platform: Updating manga entry progress")
+
+	return sp.helper.TriggerUpdateEntryProgressHooks(ctx, mediaID, progress, progressVolumes, totalChapters, func(event *platform.PreUpdateEntryProgressEvent) error {
+		// Check if this is a custom source entry (after hooks have been triggered)
+		if handled, err := sp.helper.HandleCustomSourceUpdateEntryProgress(ctx, mediaID, *event.Progress, event.TotalCount); handled {
+			return err
+		}
+
+		sp.mu.Lock()
+		defer sp.mu.Unlock()
+
+		status := anilist.MediaListStatusCurrent
+		if event.TotalCount != nil && *event.Progress >= *event.TotalCount {
+			status = anilist.MediaListStatusCompleted
+			*event.Status = status
+		}
+
+		mangaWrapper := sp.GetMangaCollectionWrapper()
+		if _, err := mangaWrapper.FindEntry(mediaID); err == nil {
+			return mangaWrapper.UpdateMangaEntryProgress(mediaID, *event.Progress, event.ProgressVolumes, event.TotalCount)
+		}
+
+		// Entry doesn't exist, add it
+		if _, err := sp.client.BaseMangaByID(ctx, &mediaID); err != nil {
+			return errors.New("media not found on AniList")
+		}
+		sp.logger.Trace().Int("mediaID", mediaID).Msg("simulated platform: Adding new manga entry for progress update")
+		if err := mangaWrapper.AddEntry(mediaID, status); err != nil {
+			return err
+		}
+		return mangaWrapper.UpdateMangaEntryProgress(mediaID, *event.Progress, event.ProgressVolumes, event.TotalCount)
+	})
+}
+
 func (sp *SimulatedPlatform) UpdateEntryRepeat(ctx context.Context, mediaID int, repeat int) error {
 	sp.logger.Trace().Int("mediaID", mediaID).Int("repeat", repeat).Msg("simulated platform: Updating entry repeat")
 
@@ -462,10 +498,12 @@ func (sp *SimulatedPlatform) GetMangaDetails(ctx context.Context, mediaID int) (
 	return resp.GetMedia(), nil
 }
 
-func (sp *SimulatedPlatform) GetAnimeCollection(ctx context.Context, bypassCache bool) (*anilist.AnimeCollection, error) {
-	sp.logger.Trace().Bool("bypassCache", bypassCache).Msg("simulated platform: Getting anime collection")
+func (sp *SimulatedPlatform) GetAnimeCollection(ctx context.Context, maxStaleness time.Duration) (*anilist.AnimeCollection, error) {
+	sp.logger.Trace().Dur("maxStaleness", maxStaleness).Msg("simulated platform: Getting anime collection")
 
-	if !bypassCache && sp.animeCollection != nil {
+	isFresh := sp.animeCollection != nil && platform.IsFresh(sp.animeCollectionLoadedAt, maxStaleness)
+
+	if isFresh {
 		event := new(platform.GetCachedAnimeCollectionEvent)
 		event.AnimeCollection = sp.animeCollection
 		err := hook.GlobalHookManager.OnGetCachedAnimeCollection().Trigger(event)
@@ -475,7 +513,7 @@ func (sp *SimulatedPlatform) GetAnimeCollection(ctx context.Context, bypassCache
 		return event.AnimeCollection, nil
 	}
 
-	if bypassCache {
+	if sp.animeCollection != nil {
 		sp.invalidateAnimeCollectionCache()
 	}
 
@@ -729,7 +767,7 @@ func (sp *SimulatedPlatform) GetViewerStats(ctx context.Context) (*anilist.Viewe
 }
 
 func (sp *SimulatedPlatform) GetAnimeAiringSchedule(ctx context.Context) (*anilist.AnimeAiringSchedule, error) {
-	collection, err := sp.GetAnimeCollection(ctx, false)
+	collection, err := sp.GetAnimeCollection(ctx, platform.CachedCollection)
 	if err != nil {
 		return nil, err
 	}
@@ -760,10 +798,12 @@ func (sp *SimulatedPlatform) getOrCreateAnimeCollection() (*anilist.AnimeCollect
 	// Try to load from database
 	if collection := sp.localManager.GetSimulatedAnimeCollection(); collection.IsPresent() {
 		sp.animeCollection = collection.MustGet()
+		sp.animeCollectionLoadedAt = time.Now()
 		return sp.animeCollection, nil
 	}
 
 	// Create empty collection
+	sp.animeCollectionLoadedAt = time.Now()
 	sp.animeCollection = &anilist.AnimeCollection{
 		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
 			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{},
@@ -5,6 +5,7 @@ import (
 	"seanime/internal/api/anilist"
 	"seanime/internal/events"
 	"seanime/internal/extension"
+	"seanime/internal/platforms/platform"
 
 	"github.com/dop251/goja"
 	"github.com/rs/zerolog"
@@ -46,7 +47,11 @@ func (a *AppContextImpl) BindAnilist(vm *goja.Runtime, logger *zerolog.Logger, e
 			return anilistPlatformRef.Get().DeleteEntry(context.Background(), mediaID, entryId)
 		})
 		_ = anilistObj.Set("getAnimeCollection", func(bypassCache bool) (*anilist.AnimeCollection, error) {
-			return anilistPlatformRef.Get().GetAnimeCollection(context.Background(), bypassCache)
+			maxStaleness := platform.CachedCollection
+			if bypassCache {
+				maxStaleness = platform.FreshCollection
+			}
+			return anilistPlatformRef.Get().GetAnimeCollection(context.Background(), maxStaleness)
 		})
 		_ = anilistObj.Set("getRawAnimeCollection", func(bypassCache bool) (*anilist.AnimeCollection, error) {
 			return anilistPlatformRef.Get().GetRawAnimeCollection(context.Background(), bypassCache)
@@ -89,6 +94,11 @@ func (a *AppContextImpl) BindAnilist(vm *goja.Runtime, logger *zerolog.Logger, e
 			return anilistPlatformRef.Get().GetAnilistClient().ListRecentAnime(context.Background(), page, perPage, airingAtGreater, airingAtLesser, notYetAired)
 		})
 		_ = anilistObj.Set("customQuery", func(body map[string]interface{}, token string) (interface{}, error) {
+			// Go through the rate limiter and the cache of the server's AniList proxy
+			if proxy, ok := a.anilistProxy.Get(); ok {
+				data, _, err := proxy.Forward(body, token)
+				return data, err
+			}
 			return anilist.CustomQuery(body, a.logger, token)
 		})
 
@@ -7,6 +7,7 @@ import (
 	"seanime/internal/goja/goja_bindings"
 	"seanime/internal/hook"
 	"seanime/internal/library/anime"
+	"seanime/internal/platforms/platform"
 	goja_util "seanime/internal/util/goja"
 
 	"github.com/dop251/goja"
@@ -80,7 +81,7 @@ func (m *Anime) getAnimeEntry(call goja.FunctionCall) goja.Value {
 		}
 
 		// Get the user's anilist collection
-		animeCollection, err := anilistPlatformRef.Get().GetAnimeCollection(context.Background(), false)
+		animeCollection, err := anilistPlatformRef.Get().GetAnimeCollection(context.Background(), platform.CachedCollection)
 		if err != nil {
 			_ = reject(m.vm.ToValue(err.Error()))
 			return
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"seanime/internal/api/anilistproxy"
 	"seanime/internal/api/metadata_provider"
 	"seanime/internal/continuity"
 	"seanime/internal/database/db"
@@ -48,6 +49,7 @@ type AppContextModules struct {
 	MediastreamRepository           *mediastream.Repository
 	TorrentstreamRepository         *torrentstream.Repository
 	FillerManager                   *fillermanager.FillerManager
+	AnilistProxy                    *anilistproxy.Proxy
 	OnRefreshAnilistAnimeCollection func()
 	OnRefreshAnilistMangaCollection func()
 }
@@ -148,6 +150,7 @@ type AppContextImpl struct {
 	discordPresence                 mo.Option[*discordrpc_presence.Presence]
 	metadataProviderRef             mo.Option[*util.Ref[metadata_provider.Provider]]
 	fillerManager                   mo.Option[*fillermanager.FillerManager]
+	anilistProxy                    mo.Option[*anilistproxy.Proxy]
 	torrentClientRepository         mo.Option[*torrent_client.Repository]
 	torrentstreamRepository         mo.Option[*torrentstream.Repository]
 	mediastreamRepository           mo.Option[*mediastream.Repository]
@@ -174,6 +177,7 @@ func NewAppContext() AppContext {
 		wsEventManager:                  mo.None[events.WSEventManagerInterface](),
 		discordPresence:                 mo.None[*discordrpc_presence.Presence](),
 		fillerManager:                   mo.None[*fillermanager.FillerManager](),
+		anilistProxy:                    mo.None[*anilistproxy.Proxy](),
 		torrentClientRepository:         mo.None[*torrent_client.Repository](),
 		torrentstreamRepository:         mo.None[*torrentstream.Repository](),
 		mediastreamRepository:           mo.None[*mediastream.Repository](),
@@ -251,6 +255,10 @@ func (a *AppContextImpl) SetModulesPartial(modules AppContextModules) {
 		a.fillerManager = mo.Some(modules.FillerManager)
 	}
 
+	if modules.AnilistProxy != nil {
+		a.anilistProxy = mo.Some(modules.AnilistProxy)
+	}
+
 	if modules.OnRefreshAnilistAnimeCollection != nil {
 		a.onRefreshAnilistAnimeCollection = mo.Some(modules.OnRefreshAnilistAnimeCollection)
 	}
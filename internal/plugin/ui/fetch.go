@@ -8,6 +8,7 @@ import (
 
 func (c *Context) bindFetch(obj *goja.Object) {
 	f := goja_bindings.NewFetch(c.vm)
+	f.SetNetworkPolicy(c.ext.GetNetworkPolicy())
 
 	_ = obj.Set("fetch", f.Fetch)
 
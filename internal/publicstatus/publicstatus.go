@@ -0,0 +1,180 @@
+package publicstatus
+
+import (
+	"seanime/internal/events"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// MaxAnnouncementLength is the maximum number of characters of the announcement.
+	MaxAnnouncementLength = 500
+
+	// Each IP can make a burst of requests, then one request every requestInterval.
+	requestInterval = 6 * time.Second
+	requestBurst    = 10
+	// visitorTTL is how long the rate limiter of an IP is kept after its last request
+	visitorTTL = 10 * time.Minute
+)
+
+type (
+	// Manager builds the unauthenticated status payload shared with the people using the server.
+	// Only the fields approved by the owner are returned, and each IP is rate-limited.
+	Manager struct {
+		logger         *zerolog.Logger
+		wsEventManager events.WSEventManagerInterface
+
+		mu       sync.Mutex
+		settings *Settings
+		visitors map[string]*visitor
+		now      func() time.Time
+	}
+
+	NewManagerOptions struct {
+		Logger         *zerolog.Logger
+		WSEventManager events.WSEventManagerInterface
+	}
+
+	Settings struct {
+		Enabled         bool
+		ShowVersion     bool
+		ShowStreaming   bool
+		ShowMaintenance bool
+		// MaxStreams is the number of concurrent streams after which streaming is at capacity, 0 if there is no limit
+		MaxStreams   int
+		Announcement string
+	}
+
+	visitor struct {
+		limiter  *rate.Limiter
+		lastSeen time.Time
+	}
+
+	// Source is the current state of the server, filtered by GetStatus.
+	Source struct {
+		Version       string
+		ActiveStreams int
+		// MaintenanceActive, MaintenanceReason and MaintenanceUntil describe the maintenance mode
+		MaintenanceActive bool
+		MaintenanceReason string
+		MaintenanceUntil  *time.Time
+	}
+
+	// Status is the public status payload. Fields that are not approved by the owner are omitted.
+	Status struct {
+		Up           bool               `json:"up"`
+		Version      string             `json:"version,omitempty"`
+		Streaming    *StreamingStatus   `json:"streaming,omitempty"`
+		Maintenance  *MaintenanceNotice `json:"maintenance,omitempty"`
+		Announcement string             `json:"announcement,omitempty"`
+	}
+
+	StreamingStatus struct {
+		AtCapacity bool `json:"atCapacity"`
+	}
+
+	MaintenanceNotice struct {
+		Active bool       `json:"active"`
+		Reason string     `json:"reason,omitempty"`
+		Until  *time.Time `json:"until,omitempty"`
+	}
+
+	AnnouncementUpdatedEvent struct {
+		Announcement string `json:"announcement"`
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		logger:         opts.Logger,
+		wsEventManager: opts.WSEventManager,
+		settings:       &Settings{},
+		visitors:       make(map[string]*visitor),
+		now:            time.Now,
+	}
+}
+
+// SetSettings updates the settings.
+// Logged-in clients are notified when the announcement changes.
+func (m *Manager) SetSettings(settings *Settings) {
+	if settings == nil {
+		settings = &Settings{}
+	}
+	s := *settings
+	s.Announcement = strings.TrimSpace(s.Announcement)
+
+	m.mu.Lock()
+	changed := m.settings.Announcement != s.Announcement
+	m.settings = &s
+	if !s.Enabled {
+		clear(m.visitors)
+	}
+	m.mu.Unlock()
+
+	if changed {
+		m.logger.Debug().Msg("public status: Announcement updated")
+		m.wsEventManager.SendEvent(events.PublicAnnouncementUpdated, &AnnouncementUpdatedEvent{Announcement: s.Announcement})
+	}
+}
+
+// IsEnabled returns true if the public status is enabled by the owner.
+func (m *Manager) IsEnabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settings.Enabled
+}
+
+// Allow returns false if the IP has made too many requests.
+func (m *Manager) Allow(ip string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, v := range m.visitors {
+		if now.Sub(v.lastSeen) > visitorTTL {
+			delete(m.visitors, k)
+		}
+	}
+
+	v, found := m.visitors[ip]
+	if !found {
+		v = &visitor{limiter: rate.NewLimiter(rate.Every(requestInterval), requestBurst)}
+		m.visitors[ip] = v
+	}
+	v.lastSeen = now
+
+	return v.limiter.AllowN(now, 1)
+}
+
+// GetStatus returns the status payload with the fields approved by the owner.
+func (m *Manager) GetStatus(source *Source) *Status {
+	m.mu.Lock()
+	settings := *m.settings
+	m.mu.Unlock()
+
+	ret := &Status{
+		Up:           true,
+		Announcement: settings.Announcement,
+	}
+	if settings.ShowVersion {
+		ret.Version = source.Version
+	}
+	if settings.ShowStreaming {
+		ret.Streaming = &StreamingStatus{
+			AtCapacity: settings.MaxStreams > 0 && source.ActiveStreams >= settings.MaxStreams,
+		}
+	}
+	if settings.ShowMaintenance {
+		ret.Maintenance = &MaintenanceNotice{Active: source.MaintenanceActive}
+		if source.MaintenanceActive {
+			ret.Maintenance.Reason = source.MaintenanceReason
+			ret.Maintenance.Until = source.MaintenanceUntil
+		}
+	}
+
+	return ret
+}
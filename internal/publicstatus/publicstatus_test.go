@@ -0,0 +1,102 @@
+package publicstatus
+
+import (
+	"seanime/internal/events"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingWSEventManager struct {
+	*events.MockWSEventManager
+	sent []string
+}
+
+func (m *recordingWSEventManager) SendEvent(t string, payload interface{}) {
+	m.sent = append(m.sent, t)
+}
+
+func newTestManager() (*Manager, *recordingWSEventManager) {
+	logger := util.NewLogger()
+	ws := &recordingWSEventManager{MockWSEventManager: events.NewMockWSEventManager(logger)}
+	m := NewManager(&NewManagerOptions{
+		Logger:         logger,
+		WSEventManager: ws,
+	})
+	return m, ws
+}
+
+func TestManager_GetStatus(t *testing.T) {
+	m, _ := newTestManager()
+	until := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	source := &Source{
+		Version:           "3.0.0",
+		ActiveStreams:     2,
+		MaintenanceActive: true,
+		MaintenanceReason: "backup",
+		MaintenanceUntil:  &until,
+	}
+
+	// Only the announcement is returned if no field is approved
+	m.SetSettings(&Settings{Enabled: true, Announcement: " Movie night on Friday "})
+	status := m.GetStatus(source)
+	assert.True(t, status.Up)
+	assert.Empty(t, status.Version)
+	assert.Nil(t, status.Streaming)
+	assert.Nil(t, status.Maintenance)
+	assert.Equal(t, "Movie night on Friday", status.Announcement)
+
+	m.SetSettings(&Settings{Enabled: true, ShowVersion: true, ShowStreaming: true, ShowMaintenance: true, MaxStreams: 2})
+	status = m.GetStatus(source)
+	assert.Equal(t, "3.0.0", status.Version)
+	require.NotNil(t, status.Streaming)
+	assert.True(t, status.Streaming.AtCapacity)
+	require.NotNil(t, status.Maintenance)
+	assert.True(t, status.Maintenance.Active)
+	assert.Equal(t, "backup", status.Maintenance.Reason)
+	assert.Empty(t, status.Announcement)
+
+	// Streaming is never at capacity without a limit
+	m.SetSettings(&Settings{Enabled: true, ShowStreaming: true})
+	assert.False(t, m.GetStatus(source).Streaming.AtCapacity)
+}
+
+func TestManager_AnnouncementEvent(t *testing.T) {
+	m, ws := newTestManager()
+
+	m.SetSettings(&Settings{Enabled: true})
+	assert.Empty(t, ws.sent)
+
+	m.SetSettings(&Settings{Enabled: true, Announcement: "Down for upgrades tonight"})
+	m.SetSettings(&Settings{Enabled: true, ShowVersion: true, Announcement: "Down for upgrades tonight"})
+	assert.Equal(t, []string{events.PublicAnnouncementUpdated}, ws.sent)
+
+	m.SetSettings(&Settings{})
+	assert.Equal(t, []string{events.PublicAnnouncementUpdated, events.PublicAnnouncementUpdated}, ws.sent)
+}
+
+func TestManager_Allow(t *testing.T) {
+	m, _ := newTestManager()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < requestBurst; i++ {
+		require.True(t, m.Allow("1.1.1.1"))
+	}
+	assert.False(t, m.Allow("1.1.1.1"))
+	assert.True(t, m.Allow("2.2.2.2"), "other IPs should not be limited")
+
+	now = now.Add(requestInterval)
+	assert.True(t, m.Allow("1.1.1.1"))
+	assert.False(t, m.Allow("1.1.1.1"))
+
+	// Idle visitors are forgotten
+	now = now.Add(visitorTTL + time.Minute)
+	m.Allow("3.3.3.3")
+	m.mu.Lock()
+	assert.Len(t, m.visitors, 1)
+	m.mu.Unlock()
+}
@@ -2,32 +2,86 @@ package session
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"seanime/internal/api/anilist"
 	"seanime/internal/user"
+	"sort"
+	"strings"
 	"sync"
 	"time"
-
-	"github.com/goccy/go-json"
 )
 
+// ViewerCacheTTL is how long the Anilist viewer of a session is cached before it is fetched again
+const ViewerCacheTTL = 1 * time.Hour
+
 // Session represents a browser session with its own Anilist authentication
 type Session struct {
 	ID           string                       `json:"id"`
 	Token        string                       `json:"token"`        // Anilist JWT token
 	Username     string                       `json:"username"`     // Anilist username
-	Viewer       *anilist.GetViewer_Viewer    `json:"viewer"`       // Anilist viewer data
 	CreatedAt    time.Time                    `json:"createdAt"`
 	LastAccessed time.Time                    `json:"lastAccessed"`
 	IsSimulated  bool                         `json:"isSimulated"`  // True if not logged in to Anilist
+
+	viewerMu       sync.Mutex
+	viewerCache    *anilist.GetViewer_Viewer // Anilist viewer data, loaded on first access
+	viewerCachedAt time.Time
+	getClient      func() anilist.AnilistClient // Set by the store
+}
+
+// GetViewer returns the Anilist viewer of the session.
+// It is fetched from Anilist on first access and again once the cached one is older than ViewerCacheTTL,
+// so that changes to the profile (e.g. avatar) are picked up.
+func (s *Session) GetViewer(ctx context.Context) (*anilist.GetViewer_Viewer, error) {
+	if s.IsSimulated || s.Token == "" {
+		return nil, errors.New("session is not logged in to Anilist")
+	}
+
+	s.viewerMu.Lock()
+	defer s.viewerMu.Unlock()
+
+	if s.viewerCache != nil && time.Since(s.viewerCachedAt) < ViewerCacheTTL {
+		return s.viewerCache, nil
+	}
+
+	if s.getClient == nil {
+		return nil, errors.New("session is not registered in the store")
+	}
+
+	ret, err := s.getClient().GetViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil || ret.Viewer == nil {
+		return nil, errors.New("could not fetch the Anilist viewer")
+	}
+
+	s.viewerCache = ret.Viewer
+	s.viewerCachedAt = time.Now()
+	return s.viewerCache, nil
 }
 
-// ToUser converts the session to a user.User for compatibility with existing code
-func (s *Session) ToUser() *user.User {
+// ToUser converts the session to a user.User for compatibility with existing code.
+// If the viewer cannot be fetched, the previously cached viewer is used, or one that only has the username.
+func (s *Session) ToUser(ctx context.Context) *user.User {
 	if s.IsSimulated || s.Token == "" {
 		return user.NewSimulatedUser()
 	}
+
+	viewer, err := s.GetViewer(ctx)
+	if err != nil {
+		s.viewerMu.Lock()
+		viewer = s.viewerCache
+		s.viewerMu.Unlock()
+		if viewer == nil {
+			viewer = &anilist.GetViewer_Viewer{Name: s.Username}
+		}
+	}
+
 	return &user.User{
-		Viewer:      s.Viewer,
+		Viewer:      viewer,
 		Token:       "HIDDEN", // Don't expose token to client
 		IsSimulated: false,
 	}
@@ -41,28 +95,121 @@ func (s *Session) GetToken() string {
 	return s.Token
 }
 
+// DefaultCleanupInterval is used when no cleanup interval is set
+const DefaultCleanupInterval = 1 * time.Hour
+
+// DefaultMaxIdle is how long a session can go without being accessed before it is removed, used when no value is set
+const DefaultMaxIdle = 7 * 24 * time.Hour
+
+// ErrSessionDeleted is the cause of the cancellation of the requests made for a deleted session
+var ErrSessionDeleted = errors.New("session deleted")
+
+// sessionContext is cancelled when the session is deleted or replaced
+type sessionContext struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
 // Store manages all active sessions
 type Store struct {
-	sessions map[string]*Session
-	clients  map[string]anilist.AnilistClient // Per-session Anilist clients
-	mu       sync.RWMutex
-	cacheDir string
+	sessions        map[string]*Session
+	clients         map[string]anilist.AnilistClient // Per-session Anilist clients
+	sharedClients   map[string]anilist.AnilistClient // Token hash -> client shared by the sessions with that token
+	sharedMu        sync.RWMutex
+	contexts        map[string]*sessionContext       // Per-session contexts used for Anilist requests
+	wsClients       map[string]string                // WebSocket client ID -> session ID
+	mu              sync.RWMutex
+	cacheDir        string
+	cleanupInterval time.Duration
+	intervalCh      chan time.Duration // Sends new cleanup intervals to the cleanup loop
+	maxIdle         time.Duration
+	onExpired       func(sessionID string) // Called for each session removed by the cleanup
+	now             func() time.Time
 }
 
-// NewStore creates a new session store
-func NewStore(cacheDir string) *Store {
+// NewStore creates a new session store.
+// Stale sessions are removed every cleanupInterval, or every DefaultCleanupInterval if it is not positive.
+func NewStore(cacheDir string, cleanupInterval time.Duration) *Store {
+	if cleanupInterval <= 0 {
+		cleanupInterval = DefaultCleanupInterval
+	}
+
 	store := &Store{
-		sessions: make(map[string]*Session),
-		clients:  make(map[string]anilist.AnilistClient),
-		cacheDir: cacheDir,
+		sessions:        make(map[string]*Session),
+		clients:         make(map[string]anilist.AnilistClient),
+		sharedClients:   make(map[string]anilist.AnilistClient),
+		contexts:        make(map[string]*sessionContext),
+		wsClients:       make(map[string]string),
+		cacheDir:        cacheDir,
+		cleanupInterval: cleanupInterval,
+		intervalCh:      make(chan time.Duration, 1),
+		maxIdle:         DefaultMaxIdle,
+		now:             time.Now,
 	}
 	
 	// Start cleanup goroutine to remove stale sessions
-	go store.cleanupLoop()
+	go store.cleanupLoop(cleanupInterval)
 	
 	return store
 }
 
+// SetCleanupInterval replaces the interval of the cleanup loop.
+// It does nothing if the interval has not changed.
+func (s *Store) SetCleanupInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+
+	s.mu.Lock()
+	if s.cleanupInterval == interval {
+		s.mu.Unlock()
+		return
+	}
+	s.cleanupInterval = interval
+	s.mu.Unlock()
+
+	// Drop the previous value if the loop hasn't picked it up yet
+	select {
+	case <-s.intervalCh:
+	default:
+	}
+	s.intervalCh <- interval
+}
+
+// GetCleanupInterval returns the current interval of the cleanup loop
+func (s *Store) GetCleanupInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cleanupInterval
+}
+
+// SetMaxIdle sets how long a session can go without being accessed before it is removed.
+// DefaultMaxIdle is used if it is not positive.
+func (s *Store) SetMaxIdle(maxIdle time.Duration) {
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdle
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxIdle = maxIdle
+}
+
+// GetMaxIdle returns how long a session can go without being accessed before it is removed
+func (s *Store) GetMaxIdle() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxIdle
+}
+
+// SetOnExpired sets the function called with the ID of each session removed by the cleanup.
+// It is called without the lock held.
+func (s *Store) SetOnExpired(fn func(sessionID string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpired = fn
+}
+
 // GetSession retrieves a session by ID, creating a simulated one if it doesn't exist
 func (s *Store) GetSession(sessionID string) *Session {
 	s.mu.RLock()
@@ -75,40 +222,136 @@ func (s *Store) GetSession(sessionID string) *Session {
 			ID:           sessionID,
 			Token:        "",
 			Username:     "",
-			Viewer:       nil,
-			CreatedAt:    time.Now(),
-			LastAccessed: time.Now(),
+			CreatedAt:    s.now(),
+			LastAccessed: s.now(),
 			IsSimulated:  true,
 		}
 		s.mu.Lock()
-		s.sessions[sessionID] = session
+		s.register(session)
+		s.resetContext(sessionID)
 		s.mu.Unlock()
 	} else {
 		// Update last accessed time
 		s.mu.Lock()
-		session.LastAccessed = time.Now()
+		session.LastAccessed = s.now()
 		s.mu.Unlock()
 	}
 	
 	return session
 }
 
-// SetSession stores or updates a session
+// SetSession stores or updates a session.
+// The Anilist requests still running with the previous session are cancelled.
 func (s *Store) SetSession(session *Session) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	session.LastAccessed = time.Now()
+	session.LastAccessed = s.now()
+	s.register(session)
+	s.resetContext(session.ID)
+}
+
+// register adds a session to the store, it must be called with the lock held
+func (s *Store) register(session *Session) {
+	session.getClient = func() anilist.AnilistClient {
+		return s.GetAnilistClient(session.ID)
+	}
 	s.sessions[session.ID] = session
 }
 
-// DeleteSession removes a session
+// DeleteSession removes a session and cancels its in-flight Anilist requests
 func (s *Store) DeleteSession(sessionID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
+	s.deleteSession(sessionID)
+}
+
+// deleteSession must be called with the lock held
+func (s *Store) deleteSession(sessionID string) {
+	if sc, ok := s.contexts[sessionID]; ok {
+		sc.cancel(ErrSessionDeleted)
+		delete(s.contexts, sessionID)
+	}
 	delete(s.sessions, sessionID)
-	delete(s.clients, sessionID)
+	s.deleteClient(sessionID)
+}
+
+// deleteClient removes the Anilist client of a session.
+// It must be called with the lock held.
+func (s *Store) deleteClient(sessionID string) {
+	if client, ok := s.clients[sessionID]; ok {
+		delete(s.clients, sessionID)
+		s.releaseClient(client)
+	}
+}
+
+// releaseClient closes a client that is no longer used by any session if it holds resources,
+// and removes it from the shared clients. It must be called with the lock held.
+func (s *Store) releaseClient(client anilist.AnilistClient) {
+	for _, c := range s.clients {
+		if c == client {
+			return
+		}
+	}
+
+	s.sharedMu.Lock()
+	for key, c := range s.sharedClients {
+		if c == client {
+			delete(s.sharedClients, key)
+		}
+	}
+	s.sharedMu.Unlock()
+
+	if closer, ok := client.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
+
+// resetContext replaces the context of a session, cancelling the previous one.
+// It must be called with the lock held.
+func (s *Store) resetContext(sessionID string) *sessionContext {
+	if sc, ok := s.contexts[sessionID]; ok {
+		sc.cancel(ErrSessionDeleted)
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	sc := &sessionContext{ctx: ctx, cancel: cancel}
+	s.contexts[sessionID] = sc
+	return sc
+}
+
+// GetContext returns the context of a session.
+// It is cancelled when the session is deleted or replaced and should be used for the Anilist requests made for the session.
+func (s *Store) GetContext(sessionID string) context.Context {
+	s.mu.RLock()
+	sc, exists := s.contexts[sessionID]
+	s.mu.RUnlock()
+	if exists {
+		return sc.ctx
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sc, exists = s.contexts[sessionID]; !exists {
+		sc = s.resetContext(sessionID)
+	}
+	return sc.ctx
+}
+
+// WithContext returns a copy of ctx that is also cancelled when the session is deleted or replaced.
+// The returned cancel function must be called to release the resources.
+func (s *Store) WithContext(ctx context.Context, sessionID string) (context.Context, context.CancelFunc) {
+	sessionCtx := s.GetContext(sessionID)
+
+	ret, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(sessionCtx, func() {
+		cancel(context.Cause(sessionCtx))
+	})
+
+	return ret, func() {
+		stop()
+		cancel(context.Canceled)
+	}
 }
 
 // GetAnilistClient returns the Anilist client for a session, creating one if needed
@@ -124,53 +367,74 @@ func (s *Store) GetAnilistClient(sessionID string) anilist.AnilistClient {
 		if session != nil && !session.IsSimulated {
 			token = session.Token
 		}
-		client = anilist.NewAnilistClient(token, s.cacheDir)
-		
-		s.mu.Lock()
-		s.clients[sessionID] = client
-		s.mu.Unlock()
+		return s.UpdateAnilistClient(sessionID, token)
 	}
 	
 	return client
 }
 
-// UpdateAnilistClient updates the Anilist client for a session with a new token
+// UpdateAnilistClient updates the Anilist client for a session with a new token.
+// Sessions with the same token share the same client.
 func (s *Store) UpdateAnilistClient(sessionID string, token string) anilist.AnilistClient {
-	client := anilist.NewAnilistClient(token, s.cacheDir)
-	
 	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var client anilist.AnilistClient
+	if token != "" {
+		client = s.GetSharedClientForToken(token)
+	} else {
+		client = anilist.NewAnilistClient("", s.cacheDir)
+	}
+
+	if previous, ok := s.clients[sessionID]; ok && previous != client {
+		delete(s.clients, sessionID)
+		s.releaseClient(previous)
+	}
 	s.clients[sessionID] = client
-	s.mu.Unlock()
-	
+
 	return client
 }
 
-// Login authenticates a session with an Anilist token
-func (s *Store) Login(sessionID string, token string, viewer *anilist.GetViewer_Viewer) error {
-	viewerBytes, err := json.Marshal(viewer)
-	if err != nil {
-		return err
+// GetSharedClientForToken returns the Anilist client shared by the sessions authenticated with the given token,
+// creating it if needed. The clients are indexed by the hash of their token.
+func (s *Store) GetSharedClientForToken(token string) anilist.AnilistClient {
+	key := hashToken(token)
+
+	s.sharedMu.RLock()
+	client, ok := s.sharedClients[key]
+	s.sharedMu.RUnlock()
+	if ok {
+		return client
 	}
-	
-	var viewerData anilist.GetViewer_Viewer
-	if err := json.Unmarshal(viewerBytes, &viewerData); err != nil {
-		return err
+
+	s.sharedMu.Lock()
+	defer s.sharedMu.Unlock()
+	if client, ok = s.sharedClients[key]; !ok {
+		client = anilist.NewAnilistClient(token, s.cacheDir)
+		s.sharedClients[key] = client
 	}
-	
+	return client
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Login authenticates a session with an Anilist token.
+// The viewer is not stored, it is fetched on first access by Session.GetViewer.
+func (s *Store) Login(sessionID string, token string, username string) {
 	session := &Session{
 		ID:           sessionID,
 		Token:        token,
-		Username:     viewer.Name,
-		Viewer:       &viewerData,
-		CreatedAt:    time.Now(),
-		LastAccessed: time.Now(),
+		Username:     username,
+		CreatedAt:    s.now(),
+		LastAccessed: s.now(),
 		IsSimulated:  false,
 	}
 	
 	s.SetSession(session)
 	s.UpdateAnilistClient(sessionID, token)
-	
-	return nil
 }
 
 // Logout logs out a session, converting it to simulated
@@ -179,9 +443,8 @@ func (s *Store) Logout(sessionID string) {
 		ID:           sessionID,
 		Token:        "",
 		Username:     "",
-		Viewer:       nil,
-		CreatedAt:    time.Now(),
-		LastAccessed: time.Now(),
+		CreatedAt:    s.now(),
+		LastAccessed: s.now(),
 		IsSimulated:  true,
 	}
 	
@@ -189,6 +452,59 @@ func (s *Store) Logout(sessionID string) {
 	s.UpdateAnilistClient(sessionID, "")
 }
 
+// Exists returns true if the session exists, unlike GetSession it does not create it
+func (s *Store) Exists(sessionID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.sessions[sessionID]
+	return ok
+}
+
+// RegisterClient associates a WebSocket client with a session
+func (s *Store) RegisterClient(clientID string, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wsClients[clientID] = sessionID
+}
+
+// UnregisterClient removes a WebSocket client
+func (s *Store) UnregisterClient(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.wsClients, clientID)
+}
+
+// GetClientIDs returns the WebSocket clients connected with the given sessions
+func (s *Store) GetClientIDs(sessionIDs ...string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]string, 0)
+	for clientID, sessionID := range s.wsClients {
+		for _, id := range sessionIDs {
+			if sessionID == id {
+				ret = append(ret, clientID)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// GetSessionIDsForUser returns the IDs of the sessions logged in as the given Anilist user
+func (s *Store) GetSessionIDsForUser(username string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]string, 0)
+	for id, session := range s.sessions {
+		if !session.IsSimulated && session.Username == username {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
 // GetAllSessions returns all active sessions (for admin purposes)
 func (s *Store) GetAllSessions() []*Session {
 	s.mu.RLock()
@@ -215,28 +531,183 @@ func (s *Store) GetAuthenticatedSessions() []*Session {
 	return sessions
 }
 
+// GetPrimaryAuthenticatedSession returns the oldest session that is logged in to Anilist, or nil if there is none.
+// Its account is used for the server-wide features.
+func (s *Store) GetPrimaryAuthenticatedSession() *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ret *Session
+	for _, session := range s.sessions {
+		if session.IsSimulated || session.Token == "" {
+			continue
+		}
+		// Sessions created at the same time are ordered by ID so that the result does not depend on the map order
+		if ret == nil || session.CreatedAt.Before(ret.CreatedAt) || (session.CreatedAt.Equal(ret.CreatedAt) && session.ID < ret.ID) {
+			ret = session
+		}
+	}
+	return ret
+}
+
+// IDPrefixLength is the number of characters of the session IDs exposed by Info
+const IDPrefixLength = 8
+
+// ErrSessionNotFound is returned when no session matches an ID prefix
+var ErrSessionNotFound = errors.New("session not found")
+
+// Info is a view of a session that does not expose its token or full ID
+type Info struct {
+	IDPrefix     string    `json:"idPrefix"`
+	Username     string    `json:"username"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastAccessed time.Time `json:"lastAccessed"`
+	IsSimulated  bool      `json:"isSimulated"`
+}
+
+// GetInfos returns the sanitized sessions, most recently accessed first
+func (s *Store) GetInfos() []*Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]*Info, 0, len(s.sessions))
+	for id, session := range s.sessions {
+		ret = append(ret, &Info{
+			IDPrefix:     idPrefix(id),
+			Username:     session.Username,
+			CreatedAt:    session.CreatedAt,
+			LastAccessed: session.LastAccessed,
+			IsSimulated:  session.IsSimulated || session.Token == "",
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].LastAccessed.Equal(ret[j].LastAccessed) {
+			return ret[i].IDPrefix < ret[j].IDPrefix
+		}
+		return ret[i].LastAccessed.After(ret[j].LastAccessed)
+	})
+	return ret
+}
+
+// ResolveIDPrefix returns the ID of the only session whose ID starts with the prefix
+func (s *Store) ResolveIDPrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return "", ErrSessionNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := ""
+	for id := range s.sessions {
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+		if ret != "" {
+			return "", errors.New("more than one session matches the ID prefix")
+		}
+		ret = id
+	}
+	if ret == "" {
+		return "", ErrSessionNotFound
+	}
+	return ret, nil
+}
+
+func idPrefix(id string) string {
+	if len(id) <= IDPrefixLength {
+		return id
+	}
+	return id[:IDPrefixLength]
+}
+
+// Stats is an aggregate view of the store that does not expose individual sessions
+type Stats struct {
+	TotalSessions         int       `json:"totalSessions"`
+	AuthenticatedSessions int       `json:"authenticatedSessions"`
+	SimulatedSessions     int       `json:"simulatedSessions"`
+	OldestSession         time.Time `json:"oldestSession"`
+	NewestSession         time.Time `json:"newestSession"`
+	AverageAgeMinutes     float64   `json:"averageAgeMinutes"`
+}
+
+// GetStats returns aggregate statistics about the active sessions
+func (s *Store) GetStats() *Stats {
+	sessions := s.GetAllSessions()
+
+	ret := &Stats{
+		TotalSessions: len(sessions),
+	}
+	if len(sessions) == 0 {
+		return ret
+	}
+
+	now := time.Now()
+	var totalAge time.Duration
+	for _, session := range sessions {
+		if !session.IsSimulated && session.Token != "" {
+			ret.AuthenticatedSessions++
+		} else {
+			ret.SimulatedSessions++
+		}
+		if ret.OldestSession.IsZero() || session.CreatedAt.Before(ret.OldestSession) {
+			ret.OldestSession = session.CreatedAt
+		}
+		if session.CreatedAt.After(ret.NewestSession) {
+			ret.NewestSession = session.CreatedAt
+		}
+		totalAge += now.Sub(session.CreatedAt)
+	}
+	ret.AverageAgeMinutes = totalAge.Minutes() / float64(len(sessions))
+
+	return ret
+}
+
 // cleanupLoop periodically removes stale sessions
-func (s *Store) cleanupLoop() {
-	ticker := time.NewTicker(1 * time.Hour)
+func (s *Store) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	
-	for range ticker.C {
-		s.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			s.cleanup()
+		case interval = <-s.intervalCh:
+			ticker.Reset(interval)
+		}
 	}
 }
 
-// cleanup removes sessions that haven't been accessed in 7 days
+// cleanup removes the sessions that haven't been accessed within the max idle duration
+// and notifies onExpired of each of them.
 func (s *Store) cleanup() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	cutoff := time.Now().Add(-7 * 24 * time.Hour)
+
+	expired := make([]string, 0)
+	cutoff := s.now().Add(-s.maxIdle)
 	for id, session := range s.sessions {
 		if session.LastAccessed.Before(cutoff) {
-			delete(s.sessions, id)
-			delete(s.clients, id)
+			s.deleteSession(id)
+			expired = append(expired, id)
+		}
+	}
+
+	// Clients can be created for sessions that were never registered or were removed without deleteSession
+	for id := range s.clients {
+		if _, ok := s.sessions[id]; !ok {
+			s.deleteClient(id)
 		}
 	}
+
+	onExpired := s.onExpired
+	s.mu.Unlock()
+
+	if onExpired == nil {
+		return
+	}
+	for _, id := range expired {
+		onExpired(id)
+	}
 }
 
 // Context key for session
@@ -0,0 +1,266 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/api/anilist"
+	"testing"
+	"time"
+
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_DeleteSessionCancelsContext(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	store.SetSession(&Session{ID: "a", IsSimulated: true})
+	store.SetSession(&Session{ID: "b", IsSimulated: true})
+
+	ctx, cancel := store.WithContext(context.Background(), "a")
+	defer cancel()
+	otherCtx := store.GetContext("b")
+
+	store.DeleteSession("a")
+
+	<-ctx.Done()
+	assert.ErrorIs(t, context.Cause(ctx), ErrSessionDeleted)
+	assert.NoError(t, otherCtx.Err())
+}
+
+func TestStore_SetSessionReplacesContext(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	store.SetSession(&Session{ID: "a", IsSimulated: true})
+	previous := store.GetContext("a")
+
+	store.SetSession(&Session{ID: "a", Token: "token"})
+
+	require.Error(t, previous.Err())
+	assert.NoError(t, store.GetContext("a").Err())
+}
+
+func TestStore_WithContextCancel(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	ctx, cancel := store.WithContext(context.Background(), "a")
+	cancel()
+
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.NoError(t, store.GetContext("a").Err())
+}
+
+// viewerClientStub only implements GetViewer, calling any other method will panic.
+type viewerClientStub struct {
+	anilist.AnilistClient
+	name  string
+	err   error
+	calls int
+}
+
+func (s *viewerClientStub) GetViewer(_ context.Context, _ ...clientv2.RequestInterceptor) (*anilist.GetViewer, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &anilist.GetViewer{Viewer: &anilist.GetViewer_Viewer{Name: s.name}}, nil
+}
+
+func TestSession_GetViewer(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	store.Login("a", "token", "user")
+
+	client := &viewerClientStub{name: "user"}
+	sess := store.GetSession("a")
+	sess.getClient = func() anilist.AnilistClient { return client }
+
+	// The viewer is loaded on first access and cached
+	viewer, err := sess.GetViewer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "user", viewer.Name)
+	_, err = sess.GetViewer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.calls)
+
+	// It is refreshed once the cache is stale
+	client.name = "renamed"
+	sess.viewerCachedAt = time.Now().Add(-ViewerCacheTTL)
+	viewer, err = sess.GetViewer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", viewer.Name)
+	assert.Equal(t, 2, client.calls)
+
+	// The stale viewer is used when Anilist cannot be reached
+	client.err = errors.New("unavailable")
+	sess.viewerCachedAt = time.Now().Add(-ViewerCacheTTL)
+	_, err = sess.GetViewer(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "renamed", sess.ToUser(context.Background()).Viewer.Name)
+
+	store.Logout("a")
+	_, err = store.GetSession("a").GetViewer(context.Background())
+	assert.Error(t, err)
+	assert.True(t, store.GetSession("a").ToUser(context.Background()).IsSimulated)
+}
+
+func TestStore_GetPrimaryAuthenticatedSession(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	assert.Nil(t, store.GetPrimaryAuthenticatedSession())
+
+	now := time.Now()
+	store.SetSession(&Session{ID: "simulated", IsSimulated: true, CreatedAt: now.Add(-3 * time.Hour)})
+	store.SetSession(&Session{ID: "b", Token: "token-b", Username: "b", CreatedAt: now.Add(-1 * time.Hour)})
+	store.SetSession(&Session{ID: "a", Token: "token-a", Username: "a", CreatedAt: now.Add(-2 * time.Hour)})
+
+	primary := store.GetPrimaryAuthenticatedSession()
+	require.NotNil(t, primary)
+	assert.Equal(t, "a", primary.ID)
+
+	store.Logout("a")
+	primary = store.GetPrimaryAuthenticatedSession()
+	require.NotNil(t, primary)
+	assert.Equal(t, "b", primary.ID)
+
+	store.Logout("b")
+	assert.Nil(t, store.GetPrimaryAuthenticatedSession())
+}
+
+func TestStore_CleanupRemovesOrphanedClients(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	store.SetSession(&Session{ID: "active", IsSimulated: true})
+	store.SetSession(&Session{ID: "stale", IsSimulated: true})
+	store.GetAnilistClient("active")
+	store.GetAnilistClient("stale")
+	// Client of a session that is not in the store
+	store.UpdateAnilistClient("orphan", "")
+
+	store.mu.Lock()
+	store.sessions["stale"].LastAccessed = time.Now().Add(-8 * 24 * time.Hour)
+	store.mu.Unlock()
+
+	store.cleanup()
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	assert.Len(t, store.clients, 1)
+	for id := range store.clients {
+		assert.Contains(t, store.sessions, id)
+	}
+}
+
+// closableClientStub records whether it has been closed
+type closableClientStub struct {
+	anilist.AnilistClient
+	closed bool
+}
+
+func (s *closableClientStub) Close() {
+	s.closed = true
+}
+
+func TestStore_CleanupExpiresIdleSessions(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	var expired []string
+	store.SetOnExpired(func(sessionID string) {
+		// The callback is called without the lock held
+		assert.False(t, store.Exists(sessionID))
+		expired = append(expired, sessionID)
+	})
+
+	store.Login("idle", "token", "user")
+	store.SetSession(&Session{ID: "active", IsSimulated: true})
+	client := &closableClientStub{}
+	store.mu.Lock()
+	store.clients["idle"] = client
+	store.mu.Unlock()
+
+	// Nothing expires before the default max idle duration
+	now = now.Add(DefaultMaxIdle - time.Minute)
+	store.GetSession("active")
+	store.cleanup()
+	assert.Empty(t, expired)
+
+	now = now.Add(2 * time.Minute)
+	store.cleanup()
+	assert.Equal(t, []string{"idle"}, expired)
+	assert.True(t, client.closed)
+	assert.True(t, store.Exists("active"))
+
+	// A shorter max idle duration is picked up by the next cleanup
+	store.SetMaxIdle(time.Hour)
+	now = now.Add(time.Hour + time.Minute)
+	store.cleanup()
+	assert.Equal(t, []string{"idle", "active"}, expired)
+
+	store.SetMaxIdle(0)
+	assert.Equal(t, DefaultMaxIdle, store.GetMaxIdle())
+}
+
+func TestStore_SharedClientForToken(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	// Two tabs of the same user share the client
+	store.Login("tab-1", "token", "user")
+	store.Login("tab-2", "token", "user")
+	store.Login("other", "other-token", "other")
+	assert.Same(t, store.GetAnilistClient("tab-1"), store.GetAnilistClient("tab-2"))
+	assert.Same(t, store.GetSharedClientForToken("token"), store.GetAnilistClient("tab-1"))
+	assert.NotSame(t, store.GetAnilistClient("tab-1"), store.GetAnilistClient("other"))
+
+	// Simulated sessions are not shared
+	store.GetSession("simulated-1")
+	store.GetSession("simulated-2")
+	assert.NotSame(t, store.GetAnilistClient("simulated-1"), store.GetAnilistClient("simulated-2"))
+
+	// The shared client is kept while a session uses it
+	shared := store.GetAnilistClient("tab-1")
+	store.DeleteSession("tab-1")
+	assert.Same(t, shared, store.GetAnilistClient("tab-2"))
+	store.Logout("tab-2")
+	assert.NotSame(t, shared, store.GetAnilistClient("tab-2"))
+
+	store.sharedMu.RLock()
+	assert.Len(t, store.sharedClients, 1)
+	store.sharedMu.RUnlock()
+	assert.NotSame(t, shared, store.GetSharedClientForToken("token"))
+}
+
+func TestStore_GetInfos(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.Login("aaaaaaaa-1111", "token", "user")
+	now = now.Add(time.Minute)
+	store.GetSession("aaaaaaaa-2222")
+	store.GetSession("bbbbbbbb-3333")
+
+	infos := store.GetInfos()
+	assert.Len(t, infos, 3)
+	assert.Equal(t, "user", infos[2].Username)
+	assert.False(t, infos[2].IsSimulated)
+	for _, info := range infos {
+		assert.Len(t, info.IDPrefix, IDPrefixLength)
+	}
+
+	id, err := store.ResolveIDPrefix("bbbbbbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, "bbbbbbbb-3333", id)
+
+	id, err = store.ResolveIDPrefix("aaaaaaaa-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaaaaaa-1111", id)
+
+	_, err = store.ResolveIDPrefix("aaaaaaaa")
+	assert.Error(t, err)
+	_, err = store.ResolveIDPrefix("cccc")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = store.ResolveIDPrefix("")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -0,0 +1,322 @@
+package syncstatus
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/platforms/platform"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tracker records the outcome of AniList mutations so users can see whether their updates actually landed.
+// Failed mutations are kept as pending items that can be retried or discarded.
+type Tracker struct {
+	mu          sync.Mutex
+	users       map[string]*userState
+	maxFailures int
+}
+
+type MediaKind string
+
+const (
+	MediaKindAnime MediaKind = "anime"
+	MediaKindManga MediaKind = "manga"
+)
+
+// Mutation is a progress update that failed and is waiting to be retried.
+type Mutation struct {
+	ID            string    `json:"id"`
+	MediaID       int       `json:"mediaId"`
+	Kind          MediaKind `json:"kind"`
+	Progress      int       `json:"progress"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"createdAt"`
+	LastAttemptAt time.Time `json:"lastAttemptAt"`
+	AgeSeconds    float64   `json:"ageSeconds"`
+
+	retry func(ctx context.Context) error
+}
+
+// Failure is a past mutation failure.
+type Failure struct {
+	MediaID  int       `json:"mediaId"`
+	Kind     MediaKind `json:"kind"`
+	Progress int       `json:"progress"`
+	Error    string    `json:"error"`
+	At       time.Time `json:"at"`
+}
+
+// StaleMutation is a progress update that was not sent because the progress on AniList was already ahead,
+// e.g. it was updated from another device in the meantime.
+type StaleMutation struct {
+	MediaID        int       `json:"mediaId"`
+	Kind           MediaKind `json:"kind"`
+	Progress       int       `json:"progress"`
+	RemoteProgress int       `json:"remoteProgress"`
+	At             time.Time `json:"at"`
+}
+
+// Status is the per-user summary returned by the sync-status endpoint.
+type Status struct {
+	Username             string      `json:"username"`
+	IsOnline             bool        `json:"isOnline"`
+	Pending              []*Mutation `json:"pending"`
+	RecentFailures       []*Failure  `json:"recentFailures"`
+	LastSuccessAt        *time.Time  `json:"lastSuccessAt,omitempty"`
+	LastSuccessMediaID   int         `json:"lastSuccessMediaId,omitempty"`
+	LastSuccessMediaKind MediaKind   `json:"lastSuccessMediaKind,omitempty"`
+	// RecentStale are the updates skipped because the progress on AniList was ahead, most recent first
+	RecentStale []*StaleMutation `json:"recentStale"`
+	// AnimeCollectionCache is the health of the cached anime collection, it is set by the sync-status endpoint
+	AnimeCollectionCache *platform.CacheHealth `json:"animeCollectionCache,omitempty"`
+}
+
+// MediaState is merged into entry responses when a media has a pending or failed update.
+type MediaState struct {
+	HasPending bool      `json:"hasPending"`
+	HasFailed  bool      `json:"hasFailed"`
+	MutationID string    `json:"mutationId,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Progress   int       `json:"progress"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type userState struct {
+	pending       map[string]*Mutation
+	failures      []*Failure
+	stale         []*StaleMutation
+	lastSuccessAt time.Time
+	lastSuccess   struct {
+		mediaID int
+		kind    MediaKind
+	}
+}
+
+var ErrMutationNotFound = errors.New("sync status: mutation not found")
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		users:       make(map[string]*userState),
+		maxFailures: 20,
+	}
+}
+
+func (t *Tracker) getUser(username string) *userState {
+	u, ok := t.users[username]
+	if !ok {
+		u = &userState{pending: make(map[string]*Mutation)}
+		t.users[username] = u
+	}
+	return u
+}
+
+// Record records the outcome of a progress update.
+// On failure, the mutation is added to the pending items and retry is stored so that it can be retried later.
+// On success, any pending mutation for the same media is removed since it has been superseded.
+func (t *Tracker) Record(username string, kind MediaKind, mediaID int, progress int, err error, retry func(ctx context.Context) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.getUser(username)
+	now := time.Now()
+
+	if err == nil {
+		u.lastSuccessAt = now
+		u.lastSuccess.mediaID = mediaID
+		u.lastSuccess.kind = kind
+		for id, m := range u.pending {
+			if m.MediaID == mediaID && m.Kind == kind {
+				delete(u.pending, id)
+			}
+		}
+		return
+	}
+
+	u.failures = append(u.failures, &Failure{
+		MediaID:  mediaID,
+		Kind:     kind,
+		Progress: progress,
+		Error:    err.Error(),
+		At:       now,
+	})
+	if len(u.failures) > t.maxFailures {
+		u.failures = u.failures[len(u.failures)-t.maxFailures:]
+	}
+
+	// Replace the previous pending mutation for the same media
+	for id, m := range u.pending {
+		if m.MediaID == mediaID && m.Kind == kind {
+			delete(u.pending, id)
+		}
+	}
+
+	m := &Mutation{
+		ID:            uuid.NewString(),
+		MediaID:       mediaID,
+		Kind:          kind,
+		Progress:      progress,
+		Error:         err.Error(),
+		Attempts:      1,
+		CreatedAt:     now,
+		LastAttemptAt: now,
+		retry:         retry,
+	}
+	u.pending[m.ID] = m
+}
+
+// RecordStale records a progress update that was skipped because the progress on AniList was already ahead.
+// Any pending mutation for the same media is removed since it would move the progress backwards.
+func (t *Tracker) RecordStale(username string, kind MediaKind, mediaID int, progress int, remoteProgress int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.getUser(username)
+
+	u.stale = append(u.stale, &StaleMutation{
+		MediaID:        mediaID,
+		Kind:           kind,
+		Progress:       progress,
+		RemoteProgress: remoteProgress,
+		At:             time.Now(),
+	})
+	if len(u.stale) > t.maxFailures {
+		u.stale = u.stale[len(u.stale)-t.maxFailures:]
+	}
+
+	for id, m := range u.pending {
+		if m.MediaID == mediaID && m.Kind == kind {
+			delete(u.pending, id)
+		}
+	}
+}
+
+// Retry runs the pending mutation again.
+// The mutation is removed from the pending items if it succeeds.
+func (t *Tracker) Retry(ctx context.Context, username string, id string) error {
+	t.mu.Lock()
+	u := t.getUser(username)
+	m, ok := u.pending[id]
+	t.mu.Unlock()
+
+	if !ok || m.retry == nil {
+		return ErrMutationNotFound
+	}
+
+	err := m.retry(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if err == nil {
+		delete(u.pending, id)
+		u.lastSuccessAt = now
+		u.lastSuccess.mediaID = m.MediaID
+		u.lastSuccess.kind = m.Kind
+		return nil
+	}
+
+	m.Attempts++
+	m.LastAttemptAt = now
+	m.Error = err.Error()
+	u.failures = append(u.failures, &Failure{
+		MediaID:  m.MediaID,
+		Kind:     m.Kind,
+		Progress: m.Progress,
+		Error:    err.Error(),
+		At:       now,
+	})
+	if len(u.failures) > t.maxFailures {
+		u.failures = u.failures[len(u.failures)-t.maxFailures:]
+	}
+
+	return err
+}
+
+// Discard removes a pending mutation without retrying it.
+func (t *Tracker) Discard(username string, id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.getUser(username)
+	if _, ok := u.pending[id]; !ok {
+		return ErrMutationNotFound
+	}
+	delete(u.pending, id)
+	return nil
+}
+
+// GetStatus returns the sync status of a user.
+func (t *Tracker) GetStatus(username string, isOnline bool) *Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.getUser(username)
+	now := time.Now()
+
+	ret := &Status{
+		Username:       username,
+		IsOnline:       isOnline,
+		Pending:        make([]*Mutation, 0, len(u.pending)),
+		RecentFailures: make([]*Failure, 0, len(u.failures)),
+		RecentStale:    make([]*StaleMutation, 0, len(u.stale)),
+	}
+
+	for _, m := range u.pending {
+		cp := *m
+		cp.AgeSeconds = now.Sub(m.CreatedAt).Seconds()
+		ret.Pending = append(ret.Pending, &cp)
+	}
+	sort.Slice(ret.Pending, func(i, j int) bool {
+		return ret.Pending[i].CreatedAt.Before(ret.Pending[j].CreatedAt)
+	})
+
+	// Most recent first
+	for i := len(u.failures) - 1; i >= 0; i-- {
+		cp := *u.failures[i]
+		ret.RecentFailures = append(ret.RecentFailures, &cp)
+	}
+	for i := len(u.stale) - 1; i >= 0; i-- {
+		cp := *u.stale[i]
+		ret.RecentStale = append(ret.RecentStale, &cp)
+	}
+
+	if !u.lastSuccessAt.IsZero() {
+		lastSuccessAt := u.lastSuccessAt
+		ret.LastSuccessAt = &lastSuccessAt
+		ret.LastSuccessMediaID = u.lastSuccess.mediaID
+		ret.LastSuccessMediaKind = u.lastSuccess.kind
+	}
+
+	return ret
+}
+
+// GetMediaState returns the state of a media if it has a pending or failed update, nil otherwise.
+func (t *Tracker) GetMediaState(username string, kind MediaKind, mediaID int) *MediaState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u, ok := t.users[username]
+	if !ok {
+		return nil
+	}
+
+	for _, m := range u.pending {
+		if m.MediaID == mediaID && m.Kind == kind {
+			return &MediaState{
+				HasPending: true,
+				HasFailed:  true,
+				MutationID: m.ID,
+				Error:      m.Error,
+				Progress:   m.Progress,
+				UpdatedAt:  m.LastAttemptAt,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package syncstatus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+
+	shouldFail := true
+	retry := func(ctx context.Context) error {
+		if shouldFail {
+			return errors.New("rate limited")
+		}
+		return nil
+	}
+
+	tracker.Record("user", MediaKindAnime, 1, 5, errors.New("rate limited"), retry)
+	tracker.Record("user", MediaKindManga, 2, 10, nil, nil)
+
+	status := tracker.GetStatus("user", true)
+	require.Len(t, status.Pending, 1)
+	require.Len(t, status.RecentFailures, 1)
+	require.NotNil(t, status.LastSuccessAt)
+	assert.Equal(t, 2, status.LastSuccessMediaID)
+	assert.True(t, status.IsOnline)
+
+	state := tracker.GetMediaState("user", MediaKindAnime, 1)
+	require.NotNil(t, state)
+	assert.True(t, state.HasPending)
+	assert.Equal(t, 5, state.Progress)
+	assert.Nil(t, tracker.GetMediaState("user", MediaKindManga, 1))
+	assert.Nil(t, tracker.GetMediaState("other", MediaKindAnime, 1))
+
+	// Failed retry keeps the mutation pending
+	id := status.Pending[0].ID
+	err := tracker.Retry(context.Background(), "user", id)
+	require.Error(t, err)
+	status = tracker.GetStatus("user", true)
+	require.Len(t, status.Pending, 1)
+	assert.Equal(t, 2, status.Pending[0].Attempts)
+	assert.Len(t, status.RecentFailures, 2)
+
+	// Successful retry removes it
+	shouldFail = false
+	require.NoError(t, tracker.Retry(context.Background(), "user", id))
+	assert.Empty(t, tracker.GetStatus("user", true).Pending)
+	assert.ErrorIs(t, tracker.Retry(context.Background(), "user", id), ErrMutationNotFound)
+
+	// A later successful update supersedes the pending mutation
+	tracker.Record("user", MediaKindAnime, 3, 1, errors.New("offline"), retry)
+	tracker.Record("user", MediaKindAnime, 3, 2, nil, nil)
+	assert.Empty(t, tracker.GetStatus("user", true).Pending)
+
+	// Discard
+	tracker.Record("user", MediaKindAnime, 4, 1, errors.New("offline"), retry)
+	id = tracker.GetStatus("user", true).Pending[0].ID
+	require.NoError(t, tracker.Discard("user", id))
+	assert.Empty(t, tracker.GetStatus("user", true).Pending)
+	assert.ErrorIs(t, tracker.Discard("user", id), ErrMutationNotFound)
+}
+
+func TestTracker_RecordStale(t *testing.T) {
+	tracker := NewTracker()
+
+	tracker.Record("user", MediaKindAnime, 1, 4, errors.New("offline"), func(ctx context.Context) error { return nil })
+	tracker.RecordStale("user", MediaKindAnime, 1, 5, 6)
+	tracker.RecordStale("user", MediaKindAnime, 2, 1, 3)
+
+	status := tracker.GetStatus("user", true)
+	// The pending update would move the progress backwards
+	assert.Empty(t, status.Pending)
+	require.Len(t, status.RecentStale, 2)
+	assert.Equal(t, 2, status.RecentStale[0].MediaID)
+	assert.Equal(t, 5, status.RecentStale[1].Progress)
+	assert.Equal(t, 6, status.RecentStale[1].RemoteProgress)
+	assert.Nil(t, status.LastSuccessAt)
+
+	assert.Empty(t, tracker.GetStatus("other", true).RecentStale)
+}
@@ -0,0 +1,660 @@
+package batchwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/metadata_provider"
+	"seanime/internal/database/db"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/notifications"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/torrent_client"
+	torrent_analyzer "seanime/internal/torrents/analyzer"
+	"seanime/internal/torrents/torrent"
+	"seanime/internal/util"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/strutil"
+	"github.com/adrg/strutil/metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// checkInterval is the time between two checks of the watched torrents
+	checkInterval = time.Hour
+	// searchInterval is the time before the provider is searched again for a replacement of a watched torrent
+	searchInterval = 6 * time.Hour
+	// minNameSimilarity is the minimum similarity between the names of a watched torrent and its replacement
+	minNameSimilarity = 0.8
+)
+
+var ErrWatchNotFound = errors.New("batchwatch: watch not found")
+
+type (
+	// Manager watches the batch torrents whose files were smart-selected.
+	//
+	// Batch torrents of airing series are often updated when new episodes are released:
+	//  - The torrent client reports more files for the same torrent (e.g. the torrent was edited in place).
+	//  - The provider lists a replacement torrent with a matching name, the old one is not updated.
+	//
+	// In both cases the new files are analyzed and the ones matching episodes that are still missing are selected.
+	// Files that were already selected are never deselected, they may have been completed.
+	Manager struct {
+		db                  *db.Database
+		logger              *zerolog.Logger
+		platformRef         *util.Ref[platform.Platform]
+		metadataProviderRef *util.Ref[metadata_provider.Provider]
+		torrentRepository   *torrent.Repository
+		notifications       *notifications.Manager
+		isPausedFunc        func() bool
+
+		mu     sync.Mutex
+		client torrentClient
+		// getMedia, analyze, search, getMagnet and now are replaced in tests
+		getMedia  func(ctx context.Context, mediaId int) (*anilist.CompleteAnime, error)
+		analyze   func(media *anilist.CompleteAnime, filepaths []string) (map[int]int, error)
+		search    func(ctx context.Context, watch *Watch, media *anilist.CompleteAnime) ([]*hibiketorrent.AnimeTorrent, error)
+		getMagnet func(t *hibiketorrent.AnimeTorrent) (string, error)
+		now       func() time.Time
+	}
+
+	NewManagerOptions struct {
+		DB                      *db.Database
+		Logger                  *zerolog.Logger
+		PlatformRef             *util.Ref[platform.Platform]
+		MetadataProviderRef     *util.Ref[metadata_provider.Provider]
+		TorrentRepository       *torrent.Repository
+		TorrentClientRepository *torrent_client.Repository
+		Notifications           *notifications.Manager
+		IsPausedFunc            func() bool
+	}
+
+	// Watch is a batch torrent watched for new episodes.
+	Watch struct {
+		DbId    uint   `json:"dbId"`
+		Hash    string `json:"hash"`
+		MediaId int    `json:"mediaId"`
+		Enabled bool   `json:"enabled"`
+		// Name is the name of the torrent, replacements are found by comparing it with the search results
+		Name     string `json:"name"`
+		Provider string `json:"provider"`
+		// Destination is the directory of the torrent, as seen by the torrent client
+		Destination string `json:"destination"`
+		// Files are the names of the files of the torrent during the last check
+		Files []string `json:"files"`
+		// SelectedEpisodes are the episodes whose files were selected
+		SelectedEpisodes []int `json:"selectedEpisodes"`
+		// ReplacedHashes are the hashes of the previous torrents and of the replacements that had no new episodes
+		ReplacedHashes []string  `json:"replacedHashes"`
+		LastCheckedAt  time.Time `json:"lastCheckedAt"`
+		LastSearchedAt time.Time `json:"lastSearchedAt"`
+	}
+
+	// torrentClient is implemented by torrent_client.Repository.
+	torrentClient interface {
+		Start() bool
+		TorrentExists(hash string) bool
+		GetTorrentFiles(hash string) ([]*torrent_client.TorrentFile, error)
+		GetFiles(hash string) ([]string, error)
+		SelectFiles(hash string, indices []int) error
+		DeselectFiles(hash string, indices []int) error
+		AddMagnets(magnets []string, dest string) error
+		PauseTorrents(hashes []string) error
+		ResumeTorrents(hashes []string) error
+		RemoveTorrentsKeepData(hashes []string) error
+	}
+)
+
+func New(opts *NewManagerOptions) *Manager {
+	ret := &Manager{
+		db:                  opts.DB,
+		logger:              opts.Logger,
+		platformRef:         opts.PlatformRef,
+		metadataProviderRef: opts.MetadataProviderRef,
+		torrentRepository:   opts.TorrentRepository,
+		notifications:       opts.Notifications,
+		isPausedFunc:        opts.IsPausedFunc,
+		now:                 time.Now,
+	}
+	ret.getMedia = ret.getAnilistMedia
+	ret.analyze = ret.analyzeFiles
+	ret.search = ret.searchProvider
+	ret.getMagnet = ret.getProviderMagnet
+	ret.SetTorrentClientRepository(opts.TorrentClientRepository)
+	return ret
+}
+
+// SetTorrentClientRepository should be called every time the torrent client is initialized.
+func (m *Manager) SetTorrentClientRepository(repo *torrent_client.Repository) {
+	if m == nil || repo == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.client = repo
+}
+
+// Start checks the watched torrents periodically in a goroutine.
+func (m *Manager) Start() {
+	if m == nil {
+		return
+	}
+	go func() {
+		defer util.HandlePanicInModuleThen("batchwatch/Start", func() {})
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.Check()
+		}
+	}()
+}
+
+// Check looks for new episodes in the enabled watched torrents.
+// It returns immediately if a check is already running or if the task is paused.
+func (m *Manager) Check() {
+	defer util.HandlePanicInModuleThen("batchwatch/Check", func() {})
+
+	if m.isPausedFunc != nil && m.isPausedFunc() {
+		return
+	}
+
+	if !m.mu.TryLock() {
+		return
+	}
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		return
+	}
+
+	watches, err := m.getWatches()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("batchwatch: Failed to get the watched torrents")
+		return
+	}
+
+	watches = slices.DeleteFunc(watches, func(w *Watch) bool { return !w.Enabled })
+	if len(watches) == 0 {
+		return
+	}
+
+	if !m.client.Start() {
+		m.logger.Warn().Msg("batchwatch: Failed to start the torrent client")
+		return
+	}
+
+	for _, w := range watches {
+		m.checkWatch(w)
+	}
+}
+
+// AddWatch starts watching a batch torrent whose files were selected.
+// The current files of the torrent are recorded so that only the files added later are considered.
+func (m *Manager) AddWatch(w *Watch) (*Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		return nil, errors.New("batchwatch: torrent client is not available")
+	}
+
+	w.Hash = strings.ToLower(w.Hash)
+	files, err := m.client.GetTorrentFiles(w.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	w.DbId = 0
+	w.Enabled = true
+	w.Files = getFileNames(files)
+	w.SelectedEpisodes = uniqueSorted(w.SelectedEpisodes)
+	w.ReplacedHashes = make([]string, 0)
+	w.LastCheckedAt = m.now()
+	w.LastSearchedAt = m.now()
+
+	if err := m.saveWatch(w); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info().Str("hash", w.Hash).Int("mediaId", w.MediaId).Msg("batchwatch: Watching batch torrent for new episodes")
+	return w, nil
+}
+
+// GetWatches returns the watched torrents.
+func (m *Manager) GetWatches() ([]*Watch, error) {
+	return m.getWatches()
+}
+
+// SetWatchEnabled enables or disables a watch without deleting it.
+func (m *Manager) SetWatchEnabled(id uint, enabled bool) (*Watch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, err := m.getWatch(id)
+	if err != nil {
+		return nil, err
+	}
+	w.Enabled = enabled
+	if err := m.saveWatch(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// DeleteWatch stops watching a torrent. The torrent is not removed from the client.
+func (m *Manager) DeleteWatch(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getWatch(id); err != nil {
+		return err
+	}
+	return m.db.DeleteBatchTorrentWatch(id)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+func (m *Manager) checkWatch(w *Watch) {
+	files, err := m.client.GetTorrentFiles(w.Hash)
+	if errors.Is(err, torrent_client.ErrTorrentNotFound) {
+		// The torrent was removed by the user
+		m.logger.Info().Str("hash", w.Hash).Msg("batchwatch: Torrent was removed from the client, disabling watch")
+		w.Enabled = false
+		m.saveOrLog(w)
+		return
+	}
+	if err != nil {
+		m.logger.Warn().Err(err).Str("hash", w.Hash).Msg("batchwatch: Failed to get the files of the torrent")
+		return
+	}
+
+	w.LastCheckedAt = m.now()
+
+	// In-place update, the torrent client reports a different number of files
+	if len(files) > 0 && len(files) != len(w.Files) {
+		m.checkNewFiles(w, files)
+	}
+
+	// Replacement torrent
+	if w.Provider != "" && m.now().Sub(w.LastSearchedAt) >= searchInterval {
+		w.LastSearchedAt = m.now()
+		m.checkReplacement(w)
+	}
+
+	m.saveOrLog(w)
+}
+
+// checkNewFiles selects the new files of the torrent that match episodes that are still missing.
+// The files that are not selected are left as they are.
+func (m *Manager) checkNewFiles(w *Watch, files []*torrent_client.TorrentFile) {
+	media, err := m.getMedia(context.Background(), w.MediaId)
+	if err != nil {
+		m.logger.Warn().Err(err).Int("mediaId", w.MediaId).Msg("batchwatch: Failed to get media")
+		return
+	}
+
+	selected, err := m.selectMissingEpisodes(w, media, files)
+	if err != nil {
+		m.logger.Warn().Err(err).Str("hash", w.Hash).Msg("batchwatch: Failed to analyze the new files")
+		return
+	}
+
+	if len(selected) > 0 {
+		if err := m.client.SelectFiles(w.Hash, getIndices(selected)); err != nil {
+			m.logger.Warn().Err(err).Str("hash", w.Hash).Msg("batchwatch: Failed to select the new files")
+			return
+		}
+		m.addSelected(w, selected)
+		m.notify(w, media, selected)
+	}
+
+	w.Files = getFileNames(files)
+}
+
+// checkReplacement searches the provider for a torrent replacing the watched one.
+// The replacement is added to the same destination with only the files of the missing episodes selected.
+func (m *Manager) checkReplacement(w *Watch) {
+	media, err := m.getMedia(context.Background(), w.MediaId)
+	if err != nil {
+		m.logger.Warn().Err(err).Int("mediaId", w.MediaId).Msg("batchwatch: Failed to get media")
+		return
+	}
+
+	results, err := m.search(context.Background(), w, media)
+	if err != nil {
+		m.logger.Warn().Err(err).Str("provider", w.Provider).Msg("batchwatch: Failed to search for a replacement torrent")
+		return
+	}
+
+	replacement, ok := findReplacement(w, results)
+	if !ok {
+		return
+	}
+	hash := strings.ToLower(replacement.InfoHash)
+
+	m.logger.Debug().Str("hash", w.Hash).Str("replacement", replacement.Name).Msg("batchwatch: Found a replacement torrent")
+
+	// The replacement is only checked once
+	w.ReplacedHashes = append(w.ReplacedHashes, hash)
+
+	if m.client.TorrentExists(hash) {
+		return
+	}
+
+	magnet, err := m.getMagnet(replacement)
+	if err != nil {
+		m.logger.Warn().Err(err).Str("name", replacement.Name).Msg("batchwatch: Failed to get the magnet link of the replacement torrent")
+		return
+	}
+
+	if err := m.client.AddMagnets([]string{magnet}, w.Destination); err != nil {
+		m.logger.Warn().Err(err).Str("name", replacement.Name).Msg("batchwatch: Failed to add the replacement torrent")
+		return
+	}
+
+	// Wait for the metadata and pause the torrent before anything is downloaded
+	if _, err := m.client.GetFiles(hash); err != nil {
+		m.logger.Warn().Err(err).Str("name", replacement.Name).Msg("batchwatch: Failed to get the files of the replacement torrent")
+		_ = m.client.RemoveTorrentsKeepData([]string{hash})
+		return
+	}
+	_ = m.client.PauseTorrents([]string{hash})
+
+	files, err := m.client.GetTorrentFiles(hash)
+	if err == nil {
+		var selected map[int]int
+		selected, err = m.selectMissingEpisodes(w, media, files)
+		if err == nil && len(selected) > 0 {
+			// The files of the replacement were all selected when it was added
+			if others := getOtherIndices(files, selected); len(others) > 0 {
+				err = m.client.DeselectFiles(hash, others)
+			}
+			if err == nil {
+				_ = m.client.ResumeTorrents([]string{hash})
+
+				m.logger.Info().Str("from", w.Hash).Str("to", hash).Msg("batchwatch: Replaced watched torrent")
+				m.addSelected(w, selected)
+				m.notify(w, media, selected)
+				w.ReplacedHashes = append(w.ReplacedHashes, w.Hash)
+				w.Hash = hash
+				w.Name = replacement.Name
+				w.Files = getFileNames(files)
+				return
+			}
+		}
+	}
+	if err != nil {
+		m.logger.Warn().Err(err).Str("name", replacement.Name).Msg("batchwatch: Failed to select the files of the replacement torrent")
+	}
+
+	// Nothing to download, the files may be shared with the watched torrent
+	_ = m.client.RemoveTorrentsKeepData([]string{hash})
+}
+
+// selectMissingEpisodes returns the files, by index, of the episodes that are still missing among the files that are not known yet.
+func (m *Manager) selectMissingEpisodes(w *Watch, media *anilist.CompleteAnime, files []*torrent_client.TorrentFile) (map[int]int, error) {
+	known := make(map[string]struct{}, len(w.Files))
+	for _, name := range w.Files {
+		known[name] = struct{}{}
+	}
+
+	episodes, err := m.analyze(media, getFileNames(files))
+	if err != nil {
+		return nil, err
+	}
+
+	missing := func(ep int) bool {
+		return !slices.Contains(w.SelectedEpisodes, ep)
+	}
+	if lfs, _, err := db_bridge.GetLocalFiles(m.db); err == nil {
+		inLibrary := make(map[int]struct{})
+		for _, lf := range lfs {
+			if lf.MediaId == w.MediaId {
+				inLibrary[lf.GetEpisodeNumber()] = struct{}{}
+			}
+		}
+		missing = func(ep int) bool {
+			_, found := inLibrary[ep]
+			return !found && !slices.Contains(w.SelectedEpisodes, ep)
+		}
+	}
+
+	ret := make(map[int]int)
+	taken := make(map[int]struct{})
+	for _, f := range files {
+		ep, ok := episodes[f.Index]
+		if !ok {
+			continue
+		}
+		if _, ok := known[f.Name]; ok {
+			continue
+		}
+		if _, ok := taken[ep]; ok || !missing(ep) {
+			continue
+		}
+		taken[ep] = struct{}{}
+		ret[f.Index] = ep
+	}
+
+	return ret, nil
+}
+
+func (m *Manager) addSelected(w *Watch, selected map[int]int) {
+	for _, ep := range selected {
+		w.SelectedEpisodes = append(w.SelectedEpisodes, ep)
+	}
+	w.SelectedEpisodes = uniqueSorted(w.SelectedEpisodes)
+}
+
+func (m *Manager) notify(w *Watch, media *anilist.CompleteAnime, selected map[int]int) {
+	episodes := make([]int, 0, len(selected))
+	for _, ep := range selected {
+		episodes = append(episodes, ep)
+	}
+	slices.Sort(episodes)
+
+	m.logger.Info().Str("hash", w.Hash).Ints("episodes", episodes).Msg("batchwatch: Selected new episodes")
+	m.notifications.Notify(notifications.TypeBatchTorrentUpdated,
+		fmt.Sprintf("New episodes of %s were found in a batch torrent: %s", media.GetPreferredTitle(), formatEpisodes(episodes)),
+		w.MediaId)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+func (m *Manager) getAnilistMedia(ctx context.Context, mediaId int) (*anilist.CompleteAnime, error) {
+	if m.platformRef.IsAbsent() {
+		return nil, errors.New("batchwatch: platform is not available")
+	}
+	return m.platformRef.Get().GetAnimeWithRelations(ctx, mediaId)
+}
+
+// analyzeFiles returns the episode number of the main files of the torrent, by index.
+func (m *Manager) analyzeFiles(media *anilist.CompleteAnime, filepaths []string) (map[int]int, error) {
+	analyzer := torrent_analyzer.NewAnalyzer(&torrent_analyzer.NewAnalyzerOptions{
+		Logger:              m.logger,
+		Filepaths:           filepaths,
+		Media:               media,
+		PlatformRef:         m.platformRef,
+		MetadataProviderRef: m.metadataProviderRef,
+	})
+
+	analysis, err := analyzer.AnalyzeTorrentFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]int)
+	for idx, f := range analysis.GetCorrespondingMainFiles() {
+		ret[idx] = f.GetLocalFile().GetEpisodeNumber()
+	}
+	return ret, nil
+}
+
+func (m *Manager) searchProvider(ctx context.Context, w *Watch, media *anilist.CompleteAnime) ([]*hibiketorrent.AnimeTorrent, error) {
+	if m.torrentRepository == nil {
+		return nil, errors.New("batchwatch: torrent repository is not available")
+	}
+	data, err := m.torrentRepository.SearchAnime(ctx, torrent.AnimeSearchOptions{
+		Provider: w.Provider,
+		Type:     torrent.AnimeSearchTypeSimple,
+		Media:    media.ToBaseAnime(),
+		Query:    w.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.Torrents, nil
+}
+
+func (m *Manager) getProviderMagnet(t *hibiketorrent.AnimeTorrent) (string, error) {
+	if m.torrentRepository == nil {
+		return "", errors.New("batchwatch: torrent repository is not available")
+	}
+	providerExtension, ok := m.torrentRepository.GetAnimeProviderExtension(t.Provider)
+	if !ok {
+		return "", errors.New("batchwatch: provider extension not found")
+	}
+	return providerExtension.GetProvider().GetTorrentMagnetLink(t)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+func (m *Manager) getWatches() ([]*Watch, error) {
+	res, err := m.db.GetBatchTorrentWatches()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Watch, 0, len(res))
+	for _, r := range res {
+		w, err := decodeWatch(r)
+		if err != nil {
+			m.logger.Warn().Err(err).Uint("id", r.ID).Msg("batchwatch: Failed to decode watch")
+			continue
+		}
+		ret = append(ret, w)
+	}
+	return ret, nil
+}
+
+func (m *Manager) getWatch(id uint) (*Watch, error) {
+	res, err := m.db.GetBatchTorrentWatch(id)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrWatchNotFound
+	}
+	return decodeWatch(res)
+}
+
+func (m *Manager) saveWatch(w *Watch) error {
+	value, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	res := &models.BatchTorrentWatch{
+		Hash:    w.Hash,
+		MediaId: w.MediaId,
+		Value:   value,
+	}
+	res.ID = w.DbId
+	if err := m.db.SaveBatchTorrentWatch(res); err != nil {
+		return err
+	}
+	w.DbId = res.ID
+	return nil
+}
+
+func (m *Manager) saveOrLog(w *Watch) {
+	if err := m.saveWatch(w); err != nil {
+		m.logger.Error().Err(err).Str("hash", w.Hash).Msg("batchwatch: Failed to save watch")
+	}
+}
+
+func decodeWatch(res *models.BatchTorrentWatch) (*Watch, error) {
+	var w Watch
+	if err := json.Unmarshal(res.Value, &w); err != nil {
+		return nil, err
+	}
+	w.DbId = res.ID
+	return &w, nil
+}
+
+// findReplacement returns the search result whose name is the closest to the name of the watched torrent.
+// Results that were already checked and results with a name that is too different are ignored.
+func findReplacement(w *Watch, results []*hibiketorrent.AnimeTorrent) (*hibiketorrent.AnimeTorrent, bool) {
+	dice := metrics.NewSorensenDice()
+	dice.CaseSensitive = false
+
+	var best *hibiketorrent.AnimeTorrent
+	bestRating := 0.0
+	for _, t := range results {
+		if t == nil || t.InfoHash == "" || strings.EqualFold(t.InfoHash, w.Hash) {
+			continue
+		}
+		if slices.ContainsFunc(w.ReplacedHashes, func(h string) bool { return strings.EqualFold(h, t.InfoHash) }) {
+			continue
+		}
+		rating := strutil.Similarity(w.Name, t.Name, dice)
+		if rating >= minNameSimilarity && rating > bestRating {
+			best = t
+			bestRating = rating
+		}
+	}
+	return best, best != nil
+}
+
+func getFileNames(files []*torrent_client.TorrentFile) []string {
+	ret := make([]string, len(files))
+	for i, f := range files {
+		ret[i] = f.Name
+	}
+	return ret
+}
+
+func getIndices(selected map[int]int) []int {
+	ret := make([]int, 0, len(selected))
+	for idx := range selected {
+		ret = append(ret, idx)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// getOtherIndices returns the indices of the files that are not selected.
+func getOtherIndices(files []*torrent_client.TorrentFile, selected map[int]int) []int {
+	ret := make([]int, 0, len(files))
+	for _, f := range files {
+		if _, ok := selected[f.Index]; !ok {
+			ret = append(ret, f.Index)
+		}
+	}
+	return ret
+}
+
+func uniqueSorted(s []int) []int {
+	ret := slices.Clone(s)
+	if ret == nil {
+		ret = make([]int, 0)
+	}
+	slices.Sort(ret)
+	return slices.Compact(ret)
+}
+
+func formatEpisodes(episodes []int) string {
+	strs := make([]string, len(episodes))
+	for i, ep := range episodes {
+		strs[i] = fmt.Sprintf("%d", ep)
+	}
+	return strings.Join(strs, ", ")
+}
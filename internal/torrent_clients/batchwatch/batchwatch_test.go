@@ -0,0 +1,316 @@
+package batchwatch
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/events"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/notifications"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTorrentClient keeps the files of the torrents in memory.
+type fakeTorrentClient struct {
+	torrents map[string][]*torrent_client.TorrentFile
+	// added are the files of the torrents added with a magnet link, by hash
+	added   map[string][]string
+	removed []string
+	resumed []string
+}
+
+func newFakeTorrentClient() *fakeTorrentClient {
+	return &fakeTorrentClient{
+		torrents: make(map[string][]*torrent_client.TorrentFile),
+		added:    make(map[string][]string),
+	}
+}
+
+func (c *fakeTorrentClient) setFiles(hash string, selected bool, names ...string) {
+	for _, name := range names {
+		c.torrents[hash] = append(c.torrents[hash], &torrent_client.TorrentFile{Index: len(c.torrents[hash]), Name: name, Selected: selected})
+	}
+}
+
+func (c *fakeTorrentClient) selected(hash string) []string {
+	ret := make([]string, 0)
+	for _, f := range c.torrents[hash] {
+		if f.Selected {
+			ret = append(ret, f.Name)
+		}
+	}
+	return ret
+}
+
+func (c *fakeTorrentClient) Start() bool { return true }
+
+func (c *fakeTorrentClient) TorrentExists(hash string) bool {
+	_, ok := c.torrents[hash]
+	return ok
+}
+
+func (c *fakeTorrentClient) GetTorrentFiles(hash string) ([]*torrent_client.TorrentFile, error) {
+	files, ok := c.torrents[hash]
+	if !ok {
+		return nil, torrent_client.ErrTorrentNotFound
+	}
+	return files, nil
+}
+
+func (c *fakeTorrentClient) GetFiles(hash string) ([]string, error) {
+	files, err := c.GetTorrentFiles(hash)
+	return getFileNames(files), err
+}
+
+func (c *fakeTorrentClient) setSelected(hash string, indices []int, selected bool) error {
+	for _, idx := range indices {
+		c.torrents[hash][idx].Selected = selected
+	}
+	return nil
+}
+
+func (c *fakeTorrentClient) SelectFiles(hash string, indices []int) error {
+	return c.setSelected(hash, indices, true)
+}
+
+func (c *fakeTorrentClient) DeselectFiles(hash string, indices []int) error {
+	return c.setSelected(hash, indices, false)
+}
+
+func (c *fakeTorrentClient) AddMagnets(magnets []string, _ string) error {
+	for _, magnet := range magnets {
+		hash := strings.ToLower(magnet)
+		c.setFiles(hash, true, c.added[hash]...)
+	}
+	return nil
+}
+
+func (c *fakeTorrentClient) PauseTorrents([]string) error { return nil }
+
+func (c *fakeTorrentClient) ResumeTorrents(hashes []string) error {
+	c.resumed = append(c.resumed, hashes...)
+	return nil
+}
+
+func (c *fakeTorrentClient) RemoveTorrentsKeepData(hashes []string) error {
+	for _, hash := range hashes {
+		delete(c.torrents, hash)
+	}
+	c.removed = append(c.removed, hashes...)
+	return nil
+}
+
+var episodeRegex = regexp.MustCompile(` - (\d+)\.mkv$`)
+
+func newTestManager(t *testing.T, client *fakeTorrentClient) (*Manager, *db.Database) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "batchwatch_test", logger)
+	require.NoError(t, err)
+
+	wsEventManager := events.NewMockWSEventManager(logger)
+	m := New(&NewManagerOptions{
+		DB:     database,
+		Logger: logger,
+		Notifications: notifications.NewManager(&notifications.NewManagerOptions{
+			Logger:         logger,
+			Database:       database,
+			WSEventManager: wsEventManager,
+		}),
+	})
+	m.client = client
+	m.getMedia = func(_ context.Context, mediaId int) (*anilist.CompleteAnime, error) {
+		title := "Show"
+		return &anilist.CompleteAnime{ID: mediaId, Title: &anilist.CompleteAnime_Title{UserPreferred: &title}}, nil
+	}
+	// The episode number is the number at the end of the file name
+	m.analyze = func(_ *anilist.CompleteAnime, filepaths []string) (map[int]int, error) {
+		ret := make(map[int]int)
+		for idx, path := range filepaths {
+			if matches := episodeRegex.FindStringSubmatch(filepath.Base(path)); matches != nil {
+				ret[idx], _ = strconv.Atoi(matches[1])
+			}
+		}
+		return ret, nil
+	}
+	m.search = func(context.Context, *Watch, *anilist.CompleteAnime) ([]*hibiketorrent.AnimeTorrent, error) {
+		return nil, nil
+	}
+	m.getMagnet = func(t *hibiketorrent.AnimeTorrent) (string, error) {
+		return t.InfoHash, nil
+	}
+	return m, database
+}
+
+func TestCheck_InPlace(t *testing.T) {
+	client := newFakeTorrentClient()
+	client.setFiles("aaaa", true, "Show/Show - 01.mkv", "Show/Show - 02.mkv")
+	m, database := newTestManager(t, client)
+
+	w, err := m.AddWatch(&Watch{Hash: "AAAA", MediaId: 1, Name: "[Group] Show (01-02)", SelectedEpisodes: []int{2}})
+	require.NoError(t, err)
+	assert.Equal(t, "aaaa", w.Hash)
+	// Episode 1 was deselected by smart select
+	client.torrents["aaaa"][0].Selected = false
+
+	// Nothing changed
+	m.Check()
+	assert.Equal(t, []string{"Show/Show - 02.mkv"}, client.selected("aaaa"))
+
+	// The torrent was updated with two new episodes, the client does not download them
+	client.setFiles("aaaa", false, "Show/Show - 03.mkv", "Show/Show - 04.mkv", "Show/Extras.mkv")
+	m.Check()
+
+	// Only the new episodes are selected, episode 1 is still deselected
+	assert.Equal(t, []string{"Show/Show - 02.mkv", "Show/Show - 03.mkv", "Show/Show - 04.mkv"}, client.selected("aaaa"))
+
+	watches, err := m.GetWatches()
+	require.NoError(t, err)
+	require.Len(t, watches, 1)
+	assert.Equal(t, []int{2, 3, 4}, watches[0].SelectedEpisodes)
+	assert.Len(t, watches[0].Files, 5)
+
+	notifs, err := database.GetNotifications(false)
+	require.NoError(t, err)
+	require.Len(t, notifs, 1)
+	assert.Equal(t, string(notifications.TypeBatchTorrentUpdated), notifs[0].Type)
+	assert.Contains(t, notifs[0].Message, "3, 4")
+}
+
+func TestCheck_NeverDeselects(t *testing.T) {
+	client := newFakeTorrentClient()
+	client.setFiles("aaaa", true, "Show - 01.mkv", "Show - 02.mkv")
+	m, _ := newTestManager(t, client)
+
+	_, err := m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "Show", SelectedEpisodes: []int{1, 2}})
+	require.NoError(t, err)
+
+	// The new files are selected by default and the new file of episode 2 is a duplicate
+	client.setFiles("aaaa", true, "Show - 02.mkv", "Show - 03.mkv", "Show - 02 v2.mkv")
+	m.Check()
+
+	for _, f := range client.torrents["aaaa"] {
+		assert.True(t, f.Selected, f.Name)
+	}
+}
+
+func TestCheck_Replacement(t *testing.T) {
+	client := newFakeTorrentClient()
+	client.setFiles("aaaa", true, "Show - 01.mkv", "Show - 02.mkv")
+	m, _ := newTestManager(t, client)
+
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	_, err := m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "[Group] Show (01-02) [1080p]", Provider: "provider", SelectedEpisodes: []int{1, 2}})
+	require.NoError(t, err)
+
+	client.added["bbbb"] = []string{"Show - 01.mkv", "Show - 02.mkv", "Show - 03.mkv"}
+	searches := 0
+	m.search = func(context.Context, *Watch, *anilist.CompleteAnime) ([]*hibiketorrent.AnimeTorrent, error) {
+		searches++
+		return []*hibiketorrent.AnimeTorrent{
+			{Name: "[Group] Show (01-02) [1080p]", InfoHash: "aaaa"},
+			{Name: "Another Show (01-12)", InfoHash: "cccc"},
+			{Name: "[Group] Show (01-03) [1080p]", InfoHash: "BBBB", Provider: "provider"},
+		}, nil
+	}
+
+	// The provider is not searched before the interval
+	m.Check()
+	assert.Zero(t, searches)
+
+	now = now.Add(searchInterval)
+	m.Check()
+	assert.Equal(t, 1, searches)
+
+	// Only the new episode is downloaded in the replacement, the old torrent is left as is
+	assert.Equal(t, []string{"Show - 03.mkv"}, client.selected("bbbb"))
+	assert.Equal(t, []string{"Show - 01.mkv", "Show - 02.mkv"}, client.selected("aaaa"))
+	assert.Contains(t, client.resumed, "bbbb")
+
+	watches, err := m.GetWatches()
+	require.NoError(t, err)
+	require.Len(t, watches, 1)
+	assert.Equal(t, "bbbb", watches[0].Hash)
+	assert.Equal(t, "[Group] Show (01-03) [1080p]", watches[0].Name)
+	assert.Equal(t, []int{1, 2, 3}, watches[0].SelectedEpisodes)
+	assert.True(t, slices.Contains(watches[0].ReplacedHashes, "aaaa"))
+	assert.True(t, slices.Contains(watches[0].ReplacedHashes, "bbbb"))
+}
+
+func TestCheck_ReplacementWithoutNewEpisodes(t *testing.T) {
+	client := newFakeTorrentClient()
+	client.setFiles("aaaa", true, "Show - 01.mkv", "Show - 02.mkv")
+	m, _ := newTestManager(t, client)
+
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	_, err := m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "[Group] Show (01-02)", Provider: "provider", SelectedEpisodes: []int{1, 2}})
+	require.NoError(t, err)
+
+	// A re-encode with the same episodes
+	client.added["bbbb"] = []string{"Show - 01.mkv", "Show - 02.mkv"}
+	m.search = func(context.Context, *Watch, *anilist.CompleteAnime) ([]*hibiketorrent.AnimeTorrent, error) {
+		return []*hibiketorrent.AnimeTorrent{{Name: "[Group] Show (01-02) v2", InfoHash: "bbbb"}}, nil
+	}
+
+	now = now.Add(searchInterval)
+	m.Check()
+
+	// The replacement is removed without its data and is not checked again
+	assert.Equal(t, []string{"bbbb"}, client.removed)
+	assert.False(t, client.TorrentExists("bbbb"))
+
+	now = now.Add(searchInterval)
+	m.Check()
+	assert.Equal(t, []string{"bbbb"}, client.removed)
+
+	watches, err := m.GetWatches()
+	require.NoError(t, err)
+	require.Len(t, watches, 1)
+	assert.Equal(t, "aaaa", watches[0].Hash)
+}
+
+func TestWatches(t *testing.T) {
+	client := newFakeTorrentClient()
+	client.setFiles("aaaa", true, "Show - 01.mkv")
+	m, _ := newTestManager(t, client)
+
+	w, err := m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "Show"})
+	require.NoError(t, err)
+	assert.NotZero(t, w.DbId)
+
+	// Watching the same torrent again replaces the watch
+	w2, err := m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "Show"})
+	require.NoError(t, err)
+	assert.Equal(t, w.DbId, w2.DbId)
+
+	w, err = m.SetWatchEnabled(w.DbId, false)
+	require.NoError(t, err)
+	assert.False(t, w.Enabled)
+
+	require.NoError(t, m.DeleteWatch(w.DbId))
+	assert.ErrorIs(t, m.DeleteWatch(w.DbId), ErrWatchNotFound)
+
+	// A torrent removed from the client disables its watch
+	w, err = m.AddWatch(&Watch{Hash: "aaaa", MediaId: 1, Name: "Show"})
+	require.NoError(t, err)
+	delete(client.torrents, "aaaa")
+	m.Check()
+	watches, err := m.GetWatches()
+	require.NoError(t, err)
+	require.Len(t, watches, 1)
+	assert.False(t, watches[0].Enabled)
+}
@@ -0,0 +1,173 @@
+package client_import
+
+import (
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"sort"
+	"strings"
+
+	"github.com/5rahim/habari"
+	"github.com/adrg/strutil"
+	"github.com/adrg/strutil/metrics"
+)
+
+const (
+	SourceLibrary = "library" // Files of the torrent are in the library and matched to the media
+	SourceName    = "name"    // The title parsed from the name of the torrent is similar to a title of the media
+
+	// minNameConfidence is the minimum similarity for a media to be proposed from the name of a torrent
+	minNameConfidence = 0.6
+)
+
+type (
+	// Proposal is the media proposed for a torrent of the client that Seanime does not know.
+	Proposal struct {
+		Hash string `json:"hash"`
+		Name string `json:"name"`
+		// ContentPath is the path of the torrent in the torrent client
+		ContentPath string                       `json:"contentPath"`
+		Status      torrent_client.TorrentStatus `json:"status"`
+		// MediaId is 0 if the torrent could not be matched, it has to be assigned manually or skipped
+		MediaId    int    `json:"mediaId"`
+		MediaTitle string `json:"mediaTitle"`
+		// Confidence is between 0 and 1
+		Confidence float64 `json:"confidence"`
+		Source     string  `json:"source,omitempty"`
+	}
+
+	PlanOptions struct {
+		Torrents []*torrent_client.Torrent
+		// KnownHashes are the lowercase info hashes of the torrents that were downloaded or skipped before
+		KnownHashes map[string]struct{}
+		PreMatches  []*models.TorrentPreMatch
+		LocalFiles  []*anime.LocalFile
+		Collection  *anilist.AnimeCollection
+		Translator  *clientpath.Translator
+	}
+)
+
+// Plan proposes a media for each torrent of the client that is not known yet.
+//
+// Torrents are known if they were downloaded or skipped before, or if they are inside the destination of a pre-match.
+// The files of the library inside a torrent are used first, the name of the torrent is compared with the titles of the collection otherwise.
+func Plan(opts *PlanOptions) []*Proposal {
+	titles := getCollectionTitles(opts.Collection)
+
+	ret := make([]*Proposal, 0)
+	for _, t := range opts.Torrents {
+		hash := strings.ToLower(t.Hash)
+		if _, ok := opts.KnownHashes[hash]; ok || isPreMatched(t, opts.PreMatches, opts.Translator) {
+			continue
+		}
+
+		p := &Proposal{
+			Hash:        hash,
+			Name:        t.Name,
+			ContentPath: t.ContentPath,
+			Status:      t.Status,
+		}
+		if mediaId, confidence, ok := matchLibraryFiles(opts.Translator.ToServer(t.ContentPath), opts.LocalFiles); ok {
+			p.MediaId, p.Confidence, p.Source = mediaId, confidence, SourceLibrary
+		} else if mediaId, confidence, ok := matchName(t.Name, titles); ok {
+			p.MediaId, p.Confidence, p.Source = mediaId, confidence, SourceName
+		}
+		if p.MediaId != 0 && opts.Collection != nil {
+			if media, found := opts.Collection.FindAnime(p.MediaId); found {
+				p.MediaTitle = media.GetPreferredTitle()
+			}
+		}
+		ret = append(ret, p)
+	}
+
+	// The most confident proposals first
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Confidence > ret[j].Confidence
+	})
+
+	return ret
+}
+
+func isPreMatched(t *torrent_client.Torrent, preMatches []*models.TorrentPreMatch, translator *clientpath.Translator) bool {
+	for _, pm := range preMatches {
+		if translator.IsInDestination(pm.Destination, t.ContentPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLibraryFiles returns the media most of the matched local files inside the content path belong to.
+// The confidence is the share of the matched files that belong to it.
+func matchLibraryFiles(contentPath string, lfs []*anime.LocalFile) (int, float64, bool) {
+	if contentPath == "" {
+		return 0, 0, false
+	}
+
+	counts := make(map[int]int)
+	total := 0
+	for _, lf := range lfs {
+		if lf.MediaId == 0 || !util.IsSubpath(contentPath, lf.Path) {
+			continue
+		}
+		counts[lf.MediaId]++
+		total++
+	}
+	if total == 0 {
+		return 0, 0, false
+	}
+
+	bestMediaId, bestCount := 0, 0
+	for mediaId, count := range counts {
+		if count > bestCount || (count == bestCount && mediaId < bestMediaId) {
+			bestMediaId, bestCount = mediaId, count
+		}
+	}
+	return bestMediaId, float64(bestCount) / float64(total), true
+}
+
+type mediaTitle struct {
+	mediaId int
+	title   string
+}
+
+func getCollectionTitles(collection *anilist.AnimeCollection) []*mediaTitle {
+	ret := make([]*mediaTitle, 0)
+	if collection == nil {
+		return ret
+	}
+	for _, media := range collection.GetAllAnime() {
+		for _, title := range media.GetAllTitlesDeref() {
+			if title != "" {
+				ret = append(ret, &mediaTitle{mediaId: media.GetID(), title: title})
+			}
+		}
+	}
+	return ret
+}
+
+// matchName returns the media with the title most similar to the title parsed from the name of the torrent.
+func matchName(name string, titles []*mediaTitle) (int, float64, bool) {
+	parsed := habari.Parse(name)
+	if parsed == nil || parsed.Title == "" {
+		return 0, 0, false
+	}
+
+	dice := metrics.NewSorensenDice()
+	dice.CaseSensitive = false
+
+	bestMediaId, bestRating := 0, 0.0
+	for _, t := range titles {
+		rating := strutil.Similarity(parsed.Title, t.title, dice)
+		if rating > bestRating {
+			bestMediaId, bestRating = t.mediaId, rating
+		}
+	}
+	if bestRating < minNameConfidence {
+		return 0, 0, false
+	}
+	return bestMediaId, bestRating, true
+}
@@ -0,0 +1,82 @@
+package client_import
+
+import (
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/torrent_client"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCollection(media ...*anilist.BaseAnime) *anilist.AnimeCollection {
+	entries := make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0, len(media))
+	for _, m := range media {
+		entries = append(entries, &anilist.AnimeCollection_MediaListCollection_Lists_Entries{Media: m})
+	}
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{Entries: entries}},
+		},
+	}
+}
+
+func newTestMedia(id int, title string) *anilist.BaseAnime {
+	return &anilist.BaseAnime{ID: id, Title: &anilist.BaseAnime_Title{Romaji: &title, UserPreferred: &title}}
+}
+
+func TestPlan(t *testing.T) {
+	// The torrent client sees "/mnt/anime" as "/downloads"
+	translator := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, []clientpath.Mapping{
+		{ClientPrefix: "/downloads", ServerPrefix: "/mnt/anime"},
+	})
+
+	proposals := Plan(&PlanOptions{
+		Torrents: []*torrent_client.Torrent{
+			// Downloaded with Seanime
+			{Hash: "AAAA", Name: "[Group] Known Show (01-12)", ContentPath: "/downloads/Known Show"},
+			// Inside the destination of a pre-match
+			{Hash: "bbbb", Name: "[Group] Pre-matched Show - 01.mkv", ContentPath: "/downloads/Pre-matched/[Group] Pre-matched Show - 01.mkv"},
+			// Files in the library, one of them was matched to another media
+			{Hash: "cccc", Name: "Library Show S1", ContentPath: "/downloads/Library Show"},
+			// Matched from the name
+			{Hash: "dddd", Name: "[SubsPlease] Frieren - 05 (1080p) [ABCDEF].mkv", ContentPath: "/downloads/[SubsPlease] Frieren - 05 (1080p) [ABCDEF].mkv"},
+			// Unknown
+			{Hash: "eeee", Name: "Some Movie (2001) 1080p", ContentPath: "/downloads/Some Movie"},
+		},
+		KnownHashes: map[string]struct{}{"aaaa": {}},
+		PreMatches:  []*models.TorrentPreMatch{{Destination: "/mnt/anime/Pre-matched", MediaId: 2}},
+		LocalFiles: []*anime.LocalFile{
+			{Path: "/mnt/anime/Library Show/Library Show - 01.mkv", MediaId: 3},
+			{Path: "/mnt/anime/Library Show/Library Show - 02.mkv", MediaId: 3},
+			{Path: "/mnt/anime/Library Show/Library Show - 03.mkv", MediaId: 3},
+			{Path: "/mnt/anime/Library Show/Library Show - OVA.mkv", MediaId: 30},
+			{Path: "/mnt/anime/Library Show/Extras/Menu.mkv", MediaId: 0},
+			{Path: "/mnt/anime/Library Show 2/Library Show 2 - 01.mkv", MediaId: 4},
+		},
+		Collection: newTestCollection(newTestMedia(3, "Library Show"), newTestMedia(5, "Frieren"), newTestMedia(6, "Another Show")),
+		Translator: translator,
+	})
+
+	// The most confident proposals first
+	require.Len(t, proposals, 3)
+
+	assert.Equal(t, "dddd", proposals[0].Hash)
+	assert.Equal(t, 5, proposals[0].MediaId)
+	assert.Equal(t, SourceName, proposals[0].Source)
+	assert.InDelta(t, 1, proposals[0].Confidence, 0.001)
+
+	assert.Equal(t, "cccc", proposals[1].Hash)
+	assert.Equal(t, 3, proposals[1].MediaId)
+	assert.Equal(t, "Library Show", proposals[1].MediaTitle)
+	assert.Equal(t, SourceLibrary, proposals[1].Source)
+	assert.InDelta(t, 0.75, proposals[1].Confidence, 0.001)
+
+	// Unmatched torrents are proposed without a media
+	assert.Equal(t, "eeee", proposals[2].Hash)
+	assert.Zero(t, proposals[2].MediaId)
+	assert.Empty(t, proposals[2].Source)
+}
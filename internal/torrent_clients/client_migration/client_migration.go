@@ -0,0 +1,449 @@
+package client_migration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/torrent_client"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending  = "pending"  // The torrent has not been processed yet
+	StatusMigrated = "migrated" // The torrent is in the target client with the same path and files
+	StatusFailed   = "failed"   // The torrent could not be added or verified, it is retried when the migration is resumed
+	StatusSkipped  = "skipped"  // The torrent cannot be migrated
+
+	defaultVerifyTimeout  = 2 * time.Minute
+	defaultVerifyInterval = 2 * time.Second
+)
+
+var (
+	ErrRunning        = errors.New("client migration: A migration is already running")
+	ErrNoMigration    = errors.New("client migration: There is no migration to confirm")
+	ErrSameClient     = errors.New("client migration: The target client is the current torrent client")
+	ErrOtherMigration = errors.New("client migration: Another migration has not been confirmed, discard it first")
+	ErrPending        = errors.New("client migration: Some torrents have not been processed, resume the migration first")
+)
+
+type (
+	// Client is implemented by [torrent_client.Repository].
+	Client interface {
+		GetProvider() string
+		PathTranslator() *clientpath.Translator
+		ListMigrationTorrents() ([]*torrent_client.MigrationTorrent, error)
+		// GetMigrationTorrent returns nil if the torrent is not in the client
+		GetMigrationTorrent(hash string) (*torrent_client.MigrationTorrent, error)
+		AddMigrationTorrent(t *torrent_client.MigrationTorrent, savePath string) error
+		RemoveTorrentsKeepData(hashes []string) error
+	}
+
+	// Migrator moves the torrents managed by Seanime from the current torrent client to another one.
+	// The result of each torrent is stored so that an interrupted migration can be resumed,
+	// migrated torrents are not added again and failed ones are retried.
+	Migrator struct {
+		logger         *zerolog.Logger
+		db             *db.Database
+		wsEventManager events.WSEventManagerInterface
+		verifyTimeout  time.Duration
+		verifyInterval time.Duration
+		mu             sync.Mutex
+		running        bool
+		lastErr        error
+	}
+
+	NewMigratorOptions struct {
+		Logger         *zerolog.Logger
+		Database       *db.Database
+		WSEventManager events.WSEventManagerInterface
+	}
+
+	StartOptions struct {
+		Source Client
+		Target Client
+		// TargetSettings are saved when the migration is confirmed
+		TargetSettings *models.TorrentSettings
+		// RemoveFromSource removes the migrated torrents from the source client, their data is kept
+		RemoveFromSource bool
+		// Destinations are the destinations of the pre-matches
+		Destinations []string
+		// ManagedTags are the categories, tags and labels of the torrents added by Seanime
+		ManagedTags []string
+	}
+
+	Report struct {
+		Migration *models.TorrentClientMigration       `json:"migration"`
+		Items     []*models.TorrentClientMigrationItem `json:"items"`
+		Running   bool                                 `json:"running"`
+		// Error is set if the torrents of the source client could not be listed
+		Error    string `json:"error,omitempty"`
+		Migrated int    `json:"migrated"`
+		Failed   int    `json:"failed"`
+		Skipped  int    `json:"skipped"`
+		Pending  int    `json:"pending"`
+	}
+)
+
+func NewMigrator(opts *NewMigratorOptions) *Migrator {
+	return &Migrator{
+		logger:         opts.Logger,
+		db:             opts.Database,
+		wsEventManager: opts.WSEventManager,
+		verifyTimeout:  defaultVerifyTimeout,
+		verifyInterval: defaultVerifyInterval,
+	}
+}
+
+// Start starts the migration in the background, or resumes the migration to the same client.
+func (m *Migrator) Start(opts *StartOptions) (*Report, error) {
+	if opts.Source.GetProvider() == opts.Target.GetProvider() {
+		return nil, ErrSameClient
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return nil, ErrRunning
+	}
+
+	migration, err := m.db.GetTorrentClientMigration()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		migration = &models.TorrentClientMigration{
+			SourceClient: opts.Source.GetProvider(),
+			TargetClient: opts.Target.GetProvider(),
+		}
+	} else if migration.SourceClient != opts.Source.GetProvider() || migration.TargetClient != opts.Target.GetProvider() {
+		return nil, ErrOtherMigration
+	}
+
+	migration.TargetSettings, err = json.Marshal(opts.TargetSettings)
+	if err != nil {
+		return nil, err
+	}
+	migration.RemoveFromSource = opts.RemoveFromSource
+	migration.FinishedAt = nil
+	if err := m.db.SaveTorrentClientMigration(migration); err != nil {
+		return nil, err
+	}
+
+	m.running = true
+	m.lastErr = nil
+	running := *migration
+	go m.run(opts, &running)
+
+	return m.getReport(migration)
+}
+
+// GetReport returns the current migration, or nil if there is none.
+func (m *Migrator) GetReport() (*Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	migration, err := m.db.GetTorrentClientMigration()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return m.getReport(migration)
+}
+
+// Confirm returns the settings of the target client and deletes the migration.
+// The caller saves the settings, which switches to the target client.
+func (m *Migrator) Confirm() (*models.TorrentSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return nil, ErrRunning
+	}
+
+	migration, err := m.db.GetTorrentClientMigration()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoMigration
+		}
+		return nil, err
+	}
+
+	report, err := m.getReport(migration)
+	if err != nil {
+		return nil, err
+	}
+	if report.Pending > 0 || migration.FinishedAt == nil || m.lastErr != nil {
+		return nil, ErrPending
+	}
+
+	var settings models.TorrentSettings
+	if err := json.Unmarshal(migration.TargetSettings, &settings); err != nil {
+		return nil, err
+	}
+	settings.Default = migration.TargetClient
+
+	if err := m.db.DeleteTorrentClientMigrations(); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info().Str("target", migration.TargetClient).Msg("client migration: Migration confirmed")
+	return &settings, nil
+}
+
+// Discard deletes the migration without switching clients. The torrents already added to the target client are kept.
+func (m *Migrator) Discard() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return ErrRunning
+	}
+	return m.db.DeleteTorrentClientMigrations()
+}
+
+func (m *Migrator) getReport(migration *models.TorrentClientMigration) (*Report, error) {
+	items, err := m.db.GetTorrentClientMigrationItems(migration.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Report{
+		Migration: migration,
+		Items:     items,
+		Running:   m.running,
+	}
+	if m.lastErr != nil {
+		ret.Error = m.lastErr.Error()
+	}
+	for _, item := range items {
+		switch item.Status {
+		case StatusMigrated:
+			ret.Migrated++
+		case StatusFailed:
+			ret.Failed++
+		case StatusSkipped:
+			ret.Skipped++
+		default:
+			ret.Pending++
+		}
+	}
+	return ret, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (m *Migrator) run(opts *StartOptions, migration *models.TorrentClientMigration) {
+	m.logger.Info().Str("source", migration.SourceClient).Str("target", migration.TargetClient).Msg("client migration: Starting migration")
+
+	defer func() {
+		m.mu.Lock()
+		now := time.Now()
+		migration.FinishedAt = &now
+		if err := m.db.SaveTorrentClientMigration(migration); err != nil {
+			m.logger.Error().Err(err).Msg("client migration: Failed to save the migration")
+		}
+		m.running = false
+		m.mu.Unlock()
+		m.sendUpdate()
+		m.logger.Info().Msg("client migration: Migration finished")
+	}()
+
+	err := m.migrate(opts, migration)
+	if err != nil {
+		m.logger.Error().Err(err).Msg("client migration: Migration interrupted")
+		m.mu.Lock()
+		m.lastErr = err
+		m.mu.Unlock()
+	}
+}
+
+func (m *Migrator) migrate(opts *StartOptions, migration *models.TorrentClientMigration) error {
+	items, err := m.db.GetTorrentClientMigrationItems(migration.ID)
+	if err != nil {
+		return err
+	}
+
+	torrents, err := opts.Source.ListMigrationTorrents()
+	if err != nil {
+		return fmt.Errorf("could not list the torrents of the source client: %w", err)
+	}
+
+	// Add the managed torrents that are not part of the migration yet
+	byHash := make(map[string]*torrent_client.MigrationTorrent, len(torrents))
+	known := make(map[string]bool, len(items))
+	for _, item := range items {
+		known[item.Hash] = true
+	}
+	for _, t := range torrents {
+		byHash[t.Hash] = t
+		if known[t.Hash] || !isManaged(t, opts) {
+			continue
+		}
+		item := &models.TorrentClientMigrationItem{
+			MigrationID: migration.ID,
+			Hash:        t.Hash,
+			Name:        t.Name,
+			SourcePath:  t.SavePath,
+			TargetPath:  opts.Target.PathTranslator().ToClient(opts.Source.PathTranslator().ToServer(t.SavePath)),
+			Status:      StatusPending,
+		}
+		if err := m.db.SaveTorrentClientMigrationItem(item); err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	m.sendUpdate()
+
+	for _, item := range items {
+		switch item.Status {
+		case StatusSkipped:
+			continue
+		case StatusMigrated:
+			// The migration was interrupted before the torrent was removed from the source client
+			if opts.RemoveFromSource && !item.RemovedFromSource && byHash[item.Hash] != nil {
+				m.removeFromSource(opts, item)
+			}
+		default:
+			m.migrateTorrent(opts, item, byHash[item.Hash])
+		}
+
+		if err := m.db.SaveTorrentClientMigrationItem(item); err != nil {
+			return err
+		}
+		m.sendUpdate()
+	}
+
+	return nil
+}
+
+// migrateTorrent adds the torrent to the target client and verifies it, t is nil if it is no longer in the source client.
+func (m *Migrator) migrateTorrent(opts *StartOptions, item *models.TorrentClientMigrationItem, t *torrent_client.MigrationTorrent) {
+	item.Error = ""
+
+	existing, err := opts.Target.GetMigrationTorrent(item.Hash)
+	if err != nil {
+		m.fail(item, fmt.Errorf("could not contact the target client: %w", err))
+		return
+	}
+
+	if t == nil {
+		// It was removed from the source client after being added to the target client
+		if existing != nil {
+			item.Status = StatusMigrated
+			item.RemovedFromSource = true
+			return
+		}
+		item.Status = StatusSkipped
+		item.Error = "The torrent is no longer in the source client"
+		return
+	}
+
+	if existing == nil {
+		if t.Magnet == "" && len(t.TorrentFile) == 0 {
+			item.Status = StatusSkipped
+			item.Error = "The source client did not provide a magnet link or a torrent file"
+			return
+		}
+		if err := opts.Target.AddMigrationTorrent(t, item.TargetPath); err != nil {
+			m.fail(item, fmt.Errorf("could not add the torrent: %w", err))
+			return
+		}
+	}
+
+	if err := m.verify(opts.Target, item, t); err != nil {
+		m.fail(item, err)
+		return
+	}
+
+	item.Status = StatusMigrated
+	m.logger.Debug().Str("hash", item.Hash).Msg("client migration: Migrated torrent")
+
+	if opts.RemoveFromSource {
+		m.removeFromSource(opts, item)
+	}
+}
+
+// verify waits for the torrent to appear in the target client and checks its path and file selection.
+func (m *Migrator) verify(target Client, item *models.TorrentClientMigrationItem, t *torrent_client.MigrationTorrent) error {
+	var added *torrent_client.MigrationTorrent
+	deadline := time.Now().Add(m.verifyTimeout)
+	for {
+		var err error
+		added, err = target.GetMigrationTorrent(item.Hash)
+		if err != nil {
+			return fmt.Errorf("could not contact the target client: %w", err)
+		}
+		// The files of a magnet link are only known once the client has fetched the metadata
+		if added != nil && (added.FileCount > 0 || t.FileCount == 0) {
+			break
+		}
+		if time.Now().After(deadline) {
+			if added == nil {
+				return errors.New("the torrent did not appear in the target client")
+			}
+			return errors.New("the target client did not fetch the metadata of the torrent")
+		}
+		time.Sleep(m.verifyInterval)
+	}
+
+	translator := target.PathTranslator()
+	if translator.Canonical(added.SavePath) != translator.Canonical(item.TargetPath) {
+		return fmt.Errorf("the torrent is saved in %q instead of %q", added.SavePath, item.TargetPath)
+	}
+	if t.FileCount > 0 && (added.FileCount != t.FileCount || !slices.Equal(added.UnwantedFiles, t.UnwantedFiles)) {
+		return errors.New("the files selected in the target client do not match the source client")
+	}
+	return nil
+}
+
+func (m *Migrator) removeFromSource(opts *StartOptions, item *models.TorrentClientMigrationItem) {
+	if err := opts.Source.RemoveTorrentsKeepData([]string{item.Hash}); err != nil {
+		item.Error = fmt.Sprintf("Could not remove the torrent from the source client: %v", err)
+		return
+	}
+	item.Error = ""
+	item.RemovedFromSource = true
+}
+
+func (m *Migrator) fail(item *models.TorrentClientMigrationItem, err error) {
+	m.logger.Warn().Err(err).Str("hash", item.Hash).Msg("client migration: Failed to migrate torrent")
+	item.Status = StatusFailed
+	item.Error = err.Error()
+}
+
+func (m *Migrator) sendUpdate() {
+	if m.wsEventManager != nil {
+		m.wsEventManager.SendEvent(events.TorrentClientMigrationUpdated, nil)
+	}
+}
+
+// isManaged returns true if the torrent has one of the managed tags or is inside the destination of a pre-match.
+func isManaged(t *torrent_client.MigrationTorrent, opts *StartOptions) bool {
+	for _, tag := range append([]string{t.Category}, t.Tags...) {
+		for _, managed := range opts.ManagedTags {
+			if tag != "" && strings.EqualFold(tag, managed) {
+				return true
+			}
+		}
+	}
+	translator := opts.Source.PathTranslator()
+	for _, destination := range opts.Destinations {
+		if translator.IsInDestination(destination, t.SavePath) {
+			return true
+		}
+	}
+	return false
+}
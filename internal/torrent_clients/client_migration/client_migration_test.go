@@ -0,0 +1,206 @@
+package client_migration
+
+import (
+	"errors"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/clientpath"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	provider   string
+	translator *clientpath.Translator
+	mu         sync.Mutex
+	torrents   map[string]*torrent_client.MigrationTorrent
+	order      []string
+	failAdd    map[string]bool
+	removed    []string
+}
+
+func newFakeClient(provider string, translator *clientpath.Translator, torrents ...*torrent_client.MigrationTorrent) *fakeClient {
+	ret := &fakeClient{
+		provider:   provider,
+		translator: translator,
+		torrents:   make(map[string]*torrent_client.MigrationTorrent),
+		failAdd:    make(map[string]bool),
+	}
+	for _, t := range torrents {
+		ret.torrents[t.Hash] = t
+		ret.order = append(ret.order, t.Hash)
+	}
+	return ret
+}
+
+func (c *fakeClient) GetProvider() string                    { return c.provider }
+func (c *fakeClient) PathTranslator() *clientpath.Translator { return c.translator }
+
+func (c *fakeClient) ListMigrationTorrents() ([]*torrent_client.MigrationTorrent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*torrent_client.MigrationTorrent, 0)
+	for _, hash := range c.order {
+		if t, ok := c.torrents[hash]; ok {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
+func (c *fakeClient) GetMigrationTorrent(hash string) (*torrent_client.MigrationTorrent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.torrents[hash], nil
+}
+
+func (c *fakeClient) AddMigrationTorrent(t *torrent_client.MigrationTorrent, savePath string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failAdd[t.Hash] {
+		return errors.New("add failed")
+	}
+	added := *t
+	added.SavePath = savePath
+	c.torrents[t.Hash] = &added
+	c.order = append(c.order, t.Hash)
+	return nil
+}
+
+func (c *fakeClient) RemoveTorrentsKeepData(hashes []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, hash := range hashes {
+		delete(c.torrents, hash)
+		c.removed = append(c.removed, hash)
+	}
+	return nil
+}
+
+func newTestMigrator(t *testing.T) *Migrator {
+	database, err := db.NewDatabase(t.TempDir(), "client_migration_test", util.NewLogger())
+	require.NoError(t, err)
+	ret := NewMigrator(&NewMigratorOptions{
+		Logger:   util.NewLogger(),
+		Database: database,
+	})
+	ret.verifyTimeout = 50 * time.Millisecond
+	ret.verifyInterval = 10 * time.Millisecond
+	return ret
+}
+
+func waitForReport(t *testing.T, m *Migrator) *Report {
+	require.Eventually(t, func() bool {
+		report, err := m.GetReport()
+		return err == nil && report != nil && !report.Running
+	}, 5*time.Second, 10*time.Millisecond)
+	report, err := m.GetReport()
+	require.NoError(t, err)
+	return report
+}
+
+func TestMigrator(t *testing.T) {
+	posix := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, nil)
+	// The target client runs in Docker and sees the library in /downloads
+	docker := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, []clientpath.Mapping{
+		{ClientPrefix: "/downloads", ServerPrefix: "/mnt/anime"},
+	})
+
+	source := newFakeClient(torrent_client.TransmissionClient, posix,
+		&torrent_client.MigrationTorrent{Hash: "a", Magnet: "magnet:?xt=a", SavePath: "/mnt/anime/Show", FileCount: 3, UnwantedFiles: []int{1}},
+		&torrent_client.MigrationTorrent{Hash: "b", Magnet: "magnet:?xt=b", SavePath: "/other", Tags: []string{"Seanime"}},
+		&torrent_client.MigrationTorrent{Hash: "c", Magnet: "magnet:?xt=c", SavePath: "/other"},
+		&torrent_client.MigrationTorrent{Hash: "d", SavePath: "/mnt/anime/Show 2"},
+		&torrent_client.MigrationTorrent{Hash: "e", Magnet: "magnet:?xt=e", SavePath: "/mnt/anime/Show 3"},
+	)
+	target := newFakeClient(torrent_client.QbittorrentClient, docker)
+	target.failAdd["e"] = true
+
+	m := newTestMigrator(t)
+	opts := &StartOptions{
+		Source:           source,
+		Target:           target,
+		TargetSettings:   &models.TorrentSettings{QBittorrentHost: "127.0.0.1"},
+		RemoveFromSource: true,
+		Destinations:     []string{"/mnt/anime/Show", "/mnt/anime/Show 2", "/mnt/anime/Show 3"},
+		ManagedTags:      []string{"seanime"},
+	}
+
+	_, err := m.Start(opts)
+	require.NoError(t, err)
+	report := waitForReport(t, m)
+
+	statuses := make(map[string]string)
+	for _, item := range report.Items {
+		statuses[item.Hash] = item.Status
+	}
+	assert.Equal(t, map[string]string{
+		"a": StatusMigrated,
+		"b": StatusMigrated,
+		"d": StatusSkipped,
+		"e": StatusFailed,
+	}, statuses)
+	assert.Equal(t, 2, report.Migrated)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+
+	// The path is translated to the target client and the file selection is kept
+	require.NotNil(t, target.torrents["a"])
+	assert.Equal(t, "/downloads/Show", target.torrents["a"].SavePath)
+	assert.Equal(t, []int{1}, target.torrents["a"].UnwantedFiles)
+	assert.ElementsMatch(t, []string{"a", "b"}, source.removed)
+
+	// Resuming retries the failed torrent only
+	target.failAdd["e"] = false
+	_, err = m.Start(opts)
+	require.NoError(t, err)
+	report = waitForReport(t, m)
+	assert.Equal(t, 3, report.Migrated)
+	assert.Equal(t, 0, report.Failed)
+	assert.ElementsMatch(t, []string{"a", "b", "e"}, source.removed)
+
+	// Another target cannot be used until the migration is confirmed or discarded
+	_, err = m.Start(&StartOptions{Source: source, Target: newFakeClient(torrent_client.NoneClient, posix)})
+	assert.ErrorIs(t, err, ErrOtherMigration)
+
+	settings, err := m.Confirm()
+	require.NoError(t, err)
+	assert.Equal(t, torrent_client.QbittorrentClient, settings.Default)
+	assert.Equal(t, "127.0.0.1", settings.QBittorrentHost)
+
+	report, err = m.GetReport()
+	require.NoError(t, err)
+	assert.Nil(t, report)
+}
+
+func TestMigrator_VerifyPath(t *testing.T) {
+	posix := clientpath.NewTranslator(clientpath.StylePosix, clientpath.StylePosix, nil)
+	source := newFakeClient(torrent_client.TransmissionClient, posix,
+		&torrent_client.MigrationTorrent{Hash: "a", Magnet: "magnet:?xt=a", SavePath: "/anime/Show"},
+	)
+	// The torrent is already in the target client in another directory
+	target := newFakeClient(torrent_client.QbittorrentClient, posix,
+		&torrent_client.MigrationTorrent{Hash: "a", SavePath: "/elsewhere"},
+	)
+
+	m := newTestMigrator(t)
+	_, err := m.Start(&StartOptions{
+		Source:         source,
+		Target:         target,
+		TargetSettings: &models.TorrentSettings{},
+		Destinations:   []string{"/anime"},
+	})
+	require.NoError(t, err)
+	report := waitForReport(t, m)
+
+	require.Len(t, report.Items, 1)
+	assert.Equal(t, StatusFailed, report.Items[0].Status)
+	assert.Contains(t, report.Items[0].Error, "/elsewhere")
+	assert.Empty(t, source.removed)
+}
@@ -0,0 +1,264 @@
+package clientpath
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+)
+
+const (
+	// StyleAuto detects the style from the torrent client's default download directory.
+	StyleAuto Style = ""
+	// StylePosix is used by torrent clients running on Linux, macOS or in Docker.
+	StylePosix Style = "posix"
+	// StyleWindows is used by torrent clients running on Windows.
+	StyleWindows Style = "windows"
+)
+
+type (
+	// Style is the path semantics of the machine a torrent client runs on.
+	Style string
+
+	// Mapping maps a directory as seen by the torrent client to the same directory as seen by the server,
+	// e.g. "D:\Anime" on the machine of the client and "/mnt/anime" on the server.
+	Mapping struct {
+		ClientPrefix string `json:"clientPrefix"`
+		ServerPrefix string `json:"serverPrefix"`
+	}
+
+	// Translator converts the paths of a torrent client to the paths of the server and back.
+	// Paths are compared with the semantics of the side they belong to, Windows paths are case-insensitive.
+	Translator struct {
+		client   Style
+		server   Style
+		mappings []Mapping
+	}
+)
+
+func (s Style) IsValid() bool {
+	return s == StyleAuto || s == StylePosix || s == StyleWindows
+}
+
+// ServerStyle returns the style of the machine the server runs on.
+func ServerStyle() Style {
+	if runtime.GOOS == "windows" {
+		return StyleWindows
+	}
+	return StylePosix
+}
+
+// DetectStyle guesses the style of an absolute path. It returns StyleAuto if it cannot be guessed.
+func DetectStyle(p string) Style {
+	switch {
+	case IsAbs(p, StyleWindows) && !strings.HasPrefix(p, "//"):
+		return StyleWindows
+	case strings.HasPrefix(p, "/"):
+		return StylePosix
+	}
+	return StyleAuto
+}
+
+// NewTranslator returns a translator for a torrent client with the given style.
+// StyleAuto is treated as the style of the server.
+func NewTranslator(client Style, server Style, mappings []Mapping) *Translator {
+	if client == StyleAuto {
+		client = server
+	}
+	ret := &Translator{
+		client:   client,
+		server:   server,
+		mappings: make([]Mapping, 0, len(mappings)),
+	}
+	for _, m := range mappings {
+		if m.ClientPrefix == "" || m.ServerPrefix == "" {
+			continue
+		}
+		ret.mappings = append(ret.mappings, m)
+	}
+	return ret
+}
+
+// ClientStyle returns the style of the torrent client.
+func (t *Translator) ClientStyle() Style {
+	return t.client
+}
+
+// ResolveDestination returns the path of the destination for the torrent client.
+// The destination can be a path of the server inside a mapped directory, or an absolute path of the torrent client.
+func (t *Translator) ResolveDestination(dest string) (string, error) {
+	if ret, ok := t.mapPath(dest, t.server, t.client, func(m Mapping) (string, string) { return m.ServerPrefix, m.ClientPrefix }); ok {
+		return ret, nil
+	}
+	if !IsAbs(dest, t.client) {
+		return "", fmt.Errorf("destination path must be an absolute %s path of the torrent client", t.client)
+	}
+	return Clean(dest, t.client), nil
+}
+
+// ToClient returns the path of the torrent client for a path of the server.
+// Paths outside the mapped directories are returned as is.
+func (t *Translator) ToClient(serverPath string) string {
+	ret, err := t.ResolveDestination(serverPath)
+	if err != nil {
+		return serverPath
+	}
+	return ret
+}
+
+// ToServer returns the path of the server for a path of the torrent client.
+// Paths outside the mapped directories are returned as is.
+func (t *Translator) ToServer(clientPath string) string {
+	if ret, ok := t.mapPath(clientPath, t.client, t.server, func(m Mapping) (string, string) { return m.ClientPrefix, m.ServerPrefix }); ok {
+		return ret
+	}
+	if IsAbs(clientPath, t.client) {
+		return Clean(clientPath, t.client)
+	}
+	return clientPath
+}
+
+// Canonical returns the form of a path of the torrent client used to store and compare pre-matches.
+// It is the path as seen by the server, with forward slashes, in lower case if the server runs on Windows.
+func (t *Translator) Canonical(clientPath string) string {
+	serverPath := t.ToServer(clientPath)
+	style := t.server
+	// Unmapped paths of a Windows client keep their semantics
+	if serverPath == Clean(clientPath, t.client) && IsAbs(clientPath, t.client) && !IsAbs(clientPath, t.server) {
+		style = t.client
+	}
+	return canonical(serverPath, style)
+}
+
+// IsInDestination returns true if the path of the torrent client is inside the destination of a pre-match.
+func (t *Translator) IsInDestination(destination string, clientPath string) bool {
+	if destination == "" || clientPath == "" {
+		return false
+	}
+	return hasPathPrefix(t.Canonical(clientPath), t.canonicalDestination(destination))
+}
+
+// canonicalDestination returns the canonical form of a destination, which is either a path of the server
+// or a path of the torrent client outside the mapped directories.
+func (t *Translator) canonicalDestination(destination string) string {
+	if IsAbs(destination, t.server) {
+		return canonical(destination, t.server)
+	}
+	return t.Canonical(destination)
+}
+
+// mapPath replaces the longest prefix of the mappings that contains the path.
+func (t *Translator) mapPath(p string, from Style, to Style, prefixes func(Mapping) (string, string)) (string, bool) {
+	if !IsAbs(p, from) {
+		return "", false
+	}
+	canonicalPath := canonical(p, from)
+
+	found := false
+	var toPrefix, canonicalPrefix string
+	for _, m := range t.mappings {
+		mFrom, mTo := prefixes(m)
+		c := canonical(mFrom, from)
+		if hasPathPrefix(canonicalPath, c) && (!found || len(c) > len(canonicalPrefix)) {
+			found, toPrefix, canonicalPrefix = true, mTo, c
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(canonicalPath, canonicalPrefix), "/")
+	if original := toSlash(Clean(p, from)); from == StyleWindows && len(original) == len(canonicalPath) {
+		// Keep the case of the original path
+		rest = strings.TrimPrefix(original[len(canonicalPrefix):], "/")
+	}
+	if rest == "" {
+		return Clean(toPrefix, to), true
+	}
+	return Clean(strings.TrimSuffix(toSlash(toPrefix), "/")+"/"+rest, to), true
+}
+
+// IsAbs returns true if the path is absolute with the semantics of the style.
+func IsAbs(p string, style Style) bool {
+	if style == StyleWindows {
+		if len(p) >= 3 && isDriveLetter(p[0]) && p[1] == ':' && (p[2] == '\\' || p[2] == '/') {
+			return true
+		}
+		// UNC path, e.g. \\server\share
+		return len(p) > 2 && (strings.HasPrefix(p, `\\`) || strings.HasPrefix(p, "//"))
+	}
+	return strings.HasPrefix(p, "/")
+}
+
+// Clean cleans the path with the semantics of the style and returns it with the separators of the style.
+func Clean(p string, style Style) string {
+	if style != StyleWindows {
+		return path.Clean(p)
+	}
+
+	p = toSlash(p)
+	unc := strings.HasPrefix(p, "//")
+	p = path.Clean(p)
+	switch {
+	case unc:
+		p = "/" + p
+	case len(p) == 2 && p[1] == ':':
+		// Root of a drive
+		p += "/"
+	}
+	return strings.ReplaceAll(p, "/", `\`)
+}
+
+func canonical(p string, style Style) string {
+	if style != StyleWindows {
+		return path.Clean(p)
+	}
+	return strings.ToLower(toSlash(Clean(p, style)))
+}
+
+func hasPathPrefix(p string, prefix string) bool {
+	if p == prefix {
+		return true
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return strings.HasPrefix(p, prefix)
+}
+
+func toSlash(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+func isDriveLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// MappingsFromMap returns the mappings of the settings, which map the client prefixes to the server prefixes.
+func MappingsFromMap(m map[string]string) []Mapping {
+	ret := make([]Mapping, 0, len(m))
+	for clientPrefix, serverPrefix := range m {
+		ret = append(ret, Mapping{ClientPrefix: clientPrefix, ServerPrefix: serverPrefix})
+	}
+	return ret
+}
+
+// ValidateSettings checks the path style of a torrent client and its mappings.
+// The client prefixes are checked against the style of the client when it is set.
+func ValidateSettings(style Style, mappings map[string]string) error {
+	if !style.IsValid() {
+		return fmt.Errorf("clientpath: Invalid path style %q", style)
+	}
+	for clientPrefix, serverPrefix := range mappings {
+		if style != StyleAuto && !IsAbs(clientPrefix, style) {
+			return fmt.Errorf("clientpath: %q is not an absolute %s path", clientPrefix, style)
+		}
+		if style == StyleAuto && DetectStyle(clientPrefix) == StyleAuto {
+			return fmt.Errorf("clientpath: %q is not an absolute path", clientPrefix)
+		}
+		if !IsAbs(serverPrefix, ServerStyle()) {
+			return fmt.Errorf("clientpath: %q is not an absolute path of the server", serverPrefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package clientpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslator_LinuxServerWindowsClient(t *testing.T) {
+	tr := NewTranslator(StyleWindows, StylePosix, []Mapping{
+		{ClientPrefix: `D:\Anime`, ServerPrefix: "/mnt/anime"},
+		{ClientPrefix: `D:\Anime\Seasonal`, ServerPrefix: "/mnt/seasonal"},
+	})
+
+	// Destinations are validated with the semantics of the client
+	dest, err := tr.ResolveDestination(`D:\Anime\Show\`)
+	require.NoError(t, err)
+	assert.Equal(t, `D:\Anime\Show`, dest)
+
+	_, err = tr.ResolveDestination(`Anime\Show`)
+	assert.Error(t, err)
+
+	_, err = tr.ResolveDestination("/downloads/Show")
+	assert.Error(t, err)
+
+	// Paths of the server are translated
+	dest, err = tr.ResolveDestination("/mnt/anime/Show")
+	require.NoError(t, err)
+	assert.Equal(t, `D:\Anime\Show`, dest)
+
+	// The longest prefix wins and the case of the path is kept
+	assert.Equal(t, "/mnt/seasonal/Show/Episode 1.mkv", tr.ToServer(`d:\anime\seasonal\Show\Episode 1.mkv`))
+	assert.Equal(t, "/mnt/anime", tr.ToServer(`D:\Anime`))
+	assert.Equal(t, `D:\Anime\Seasonal\Show`, tr.ToClient("/mnt/seasonal/Show"))
+
+	// Pre-matches are stored as seen by the server
+	assert.Equal(t, "/mnt/anime/Show", tr.Canonical(`D:\Anime\Show`))
+	assert.True(t, tr.IsInDestination("/mnt/anime/Show", `D:\ANIME\Show\Episode 1.mkv`))
+	assert.False(t, tr.IsInDestination("/mnt/anime/Show", `D:\Anime\Show 2\Episode 1.mkv`))
+
+	// The longest server prefix wins when translating paths of the server
+	tr2 := NewTranslator(StyleWindows, StylePosix, []Mapping{
+		{ClientPrefix: `E:\Seasonal`, ServerPrefix: "/mnt/anime/seasonal"},
+		{ClientPrefix: `D:\Anime`, ServerPrefix: "/mnt/anime"},
+	})
+	assert.Equal(t, `E:\Seasonal\Show`, tr2.ToClient("/mnt/anime/seasonal/Show"))
+	assert.Equal(t, `D:\Anime\Show`, tr2.ToClient("/mnt/anime/Show"))
+
+	// Unmapped paths are compared with the semantics of the client
+	assert.Equal(t, "e:/other/show", tr.Canonical(`E:\Other\Show`))
+	assert.True(t, tr.IsInDestination(`E:\Other\Show`, `e:\other\show\Episode 1.mkv`))
+}
+
+func TestTranslator_WindowsServerLinuxClient(t *testing.T) {
+	tr := NewTranslator(StylePosix, StyleWindows, []Mapping{
+		{ClientPrefix: "/downloads", ServerPrefix: `\\nas\downloads`},
+	})
+
+	dest, err := tr.ResolveDestination("/downloads/Show/")
+	require.NoError(t, err)
+	assert.Equal(t, "/downloads/Show", dest)
+
+	_, err = tr.ResolveDestination(`C:\Anime\Show`)
+	assert.Error(t, err)
+
+	dest, err = tr.ResolveDestination(`\\NAS\Downloads\Show`)
+	require.NoError(t, err)
+	assert.Equal(t, "/downloads/Show", dest)
+
+	assert.Equal(t, `\\nas\downloads\Show\Episode 1.mkv`, tr.ToServer("/downloads/Show/Episode 1.mkv"))
+
+	// The server is case-insensitive
+	assert.Equal(t, "//nas/downloads/show", tr.Canonical("/downloads/Show"))
+	assert.True(t, tr.IsInDestination(`\\nas\downloads\show`, "/downloads/Show/Episode 1.mkv"))
+
+	// The client is case-sensitive
+	assert.False(t, tr.IsInDestination("/other/Show", "/other/show/Episode 1.mkv"))
+}
+
+func TestTranslator_SameStyle(t *testing.T) {
+	tr := NewTranslator(StyleAuto, StylePosix, nil)
+	assert.Equal(t, StylePosix, tr.ClientStyle())
+
+	dest, err := tr.ResolveDestination("/downloads/Anime/../Show")
+	require.NoError(t, err)
+	assert.Equal(t, "/downloads/Show", dest)
+
+	assert.True(t, tr.IsInDestination("/downloads/Anime", "/downloads/Anime/Episode 1.mkv"))
+	assert.False(t, tr.IsInDestination("/downloads/Anime", "/downloads/Anime 2/Episode 1.mkv"))
+}
+
+func TestDetectStyle(t *testing.T) {
+	assert.Equal(t, StyleWindows, DetectStyle(`C:\Users\me\Downloads`))
+	assert.Equal(t, StyleWindows, DetectStyle("C:/Users/me/Downloads"))
+	assert.Equal(t, StyleWindows, DetectStyle(`\\nas\downloads`))
+	assert.Equal(t, StylePosix, DetectStyle("/downloads"))
+	assert.Equal(t, StyleAuto, DetectStyle("downloads"))
+}
+
+func TestClean_Windows(t *testing.T) {
+	assert.Equal(t, `D:\`, Clean(`D:\`, StyleWindows))
+	assert.Equal(t, `D:\Anime`, Clean("D:/Anime/./", StyleWindows))
+	assert.Equal(t, `\\nas\share\Anime`, Clean(`\\nas\share\\Anime`, StyleWindows))
+}
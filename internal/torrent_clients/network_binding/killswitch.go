@@ -0,0 +1,275 @@
+package network_binding
+
+import (
+	"fmt"
+	"net"
+	"seanime/internal/events"
+	"seanime/internal/notifier"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	StateUnbound State = "unbound" // The torrent traffic uses the default route
+	StateUp      State = "up"      // The bound interface has its address
+	StateDown    State = "down"    // The bound interface has no address, the kill switch is disabled
+	StateTripped State = "tripped" // The bound interface has no address, the torrent activity is paused
+
+	checkInterval = 5 * time.Second
+)
+
+type (
+	State string
+
+	// Client is implemented by adapters of [torrent_client.Repository] and [torrentstream.Repository].
+	Client interface {
+		// PauseAll pauses all torrent activity, it is called on every check while the kill switch is tripped.
+		PauseAll() error
+		// ResumeAll resumes the torrents paused by PauseAll.
+		ResumeAll() error
+	}
+
+	Status struct {
+		State     State  `json:"state"`
+		Interface string `json:"interface"`
+		Address   string `json:"address"`
+		// ResolvedAddress is the local address the torrent traffic goes out from, empty if the interface is down
+		ResolvedAddress string `json:"resolvedAddress"`
+		// Socks5Proxy is the proxy used by the embedded torrent client, without the credentials
+		Socks5Proxy string     `json:"socks5Proxy"`
+		KillSwitch  bool       `json:"killSwitch"`
+		Error       string     `json:"error,omitempty"`
+		TrippedAt   *time.Time `json:"trippedAt"`
+		LastCheck   *time.Time `json:"lastCheck"`
+		// Clients are the torrent clients paused by the kill switch
+		Clients []string `json:"clients"`
+	}
+
+	// Manager watches the interface the torrent traffic is bound to.
+	// When the kill switch is enabled and the interface loses its address, all torrent activity is paused
+	// until the address comes back, so that torrents never fall back to the default route.
+	Manager struct {
+		logger         *zerolog.Logger
+		wsEventManager events.WSEventManagerInterface
+		lookup         AddrsFunc
+		mu             sync.Mutex
+		settings       Settings
+		clients        map[string]Client
+		paused         map[string]bool
+		state          State
+		resolved       string
+		lastErr        error
+		trippedAt      *time.Time
+		lastCheck      *time.Time
+	}
+
+	NewManagerOptions struct {
+		Logger         *zerolog.Logger
+		WSEventManager events.WSEventManagerInterface
+		// Lookup defaults to [InterfaceAddrs]
+		Lookup AddrsFunc
+		// DisableMonitor does not start the periodic check, used in tests
+		DisableMonitor bool
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	ret := &Manager{
+		logger:         opts.Logger,
+		wsEventManager: opts.WSEventManager,
+		lookup:         opts.Lookup,
+		clients:        make(map[string]Client),
+		paused:         make(map[string]bool),
+		state:          StateUnbound,
+	}
+	if ret.lookup == nil {
+		ret.lookup = InterfaceAddrs
+	}
+
+	if !opts.DisableMonitor {
+		go func() {
+			ticker := time.NewTicker(checkInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				ret.Check()
+			}
+		}()
+	}
+
+	return ret
+}
+
+// SetClient registers a client whose torrents are paused by the kill switch, nil removes it.
+// The previous client with the same name is resumed if it was paused.
+func (m *Manager) SetClient(name string, client Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if prev, found := m.clients[name]; found && m.paused[name] {
+		if err := prev.ResumeAll(); err != nil {
+			m.logger.Warn().Err(err).Str("client", name).Msg("network binding: Failed to resume torrents")
+		}
+		delete(m.paused, name)
+	}
+	if client == nil {
+		delete(m.clients, name)
+		return
+	}
+	m.clients[name] = client
+	if m.state == StateTripped {
+		m.pause(name, client)
+	}
+}
+
+// SetSettings should be called each time the settings are changed.
+func (m *Manager) SetSettings(settings Settings) {
+	m.mu.Lock()
+	m.settings = settings
+	m.mu.Unlock()
+
+	m.Check()
+}
+
+// GetSettings returns the current settings.
+func (m *Manager) GetSettings() Settings {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settings
+}
+
+// ResolveAddress returns the local address the torrent traffic should be bound to, nil if it is not bound.
+func (m *Manager) ResolveAddress() (net.IP, error) {
+	return ResolveAddress(m.GetSettings(), m.lookup)
+}
+
+// CheckAllowed returns [ErrKillSwitch] if new torrent activity should not be started.
+func (m *Manager) CheckAllowed() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state == StateTripped {
+		return ErrKillSwitch
+	}
+	return nil
+}
+
+// Check looks up the address of the bound interface and trips or resets the kill switch.
+func (m *Manager) Check() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.lastCheck = &now
+
+	if !m.settings.IsBound() {
+		m.resolved = ""
+		m.lastErr = nil
+		m.setState(StateUnbound)
+		return
+	}
+
+	addr, err := ResolveAddress(m.settings, m.lookup)
+	m.lastErr = err
+	if err == nil {
+		m.resolved = addr.String()
+		m.setState(StateUp)
+		return
+	}
+
+	m.resolved = ""
+	if !m.settings.KillSwitch {
+		m.setState(StateDown)
+		return
+	}
+
+	m.setState(StateTripped)
+	// Pause again on every check so that torrents added in the meantime are paused as well
+	for name, client := range m.clients {
+		m.pause(name, client)
+	}
+}
+
+// GetStatus returns the state of the binding and of the kill switch.
+func (m *Manager) GetStatus() *Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := &Status{
+		State:           m.state,
+		Interface:       m.settings.Interface,
+		Address:         m.settings.Address,
+		ResolvedAddress: m.resolved,
+		Socks5Proxy:     redactProxy(m.settings.Socks5Proxy),
+		KillSwitch:      m.settings.KillSwitch,
+		TrippedAt:       m.trippedAt,
+		LastCheck:       m.lastCheck,
+		Clients:         make([]string, 0, len(m.paused)),
+	}
+	if m.lastErr != nil {
+		ret.Error = m.lastErr.Error()
+	}
+	for name := range m.paused {
+		ret.Clients = append(ret.Clients, name)
+	}
+	sort.Strings(ret.Clients)
+	return ret
+}
+
+// setState resumes the paused clients when leaving the tripped state and notifies the transition.
+func (m *Manager) setState(state State) {
+	if m.state == state {
+		return
+	}
+	prev := m.state
+	m.state = state
+
+	switch {
+	case state == StateTripped:
+		now := time.Now()
+		m.trippedAt = &now
+		m.logger.Warn().Str("interface", m.settings.Interface).Str("address", m.settings.Address).Msg("network binding: Bound interface is down, pausing all torrent activity")
+		notifier.GlobalNotifier.Notify(notifier.NetworkBinding, fmt.Sprintf("%s is down, all torrent activity has been paused.", m.describeBinding()))
+	case prev == StateTripped:
+		m.trippedAt = nil
+		for name, client := range m.clients {
+			if !m.paused[name] {
+				continue
+			}
+			if err := client.ResumeAll(); err != nil {
+				m.logger.Warn().Err(err).Str("client", name).Msg("network binding: Failed to resume torrents")
+			}
+		}
+		m.paused = make(map[string]bool)
+		m.logger.Info().Msg("network binding: Bound interface is back up, resumed torrent activity")
+		if state == StateUp {
+			notifier.GlobalNotifier.Notify(notifier.NetworkBinding, fmt.Sprintf("%s is back up, torrent activity has been resumed.", m.describeBinding()))
+		}
+	case state == StateDown:
+		m.logger.Warn().Str("interface", m.settings.Interface).Str("address", m.settings.Address).Msg("network binding: Bound interface is down")
+	}
+
+	if m.wsEventManager != nil {
+		m.wsEventManager.SendEvent(events.NetworkBindingUpdated, state)
+	}
+}
+
+func (m *Manager) pause(name string, client Client) {
+	if err := client.PauseAll(); err != nil {
+		m.logger.Warn().Err(err).Str("client", name).Msg("network binding: Failed to pause torrents")
+		return
+	}
+	m.paused[name] = true
+}
+
+func (m *Manager) describeBinding() string {
+	switch {
+	case m.settings.Interface != "" && m.settings.Address != "":
+		return fmt.Sprintf("Interface %s (%s)", m.settings.Interface, m.settings.Address)
+	case m.settings.Interface != "":
+		return fmt.Sprintf("Interface %s", m.settings.Interface)
+	default:
+		return fmt.Sprintf("Address %s", m.settings.Address)
+	}
+}
@@ -0,0 +1,326 @@
+package playback_priority
+
+import (
+	"fmt"
+	"path/filepath"
+	"seanime/internal/util"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	StateIdle      State = "idle"      // Downloads run at full speed
+	StateLimited   State = "limited"   // The download limit is applied
+	StateRestoring State = "restoring" // The last session ended, the full speed will be restored after the delay
+
+	evaluateInterval = 30 * time.Second
+	maxTransitions   = 20
+)
+
+type (
+	State string
+
+	// TorrentClient is implemented by [torrent_client.Repository].
+	TorrentClient interface {
+		GetGlobalDownloadLimit() (int, error)
+		SetGlobalDownloadLimit(limit int) error
+		GetDownloadingPaths() ([]string, error)
+	}
+
+	Settings struct {
+		Enabled bool
+		// DownloadLimit is the download limit applied during playback, in bytes per second
+		DownloadLimit int
+		// MinActiveTorrents is the number of downloading torrents above which the limit is applied, 0 to ignore
+		MinActiveTorrents int
+		// SameDevice applies the limit when a download is on the same device as the file being played
+		SameDevice bool
+		// RestoreDelay is the time to wait after the last session ends before restoring the full speed
+		RestoreDelay time.Duration
+	}
+
+	Session struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+		// Path of the file being played, empty if it is not a local file
+		Path      string    `json:"path"`
+		StartedAt time.Time `json:"startedAt"`
+		// Incognito is true if the playback does not update the progress
+		Incognito bool `json:"incognito"`
+	}
+
+	Transition struct {
+		From   State     `json:"from"`
+		To     State     `json:"to"`
+		Reason string    `json:"reason"`
+		Time   time.Time `json:"time"`
+	}
+
+	Status struct {
+		Enabled bool   `json:"enabled"`
+		State   State  `json:"state"`
+		Reason  string `json:"reason"`
+		// AppliedLimit is the download limit set during playback, in bytes per second
+		AppliedLimit int `json:"appliedLimit"`
+		// PreviousLimit is the download limit that will be restored, 0 if there was none
+		PreviousLimit int           `json:"previousLimit"`
+		Sessions      []*Session    `json:"sessions"`
+		Transitions   []*Transition `json:"transitions"`
+	}
+
+	// Manager limits the download speed of the torrent client while something is being played,
+	// so that downloads do not cause playback to stutter when they compete for the same disk.
+	// The full speed is restored some time after the last playback session ends.
+	Manager struct {
+		logger        *zerolog.Logger
+		mu            sync.Mutex
+		client        TorrentClient
+		settings      Settings
+		sessions      map[string]*Session
+		state         State
+		reason        string
+		previousLimit int
+		restoreTimer  *time.Timer
+		transitions   []*Transition
+	}
+)
+
+func NewManager(logger *zerolog.Logger) *Manager {
+	ret := &Manager{
+		logger:      logger,
+		sessions:    make(map[string]*Session),
+		state:       StateIdle,
+		transitions: make([]*Transition, 0),
+	}
+
+	go func() {
+		ticker := time.NewTicker(evaluateInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ret.evaluate()
+		}
+	}()
+
+	return ret
+}
+
+// SetTorrentClient should be called each time the torrent client is changed.
+// The limit applied to the previous client is removed.
+func (m *Manager) SetTorrentClient(client TorrentClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state != StateIdle {
+		m.restore("Torrent client changed")
+	}
+	m.client = client
+}
+
+// SetSettings should be called each time the settings are changed.
+func (m *Manager) SetSettings(settings Settings) {
+	m.mu.Lock()
+	if !settings.Enabled && m.state != StateIdle {
+		m.restore("Playback priority disabled")
+	}
+	m.settings = settings
+	m.mu.Unlock()
+
+	m.evaluate()
+}
+
+// StartSession registers a playback or stream session.
+// Starting a session with an ID that is already registered replaces it.
+func (m *Manager) StartSession(id string, kind string, path string) {
+	if path != "" && !filepath.IsAbs(path) {
+		path = "" // e.g. URLs
+	}
+
+	m.mu.Lock()
+	m.sessions[id] = &Session{
+		ID:        id,
+		Kind:      kind,
+		Path:      path,
+		StartedAt: time.Now(),
+	}
+	if m.state == StateRestoring {
+		m.stopRestoreTimer()
+		m.transition(StateLimited, "Playback resumed")
+	}
+	m.mu.Unlock()
+
+	m.evaluate()
+}
+
+// SetSessionIncognito marks a session as incognito so that the status explains why its progress is not updated.
+func (m *Manager) SetSessionIncognito(id string, incognito bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if session, found := m.sessions[id]; found {
+		session.Incognito = incognito
+	}
+}
+
+// EndSession removes a session.
+// When the last session ends, the full speed is restored after the delay.
+func (m *Manager) EndSession(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.sessions[id]; !found {
+		return
+	}
+	delete(m.sessions, id)
+
+	if len(m.sessions) > 0 || m.state != StateLimited {
+		return
+	}
+
+	if m.settings.RestoreDelay <= 0 {
+		m.restore("Playback ended")
+		return
+	}
+
+	m.transition(StateRestoring, fmt.Sprintf("Playback ended, restoring in %s", m.settings.RestoreDelay))
+	m.restoreTimer = time.AfterFunc(m.settings.RestoreDelay, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.state == StateRestoring {
+			m.restore("Restore delay elapsed")
+		}
+	})
+}
+
+// GetStatus returns the current state, sessions and the last state transitions.
+func (m *Manager) GetStatus() *Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := &Status{
+		Enabled:       m.settings.Enabled,
+		State:         m.state,
+		Reason:        m.reason,
+		PreviousLimit: m.previousLimit,
+		Sessions:      make([]*Session, 0, len(m.sessions)),
+		Transitions:   slices.Clone(m.transitions),
+	}
+	if m.state != StateIdle {
+		ret.AppliedLimit = m.settings.DownloadLimit
+	}
+	for _, s := range m.sessions {
+		ret.Sessions = append(ret.Sessions, s)
+	}
+	slices.SortFunc(ret.Sessions, func(a, b *Session) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+
+	return ret
+}
+
+// evaluate applies the limit if there are active sessions and the thresholds are met.
+func (m *Manager) evaluate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.settings.Enabled || m.settings.DownloadLimit <= 0 || m.client == nil || len(m.sessions) == 0 || m.state != StateIdle {
+		return
+	}
+
+	reason, ok := m.shouldLimit()
+	if !ok {
+		return
+	}
+
+	previousLimit, err := m.client.GetGlobalDownloadLimit()
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("playback priority: Failed to get the download limit")
+		return
+	}
+	if previousLimit > 0 && previousLimit <= m.settings.DownloadLimit {
+		// The torrent client is already slower than the limit
+		return
+	}
+
+	if err := m.client.SetGlobalDownloadLimit(m.settings.DownloadLimit); err != nil {
+		m.logger.Warn().Err(err).Msg("playback priority: Failed to set the download limit")
+		return
+	}
+
+	m.previousLimit = previousLimit
+	m.transition(StateLimited, reason)
+}
+
+// shouldLimit checks the thresholds.
+func (m *Manager) shouldLimit() (string, bool) {
+	paths, err := m.client.GetDownloadingPaths()
+	if err != nil || len(paths) == 0 {
+		return "", false
+	}
+
+	if m.settings.MinActiveTorrents <= 0 && !m.settings.SameDevice {
+		return fmt.Sprintf("Playback started while %d torrents are downloading", len(paths)), true
+	}
+
+	if m.settings.MinActiveTorrents > 0 && len(paths) > m.settings.MinActiveTorrents {
+		return fmt.Sprintf("%d torrents are downloading", len(paths)), true
+	}
+
+	if m.settings.SameDevice {
+		for _, session := range m.sessions {
+			if session.Path == "" {
+				continue
+			}
+			for _, path := range paths {
+				if path != "" && util.IsSameDevice(session.Path, path) {
+					return fmt.Sprintf("A download is on the same device as %s", filepath.Base(session.Path)), true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// restore removes the limit.
+// The caller must hold the lock.
+func (m *Manager) restore(reason string) {
+	m.stopRestoreTimer()
+
+	if m.client != nil {
+		if err := m.client.SetGlobalDownloadLimit(m.previousLimit); err != nil {
+			m.logger.Warn().Err(err).Msg("playback priority: Failed to restore the download limit")
+		}
+	}
+
+	m.previousLimit = 0
+	m.transition(StateIdle, reason)
+}
+
+func (m *Manager) stopRestoreTimer() {
+	if m.restoreTimer != nil {
+		m.restoreTimer.Stop()
+		m.restoreTimer = nil
+	}
+}
+
+// transition changes the state and keeps track of it.
+// The caller must hold the lock.
+func (m *Manager) transition(to State, reason string) {
+	m.logger.Info().Str("from", string(m.state)).Str("to", string(to)).Msgf("playback priority: %s", reason)
+
+	m.transitions = append(m.transitions, &Transition{
+		From:   m.state,
+		To:     to,
+		Reason: reason,
+		Time:   time.Now(),
+	})
+	if len(m.transitions) > maxTransitions {
+		m.transitions = m.transitions[len(m.transitions)-maxTransitions:]
+	}
+
+	m.state = to
+	m.reason = reason
+}
@@ -0,0 +1,185 @@
+package playback_priority
+
+import (
+	"path/filepath"
+	"seanime/internal/util"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTorrentClient struct {
+	mu    sync.Mutex
+	limit int
+	paths []string
+}
+
+func (c *fakeTorrentClient) GetGlobalDownloadLimit() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit, nil
+}
+
+func (c *fakeTorrentClient) SetGlobalDownloadLimit(limit int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	return nil
+}
+
+func (c *fakeTorrentClient) GetDownloadingPaths() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paths, nil
+}
+
+func (c *fakeTorrentClient) getLimit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+func newTestManager(client TorrentClient, settings Settings) *Manager {
+	m := NewManager(util.NewLogger())
+	m.SetTorrentClient(client)
+	m.SetSettings(settings)
+	return m
+}
+
+func TestPlaybackPriority_LimitAndRestore(t *testing.T) {
+	client := &fakeTorrentClient{limit: 5000, paths: []string{"/downloads/a"}}
+	m := newTestManager(client, Settings{Enabled: true, DownloadLimit: 1000})
+
+	m.StartSession("playback", "playback", "/anime/ep1.mkv")
+	assert.Equal(t, 1000, client.getLimit())
+	assert.Equal(t, StateLimited, m.GetStatus().State)
+	assert.Equal(t, 5000, m.GetStatus().PreviousLimit)
+
+	// Another session does not change anything
+	m.StartSession("directstream", "directstream", "")
+	m.EndSession("playback")
+	assert.Equal(t, StateLimited, m.GetStatus().State)
+
+	m.EndSession("directstream")
+	assert.Equal(t, 5000, client.getLimit())
+
+	status := m.GetStatus()
+	assert.Equal(t, StateIdle, status.State)
+	assert.Empty(t, status.Sessions)
+	require.Len(t, status.Transitions, 2)
+	assert.Equal(t, StateLimited, status.Transitions[0].To)
+	assert.Equal(t, StateIdle, status.Transitions[1].To)
+}
+
+func TestPlaybackPriority_RestoreDelay(t *testing.T) {
+	client := &fakeTorrentClient{paths: []string{"/downloads/a"}}
+	m := newTestManager(client, Settings{Enabled: true, DownloadLimit: 1000, RestoreDelay: 50 * time.Millisecond})
+
+	m.StartSession("playback", "playback", "")
+	m.EndSession("playback")
+	assert.Equal(t, StateRestoring, m.GetStatus().State)
+	assert.Equal(t, 1000, client.getLimit())
+
+	// Playback resumes before the delay elapses
+	m.StartSession("playback", "playback", "")
+	assert.Equal(t, StateLimited, m.GetStatus().State)
+
+	m.EndSession("playback")
+	assert.Eventually(t, func() bool {
+		return m.GetStatus().State == StateIdle
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, client.getLimit())
+}
+
+func TestPlaybackPriority_Thresholds(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		settings Settings
+		paths    []string
+		session  string
+		expected State
+	}{
+		{
+			name:     "no downloads",
+			settings: Settings{Enabled: true, DownloadLimit: 1000},
+			expected: StateIdle,
+		},
+		{
+			name:     "disabled",
+			settings: Settings{Enabled: false, DownloadLimit: 1000},
+			paths:    []string{"/downloads/a"},
+			expected: StateIdle,
+		},
+		{
+			name:     "below active torrents threshold",
+			settings: Settings{Enabled: true, DownloadLimit: 1000, MinActiveTorrents: 2},
+			paths:    []string{"/downloads/a", "/downloads/b"},
+			expected: StateIdle,
+		},
+		{
+			name:     "above active torrents threshold",
+			settings: Settings{Enabled: true, DownloadLimit: 1000, MinActiveTorrents: 2},
+			paths:    []string{"/downloads/a", "/downloads/b", "/downloads/c"},
+			expected: StateLimited,
+		},
+		{
+			name:     "same device",
+			settings: Settings{Enabled: true, DownloadLimit: 1000, MinActiveTorrents: 5, SameDevice: true},
+			paths:    []string{filepath.Join(dir, "downloads", "batch")},
+			session:  filepath.Join(dir, "anime", "ep1.mkv"),
+			expected: StateLimited,
+		},
+		{
+			name:     "same device without local file",
+			settings: Settings{Enabled: true, DownloadLimit: 1000, SameDevice: true},
+			paths:    []string{filepath.Join(dir, "downloads", "batch")},
+			session:  "http://127.0.0.1/stream",
+			expected: StateIdle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeTorrentClient{paths: tt.paths}
+			m := newTestManager(client, tt.settings)
+
+			m.StartSession("playback", "playback", tt.session)
+			assert.Equal(t, tt.expected, m.GetStatus().State)
+		})
+	}
+}
+
+func TestPlaybackPriority_Disable(t *testing.T) {
+	client := &fakeTorrentClient{paths: []string{"/downloads/a"}}
+	settings := Settings{Enabled: true, DownloadLimit: 1000, RestoreDelay: time.Hour}
+	m := newTestManager(client, settings)
+
+	m.StartSession("playback", "playback", "")
+	assert.Equal(t, 1000, client.getLimit())
+
+	settings.Enabled = false
+	m.SetSettings(settings)
+	assert.Equal(t, StateIdle, m.GetStatus().State)
+	assert.Equal(t, 0, client.getLimit())
+}
+
+func TestPlaybackPriority_SessionIncognito(t *testing.T) {
+	m := newTestManager(&fakeTorrentClient{}, Settings{})
+
+	m.StartSession("playback", "playback", "/anime/ep1.mkv")
+	m.SetSessionIncognito("playback", true)
+	m.SetSessionIncognito("unknown", true)
+
+	status := m.GetStatus()
+	require.Len(t, status.Sessions, 1)
+	assert.True(t, status.Sessions[0].Incognito)
+
+	// A new playback replaces the session
+	m.StartSession("playback", "stream", "")
+	assert.False(t, m.GetStatus().Sessions[0].Incognito)
+}
@@ -0,0 +1,433 @@
+package playback_priority
+
+import (
+	"fmt"
+	"net"
+	"seanime/internal/notifier"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	SeedingActionPaused  SeedingAction = "paused"
+	SeedingActionResumed SeedingAction = "resumed"
+
+	maxSeedingEvents = 100
+)
+
+// DefaultLanRanges are used when no LAN ranges are configured.
+var DefaultLanRanges = []string{
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"169.254.0.0/16",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+type (
+	SeedingAction string
+
+	SeedingTorrent struct {
+		Hash string
+		Name string
+		Tags []string
+	}
+
+	// SeedingClient is implemented by adapters of [torrent_client.Repository] and [torrentstream.Repository].
+	SeedingClient interface {
+		GetSeedingTorrents() ([]*SeedingTorrent, error)
+		PauseSeeding(hashes []string) error
+		ResumeSeeding(hashes []string) error
+	}
+
+	SeedingSettings struct {
+		Enabled bool
+		// LanRanges are the CIDR ranges of the local network, streams to clients in these ranges do not pause seeding
+		LanRanges []string
+		// GracePeriod is the time to wait after the last remote session ends before resuming the torrents
+		GracePeriod time.Duration
+		// ProtectedTags exempts torrents with any of these tags (or categories, labels) from pausing
+		ProtectedTags []string
+	}
+
+	RemoteSession struct {
+		ID        string    `json:"id"`
+		IP        string    `json:"ip"`
+		StartedAt time.Time `json:"startedAt"`
+	}
+
+	PausedTorrent struct {
+		Client   string    `json:"client"`
+		Hash     string    `json:"hash"`
+		Name     string    `json:"name"`
+		PausedAt time.Time `json:"pausedAt"`
+	}
+
+	// SeedingEvent is an entry of the trail of actions taken by the [SeedingManager].
+	SeedingEvent struct {
+		Action SeedingAction `json:"action"`
+		Client string        `json:"client"`
+		Hash   string        `json:"hash"`
+		Name   string        `json:"name"`
+		Reason string        `json:"reason"`
+		Time   time.Time     `json:"time"`
+	}
+
+	SeedingStatus struct {
+		Enabled bool `json:"enabled"`
+		// ResumeAt is set while waiting for the grace period to elapse
+		ResumeAt *time.Time       `json:"resumeAt"`
+		Sessions []*RemoteSession `json:"sessions"`
+		Paused   []*PausedTorrent `json:"paused"`
+		Events   []*SeedingEvent  `json:"events"`
+	}
+
+	// SeedingManager pauses seeding-only torrents while media is streamed to a client outside the local network,
+	// so that the upload bandwidth is available for the stream.
+	// The torrents are resumed when the grace period after the last remote session has elapsed.
+	SeedingManager struct {
+		logger      *zerolog.Logger
+		mu          sync.Mutex
+		clients     map[string]SeedingClient
+		settings    SeedingSettings
+		lanNets     []*net.IPNet
+		sessions    map[string]*RemoteSession
+		paused      map[string]*PausedTorrent // Key: client + hash
+		resumeTimer *time.Timer
+		resumeAt    *time.Time
+		events      []*SeedingEvent
+	}
+)
+
+func NewSeedingManager(logger *zerolog.Logger) *SeedingManager {
+	return &SeedingManager{
+		logger:   logger,
+		clients:  make(map[string]SeedingClient),
+		lanNets:  mustParseLanRanges(DefaultLanRanges),
+		sessions: make(map[string]*RemoteSession),
+		paused:   make(map[string]*PausedTorrent),
+		events:   make([]*SeedingEvent, 0),
+	}
+}
+
+// ParseLanRanges parses CIDR ranges.
+func ParseLanRanges(ranges []string) ([]*net.IPNet, error) {
+	ret := make([]*net.IPNet, 0, len(ranges))
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("playback priority: Invalid LAN range %q", r)
+		}
+		ret = append(ret, ipNet)
+	}
+	return ret, nil
+}
+
+func mustParseLanRanges(ranges []string) []*net.IPNet {
+	ret, err := ParseLanRanges(ranges)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// SetClient registers a client whose torrents can be paused, nil removes it.
+// The torrents paused on the previous client with the same name are resumed.
+func (m *SeedingManager) SetClient(name string, client SeedingClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.clients[name]; found {
+		m.resumeClient(name, "Torrent client changed")
+	}
+	if client == nil {
+		delete(m.clients, name)
+		return
+	}
+	m.clients[name] = client
+}
+
+// SetSettings should be called each time the settings are changed.
+// Invalid LAN ranges are ignored, the default ranges are used if none are valid.
+func (m *SeedingManager) SetSettings(settings SeedingSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lanNets, err := ParseLanRanges(settings.LanRanges)
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("playback priority: Using the default LAN ranges")
+		lanNets = nil
+	}
+	if len(lanNets) == 0 {
+		lanNets = mustParseLanRanges(DefaultLanRanges)
+	}
+
+	m.settings = settings
+	m.lanNets = lanNets
+
+	if !settings.Enabled {
+		m.sessions = make(map[string]*RemoteSession)
+		m.resumeAll("Seeding pause disabled")
+	}
+}
+
+// IsRemote returns true if the IP address is outside the LAN ranges.
+func (m *SeedingManager) IsRemote(ip string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.isRemote(ip)
+}
+
+func (m *SeedingManager) isRemote(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range m.lanNets {
+		if ipNet.Contains(parsed) {
+			return false
+		}
+	}
+	return true
+}
+
+// StartSession registers a stream session from the given client IP.
+// If the client is outside the LAN ranges, the seeding torrents are paused.
+// Starting a session with an ID that is already registered replaces it and pauses newly seeding torrents.
+func (m *SeedingManager) StartSession(id string, ip string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.settings.Enabled {
+		return
+	}
+	if !m.isRemote(ip) {
+		// The client may have moved to the LAN
+		m.endSession(id)
+		return
+	}
+
+	m.sessions[id] = &RemoteSession{
+		ID:        id,
+		IP:        ip,
+		StartedAt: time.Now(),
+	}
+	m.stopResumeTimer()
+	m.pauseSeeding(fmt.Sprintf("Remote stream started from %s", ip))
+}
+
+// EndSession removes a session.
+// When the last remote session ends, the torrents are resumed after the grace period.
+func (m *SeedingManager) EndSession(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endSession(id)
+}
+
+func (m *SeedingManager) endSession(id string) {
+	if _, found := m.sessions[id]; !found {
+		return
+	}
+	delete(m.sessions, id)
+
+	if len(m.sessions) > 0 || len(m.paused) == 0 {
+		return
+	}
+
+	if m.settings.GracePeriod <= 0 {
+		m.resumeAll("Remote stream ended")
+		return
+	}
+
+	m.stopResumeTimer()
+	resumeAt := time.Now().Add(m.settings.GracePeriod)
+	m.resumeAt = &resumeAt
+	m.resumeTimer = time.AfterFunc(m.settings.GracePeriod, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if len(m.sessions) == 0 {
+			m.resumeAll("Grace period elapsed")
+		}
+	})
+}
+
+// GetStatus returns the remote sessions, the paused torrents and the trail of actions.
+func (m *SeedingManager) GetStatus() *SeedingStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := &SeedingStatus{
+		Enabled:  m.settings.Enabled,
+		ResumeAt: m.resumeAt,
+		Sessions: make([]*RemoteSession, 0, len(m.sessions)),
+		Paused:   make([]*PausedTorrent, 0, len(m.paused)),
+		Events:   slices.Clone(m.events),
+	}
+	for _, s := range m.sessions {
+		ret.Sessions = append(ret.Sessions, s)
+	}
+	slices.SortFunc(ret.Sessions, func(a, b *RemoteSession) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	for _, p := range m.paused {
+		ret.Paused = append(ret.Paused, p)
+	}
+	slices.SortFunc(ret.Paused, func(a, b *PausedTorrent) int {
+		return a.PausedAt.Compare(b.PausedAt)
+	})
+
+	return ret
+}
+
+// pauseSeeding pauses the seeding torrents that are not protected.
+// The caller must hold the lock.
+func (m *SeedingManager) pauseSeeding(reason string) {
+	count := 0
+	for name, client := range m.clients {
+		torrents, err := client.GetSeedingTorrents()
+		if err != nil {
+			m.logger.Warn().Err(err).Str("client", name).Msg("playback priority: Failed to get seeding torrents")
+			continue
+		}
+
+		toPause := make([]*SeedingTorrent, 0, len(torrents))
+		for _, t := range torrents {
+			if _, found := m.paused[name+t.Hash]; found || m.isProtected(t) {
+				continue
+			}
+			toPause = append(toPause, t)
+		}
+		if len(toPause) == 0 {
+			continue
+		}
+
+		hashes := make([]string, 0, len(toPause))
+		for _, t := range toPause {
+			hashes = append(hashes, t.Hash)
+		}
+		if err := client.PauseSeeding(hashes); err != nil {
+			m.logger.Warn().Err(err).Str("client", name).Msg("playback priority: Failed to pause seeding torrents")
+			continue
+		}
+
+		now := time.Now()
+		for _, t := range toPause {
+			m.paused[name+t.Hash] = &PausedTorrent{
+				Client:   name,
+				Hash:     t.Hash,
+				Name:     t.Name,
+				PausedAt: now,
+			}
+			m.addEvent(SeedingActionPaused, name, t.Hash, t.Name, reason)
+		}
+		count += len(toPause)
+	}
+
+	if count > 0 {
+		m.logger.Info().Int("count", count).Msgf("playback priority: Paused seeding torrents, %s", reason)
+		notifier.GlobalNotifier.Notify(notifier.Seeding, fmt.Sprintf("%s, paused %d seeding torrent(s).", reason, count))
+	}
+}
+
+// isProtected returns true if the torrent has one of the protected tags.
+func (m *SeedingManager) isProtected(t *SeedingTorrent) bool {
+	for _, tag := range t.Tags {
+		for _, protected := range m.settings.ProtectedTags {
+			if strings.EqualFold(strings.TrimSpace(tag), strings.TrimSpace(protected)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resumeAll resumes all the paused torrents.
+// The caller must hold the lock.
+func (m *SeedingManager) resumeAll(reason string) {
+	m.stopResumeTimer()
+
+	count := 0
+	for name := range m.clients {
+		count += m.resumeClient(name, reason)
+	}
+	// Torrents of clients that were removed cannot be resumed
+	for key, p := range m.paused {
+		if _, found := m.clients[p.Client]; !found {
+			delete(m.paused, key)
+		}
+	}
+
+	if count > 0 {
+		m.logger.Info().Int("count", count).Msgf("playback priority: Resumed seeding torrents, %s", reason)
+		notifier.GlobalNotifier.Notify(notifier.Seeding, fmt.Sprintf("%s, resumed %d seeding torrent(s).", reason, count))
+	}
+}
+
+// resumeClient resumes the torrents paused on a client and returns the number of resumed torrents.
+// The caller must hold the lock.
+func (m *SeedingManager) resumeClient(name string, reason string) int {
+	client, found := m.clients[name]
+	if !found {
+		return 0
+	}
+
+	toResume := make([]*PausedTorrent, 0)
+	for _, p := range m.paused {
+		if p.Client == name {
+			toResume = append(toResume, p)
+		}
+	}
+	if len(toResume) == 0 {
+		return 0
+	}
+
+	hashes := make([]string, 0, len(toResume))
+	for _, p := range toResume {
+		hashes = append(hashes, p.Hash)
+	}
+	if err := client.ResumeSeeding(hashes); err != nil {
+		m.logger.Warn().Err(err).Str("client", name).Msg("playback priority: Failed to resume seeding torrents")
+		return 0
+	}
+
+	for _, p := range toResume {
+		delete(m.paused, name+p.Hash)
+		m.addEvent(SeedingActionResumed, name, p.Hash, p.Name, reason)
+	}
+	return len(toResume)
+}
+
+func (m *SeedingManager) stopResumeTimer() {
+	if m.resumeTimer != nil {
+		m.resumeTimer.Stop()
+		m.resumeTimer = nil
+	}
+	m.resumeAt = nil
+}
+
+// addEvent keeps track of the actions taken.
+// The caller must hold the lock.
+func (m *SeedingManager) addEvent(action SeedingAction, client string, hash string, name string, reason string) {
+	m.events = append(m.events, &SeedingEvent{
+		Action: action,
+		Client: client,
+		Hash:   hash,
+		Name:   name,
+		Reason: reason,
+		Time:   time.Now(),
+	})
+	if len(m.events) > maxSeedingEvents {
+		m.events = m.events[len(m.events)-maxSeedingEvents:]
+	}
+}
@@ -0,0 +1,147 @@
+package playback_priority
+
+import (
+	"seanime/internal/util"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSeedingClient struct {
+	mu       sync.Mutex
+	torrents []*SeedingTorrent
+	paused   []string
+}
+
+func (c *fakeSeedingClient) GetSeedingTorrents() ([]*SeedingTorrent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*SeedingTorrent, 0)
+	for _, t := range c.torrents {
+		if !slices.Contains(c.paused, t.Hash) {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
+func (c *fakeSeedingClient) PauseSeeding(hashes []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = append(c.paused, hashes...)
+	return nil
+}
+
+func (c *fakeSeedingClient) ResumeSeeding(hashes []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = slices.DeleteFunc(c.paused, func(h string) bool {
+		return slices.Contains(hashes, h)
+	})
+	return nil
+}
+
+func (c *fakeSeedingClient) getPaused() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.paused)
+}
+
+func newTestSeedingManager(settings SeedingSettings) (*SeedingManager, *fakeSeedingClient) {
+	client := &fakeSeedingClient{
+		torrents: []*SeedingTorrent{
+			{Hash: "a", Name: "Torrent A"},
+			{Hash: "b", Name: "Torrent B", Tags: []string{"Private-Tracker"}},
+		},
+	}
+	m := NewSeedingManager(util.NewLogger())
+	m.SetClient("torrent-client", client)
+	m.SetSettings(settings)
+	return m, client
+}
+
+func TestSeedingManager_PauseAndResume(t *testing.T) {
+	m, client := newTestSeedingManager(SeedingSettings{Enabled: true, ProtectedTags: []string{"private-tracker"}})
+
+	// LAN client
+	m.StartSession("mediastream", "192.168.1.20")
+	assert.Empty(t, client.getPaused())
+
+	m.StartSession("mediastream", "203.0.113.5")
+	assert.Equal(t, []string{"a"}, client.getPaused())
+
+	status := m.GetStatus()
+	require.Len(t, status.Paused, 1)
+	assert.Equal(t, "a", status.Paused[0].Hash)
+	require.Len(t, status.Sessions, 1)
+
+	m.EndSession("mediastream")
+	assert.Empty(t, client.getPaused())
+
+	status = m.GetStatus()
+	assert.Empty(t, status.Paused)
+	require.Len(t, status.Events, 2)
+	assert.Equal(t, SeedingActionPaused, status.Events[0].Action)
+	assert.Equal(t, "Torrent A", status.Events[0].Name)
+	assert.Equal(t, SeedingActionResumed, status.Events[1].Action)
+}
+
+func TestSeedingManager_GracePeriod(t *testing.T) {
+	m, client := newTestSeedingManager(SeedingSettings{Enabled: true, GracePeriod: 50 * time.Millisecond})
+
+	m.StartSession("mediastream", "203.0.113.5")
+	m.EndSession("mediastream")
+	assert.Len(t, client.getPaused(), 2)
+	assert.NotNil(t, m.GetStatus().ResumeAt)
+
+	// The stream restarts before the grace period elapses
+	m.StartSession("mediastream", "203.0.113.5")
+	assert.Nil(t, m.GetStatus().ResumeAt)
+
+	m.EndSession("mediastream")
+	assert.Eventually(t, func() bool {
+		return len(client.getPaused()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSeedingManager_LanRanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		lanRanges []string
+		ip        string
+		expected  bool
+	}{
+		{name: "default private range", ip: "10.0.0.3", expected: false},
+		{name: "default loopback", ip: "::1", expected: false},
+		{name: "default public", ip: "203.0.113.5", expected: true},
+		{name: "custom range", lanRanges: []string{"203.0.113.0/24"}, ip: "203.0.113.5", expected: false},
+		{name: "outside custom range", lanRanges: []string{"203.0.113.0/24"}, ip: "192.168.1.20", expected: true},
+		{name: "invalid range falls back to default", lanRanges: []string{"invalid"}, ip: "192.168.1.20", expected: false},
+		{name: "invalid ip", ip: "unknown", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewSeedingManager(util.NewLogger())
+			m.SetSettings(SeedingSettings{Enabled: true, LanRanges: tt.lanRanges})
+			assert.Equal(t, tt.expected, m.IsRemote(tt.ip))
+		})
+	}
+}
+
+func TestSeedingManager_Disable(t *testing.T) {
+	settings := SeedingSettings{Enabled: true, GracePeriod: time.Hour}
+	m, client := newTestSeedingManager(settings)
+
+	m.StartSession("mediastream", "203.0.113.5")
+	assert.Len(t, client.getPaused(), 2)
+
+	settings.Enabled = false
+	m.SetSettings(settings)
+	assert.Empty(t, client.getPaused())
+	assert.Empty(t, m.GetStatus().Sessions)
+}
@@ -1,10 +1,13 @@
 package qbittorrent_application
 
 import (
+	"encoding/json"
 	"github.com/rs/zerolog"
 	"net/http"
+	"net/url"
 	qbittorrent_model "seanime/internal/torrent_clients/qbittorrent/model"
 	qbittorrent_util "seanime/internal/torrent_clients/qbittorrent/util"
+	"strings"
 )
 
 type Client struct {
@@ -52,6 +55,20 @@ func (c Client) SetAppPreferences(p *qbittorrent_model.Preferences) error {
 	return nil
 }
 
+// SetPreferences only updates the given preferences, keyed by their JSON name.
+func (c Client) SetPreferences(prefs map[string]interface{}) error {
+	b, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Add("json", string(b))
+	if err := qbittorrent_util.PostWithContentType(c.Client, c.BaseUrl+"/setPreferences", strings.NewReader(params.Encode()), "application/x-www-form-urlencoded"); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c Client) GetDefaultSavePath() (string, error) {
 	var res string
 	if err := qbittorrent_util.GetInto(c.Client, &res, c.BaseUrl+"/defaultSavePath", nil); err != nil {
@@ -1,10 +1,9 @@
 package qbittorrent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"net/http/cookiejar"
-	"net/url"
 	"seanime/internal/torrent_clients/qbittorrent/application"
 	"seanime/internal/torrent_clients/qbittorrent/log"
 	"seanime/internal/torrent_clients/qbittorrent/rss"
@@ -12,17 +11,17 @@ import (
 	"seanime/internal/torrent_clients/qbittorrent/sync"
 	"seanime/internal/torrent_clients/qbittorrent/torrent"
 	"seanime/internal/torrent_clients/qbittorrent/transfer"
+	httputil "seanime/internal/util/http"
 	"strings"
 
 	"github.com/rs/zerolog"
-
-	"golang.org/x/net/publicsuffix"
 )
 
 type Client struct {
 	baseURL          string
 	logger           *zerolog.Logger
 	client           *http.Client
+	transport        *sessionTransport
 	Username         string
 	Password         string
 	Port             int
@@ -48,6 +47,8 @@ type NewClientOptions struct {
 	Path             string
 	DisableBinaryUse bool
 	Tags             string
+	// CustomHeaders are added to every request, e.g. for reverse-proxy authentication
+	CustomHeaders map[string]string
 }
 
 func NewClient(opts *NewClientOptions) *Client {
@@ -69,11 +70,16 @@ func NewClient(opts *NewClientOptions) *Client {
 		baseURL = fmt.Sprintf("%s://%s/api/v2", scheme, host)
 	}
 
-	client := &http.Client{}
+	// The API clients share the same authenticated session and connections
+	transport := newSessionTransport(httputil.NewHeaderRoundTripper(newPooledTransport(), opts.CustomHeaders), baseURL, opts.Username, opts.Password)
+	client := &http.Client{
+		Transport: transport,
+	}
 	return &Client{
 		baseURL:          baseURL,
 		logger:           opts.Logger,
 		client:           client,
+		transport:        transport,
 		Username:         opts.Username,
 		Password:         opts.Password,
 		Port:             opts.Port,
@@ -119,42 +125,11 @@ func NewClient(opts *NewClientOptions) *Client {
 	}
 }
 
+// Login authenticates to qBittorrent.
+// It is not required before using the API clients, they log in again when the session has expired.
+// The returned error wraps ErrAuthFailed if the credentials are rejected.
 func (c *Client) Login() error {
-	endpoint := c.baseURL + "/auth/login"
-	data := url.Values{}
-	data.Add("username", c.Username)
-	data.Add("password", c.Password)
-	request, err := http.NewRequest("POST", endpoint, strings.NewReader(data.Encode()))
-	if err != nil {
-		return err
-	}
-	request.Header.Add("content-type", "application/x-www-form-urlencoded")
-	resp, err := c.client.Do(request)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			c.logger.Err(err).Msg("failed to close login response body")
-		}
-	}()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("invalid status %s", resp.Status)
-	}
-	if len(resp.Cookies()) < 1 {
-		return fmt.Errorf("no cookies in login response")
-	}
-	apiURL, err := url.Parse(c.baseURL)
-	if err != nil {
-		return err
-	}
-	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
-	if err != nil {
-		return err
-	}
-	jar.SetCookies(apiURL, []*http.Cookie{resp.Cookies()[0]})
-	c.client.Jar = jar
-	return nil
+	return c.transport.login(context.Background())
 }
 
 func (c *Client) Logout() error {
@@ -167,8 +142,10 @@ func (c *Client) Logout() error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("invalid status %s", resp.Status)
 	}
+	c.transport.clearCookie()
 	return nil
 }
@@ -77,6 +77,10 @@ type Preferences struct {
 	MaxRatioAct MaxRatioAction `json:"max_ratio_act"`
 	// Port for incoming connections
 	ListenPort int `json:"listen_port"`
+	// Name of the network interface used for the torrent traffic; empty means any interface
+	CurrentNetworkInterface string `json:"current_network_interface"`
+	// IP address of the network interface used for the torrent traffic; empty means all addresses
+	CurrentInterfaceAddress string `json:"current_interface_address"`
 	// True if UPnP/NAT-PMP is enabled
 	Upnp bool `json:"upnp"`
 	// True if the port is randomly selected
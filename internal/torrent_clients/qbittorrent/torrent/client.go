@@ -3,6 +3,7 @@ package qbittorrent_torrent
 import (
 	"fmt"
 	"github.com/rs/zerolog"
+	"io"
 	"net/http"
 	"net/url"
 	qbittorrent_model "seanime/internal/torrent_clients/qbittorrent/model"
@@ -107,6 +108,29 @@ func (c Client) GetPieceHashes(hash string) ([]string, error) {
 	return res, nil
 }
 
+// ExportTorrent returns the .torrent file of a torrent, it requires qBittorrent v4.5.0 or later.
+func (c Client) ExportTorrent(hash string) (ret []byte, err error) {
+	params := url.Values{}
+	params.Add("hash", hash)
+	r, err := http.NewRequest("GET", c.BaseUrl+"/export?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err2 := resp.Body.Close(); err2 != nil {
+			err = err2
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid response status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 func (c Client) StopTorrents(hashes []string) error {
 	value := strings.Join(hashes, "|")
 	params := url.Values{}
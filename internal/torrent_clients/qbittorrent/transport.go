@@ -0,0 +1,301 @@
+package qbittorrent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrAuthFailed is returned when qBittorrent rejects the credentials.
+// Connectivity failures are returned as the errors of the HTTP client.
+var ErrAuthFailed = errors.New("qbittorrent: authentication failed")
+
+const (
+	// readCacheTTL is how long the responses of the read endpoints are shared between callers
+	readCacheTTL = 500 * time.Millisecond
+	// maxIdleConns is the number of idle connections kept open to qBittorrent
+	maxIdleConns = 8
+)
+
+// cachedEndpoints are the read endpoints whose responses are cached for readCacheTTL.
+// They are polled by the progress watcher and the UI.
+var cachedEndpoints = []string{
+	"/torrents/info",
+	"/torrents/properties",
+	"/torrents/files",
+	"/torrents/trackers",
+	"/torrents/pieceStates",
+	"/transfer/info",
+	"/app/version",
+	"/app/preferences",
+	"/app/defaultSavePath",
+}
+
+// sessionTransport is the http.RoundTripper shared by the API clients.
+//
+// It keeps the session cookie of qBittorrent and logs in again when a request is rejected with a 403 because the session has expired.
+// Identical GET requests that are in flight at the same time share one upstream request,
+// and the responses of the cachedEndpoints are reused for readCacheTTL.
+// Any other request (e.g. adding or removing torrents) clears the cache.
+type sessionTransport struct {
+	inner    http.RoundTripper
+	loginURL string
+	username string
+	password string
+	cacheTTL time.Duration
+
+	mu         sync.RWMutex
+	cookie     *http.Cookie
+	cache      map[string]*cachedResponse
+	generation uint64 // Incremented by every write so that reads started before it are not cached
+
+	group singleflight.Group
+	// upstreamRequests is the number of requests sent to qBittorrent, including the logins
+	upstreamRequests atomic.Int64
+}
+
+type cachedResponse struct {
+	statusCode int
+	status     string
+	header     http.Header
+	body       []byte
+	expiresAt  time.Time
+}
+
+func newSessionTransport(inner http.RoundTripper, baseURL string, username string, password string) *sessionTransport {
+	return &sessionTransport{
+		inner:    inner,
+		loginURL: baseURL + "/auth/login",
+		username: username,
+		password: password,
+		cacheTTL: readCacheTTL,
+		cache:    make(map[string]*cachedResponse),
+	}
+}
+
+// newPooledTransport returns a transport that keeps more idle connections to qBittorrent than http.DefaultTransport.
+func newPooledTransport() *http.Transport {
+	ret := http.DefaultTransport.(*http.Transport).Clone()
+	ret.MaxIdleConns = maxIdleConns
+	ret.MaxIdleConnsPerHost = maxIdleConns
+	ret.IdleConnTimeout = 90 * time.Second
+	return ret
+}
+
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		t.invalidate()
+		return t.do(req)
+	}
+	// GET requests with a body cannot be told apart by their URL
+	if req.ContentLength != 0 {
+		return t.do(req)
+	}
+	return t.read(req)
+}
+
+// read sends a GET request, sharing the response with the identical requests in flight and caching it if it is a cached endpoint.
+func (t *sessionTransport) read(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	cacheable := t.cacheTTL > 0 && isCachedEndpoint(req.URL.Path)
+
+	t.mu.RLock()
+	generation := t.generation
+	cached, ok := t.cache[key]
+	t.mu.RUnlock()
+	if cacheable && ok && time.Now().Before(cached.expiresAt) {
+		return cached.toResponse(req), nil
+	}
+
+	// The upstream request is not cancelled with the first caller since it is shared with the others
+	ch := t.group.DoChan(fmt.Sprintf("%d:%s", generation, key), func() (interface{}, error) {
+		resp, err := t.do(req.WithContext(context.WithoutCancel(req.Context())))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		ret := &cachedResponse{
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			header:     resp.Header.Clone(),
+			body:       body,
+			expiresAt:  time.Now().Add(t.cacheTTL),
+		}
+		if cacheable && resp.StatusCode == http.StatusOK {
+			t.store(key, ret, generation)
+		}
+		return ret, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*cachedResponse).toResponse(req), nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// do sends the request with the session cookie, logging in again if the session has expired.
+func (t *sessionTransport) do(req *http.Request) (*http.Response, error) {
+	cookie := t.getCookie()
+	resp, err := t.send(req, cookie)
+	if err != nil || resp.StatusCode != http.StatusForbidden {
+		return resp, err
+	}
+
+	// The request cannot be sent again if its body cannot be read again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+
+	if err := t.relogin(req.Context(), cookie); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return t.send(retry, t.getCookie())
+}
+
+func (t *sessionTransport) send(req *http.Request, cookie *http.Cookie) (*http.Response, error) {
+	// RoundTrippers should not modify the original request
+	req = req.Clone(req.Context())
+	if cookie != nil {
+		req.Header.Del("Cookie")
+		req.AddCookie(cookie)
+	}
+	t.upstreamRequests.Add(1)
+	return t.inner.RoundTrip(req)
+}
+
+// relogin logs in again unless another request has already done it since the expired cookie was used.
+// Concurrent callers share the same login.
+func (t *sessionTransport) relogin(ctx context.Context, expired *http.Cookie) error {
+	_, err, _ := t.group.Do("login", func() (interface{}, error) {
+		if current := t.getCookie(); current != nil && current != expired {
+			return nil, nil
+		}
+		return nil, t.login(context.WithoutCancel(ctx))
+	})
+	return err
+}
+
+// login authenticates to qBittorrent and stores the session cookie.
+func (t *sessionTransport) login(ctx context.Context) error {
+	data := url.Values{}
+	data.Add("username", t.username)
+	data.Add("password", t.password)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.loginURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	t.upstreamRequests.Add(1)
+	resp, err := t.inner.RoundTrip(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	// qBittorrent bans the IP after too many failed attempts
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: invalid status %s", ErrAuthFailed, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("invalid status %s", resp.Status)
+	}
+	// qBittorrent responds with a 200 without a cookie when the credentials are wrong
+	if len(resp.Cookies()) < 1 {
+		return fmt.Errorf("%w: no cookies in login response", ErrAuthFailed)
+	}
+
+	t.mu.Lock()
+	t.cookie = resp.Cookies()[0]
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *sessionTransport) getCookie() *http.Cookie {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.cookie
+}
+
+func (t *sessionTransport) clearCookie() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cookie = nil
+}
+
+func (t *sessionTransport) store(key string, resp *cachedResponse, generation uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// A write was sent while the response was being read
+	if t.generation != generation {
+		return
+	}
+	now := time.Now()
+	for k, v := range t.cache {
+		if now.After(v.expiresAt) {
+			delete(t.cache, k)
+		}
+	}
+	t.cache[key] = resp
+}
+
+func (t *sessionTransport) invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.generation++
+	clear(t.cache)
+}
+
+func (r *cachedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        r.status,
+		StatusCode:    r.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+func isCachedEndpoint(path string) bool {
+	for _, endpoint := range cachedEndpoints {
+		if strings.HasSuffix(path, endpoint) {
+			return true
+		}
+	}
+	return false
+}
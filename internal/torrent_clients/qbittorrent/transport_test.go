@@ -0,0 +1,231 @@
+package qbittorrent
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/util"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQbittorrent is a qBittorrent Web API that only knows the endpoints used by the tests.
+type fakeQbittorrent struct {
+	server   *httptest.Server
+	sid      atomic.Int64 // The valid session ID, incremented on each login
+	logins   atomic.Int64
+	lists    atomic.Int64
+	delay    time.Duration
+	mu       sync.Mutex
+	torrents []string
+}
+
+func newFakeQbittorrent(t testing.TB) *fakeQbittorrent {
+	f := &fakeQbittorrent{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		f.logins.Add(1)
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "password" {
+			_, _ = w.Write([]byte("Fails."))
+			return
+		}
+		sid := f.sid.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: strconv.FormatInt(sid, 10)})
+		_, _ = w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("GET /api/v2/torrents/info", f.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		f.lists.Add(1)
+		time.Sleep(f.delay)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[` + strings.Join(f.torrents, ",") + `]`))
+	}))
+	mux.HandleFunc("POST /api/v2/torrents/add", f.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.torrents = append(f.torrents, `{"hash":"`+r.FormValue("urls")+`"}`)
+	}))
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeQbittorrent) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("SID")
+		if err != nil || cookie.Value != strconv.FormatInt(f.sid.Load(), 10) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// expireSession invalidates the current session like qBittorrent does after the session timeout
+func (f *fakeQbittorrent) expireSession() {
+	f.sid.Add(1)
+}
+
+func (f *fakeQbittorrent) newClient(password string) *Client {
+	return NewClient(&NewClientOptions{
+		Logger:   util.NewLogger(),
+		Host:     f.server.URL,
+		Username: "admin",
+		Password: password,
+	})
+}
+
+func TestSessionTransport_Relogin(t *testing.T) {
+	f := newFakeQbittorrent(t)
+	client := f.newClient("password")
+
+	// The first request logs in
+	_, err := client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, f.logins.Load())
+
+	// The session is reused
+	client.transport.invalidate()
+	_, err = client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, f.logins.Load())
+
+	// The session has expired, concurrent requests share one login
+	f.expireSession()
+	client.transport.invalidate()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := client.Torrent.AddURLs([]string{"a" + strconv.Itoa(i)}, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 2, f.logins.Load())
+
+	res, err := client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	assert.Len(t, res, 10)
+}
+
+func TestSessionTransport_AuthFailed(t *testing.T) {
+	f := newFakeQbittorrent(t)
+	client := f.newClient("wrong")
+
+	err := client.Login()
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	_, err = client.Torrent.GetList(nil)
+	assert.ErrorIs(t, err, ErrAuthFailed)
+
+	// Connectivity failures are not reported as authentication failures
+	f.server.Close()
+	_, err = client.Torrent.GetList(nil)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrAuthFailed))
+}
+
+func TestSessionTransport_Cache(t *testing.T) {
+	f := newFakeQbittorrent(t)
+	client := f.newClient("password")
+	client.transport.cacheTTL = time.Hour
+
+	_, err := client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	_, err = client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, f.lists.Load())
+
+	// Writes clear the cache
+	err = client.Torrent.AddURLs([]string{"a"}, nil)
+	require.NoError(t, err)
+	res, err := client.Torrent.GetList(nil)
+	require.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.EqualValues(t, 2, f.lists.Load())
+}
+
+func TestSessionTransport_Singleflight(t *testing.T) {
+	f := newFakeQbittorrent(t)
+	f.delay = 100 * time.Millisecond
+	client := f.newClient("password")
+	require.NoError(t, client.Login())
+	// Only the deduplication of the in-flight requests is tested
+	client.transport.cacheTTL = 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Torrent.GetList(nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, f.lists.Load(), int64(20))
+}
+
+// BenchmarkSessionTransport_ConcurrentList reports the number of upstream requests per call of the torrent list
+// when it is polled concurrently, e.g. by the progress watcher and the UI.
+func BenchmarkSessionTransport_ConcurrentList(b *testing.B) {
+	f := newFakeQbittorrent(b)
+	f.delay = time.Millisecond
+
+	run := func(b *testing.B, client *http.Client, count func() int64) {
+		before := count()
+		b.SetParallelism(8)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				resp, err := client.Get(f.server.URL + "/api/v2/torrents/info")
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = resp.Body.Close()
+			}
+		})
+		b.ReportMetric(float64(count()-before)/float64(b.N), "upstream/op")
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		client := f.newClient("password")
+		if err := client.Login(); err != nil {
+			b.Fatal(err)
+		}
+		run(b, client.client, client.transport.upstreamRequests.Load)
+	})
+
+	b.Run("direct", func(b *testing.B) {
+		client := f.newClient("password")
+		if err := client.Login(); err != nil {
+			b.Fatal(err)
+		}
+		// Every call is sent to qBittorrent with the session cookie
+		cookie := client.transport.getCookie()
+		var requests atomic.Int64
+		direct := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			r.AddCookie(cookie)
+			requests.Add(1)
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		run(b, direct, requests.Load)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
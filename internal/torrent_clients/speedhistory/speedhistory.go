@@ -0,0 +1,162 @@
+package speedhistory
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/torrent_clients/torrent_client"
+	"seanime/internal/util"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// sampleInterval is the time between two samples
+	sampleInterval = time.Minute
+	// DefaultCapacity is the number of samples kept, 24 hours of samples
+	DefaultCapacity = 1440
+)
+
+type (
+	// Recorder samples the aggregate download and upload speeds of the torrent client every minute.
+	// The samples are stored in a ring buffer in the database, the oldest sample is replaced once it is full.
+	// No sample is recorded while the torrent client is unreachable.
+	Recorder struct {
+		logger   *zerolog.Logger
+		database *db.Database
+		capacity int
+
+		mu     sync.Mutex
+		client torrentClient
+		// nextSlot is the slot of the next sample, -1 until it is read from the database
+		nextSlot int
+	}
+
+	NewRecorderOptions struct {
+		Logger   *zerolog.Logger
+		Database *db.Database
+	}
+
+	// Sample is the aggregate speed of the torrent client at a point in time.
+	Sample struct {
+		Timestamp           time.Time `json:"timestamp"`
+		DownloadBytesPerSec int64     `json:"downloadBytesPerSec"`
+		UploadBytesPerSec   int64     `json:"uploadBytesPerSec"`
+	}
+
+	// torrentClient is implemented by torrent_client.Repository.
+	torrentClient interface {
+		GetTransferSpeed() (download int64, upload int64, err error)
+	}
+)
+
+func New(opts *NewRecorderOptions) *Recorder {
+	return &Recorder{
+		logger:   opts.Logger,
+		database: opts.Database,
+		capacity: DefaultCapacity,
+		nextSlot: -1,
+	}
+}
+
+func (r *Recorder) SetTorrentClientRepository(repo *torrent_client.Repository) {
+	if r == nil || repo == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client = repo
+}
+
+// Start samples the speeds of the torrent client periodically in a goroutine.
+func (r *Recorder) Start() {
+	if r == nil {
+		return
+	}
+	go func() {
+		defer util.HandlePanicInModuleThen("speedhistory/Start", func() {})
+
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			r.sample(now)
+		}
+	}()
+}
+
+func (r *Recorder) sample(now time.Time) {
+	r.mu.Lock()
+	client := r.client
+	r.mu.Unlock()
+	if client == nil {
+		return
+	}
+
+	download, upload, err := client.GetTransferSpeed()
+	if err != nil {
+		return
+	}
+
+	if err := r.Record(&Sample{
+		Timestamp:           now,
+		DownloadBytesPerSec: download,
+		UploadBytesPerSec:   upload,
+	}); err != nil {
+		r.logger.Error().Err(err).Msg("speed history: Failed to record sample")
+	}
+}
+
+// Record saves the sample in the next slot of the ring buffer.
+func (r *Recorder) Record(sample *Sample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Continue after the most recent sample of the previous runs
+	if r.nextSlot < 0 {
+		r.nextSlot = 0
+		latest, err := r.database.GetTorrentSpeedSamples(1)
+		if err != nil {
+			return err
+		}
+		if len(latest) > 0 {
+			r.nextSlot = (latest[0].Slot + 1) % r.capacity
+		}
+	}
+
+	err := r.database.SaveTorrentSpeedSample(&models.TorrentSpeedSample{
+		Slot:                r.nextSlot,
+		Timestamp:           sample.Timestamp,
+		DownloadBytesPerSec: sample.DownloadBytesPerSec,
+		UploadBytesPerSec:   sample.UploadBytesPerSec,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.nextSlot = (r.nextSlot + 1) % r.capacity
+	return nil
+}
+
+// GetSamples returns the last count samples, oldest first.
+// Count is bounded by the capacity of the ring buffer.
+func (r *Recorder) GetSamples(count int) ([]*Sample, error) {
+	count = min(max(count, 1), r.capacity)
+
+	samples, err := r.database.GetTorrentSpeedSamples(count)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Sample, 0, len(samples))
+	for _, s := range samples {
+		ret = append(ret, &Sample{
+			Timestamp:           s.Timestamp,
+			DownloadBytesPerSec: s.DownloadBytesPerSec,
+			UploadBytesPerSec:   s.UploadBytesPerSec,
+		})
+	}
+	slices.Reverse(ret)
+	return ret, nil
+}
@@ -0,0 +1,100 @@
+package speedhistory
+
+import (
+	"errors"
+	"seanime/internal/database/db"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	download, upload int64
+	err              error
+}
+
+func (f *fakeClient) GetTransferSpeed() (int64, int64, error) {
+	return f.download, f.upload, f.err
+}
+
+func newTestRecorder(database *db.Database, capacity int) *Recorder {
+	r := New(&NewRecorderOptions{
+		Logger:   util.NewLogger(),
+		Database: database,
+	})
+	r.capacity = capacity
+	return r
+}
+
+func TestRecorder_RingBuffer(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "speedhistory_test", util.NewLogger())
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+	r := newTestRecorder(database, 3)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Record(&Sample{
+			Timestamp:           start.Add(time.Duration(i) * time.Minute),
+			DownloadBytesPerSec: int64(i * 1000),
+			UploadBytesPerSec:   int64(i * 100),
+		}))
+	}
+
+	// The oldest samples were replaced
+	samples, err := r.GetSamples(10)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for i, s := range samples {
+		assert.True(t, start.Add(time.Duration(i+2)*time.Minute).Equal(s.Timestamp))
+		assert.Equal(t, int64((i+2)*1000), s.DownloadBytesPerSec)
+		assert.Equal(t, int64((i+2)*100), s.UploadBytesPerSec)
+	}
+
+	samples, err = r.GetSamples(2)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, int64(3000), samples[0].DownloadBytesPerSec)
+	assert.Equal(t, int64(4000), samples[1].DownloadBytesPerSec)
+
+	// A new recorder continues after the most recent sample
+	r = newTestRecorder(database, 3)
+	require.NoError(t, r.Record(&Sample{Timestamp: start.Add(5 * time.Minute), DownloadBytesPerSec: 5000}))
+
+	samples, err = r.GetSamples(3)
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, []int64{3000, 4000, 5000}, []int64{samples[0].DownloadBytesPerSec, samples[1].DownloadBytesPerSec, samples[2].DownloadBytesPerSec})
+}
+
+func TestRecorder_Sample(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "speedhistory_test", util.NewLogger())
+	require.NoError(t, err)
+
+	r := newTestRecorder(database, DefaultCapacity)
+	now := time.Date(2024, 1, 5, 15, 0, 0, 0, time.UTC)
+
+	// No client
+	r.sample(now)
+
+	// Unreachable client
+	client := &fakeClient{err: errors.New("connection refused")}
+	r.client = client
+	r.sample(now.Add(time.Minute))
+
+	samples, err := r.GetSamples(DefaultCapacity)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	client.err = nil
+	client.download, client.upload = 2_000_000, 50_000
+	r.sample(now.Add(2 * time.Minute))
+
+	samples, err = r.GetSamples(DefaultCapacity)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, int64(2_000_000), samples[0].DownloadBytesPerSec)
+	assert.Equal(t, int64(50_000), samples[0].UploadBytesPerSec)
+}
@@ -0,0 +1,32 @@
+package torrent_client
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// forbiddenCustomHeaders are headers that cannot be overridden because they are managed by the HTTP client or the torrent client session.
+var forbiddenCustomHeaders = []string{
+	"Cookie",
+	"Host",
+}
+
+// ValidateCustomHeaders checks that the custom headers sent to the torrent client are valid.
+func ValidateCustomHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("torrent client: Invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("torrent client: Invalid value for header %q", name)
+		}
+		for _, forbidden := range forbiddenCustomHeaders {
+			if http.CanonicalHeaderKey(name) == forbidden {
+				return fmt.Errorf("torrent client: Header %q cannot be set", name)
+			}
+		}
+	}
+	return nil
+}
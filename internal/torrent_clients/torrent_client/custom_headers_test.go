@@ -0,0 +1,33 @@
+package torrent_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCustomHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{name: "empty", headers: nil},
+		{name: "authorization", headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "X-Auth-Token": "token"}},
+		{name: "cookie", headers: map[string]string{"Cookie": "SID=1"}, wantErr: true},
+		{name: "lowercase host", headers: map[string]string{"host": "example.com"}, wantErr: true},
+		{name: "invalid name", headers: map[string]string{"X Auth": "token"}, wantErr: true},
+		{name: "invalid value", headers: map[string]string{"X-Auth-Token": "token\r\nX-Other: 1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomHeaders(tt.headers)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
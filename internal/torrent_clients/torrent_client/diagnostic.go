@@ -0,0 +1,111 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"seanime/internal/torrent_clients/qbittorrent"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+type TorrentClientDiagnosticType string
+
+const (
+	DiagnosticConnectionRefused TorrentClientDiagnosticType = "connection_refused"
+	DiagnosticAuthFailed        TorrentClientDiagnosticType = "auth_failed"
+	DiagnosticNotConfigured     TorrentClientDiagnosticType = "not_configured"
+	DiagnosticTimeout           TorrentClientDiagnosticType = "timeout"
+	// DiagnosticUnknown is used when the client responded with an unexpected error
+	DiagnosticUnknown TorrentClientDiagnosticType = "unknown"
+)
+
+// diagnoseTimeout is the maximum time spent contacting the torrent client
+const diagnoseTimeout = 10 * time.Second
+
+// TorrentClientDiagnostic explains why the torrent client could not be started or contacted.
+type TorrentClientDiagnostic struct {
+	Type   TorrentClientDiagnosticType `json:"type"`
+	Detail string                      `json:"detail"`
+}
+
+// DiagnoseStartFailure contacts the torrent client to find out why Start failed.
+func (r *Repository) DiagnoseStartFailure() TorrentClientDiagnostic {
+	switch r.provider {
+	case QbittorrentClient:
+		if r.qBittorrentClient == nil || r.qBittorrentClient.Host == "" {
+			return TorrentClientDiagnostic{Type: DiagnosticNotConfigured, Detail: "qBittorrent is not configured, set its host and port in the settings"}
+		}
+		err := runWithTimeout(func(ctx context.Context) error {
+			if err := r.qBittorrentClient.Login(); err != nil {
+				return err
+			}
+			_, err := r.qBittorrentClient.Application.GetAppVersion()
+			return err
+		})
+		return diagnoseError("qBittorrent", err)
+
+	case TransmissionClient:
+		if r.transmission == nil || r.transmission.Client == nil {
+			return TorrentClientDiagnostic{Type: DiagnosticNotConfigured, Detail: "Transmission is not configured, set its host and port in the settings"}
+		}
+		err := runWithTimeout(func(ctx context.Context) error {
+			_, _, _, err := r.transmission.Client.RPCVersion(ctx)
+			return err
+		})
+		return diagnoseError("Transmission", err)
+	}
+
+	return TorrentClientDiagnostic{Type: DiagnosticNotConfigured, Detail: "No torrent client is selected in the settings"}
+}
+
+// runWithTimeout returns an error wrapping context.DeadlineExceeded if f does not return within diagnoseTimeout.
+// f is left running in the background if it does not support cancellation.
+func runWithTimeout(f func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), diagnoseTimeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// diagnoseError classifies the error returned by the torrent client.
+func diagnoseError(clientName string, err error) TorrentClientDiagnostic {
+	if err == nil {
+		// The client responded, it was probably still starting
+		return TorrentClientDiagnostic{Type: DiagnosticTimeout, Detail: fmt.Sprintf("%s took too long to start, try again", clientName)}
+	}
+
+	var netErr net.Error
+	var statusCode transmissionrpc.HTTPStatusCode
+	msg := err.Error()
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(msg, "connection refused"):
+		return TorrentClientDiagnostic{Type: DiagnosticConnectionRefused, Detail: fmt.Sprintf("%s refused the connection, make sure it is running and that its Web UI is enabled on the configured port", clientName)}
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return TorrentClientDiagnostic{Type: DiagnosticTimeout, Detail: fmt.Sprintf("%s did not respond in time, check the host and port in the settings", clientName)}
+	case errors.Is(err, qbittorrent.ErrAuthFailed),
+		errors.As(err, &statusCode) && (statusCode == 401 || statusCode == 403),
+		strings.Contains(msg, "401"), strings.Contains(msg, "403"),
+		// qBittorrent responds with a 200 without a cookie when the credentials are wrong
+		strings.Contains(msg, "no cookies in login response"):
+		return TorrentClientDiagnostic{Type: DiagnosticAuthFailed, Detail: fmt.Sprintf("Authentication failed, check your %s username and password in the settings", clientName)}
+	}
+
+	return TorrentClientDiagnostic{Type: DiagnosticUnknown, Detail: fmt.Sprintf("%s could not be contacted: %s", clientName, msg)}
+}
@@ -0,0 +1,51 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"seanime/internal/torrent_clients/qbittorrent"
+	"syscall"
+	"testing"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnoseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected TorrentClientDiagnosticType
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, expected: DiagnosticConnectionRefused},
+		{name: "deadline exceeded", err: fmt.Errorf("rpc: %w", context.DeadlineExceeded), expected: DiagnosticTimeout},
+		{name: "qbittorrent wrong credentials", err: errors.New("no cookies in login response"), expected: DiagnosticAuthFailed},
+		{name: "qbittorrent forbidden", err: errors.New("invalid response status 403 Forbidden"), expected: DiagnosticAuthFailed},
+		{name: "qbittorrent session rejected", err: &url.Error{Op: "Get", URL: "http://localhost/api/v2/torrents/info", Err: fmt.Errorf("%w: invalid status 403 Forbidden", qbittorrent.ErrAuthFailed)}, expected: DiagnosticAuthFailed},
+		{name: "qbittorrent unreachable", err: &url.Error{Op: "Get", URL: "http://localhost/api/v2/torrents/info", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}, expected: DiagnosticConnectionRefused},
+		{name: "transmission unauthorized", err: transmissionrpc.HTTPStatusCode(401), expected: DiagnosticAuthFailed},
+		{name: "unknown", err: errors.New("unexpected EOF"), expected: DiagnosticUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret := diagnoseError("qBittorrent", tt.err)
+			assert.Equal(t, tt.expected, ret.Type)
+			assert.NotEmpty(t, ret.Detail)
+		})
+	}
+}
+
+func TestDiagnoseStartFailure_NotConfigured(t *testing.T) {
+	r := &Repository{provider: "none"}
+	assert.Equal(t, DiagnosticNotConfigured, r.DiagnoseStartFailure().Type)
+
+	r = &Repository{provider: QbittorrentClient}
+	assert.Equal(t, DiagnosticNotConfigured, r.DiagnoseStartFailure().Type)
+
+	r = &Repository{provider: TransmissionClient}
+	assert.Equal(t, DiagnosticNotConfigured, r.DiagnoseStartFailure().Type)
+}
@@ -0,0 +1,113 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+	qbittorrent_model "seanime/internal/torrent_clients/qbittorrent/model"
+	"strconv"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+var ErrTorrentNotFound = errors.New("torrent client: torrent not found")
+
+// TorrentFile is a file of a torrent.
+type TorrentFile struct {
+	Index int `json:"index"`
+	// Name is the path of the file in the torrent
+	Name string `json:"name"`
+	// Selected is false if the file was deselected and is not downloaded
+	Selected bool `json:"selected"`
+}
+
+// GetTorrentFiles returns the files of a torrent and whether they are selected.
+// Unlike GetFiles, it does not wait for the metadata of the torrent, the list is empty if it is not available yet.
+func (r *Repository) GetTorrentFiles(hash string) ([]*TorrentFile, error) {
+	if !r.TorrentExists(hash) {
+		return nil, ErrTorrentNotFound
+	}
+
+	ret := make([]*TorrentFile, 0)
+	switch r.provider {
+	case QbittorrentClient:
+		files, err := r.qBittorrentClient.Torrent.GetContents(hash)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range files {
+			ret = append(ret, &TorrentFile{
+				Index:    i,
+				Name:     f.Name,
+				Selected: f.Priority != qbittorrent_model.PriorityDoNotDownload,
+			})
+		}
+	case TransmissionClient:
+		torrents, err := r.transmission.Client.TorrentGetAllForHashes(context.Background(), []string{hash})
+		if err != nil {
+			return nil, err
+		}
+		if len(torrents) == 0 {
+			return nil, ErrTorrentNotFound
+		}
+		for i, f := range torrents[0].Files {
+			selected := true
+			if i < len(torrents[0].FileStats) {
+				selected = torrents[0].FileStats[i].Wanted
+			}
+			ret = append(ret, &TorrentFile{
+				Index:    i,
+				Name:     f.Name,
+				Selected: selected,
+			})
+		}
+	default:
+		return nil, errors.New("torrent client: No torrent client selected")
+	}
+
+	return ret, nil
+}
+
+// SelectFiles selects files of a torrent so that they are downloaded. It is the opposite of DeselectFiles.
+func (r *Repository) SelectFiles(hash string, indices []int) error {
+	if len(indices) == 0 {
+		return nil
+	}
+
+	var err error
+	switch r.provider {
+	case QbittorrentClient:
+		strIndices := make([]string, len(indices))
+		for i, v := range indices {
+			strIndices[i] = strconv.Itoa(v)
+		}
+		err = r.qBittorrentClient.Torrent.SetFilePriorities(hash, strIndices, qbittorrent_model.PriorityNormal)
+	case TransmissionClient:
+		var torrents []transmissionrpc.Torrent
+		torrents, err = r.transmission.Client.TorrentGetAllForHashes(context.Background(), []string{hash})
+		if err != nil {
+			break
+		}
+		if len(torrents) == 0 || torrents[0].ID == nil {
+			return ErrTorrentNotFound
+		}
+		ind := make([]int64, len(indices))
+		for i, v := range indices {
+			ind[i] = int64(v)
+		}
+		err = r.transmission.Client.TorrentSet(context.Background(), transmissionrpc.TorrentSetPayload{
+			FilesWanted: ind,
+			IDs:         []int64{*torrents[0].ID},
+		})
+	default:
+		return errors.New("torrent client: No torrent client selected")
+	}
+
+	if err != nil {
+		r.logger.Err(err).Msg("torrent client: Error while selecting files")
+		return err
+	}
+
+	r.logger.Debug().Str("hash", hash).Any("indices", indices).Msg("torrent client: Selected torrent files")
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"seanime/internal/library/scanner"
+	"seanime/internal/torrent_clients/network_binding"
+)
+
+type (
+	// IncompleteDirInfo describes the directory the torrent client keeps incomplete downloads in.
+	IncompleteDirInfo struct {
+		Provider string `json:"provider"`
+		// DetectedPath is the path read from the torrent client's preferences, empty if it is disabled or could not be read
+		DetectedPath string `json:"detectedPath"`
+		// OverridePath is the path set in the settings
+		OverridePath string `json:"overridePath"`
+		// Path is the path that is excluded from scans, the override takes precedence
+		Path                  string   `json:"path"`
+		DetectionError        string   `json:"detectionError,omitempty"`
+		PartialFileExtensions []string `json:"partialFileExtensions"`
+	}
+
+	// Status describes the torrent client and what is excluded from scans because of it.
+	Status struct {
+		Provider      string             `json:"provider"`
+		IncompleteDir *IncompleteDirInfo `json:"incompleteDir"`
+		ExcludedPaths []string           `json:"excludedPaths"`
+		// NetworkBinding is set by the handler
+		NetworkBinding *network_binding.Status `json:"networkBinding"`
+	}
+)
+
+// GetIncompleteDir reads the directory for incomplete downloads from the torrent client's preferences.
+// It returns an empty string if the client does not use one.
+func (r *Repository) GetIncompleteDir() (string, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		prefs, err := r.qBittorrentClient.Application.GetAppPreferences()
+		if err != nil {
+			return "", err
+		}
+		if !prefs.TempPathEnabled || prefs.TempPath == "" {
+			return "", nil
+		}
+		return filepath.ToSlash(filepath.Clean(prefs.TempPath)), nil
+	case TransmissionClient:
+		args, err := r.transmission.Client.SessionArgumentsGet(context.Background(), []string{"incomplete-dir-enabled", "incomplete-dir"})
+		if err != nil {
+			return "", err
+		}
+		if args.IncompleteDirEnabled == nil || !*args.IncompleteDirEnabled || args.IncompleteDir == nil || *args.IncompleteDir == "" {
+			return "", nil
+		}
+		return filepath.ToSlash(filepath.Clean(*args.IncompleteDir)), nil
+	default:
+		return "", errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// GetIncompleteDirInfo returns the directory for incomplete downloads, taking the override into account.
+// The last detected path is kept so that it is still excluded when the client is not reachable.
+func (r *Repository) GetIncompleteDirInfo() *IncompleteDirInfo {
+	ret := &IncompleteDirInfo{
+		Provider:              r.provider,
+		OverridePath:          r.incompleteDirOverride,
+		PartialFileExtensions: scanner.PartialFileExtensions,
+	}
+
+	detected, err := r.GetIncompleteDir()
+	r.incompleteDirMu.Lock()
+	if err != nil {
+		ret.DetectionError = err.Error()
+		detected = r.lastDetectedIncompleteDir
+	} else {
+		r.lastDetectedIncompleteDir = detected
+	}
+	r.incompleteDirMu.Unlock()
+
+	ret.DetectedPath = detected
+	ret.Path = detected
+	if r.incompleteDirOverride != "" {
+		ret.Path = filepath.ToSlash(filepath.Clean(r.incompleteDirOverride))
+	}
+
+	return ret
+}
+
+// GetExcludedPaths returns the paths that should not be scanned or watched.
+func (r *Repository) GetExcludedPaths() []string {
+	if r == nil {
+		return nil
+	}
+	return getExcludedPaths(r.GetIncompleteDirInfo())
+}
+
+// GetStatus returns the torrent client status including the excluded paths.
+func (r *Repository) GetStatus() *Status {
+	info := r.GetIncompleteDirInfo()
+	return &Status{
+		Provider:      r.provider,
+		IncompleteDir: info,
+		ExcludedPaths: getExcludedPaths(info),
+	}
+}
+
+func getExcludedPaths(info *IncompleteDirInfo) []string {
+	if info.Path == "" {
+		return []string{}
+	}
+	return []string{info.Path}
+}
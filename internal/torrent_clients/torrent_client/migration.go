@@ -0,0 +1,296 @@
+package torrent_client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"seanime/internal/torrent_clients/qbittorrent/model"
+	"strings"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+// MigrationTorrent is a torrent exported from a torrent client to be added to another one.
+type MigrationTorrent struct {
+	Hash   string `json:"hash"`
+	Name   string `json:"name"`
+	Magnet string `json:"-"`
+	// TorrentFile is the content of the .torrent file, it is empty if the client could not export it
+	TorrentFile []byte `json:"-"`
+	// SavePath is the directory of the torrent as seen by the torrent client
+	SavePath string `json:"savePath"`
+	// Category is the qBittorrent category of the torrent
+	Category string `json:"category"`
+	// Tags are the qBittorrent tags or the Transmission labels of the torrent
+	Tags []string `json:"tags"`
+	// Verified is true if the client has all the wanted data of the torrent and no error
+	Verified bool `json:"verified"`
+	// FileCount is 0 when the metadata of the torrent is not known yet
+	FileCount int `json:"fileCount"`
+	// UnwantedFiles are the indices of the files that are not downloaded
+	UnwantedFiles []int `json:"unwantedFiles"`
+}
+
+// ListMigrationTorrents returns all the torrents of the client with what is needed to add them to another client.
+func (r *Repository) ListMigrationTorrents() ([]*MigrationTorrent, error) {
+	ret := make([]*MigrationTorrent, 0)
+	switch r.provider {
+	case QbittorrentClient:
+		torrents, err := r.qBittorrentClient.Torrent.GetList(&qbittorrent_model.GetTorrentListOptions{Filter: "all"})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range torrents {
+			ret = append(ret, r.fromQbitMigrationTorrent(t, true))
+		}
+	case TransmissionClient:
+		torrents, err := r.transmission.Client.TorrentGetAll(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range torrents {
+			ret = append(ret, r.fromTransmissionMigrationTorrent(&t, true))
+		}
+	default:
+		return nil, errors.New("torrent client: No torrent client selected")
+	}
+	return ret, nil
+}
+
+// GetMigrationTorrent returns the torrent without its .torrent file, or nil if it is not in the client.
+func (r *Repository) GetMigrationTorrent(hash string) (*MigrationTorrent, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		torrents, err := r.qBittorrentClient.Torrent.GetList(&qbittorrent_model.GetTorrentListOptions{Filter: "all", Hashes: hash})
+		if err != nil {
+			return nil, err
+		}
+		if len(torrents) == 0 {
+			return nil, nil
+		}
+		return r.fromQbitMigrationTorrent(torrents[0], false), nil
+	case TransmissionClient:
+		torrents, err := r.transmission.Client.TorrentGetAllForHashes(context.Background(), []string{hash})
+		if err != nil {
+			return nil, err
+		}
+		if len(torrents) == 0 {
+			return nil, nil
+		}
+		return r.fromTransmissionMigrationTorrent(&torrents[0], false), nil
+	default:
+		return nil, errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// AddMigrationTorrent adds a torrent exported from another client with the same files selected.
+// The hash check is only skipped if the data was verified by the other client. Transmission always checks the data it finds.
+// savePath is the directory of the torrent as seen by this client.
+func (r *Repository) AddMigrationTorrent(t *MigrationTorrent, savePath string) error {
+	if t.Magnet == "" && len(t.TorrentFile) == 0 {
+		return errors.New("torrent client: The torrent has no magnet link or torrent file")
+	}
+
+	// The files of a magnet link are only known once the client has fetched the metadata
+	deselectLater := len(t.UnwantedFiles) > 0
+
+	var err error
+	switch r.provider {
+	case QbittorrentClient:
+		opts := &qbittorrent_model.AddTorrentsOptions{
+			Savepath:     savePath,
+			Category:     t.Category,
+			SkipChecking: t.Verified,
+			Tags:         joinTags(t.Tags, r.qBittorrentClient.Tags),
+		}
+		if len(t.TorrentFile) > 0 {
+			err = r.qBittorrentClient.Torrent.AddFiles(map[string][]byte{t.Hash + ".torrent": t.TorrentFile}, opts)
+		} else {
+			err = r.qBittorrentClient.Torrent.AddURLs([]string{t.Magnet}, opts)
+		}
+	case TransmissionClient:
+		payload := transmissionrpc.TorrentAddPayload{
+			DownloadDir: &savePath,
+		}
+		labels := t.Tags
+		if t.Category != "" {
+			labels = append([]string{t.Category}, labels...)
+		}
+		if len(labels) > 0 {
+			payload.Labels = labels
+		}
+		if len(t.TorrentFile) > 0 {
+			metaInfo := base64.StdEncoding.EncodeToString(t.TorrentFile)
+			payload.MetaInfo = &metaInfo
+			if len(t.UnwantedFiles) > 0 {
+				payload.FilesUnwanted = make([]int64, len(t.UnwantedFiles))
+				for i, idx := range t.UnwantedFiles {
+					payload.FilesUnwanted[i] = int64(idx)
+				}
+			}
+			deselectLater = false
+		} else {
+			magnet := t.Magnet
+			payload.Filename = &magnet
+		}
+		_, err = r.transmission.Client.TorrentAdd(context.Background(), payload)
+	default:
+		return errors.New("torrent client: No torrent client selected")
+	}
+	if err != nil {
+		r.logger.Err(err).Str("hash", t.Hash).Msg("torrent client: Error while adding migrated torrent")
+		return err
+	}
+
+	if deselectLater {
+		if _, err := r.GetFiles(t.Hash); err != nil {
+			return err
+		}
+		if err := r.DeselectFiles(t.Hash, t.UnwantedFiles); err != nil {
+			return err
+		}
+	}
+
+	r.logger.Debug().Str("hash", t.Hash).Str("savePath", savePath).Msg("torrent client: Added migrated torrent")
+	return nil
+}
+
+// RemoveTorrentsKeepData removes the torrents from the client without deleting their files.
+func (r *Repository) RemoveTorrentsKeepData(hashes []string) error {
+	var err error
+	switch r.provider {
+	case QbittorrentClient:
+		err = r.qBittorrentClient.Torrent.DeleteTorrents(hashes, false)
+	case TransmissionClient:
+		var torrents []transmissionrpc.Torrent
+		torrents, err = r.transmission.Client.TorrentGetAllForHashes(context.Background(), hashes)
+		if err != nil {
+			break
+		}
+		ids := make([]int64, 0, len(torrents))
+		for _, t := range torrents {
+			if t.ID != nil {
+				ids = append(ids, *t.ID)
+			}
+		}
+		err = r.transmission.Client.TorrentRemove(context.Background(), transmissionrpc.TorrentRemovePayload{
+			IDs:             ids,
+			DeleteLocalData: false,
+		})
+	default:
+		err = errors.New("torrent client: No torrent client selected")
+	}
+	if err != nil {
+		r.logger.Err(err).Msg("torrent client: Error while removing torrents")
+		return err
+	}
+
+	r.logger.Debug().Any("hashes", hashes).Msg("torrent client: Removed torrents, kept their data")
+	return nil
+}
+
+func (r *Repository) fromQbitMigrationTorrent(t *qbittorrent_model.Torrent, withFile bool) *MigrationTorrent {
+	ret := &MigrationTorrent{
+		Hash:          t.Hash,
+		Name:          t.Name,
+		Magnet:        t.MagnetUri,
+		SavePath:      t.SavePath,
+		Category:      t.Category,
+		Tags:          splitTags(t.Tags),
+		UnwantedFiles: make([]int, 0),
+	}
+
+	switch t.State {
+	case qbittorrent_model.StateError, qbittorrent_model.StateMissingFiles, qbittorrent_model.StateCheckingUP,
+		qbittorrent_model.StateCheckingDL, qbittorrent_model.StateCheckingResumeData, qbittorrent_model.StateMoving:
+	default:
+		ret.Verified = t.Progress >= 1
+	}
+
+	files, err := r.qBittorrentClient.Torrent.GetContents(t.Hash)
+	if err != nil {
+		r.logger.Debug().Err(err).Str("hash", t.Hash).Msg("torrent client: Could not get the files of the torrent")
+	}
+	ret.FileCount = len(files)
+	for i, f := range files {
+		if f.Priority == qbittorrent_model.PriorityDoNotDownload {
+			ret.UnwantedFiles = append(ret.UnwantedFiles, i)
+		}
+	}
+
+	if withFile {
+		// Requires qBittorrent v4.5.0 or later, the magnet link is used otherwise
+		ret.TorrentFile, err = r.qBittorrentClient.Torrent.ExportTorrent(t.Hash)
+		if err != nil {
+			r.logger.Debug().Err(err).Str("hash", t.Hash).Msg("torrent client: Could not export the torrent file")
+		}
+	}
+
+	return ret
+}
+
+func (r *Repository) fromTransmissionMigrationTorrent(t *transmissionrpc.Torrent, withFile bool) *MigrationTorrent {
+	ret := &MigrationTorrent{
+		Tags:          make([]string, 0, len(t.Labels)),
+		UnwantedFiles: make([]int, 0),
+		FileCount:     len(t.Files),
+	}
+	ret.Tags = append(ret.Tags, t.Labels...)
+	if t.HashString != nil {
+		ret.Hash = *t.HashString
+	}
+	if t.Name != nil {
+		ret.Name = *t.Name
+	}
+	if t.MagnetLink != nil {
+		ret.Magnet = *t.MagnetLink
+	}
+	if t.DownloadDir != nil {
+		ret.SavePath = *t.DownloadDir
+	}
+	for i, wanted := range t.Wanted {
+		if !wanted {
+			ret.UnwantedFiles = append(ret.UnwantedFiles, i)
+		}
+	}
+
+	hasError := t.Error != nil && *t.Error != 0
+	isChecking := t.Status != nil && (*t.Status == transmissionrpc.TorrentStatusCheck || *t.Status == transmissionrpc.TorrentStatusCheckWait)
+	ret.Verified = !hasError && !isChecking && t.PercentDone != nil && *t.PercentDone >= 1
+
+	// The .torrent file is kept by Transmission, it can only be read if it is in a directory the server can access
+	if withFile && t.TorrentFile != nil && *t.TorrentFile != "" {
+		b, err := os.ReadFile(r.PathTranslator().ToServer(*t.TorrentFile))
+		if err != nil {
+			r.logger.Debug().Err(err).Str("hash", ret.Hash).Msg("torrent client: Could not read the torrent file")
+		} else {
+			ret.TorrentFile = b
+		}
+	}
+
+	return ret
+}
+
+func splitTags(s string) []string {
+	ret := make([]string, 0)
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			ret = append(ret, tag)
+		}
+	}
+	return ret
+}
+
+// joinTags returns the tags of the torrent and the tags of the client, without duplicates and split by ','.
+func joinTags(tags []string, clientTags string) string {
+	ret := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, tags...), splitTags(clientTags)...) {
+		if !seen[tag] {
+			seen[tag] = true
+			ret = append(ret, tag)
+		}
+	}
+	return strings.Join(ret, ",")
+}
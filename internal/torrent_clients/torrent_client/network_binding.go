@@ -0,0 +1,76 @@
+package torrent_client
+
+import (
+	"errors"
+)
+
+var ErrNetworkBindingNotSupported = errors.New("torrent client: The network interface cannot be configured through the torrent client's API")
+
+// NetworkBinding is the network interface and address the torrent client sends its traffic through.
+type NetworkBinding struct {
+	// Interface is empty if the client uses any interface
+	Interface string `json:"interface"`
+	// Address is empty if the client uses all the addresses of the interface
+	Address string `json:"address"`
+}
+
+// GetNetworkBinding reads the network interface the torrent client is bound to from its preferences.
+// It returns [ErrNetworkBindingNotSupported] if the client does not expose it.
+func (r *Repository) GetNetworkBinding() (*NetworkBinding, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		prefs, err := r.qBittorrentClient.Application.GetAppPreferences()
+		if err != nil {
+			return nil, err
+		}
+		return &NetworkBinding{
+			Interface: prefs.CurrentNetworkInterface,
+			Address:   prefs.CurrentInterfaceAddress,
+		}, nil
+	case TransmissionClient:
+		// The bind addresses are only read from Transmission's settings.json
+		return nil, ErrNetworkBindingNotSupported
+	default:
+		return nil, errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// SetNetworkBinding binds the torrent client to the network interface and address through its preferences.
+// Empty values let the client use any interface or address.
+// It returns [ErrNetworkBindingNotSupported] if the client does not expose it.
+func (r *Repository) SetNetworkBinding(binding *NetworkBinding) error {
+	r.logger.Debug().Str("interface", binding.Interface).Str("address", binding.Address).Msg("torrent client: Setting network binding")
+
+	switch r.provider {
+	case QbittorrentClient:
+		return r.qBittorrentClient.Application.SetPreferences(map[string]interface{}{
+			"current_network_interface": binding.Interface,
+			"current_interface_address": binding.Address,
+		})
+	case TransmissionClient:
+		return ErrNetworkBindingNotSupported
+	default:
+		return errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// PauseActiveTorrents pauses the torrents that are downloading or seeding and returns their hashes.
+func (r *Repository) PauseActiveTorrents() ([]string, error) {
+	torrents, err := r.GetList()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0)
+	for _, t := range torrents {
+		if t.Status == TorrentStatusDownloading || t.Status == TorrentStatusSeeding {
+			hashes = append(hashes, t.Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return hashes, nil
+	}
+	if err := r.PauseTorrents(hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
@@ -0,0 +1,64 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+	"seanime/internal/torrent_clients/clientpath"
+)
+
+// PathTranslator returns the translator between the paths of the torrent client and the paths of the server.
+// If no path style is set, it is detected from the default download directory of the client.
+func (r *Repository) PathTranslator() *clientpath.Translator {
+	return clientpath.NewTranslator(r.GetPathStyle(), clientpath.ServerStyle(), r.pathMappings)
+}
+
+// GetPathStyle returns the path style of the torrent client.
+// The detected style is kept, the style of the server is returned if it could not be detected yet.
+func (r *Repository) GetPathStyle() clientpath.Style {
+	if r.pathStyle != clientpath.StyleAuto {
+		return r.pathStyle
+	}
+
+	r.pathStyleMu.Lock()
+	defer r.pathStyleMu.Unlock()
+
+	if r.detectedPathStyle == clientpath.StyleAuto {
+		downloadDir, err := r.GetDefaultDownloadDir()
+		if err != nil {
+			r.logger.Debug().Err(err).Msg("torrent client: Could not detect the path style")
+			return clientpath.ServerStyle()
+		}
+		r.detectedPathStyle = clientpath.DetectStyle(downloadDir)
+		if r.detectedPathStyle == clientpath.StyleAuto {
+			r.detectedPathStyle = clientpath.ServerStyle()
+		}
+		r.logger.Debug().Str("style", string(r.detectedPathStyle)).Msg("torrent client: Detected path style")
+	}
+	return r.detectedPathStyle
+}
+
+// GetDefaultDownloadDir reads the default download directory from the torrent client's preferences.
+func (r *Repository) GetDefaultDownloadDir() (string, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		prefs, err := r.qBittorrentClient.Application.GetAppPreferences()
+		if err != nil {
+			return "", err
+		}
+		return prefs.SavePath, nil
+	case TransmissionClient:
+		if r.transmission == nil {
+			return "", errors.New("torrent client: Transmission is not initialized")
+		}
+		args, err := r.transmission.Client.SessionArgumentsGet(context.Background(), []string{"download-dir"})
+		if err != nil {
+			return "", err
+		}
+		if args.DownloadDir == nil {
+			return "", nil
+		}
+		return *args.DownloadDir, nil
+	default:
+		return "", errors.New("torrent client: No torrent client selected")
+	}
+}
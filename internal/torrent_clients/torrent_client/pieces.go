@@ -0,0 +1,73 @@
+package torrent_client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	qbittorrent_model "seanime/internal/torrent_clients/qbittorrent/model"
+)
+
+const (
+	PieceStateNotDownloaded byte = 0
+	PieceStateDownloading   byte = 1
+	PieceStateDownloaded    byte = 2
+)
+
+// GetTorrentPieceStates returns the state of each piece of the torrent.
+// Each byte is either PieceStateNotDownloaded, PieceStateDownloading or PieceStateDownloaded.
+// Transmission does not report pieces that are being downloaded.
+func (r *Repository) GetTorrentPieceStates(hash string) ([]byte, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		states, err := r.qBittorrentClient.Torrent.GetPieceStates(hash)
+		if err != nil {
+			r.logger.Err(err).Str("hash", hash).Msg("torrent client: Error while getting piece states (qBittorrent)")
+			return nil, err
+		}
+		return fromQbitPieceStates(states), nil
+	case TransmissionClient:
+		torrents, err := r.transmission.Client.TorrentGetHashes(context.Background(), []string{"pieces", "pieceCount"}, []string{hash})
+		if err != nil {
+			r.logger.Err(err).Str("hash", hash).Msg("torrent client: Error while getting piece states (Transmission)")
+			return nil, err
+		}
+		if len(torrents) == 0 || torrents[0].Pieces == nil || torrents[0].PieceCount == nil {
+			return nil, errors.New("torrent client: Torrent not found")
+		}
+		return fromTransmissionPieces(*torrents[0].Pieces, int(*torrents[0].PieceCount))
+	}
+
+	return nil, errors.New("torrent client: No torrent client selected")
+}
+
+func fromQbitPieceStates(states []qbittorrent_model.TorrentPieceState) []byte {
+	ret := make([]byte, len(states))
+	for i, s := range states {
+		switch s {
+		case qbittorrent_model.PieceStateDownloading:
+			ret[i] = PieceStateDownloading
+		case qbittorrent_model.PieceStateDownloaded:
+			ret[i] = PieceStateDownloaded
+		default:
+			ret[i] = PieceStateNotDownloaded
+		}
+	}
+	return ret
+}
+
+// fromTransmissionPieces converts the base64-encoded bitfield returned by Transmission.
+// The first piece is the most significant bit of the first byte.
+func fromTransmissionPieces(pieces string, pieceCount int) ([]byte, error) {
+	bitfield, err := base64.StdEncoding.DecodeString(pieces)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, pieceCount)
+	for i := 0; i < pieceCount && i/8 < len(bitfield); i++ {
+		if bitfield[i/8]&(0x80>>(i%8)) != 0 {
+			ret[i] = PieceStateDownloaded
+		}
+	}
+	return ret, nil
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"seanime/internal/api/metadata_provider"
 	"seanime/internal/events"
+	"seanime/internal/torrent_clients/clientpath"
 	"seanime/internal/torrent_clients/qbittorrent"
 	"seanime/internal/torrent_clients/qbittorrent/model"
 	"seanime/internal/torrent_clients/transmission"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/util"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hekmon/transmissionrpc/v3"
@@ -33,6 +35,14 @@ type (
 		metadataProviderRef         *util.Ref[metadata_provider.Provider]
 		activeTorrentCountCtxCancel context.CancelFunc
 		activeTorrentCount          *ActiveCount
+		incompleteDirOverride       string
+		lastDetectedIncompleteDir   string
+		incompleteDirMu             sync.Mutex
+		isPausedFunc                func() bool
+		pathStyle                   clientpath.Style
+		pathMappings                []clientpath.Mapping
+		detectedPathStyle           clientpath.Style
+		pathStyleMu                 sync.Mutex
 	}
 
 	NewRepositoryOptions struct {
@@ -42,6 +52,14 @@ type (
 		TorrentRepository   *torrent.Repository
 		Provider            string
 		MetadataProviderRef *util.Ref[metadata_provider.Provider]
+		// IncompleteDirOverride is used instead of the incomplete downloads directory reported by the client
+		IncompleteDirOverride string
+		// IsPausedFunc returns true if the active torrent count should not be polled (maintenance mode)
+		IsPausedFunc func() bool
+		// PathStyle is the path style of the selected client, it is detected from the client when empty
+		PathStyle clientpath.Style
+		// PathMappings map the directories as seen by the selected client to the directories as seen by the server
+		PathMappings []clientpath.Mapping
 	}
 
 	ActiveCount struct {
@@ -56,13 +74,17 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		opts.Provider = QbittorrentClient
 	}
 	return &Repository{
-		logger:              opts.Logger,
-		qBittorrentClient:   opts.QbittorrentClient,
-		transmission:        opts.Transmission,
-		torrentRepository:   opts.TorrentRepository,
-		provider:            opts.Provider,
-		metadataProviderRef: opts.MetadataProviderRef,
-		activeTorrentCount:  &ActiveCount{},
+		logger:                opts.Logger,
+		qBittorrentClient:     opts.QbittorrentClient,
+		transmission:          opts.Transmission,
+		torrentRepository:     opts.TorrentRepository,
+		provider:              opts.Provider,
+		metadataProviderRef:   opts.MetadataProviderRef,
+		activeTorrentCount:    &ActiveCount{},
+		incompleteDirOverride: opts.IncompleteDirOverride,
+		isPausedFunc:          opts.IsPausedFunc,
+		pathStyle:             opts.PathStyle,
+		pathMappings:          opts.PathMappings,
 	}
 }
 
@@ -92,6 +114,9 @@ func (r *Repository) InitActiveTorrentCount(enabled bool, wsEventManager events.
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
+				if r.isPausedFunc != nil && r.isPausedFunc() {
+					continue
+				}
 				r.GetActiveCount(r.activeTorrentCount)
 				wsEventManager.SendEvent(events.ActiveTorrentCountUpdated, r.activeTorrentCount)
 			}
@@ -130,6 +155,32 @@ func (r *Repository) TorrentExists(hash string) bool {
 	}
 }
 
+// GetTorrentByHash returns the torrent with the given info hash, or ErrTorrentNotFound if it is not in the client.
+func (r *Repository) GetTorrentByHash(hash string) (*Torrent, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		torrents, err := r.qBittorrentClient.Torrent.GetList(&qbittorrent_model.GetTorrentListOptions{Filter: "all", Hashes: hash})
+		if err != nil {
+			return nil, err
+		}
+		if len(torrents) == 0 {
+			return nil, ErrTorrentNotFound
+		}
+		return r.FromQbitTorrent(torrents[0]), nil
+	case TransmissionClient:
+		torrents, err := r.transmission.Client.TorrentGetAllForHashes(context.Background(), []string{hash})
+		if err != nil {
+			return nil, err
+		}
+		if len(torrents) == 0 {
+			return nil, ErrTorrentNotFound
+		}
+		return r.FromTransmissionTorrent(&torrents[0]), nil
+	default:
+		return nil, errors.New("torrent client: No torrent client provider found")
+	}
+}
+
 // GetList will return all torrents from the torrent client.
 func (r *Repository) GetList() ([]*Torrent, error) {
 	switch r.provider {
@@ -225,6 +276,9 @@ func (r *Repository) AddMagnets(magnets []string, dest string) error {
 		return nil
 	}
 
+	// The destination can be a path of the server inside a mapped directory
+	dest = r.PathTranslator().ToClient(dest)
+
 	var err error
 	switch r.provider {
 	case QbittorrentClient:
@@ -8,7 +8,10 @@ import (
 	"seanime/internal/platforms/platform"
 	torrent_analyzer "seanime/internal/torrents/analyzer"
 	"seanime/internal/util"
+	"slices"
 	"time"
+
+	"github.com/samber/lo"
 )
 
 type (
@@ -77,31 +80,48 @@ type (
 		Destination      string
 		ShouldAddTorrent bool
 		PlatformRef      *util.Ref[platform.Platform]
+		// IsOngoing is true if the series is still airing, its total episode count is then unknown.
+		// The selection never changes the list status, an ongoing series must not be considered complete.
+		IsOngoing bool
+	}
+
+	// SmartSelectResult is returned by SmartSelect, also with the error when files are missing for some of the episodes.
+	SmartSelectResult struct {
+		// UnmatchedEpisodes are the requested episodes that have no file in the torrent
+		UnmatchedEpisodes []int
 	}
 )
 
+// IsOngoingMedia returns true if the total episode count of the media is unknown or if it is still airing.
+func IsOngoingMedia(media *anilist.CompleteAnime) bool {
+	if media == nil {
+		return false
+	}
+	return media.GetTotalEpisodeCount() <= 0 || (media.Status != nil && *media.Status == anilist.MediaStatusReleasing)
+}
+
 // SmartSelect will automatically the provided episode files from the torrent.
 // If the torrent has not been added yet, set SmartSelect.ShouldAddTorrent to true.
 // The torrent will NOT be removed if the selection fails.
-func (r *Repository) SmartSelect(p *SmartSelectParams) error {
+func (r *Repository) SmartSelect(p *SmartSelectParams) (*SmartSelectResult, error) {
 	if p.Media == nil || p.PlatformRef.IsAbsent() || r.torrentRepository == nil {
 		r.logger.Error().Msg("torrent client: media or platform is nil (smart select)")
-		return errors.New("media or anilist client wrapper is nil")
+		return nil, errors.New("media or anilist client wrapper is nil")
 	}
 
 	providerExtension, ok := r.torrentRepository.GetAnimeProviderExtension(p.Torrent.Provider)
 	if !ok {
 		r.logger.Error().Str("provider", p.Torrent.Provider).Msg("torrent client: provider extension not found (smart select)")
-		return errors.New("provider extension not found")
+		return nil, errors.New("provider extension not found")
 	}
 
 	if p.Media.IsMovieOrSingleEpisode() {
-		return errors.New("smart select is not supported for movies or single-episode series")
+		return nil, errors.New("smart select is not supported for movies or single-episode series")
 	}
 
 	if len(p.EpisodeNumbers) == 0 {
 		r.logger.Error().Msg("torrent client: no episode numbers provided (smart select)")
-		return errors.New("no episode numbers provided")
+		return nil, errors.New("no episode numbers provided")
 	}
 
 	if p.ShouldAddTorrent {
@@ -109,12 +129,12 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 		// Get magnet
 		magnet, err := providerExtension.GetProvider().GetTorrentMagnetLink(p.Torrent)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Add the torrent
 		err = r.AddMagnets([]string{magnet}, p.Destination)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -122,7 +142,7 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 	if err != nil {
 		r.logger.Err(err).Msg("torrent client: error getting files (smart select)")
 		_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-		return fmt.Errorf("error getting files, torrent still added: %w", err)
+		return nil, fmt.Errorf("error getting files, torrent still added: %w", err)
 	}
 
 	// Pause the torrent
@@ -130,7 +150,7 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 	if err != nil {
 		r.logger.Err(err).Msg("torrent client: error while pausing torrent (smart select)")
 		_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-		return fmt.Errorf("error while selecting files: %w", err)
+		return nil, fmt.Errorf("error while selecting files: %w", err)
 	}
 
 	// AnalyzeTorrentFiles the torrent files
@@ -142,13 +162,13 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 		MetadataProviderRef: r.metadataProviderRef,
 	})
 
-	r.logger.Debug().Msg("torrent client: analyzing torrent files (smart select)")
+	r.logger.Debug().Bool("ongoing", p.IsOngoing).Msg("torrent client: analyzing torrent files (smart select)")
 
 	analysis, err := analyzer.AnalyzeTorrentFiles()
 	if err != nil {
 		r.logger.Err(err).Msg("torrent client: error while analyzing torrent files (smart select)")
 		_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-		return fmt.Errorf("error while analyzing torrent files: %w", err)
+		return nil, fmt.Errorf("error while analyzing torrent files: %w", err)
 	}
 
 	r.logger.Debug().Msg("torrent client: finished analyzing torrent files (smart select)")
@@ -172,7 +192,13 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 	}
 	if dupCount > 2 {
 		_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-		return errors.New("failed to select files, can't tell seasons apart")
+		return nil, errors.New("failed to select files, can't tell seasons apart")
+	}
+
+	ret := &SmartSelectResult{
+		UnmatchedEpisodes: getUnmatchedEpisodes(p.EpisodeNumbers, lo.MapToSlice(mainFiles, func(_ int, f *torrent_analyzer.File) int {
+			return f.GetLocalFile().GetEpisodeNumber()
+		})),
 	}
 
 	selectedFiles := make(map[int]*torrent_analyzer.File)
@@ -188,7 +214,7 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 
 	if selectedCount == 0 || selectedCount < len(p.EpisodeNumbers) {
 		_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-		return errors.New("failed to select files, could not find the right season files")
+		return ret, errors.New("failed to select files, could not find the right season files")
 	}
 
 	indicesToRemove := analysis.GetUnselectedIndices(selectedFiles)
@@ -199,7 +225,7 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 		if err != nil {
 			r.logger.Err(err).Msg("torrent client: error while deselecting files (smart select)")
 			_ = r.RemoveTorrents([]string{p.Torrent.InfoHash})
-			return fmt.Errorf("error while deselecting files: %w", err)
+			return nil, fmt.Errorf("error while deselecting files: %w", err)
 		}
 	}
 
@@ -208,5 +234,12 @@ func (r *Repository) SmartSelect(p *SmartSelectParams) error {
 	// Resume the torrent
 	_ = r.ResumeTorrents([]string{p.Torrent.InfoHash})
 
-	return nil
+	return ret, nil
+}
+
+// getUnmatchedEpisodes returns the requested episodes that are not in the available episodes, sorted.
+func getUnmatchedEpisodes(requested []int, available []int) []int {
+	ret := lo.Uniq(lo.Without(requested, available...))
+	slices.Sort(ret)
+	return ret
 }
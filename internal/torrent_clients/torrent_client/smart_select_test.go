@@ -1,5 +1,13 @@
 package torrent_client
 
+import (
+	"seanime/internal/api/anilist"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
 //func TestSmartSelect(t *testing.T) {
 //	t.Skip("Refactor test")
 //	test_utils.InitTestProvider(t, test_utils.TorrentClient())
@@ -78,3 +86,17 @@ package torrent_client
 //	}
 //
 //}
+
+func TestIsOngoingMedia(t *testing.T) {
+	assert.False(t, IsOngoingMedia(nil))
+	assert.True(t, IsOngoingMedia(&anilist.CompleteAnime{}))
+	assert.True(t, IsOngoingMedia(&anilist.CompleteAnime{Episodes: lo.ToPtr(0)}))
+	assert.True(t, IsOngoingMedia(&anilist.CompleteAnime{Episodes: lo.ToPtr(12), Status: lo.ToPtr(anilist.MediaStatusReleasing)}))
+	assert.False(t, IsOngoingMedia(&anilist.CompleteAnime{Episodes: lo.ToPtr(12), Status: lo.ToPtr(anilist.MediaStatusFinished)}))
+}
+
+func TestGetUnmatchedEpisodes(t *testing.T) {
+	assert.Equal(t, []int{5, 6, 7}, getUnmatchedEpisodes([]int{7, 4, 5, 6, 5}, []int{1, 2, 3, 4}))
+	assert.Empty(t, getUnmatchedEpisodes([]int{1, 2}, []int{2, 1, 3}))
+	assert.Empty(t, getUnmatchedEpisodes(nil, []int{1}))
+}
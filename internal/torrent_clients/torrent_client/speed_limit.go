@@ -0,0 +1,172 @@
+package torrent_client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hekmon/transmissionrpc/v3"
+)
+
+// GetGlobalDownloadLimit returns the global download limit of the torrent client in bytes per second.
+// It returns 0 if there is no limit.
+func (r *Repository) GetGlobalDownloadLimit() (int, error) {
+	switch r.provider {
+	case QbittorrentClient:
+		return r.qBittorrentClient.Transfer.GetGlobalDownloadLimit()
+	case TransmissionClient:
+		args, err := r.transmission.Client.SessionArgumentsGet(context.Background(), []string{"speed-limit-down-enabled", "speed-limit-down"})
+		if err != nil {
+			return 0, err
+		}
+		if args.SpeedLimitDownEnabled == nil || !*args.SpeedLimitDownEnabled || args.SpeedLimitDown == nil {
+			return 0, nil
+		}
+		// Transmission uses kB/s
+		return int(*args.SpeedLimitDown) * 1000, nil
+	default:
+		return 0, errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// GetTransferSpeed returns the aggregate download and upload speeds of the torrent client in bytes per second.
+func (r *Repository) GetTransferSpeed() (download int64, upload int64, err error) {
+	switch r.provider {
+	case QbittorrentClient:
+		info, err := r.qBittorrentClient.Transfer.GetTransferInfo()
+		if err != nil {
+			return 0, 0, err
+		}
+		return int64(info.DlInfoSpeed), int64(info.UpInfoSpeed), nil
+	case TransmissionClient:
+		stats, err := r.transmission.Client.SessionStats(context.Background())
+		if err != nil {
+			return 0, 0, err
+		}
+		return stats.DownloadSpeed, stats.UploadSpeed, nil
+	default:
+		return 0, 0, errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// SetGlobalDownloadLimit sets the global download limit of the torrent client in bytes per second.
+// A limit of 0 removes the limit.
+func (r *Repository) SetGlobalDownloadLimit(limit int) error {
+	if limit < 0 {
+		limit = 0
+	}
+
+	r.logger.Debug().Int("limit", limit).Msg("torrent client: Setting download limit")
+
+	switch r.provider {
+	case QbittorrentClient:
+		return r.qBittorrentClient.Transfer.SetGlobalDownloadLimit(limit)
+	case TransmissionClient:
+		enabled := limit > 0
+		args := transmissionrpc.SessionArguments{
+			SpeedLimitDownEnabled: &enabled,
+		}
+		if enabled {
+			kbps := max(int64(limit/1000), 1)
+			args.SpeedLimitDown = &kbps
+		}
+		return r.transmission.Client.SessionArgumentsSet(context.Background(), args)
+	default:
+		return errors.New("torrent client: No torrent client selected")
+	}
+}
+
+// SetDownloadLimit sets the download limit of a torrent in bytes per second.
+// A limit of 0 removes the limit.
+func (r *Repository) SetDownloadLimit(hash string, limit int64) error {
+	return r.setTorrentLimit(hash, limit, false)
+}
+
+// SetUploadLimit sets the upload limit of a torrent in bytes per second.
+// A limit of 0 removes the limit.
+func (r *Repository) SetUploadLimit(hash string, limit int64) error {
+	return r.setTorrentLimit(hash, limit, true)
+}
+
+func (r *Repository) setTorrentLimit(hash string, limit int64, upload bool) error {
+	if limit < 0 {
+		limit = 0
+	}
+
+	r.logger.Debug().Str("hash", hash).Int64("limit", limit).Bool("upload", upload).Msg("torrent client: Setting torrent limit")
+
+	var err error
+	switch r.provider {
+	case QbittorrentClient:
+		if upload {
+			err = r.qBittorrentClient.Torrent.SetUploadLimits([]string{hash}, int(limit))
+		} else {
+			err = r.qBittorrentClient.Torrent.SetDownloadLimits([]string{hash}, int(limit))
+		}
+	case TransmissionClient:
+		var torrents []transmissionrpc.Torrent
+		torrents, err = r.transmission.Client.TorrentGetAllForHashes(context.Background(), []string{hash})
+		if err != nil {
+			break
+		}
+		if len(torrents) == 0 || torrents[0].ID == nil {
+			return ErrTorrentNotFound
+		}
+		enabled := limit > 0
+		payload := transmissionrpc.TorrentSetPayload{
+			IDs: []int64{*torrents[0].ID},
+		}
+		// Transmission uses kB/s
+		kbps := max(limit/1000, 1)
+		if upload {
+			payload.UploadLimited = &enabled
+			if enabled {
+				payload.UploadLimit = &kbps
+			}
+		} else {
+			payload.DownloadLimited = &enabled
+			if enabled {
+				payload.DownloadLimit = &kbps
+			}
+		}
+		err = r.transmission.Client.TorrentSet(context.Background(), payload)
+	default:
+		return errors.New("torrent client: No torrent client selected")
+	}
+
+	if err != nil {
+		r.logger.Err(err).Msg("torrent client: Error while setting torrent limit")
+		return err
+	}
+
+	return nil
+}
+
+// GetDownloadingPaths returns the content paths of the torrents that are currently downloading.
+func (r *Repository) GetDownloadingPaths() ([]string, error) {
+	torrents, err := r.GetList()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0)
+	for _, t := range torrents {
+		if t.Status == TorrentStatusDownloading {
+			ret = append(ret, t.ContentPath)
+		}
+	}
+	return ret, nil
+}
+
+// GetSeedingTorrents returns the torrents that are currently seeding.
+func (r *Repository) GetSeedingTorrents() ([]*Torrent, error) {
+	torrents, err := r.GetList()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Torrent, 0)
+	for _, t := range torrents {
+		if t.Status == TorrentStatusSeeding {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
@@ -3,6 +3,8 @@ package torrent_client
 import (
 	"seanime/internal/torrent_clients/qbittorrent/model"
 	"seanime/internal/util"
+	"strings"
+	"time"
 
 	"github.com/hekmon/transmissionrpc/v3"
 )
@@ -27,6 +29,14 @@ type (
 		Eta         string        `json:"eta"`
 		Status      TorrentStatus `json:"status"`
 		ContentPath string        `json:"contentPath"`
+		// Tags contains the tags and category (qBittorrent) or labels (Transmission) of the torrent
+		Tags []string `json:"tags"`
+		// AddedOn is when the torrent was added to the client
+		AddedOn time.Time `json:"addedOn"`
+		// CompletedOn is when the torrent finished downloading, nil if it is not complete
+		CompletedOn *time.Time `json:"completedOn"`
+		// Tracker is the announce URL of the current tracker, passkeys are redacted
+		Tracker string `json:"tracker"`
 	}
 	TorrentStatus string
 )
@@ -96,6 +106,21 @@ func (r *Repository) FromTransmissionTorrent(t *transmissionrpc.Torrent) *Torren
 		torrent.Status = fromTransmissionTorrentStatus(*t.Status, *t.IsFinished)
 	}
 
+	torrent.Tags = make([]string, 0, len(t.Labels))
+	torrent.Tags = append(torrent.Tags, t.Labels...)
+
+	if len(t.Trackers) > 0 {
+		torrent.Tracker = RedactTrackerURL(t.Trackers[0].Announce)
+	}
+
+	if t.AddedDate != nil {
+		torrent.AddedOn = *t.AddedDate
+	}
+	if t.DoneDate != nil && t.DoneDate.Unix() > 0 && isComplete(torrent) {
+		completedOn := *t.DoneDate
+		torrent.CompletedOn = &completedOn
+	}
+
 	return torrent
 }
 
@@ -134,10 +159,34 @@ func (r *Repository) FromQbitTorrent(t *qbittorrent_model.Torrent) *Torrent {
 	torrent.Eta = util.FormatETA(t.Eta)
 	torrent.ContentPath = t.ContentPath
 	torrent.Status = fromQbitTorrentStatus(t.State)
+	torrent.Tracker = RedactTrackerURL(t.Tracker)
+
+	torrent.Tags = make([]string, 0)
+	if t.Category != "" {
+		torrent.Tags = append(torrent.Tags, t.Category)
+	}
+	for _, tag := range strings.Split(t.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			torrent.Tags = append(torrent.Tags, tag)
+		}
+	}
+
+	if t.AddedOn > 0 {
+		torrent.AddedOn = time.Unix(int64(t.AddedOn), 0)
+	}
+	if t.CompletionOn > 0 && isComplete(torrent) {
+		completedOn := time.Unix(int64(t.CompletionOn), 0)
+		torrent.CompletedOn = &completedOn
+	}
 
 	return torrent
 }
 
+// isComplete returns true if the torrent has finished downloading.
+func isComplete(t *Torrent) bool {
+	return t.Status == TorrentStatusSeeding || t.Status == TorrentStatusStopped || t.Progress >= 1
+}
+
 // fromQbitTorrentStatus returns a normalized status for the torrent.
 func fromQbitTorrentStatus(st qbittorrent_model.TorrentState) TorrentStatus {
 	if st == qbittorrent_model.StateQueuedUP ||
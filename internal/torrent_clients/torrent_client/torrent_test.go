@@ -0,0 +1,82 @@
+package torrent_client
+
+import (
+	"seanime/internal/torrent_clients/qbittorrent/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromQbitTorrent_Dates(t *testing.T) {
+	r := &Repository{}
+	completedOn := time.Unix(1700003600, 0)
+
+	tests := []struct {
+		name                string
+		torrent             *qbittorrent_model.Torrent
+		expectedAddedOn     time.Time
+		expectedCompletedOn *time.Time
+	}{
+		{
+			name: "downloading",
+			torrent: &qbittorrent_model.Torrent{
+				State:        qbittorrent_model.StateDownloading,
+				Progress:     0.5,
+				AddedOn:      1700000000,
+				CompletionOn: -1,
+			},
+			expectedAddedOn: time.Unix(1700000000, 0),
+		},
+		{
+			name: "seeding",
+			torrent: &qbittorrent_model.Torrent{
+				State:        qbittorrent_model.StateUploading,
+				Progress:     1,
+				AddedOn:      1700000000,
+				CompletionOn: 1700003600,
+			},
+			expectedAddedOn:     time.Unix(1700000000, 0),
+			expectedCompletedOn: &completedOn,
+		},
+		{
+			name: "no dates",
+			torrent: &qbittorrent_model.Torrent{
+				State: qbittorrent_model.StatePausedDL,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret := r.FromQbitTorrent(tt.torrent)
+			assert.True(t, tt.expectedAddedOn.Equal(ret.AddedOn))
+			if tt.expectedCompletedOn == nil {
+				assert.Nil(t, ret.CompletedOn)
+				return
+			}
+			require.NotNil(t, ret.CompletedOn)
+			assert.True(t, tt.expectedCompletedOn.Equal(*ret.CompletedOn))
+		})
+	}
+}
+
+func TestFromTransmissionPieces(t *testing.T) {
+	// 0b10100000, 0b10000000
+	ret, err := fromTransmissionPieces("oIA=", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0, 2, 0, 0, 0, 0, 0, 2, 0}, ret)
+
+	_, err = fromTransmissionPieces("not base64", 10)
+	assert.Error(t, err)
+}
+
+func TestFromQbitPieceStates(t *testing.T) {
+	ret := fromQbitPieceStates([]qbittorrent_model.TorrentPieceState{
+		qbittorrent_model.PieceStateDownloaded,
+		qbittorrent_model.PieceStateDownloading,
+		qbittorrent_model.PieceStateNotDownloaded,
+	})
+	assert.Equal(t, []byte{2, 1, 0}, ret)
+}
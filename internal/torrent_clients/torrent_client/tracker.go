@@ -0,0 +1,22 @@
+package torrent_client
+
+import (
+	"regexp"
+)
+
+const redactedTrackerToken = "[REDACTED]"
+
+var (
+	// trackerQueryTokenRegex matches the authentication parameters of private tracker announce URLs,
+	// e.g. "announce.php?passkey=abc" or "announce?authkey=abc&torrent_pass=def"
+	trackerQueryTokenRegex = regexp.MustCompile(`(?i)([?&;](?:passkey|authkey|auth|torrent_pass)=)[^&;#]+`)
+	// trackerPathTokenRegex matches passkeys in the path of announce URLs, e.g. "/0123456789abcdef0123456789abcdef/announce"
+	trackerPathTokenRegex = regexp.MustCompile(`/[a-zA-Z0-9]{24,}(/announce)`)
+)
+
+// RedactTrackerURL replaces the passkeys and authentication tokens of a tracker URL with "[REDACTED]".
+func RedactTrackerURL(url string) string {
+	url = trackerQueryTokenRegex.ReplaceAllString(url, "${1}"+redactedTrackerToken)
+	url = trackerPathTokenRegex.ReplaceAllString(url, "/"+redactedTrackerToken+"${1}")
+	return url
+}
@@ -0,0 +1,78 @@
+package torrent_client
+
+import (
+	"seanime/internal/torrent_clients/qbittorrent/model"
+	"testing"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactTrackerURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "passkey",
+			url:      "https://tracker.example.org/announce.php?passkey=0123456789abcdef",
+			expected: "https://tracker.example.org/announce.php?passkey=[REDACTED]",
+		},
+		{
+			name:     "authkey and torrent_pass",
+			url:      "https://tracker.example.org/announce?authkey=abc123&torrent_pass=def456",
+			expected: "https://tracker.example.org/announce?authkey=[REDACTED]&torrent_pass=[REDACTED]",
+		},
+		{
+			name:     "auth with other parameters",
+			url:      "http://tracker.example.org:2710/announce?info=1&auth=s3cr3t&uploaded=0",
+			expected: "http://tracker.example.org:2710/announce?info=1&auth=[REDACTED]&uploaded=0",
+		},
+		{
+			name:     "uppercase parameter",
+			url:      "https://tracker.example.org/announce?PassKey=ABCDEF",
+			expected: "https://tracker.example.org/announce?PassKey=[REDACTED]",
+		},
+		{
+			name:     "passkey in path",
+			url:      "https://tracker.example.org/0123456789abcdef0123456789abcdef/announce",
+			expected: "https://tracker.example.org/[REDACTED]/announce",
+		},
+		{
+			name:     "public tracker",
+			url:      "udp://tracker.opentrackr.org:1337/announce",
+			expected: "udp://tracker.opentrackr.org:1337/announce",
+		},
+		{
+			name:     "parameter name inside another name",
+			url:      "https://tracker.example.org/announce?oauth_state=1",
+			expected: "https://tracker.example.org/announce?oauth_state=1",
+		},
+		{
+			name:     "empty",
+			url:      "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RedactTrackerURL(tt.url))
+		})
+	}
+}
+
+func TestFromTorrent_RedactsTracker(t *testing.T) {
+	r := &Repository{}
+
+	qbit := r.FromQbitTorrent(&qbittorrent_model.Torrent{
+		Tracker: "https://tracker.example.org/announce.php?passkey=0123456789abcdef",
+	})
+	assert.Equal(t, "https://tracker.example.org/announce.php?passkey=[REDACTED]", qbit.Tracker)
+
+	transmission := r.FromTransmissionTorrent(&transmissionrpc.Torrent{
+		Trackers: []transmissionrpc.Tracker{{Announce: "https://tracker.example.org/announce?authkey=abc123"}},
+	})
+	assert.Equal(t, "https://tracker.example.org/announce?authkey=[REDACTED]", transmission.Tracker)
+}
@@ -2,7 +2,9 @@ package transmission
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	httputil "seanime/internal/util/http"
 	"strings"
 
 	"github.com/hekmon/transmissionrpc/v3"
@@ -23,6 +25,8 @@ type (
 		Password string
 		Host     string // Default: 127.0.0.1
 		Port     int
+		// CustomHeaders are added to every request, e.g. for reverse-proxy authentication
+		CustomHeaders map[string]string
 	}
 )
 
@@ -56,7 +60,16 @@ func New(options *NewTransmissionOptions) (*Transmission, error) {
 		return nil, err
 	}
 
-	client, _ := transmissionrpc.New(_url, nil)
+	var config *transmissionrpc.Config
+	if len(options.CustomHeaders) > 0 {
+		config = &transmissionrpc.Config{
+			CustomClient: &http.Client{
+				Transport: httputil.NewHeaderRoundTripper(http.DefaultTransport.(*http.Transport).Clone(), options.CustomHeaders),
+			},
+		}
+	}
+
+	client, _ := transmissionrpc.New(_url, config)
 	return &Transmission{
 		Client: client,
 		Path:   options.Path,
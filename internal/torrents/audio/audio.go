@@ -0,0 +1,151 @@
+package torrent_audio
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/5rahim/habari"
+)
+
+const (
+	// KindSub is assumed when no audio marker is found, most releases only have the original audio.
+	KindSub Kind = "sub"
+	// KindDub is a release with dubbed audio only.
+	KindDub Kind = "dub"
+	// KindDual is a release with both the original and the dubbed audio.
+	KindDual Kind = "dual"
+)
+
+const (
+	PreferenceNone         Preference = ""
+	PreferenceSubOnly      Preference = "sub-only"
+	PreferenceDubPreferred Preference = "dub-preferred"
+	PreferenceDubRequired  Preference = "dub-required"
+)
+
+type (
+	// Kind is the audio detected from a release name.
+	Kind string
+	// Preference is the audio a user wants for a rule or a media.
+	Preference string
+)
+
+var (
+	dualAudioRegex = regexp.MustCompile(`(?i)\b(dual|multi)[\s._-]?audio\b|\bdual\b|\bmulti[\s._-]?(lang|language)s?\b`)
+	dubRegex       = regexp.MustCompile(`(?i)\b(eng(lish)?[\s._-]?dub(bed)?|dub(bed)?)\b`)
+)
+
+// Detect returns the audio of a release based on its name and its parsed metadata.
+func Detect(name string, parsedData *habari.Metadata) Kind {
+	if parsedData != nil {
+		for _, term := range parsedData.AudioTerm {
+			upper := strings.ToUpper(term)
+			if strings.Contains(upper, "DUAL") || strings.Contains(upper, "MULTI") {
+				return KindDual
+			}
+		}
+	}
+
+	if dualAudioRegex.MatchString(name) {
+		return KindDual
+	}
+
+	if dubRegex.MatchString(name) {
+		return KindDub
+	}
+
+	return KindSub
+}
+
+// HasDub returns true if the release contains dubbed audio.
+func (k Kind) HasDub() bool {
+	return k == KindDub || k == KindDual
+}
+
+// IsValid returns true if the preference is known.
+func (p Preference) IsValid() bool {
+	switch p {
+	case PreferenceNone, PreferenceSubOnly, PreferenceDubPreferred, PreferenceDubRequired:
+		return true
+	}
+	return false
+}
+
+// Accepts returns true if a release with the given audio can be selected.
+func (p Preference) Accepts(kind Kind) bool {
+	switch p {
+	case PreferenceSubOnly:
+		return kind == KindSub
+	case PreferenceDubRequired:
+		return kind.HasDub()
+	}
+	return true
+}
+
+// Score returns a higher value for releases that better match the preference.
+// It is used to sort releases that are all accepted by the preference.
+func (p Preference) Score(kind Kind) int {
+	switch p {
+	case PreferenceDubPreferred, PreferenceDubRequired:
+		switch kind {
+		case KindDual:
+			return 2
+		case KindDub:
+			return 1
+		}
+	case PreferenceSubOnly:
+		if kind == KindSub {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Resolve returns the first preference that is set.
+// Rule preferences should be passed before media preferences.
+func Resolve(preferences ...Preference) Preference {
+	for _, p := range preferences {
+		if p != PreferenceNone {
+			return p
+		}
+	}
+	return PreferenceNone
+}
+
+// ValidateLanguages checks the audio languages of a downloaded file against the detected audio of its release.
+// Languages are BCP 47 codes as returned by ffprobe, e.g. "en" or "ja".
+// It returns false and a reason if the file does not contain the expected audio.
+// Files without language tags cannot be validated and are considered valid.
+func ValidateLanguages(kind Kind, languages []string) (bool, string) {
+	hasEnglish := false
+	tagged := 0
+	for _, lang := range languages {
+		if lang == "" || strings.EqualFold(lang, "und") {
+			continue
+		}
+		tagged++
+		if strings.HasPrefix(strings.ToLower(lang), "en") {
+			hasEnglish = true
+		}
+	}
+
+	if tagged == 0 {
+		return true, ""
+	}
+
+	switch kind {
+	case KindDual:
+		if len(languages) < 2 {
+			return false, "release is labeled dual audio but the file has a single audio track"
+		}
+		if !hasEnglish {
+			return false, "release is labeled dual audio but the file has no English audio track"
+		}
+	case KindDub:
+		if !hasEnglish {
+			return false, "release is labeled dubbed but the file has no English audio track"
+		}
+	}
+
+	return true, ""
+}
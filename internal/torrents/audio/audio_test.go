@@ -0,0 +1,72 @@
+package torrent_audio
+
+import (
+	"testing"
+
+	"github.com/5rahim/habari"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Kind
+	}{
+		{"[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", KindSub},
+		{"[Judas] Sousou no Frieren - S01E01 [1080p][HEVC x265 10bit][Dual-Audio][Multi-Subs]", KindDual},
+		{"Frieren Beyond Journey's End S01E01 1080p WEB H.264 DUAL AUDIO", KindDual},
+		{"[Anime Time] Frieren - 01 [Dual Audio][1080p][HEVC 10bit x265][AAC][Multi Sub]", KindDual},
+		{"[EMBER] Frieren - 01 (1080p) [Multi-Audio]", KindDual},
+		{"Frieren - 01 (English Dub) [1080p]", KindDub},
+		{"[Erai-raws] Frieren - 01 [1080p][ENG DUB]", KindDub},
+		{"Frieren Dubbed Episode 1", KindDub},
+		{"[Doobed] Frieren - 01", KindSub},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Detect(tt.name, habari.Parse(tt.name)))
+		})
+	}
+}
+
+func TestPreference(t *testing.T) {
+	assert.True(t, PreferenceNone.Accepts(KindSub))
+	assert.True(t, PreferenceNone.Accepts(KindDual))
+
+	assert.True(t, PreferenceSubOnly.Accepts(KindSub))
+	assert.False(t, PreferenceSubOnly.Accepts(KindDub))
+	assert.False(t, PreferenceSubOnly.Accepts(KindDual))
+
+	assert.True(t, PreferenceDubPreferred.Accepts(KindSub))
+	assert.Greater(t, PreferenceDubPreferred.Score(KindDual), PreferenceDubPreferred.Score(KindSub))
+	assert.Greater(t, PreferenceDubPreferred.Score(KindDub), PreferenceDubPreferred.Score(KindSub))
+
+	assert.False(t, PreferenceDubRequired.Accepts(KindSub))
+	assert.True(t, PreferenceDubRequired.Accepts(KindDub))
+	assert.True(t, PreferenceDubRequired.Accepts(KindDual))
+
+	assert.Equal(t, PreferenceDubRequired, Resolve(PreferenceNone, PreferenceDubRequired, PreferenceSubOnly))
+	assert.Equal(t, PreferenceNone, Resolve())
+
+	assert.False(t, Preference("other").IsValid())
+}
+
+func TestValidateLanguages(t *testing.T) {
+	ok, _ := ValidateLanguages(KindDual, []string{"ja", "en"})
+	assert.True(t, ok)
+
+	ok, reason := ValidateLanguages(KindDual, []string{"ja"})
+	assert.False(t, ok)
+	assert.NotEmpty(t, reason)
+
+	ok, _ = ValidateLanguages(KindDub, []string{"ja", "de"})
+	assert.False(t, ok)
+
+	ok, _ = ValidateLanguages(KindSub, []string{"ja"})
+	assert.True(t, ok)
+
+	// Untagged files cannot be validated
+	ok, _ = ValidateLanguages(KindDual, []string{"", "und"})
+	assert.True(t, ok)
+}
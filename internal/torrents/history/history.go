@@ -0,0 +1,235 @@
+package torrent_history
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/torrents/torrent"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+)
+
+const (
+	// sessionWindow is how long a result shown again is considered part of the same search, e.g. when loading more pages
+	sessionWindow = time.Hour
+	// retention is how long entries are kept after they were last updated
+	retention = 365 * 24 * time.Hour
+	// maxEntriesPerOwner bounds the history of a user
+	maxEntriesPerOwner = 20000
+	pruneInterval      = 24 * time.Hour
+)
+
+type (
+	// Store remembers, per user, the torrent search results that were shown, dismissed or downloaded.
+	// It is used to hide the results the user is not interested in and to mark the results already seen.
+	Store struct {
+		logger   *zerolog.Logger
+		database *db.Database
+
+		mu         sync.Mutex
+		lastPruned time.Time
+	}
+
+	NewStoreOptions struct {
+		Logger   *zerolog.Logger
+		Database *db.Database
+	}
+)
+
+func NewStore(opts *NewStoreOptions) *Store {
+	return &Store{
+		logger:   opts.Logger,
+		database: opts.Database,
+	}
+}
+
+// Key returns the key of a result, the info hash of the torrent or its provider and link when the hash is unknown.
+func Key(t *hibiketorrent.AnimeTorrent) string {
+	if t.InfoHash != "" {
+		return strings.ToLower(t.InfoHash)
+	}
+	return t.Provider + "|" + t.Link
+}
+
+// Annotate records the results of a search as seen by the owner and sets the time they were previously seen.
+// If hideSeen is true, the dismissed and downloaded results are removed first.
+func (s *Store) Annotate(owner string, data *torrent.SearchData, hideSeen bool) {
+	if data == nil {
+		return
+	}
+	defer s.pruneIfNeeded()
+
+	keys := lo.Uniq(lo.FilterMap(data.Torrents, func(t *hibiketorrent.AnimeTorrent, _ int) (string, bool) {
+		if t == nil {
+			return "", false
+		}
+		return Key(t), true
+	}))
+
+	entries, err := s.database.GetTorrentResultHistory(owner, keys)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("torrent history: Failed to get history")
+		return
+	}
+	entriesByKey := lo.KeyBy(entries, func(e *models.TorrentResultHistory) string { return e.Key })
+
+	if hideSeen {
+		downloaded := s.getDownloadedHashes()
+		keep := func(t *hibiketorrent.AnimeTorrent) bool {
+			if t == nil {
+				return false
+			}
+			if e, ok := entriesByKey[Key(t)]; ok && (e.DismissedAt != nil || e.DownloadedAt != nil) {
+				return false
+			}
+			_, found := downloaded[strings.ToLower(t.InfoHash)]
+			return t.InfoHash == "" || !found
+		}
+		data.Torrents = lo.Filter(data.Torrents, func(t *hibiketorrent.AnimeTorrent, _ int) bool { return keep(t) })
+		data.Previews = lo.Filter(data.Previews, func(p *torrent.Preview, _ int) bool { return keep(p.Torrent) })
+	}
+
+	now := time.Now()
+	data.PreviouslySeen = make(map[string]time.Time)
+	updated := make([]*models.TorrentResultHistory, 0, len(data.Torrents))
+	for _, t := range data.Torrents {
+		key := Key(t)
+		e, ok := entriesByKey[key]
+		if !ok {
+			updated = append(updated, &models.TorrentResultHistory{
+				Owner:    owner,
+				Key:      key,
+				Name:     t.Name,
+				Provider: t.Provider,
+				SeenAt:   &now,
+			})
+			entriesByKey[key] = updated[len(updated)-1]
+			continue
+		}
+		// Results shown again in the same search, e.g. on the next page, keep the time of the previous search
+		if e.SeenAt == nil || now.Sub(*e.SeenAt) >= sessionWindow {
+			// The entry is upserted by owner and key
+			updated = append(updated, &models.TorrentResultHistory{
+				Owner:          owner,
+				Key:            key,
+				Name:           t.Name,
+				Provider:       t.Provider,
+				SeenAt:         &now,
+				PreviousSeenAt: e.SeenAt,
+			})
+			e.PreviousSeenAt, e.SeenAt = e.SeenAt, &now
+		}
+		if e.PreviousSeenAt != nil {
+			data.PreviouslySeen[key] = *e.PreviousSeenAt
+		}
+	}
+
+	if err := s.database.UpsertTorrentResultHistory(updated, []string{"seen_at", "previous_seen_at"}); err != nil {
+		s.logger.Warn().Err(err).Msg("torrent history: Failed to record seen results")
+	}
+}
+
+// Dismiss hides the result from the owner's searches that hide the seen results.
+func (s *Store) Dismiss(owner string, t *hibiketorrent.AnimeTorrent) (*models.TorrentResultHistory, error) {
+	now := time.Now()
+	entry := &models.TorrentResultHistory{
+		Owner:       owner,
+		Key:         Key(t),
+		Name:        t.Name,
+		Provider:    t.Provider,
+		DismissedAt: &now,
+	}
+	if err := s.database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{entry}, []string{"name", "dismissed_at"}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Undismiss shows a dismissed result again. It returns false if the result was not dismissed.
+func (s *Store) Undismiss(owner string, key string) (bool, error) {
+	return s.database.UndismissTorrentResult(owner, key)
+}
+
+// GetDismissed returns the dismissed results of the owner, most recently dismissed first.
+func (s *Store) GetDismissed(owner string) ([]*models.TorrentResultHistory, error) {
+	return s.database.GetDismissedTorrentResults(owner)
+}
+
+// MarkDownloaded records the torrents downloaded by the owner.
+func (s *Store) MarkDownloaded(owner string, torrents []hibiketorrent.AnimeTorrent) {
+	now := time.Now()
+	entries := make([]*models.TorrentResultHistory, 0, len(torrents))
+	for _, t := range torrents {
+		entries = append(entries, &models.TorrentResultHistory{
+			Owner:        owner,
+			Key:          Key(&t),
+			Name:         t.Name,
+			Provider:     t.Provider,
+			DownloadedAt: &now,
+		})
+	}
+	entries = lo.UniqBy(entries, func(e *models.TorrentResultHistory) string { return e.Key })
+	if err := s.database.UpsertTorrentResultHistory(entries, []string{"downloaded_at"}); err != nil {
+		s.logger.Warn().Err(err).Msg("torrent history: Failed to record downloaded torrents")
+	}
+}
+
+// RecordDownload records a torrent downloaded by the owner and returns its history entry.
+func (s *Store) RecordDownload(owner string, t *hibiketorrent.AnimeTorrent) (*models.TorrentResultHistory, error) {
+	now := time.Now()
+	key := Key(t)
+	entry := &models.TorrentResultHistory{
+		Owner:        owner,
+		Key:          key,
+		Name:         t.Name,
+		Provider:     t.Provider,
+		DownloadedAt: &now,
+	}
+	if err := s.database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{entry}, []string{"downloaded_at"}); err != nil {
+		return nil, err
+	}
+
+	// The ID of the entry is not returned when it already existed
+	entries, err := s.database.GetTorrentResultHistory(owner, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return entry, nil
+	}
+	return entries[0], nil
+}
+
+// getDownloadedHashes returns the hashes of the torrents downloaded by the AutoDownloader.
+func (s *Store) getDownloadedHashes() map[string]struct{} {
+	ret := make(map[string]struct{})
+	items, err := s.database.GetAutoDownloaderItems()
+	if err != nil {
+		return ret
+	}
+	for _, item := range items {
+		if item.Hash != "" && !item.AwaitingConfirmation {
+			ret[strings.ToLower(item.Hash)] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// pruneIfNeeded deletes the old entries and bounds the history of each user, at most once a day.
+func (s *Store) pruneIfNeeded() {
+	s.mu.Lock()
+	if time.Since(s.lastPruned) < pruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPruned = time.Now()
+	s.mu.Unlock()
+
+	if err := s.database.PruneTorrentResultHistory(time.Now().Add(-retention), maxEntriesPerOwner); err != nil {
+		s.logger.Warn().Err(err).Msg("torrent history: Failed to prune history")
+	}
+}
@@ -0,0 +1,125 @@
+package torrent_history
+
+import (
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"seanime/internal/torrents/torrent"
+	"seanime/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "torrent_history_test", logger)
+	require.NoError(t, err)
+	return NewStore(&NewStoreOptions{Logger: logger, Database: database})
+}
+
+func newSearchData(torrents ...*hibiketorrent.AnimeTorrent) *torrent.SearchData {
+	data := &torrent.SearchData{}
+	for _, t := range torrents {
+		data.Torrents = append(data.Torrents, t)
+		data.Previews = append(data.Previews, &torrent.Preview{Torrent: t})
+	}
+	return data
+}
+
+func TestAnnotate(t *testing.T) {
+	s := newTestStore(t)
+
+	batch := &hibiketorrent.AnimeTorrent{Provider: "nyaa", Name: "[Group] Show (BD 1080p)", InfoHash: "ABC"}
+	junk := &hibiketorrent.AnimeTorrent{Provider: "nyaa", Name: "[Junk] Show", Link: "https://example.com/1"}
+	downloaded := &hibiketorrent.AnimeTorrent{Provider: "nyaa", Name: "[Group] Show - 01", InfoHash: "def"}
+
+	// First search, nothing has been seen
+	data := newSearchData(batch, junk, downloaded)
+	s.Annotate("user:a", data, true)
+	assert.Len(t, data.Torrents, 3)
+	assert.Empty(t, data.PreviouslySeen)
+
+	_, err := s.Dismiss("user:a", junk)
+	require.NoError(t, err)
+	s.MarkDownloaded("user:a", []hibiketorrent.AnimeTorrent{*downloaded})
+
+	// Same search, e.g. the next page, the results are not considered previously seen
+	data = newSearchData(batch, junk, downloaded)
+	s.Annotate("user:a", data, false)
+	assert.Len(t, data.Torrents, 3)
+	assert.Empty(t, data.PreviouslySeen)
+
+	// Later search
+	seenAt := time.Now().Add(-2 * sessionWindow)
+	require.NoError(t, s.database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{
+		{Owner: "user:a", Key: Key(batch), SeenAt: &seenAt},
+	}, []string{"seen_at"}))
+
+	data = newSearchData(batch, junk, downloaded)
+	s.Annotate("user:a", data, true)
+	require.Len(t, data.Torrents, 1)
+	require.Len(t, data.Previews, 1)
+	assert.Equal(t, batch, data.Torrents[0])
+	assert.WithinDuration(t, seenAt, data.PreviouslySeen["abc"], time.Second)
+
+	// The memory is per user
+	data = newSearchData(batch, junk, downloaded)
+	s.Annotate("user:b", data, true)
+	assert.Len(t, data.Torrents, 3)
+
+	// Un-hiding a dismissed result
+	dismissed, err := s.GetDismissed("user:a")
+	require.NoError(t, err)
+	require.Len(t, dismissed, 1)
+	assert.Equal(t, "nyaa|https://example.com/1", dismissed[0].Key)
+
+	ok, err := s.Undismiss("user:a", dismissed[0].Key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	data = newSearchData(batch, junk, downloaded)
+	s.Annotate("user:a", data, true)
+	assert.Len(t, data.Torrents, 2)
+}
+
+func TestAnnotate_UpdatesSeenTime(t *testing.T) {
+	s := newTestStore(t)
+
+	result := &hibiketorrent.AnimeTorrent{Provider: "nyaa", Name: "[Group] Show - 01", InfoHash: "abc"}
+	s.Annotate("user:a", newSearchData(result), false)
+
+	seenAt := time.Now().Add(-2 * sessionWindow)
+	require.NoError(t, s.database.UpsertTorrentResultHistory([]*models.TorrentResultHistory{
+		{Owner: "user:a", Key: Key(result), SeenAt: &seenAt},
+	}, []string{"seen_at"}))
+
+	s.Annotate("user:a", newSearchData(result), false)
+
+	entries, err := s.database.GetTorrentResultHistory("user:a", []string{Key(result)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NotNil(t, entries[0].PreviousSeenAt)
+	assert.WithinDuration(t, seenAt, *entries[0].PreviousSeenAt, time.Second)
+	assert.WithinDuration(t, time.Now(), *entries[0].SeenAt, time.Minute)
+}
+
+func TestRecordDownload(t *testing.T) {
+	s := newTestStore(t)
+
+	result := &hibiketorrent.AnimeTorrent{Name: "[Group] Show - 01", InfoHash: "abc"}
+	s.Annotate("user:a", newSearchData(result), false)
+
+	entry, err := s.RecordDownload("user:a", result)
+	require.NoError(t, err)
+	assert.NotZero(t, entry.ID)
+	require.NotNil(t, entry.DownloadedAt)
+	// The entry of the seen result is updated
+	assert.NotNil(t, entry.SeenAt)
+
+	again, err := s.RecordDownload("user:a", result)
+	require.NoError(t, err)
+	assert.Equal(t, entry.ID, again.ID)
+}
@@ -4,18 +4,21 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"maps"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/metadata"
 	"seanime/internal/debrid/debrid"
 	"seanime/internal/extension"
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/library/anime"
+	torrent_audio "seanime/internal/torrents/audio"
 	"seanime/internal/util"
 	"seanime/internal/util/comparison"
 	"seanime/internal/util/result"
 	"slices"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/5rahim/habari"
 	"github.com/samber/lo"
@@ -27,6 +30,9 @@ const (
 	AnimeSearchTypeSimple AnimeSearchType = "simple"
 )
 
+// maxSearchPages is the maximum number of pages included in the results
+const maxSearchPages = 10
+
 var (
 	metadataCache = result.NewMap[string, *TorrentMetadata]()
 )
@@ -46,6 +52,8 @@ type (
 		EpisodeNumber int
 		BestReleases  bool
 		Resolution    string
+		// Cursor of the page to load, the results of the previous pages are included
+		Cursor string
 	}
 
 	// Preview contains the torrent and episode information
@@ -55,8 +63,9 @@ type (
 	}
 
 	TorrentMetadata struct {
-		Distance int              `json:"distance"`
-		Metadata *habari.Metadata `json:"metadata"`
+		Distance int                `json:"distance"`
+		Metadata *habari.Metadata   `json:"metadata"`
+		Audio    torrent_audio.Kind `json:"audio"` // Audio detected from the name, used to show dub availability
 	}
 
 	// SearchData is the struct returned by NewSmartSearch
@@ -66,6 +75,20 @@ type (
 		TorrentMetadata           map[string]*TorrentMetadata                      `json:"torrentMetadata"`           // Torrent metadata
 		DebridInstantAvailability map[string]debrid.TorrentItemInstantAvailability `json:"debridInstantAvailability"` // Debrid instant availability
 		AnimeMetadata             *metadata.AnimeMetadata                          `json:"animeMetadata"`             // Animap media
+		NextCursor                string                                           `json:"nextCursor"`                // Cursor of the next page, empty if there are no more results
+		// PreviouslySeen maps the results that were shown in a previous search to the time they were shown, see torrent_history.Key
+		PreviouslySeen map[string]time.Time `json:"previouslySeen,omitempty"`
+	}
+
+	// animeSearch holds the resolved parameters of a search, shared by all its pages
+	animeSearch struct {
+		providerExtension extension.AnimeTorrentProviderExtension
+		opts              *AnimeSearchOptions
+		queryMedia        hibiketorrent.Media
+		animeMetadata     mo.Option[*metadata.AnimeMetadata]
+		anidbAID          int
+		anidbEID          int
+		queryKey          string
 	}
 )
 
@@ -88,8 +111,6 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 		return nil, fmt.Errorf("provider does not support smart search")
 	}
 
-	var torrents []*hibiketorrent.AnimeTorrent
-
 	// Fetch Animap media
 	animeMetadata := mo.None[*metadata.AnimeMetadata]()
 	animeMetadataF, err := r.metadataProviderRef.Get().GetAnimeMetadata(metadata.AnilistPlatform, opts.Media.GetID())
@@ -120,89 +141,96 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 	//	opts.Type = AnimeSearchTypeSimple
 	//}
 
-	var queryKey string
+	search := &animeSearch{
+		providerExtension: providerExtension,
+		opts:              &opts,
+		queryMedia:        queryMedia,
+		animeMetadata:     animeMetadata,
+	}
 
 	switch opts.Type {
 	case AnimeSearchTypeSmart:
-		anidbAID := 0
-		anidbEID := 0
-
 		// Get the AniDB Anime ID and Episode ID
 		if animeMetadata.IsPresent() {
 			// Override absolute offset value of queryMedia
-			queryMedia.AbsoluteSeasonOffset = animeMetadata.MustGet().GetOffset()
+			search.queryMedia.AbsoluteSeasonOffset = animeMetadata.MustGet().GetOffset()
 
 			if animeMetadata.MustGet().GetMappings() != nil {
 
-				anidbAID = animeMetadata.MustGet().GetMappings().AnidbId
+				search.anidbAID = animeMetadata.MustGet().GetMappings().AnidbId
 				// Find Animap Episode based on inputted episode number
 				episodeMetadata, found := animeMetadata.MustGet().FindEpisode(strconv.Itoa(opts.EpisodeNumber))
 				if found {
-					anidbEID = episodeMetadata.AnidbEid
+					search.anidbEID = episodeMetadata.AnidbEid
 				}
 			}
 		}
 
-		queryKey = fmt.Sprintf("%d-%s-%d-%d-%d-%s-%t-%t", opts.Media.GetID(), opts.Query, opts.EpisodeNumber, anidbAID, anidbEID, opts.Resolution, opts.BestReleases, opts.Batch)
-		if cache, found := r.animeProviderSmartSearchCaches.Get(opts.Provider); found {
-			// Check the cache
-			data, found := cache.Get(queryKey)
-			if found {
-				r.logger.Debug().Str("provider", opts.Provider).Str("type", string(opts.Type)).Msg("torrent repo: Cache HIT")
-				return data, nil
-			}
-		}
-
-		// Check for context cancellation before making the request
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
+		search.queryKey = fmt.Sprintf("%d-%s-%d-%d-%d-%s-%t-%t", opts.Media.GetID(), opts.Query, opts.EpisodeNumber, search.anidbAID, search.anidbEID, opts.Resolution, opts.BestReleases, opts.Batch)
+	case AnimeSearchTypeSimple:
+		search.queryKey = fmt.Sprintf("%d-%s", opts.Media.GetID(), opts.Query)
+	}
 
-		torrents, err = providerExtension.GetProvider().SmartSearch(hibiketorrent.AnimeSmartSearchOptions{
-			Media:         queryMedia,
-			Query:         opts.Query,
-			Batch:         opts.Batch,
-			EpisodeNumber: opts.EpisodeNumber,
-			Resolution:    opts.Resolution,
-			AnidbAID:      anidbAID,
-			AnidbEID:      anidbEID,
-			BestReleases:  opts.BestReleases,
-		})
+	ret, err = r.searchAnimePage(ctx, search, "")
+	if err != nil || opts.Cursor == "" {
+		return ret, err
+	}
 
-		torrents = lo.UniqBy(torrents, func(t *hibiketorrent.AnimeTorrent) string {
-			return t.InfoHash
-		})
+	// Interleave the requested page with the previous ones.
+	// The previous pages are cached so this only fetches the requested page.
+	pages := []*SearchData{ret}
+	last := ret
+	for last.NextCursor != "" && last.NextCursor != opts.Cursor && len(pages) < maxSearchPages-1 {
+		last, err = r.searchAnimePage(ctx, search, last.NextCursor)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, last)
+	}
 
-	case AnimeSearchTypeSimple:
+	page, err := r.searchAnimePage(ctx, search, opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	pages = append(pages, page)
 
-		queryKey = fmt.Sprintf("%d-%s", opts.Media.GetID(), opts.Query)
-		if cache, found := r.animeProviderSearchCaches.Get(opts.Provider); found {
-			// Check the cache
-			data, found := cache.Get(queryKey)
-			if found {
-				r.logger.Debug().Str("provider", opts.Provider).Str("type", string(opts.Type)).Msg("torrent repo: Cache HIT")
-				return data, nil
-			}
-		}
+	return mergeSearchPages(pages), nil
+}
 
-		// Check for context cancellation before making the request
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+// searchAnimePage returns a page of results.
+// Each page is cached separately, the first page has an empty cursor.
+func (r *Repository) searchAnimePage(ctx context.Context, search *animeSearch, cursor string) (ret *SearchData, err error) {
+	opts := search.opts
+	animeMetadata := search.animeMetadata
+
+	cacheKey := search.queryKey + "-" + cursor
+	if cache, found := r.getSearchCache(opts.Type, opts.Provider); found {
+		// Check the cache
+		data, found := cache.Get(cacheKey)
+		if found {
+			r.logger.Debug().Str("provider", opts.Provider).Str("type", string(opts.Type)).Str("cursor", cursor).Msg("torrent repo: Cache HIT")
+			return data, nil
 		}
+	}
 
-		torrents, err = providerExtension.GetProvider().Search(hibiketorrent.AnimeSearchOptions{
-			Media: queryMedia,
-			Query: opts.Query,
-		})
+	// Check for context cancellation before making the request
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
 	}
+
+	torrents, nextCursor, err := search.fetch(cursor)
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.Type == AnimeSearchTypeSmart {
+		torrents = lo.UniqBy(torrents, func(t *hibiketorrent.AnimeTorrent) string {
+			return t.InfoHash
+		})
+	}
+
 	//
 	// Torrent metadata
 	//
@@ -226,6 +254,7 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 				metadata = &TorrentMetadata{
 					Distance: distance.Distance,
 					Metadata: m,
+					Audio:    torrent_audio.Detect(t.Name, m),
 				}
 				metadataCache.Set(t.Name, metadata)
 			}
@@ -260,10 +289,12 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 					torrent:       t,
 					media:         opts.Media,
 					animeMetadata: animeMetadata,
-					searchOpts:    &opts,
+					searchOpts:    opts,
 				})
 				if preview != nil {
+					mu.Lock()
 					previews = append(previews, preview)
+					mu.Unlock()
 				}
 			}(t)
 		}
@@ -277,21 +308,16 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 		}
 	}
 
-	// sort both by seeders
-	slices.SortFunc(torrents, func(i, j *hibiketorrent.AnimeTorrent) int {
-		return cmp.Compare(j.Seeders, i.Seeders)
-	})
 	previews = lo.Filter(previews, func(p *Preview, _ int) bool {
 		return p != nil && p.Torrent != nil
 	})
-	slices.SortFunc(previews, func(i, j *Preview) int {
-		return cmp.Compare(j.Torrent.Seeders, i.Torrent.Seeders)
-	})
+	sortSearchResults(torrents, previews)
 
 	ret = &SearchData{
 		Torrents:        torrents,
 		Previews:        previews,
 		TorrentMetadata: torrentMetadata,
+		NextCursor:      nextCursor,
 	}
 
 	if animeMetadata.IsPresent() {
@@ -299,18 +325,132 @@ func (r *Repository) SearchAnime(ctx context.Context, opts AnimeSearchOptions) (
 	}
 
 	// Store the data in the cache
-	switch opts.Type {
+	if cache, found := r.getSearchCache(opts.Type, opts.Provider); found {
+		cache.Set(cacheKey, ret)
+	}
+
+	return
+}
+
+// fetch calls the provider for the page of the given cursor.
+// Providers that do not support pagination only return the first page.
+func (s *animeSearch) fetch(cursor string) (torrents []*hibiketorrent.AnimeTorrent, nextCursor string, err error) {
+	provider := s.providerExtension.GetProvider()
+	paginatedProvider, canPaginate := provider.(hibiketorrent.AnimePaginatedProvider)
+	if cursor != "" && !canPaginate {
+		return nil, "", fmt.Errorf("provider does not support pagination")
+	}
+
+	var page *hibiketorrent.AnimeSearchPage
+
+	switch s.opts.Type {
 	case AnimeSearchTypeSmart:
-		if cache, found := r.animeProviderSmartSearchCaches.Get(opts.Provider); found {
-			cache.Set(queryKey, ret)
+		searchOpts := hibiketorrent.AnimeSmartSearchOptions{
+			Media:         s.queryMedia,
+			Query:         s.opts.Query,
+			Batch:         s.opts.Batch,
+			EpisodeNumber: s.opts.EpisodeNumber,
+			Resolution:    s.opts.Resolution,
+			AnidbAID:      s.anidbAID,
+			AnidbEID:      s.anidbEID,
+			BestReleases:  s.opts.BestReleases,
+			Cursor:        cursor,
+		}
+		if !canPaginate {
+			torrents, err = provider.SmartSearch(searchOpts)
+			return torrents, "", err
 		}
+		page, err = paginatedProvider.SmartSearchPage(searchOpts)
 	case AnimeSearchTypeSimple:
-		if cache, found := r.animeProviderSearchCaches.Get(opts.Provider); found {
-			cache.Set(queryKey, ret)
+		searchOpts := hibiketorrent.AnimeSearchOptions{
+			Media:  s.queryMedia,
+			Query:  s.opts.Query,
+			Cursor: cursor,
 		}
+		if !canPaginate {
+			torrents, err = provider.Search(searchOpts)
+			return torrents, "", err
+		}
+		page, err = paginatedProvider.SearchPage(searchOpts)
+	}
+	if err != nil || page == nil {
+		return nil, "", err
 	}
 
-	return
+	// Guard against providers returning the same cursor
+	if page.NextCursor == cursor {
+		page.NextCursor = ""
+	}
+
+	return page.Torrents, page.NextCursor, nil
+}
+
+func (r *Repository) getSearchCache(searchType AnimeSearchType, provider string) (*result.Cache[string, *SearchData], bool) {
+	switch searchType {
+	case AnimeSearchTypeSmart:
+		return r.animeProviderSmartSearchCaches.Get(provider)
+	case AnimeSearchTypeSimple:
+		return r.animeProviderSearchCaches.Get(provider)
+	}
+	return nil, false
+}
+
+// mergeSearchPages combines pages of results into one, the pages are not modified.
+func mergeSearchPages(pages []*SearchData) *SearchData {
+	ret := &SearchData{
+		Torrents:        make([]*hibiketorrent.AnimeTorrent, 0),
+		Previews:        make([]*Preview, 0),
+		TorrentMetadata: make(map[string]*TorrentMetadata),
+	}
+
+	seenTorrents := make(map[string]struct{})
+	seenPreviews := make(map[string]struct{})
+	for _, page := range pages {
+		for _, t := range page.Torrents {
+			if _, found := seenTorrents[getTorrentKey(t)]; found {
+				continue
+			}
+			seenTorrents[getTorrentKey(t)] = struct{}{}
+			ret.Torrents = append(ret.Torrents, t)
+		}
+		for _, p := range page.Previews {
+			if _, found := seenPreviews[getTorrentKey(p.Torrent)]; found {
+				continue
+			}
+			seenPreviews[getTorrentKey(p.Torrent)] = struct{}{}
+			ret.Previews = append(ret.Previews, p)
+		}
+		maps.Copy(ret.TorrentMetadata, page.TorrentMetadata)
+		if page.AnimeMetadata != nil {
+			ret.AnimeMetadata = page.AnimeMetadata
+		}
+		ret.NextCursor = page.NextCursor
+	}
+
+	sortSearchResults(ret.Torrents, ret.Previews)
+
+	return ret
+}
+
+// getTorrentKey returns the key used to deduplicate torrents across pages
+func getTorrentKey(t *hibiketorrent.AnimeTorrent) string {
+	if t.InfoHash != "" {
+		return t.InfoHash
+	}
+	if t.Link != "" {
+		return t.Link
+	}
+	return t.Name
+}
+
+// sortSearchResults sorts both torrents and previews by seeders
+func sortSearchResults(torrents []*hibiketorrent.AnimeTorrent, previews []*Preview) {
+	slices.SortFunc(torrents, func(i, j *hibiketorrent.AnimeTorrent) int {
+		return cmp.Compare(j.Seeders, i.Seeders)
+	})
+	slices.SortFunc(previews, func(i, j *Preview) int {
+		return cmp.Compare(j.Torrent.Seeders, i.Torrent.Seeders)
+	})
 }
 
 type createAnimeTorrentPreviewOptions struct {
@@ -329,6 +469,7 @@ func (r *Repository) createAnimeTorrentPreview(opts createAnimeTorrentPreviewOpt
 		metadataCache.Set(opts.torrent.Name, &TorrentMetadata{
 			Distance: 1000,
 			Metadata: parsedData,
+			Audio:    torrent_audio.Detect(opts.torrent.Name, parsedData),
 		})
 	}
 	parsedData = metadata.Metadata
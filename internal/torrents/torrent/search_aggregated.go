@@ -0,0 +1,89 @@
+package torrent
+
+import (
+	"context"
+	"errors"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+type (
+	// AggregatedSearchOptions runs the same search on multiple providers.
+	AggregatedSearchOptions struct {
+		Providers []string
+		AnimeSearchOptions
+		// MinSize and MaxSize filter the results by size in bytes, 0 means no limit.
+		// Torrents with an unknown size are kept.
+		MinSize int64
+		MaxSize int64
+	}
+)
+
+// SearchAnimeAggregated searches the providers concurrently and merges the results.
+// It only fails if every provider fails. The results cannot be paginated.
+func (r *Repository) SearchAnimeAggregated(ctx context.Context, opts AggregatedSearchOptions) (*SearchData, error) {
+	if len(opts.Providers) == 0 {
+		return nil, errors.New("no provider selected")
+	}
+
+	pages := make([]*SearchData, len(opts.Providers))
+	errs := make([]error, len(opts.Providers))
+
+	wg := sync.WaitGroup{}
+	for i, provider := range opts.Providers {
+		wg.Add(1)
+		go func(i int, provider string) {
+			defer wg.Done()
+			searchOpts := opts.AnimeSearchOptions
+			searchOpts.Provider = provider
+			searchOpts.Cursor = ""
+			pages[i], errs[i] = r.SearchAnime(ctx, searchOpts)
+			if errs[i] != nil {
+				r.logger.Warn().Err(errs[i]).Str("provider", provider).Msg("torrent repo: Aggregated search failed for provider")
+			}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	pages = lo.Filter(pages, func(p *SearchData, _ int) bool { return p != nil })
+	if len(pages) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	ret := mergeSearchPages(pages)
+	ret.NextCursor = ""
+
+	keep := func(t *hibiketorrent.AnimeTorrent) bool {
+		return t != nil && matchesResolution(t, opts.Resolution) && matchesSize(t, opts.MinSize, opts.MaxSize)
+	}
+	ret.Torrents = lo.Filter(ret.Torrents, func(t *hibiketorrent.AnimeTorrent, _ int) bool { return keep(t) })
+	ret.Previews = lo.Filter(ret.Previews, func(p *Preview, _ int) bool { return keep(p.Torrent) })
+
+	return ret, nil
+}
+
+func matchesResolution(t *hibiketorrent.AnimeTorrent, resolution string) bool {
+	if resolution == "" {
+		return true
+	}
+	if t.Resolution != "" {
+		return strings.Contains(t.Resolution, resolution)
+	}
+	return strings.Contains(t.Name, resolution)
+}
+
+func matchesSize(t *hibiketorrent.AnimeTorrent, minSize int64, maxSize int64) bool {
+	if t.Size <= 0 {
+		return true
+	}
+	if minSize > 0 && t.Size < minSize {
+		return false
+	}
+	if maxSize > 0 && t.Size > maxSize {
+		return false
+	}
+	return true
+}
@@ -2,9 +2,18 @@ package torrent
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/anacrolix/torrent/metainfo"
 )
 
+var (
+	ErrInvalidMagnet   = errors.New("invalid magnet link")
+	ErrInvalidInfoHash = errors.New("invalid info hash, expected 40 hexadecimal or 32 base32 characters")
+)
+
 func StrDataToMagnetLink(data string) (string, error) {
 	meta, err := metainfo.Load(bytes.NewReader([]byte(data)))
 	if err != nil {
@@ -18,3 +27,23 @@ func StrDataToMagnetLink(data string) (string, error) {
 
 	return magnetLink.String(), nil
 }
+
+// ParseMagnetOrInfoHash returns the magnet link and the lower-case hexadecimal info hash of a magnet link or a v1 info hash.
+// A magnet link is built from the info hash.
+func ParseMagnetOrInfoHash(s string) (magnet string, infoHash string, err error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(strings.ToLower(s), "magnet:") {
+		m, err := metainfo.ParseMagnetUri(s)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrInvalidMagnet, err)
+		}
+		return s, m.InfoHash.HexString(), nil
+	}
+
+	m, err := metainfo.ParseMagnetUri("magnet:?xt=urn:btih:" + s)
+	if err != nil || strings.ContainsAny(s, "&?=") {
+		return "", "", ErrInvalidInfoHash
+	}
+	return m.String(), m.InfoHash.HexString(), nil
+}
@@ -1,8 +1,10 @@
 package torrent
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -42,3 +44,42 @@ func TestFileToMagnetLink(t *testing.T) {
 	}
 
 }
+
+func TestParseMagnetOrInfoHash(t *testing.T) {
+	hash := "08ada5a7a6183aae1e09d831df6748d566095a10"
+
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr error
+	}{
+		{name: "magnet", input: "magnet:?xt=urn:btih:" + hash + "&dn=Sintel"},
+		{name: "uppercase hash", input: " " + strings.ToUpper(hash) + " "},
+		{name: "base32 hash", input: "BCW2LJ5GDA5K4HQJ3AY56Z2I2VTASWQQ"},
+		{name: "magnet without info hash", input: "magnet:?dn=Sintel", expectedErr: ErrInvalidMagnet},
+		{name: "magnet with a bad info hash", input: "magnet:?xt=urn:btih:1234", expectedErr: ErrInvalidMagnet},
+		{name: "short hash", input: "08ada5a7", expectedErr: ErrInvalidInfoHash},
+		{name: "url", input: "https://example.com/a.torrent", expectedErr: ErrInvalidInfoHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			magnet, infoHash, err := ParseMagnetOrInfoHash(tt.input)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if infoHash != hash {
+				t.Errorf("expected info hash %s, got %s", hash, infoHash)
+			}
+			if !strings.HasPrefix(magnet, "magnet:?") {
+				t.Errorf("expected a magnet link, got %s", magnet)
+			}
+		})
+	}
+}
@@ -112,6 +112,13 @@ func (c *Client) initializeClient() error {
 		settings.TorrentClientPort = 43213
 	}
 	cfg.ListenPort = settings.TorrentClientPort
+
+	// Bind the traffic to the interface or proxy, fail if the interface is down
+	proxyDialer, err := c.applyNetworkBinding(cfg)
+	if err != nil {
+		return err
+	}
+
 	// Set the download directory
 	// e.g. /path/to/temp/seanime/torrentstream/{infohash}
 	cfg.DefaultStorage = storage.NewFileByInfoHash(settings.DownloadDir)
@@ -123,6 +130,9 @@ func (c *Client) initializeClient() error {
 		c.mu.Unlock()
 		return fmt.Errorf("error creating a new torrent client: %v", err)
 	}
+	if proxyDialer != nil {
+		client.AddDialer(proxyDialer)
+	}
 	c.repository.logger.Info().Msgf("torrentstream: Initialized torrent client on port %d", settings.TorrentClientPort)
 	c.torrentClient = mo.Some(client)
 	c.dropTorrents()
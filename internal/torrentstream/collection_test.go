@@ -6,6 +6,7 @@ import (
 	"seanime/internal/events"
 	"seanime/internal/library/anime"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/platform"
 	"seanime/internal/test_utils"
 	"seanime/internal/util"
 	"testing"
@@ -25,7 +26,7 @@ func TestStreamCollection(t *testing.T) {
 	anilistClient := anilist.TestGetMockAnilistClient()
 	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistClient, logger)
 	anilistPlatform.SetUsername(test_utils.ConfigData.Provider.AnilistUsername)
-	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), false)
+	animeCollection, err := anilistPlatform.GetAnimeCollection(t.Context(), platform.CachedCollection)
 	require.NoError(t, err)
 	require.NotNil(t, animeCollection)
 
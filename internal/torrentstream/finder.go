@@ -8,6 +8,7 @@ import (
 	hibiketorrent "seanime/internal/extension/hibike/torrent"
 	"seanime/internal/hook"
 	torrentanalyzer "seanime/internal/torrents/analyzer"
+	torrent_audio "seanime/internal/torrents/audio"
 	itorrent "seanime/internal/torrents/torrent"
 	"seanime/internal/util"
 	"seanime/internal/util/torrentutil"
@@ -21,6 +22,7 @@ import (
 var (
 	ErrNoTorrentsFound = fmt.Errorf("no torrents found, please select manually")
 	ErrNoEpisodeFound  = fmt.Errorf("could not select episode from torrents, please select manually")
+	ErrNoDubFound      = fmt.Errorf("no dubbed torrents found, please select manually")
 )
 
 type (
@@ -163,6 +165,12 @@ searchLoop:
 		return cmp.Compare(b.Seeders, a.Seeders)
 	})
 
+	// Filter and sort by the audio preference of the media
+	data.Torrents, err = r.applyAudioPreference(media.GetID(), data)
+	if err != nil {
+		return nil, err
+	}
+
 	// Trigger hook
 	fetchedEvent := &TorrentStreamAutoSelectTorrentsFetchedEvent{
 		Torrents: data.Torrents,
@@ -307,6 +315,50 @@ searchLoop:
 }
 
 // findBestTorrentFromManualSelection is like findBestTorrent but no need to search for the best torrent first
+// applyAudioPreference removes the torrents that do not match the audio preference of the media
+// and moves the ones that match it best to the top, keeping the seeders order otherwise.
+func (r *Repository) applyAudioPreference(mediaId int, data *itorrent.SearchData) ([]*hibiketorrent.AnimeTorrent, error) {
+	if r.db == nil {
+		return data.Torrents, nil
+	}
+
+	mediaPreference, err := r.db.GetMediaAudioPreference(mediaId)
+	if err != nil {
+		r.logger.Warn().Err(err).Msg("torrentstream: Failed to get audio preference")
+		return data.Torrents, nil
+	}
+	preference := torrent_audio.Preference(mediaPreference)
+	if preference == torrent_audio.PreferenceNone {
+		return data.Torrents, nil
+	}
+
+	getAudio := func(t *hibiketorrent.AnimeTorrent) torrent_audio.Kind {
+		if m, ok := data.TorrentMetadata[t.InfoHash]; ok && m != nil && m.Audio != "" {
+			return m.Audio
+		}
+		return torrent_audio.Detect(t.Name, nil)
+	}
+
+	ret := lo.Filter(data.Torrents, func(t *hibiketorrent.AnimeTorrent, _ int) bool {
+		return preference.Accepts(getAudio(t))
+	})
+	if len(ret) == 0 {
+		r.logger.Warn().Str("preference", string(preference)).Msg("torrentstream: No torrents match the audio preference")
+		if preference == torrent_audio.PreferenceDubRequired {
+			return nil, ErrNoDubFound
+		}
+		return nil, ErrNoTorrentsFound
+	}
+
+	slices.SortStableFunc(ret, func(a, b *hibiketorrent.AnimeTorrent) int {
+		return cmp.Compare(preference.Score(getAudio(b)), preference.Score(getAudio(a)))
+	})
+
+	r.logger.Debug().Str("preference", string(preference)).Msgf("torrentstream: %d torrents match the audio preference", len(ret))
+
+	return ret, nil
+}
+
 func (r *Repository) findBestTorrentFromManualSelection(t *hibiketorrent.AnimeTorrent, media *anilist.CompleteAnime, aniDbEpisode string, chosenFileIndex *int) (*playbackTorrent, error) {
 
 	r.logger.Debug().Msgf("torrentstream: Analyzing torrent from %s for %s", t.Link, media.GetTitleSafe())
@@ -0,0 +1,84 @@
+package torrentstream
+
+import (
+	"net/http"
+	"seanime/internal/torrent_clients/network_binding"
+
+	"github.com/anacrolix/torrent"
+)
+
+// applyNetworkBinding binds the listeners and outgoing connections of the torrent client to the configured interface.
+// If a SOCKS5 proxy is set, peers are only reached through the proxy: incoming connections, uTP and DHT are disabled
+// because they cannot go through it, and the returned dialer must be added to the client.
+func (c *Client) applyNetworkBinding(cfg *torrent.ClientConfig) (torrent.Dialer, error) {
+	nb := c.repository.networkBinding
+	if nb == nil {
+		return nil, nil
+	}
+
+	settings := nb.GetSettings()
+	localAddr, err := nb.ResolveAddress()
+	if err != nil {
+		return nil, err
+	}
+	proxyUrl, err := network_binding.ParseSocks5Proxy(settings.Socks5Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if localAddr == nil && proxyUrl == nil {
+		return nil, nil
+	}
+
+	dialer, err := network_binding.Dialer(localAddr, proxyUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if localAddr != nil {
+		host := localAddr.String()
+		cfg.ListenHost = func(string) string { return host }
+		cfg.DisableIPv6 = cfg.DisableIPv6 || localAddr.To4() != nil
+	}
+	cfg.TrackerDialContext = dialer.DialContext
+	cfg.HTTPDialContext = dialer.DialContext
+
+	if proxyUrl == nil {
+		c.repository.logger.Info().Str("address", localAddr.String()).Msg("torrentstream: Torrent client bound to address")
+		return nil, nil
+	}
+
+	cfg.HTTPProxy = http.ProxyURL(proxyUrl)
+	cfg.DisableTCP = true
+	cfg.DisableUTP = true
+	cfg.NoDHT = true
+	cfg.AcceptPeerConnections = false
+	cfg.NoDefaultPortForwarding = true
+
+	c.repository.logger.Info().Str("proxy", proxyUrl.Host).Msg("torrentstream: Torrent client connecting through SOCKS5 proxy")
+	return torrent.NetworkDialer{Network: "tcp", Dialer: dialer}, nil
+}
+
+// PauseAll stops downloading and uploading data for all torrents of the embedded client.
+func (r *Repository) PauseAll() error {
+	if r.client == nil || r.client.torrentClient.IsAbsent() {
+		return nil
+	}
+	for _, t := range r.client.torrentClient.MustGet().Torrents() {
+		t.DisallowDataDownload()
+		t.DisallowDataUpload()
+	}
+	return nil
+}
+
+// ResumeAll allows downloading and uploading data for all torrents of the embedded client.
+func (r *Repository) ResumeAll() error {
+	if r.client == nil || r.client.torrentClient.IsAbsent() {
+		return nil
+	}
+	for _, t := range r.client.torrentClient.MustGet().Torrents() {
+		t.AllowDataDownload()
+		t.AllowDataUpload()
+	}
+	r.logger.Debug().Msg("torrentstream: Resumed all torrents")
+	return nil
+}
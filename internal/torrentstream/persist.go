@@ -0,0 +1,151 @@
+package torrentstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	hibiketorrent "seanime/internal/extension/hibike/torrent"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+)
+
+var (
+	ErrNoStreamSession      = errors.New("torrentstream: no episode was streamed")
+	ErrStreamFileIncomplete = errors.New("torrentstream: the episode has not been fully downloaded yet")
+)
+
+type (
+	// StreamSession is the last episode streamed.
+	StreamSession struct {
+		MediaId       int `json:"mediaId"`
+		EpisodeNumber int `json:"episodeNumber"`
+		// Torrent is the selected torrent, only the name and info hash are known if it was auto-selected
+		Torrent *hibiketorrent.AnimeTorrent `json:"torrent"`
+		// FilePath is the path of the streamed file in the torrent
+		FilePath string `json:"filePath"`
+	}
+
+	// PersistedStreamFile is the file of a stream that was copied to the library.
+	PersistedStreamFile struct {
+		Session *StreamSession `json:"session"`
+		// Path is the path of the copied file
+		Path string `json:"path"`
+	}
+)
+
+func newStreamSession(opts *StartStreamOptions, pt *playbackTorrent) *StreamSession {
+	infoHash := pt.Torrent.InfoHash().HexString()
+
+	var t hibiketorrent.AnimeTorrent
+	if opts.Torrent != nil {
+		t = *opts.Torrent
+	} else {
+		t = hibiketorrent.AnimeTorrent{Name: pt.Torrent.Name()}
+	}
+	if t.InfoHash == "" {
+		t.InfoHash = infoHash
+	}
+
+	return &StreamSession{
+		MediaId:       opts.MediaId,
+		EpisodeNumber: opts.EpisodeNumber,
+		Torrent:       &t,
+		FilePath:      pt.File.Path(),
+	}
+}
+
+// GetLastStreamSession returns the last episode streamed, it is kept after the stream is stopped.
+func (r *Repository) GetLastStreamSession() (*StreamSession, bool) {
+	return r.lastStreamSession.Get()
+}
+
+// PersistLastStream copies the file of the last stream from the download directory to the destination.
+// The path of the file in the torrent is kept. The file must have been fully downloaded.
+func (r *Repository) PersistLastStream(destination string) (*PersistedStreamFile, error) {
+	session, ok := r.lastStreamSession.Get()
+	if !ok {
+		return nil, ErrNoStreamSession
+	}
+
+	if !filepath.IsAbs(destination) {
+		return nil, errors.New("torrentstream: destination must be an absolute path")
+	}
+
+	file, err := r.findStreamFile(session)
+	if err != nil {
+		return nil, err
+	}
+	if file.BytesCompleted() < file.Length() {
+		return nil, ErrStreamFileIncomplete
+	}
+
+	dest := filepath.Join(destination, filepath.FromSlash(file.Path()))
+	if rel, err := filepath.Rel(destination, dest); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("torrentstream: invalid file path %s", file.Path())
+	}
+
+	if err := copyTorrentFile(file, dest); err != nil {
+		return nil, err
+	}
+
+	r.logger.Info().Int("mediaId", session.MediaId).Str("path", dest).Msg("torrentstream: Persisted streamed file")
+
+	return &PersistedStreamFile{
+		Session: session,
+		Path:    dest,
+	}, nil
+}
+
+// findStreamFile returns the file of the session if its torrent is still in the client.
+func (r *Repository) findStreamFile(session *StreamSession) (*torrent.File, error) {
+	if r.client == nil || r.client.torrentClient.IsAbsent() {
+		return nil, ErrNoStreamSession
+	}
+
+	for _, t := range r.client.torrentClient.MustGet().Torrents() {
+		if t.Info() == nil || !strings.EqualFold(t.InfoHash().HexString(), session.Torrent.InfoHash) {
+			continue
+		}
+		for _, f := range t.Files() {
+			if f.Path() == session.FilePath {
+				return f, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("torrentstream: the torrent of the last stream was removed from the client")
+}
+
+// copyTorrentFile writes the file to a temporary file next to the destination and renames it once complete.
+func copyTorrentFile(file *torrent.File, dest string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	reader := file.NewReader()
+	defer reader.Close()
+
+	tmp := dest + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = io.Copy(out, reader); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
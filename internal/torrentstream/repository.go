@@ -17,6 +17,7 @@ import (
 	"seanime/internal/mediaplayers/mediaplayer"
 	"seanime/internal/nativeplayer"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/torrent_clients/network_binding"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/util"
 	"seanime/internal/util/result"
@@ -49,10 +50,13 @@ type (
 		nativePlayer                    *nativeplayer.NativePlayer
 		logger                          *zerolog.Logger
 		db                              *db.Database
+		networkBinding                  *network_binding.Manager
 
 		onEpisodeCollectionChanged func(ec *anime.EpisodeCollection)
 
 		previousStreamOptions mo.Option[*StartStreamOptions]
+		// lastStreamSession is kept after the stream is stopped so that its file can be persisted to the library
+		lastStreamSession mo.Option[*StreamSession]
 	}
 
 	Settings struct {
@@ -73,6 +77,8 @@ type (
 		Database            *db.Database
 		DirectStreamManager *directstream.Manager
 		NativePlayer        *nativeplayer.NativePlayer
+		// NetworkBinding binds the embedded torrent client to an interface or proxy (optional)
+		NetworkBinding *network_binding.Manager
 	}
 )
 
@@ -96,7 +102,9 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		db:                              opts.Database,
 		directStreamManager:             opts.DirectStreamManager,
 		nativePlayer:                    opts.NativePlayer,
+		networkBinding:                  opts.NetworkBinding,
 		previousStreamOptions:           mo.None[*StartStreamOptions](),
+		lastStreamSession:               mo.None[*StreamSession](),
 	}
 	ret.client = NewClient(ret)
 	ret.handler = newHandler(ret)
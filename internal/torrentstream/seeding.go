@@ -0,0 +1,63 @@
+package torrentstream
+
+import (
+	"github.com/anacrolix/torrent"
+)
+
+// GetSeedingTorrents returns the torrents of the embedded client that are complete and only uploading.
+// Torrents whose upload was disallowed by [PauseSeeding] are not returned.
+func (r *Repository) GetSeedingTorrents() []*torrent.Torrent {
+	if r.client == nil || r.client.torrentClient.IsAbsent() {
+		return nil
+	}
+
+	ret := make([]*torrent.Torrent, 0)
+	for _, t := range r.client.torrentClient.MustGet().Torrents() {
+		if t.Info() == nil || t.BytesMissing() > 0 || !t.Seeding() {
+			continue
+		}
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+// PauseSeeding stops uploading data for the given torrents.
+// Torrents that are being streamed are skipped.
+func (r *Repository) PauseSeeding(infoHashes []string) error {
+	return r.setSeeding(infoHashes, false)
+}
+
+// ResumeSeeding allows uploading data for the given torrents.
+func (r *Repository) ResumeSeeding(infoHashes []string) error {
+	return r.setSeeding(infoHashes, true)
+}
+
+func (r *Repository) setSeeding(infoHashes []string, allow bool) error {
+	if r.client == nil || r.client.torrentClient.IsAbsent() {
+		return nil
+	}
+
+	current := ""
+	if t, ok := r.client.currentTorrent.Get(); ok && !allow {
+		current = t.InfoHash().HexString()
+	}
+
+	for _, hash := range infoHashes {
+		if hash == current {
+			continue
+		}
+		for _, t := range r.client.torrentClient.MustGet().Torrents() {
+			if t.InfoHash().HexString() != hash {
+				continue
+			}
+			if allow {
+				t.AllowDataUpload()
+			} else {
+				t.DisallowDataUpload()
+			}
+		}
+	}
+
+	r.logger.Debug().Bool("allow", allow).Any("hashes", infoHashes).Msg("torrentstream: Updated seeding")
+	return nil
+}
@@ -40,6 +40,7 @@ type StartStreamOptions struct {
 	PlaybackType       PlaybackType
 	IsNakamaWatchParty bool // If this is a nakama stream (watch party)
 	BatchEpisodeFiles  *hibiketorrent.BatchEpisodeFiles
+	Incognito          bool // Do not update the progress when using the media player, see [playbackmanager.PlaybackManager.SetIncognito]
 }
 
 // StartStream is called by the client to start streaming a torrent
@@ -48,6 +49,12 @@ func (r *Repository) StartStream(ctx context.Context, opts *StartStreamOptions)
 	// DEVNOTE: Do not
 	//r.Shutdown()
 
+	if r.networkBinding != nil {
+		if err := r.networkBinding.CheckAllowed(); err != nil {
+			return err
+		}
+	}
+
 	r.previousStreamOptions = mo.Some(opts)
 
 	r.logger.Info().
@@ -116,6 +123,7 @@ func (r *Repository) StartStream(ctx context.Context, opts *StartStreamOptions)
 	//
 	r.client.currentFile = mo.Some(torrentToStream.File)
 	r.client.currentTorrent = mo.Some(torrentToStream.Torrent)
+	r.lastStreamSession = mo.Some(newStreamSession(opts, torrentToStream))
 
 	r.sendStateEvent(eventLoading, TLSStateSendingStreamToMediaPlayer)
 
@@ -257,6 +265,7 @@ func (r *Repository) sendStreamToExternalPlayer(opts *StartStreamOptions, comple
 			Payload:   streamURL,
 			UserAgent: opts.UserAgent,
 			ClientId:  opts.ClientId,
+			Incognito: opts.Incognito,
 		}, baseAnime, aniDbEpisode)
 		if err != nil {
 			// Failed to start the stream, we'll drop the torrents and stop the server
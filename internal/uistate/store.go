@@ -0,0 +1,396 @@
+package uistate
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/database/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+)
+
+// Store is a small key-value store the client uses to sync its UI state (filters, last viewed entry, scroll position...) across devices.
+// Keys and values are opaque to the server, it only enforces quotas and keeps track of when each key was last modified.
+//
+// The state of authenticated users is shared by all their sessions and persisted in the database.
+// The state of simulated users is scoped to their session and removed when the session expires.
+type Store struct {
+	mu          sync.Mutex
+	owners      map[string]*ownerState
+	persistence Persistence
+	logger      *zerolog.Logger
+
+	maxKeyLength  int
+	maxValueSize  int
+	maxOwnerSize  int
+	userRetention time.Duration
+}
+
+// Persistence stores the state of authenticated users.
+type Persistence interface {
+	GetAllUIStateEntries() ([]*models.UIStateEntry, error)
+	UpsertUIStateEntry(entry *models.UIStateEntry) error
+	DeleteUIStateEntry(owner string, key string) error
+	DeleteUIStateEntries(owner string) error
+}
+
+type (
+	// Owner is who a state belongs to.
+	Owner struct {
+		ID string
+		// Persistent is true for authenticated users
+		Persistent bool
+		// SessionID is set for session-scoped owners
+		SessionID string
+	}
+
+	// Entry is a key of the state.
+	Entry struct {
+		Key       string          `json:"key"`
+		Value     json.RawMessage `json:"value"`
+		UpdatedAt time.Time       `json:"updatedAt"`
+	}
+
+	// Usage is the space used by an owner.
+	Usage struct {
+		Keys         int `json:"keys"`
+		Size         int `json:"size"`
+		MaxSize      int `json:"maxSize"`
+		MaxValueSize int `json:"maxValueSize"`
+	}
+
+	// Change is sent to the other clients of an owner when a key is set or deleted.
+	Change struct {
+		Key       string          `json:"key"`
+		Value     json.RawMessage `json:"value,omitempty"`
+		Deleted   bool            `json:"deleted"`
+		UpdatedAt time.Time       `json:"updatedAt"`
+	}
+
+	ownerState struct {
+		entries map[string]*Entry
+		size    int
+	}
+
+	NewStoreOptions struct {
+		Persistence Persistence
+		Logger      *zerolog.Logger
+		// MaxKeyLength defaults to DefaultMaxKeyLength
+		MaxKeyLength int
+		// MaxValueSize defaults to DefaultMaxValueSize
+		MaxValueSize int
+		// MaxOwnerSize defaults to DefaultMaxOwnerSize
+		MaxOwnerSize int
+		// UserRetention is how long the state of a user that has not been modified is kept, it defaults to DefaultUserRetention
+		UserRetention time.Duration
+	}
+)
+
+const (
+	DefaultMaxKeyLength  = 128
+	DefaultMaxValueSize  = 64 * 1024
+	DefaultMaxOwnerSize  = 512 * 1024
+	DefaultUserRetention = 180 * 24 * time.Hour
+
+	userOwnerPrefix    = "user:"
+	sessionOwnerPrefix = "session:"
+)
+
+var (
+	ErrInvalidKey    = errors.New("ui state: invalid key")
+	ErrInvalidValue  = errors.New("ui state: value must be valid JSON")
+	ErrValueTooLarge = errors.New("ui state: value is too large")
+	ErrQuotaExceeded = errors.New("ui state: quota exceeded")
+	ErrConflict      = errors.New("ui state: key was modified by another client")
+)
+
+// UserOwner returns the owner for an authenticated user.
+func UserOwner(username string) Owner {
+	return Owner{ID: userOwnerPrefix + username, Persistent: true}
+}
+
+// SessionOwner returns the owner for a simulated user's session.
+func SessionOwner(sessionID string) Owner {
+	return Owner{ID: sessionOwnerPrefix + sessionID, SessionID: sessionID}
+}
+
+// Username returns the username of a user owner.
+func (o Owner) Username() string {
+	return strings.TrimPrefix(o.ID, userOwnerPrefix)
+}
+
+func NewStore(opts *NewStoreOptions) *Store {
+	s := &Store{
+		owners:        make(map[string]*ownerState),
+		persistence:   opts.Persistence,
+		logger:        opts.Logger,
+		maxKeyLength:  opts.MaxKeyLength,
+		maxValueSize:  opts.MaxValueSize,
+		maxOwnerSize:  opts.MaxOwnerSize,
+		userRetention: opts.UserRetention,
+	}
+	if s.maxKeyLength <= 0 {
+		s.maxKeyLength = DefaultMaxKeyLength
+	}
+	if s.maxValueSize <= 0 {
+		s.maxValueSize = DefaultMaxValueSize
+	}
+	if s.maxOwnerSize <= 0 {
+		s.maxOwnerSize = DefaultMaxOwnerSize
+	}
+	if s.userRetention <= 0 {
+		s.userRetention = DefaultUserRetention
+	}
+
+	s.load()
+
+	return s
+}
+
+// load reads the state of authenticated users from the database.
+func (s *Store) load() {
+	if s.persistence == nil {
+		return
+	}
+
+	entries, err := s.persistence.GetAllUIStateEntries()
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Error().Err(err).Msg("ui state: Failed to load state")
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range entries {
+		state := s.getOwner(e.Owner)
+		state.entries[e.Key] = &Entry{
+			Key:       e.Key,
+			Value:     json.RawMessage(e.Value),
+			UpdatedAt: e.UpdatedAt,
+		}
+		state.size += entrySize(e.Key, e.Value)
+	}
+}
+
+func (s *Store) getOwner(id string) *ownerState {
+	state, ok := s.owners[id]
+	if !ok {
+		state = &ownerState{entries: make(map[string]*Entry)}
+		s.owners[id] = state
+	}
+	return state
+}
+
+func entrySize(key string, value []byte) int {
+	return len(key) + len(value)
+}
+
+// Get returns a key of the owner's state.
+func (s *Store) Get(owner Owner, key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.owners[owner.ID]
+	if !ok {
+		return nil, false
+	}
+	e, ok := state.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return copyEntry(e), true
+}
+
+// GetAll returns the owner's state sorted by key.
+func (s *Store) GetAll(owner Owner) []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make([]*Entry, 0)
+	state, ok := s.owners[owner.ID]
+	if !ok {
+		return ret
+	}
+	for _, e := range state.entries {
+		ret = append(ret, copyEntry(e))
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Key < ret[j].Key
+	})
+	return ret
+}
+
+// GetUsage returns the space used by the owner.
+func (s *Store) GetUsage(owner Owner) *Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := &Usage{
+		MaxSize:      s.maxOwnerSize,
+		MaxValueSize: s.maxValueSize,
+	}
+	if state, ok := s.owners[owner.ID]; ok {
+		ret.Keys = len(state.entries)
+		ret.Size = state.size
+	}
+	return ret
+}
+
+// Set sets a key of the owner's state.
+// If baseUpdatedAt is set and the key has been modified after it, the key is not modified,
+// ErrConflict is returned along with the current entry so that the client can resolve the conflict.
+func (s *Store) Set(owner Owner, key string, value json.RawMessage, baseUpdatedAt *time.Time) (*Entry, error) {
+	if key == "" || len(key) > s.maxKeyLength {
+		return nil, ErrInvalidKey
+	}
+	if !json.Valid(value) {
+		return nil, ErrInvalidValue
+	}
+	if len(value) > s.maxValueSize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrValueTooLarge, len(value), s.maxValueSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.getOwner(owner.ID)
+	existing, exists := state.entries[key]
+
+	if exists && baseUpdatedAt != nil && existing.UpdatedAt.After(*baseUpdatedAt) {
+		return copyEntry(existing), ErrConflict
+	}
+
+	newSize := state.size + entrySize(key, value)
+	if exists {
+		newSize -= entrySize(key, existing.Value)
+	}
+	if newSize > s.maxOwnerSize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrQuotaExceeded, newSize, s.maxOwnerSize)
+	}
+
+	entry := &Entry{
+		Key:       key,
+		Value:     append(json.RawMessage(nil), value...),
+		UpdatedAt: time.Now().Round(0), // Strip the monotonic clock reading so that timestamps from clients compare correctly
+	}
+
+	if owner.Persistent && s.persistence != nil {
+		err := s.persistence.UpsertUIStateEntry(&models.UIStateEntry{
+			BaseModel: models.BaseModel{UpdatedAt: entry.UpdatedAt},
+			Owner:     owner.ID,
+			Key:       key,
+			Value:     entry.Value,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state.entries[key] = entry
+	state.size = newSize
+
+	return copyEntry(entry), nil
+}
+
+// Delete removes a key from the owner's state.
+// It returns false if the key did not exist.
+func (s *Store) Delete(owner Owner, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.owners[owner.ID]
+	if !ok {
+		return false, nil
+	}
+	existing, ok := state.entries[key]
+	if !ok {
+		return false, nil
+	}
+
+	if owner.Persistent && s.persistence != nil {
+		if err := s.persistence.DeleteUIStateEntry(owner.ID, key); err != nil {
+			return false, err
+		}
+	}
+
+	state.size -= entrySize(key, existing.Value)
+	delete(state.entries, key)
+	if len(state.entries) == 0 {
+		delete(s.owners, owner.ID)
+	}
+
+	return true, nil
+}
+
+// DeleteOwner removes the owner's state.
+func (s *Store) DeleteOwner(owner Owner) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteOwner(owner.ID)
+}
+
+func (s *Store) deleteOwner(id string) error {
+	if strings.HasPrefix(id, userOwnerPrefix) && s.persistence != nil {
+		if err := s.persistence.DeleteUIStateEntries(id); err != nil {
+			return err
+		}
+	}
+	delete(s.owners, id)
+	return nil
+}
+
+// Prune removes the state of expired sessions and of users that have not modified their state within the retention period.
+func (s *Store) Prune(sessionExists func(sessionID string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.userRetention)
+	pruned := 0
+
+	for id, state := range s.owners {
+		switch {
+		case strings.HasPrefix(id, sessionOwnerPrefix):
+			if sessionExists != nil && sessionExists(strings.TrimPrefix(id, sessionOwnerPrefix)) {
+				continue
+			}
+		case strings.HasPrefix(id, userOwnerPrefix):
+			if lastUpdatedAt(state).After(cutoff) {
+				continue
+			}
+		}
+		if err := s.deleteOwner(id); err != nil {
+			if s.logger != nil {
+				s.logger.Error().Err(err).Str("owner", id).Msg("ui state: Failed to prune state")
+			}
+			continue
+		}
+		pruned++
+	}
+
+	if pruned > 0 && s.logger != nil {
+		s.logger.Debug().Int("count", pruned).Msg("ui state: Pruned state")
+	}
+}
+
+func lastUpdatedAt(state *ownerState) time.Time {
+	var ret time.Time
+	for _, e := range state.entries {
+		if e.UpdatedAt.After(ret) {
+			ret = e.UpdatedAt
+		}
+	}
+	return ret
+}
+
+func copyEntry(e *Entry) *Entry {
+	ret := *e
+	ret.Value = append(json.RawMessage(nil), e.Value...)
+	return &ret
+}
@@ -0,0 +1,183 @@
+package uistate
+
+import (
+	"seanime/internal/database/models"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPersistence is an in-memory Persistence keyed by owner and key.
+type memoryPersistence struct {
+	entries map[string]*models.UIStateEntry
+}
+
+func newMemoryPersistence() *memoryPersistence {
+	return &memoryPersistence{entries: make(map[string]*models.UIStateEntry)}
+}
+
+func (m *memoryPersistence) GetAllUIStateEntries() ([]*models.UIStateEntry, error) {
+	ret := make([]*models.UIStateEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+func (m *memoryPersistence) UpsertUIStateEntry(entry *models.UIStateEntry) error {
+	m.entries[entry.Owner+"/"+entry.Key] = entry
+	return nil
+}
+
+func (m *memoryPersistence) DeleteUIStateEntry(owner string, key string) error {
+	delete(m.entries, owner+"/"+key)
+	return nil
+}
+
+func (m *memoryPersistence) DeleteUIStateEntries(owner string) error {
+	for k, e := range m.entries {
+		if e.Owner == owner {
+			delete(m.entries, k)
+		}
+	}
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	persistence := newMemoryPersistence()
+	store := NewStore(&NewStoreOptions{Persistence: persistence})
+
+	user := UserOwner("user")
+	other := UserOwner("other")
+
+	entry, err := store.Set(user, "library.filters", json.RawMessage(`{"genre":"Action"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "library.filters", entry.Key)
+	assert.False(t, entry.UpdatedAt.IsZero())
+
+	got, found := store.Get(user, "library.filters")
+	require.True(t, found)
+	assert.JSONEq(t, `{"genre":"Action"}`, string(got.Value))
+
+	// Other users do not see the state
+	_, found = store.Get(other, "library.filters")
+	assert.False(t, found)
+
+	// Persisted and loaded by a new store
+	assert.Len(t, persistence.entries, 1)
+	reloaded := NewStore(&NewStoreOptions{Persistence: persistence})
+	all := reloaded.GetAll(user)
+	require.Len(t, all, 1)
+	assert.JSONEq(t, `{"genre":"Action"}`, string(all[0].Value))
+
+	// Delete
+	deleted, err := store.Delete(user, "library.filters")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Empty(t, persistence.entries)
+	deleted, err = store.Delete(user, "library.filters")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestStore_Conflict(t *testing.T) {
+	store := NewStore(&NewStoreOptions{})
+	owner := UserOwner("user")
+
+	first, err := store.Set(owner, "lastViewed", json.RawMessage(`1`), nil)
+	require.NoError(t, err)
+
+	// Another client modifies the key
+	time.Sleep(time.Millisecond)
+	second, err := store.Set(owner, "lastViewed", json.RawMessage(`2`), &first.UpdatedAt)
+	require.NoError(t, err)
+
+	// A client that only knows the first version is rejected
+	current, err := store.Set(owner, "lastViewed", json.RawMessage(`3`), &first.UpdatedAt)
+	require.ErrorIs(t, err, ErrConflict)
+	require.NotNil(t, current)
+	assert.Equal(t, "2", string(current.Value))
+	assert.True(t, current.UpdatedAt.Equal(second.UpdatedAt))
+
+	// The timestamp survives a JSON round trip
+	b, err := json.Marshal(second)
+	require.NoError(t, err)
+	var fromClient Entry
+	require.NoError(t, json.Unmarshal(b, &fromClient))
+	_, err = store.Set(owner, "lastViewed", json.RawMessage(`3`), &fromClient.UpdatedAt)
+	require.NoError(t, err)
+}
+
+func TestStore_Quota(t *testing.T) {
+	store := NewStore(&NewStoreOptions{
+		MaxKeyLength: 8,
+		MaxValueSize: 16,
+		MaxOwnerSize: 32,
+	})
+	owner := UserOwner("user")
+
+	_, err := store.Set(owner, "", json.RawMessage(`1`), nil)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = store.Set(owner, "too-long-key", json.RawMessage(`1`), nil)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = store.Set(owner, "a", json.RawMessage(`{`), nil)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	_, err = store.Set(owner, "a", json.RawMessage(`"0123456789abcdef"`), nil)
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+
+	_, err = store.Set(owner, "a", json.RawMessage(`"0123456789"`), nil) // 13 bytes
+	require.NoError(t, err)
+	_, err = store.Set(owner, "b", json.RawMessage(`"0123456789"`), nil) // 26 bytes
+	require.NoError(t, err)
+	_, err = store.Set(owner, "c", json.RawMessage(`"0123456789"`), nil) // 39 bytes
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// Replacing a key only counts the difference
+	_, err = store.Set(owner, "b", json.RawMessage(`"01234567"`), nil)
+	require.NoError(t, err)
+
+	usage := store.GetUsage(owner)
+	assert.Equal(t, 2, usage.Keys)
+	assert.Equal(t, 24, usage.Size)
+
+	// Quotas are per owner
+	_, err = store.Set(UserOwner("other"), "c", json.RawMessage(`"0123456789"`), nil)
+	assert.NoError(t, err)
+}
+
+func TestStore_Prune(t *testing.T) {
+	persistence := newMemoryPersistence()
+	store := NewStore(&NewStoreOptions{Persistence: persistence, UserRetention: time.Hour})
+
+	active := SessionOwner("active")
+	expired := SessionOwner("expired")
+	user := UserOwner("user")
+	inactiveUser := UserOwner("inactive")
+
+	for _, owner := range []Owner{active, expired, user, inactiveUser} {
+		_, err := store.Set(owner, "key", json.RawMessage(`true`), nil)
+		require.NoError(t, err)
+	}
+	// Session-scoped state is not persisted
+	assert.Len(t, persistence.entries, 2)
+
+	// Simulate a user that has not modified their state for a long time
+	store.owners[inactiveUser.ID].entries["key"].UpdatedAt = time.Now().Add(-2 * time.Hour)
+
+	store.Prune(func(sessionID string) bool {
+		return sessionID == "active"
+	})
+
+	_, found := store.Get(active, "key")
+	assert.True(t, found)
+	_, found = store.Get(expired, "key")
+	assert.False(t, found)
+	_, found = store.Get(user, "key")
+	assert.True(t, found)
+	_, found = store.Get(inactiveUser, "key")
+	assert.False(t, found)
+	assert.Len(t, persistence.entries, 1)
+}
@@ -0,0 +1,166 @@
+package coalesce
+
+import (
+	"sync"
+	"time"
+)
+
+// Coordinator serializes and coalesces calls to an expensive fetch function.
+//
+//   - Calls made within Window of each other share a single fetch.
+//   - Fetches never run concurrently, a call made while a fetch is running either awaits that fetch
+//     or, if it is explicit, schedules the next one.
+//   - Background (non-explicit) calls made less than MinInterval after the last fetch return the last result.
+type Coordinator[T any] struct {
+	fetch       func() (T, error)
+	window      time.Duration
+	minInterval time.Duration
+
+	mu      sync.Mutex
+	sem     chan struct{}
+	pending *call[T] // Scheduled fetch that new callers can join
+	running *call[T] // Fetch in progress
+
+	hasLast   bool
+	lastVal   T
+	lastErr   error
+	lastAt    time.Time
+	lastCalls int
+}
+
+type call[T any] struct {
+	done    chan struct{}
+	val     T
+	err     error
+	callers int
+}
+
+type Options struct {
+	// Window is the delay during which calls are coalesced before the fetch starts.
+	Window time.Duration
+	// MinInterval is the minimum interval between two fetches triggered by background calls.
+	MinInterval time.Duration
+}
+
+type Status struct {
+	LastRefreshedAt *time.Time `json:"lastRefreshedAt,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+	// LastCallerCount is the number of callers that shared the last fetch.
+	LastCallerCount int  `json:"lastCallerCount"`
+	IsPending       bool `json:"isPending"`
+	IsRunning       bool `json:"isRunning"`
+}
+
+func NewCoordinator[T any](fetch func() (T, error), opts Options) *Coordinator[T] {
+	return &Coordinator[T]{
+		fetch:       fetch,
+		window:      opts.Window,
+		minInterval: opts.MinInterval,
+		sem:         make(chan struct{}, 1),
+	}
+}
+
+// Do requests a fetch and waits for its result.
+// Explicit calls always get a result from a fetch that started after the call was made or that they were coalesced into.
+func (c *Coordinator[T]) Do(explicit bool) (T, error) {
+	c.mu.Lock()
+
+	// Join the scheduled fetch
+	if c.pending != nil {
+		cl := c.pending
+		cl.callers++
+		c.mu.Unlock()
+		return cl.wait()
+	}
+
+	// Background calls can share the fetch that is already running
+	if c.running != nil && !explicit {
+		cl := c.running
+		cl.callers++
+		c.mu.Unlock()
+		return cl.wait()
+	}
+
+	// Rate-limit background calls
+	if !explicit && c.running == nil && c.hasLast && c.lastErr == nil && time.Since(c.lastAt) < c.minInterval {
+		val := c.lastVal
+		c.mu.Unlock()
+		return val, nil
+	}
+
+	cl := &call[T]{done: make(chan struct{}), callers: 1}
+	c.pending = cl
+	c.mu.Unlock()
+
+	go c.run(cl)
+
+	return cl.wait()
+}
+
+func (c *Coordinator[T]) run(cl *call[T]) {
+	if c.window > 0 {
+		time.Sleep(c.window)
+	}
+
+	// Wait for the previous fetch to finish
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	c.mu.Lock()
+	c.pending = nil
+	c.running = cl
+	c.mu.Unlock()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				cl.err = &PanicError{Value: r}
+			}
+		}()
+		cl.val, cl.err = c.fetch()
+	}()
+
+	c.mu.Lock()
+	c.running = nil
+	c.hasLast = true
+	c.lastVal = cl.val
+	c.lastErr = cl.err
+	c.lastAt = time.Now()
+	c.lastCalls = cl.callers
+	c.mu.Unlock()
+
+	close(cl.done)
+}
+
+func (cl *call[T]) wait() (T, error) {
+	<-cl.done
+	return cl.val, cl.err
+}
+
+// Status returns the state of the coordinator.
+func (c *Coordinator[T]) Status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &Status{
+		IsPending:       c.pending != nil,
+		IsRunning:       c.running != nil,
+		LastCallerCount: c.lastCalls,
+	}
+	if c.hasLast {
+		lastAt := c.lastAt
+		ret.LastRefreshedAt = &lastAt
+		if c.lastErr != nil {
+			ret.LastError = c.lastErr.Error()
+		}
+	}
+	return ret
+}
+
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return "coalesce: fetch panicked"
+}
@@ -0,0 +1,104 @@
+package coalesce
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type collection struct {
+	id int32
+}
+
+func TestCoordinatorCoalescesConcurrentCalls(t *testing.T) {
+	var queries atomic.Int32
+
+	c := NewCoordinator(func() (*collection, error) {
+		n := queries.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return &collection{id: n}, nil
+	}, Options{Window: 20 * time.Millisecond, MinInterval: time.Minute})
+
+	results := make([]*collection, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := c.Do(i == 0)
+			require.NoError(t, err)
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), queries.Load())
+	for _, res := range results {
+		assert.Same(t, results[0], res)
+	}
+
+	status := c.Status()
+	require.NotNil(t, status.LastRefreshedAt)
+	assert.Equal(t, 5, status.LastCallerCount)
+	assert.False(t, status.IsPending)
+	assert.False(t, status.IsRunning)
+}
+
+func TestCoordinatorRateLimitsBackgroundCalls(t *testing.T) {
+	var queries atomic.Int32
+
+	c := NewCoordinator(func() (int32, error) {
+		return queries.Add(1), nil
+	}, Options{MinInterval: time.Minute})
+
+	res, err := c.Do(false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), res)
+
+	// Background call within the interval returns the last result
+	res, err = c.Do(false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), res)
+
+	// Explicit call is always honored
+	res, err = c.Do(true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), res)
+}
+
+func TestCoordinatorSerializesFetches(t *testing.T) {
+	var running, maxRunning, queries atomic.Int32
+	started := make(chan struct{}, 10)
+
+	c := NewCoordinator(func() (int32, error) {
+		n := running.Add(1)
+		if n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		started <- struct{}{}
+		time.Sleep(50 * time.Millisecond)
+		running.Add(-1)
+		return queries.Add(1), nil
+	}, Options{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = c.Do(true)
+	}()
+
+	<-started
+
+	// An explicit call made while a fetch is running schedules the next fetch
+	res, err := c.Do(true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), res)
+
+	wg.Wait()
+	assert.Equal(t, int32(1), maxRunning.Load())
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package util
+
+import (
+	"strconv"
+	"syscall"
+)
+
+// GetDiskUsage returns the free and total space of the filesystem containing the given path.
+func GetDiskUsage(path string) (*DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return nil, err
+	}
+	return &DiskUsage{
+		FreeBytes:  uint64(stat.Bavail) * uint64(stat.Bsize),
+		TotalBytes: uint64(stat.Blocks) * uint64(stat.Bsize),
+	}, nil
+}
+
+// getDeviceID returns the ID of the device containing the given path.
+func getDeviceID(path string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(uint64(stat.Dev), 10), nil
+}
@@ -0,0 +1,45 @@
+//go:build windows
+
+package util
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// GetDiskUsage returns the free and total space of the volume containing the given path.
+func GetDiskUsage(path string) (*DiskUsage, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	r, _, err := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFreeBytes)),
+	)
+	if r == 0 {
+		return nil, err
+	}
+
+	return &DiskUsage{
+		FreeBytes:  freeBytesAvailable,
+		TotalBytes: totalBytes,
+	}, nil
+}
+
+// getDeviceID returns the volume containing the given path.
+func getDeviceID(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(filepath.VolumeName(abs)), nil
+}
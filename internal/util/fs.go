@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -51,6 +52,21 @@ func IsSubdirectory(parent, child string) bool {
 	return rel != "." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
 }
 
+// IsSubpath returns true if child is parent or is inside parent.
+// Unlike a plain prefix comparison, "/a/b" is not a subpath of "/a/bc".
+// The paths are compared as strings so they do not need to exist on this machine.
+func IsSubpath(parent, child string) bool {
+	parent = path.Clean(NormalizePath(parent))
+	child = path.Clean(NormalizePath(child))
+	if parent == child {
+		return true
+	}
+	if !strings.HasSuffix(parent, "/") {
+		parent += "/"
+	}
+	return strings.HasPrefix(child, parent)
+}
+
 func IsSubdirectoryOfAny(dirs []string, child string) bool {
 	for _, dir := range dirs {
 		if IsSubdirectory(dir, child) {
@@ -417,3 +433,39 @@ func GetDeepestFile(src string) (fp string) {
 
 	return ""
 }
+
+// DiskUsage describes the space available on the filesystem that holds a path.
+type DiskUsage struct {
+	FreeBytes  uint64 `json:"freeBytes"`
+	TotalBytes uint64 `json:"totalBytes"`
+}
+
+// GetDeviceID returns the ID of the device that holds a path.
+// If the path does not exist yet, the closest existing parent directory is used.
+func GetDeviceID(path string) (string, error) {
+	path = filepath.Clean(path)
+	for {
+		id, err := getDeviceID(path)
+		if err == nil {
+			return id, nil
+		}
+		parent := filepath.Dir(path)
+		if parent == path || !os.IsNotExist(err) {
+			return "", err
+		}
+		path = parent
+	}
+}
+
+// IsSameDevice returns true if both paths are on the same device.
+func IsSameDevice(a string, b string) bool {
+	idA, err := GetDeviceID(a)
+	if err != nil {
+		return false
+	}
+	idB, err := GetDeviceID(b)
+	if err != nil {
+		return false
+	}
+	return idA == idB
+}
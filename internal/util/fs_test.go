@@ -1,6 +1,7 @@
 package util
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -50,6 +51,30 @@ func TestSubdirectory(t *testing.T) {
 	}
 }
 
+func TestIsSubpath(t *testing.T) {
+	tests := []struct {
+		parent   string
+		child    string
+		expected bool
+	}{
+		{parent: "/downloads/Anime", child: "/downloads/Anime", expected: true},
+		{parent: "/downloads/Anime", child: "/downloads/Anime/", expected: true},
+		{parent: "/downloads/Anime", child: "/downloads/Anime/Episode 1.mkv", expected: true},
+		{parent: "/downloads/Anime/", child: "/downloads/Anime/Episode 1.mkv", expected: true},
+		{parent: "/downloads/Anime", child: "/downloads/Anime 2", expected: false},
+		{parent: "/downloads/Anime", child: "/downloads/Anime 2/Episode 1.mkv", expected: false},
+		{parent: "/downloads/Anime", child: "/downloads/Anime/../Other", expected: false},
+		{parent: "/", child: "/downloads", expected: true},
+		{parent: "", child: "/downloads", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.parent+"|"+test.child, func(t *testing.T) {
+			require.Equal(t, test.expected, IsSubpath(test.parent, test.child))
+		})
+	}
+}
+
 func TestIsFileUnderDir(t *testing.T) {
 	tests := []struct {
 		parent   string
@@ -89,3 +114,13 @@ func TestSameDir(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSameDevice(t *testing.T) {
+	dir := t.TempDir()
+
+	// Paths that do not exist yet use their closest existing parent
+	require.True(t, IsSameDevice(dir, filepath.Join(dir, "not", "downloaded", "yet.mkv")))
+
+	_, err := GetDeviceID(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"net/http"
+)
+
+// headerRoundTripper adds a set of headers to every request.
+type headerRoundTripper struct {
+	inner   http.RoundTripper
+	headers http.Header
+}
+
+// NewHeaderRoundTripper returns a round tripper that sets the given headers on every request before passing it to inner.
+// If inner is nil, http.DefaultTransport is used.
+// If there are no headers, inner is returned as is.
+func NewHeaderRoundTripper(inner http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	if len(headers) == 0 {
+		return inner
+	}
+
+	h := make(http.Header, len(headers))
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+
+	return &headerRoundTripper{
+		inner:   inner,
+		headers: h,
+	}
+}
+
+func (rt *headerRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	// RoundTrippers should not modify the original request
+	r = r.Clone(r.Context())
+	for name, values := range rt.headers {
+		r.Header[name] = values
+	}
+	return rt.inner.RoundTrip(r)
+}
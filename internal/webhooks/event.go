@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"slices"
+	"time"
+)
+
+// SchemaVersion is the version of the event payloads.
+// It is incremented when a field is removed or changes meaning, new fields can be added without changing it.
+const SchemaVersion = 1
+
+const (
+	EventPlaybackStarted   EventType = "playback.started"
+	EventPlaybackStopped   EventType = "playback.stopped"
+	EventDownloadCompleted EventType = "download.completed"
+	EventScanFinished      EventType = "scan.finished"
+	EventEpisodeAired      EventType = "episode.aired"
+)
+
+// EventTypes are the event types that can be subscribed to.
+var EventTypes = []EventType{
+	EventPlaybackStarted,
+	EventPlaybackStopped,
+	EventDownloadCompleted,
+	EventScanFinished,
+	EventEpisodeAired,
+}
+
+type (
+	EventType string
+
+	// Event is the body of the requests sent to the subscriptions.
+	Event struct {
+		// ID is unique to the event, retries of the same event have the same ID
+		ID        string    `json:"id"`
+		Type      EventType `json:"type"`
+		Version   int       `json:"version"`
+		CreatedAt time.Time `json:"createdAt"`
+		// Data is one of the payloads below, depending on the type
+		Data interface{} `json:"data"`
+	}
+
+	PlaybackStartedPayload struct {
+		// Kind is "local" for local files and "stream" for streams
+		Kind          string `json:"kind"`
+		Filename      string `json:"filename"`
+		MediaId       int    `json:"mediaId,omitempty"`
+		MediaTitle    string `json:"mediaTitle,omitempty"`
+		EpisodeNumber int    `json:"episodeNumber,omitempty"`
+		// Incognito is true if the playback does not update the progress, the media is not sent
+		Incognito bool `json:"incognito,omitempty"`
+	}
+
+	PlaybackStoppedPayload struct {
+		Kind          string `json:"kind"`
+		Filename      string `json:"filename"`
+		MediaId       int    `json:"mediaId,omitempty"`
+		MediaTitle    string `json:"mediaTitle,omitempty"`
+		EpisodeNumber int    `json:"episodeNumber,omitempty"`
+		// Reason is set when the playback stopped because of an error
+		Reason string `json:"reason,omitempty"`
+	}
+
+	DownloadCompletedPayload struct {
+		Name        string `json:"name"`
+		Hash        string `json:"hash"`
+		ContentPath string `json:"contentPath,omitempty"`
+	}
+
+	ScanFinishedPayload struct {
+		// Automatic is true if the scan was started by the auto scanner
+		Automatic      bool `json:"automatic"`
+		TotalFiles     int  `json:"totalFiles"`
+		UnmatchedFiles int  `json:"unmatchedFiles"`
+	}
+
+	EpisodeAiredPayload struct {
+		MediaId       int       `json:"mediaId"`
+		MediaTitle    string    `json:"mediaTitle"`
+		EpisodeNumber int       `json:"episodeNumber"`
+		AiredAt       time.Time `json:"airedAt"`
+	}
+)
+
+func (t EventType) IsValid() bool {
+	return slices.Contains(EventTypes, t)
+}
@@ -0,0 +1,367 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"seanime/internal/constants"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/notifier"
+	"seanime/internal/util"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Headers sent with each delivery
+	HeaderEvent     = "X-Seanime-Event"
+	HeaderDelivery  = "X-Seanime-Delivery"
+	HeaderTimestamp = "X-Seanime-Timestamp"
+	// HeaderSignature is the HMAC-SHA256 of "<timestamp>.<body>" with the secret of the subscription, see Sign
+	HeaderSignature = "X-Seanime-Signature"
+
+	// maxAttempts is the number of times an event is sent before it is considered failed
+	maxAttempts = 5
+	// defaultBackoff is the wait before the first retry, it doubles after each attempt
+	defaultBackoff = 10 * time.Second
+	// maxConsecutiveFailures is the number of failed events in a row after which a subscription is disabled
+	maxConsecutiveFailures = 10
+	// deliveriesKept is the number of delivery attempts kept per subscription
+	deliveriesKept = 100
+	// maxResponseBodyLength is the number of bytes of the response body that are recorded
+	maxResponseBodyLength = 1024
+	requestTimeout        = 10 * time.Second
+)
+
+var (
+	ErrInvalidURL        = errors.New("webhooks: URL must be an absolute http or https URL")
+	ErrInvalidEventTypes = errors.New("webhooks: at least one valid event type is required")
+)
+
+type (
+	// Manager sends the events to the subscribed URLs.
+	// Each event is signed with the secret of the subscription and retried with an exponential backoff.
+	// Subscriptions are disabled after too many failed events in a row.
+	Manager struct {
+		logger   *zerolog.Logger
+		database *db.Database
+		client   *http.Client
+		// mu serializes the updates of the failure counters
+		mu sync.Mutex
+		// backoff is replaced in tests
+		backoff time.Duration
+	}
+
+	NewManagerOptions struct {
+		Logger   *zerolog.Logger
+		Database *db.Database
+	}
+)
+
+func NewManager(opts *NewManagerOptions) *Manager {
+	return &Manager{
+		logger:   opts.Logger,
+		database: opts.Database,
+		client:   &http.Client{Timeout: requestTimeout},
+		backoff:  defaultBackoff,
+	}
+}
+
+// Sign returns the signature of the body, as sent in the HeaderSignature header.
+// Receivers should compute it with the secret of the subscription and the HeaderTimestamp header, and compare it to the header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// +---------------------+
+// |    Subscriptions    |
+// +---------------------+
+
+func (m *Manager) GetSubscriptions() ([]*models.WebhookSubscription, error) {
+	return m.database.GetWebhookSubscriptions()
+}
+
+// CreateSubscription registers a URL for the event types. A secret is generated to sign the payloads.
+func (m *Manager) CreateSubscription(rawURL string, eventTypes []EventType) (*models.WebhookSubscription, error) {
+	if err := validateSubscription(rawURL, eventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: toStringSlice(eventTypes),
+		Enabled:    true,
+	}
+	if err := m.database.InsertWebhookSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// UpdateSubscription changes the URL, event types and state of a subscription.
+// Re-enabling a subscription resets its failures.
+func (m *Manager) UpdateSubscription(id uint, rawURL string, eventTypes []EventType, enabled bool) (*models.WebhookSubscription, error) {
+	if err := validateSubscription(rawURL, eventTypes); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, err := m.database.GetWebhookSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled && !sub.Enabled {
+		sub.ConsecutiveFailures = 0
+		sub.DisabledReason = ""
+	}
+	sub.URL = rawURL
+	sub.EventTypes = toStringSlice(eventTypes)
+	sub.Enabled = enabled
+
+	if err := m.database.SaveWebhookSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (m *Manager) DeleteSubscription(id uint) error {
+	return m.database.DeleteWebhookSubscription(id)
+}
+
+// GetDeliveries returns the last delivery attempts of a subscription, most recent first.
+func (m *Manager) GetDeliveries(id uint) ([]*models.WebhookDelivery, error) {
+	if _, err := m.database.GetWebhookSubscription(id); err != nil {
+		return nil, err
+	}
+	return m.database.GetWebhookDeliveries(id, deliveriesKept)
+}
+
+// +---------------------+
+// |      Delivery       |
+// +---------------------+
+
+// HasSubscribers returns true if an enabled subscription listens to the event type.
+// It is used to avoid watching for events nobody is subscribed to.
+func (m *Manager) HasSubscribers(eventType EventType) bool {
+	return len(m.getSubscribers(eventType)) > 0
+}
+
+// Dispatch sends the event to the enabled subscriptions that listen to its type.
+// The deliveries happen in the background.
+func (m *Manager) Dispatch(eventType EventType, data interface{}) {
+	subs := m.getSubscribers(eventType)
+	if len(subs) == 0 {
+		return
+	}
+
+	event := &Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Version:   SchemaVersion,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		m.logger.Error().Err(err).Str("type", string(eventType)).Msg("webhooks: Failed to marshal event")
+		return
+	}
+
+	m.logger.Debug().Str("type", string(eventType)).Int("subscriptions", len(subs)).Msg("webhooks: Dispatching event")
+
+	for _, sub := range subs {
+		go m.deliver(sub, event, body)
+	}
+}
+
+func (m *Manager) getSubscribers(eventType EventType) []*models.WebhookSubscription {
+	if m == nil || m.database == nil {
+		return nil
+	}
+	subs, err := m.database.GetWebhookSubscriptions()
+	if err != nil {
+		return nil
+	}
+	ret := make([]*models.WebhookSubscription, 0)
+	for _, sub := range subs {
+		if !sub.Enabled {
+			continue
+		}
+		for _, t := range sub.EventTypes {
+			if EventType(t) == eventType {
+				ret = append(ret, sub)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// deliver sends the event until it succeeds or the attempts are exhausted.
+func (m *Manager) deliver(sub *models.WebhookSubscription, event *Event, body []byte) {
+	defer util.HandlePanicInModuleThen("webhooks/deliver", func() {})
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(m.backoff << (attempt - 2))
+			// Stop retrying if the subscription was disabled or deleted in the meantime
+			current, err := m.database.GetWebhookSubscription(sub.ID)
+			if err != nil || !current.Enabled {
+				return
+			}
+			sub = current
+		}
+
+		delivery, retry := m.send(sub, event, body, attempt)
+		if err := m.database.InsertWebhookDelivery(delivery, deliveriesKept); err != nil {
+			m.logger.Warn().Err(err).Msg("webhooks: Failed to record delivery")
+		}
+
+		if delivery.Success {
+			m.recordResult(sub.ID, true)
+			return
+		}
+		if !retry {
+			break
+		}
+	}
+
+	m.recordResult(sub.ID, false)
+}
+
+// send makes a single attempt. It returns false if the request should not be retried.
+func (m *Manager) send(sub *models.WebhookSubscription, event *Event, body []byte, attempt int) (*models.WebhookDelivery, bool) {
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      string(event.Type),
+		Attempt:        attempt,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, false
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Seanime/"+constants.Version)
+	req.Header.Set(HeaderEvent, string(event.Type))
+	req.Header.Set(HeaderDelivery, event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, true
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyLength))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(respBody)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if delivery.Success {
+		return delivery, false
+	}
+
+	delivery.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	// Client errors are not retried, except timeouts and rate limits
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return delivery, retry
+}
+
+// recordResult updates the failure counter of the subscription and disables it after too many failed events.
+func (m *Manager) recordResult(id uint, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, err := m.database.GetWebhookSubscription(id)
+	if err != nil {
+		return
+	}
+
+	if success {
+		if sub.ConsecutiveFailures == 0 {
+			return
+		}
+		sub.ConsecutiveFailures = 0
+		_ = m.database.SaveWebhookSubscription(sub)
+		return
+	}
+
+	sub.ConsecutiveFailures++
+	if sub.ConsecutiveFailures >= maxConsecutiveFailures && sub.Enabled {
+		sub.Enabled = false
+		sub.DisabledReason = fmt.Sprintf("Disabled after %d failed events in a row", sub.ConsecutiveFailures)
+		m.logger.Warn().Str("url", sub.URL).Msg("webhooks: Subscription disabled after too many failures")
+		notifier.GlobalNotifier.Notify(notifier.Webhooks, fmt.Sprintf("The webhook %s has been disabled after too many failed deliveries.", sub.URL))
+	}
+	_ = m.database.SaveWebhookSubscription(sub)
+}
+
+func validateSubscription(rawURL string, eventTypes []EventType) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if len(eventTypes) == 0 {
+		return ErrInvalidEventTypes
+	}
+	for _, t := range eventTypes {
+		if !t.IsValid() {
+			return fmt.Errorf("webhooks: unknown event type %q", t)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toStringSlice(eventTypes []EventType) models.StringSlice {
+	ret := make(models.StringSlice, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		ret = append(ret, string(t))
+	}
+	return ret
+}
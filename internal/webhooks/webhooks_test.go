@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/database/db"
+	"seanime/internal/util"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "webhooks_test", logger)
+	require.NoError(t, err)
+
+	m := NewManager(&NewManagerOptions{Logger: logger, Database: database})
+	m.backoff = 0
+	return m
+}
+
+func TestDispatch_Signed(t *testing.T) {
+	m := newTestManager(t)
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	sub, err := m.CreateSubscription(server.URL, []EventType{EventScanFinished})
+	require.NoError(t, err)
+	require.NotEmpty(t, sub.Secret)
+
+	// Not subscribed
+	m.Dispatch(EventPlaybackStarted, &PlaybackStartedPayload{Kind: "local"})
+	m.Dispatch(EventScanFinished, &ScanFinishedPayload{TotalFiles: 12, UnmatchedFiles: 2})
+
+	var r *http.Request
+	select {
+	case r = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered")
+	}
+	body := <-bodies
+
+	assert.Equal(t, string(EventScanFinished), r.Header.Get(HeaderEvent))
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign(sub.Secret, timestamp, body), r.Header.Get(HeaderSignature))
+
+	var event struct {
+		ID      string              `json:"id"`
+		Type    EventType           `json:"type"`
+		Version int                 `json:"version"`
+		Data    ScanFinishedPayload `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, r.Header.Get(HeaderDelivery), event.ID)
+	assert.Equal(t, SchemaVersion, event.Version)
+	assert.Equal(t, 12, event.Data.TotalFiles)
+
+	require.Eventually(t, func() bool {
+		deliveries, _ := m.GetDeliveries(sub.ID)
+		return len(deliveries) == 1 && deliveries[0].Success
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDispatch_RetriesAndDisables(t *testing.T) {
+	m := newTestManager(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sub, err := m.CreateSubscription(server.URL, []EventType{EventDownloadCompleted})
+	require.NoError(t, err)
+
+	// Each event is retried until the attempts are exhausted
+	m.Dispatch(EventDownloadCompleted, &DownloadCompletedPayload{Name: "torrent"})
+	require.Eventually(t, func() bool {
+		sub, _ := m.database.GetWebhookSubscription(sub.ID)
+		return sub.ConsecutiveFailures == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, maxAttempts, requests.Load())
+
+	deliveries, err := m.GetDeliveries(sub.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, maxAttempts)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].StatusCode)
+	assert.Equal(t, maxAttempts, deliveries[0].Attempt)
+
+	// The subscription is disabled after too many failed events
+	for i := 1; i < maxConsecutiveFailures; i++ {
+		m.deliver(sub, &Event{ID: strconv.Itoa(i), Type: EventDownloadCompleted}, []byte(`{}`))
+	}
+	sub, err = m.database.GetWebhookSubscription(sub.ID)
+	require.NoError(t, err)
+	assert.False(t, sub.Enabled)
+	assert.NotEmpty(t, sub.DisabledReason)
+	assert.False(t, m.HasSubscribers(EventDownloadCompleted))
+
+	// Re-enabling resets the failures
+	sub, err = m.UpdateSubscription(sub.ID, sub.URL, []EventType{EventDownloadCompleted}, true)
+	require.NoError(t, err)
+	assert.Zero(t, sub.ConsecutiveFailures)
+	assert.Empty(t, sub.DisabledReason)
+}
+
+func TestCreateSubscription_Validation(t *testing.T) {
+	m := newTestManager(t)
+
+	_, err := m.CreateSubscription("ftp://example.com", []EventType{EventScanFinished})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = m.CreateSubscription("http://homeassistant.local:8123/api/webhook/seanime", nil)
+	assert.ErrorIs(t, err, ErrInvalidEventTypes)
+
+	_, err = m.CreateSubscription("http://homeassistant.local:8123/api/webhook/seanime", []EventType{"library.deleted"})
+	assert.Error(t, err)
+}